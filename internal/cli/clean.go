@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/daemon"
+	"github.com/kar98k/internal/output"
+	"github.com/kar98k/internal/tui"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	cleanConfig   string
+	cleanKeepLast int
+	cleanMaxAge   time.Duration
+	cleanDryRun   bool
+)
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove old run artifacts per report.retention",
+	Long: `Remove the timestamped artifacts earlier runs left behind: snapshots
+and output files whose path contains {time}, plus the rotated daemon
+log. The daemon applies the same report.retention policy when it
+starts; this is the manual version.
+
+Only files whose name carries a {time} stamp are considered, so a
+fixed output path is never removed.
+
+Examples:
+  kar clean                       # report.retention from kar.yaml
+  kar clean -c ci.yaml --dry-run  # list what would go
+  kar clean --keep-last 5         # keep the 5 newest per path
+  kar clean --max-age 168h        # drop anything older than a week`,
+	RunE: runClean,
+}
+
+func init() {
+	cleanCmd.Flags().StringVarP(&cleanConfig, "config", "c", "kar.yaml", "Config whose output paths and report.retention to use")
+	cleanCmd.Flags().IntVar(&cleanKeepLast, "keep-last", 0, "Keep the newest N files per path (overrides report.retention.keep_last; -1 keeps all)")
+	cleanCmd.Flags().DurationVar(&cleanMaxAge, "max-age", 0, "Remove files older than this (overrides report.retention.max_age)")
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "List what would be removed without removing it")
+	rootCmd.AddCommand(cleanCmd)
+}
+
+func runClean(cmd *cobra.Command, args []string) error {
+	// Without a config only the default snapshot path is known.
+	cfg := config.DefaultConfig()
+	if data, err := os.ReadFile(cleanConfig); err == nil {
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("parse %s: %w", cleanConfig, err)
+		}
+	} else if cmd.Flags().Changed("config") {
+		return err
+	}
+
+	ret := cfg.Report.Retention
+	if cmd.Flags().Changed("keep-last") {
+		ret.KeepLast = cleanKeepLast
+	}
+	if cmd.Flags().Changed("max-age") {
+		ret.MaxAge = cleanMaxAge
+	}
+
+	removed, err := output.Prune(cfg.ArtifactPatterns(), ret, time.Now(), cleanDryRun)
+	rotated := daemon.GetLogPath() + ".1"
+	if _, statErr := os.Stat(rotated); statErr == nil {
+		if !cleanDryRun {
+			if rmErr := os.Remove(rotated); rmErr != nil {
+				err = errors.Join(err, rmErr)
+			}
+		}
+		removed = append(removed, rotated)
+	}
+
+	verb := "Removed"
+	if cleanDryRun {
+		verb = "Would remove"
+	}
+	if len(removed) == 0 {
+		fmt.Println(tui.DimStyle.Render("Nothing to clean"))
+	} else {
+		fmt.Printf("%s %d file(s):\n", verb, len(removed))
+		for _, p := range removed {
+			fmt.Println("  " + p)
+		}
+	}
+	return err
+}
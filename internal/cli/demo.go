@@ -70,7 +70,7 @@ func runDemo(cmd *cobra.Command, args []string) error {
 	// Private registry: promauto registers on the default registerer
 	// by default, which would panic if the user re-runs `kar demo`
 	// inside the same process (e.g. tests).
-	metrics := health.NewMetricsWithRegistry(prometheus.NewRegistry())
+	metrics := health.NewMetrics(prometheus.NewRegistry())
 
 	ctx, cancel := context.WithTimeout(context.Background(), demoDuration)
 	defer cancel()
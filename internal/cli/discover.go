@@ -3,9 +3,9 @@ package cli
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -13,6 +13,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/daemon"
 	"github.com/kar98k/internal/discovery"
 	"github.com/kar98k/internal/health"
 	"github.com/kar98k/internal/tui"
@@ -29,6 +30,21 @@ var (
 	discoverMaxTPS       float64
 	discoverStepDuration time.Duration
 	discoverHeadless     bool
+	discoverCurve        string
+	discoverSweep        bool
+	discoverSweepSteps   int
+	discoverLatencyUnit  string
+	discoverInsecure     bool
+	discoverConnRetries  int
+	discoverConnTolerate float64
+	discoverConcurrency  bool
+	discoverMinConc      int
+	discoverMaxConc      int
+	discoverExpectStatus []int
+	discoverExpectBody   string
+	discoverStaged       bool
+	discoverCoarseFactor float64
+	discoverCoarseStep   time.Duration
 )
 
 var discoverCmd = &cobra.Command{
@@ -46,7 +62,9 @@ The discovery process will:
 Examples:
   kar discover --url http://localhost:8080/api/health
   kar discover --url https://api.example.com --latency-limit 200ms
-  kar discover --url http://localhost:8080 --min-tps 100 --max-tps 5000`,
+  kar discover --url http://localhost:8080 --min-tps 100 --max-tps 5000
+  kar discover --url http://localhost:8080 --headless --sweep --curve capacity.csv
+  kar discover --url http://localhost:8080 --headless --concurrency --max-concurrency 200 --curve knee.csv`,
 	RunE: runDiscover,
 }
 
@@ -62,9 +80,43 @@ func init() {
 	discoverCmd.Flags().Float64Var(&discoverMaxTPS, "max-tps", 10000, "Maximum TPS to test")
 	discoverCmd.Flags().DurationVar(&discoverStepDuration, "step-duration", 10*time.Second, "Duration for each TPS test step")
 	discoverCmd.Flags().BoolVar(&discoverHeadless, "headless", false, "Run without TUI (print results to stdout)")
+	discoverCmd.Flags().StringVar(&discoverCurve, "curve", "", "Write every tested TPS level (p95, p99, error rate, achieved TPS) to this CSV file")
+	discoverCmd.Flags().BoolVar(&discoverSweep, "sweep", false, "Test evenly spaced levels from --min-tps to --max-tps instead of binary search")
+	discoverCmd.Flags().IntVar(&discoverSweepSteps, "sweep-steps", config.DefaultSweepSteps, "Number of levels tested by --sweep")
+	discoverCmd.Flags().StringVar(&discoverLatencyUnit, "latency-unit", config.LatencyUnitAuto, "Unit latencies are printed in: auto, us, ms or s")
+	discoverCmd.Flags().BoolVar(&discoverInsecure, "insecure", false, "Skip TLS certificate verification (test endpoints only)")
+	discoverCmd.Flags().IntVar(&discoverConnRetries, "conn-retries", 0, "Retry a request up to N times when it fails to connect (refused, reset, connect timeout)")
+	discoverCmd.Flags().Float64Var(&discoverConnTolerate, "conn-error-tolerance", 0, "Percent of a step's requests that may fail to connect without counting as errors")
+	discoverCmd.Flags().BoolVar(&discoverConcurrency, "concurrency", false, "Sweep requests in flight (closed loop) instead of TPS to find the latency knee")
+	discoverCmd.Flags().IntVar(&discoverMinConc, "min-concurrency", config.DefaultMinConcurrency, "Lowest concurrency tested by --concurrency")
+	discoverCmd.Flags().IntVar(&discoverMaxConc, "max-concurrency", config.DefaultMaxConcurrency, "Highest concurrency tested by --concurrency")
+	discoverCmd.Flags().BoolVar(&discoverStaged, "staged", false, "Bracket the breaking point with a short coarse sweep, then binary-search only the bracket")
+	discoverCmd.Flags().Float64Var(&discoverCoarseFactor, "coarse-factor", config.DefaultCoarseFactor, "Ratio between consecutive coarse levels of --staged")
+	discoverCmd.Flags().DurationVar(&discoverCoarseStep, "coarse-step-duration", config.DefaultCoarseStepDuration, "Duration of each coarse step of --staged")
+	discoverCmd.Flags().IntSliceVar(&discoverExpectStatus, "expect-status", nil, "Status codes that count as success (e.g. 200,201); others are errors")
+	discoverCmd.Flags().StringVar(&discoverExpectBody, "expect-body", "", "Text every response body must contain within its first 4KB, or the request is an error")
 }
 
 func runDiscover(cmd *cobra.Command, args []string) error {
+	if !config.ValidLatencyUnit(discoverLatencyUnit) {
+		return fmt.Errorf("--latency-unit must be auto, us, ms or s")
+	}
+	if discoverConnRetries < 0 {
+		return fmt.Errorf("--conn-retries must not be negative")
+	}
+	if discoverConnTolerate < 0 || discoverConnTolerate > 100 {
+		return fmt.Errorf("--conn-error-tolerance is a percentage between 0 and 100")
+	}
+	if discoverConcurrency && (discoverMinConc < 1 || discoverMaxConc < discoverMinConc) {
+		return fmt.Errorf("--min-concurrency must be at least 1 and no more than --max-concurrency")
+	}
+	if discoverStaged && (discoverSweep || discoverConcurrency) {
+		return fmt.Errorf("--staged replaces the binary search; it can't be combined with --sweep or --concurrency")
+	}
+	if discoverStaged && (discoverCoarseFactor <= 1 || discoverCoarseStep <= 0) {
+		return fmt.Errorf("--coarse-factor must be above 1 and --coarse-step-duration positive")
+	}
+
 	// If URL not provided via flag and not headless, use TUI
 	if discoverURL == "" && !discoverHeadless {
 		return runDiscoverTUI()
@@ -80,18 +132,15 @@ func runDiscover(cmd *cobra.Command, args []string) error {
 }
 
 func runDiscoverTUI() error {
-	// Initialize logger
-	if err := tui.InitLogger(); err != nil {
+	// Initialize logger (creates the runtime directory)
+	if err := tui.InitLogger(daemon.GetLogPath()); err != nil {
 		return fmt.Errorf("failed to init logger: %w", err)
 	}
 	defer tui.CloseLogger()
 
-	// Create runtime directory
-	runtimeDir := filepath.Join(os.TempDir(), "kar98k")
-	os.MkdirAll(runtimeDir, 0755)
-
 	// Run the TUI
 	m := tui.NewDiscoverModel()
+	m.SetLatencyFormat(discoverLatency())
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	// Handle signals
@@ -119,6 +168,17 @@ func runDiscoverTUI() error {
 
 	// Build discovery config
 	cfg := buildDiscoveryConfigFromTUI(tuiConfig)
+	cfg.Sweep = discoverSweep
+	cfg.SweepSteps = discoverSweepSteps
+	cfg.ConnRetries = discoverConnRetries
+	cfg.ConnErrorTolerance = discoverConnTolerate
+	cfg.Concurrency = discoverConcurrency
+	cfg.MinConcurrency = discoverMinConc
+	cfg.MaxConcurrency = discoverMaxConc
+	cfg.Expect = discoverExpect()
+	cfg.Staged = discoverStaged
+	cfg.CoarseFactor = discoverCoarseFactor
+	cfg.CoarseStepDuration = discoverCoarseStep
 
 	// Run discovery with the config
 	return executeDiscovery(cfg, false)
@@ -135,21 +195,58 @@ func runDiscoverHeadless() error {
 		MaxTPS:          discoverMaxTPS,
 		StepDuration:    discoverStepDuration,
 		ConvergenceRate: 0.05,
+		Sweep:           discoverSweep,
+		SweepSteps:      discoverSweepSteps,
+		TLSInsecure:     discoverInsecure,
+
+		ConnRetries:        discoverConnRetries,
+		ConnErrorTolerance: discoverConnTolerate,
+
+		Concurrency:    discoverConcurrency,
+		MinConcurrency: discoverMinConc,
+		MaxConcurrency: discoverMaxConc,
+
+		Staged:             discoverStaged,
+		CoarseFactor:       discoverCoarseFactor,
+		CoarseStepDuration: discoverCoarseStep,
+
+		Expect: discoverExpect(),
 	}
 
 	return executeDiscovery(cfg, true)
 }
 
+// discoverExpect builds the response assertions of --expect-status and
+// --expect-body; nil when neither is set.
+func discoverExpect() *config.Expect {
+	if len(discoverExpectStatus) == 0 && discoverExpectBody == "" {
+		return nil
+	}
+	return &config.Expect{Status: discoverExpectStatus, BodyContains: discoverExpectBody}
+}
+
 func executeDiscovery(cfg config.Discovery, headless bool) error {
 	if !headless {
 		fmt.Println("\n🔍 Starting Adaptive Load Discovery...")
 		fmt.Printf("   Target: %s %s\n", cfg.Method, cfg.TargetURL)
 		fmt.Printf("   Limits: P95 < %dms, Error < %.1f%%\n", cfg.LatencyLimitMs, cfg.ErrorRateLimit)
-		fmt.Printf("   Range:  %.0f - %.0f TPS\n\n", cfg.MinTPS, cfg.MaxTPS)
+		if cfg.Concurrency {
+			fmt.Printf("   Range:  %d - %d in flight\n", cfg.MinConcurrency, cfg.MaxConcurrency)
+			fmt.Printf("   Mode:   concurrency (%d levels)\n", len(discovery.ConcurrencyLevels(cfg.MinConcurrency, cfg.MaxConcurrency, cfg.SweepSteps)))
+		} else {
+			fmt.Printf("   Range:  %.0f - %.0f TPS\n", cfg.MinTPS, cfg.MaxTPS)
+		}
+		if cfg.Sweep && !cfg.Concurrency {
+			fmt.Printf("   Mode:   sweep (%d levels)\n", len(discovery.SweepLevels(cfg.MinTPS, cfg.MaxTPS, cfg.SweepSteps)))
+		}
+		if cfg.Staged {
+			fmt.Printf("   Mode:   staged (coarse %s steps, then fine %s steps)\n", cfg.CoarseStepDuration, cfg.StepDuration)
+		}
+		fmt.Println()
 	}
 
 	// Create metrics
-	metrics := health.NewMetrics()
+	metrics := health.NewMetrics(health.NewRegistry())
 
 	// Create context
 	ctx, cancel := context.WithCancel(context.Background())
@@ -161,8 +258,8 @@ func executeDiscovery(cfg config.Discovery, headless bool) error {
 	// Set up progress callback for headless mode
 	if headless {
 		controller.SetProgressCallback(func(progress float64, currentTPS float64, p95 float64, errRate float64, status string) {
-			fmt.Printf("\r[%.0f%%] TPS: %.0f | P95: %.0fms | Errors: %.1f%% | %s",
-				progress, currentTPS, p95, errRate, status)
+			fmt.Printf("\r[%.0f%%] TPS: %.0f | P95: %s | Errors: %.1f%% | %s",
+				progress, currentTPS, discoverLatency().Format(p95), errRate, status)
 		})
 	}
 
@@ -193,11 +290,38 @@ func executeDiscovery(cfg config.Discovery, headless bool) error {
 	}
 
 	// Print results
-	printDiscoveryResult(result)
+	if cfg.Concurrency {
+		printConcurrencyResult(result)
+	} else {
+		printDiscoveryResult(result)
+	}
+
+	if discoverCurve != "" {
+		write := discovery.WriteCurveCSV
+		if cfg.Concurrency {
+			write = discovery.WriteConcurrencyCurveCSV
+		}
+		if err := writeDiscoveryCurve(discoverCurve, result.Steps, write); err != nil {
+			return err
+		}
+		fmt.Printf("  Capacity curve written to %s (%d levels)\n\n", discoverCurve, len(result.Steps))
+	}
 
 	return nil
 }
 
+func writeDiscoveryCurve(path string, steps []discovery.StepResult, write func(io.Writer, []discovery.StepResult) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create curve file: %w", err)
+	}
+	if err := write(f, steps); err != nil {
+		f.Close()
+		return fmt.Errorf("write curve: %w", err)
+	}
+	return f.Close()
+}
+
 func buildDiscoveryConfigFromTUI(tuiConfig map[string]string) config.Discovery {
 	latencyLimit, _ := strconv.ParseInt(tuiConfig["latency_limit"], 10, 64)
 	errorLimit, _ := strconv.ParseFloat(tuiConfig["error_limit"], 64)
@@ -230,6 +354,11 @@ func buildDiscoveryConfigFromTUI(tuiConfig map[string]string) config.Discovery {
 	}
 }
 
+// discoverLatency is the --latency-unit format (#1213).
+func discoverLatency() config.LatencyFormat {
+	return config.LatencyFormat{Unit: discoverLatencyUnit}
+}
+
 func printDiscoveryResult(r *discovery.Result) {
 	fmt.Println()
 	fmt.Println()
@@ -250,7 +379,7 @@ func printDiscoveryResult(r *discovery.Result) {
 	fmt.Println()
 	fmt.Println("  At sustained load:")
 	fmt.Println()
-	fmt.Printf("    %s  %.0fms\n", tui.LabelStyle.Render("P95 Latency:"), r.P95Latency)
+	fmt.Printf("    %s  %s\n", tui.LabelStyle.Render("P95 Latency:"), discoverLatency().Format(r.P95Latency))
 	fmt.Printf("    %s  %.1f%%\n", tui.LabelStyle.Render("Error Rate:"), r.ErrorRate)
 	fmt.Println()
 	fmt.Println(strings.Repeat("─", 60))
@@ -263,6 +392,77 @@ func printDiscoveryResult(r *discovery.Result) {
 	fmt.Printf("    Set %s to %s (safe spike limit)\n",
 		tui.LabelStyle.Render("MaxTPS"),
 		tui.SuccessStyle.Render(fmt.Sprintf("%.0f", r.Recommendation.MaxTPS)))
+	fmt.Printf("    Set %s to %s for capacity-relative spikes (spike_capacity)\n",
+		tui.LabelStyle.Render("pattern.poisson.capacity_tps"),
+		tui.SuccessStyle.Render(fmt.Sprintf("%.0f", r.BreakingTPS)))
+	fmt.Println()
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Println()
+	fmt.Printf("  Test completed in %s (%d steps)\n",
+		r.TestDuration.Round(time.Second), r.StepsCompleted)
+	printDiscoveryPhases(r)
+	fmt.Println()
+}
+
+// printDiscoveryPhases reports a staged discovery's two phases.
+func printDiscoveryPhases(r *discovery.Result) {
+	var coarse, fine int
+	var coarseTime, fineTime time.Duration
+	for _, s := range r.Steps {
+		switch s.Phase {
+		case discovery.PhaseCoarse:
+			coarse++
+			coarseTime += s.Duration
+		case discovery.PhaseFine:
+			fine++
+			fineTime += s.Duration
+		}
+	}
+	if coarse == 0 {
+		return
+	}
+	bracket := "no bracket: every level held"
+	if r.BracketHigh > 0 {
+		bracket = fmt.Sprintf("bracketed %.0f-%.0f TPS", r.BracketLow, r.BracketHigh)
+	}
+	fmt.Printf("    %s  %d steps in %s, %s\n", tui.LabelStyle.Render("Coarse:"), coarse, coarseTime, bracket)
+	if fine > 0 {
+		fmt.Printf("    %s    %d steps in %s\n", tui.LabelStyle.Render("Fine:"), fine, fineTime)
+	}
+}
+
+// printConcurrencyResult reports a concurrency sweep's knee (#1250).
+func printConcurrencyResult(r *discovery.Result) {
+	fmt.Println()
+	fmt.Println()
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Println()
+	fmt.Println(tui.SuccessStyle.Render("  ✓ CONCURRENCY SWEEP COMPLETE"))
+	fmt.Println()
+	fmt.Println(strings.Repeat("─", 60))
+	fmt.Println()
+	if r.KneeConcurrency == 0 {
+		fmt.Println(tui.WarningStyle.Render("  Unstable at the lowest concurrency; lower --min-concurrency"))
+	} else {
+		fmt.Println("  Latency holds up to:")
+		fmt.Println()
+		fmt.Printf("    %s  %s\n",
+			tui.LabelStyle.Render("Knee:"),
+			tui.HighlightStyle.Render(fmt.Sprintf("%d in flight", r.KneeConcurrency)))
+		fmt.Printf("    %s  %.0f TPS\n", tui.LabelStyle.Render("Throughput:"), r.SustainedTPS)
+	}
+	fmt.Println()
+	fmt.Println("  Per level:")
+	fmt.Println()
+	lf := discoverLatency()
+	for _, s := range r.Steps {
+		mark := ""
+		if s.Concurrency == r.KneeConcurrency {
+			mark = "  ← knee"
+		}
+		fmt.Printf("    %5d  %8.0f TPS  p95 %-10s  err %5.1f%%%s\n",
+			s.Concurrency, s.AchievedTPS, lf.Format(s.P95Latency), s.ErrorRate, mark)
+	}
 	fmt.Println()
 	fmt.Println(strings.Repeat("─", 60))
 	fmt.Println()
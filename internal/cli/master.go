@@ -16,18 +16,19 @@ import (
 )
 
 var (
-	masterListen       string
-	masterConfigPath   string
-	masterTLSCert      string
-	masterTLSKey       string
-	masterTLSCA        string
-	masterAuthToken    string
-	masterAuthTokenEnv string
-	masterHAStore      string
-	masterHAID         string
-	masterHAEndpoints  string
-	masterHAKey        string
-	masterHATTL        time.Duration
+	masterListen        string
+	masterConfigPath    string
+	masterTLSCert       string
+	masterTLSKey        string
+	masterTLSCA         string
+	masterAuthToken     string
+	masterAuthTokenEnv  string
+	masterAuthTokenFile string
+	masterHAStore       string
+	masterHAID          string
+	masterHAEndpoints   string
+	masterHAKey         string
+	masterHATTL         time.Duration
 
 	failoverHAStore     string
 	failoverHAEndpoints string
@@ -59,6 +60,7 @@ func init() {
 	masterCmd.Flags().StringVar(&masterTLSCA, "tls-client-ca", "", "Path to client CA PEM for mTLS (optional)")
 	masterCmd.Flags().StringVar(&masterAuthToken, "auth-token", "", "Bearer token workers must present (prefer --auth-token-env)")
 	masterCmd.Flags().StringVar(&masterAuthTokenEnv, "auth-token-env", "KAR_AUTH_TOKEN", "Env var name to read auth token from (takes precedence over --auth-token)")
+	masterCmd.Flags().StringVar(&masterAuthTokenFile, "auth-token-file", "", "File to read auth token from, e.g. a mounted secret (takes precedence over --auth-token-env)")
 	masterCmd.Flags().StringVar(&masterHAStore, "ha-store", "", "HA backend (none|memory|etcd) — default 'none' disables HA; 'etcd' requires -tags ha_etcd build")
 	masterCmd.Flags().StringVar(&masterHAID, "ha-id", "", "Self-identifier for HA lease (defaults to hostname when set with --ha-store)")
 	masterCmd.Flags().StringVar(&masterHAEndpoints, "ha-endpoints", "", "Comma-separated etcd endpoints (etcd backend only)")
@@ -160,13 +162,20 @@ func runMaster(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Resolve auth token: env var takes precedence over raw flag.
+	// Resolve auth token: file, then env var, then raw flag.
 	token := masterAuthToken
 	if masterAuthTokenEnv != "" {
 		if v := os.Getenv(masterAuthTokenEnv); v != "" {
 			token = v
 		}
 	}
+	if masterAuthTokenFile != "" {
+		v, err := config.SecretRef{File: masterAuthTokenFile}.Resolve()
+		if err != nil {
+			return fmt.Errorf("--auth-token-file: %w", err)
+		}
+		token = v
+	}
 	if token != "" {
 		cfg.Master.AuthToken = token
 	}
@@ -193,6 +202,7 @@ func runMaster(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create master daemon: %w", err)
 	}
+	d.SetBuildInfo(version, gitCommit)
 
 	if err := d.Start(); err != nil {
 		return fmt.Errorf("failed to start master: %w", err)
@@ -120,6 +120,7 @@ func runQuickstart(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create daemon: %w", err)
 	}
+	d.SetBuildInfo(version, gitCommit)
 
 	if err := d.Start(); err != nil {
 		return fmt.Errorf("failed to start: %w", err)
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kar98k/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+// RPC command — raw access to the daemon's control protocol (#1212),
+// for scripts that want JSON in and JSON out.
+var rpcCmd = &cobra.Command{
+	Use:   "rpc <method> [params-json]",
+	Short: "Call a control method on the running daemon",
+	Long: `Make one JSON-RPC call to the running daemon and print its result as
+JSON. params-json is the method's params object; pass - to read it from
+stdin. A failed call prints the error and exits non-zero.
+
+Methods: ` + strings.Join(daemon.Methods(), ", ") + `
+
+Examples:
+  kar rpc status | jq .current_tps
+  kar rpc spike '{"factor": 3, "duration": "30s"}'
+  kar rpc setrate '{"target": "api", "tps": 50}'
+  kar rpc annotate '{"text": "deployed v2"}'`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var params json.RawMessage
+		if len(args) == 2 {
+			raw := []byte(args[1])
+			if args[1] == "-" {
+				var err error
+				if raw, err = io.ReadAll(os.Stdin); err != nil {
+					return err
+				}
+			}
+			if !json.Valid(raw) {
+				return fmt.Errorf("params are not valid JSON")
+			}
+			params = bytes.TrimSpace(raw)
+		}
+
+		result, err := daemon.Call(args[0], params)
+		if err != nil {
+			return err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, result, "", "  "); err != nil {
+			return err
+		}
+		fmt.Println(out.String())
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rpcCmd)
+}
@@ -1,13 +1,21 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/kar98k/internal/config"
 	"github.com/kar98k/internal/daemon"
+	"github.com/kar98k/internal/output"
+	"github.com/kar98k/internal/pattern"
+	"github.com/kar98k/internal/report"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +23,20 @@ var (
 	configPath   string
 	daemonMode   bool
 	autoTrigger  bool
+	faultInject  string
+
+	baselinePath   string
+	regressionGate bool
+	updateBaseline bool
+	tolerance      float64
+	spikeSchedule  string
+	runTags        []string
+	manifestPath   string
+	traceMaxSize   string
+	resultWebhook  string
+	planPath       string
+	runReportJSON  string
+	runReportCSV   string
 )
 
 var runCmd = &cobra.Command{
@@ -25,7 +47,35 @@ This is useful for server deployments or CI/CD pipelines.
 
 Example:
   kar run --config kar.yaml
-  kar run --config kar.yaml --trigger`,
+  kar run --config kar.yaml --trigger
+  kar run --config kar.yaml --trigger --baseline baseline.json --regression-gate
+  kar run --config kar.yaml --trigger --spike-schedule last-run.json
+
+With --baseline, the run's percentiles, error rate and achieved TPS are
+compared against a result saved by the json output sink and printed as a
+delta table. --regression-gate makes the command fail when any metric
+regressed beyond report.regression's tolerances; --update-baseline saves
+this run as the new baseline unless it failed the gate.
+
+--spike-schedule replays the spikes of an earlier run, read from its
+JSON result, instead of drawing them at random, so two runs differing
+in one setting see the same spike timeline.
+
+--manifest writes what the run will do before any traffic flows:
+targets and their shares, base/max TPS, ramp, phases and the expected
+peak TPS. Without --trigger the daemon then waits, so an orchestrator
+can check the manifest and approve the run with 'kar trigger'.
+
+--plan runs a test plan: a YAML file of ordered stages, each with its
+duration, load and SLO, in place of the config's scenarios. The run
+triggers itself (unless --manifest asks for approval), stops after the
+last stage, prints each stage's verdict and fails if any stage missed
+its SLO.
+
+--result-webhook POSTs the run's JSON summary, with a pass/fail verdict
+from report.slo and the gates above, to a URL once the run ends, e.g.
+for a chat bot or dashboard. A webhook that can't be reached is
+reported but doesn't change the exit code.`,
 	RunE: runRun,
 }
 
@@ -33,6 +83,28 @@ func init() {
 	runCmd.Flags().StringVarP(&configPath, "config", "c", "kar.yaml", "Path to configuration file")
 	runCmd.Flags().BoolVarP(&daemonMode, "daemon", "d", false, "Run as background daemon")
 	runCmd.Flags().BoolVarP(&autoTrigger, "trigger", "t", false, "Auto-trigger on start")
+	runCmd.Flags().StringVar(&faultInject, "fault-inject", "",
+		`Fake failures in kar's own clients to test its error handling, e.g. "error=0.05,timeout=0.01,slow=0.1,delay=2s"`)
+	runCmd.Flags().StringVar(&spikeSchedule, "spike-schedule", "", "Replay the spike timeline recorded in this JSON result instead of drawing spikes")
+	runCmd.Flags().StringVar(&baselinePath, "baseline", "", "Compare the run against this JSON result")
+	runCmd.Flags().BoolVar(&regressionGate, "regression-gate", false, "Exit non-zero when a metric regressed against --baseline")
+	runCmd.Flags().BoolVar(&updateBaseline, "update-baseline", false, "Save this run as the new --baseline (skipped if the gate fails)")
+	runCmd.Flags().Float64Var(&tolerance, "tolerance", 0,
+		"Allowed relative latency increase / TPS drop, e.g. 0.1 for 10% (overrides report.regression)")
+	runCmd.Flags().StringVar(&manifestPath, "manifest", "",
+		"Write what the run will do (targets, rates, expected peak TPS) as JSON to this file before any traffic flows")
+	runCmd.Flags().StringVar(&traceMaxSize, "trace-max-size", "",
+		"Roll jsonl outputs over to .1, .2, ... at this size, e.g. 100MB (for sinks without max_size)")
+	runCmd.Flags().StringArrayVar(&runTags, "tag", nil,
+		"Record key=value in the run's metadata, e.g. --tag target_sha=abc123 (repeatable; adds to report.tags)")
+	runCmd.Flags().StringVar(&planPath, "plan", "",
+		"Run the stages of this test plan, each judged against its own SLO, then stop")
+	runCmd.Flags().StringVar(&resultWebhook, "result-webhook", "",
+		"POST the run's JSON summary and SLO verdict to this URL when it ends")
+	runCmd.Flags().StringVar(&runReportJSON, "report-json", "",
+		"Write the report's summary, percentiles, status codes and timeline as JSON to this file, as kar start does")
+	runCmd.Flags().StringVar(&runReportCSV, "report-csv", "",
+		"Write the report's timeline, a row per report.interval slot, and a summary as CSV to this file")
 	rootCmd.AddCommand(runCmd)
 }
 
@@ -48,11 +120,105 @@ func runRun(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if faultInject != "" {
+		f, err := config.ParseFaultInject(faultInject)
+		if err != nil {
+			return fmt.Errorf("invalid --fault-inject: %w", err)
+		}
+		cfg.Worker.FaultInject = f
+		for _, iss := range config.ValidateConfig(cfg) {
+			if iss.Severity == config.SeverityError && strings.HasPrefix(iss.Path, "worker.fault_inject") {
+				return fmt.Errorf("invalid --fault-inject: %s", iss.Message)
+			}
+		}
+	}
+
+	if planPath != "" {
+		plan, err := config.LoadPlan(planPath)
+		if err != nil {
+			return err
+		}
+		if err := cfg.ApplyPlan(plan); err != nil {
+			return fmt.Errorf("invalid --plan: %w", err)
+		}
+		for _, iss := range config.ValidateConfig(cfg) {
+			if iss.Severity == config.SeverityError && strings.HasPrefix(iss.Path, "scenarios") {
+				return fmt.Errorf("invalid --plan: %s: %s", strings.Replace(iss.Path, "scenarios", "stages", 1), iss.Message)
+			}
+		}
+		if manifestPath == "" {
+			autoTrigger = true
+		}
+	}
+
+	// Load the baseline before the run so a bad path fails in seconds,
+	// not after an hour of load. A missing file is fine when this run
+	// is meant to create it.
+	var baseline *output.Result
+	if (regressionGate || updateBaseline) && baselinePath == "" {
+		return fmt.Errorf("--regression-gate and --update-baseline need --baseline")
+	}
+	if tolerance < 0 {
+		return fmt.Errorf("invalid --tolerance %v: must not be negative", tolerance)
+	}
+	if tolerance > 0 {
+		cfg.Report.Regression.Latency = tolerance
+		cfg.Report.Regression.TPS = tolerance
+	}
+	if baselinePath != "" {
+		baseline, err = output.LoadBaseline(baselinePath)
+		if err != nil && !(updateBaseline && errors.Is(err, os.ErrNotExist)) {
+			return fmt.Errorf("failed to load baseline: %w", err)
+		}
+	}
+
+	if resultWebhook != "" {
+		if err := output.ValidateWebhookURL(resultWebhook); err != nil {
+			return fmt.Errorf("--result-webhook: %w", err)
+		}
+	}
+
+	if traceMaxSize != "" {
+		if _, err := config.ParseSize(traceMaxSize); err != nil {
+			return fmt.Errorf("--trace-max-size: %w", err)
+		}
+		for i := range cfg.Output {
+			if cfg.Output[i].Type == config.OutputJSONL && cfg.Output[i].MaxSize == "" {
+				cfg.Output[i].MaxSize = traceMaxSize
+			}
+		}
+	}
+
+	if cfg.Report.Tags, err = config.ParseTags(cfg.Report.Tags, runTags); err != nil {
+		return fmt.Errorf("--tag: %w", err)
+	}
+
+	var spikes []pattern.SpikeEvent
+	if spikeSchedule != "" {
+		spikes, err = pattern.LoadSpikeSchedule(spikeSchedule)
+		if err != nil {
+			return fmt.Errorf("failed to load spike schedule: %w", err)
+		}
+	}
 
 	fmt.Printf("⌖ kar starting (config: %s)\n", configPath)
 	fmt.Printf("  Targets: %d\n", len(cfg.Targets))
 	fmt.Printf("  Base TPS: %.0f\n", cfg.Controller.BaseTPS)
 	fmt.Printf("  Max TPS: %.0f\n", cfg.Controller.MaxTPS)
+	if f := cfg.Worker.FaultInject; f.Enabled() {
+		fmt.Printf("  ⚠️  Fault injection: timeout=%g error=%g slow=%g (delay %s) — results describe kar, not the target\n",
+			f.Timeout, f.Error, f.Slow, f.Delay())
+	}
+	if spikeSchedule != "" {
+		fmt.Printf("  Spikes: replaying %d from %s\n", len(spikes), spikeSchedule)
+	}
+	if planPath != "" {
+		var total time.Duration
+		for _, st := range cfg.Scenarios {
+			total += st.Duration
+		}
+		fmt.Printf("  Plan: %d stages, %s (%s)\n", len(cfg.Scenarios), total, planPath)
+	}
 	fmt.Println()
 
 	// Create daemon
@@ -60,11 +226,22 @@ func runRun(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create daemon: %w", err)
 	}
+	d.SetBuildInfo(version, gitCommit)
+	if spikeSchedule != "" {
+		d.ReplaySpikes(spikes)
+	}
 
 	// Start daemon
 	if err := d.Start(); err != nil {
 		return fmt.Errorf("failed to start: %w", err)
 	}
+	if manifestPath != "" {
+		if err := d.WriteManifest(manifestPath); err != nil {
+			d.Stop()
+			return err
+		}
+		fmt.Printf("📋 Manifest written to %s\n", manifestPath)
+	}
 
 	// Auto-trigger if requested
 	if autoTrigger {
@@ -79,9 +256,240 @@ func runRun(cmd *cobra.Command, args []string) error {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
-	<-sigCh
-	fmt.Println("\n🛑 Shutting down...")
+	var planDone <-chan struct{}
+	if planPath != "" {
+		planDone = d.ScenariosDone()
+	}
+	select {
+	case <-sigCh:
+		fmt.Println("\n🛑 Shutting down...")
+	case <-planDone:
+		fmt.Println("\n🏁 Plan complete, shutting down...")
+	}
 	d.Stop()
 
-	return nil
+	if len(cfg.Output) > 0 {
+		errs := d.OutputErrors()
+		fmt.Printf("📄 Results written to %d of %d output sink(s)\n", len(cfg.Output)-len(errs), len(cfg.Output))
+		for _, err := range errs {
+			fmt.Printf("   ✗ %v\n", err)
+		}
+	}
+
+	if r := d.FinalResult(); r != nil {
+		writeRunReports(r)
+	}
+
+	if r := d.FinalResult(); r != nil && r.Fidelity != nil && r.Fidelity.Low {
+		f := r.Fidelity
+		fmt.Printf("⚠️  Delivered %.0f%% of the requested load (%.1f of %.1f TPS on average), below %.0f%%:\n",
+			f.Percent(), f.AchievedTPS, f.RequestedTPS, f.Threshold*100)
+		fmt.Println("   kar's workers or the target couldn't keep up; the figures describe a lighter load than configured")
+	}
+
+	var intentErr error
+	if cfg.IntentCheck.Enabled {
+		devs := d.IntentDeviations()
+		if len(devs) == 0 {
+			fmt.Println("✓ Intent check: generated load matched the configured pattern")
+		} else {
+			fmt.Printf("⚠️  Intent check: %d deviation(s)\n", len(devs))
+			for _, dev := range devs {
+				fmt.Printf("   %-13s expected %s, observed %s\n", dev.Check, dev.Expected, dev.Observed)
+				fmt.Printf("   %-13s %s\n", "", dev.Message)
+			}
+			if cfg.IntentCheck.FailOnDeviation {
+				intentErr = fmt.Errorf("intent check failed: %d deviation(s)", len(devs))
+			}
+		}
+	}
+
+	var postErr error
+	if r := d.FinalResult(); r != nil && len(r.PostChecks) > 0 {
+		failed := 0
+		for _, pc := range r.PostChecks {
+			if pc.Passed {
+				fmt.Printf("✓ Post check %s\n", pc.Name)
+				continue
+			}
+			failed++
+			fmt.Printf("✗ Post check %s\n", pc.Name)
+			for _, f := range pc.Failures {
+				fmt.Printf("   %s\n", f)
+			}
+		}
+		if failed > 0 {
+			postErr = fmt.Errorf("post checks failed: %d of %d", failed, len(r.PostChecks))
+		}
+	}
+
+	var planErr error
+	if r := d.FinalResult(); r != nil && len(r.Stages) > 0 {
+		if failed := printStages(r.Stages, cfg.Report.Latency); failed > 0 && planPath != "" {
+			planErr = fmt.Errorf("plan failed: %d of %d stage(s) missed their SLO", failed, len(r.Stages))
+		}
+	}
+
+	var baseErr error
+	if baselinePath != "" {
+		baseErr = checkBaseline(d.FinalResult(), baseline, cfg.Report.Regression)
+	}
+	if resultWebhook != "" {
+		sendResult(d.FinalResult(), cfg.Report.SLO, baseErr, intentErr, postErr, planErr)
+	}
+	if baseErr != nil {
+		return baseErr
+	}
+	if intentErr != nil {
+		return intentErr
+	}
+	if postErr != nil {
+		return postErr
+	}
+	return planErr
+}
+
+// writeRunReports writes --report-json and --report-csv (#1261) from
+// the run's final result, in the format `kar start` uses. A failed
+// write is reported, not fatal: the run itself already happened.
+func writeRunReports(r *output.Result) {
+	for _, out := range []struct {
+		path, kind string
+		write      func(report.ReportData, io.Writer) error
+	}{
+		{runReportJSON, "JSON", report.WriteJSON},
+		{runReportCSV, "CSV", report.WriteCSV},
+	} {
+		if out.path == "" {
+			continue
+		}
+		if err := writeReport(out.path, r.Report(), out.write); err != nil {
+			fmt.Printf("✗ Failed to write %s report: %v\n", out.kind, err)
+			continue
+		}
+		fmt.Printf("📄 %s report written to %s\n", out.kind, out.path)
+	}
+}
+
+// printStages prints each scenario phase's figures and SLO verdict
+// (#1262) and returns how many failed.
+func printStages(stages []output.Stage, lat config.LatencyFormat) (failed int) {
+	fmt.Println("📋 Stages")
+	for _, st := range stages {
+		mark := "✓"
+		switch {
+		case !st.Passed:
+			mark = "✗"
+			failed++
+		case st.SLO == nil:
+			mark = "·"
+		}
+		fmt.Printf("   %s %-14s %8d req  p95 %9s  p99 %9s  errors %6.2f%%", mark, st.Name,
+			st.Requests, lat.Format(st.P95Ms), lat.Format(st.P99Ms), st.ErrorRate)
+		if !st.Passed {
+			fmt.Printf("  missed: %s", strings.Join(st.Breaches, ", "))
+		}
+		fmt.Println()
+	}
+	return failed
+}
+
+// sendResult posts the final result and its verdict to
+// --result-webhook (#1254). The run's outcome is already settled, so a
+// failure is only reported.
+func sendResult(r *output.Result, slo config.SLO, gateErrs ...error) {
+	var failures []string
+	for _, err := range gateErrs {
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	v := output.NewVerdict(r, slo, failures)
+	if r == nil {
+		r = &output.Result{}
+	}
+	if err := output.PostResult(context.Background(), resultWebhook, r, v); err != nil {
+		fmt.Printf("⚠️  Result webhook failed: %v\n", err)
+		return
+	}
+	verdict := "passed"
+	if !v.Passed {
+		verdict = "failed"
+	}
+	fmt.Printf("📨 Result posted to webhook (verdict: %s)\n", verdict)
+}
+
+// checkBaseline prints the delta table against baseline and applies
+// --regression-gate and --update-baseline (#1203).
+func checkBaseline(cur, baseline *output.Result, tol config.Regression) error {
+	if cur == nil || cur.Requests == 0 {
+		fmt.Println("⚠️  The run completed no requests: nothing to compare, baseline not updated")
+		if regressionGate {
+			return fmt.Errorf("regression gate failed: the run completed no requests")
+		}
+		return nil
+	}
+
+	var gateErr error
+	if baseline == nil {
+		fmt.Printf("📏 No baseline at %s yet\n", baselinePath)
+	} else {
+		deltas := output.Compare(baseline, cur, tol)
+		n := output.Regressions(deltas)
+		fmt.Printf("📏 Baseline comparison (%s)\n", baselinePath)
+		if diffs := output.ConfigMismatch(baseline, cur); len(diffs) > 0 {
+			fmt.Println("⚠️  The baseline ran with different settings; deltas reflect the config change too:")
+			for _, d := range diffs {
+				fmt.Println("     " + d)
+			}
+		}
+		fmt.Printf("   %-22s %12s %12s %9s\n", "Metric", "Baseline", "Current", "Change")
+		for _, dl := range deltas {
+			mark := ""
+			if dl.Regressed {
+				mark = "  ✗ regressed"
+			}
+			fmt.Printf("   %-22s %12s %12s %9s%s\n", dl.Metric,
+				formatMetric(dl.Baseline, dl.Unit, cur.Latency), formatMetric(dl.Current, dl.Unit, cur.Latency), formatChange(dl), mark)
+		}
+		if n == 0 {
+			fmt.Println("✓ No regressions")
+		} else {
+			fmt.Printf("⚠️  %d metric(s) regressed\n", n)
+			if regressionGate {
+				gateErr = fmt.Errorf("regression gate failed: %d metric(s) regressed against %s", n, baselinePath)
+			}
+		}
+	}
+	if updateBaseline {
+		if gateErr != nil {
+			fmt.Println("   Baseline not updated: the run failed the regression gate")
+		} else if err := output.WriteBaseline(baselinePath, cur); err != nil {
+			return fmt.Errorf("failed to update baseline: %w", err)
+		} else {
+			fmt.Printf("📏 Baseline updated: %s\n", baselinePath)
+		}
+	}
+	return gateErr
+}
+
+func formatMetric(v float64, unit string, lat config.LatencyFormat) string {
+	switch unit {
+	case "ms":
+		return lat.Format(v)
+	case "%":
+		return fmt.Sprintf("%.2f%%", v)
+	}
+	return fmt.Sprintf("%.1f", v)
+}
+
+// formatChange shows error rates in points and the rest relative.
+func formatChange(dl output.Delta) string {
+	if dl.Unit == "%" {
+		return fmt.Sprintf("%+.2fpp", dl.Current-dl.Baseline)
+	}
+	if dl.Baseline == 0 {
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", dl.Change*100)
 }
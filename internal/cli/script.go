@@ -25,6 +25,7 @@ var (
 	scriptDashPort   string
 	scriptWait       bool
 	scriptOut        string
+	scriptFormat     string
 	scriptReport     string
 )
 
@@ -43,7 +44,8 @@ Supported languages:
 Examples:
   kar script test.star
   kar script test.js --vus 50 --duration 2m
-  kar script test.py --preset aggressive`,
+  kar script test.py --preset aggressive
+  kar script test.star --out json=summary.json --export-format k6`,
 	Args: cobra.ExactArgs(1),
 	RunE: runScript,
 }
@@ -56,6 +58,7 @@ func init() {
 	scriptCmd.Flags().StringVar(&scriptDashPort, "dash-port", ":8888", "Dashboard listen address")
 	scriptCmd.Flags().BoolVar(&scriptWait, "wait", false, "Wait for trigger from dashboard before starting")
 	scriptCmd.Flags().StringVar(&scriptOut, "out", "", "Export results: json=file.json or junit=report.xml")
+	scriptCmd.Flags().StringVar(&scriptFormat, "export-format", "kar", "Schema for --out json=: kar or k6 (k6 --summary-export compatible)")
 	scriptCmd.Flags().StringVar(&scriptReport, "report", "", "Generate HTML report (e.g. report.html)")
 	rootCmd.AddCommand(scriptCmd)
 }
@@ -259,10 +262,20 @@ func runScriptExport(runner script.Runner, elapsed time.Duration) error {
 
 	switch outType {
 	case "json":
-		if err := script.ExportJSON(outPath, runner, elapsed); err != nil {
-			return err
+		switch scriptFormat {
+		case "", "kar":
+			if err := script.ExportJSON(outPath, runner, elapsed); err != nil {
+				return err
+			}
+			fmt.Printf("  JSON results written to %s\n", outPath)
+		case "k6":
+			if err := script.ExportK6(outPath, runner, elapsed); err != nil {
+				return err
+			}
+			fmt.Printf("  k6 summary written to %s\n", outPath)
+		default:
+			return fmt.Errorf("unknown --export-format %q: use kar or k6", scriptFormat)
 		}
-		fmt.Printf("  JSON results written to %s\n", outPath)
 	case "junit":
 		if err := script.ExportJUnit(outPath, runner, elapsed); err != nil {
 			return err
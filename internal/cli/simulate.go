@@ -76,7 +76,7 @@ func runSimulate(cmd *cobra.Command, args []string) error {
 			cfg.Pattern,
 			cfg.Controller.BaseTPS,
 			cfg.Controller.MaxTPS,
-			sched.GetMultiplierForHour,
+			sched.MultiplierAt,
 			start,
 			simulateDuration,
 			simulateResolution,
@@ -138,7 +138,7 @@ func printSimulateText(cfg *config.Config, pts []pattern.SamplePoint) {
 	if len(cfg.Scenarios) > 0 {
 		fmt.Printf("  phases:      %s\n", tui.DimStyle.Render(fmt.Sprintf("%d (driven by scenarios)", len(cfg.Scenarios))))
 	}
-	fmt.Printf("  spikes:      %s\n", tui.DimStyle.Render(spikeStatusLine(cfg.Pattern.Poisson)))
+	fmt.Printf("  spikes:      %s\n", tui.DimStyle.Render(spikeStatusLine(cfg.Pattern.Poisson, cfg.Controller.BaseTPS)))
 	fmt.Println()
 
 	if len(pts) == 0 {
@@ -260,12 +260,16 @@ func humanCount(v float64) string {
 	}
 }
 
-func spikeStatusLine(p config.Poisson) string {
+func spikeStatusLine(p config.Poisson, baseTPS float64) string {
 	if !p.Enabled {
 		return "disabled"
 	}
-	return fmt.Sprintf("λ=%.4f, factor=%.1fx, ramp=%s/%s",
-		p.Lambda, p.SpikeFactor, p.RampUp, p.RampDown)
+	factor := fmt.Sprintf("%.1fx", p.Factor(baseTPS))
+	if p.SpikeCapacity > 0 {
+		factor += fmt.Sprintf(" (%.0f%% of %.0f TPS capacity)", p.SpikeCapacity*100, p.CapacityTPS)
+	}
+	return fmt.Sprintf("λ=%.4f, factor=%s, ramp=%s/%s",
+		p.Lambda, factor, p.RampUp, p.RampDown)
 }
 
 func printSimulateCSV(pts []pattern.SamplePoint) error {
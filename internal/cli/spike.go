@@ -4,12 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
-	"strconv"
-	"strings"
-	"syscall"
 	"time"
 
+	"github.com/kar98k/internal/daemon"
 	"github.com/kar98k/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -46,12 +43,11 @@ type SpikeCommand struct {
 }
 
 func runSpike(cmd *cobra.Command, args []string) error {
-	pidPath := filepath.Join(os.TempDir(), "kar98k", "kar98k.pid")
-	cmdPath := filepath.Join(os.TempDir(), "kar98k", "kar98k.cmd")
+	cmdPath := daemon.GetCmdPath()
 
-	// Check if kar is running
-	pidData, err := os.ReadFile(pidPath)
-	if err != nil {
+	// Check if kar is running; a stale PID file is cleaned up
+	pid, running := daemon.RunningPid()
+	if !running {
 		fmt.Println()
 		fmt.Println(tui.WarningStyle.Render("  kar is not running"))
 		fmt.Println(tui.DimStyle.Render("  Start kar first with: kar start"))
@@ -59,30 +55,11 @@ func runSpike(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	pid, err := strconv.Atoi(strings.TrimSpace(string(pidData)))
-	if err != nil {
-		fmt.Println()
-		fmt.Println(tui.ErrorStyle.Render("  Invalid PID file"))
-		fmt.Println()
-		return nil
-	}
-
-	// Check if process exists
 	process, err := os.FindProcess(pid)
 	if err != nil {
 		fmt.Println()
 		fmt.Println(tui.WarningStyle.Render("  kar is not running"))
 		fmt.Println()
-		os.Remove(pidPath)
-		return nil
-	}
-
-	// Verify process is actually running
-	if err := process.Signal(syscall.Signal(0)); err != nil {
-		fmt.Println()
-		fmt.Println(tui.WarningStyle.Render("  kar is not running"))
-		fmt.Println()
-		os.Remove(pidPath)
 		return nil
 	}
 
@@ -99,6 +76,24 @@ func runSpike(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// A daemon takes the spike over its control socket (#1212); it
+	// doesn't handle SIGUSR1, only a foreground `kar start` does.
+	if daemon.IsRunning() {
+		data, _ := json.Marshal(daemon.SpikeRequest{Factor: spikeFactor, Duration: spikeDuration})
+		resp, err := daemon.SendCommand(daemon.Command{Type: "spike", Data: data})
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			fmt.Println()
+			fmt.Println(tui.WarningStyle.Render("  " + resp.Message))
+			fmt.Println()
+			return nil
+		}
+		printSpikeTriggered(duration)
+		return nil
+	}
+
 	// Create spike command
 	spikeCmd := SpikeCommand{
 		Type:     "spike",
@@ -123,6 +118,11 @@ func runSpike(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	printSpikeTriggered(duration)
+	return nil
+}
+
+func printSpikeTriggered(duration time.Duration) {
 	fmt.Println()
 	fmt.Println(tui.SuccessStyle.Render("  " + tui.CheckMark + " Manual spike triggered!"))
 	if spikeFactor > 0 {
@@ -136,6 +136,4 @@ func runSpike(cmd *cobra.Command, args []string) error {
 		fmt.Println(tui.DimStyle.Render("    Duration: using default"))
 	}
 	fmt.Println()
-
-	return nil
 }
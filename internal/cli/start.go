@@ -2,19 +2,21 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"os/exec"
 	"os/signal"
-	"path/filepath"
 	"strconv"
-	"strings"
 	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kar98k/internal/config"
 	"github.com/kar98k/internal/daemon"
+	"github.com/kar98k/internal/report"
 	"github.com/kar98k/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -28,50 +30,115 @@ then pull the trigger to start generating traffic.`,
 	RunE: runStart,
 }
 
+var (
+	startReportText      string
+	startReportHTML      string
+	startReportJSON      string
+	startReportCSV       string
+	startReportInterval  time.Duration
+	startExpectedLatency time.Duration
+	startLatencyUnit     string
+	startTags            []string
+	startMinSamples      int
+)
+
 func init() {
 	rootCmd.AddCommand(startCmd)
+
+	startCmd.Flags().StringVar(&startReportText, "report-text", "",
+		"Write the final report as plain text (no colors or box drawing) to this file")
+	startCmd.Flags().StringVar(&startReportHTML, "report-html", "",
+		"Write the final report as a self-contained HTML page with charts to this file")
+	startCmd.Flags().StringVar(&startReportJSON, "report-json", "",
+		"Write the final report's summary, percentiles, status codes and timeline as JSON to this file")
+	startCmd.Flags().StringVar(&startReportCSV, "report-csv", "",
+		"Write the final report's timeline, a row per slot, and a summary as CSV to this file")
+	startCmd.Flags().DurationVar(&startReportInterval, "report-interval", tui.DefaultSlotInterval,
+		"Width of each report timeline slot (report.interval)")
+	startCmd.Flags().DurationVar(&startExpectedLatency, "expected-latency", 0,
+		"Reference latency marked on the live view and report timeline (targets[].expected_latency)")
+	startCmd.Flags().StringVar(&startLatencyUnit, "latency-unit", config.LatencyUnitAuto,
+		"Unit latencies are printed in: auto, us, ms or s (report.latency.unit)")
+	startCmd.Flags().StringArrayVar(&startTags, "tag", nil,
+		"Record key=value in the run's metadata, e.g. --tag target_sha=abc123 (repeatable; report.tags)")
+	startCmd.Flags().IntVar(&startMinSamples, "min-samples", 0,
+		"Samples needed beyond a percentile to trust it; fewer flag or omit it (report.min_samples, 0 = default, -1 = off)")
 }
 
 func runStart(cmd *cobra.Command, args []string) error {
-	// Check if already running
-	pidPath := filepath.Join(os.TempDir(), "kar98k", "kar98k.pid")
-	if pidData, err := os.ReadFile(pidPath); err == nil {
-		// PID file exists, check if process is actually running
-		if pid, err := strconv.Atoi(strings.TrimSpace(string(pidData))); err == nil {
-			if process, err := os.FindProcess(pid); err == nil {
-				// On Unix, FindProcess always succeeds, so we need to send signal 0 to check
-				if err := process.Signal(syscall.Signal(0)); err == nil {
-					fmt.Println("\n⚠️  kar is already running!")
-					fmt.Println("   Use 'kar status' to check status")
-					fmt.Println("   Use 'kar stop' to stop the running instance")
-					return nil
-				}
-			}
-		}
-		// Process not running, clean up stale PID file
-		os.Remove(pidPath)
+	// Check if already running; a stale PID file is cleaned up
+	if _, running := daemon.RunningPid(); running {
+		fmt.Println("\n⚠️  kar is already running!")
+		fmt.Println("   Use 'kar status' to check status")
+		fmt.Println("   Use 'kar stop' to stop the running instance")
+		return nil
 	}
 
-	// Initialize logger
-	if err := tui.InitLogger(); err != nil {
+	// Initialize logger (creates the runtime directory)
+	if err := tui.InitLogger(daemon.GetLogPath()); err != nil {
 		return fmt.Errorf("failed to init logger: %w", err)
 	}
 	defer tui.CloseLogger()
 
-	// Create runtime directory and PID file
-	runtimeDir := filepath.Join(os.TempDir(), "kar98k")
-	os.MkdirAll(runtimeDir, 0755)
+	// PID file, so `kar stop` and `kar spike` can find this session
+	pidPath := daemon.GetPidPath()
 	os.WriteFile(pidPath, []byte(fmt.Sprintf("%d", os.Getpid())), 0644)
 	defer os.Remove(pidPath)
 
+	if startReportInterval < config.MinReportInterval {
+		return fmt.Errorf("--report-interval must be at least %s", config.MinReportInterval)
+	}
+	if startExpectedLatency < 0 {
+		return fmt.Errorf("--expected-latency must be non-negative")
+	}
+	if !config.ValidLatencyUnit(startLatencyUnit) {
+		return fmt.Errorf("--latency-unit must be auto, us, ms or s")
+	}
+	if startMinSamples < -1 {
+		return fmt.Errorf("--min-samples must be a count, or -1 to report every percentile")
+	}
+	tags, err := config.ParseTags(nil, startTags)
+	if err != nil {
+		return fmt.Errorf("--tag: %w", err)
+	}
+
 	// Run the TUI
 	m := tui.NewModel()
+	m.SetSlotInterval(startReportInterval)
+	m.SetExpectedLatency(startExpectedLatency)
+	m.SetLatencyFormat(config.LatencyFormat{Unit: startLatencyUnit})
+	m.SetMetadata(config.NewRunMetadata(version, gitCommit, tags))
+	m.SetMinSamples(startMinSamples)
+	m.SetConfigSaver(func(tuiConfig map[string]string, path string) error {
+		cfg, err := startConfig(tuiConfig, tags)
+		if err != nil {
+			return err
+		}
+		return config.Save(cfg, path)
+	})
+	// Fire starts the daemon in-process, so the running screen's
+	// multiplier reaches live traffic over the control channel (#1232).
+	var d *daemon.Daemon
+	m.SetLauncher(func(tuiConfig map[string]string) error {
+		cfg, err := startConfig(tuiConfig, tags)
+		if err != nil {
+			return err
+		}
+		// The daemon's components use the log package; keep their
+		// lines in the log file rather than on the TUI's screen.
+		if f, err := os.OpenFile(daemon.GetLogPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644); err == nil {
+			log.SetOutput(f)
+		}
+		d, err = startDaemon(cfg)
+		return err
+	})
+	m.SetMultiplierSender(sendMultiplier)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	// Handle signals
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT, signalUSR1)
-	cmdPath := filepath.Join(os.TempDir(), "kar98k", "kar98k.cmd")
+	cmdPath := daemon.GetCmdPath()
 	go func() {
 		for sig := range sigCh {
 			switch sig {
@@ -104,21 +171,50 @@ func runStart(cmd *cobra.Command, args []string) error {
 	model := finalModel.(tui.Model)
 	tuiConfig := model.GetConfig()
 
+	if startReportText != "" && model.HasReport() {
+		if err := os.WriteFile(startReportText, []byte(tui.RenderReportText(model.Report)), 0644); err != nil {
+			return fmt.Errorf("failed to write text report: %w", err)
+		}
+		fmt.Printf("\n📄 Text report written to %s\n", startReportText)
+	}
+	if startReportHTML != "" && model.HasReport() {
+		if err := report.WriteHTML(model.Report, startReportHTML); err != nil {
+			return fmt.Errorf("failed to write HTML report: %w", err)
+		}
+		fmt.Printf("\n📊 HTML report written to %s\n", startReportHTML)
+	}
+	if startReportJSON != "" && model.HasReport() {
+		if err := writeReport(startReportJSON, model.Report, report.WriteJSON); err != nil {
+			return fmt.Errorf("failed to write JSON report: %w", err)
+		}
+		fmt.Printf("\n📄 JSON report written to %s\n", startReportJSON)
+	}
+	if startReportCSV != "" && model.HasReport() {
+		if err := writeReport(startReportCSV, model.Report, report.WriteCSV); err != nil {
+			return fmt.Errorf("failed to write CSV report: %w", err)
+		}
+		fmt.Printf("\n📄 CSV report written to %s\n", startReportCSV)
+	}
+
 	// Check if user completed configuration
 	if tuiConfig["target_url"] == "" {
 		fmt.Println("\n👋 Configuration cancelled. Goodbye!")
 		return nil
 	}
 
-	// Build configuration
-	cfg := buildConfigFromTUI(tuiConfig)
+	// Start daemon in background, unless Fire already did
+	if d == nil {
+		cfg, err := startConfig(tuiConfig, tags)
+		if err != nil {
+			return err
+		}
 
-	// Start daemon in background
-	fmt.Println("\n🚀 Starting kar daemon...")
+		fmt.Println("\n🚀 Starting kar daemon...")
 
-	// Fork to background
-	if err := startDaemon(cfg); err != nil {
-		return fmt.Errorf("failed to start daemon: %w", err)
+		// Fork to background
+		if _, err := startDaemon(cfg); err != nil {
+			return fmt.Errorf("failed to start daemon: %w", err)
+		}
 	}
 
 	fmt.Println("✅ kar is now running in the background!")
@@ -131,7 +227,35 @@ func runStart(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func buildConfigFromTUI(tuiConfig map[string]string) *config.Config {
+// writeReport creates path and writes the report to it with write.
+func writeReport(path string, r report.ReportData, write func(report.ReportData, io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(r, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// startConfig is the configuration a TUI session runs with: what the
+// screens collected plus kar start's flags. The Review screen saves it
+// too, so `kar run --config` can repeat the session.
+func startConfig(tuiConfig map[string]string, tags map[string]string) (*config.Config, error) {
+	cfg, err := buildConfigFromTUI(tuiConfig)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Report.Interval = startReportInterval
+	cfg.Targets[0].ExpectedLatency = startExpectedLatency
+	cfg.Report.Latency.Unit = startLatencyUnit
+	cfg.Report.Tags = tags
+	return cfg, nil
+}
+
+func buildConfigFromTUI(tuiConfig map[string]string) (*config.Config, error) {
 	baseTPS, _ := strconv.ParseFloat(tuiConfig["base_tps"], 64)
 	maxTPS, _ := strconv.ParseFloat(tuiConfig["max_tps"], 64)
 	lambda, _ := strconv.ParseFloat(tuiConfig["poisson_lambda"], 64)
@@ -144,6 +268,11 @@ func buildConfigFromTUI(tuiConfig map[string]string) *config.Config {
 		spikeInterval, _ = time.ParseDuration(tuiConfig["spike_interval"])
 	}
 
+	schedule, err := config.ParseSchedule(tuiConfig["schedule"])
+	if err != nil {
+		return nil, err
+	}
+
 	if baseTPS == 0 {
 		baseTPS = 100
 	}
@@ -171,6 +300,7 @@ func buildConfigFromTUI(tuiConfig map[string]string) *config.Config {
 
 	cfg.Controller.BaseTPS = baseTPS
 	cfg.Controller.MaxTPS = maxTPS
+	cfg.Controller.Schedule = schedule
 
 	// Use interval if set, otherwise use lambda
 	if spikeInterval > 0 {
@@ -183,20 +313,21 @@ func buildConfigFromTUI(tuiConfig map[string]string) *config.Config {
 	cfg.Pattern.Poisson.SpikeFactor = spikeFactor
 	cfg.Pattern.Noise.Amplitude = noiseAmp
 
-	return cfg
+	return cfg, nil
 }
 
-func startDaemon(cfg *config.Config) error {
+func startDaemon(cfg *config.Config) (*daemon.Daemon, error) {
 	// For simplicity, we'll run in foreground mode here
 	// In production, you'd fork to background
 
 	d, err := daemon.New(cfg, daemon.ModeSolo)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	d.SetBuildInfo(version, gitCommit)
 
 	if err := d.Start(); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Auto-trigger since user completed TUI
@@ -204,7 +335,30 @@ func startDaemon(cfg *config.Config) error {
 
 	// In a real implementation, we'd detach here
 	// For now, just signal that daemon started
-	return nil
+	return d, nil
+}
+
+// multiplierTimeout bounds a multiplier change's round trip, so a
+// stuck control channel can't hang the running screen's key handling.
+const multiplierTimeout = 2 * time.Second
+
+// sendMultiplier sets the running daemon's rate multiplier with
+// set-tps and returns the one it applied (#1232).
+func sendMultiplier(mult float64) (float64, error) {
+	data, _ := json.Marshal(daemon.SetTPSRequest{Multiplier: mult})
+	resp, err := daemon.SendCommandWithin(daemon.Command{Type: "set-tps", Data: data}, multiplierTimeout)
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Success {
+		return 0, errors.New(resp.Message)
+	}
+	raw, _ := json.Marshal(resp.Data)
+	var result daemon.SetTPSResult
+	if err := json.Unmarshal(raw, &result); err != nil || result.Multiplier == 0 {
+		return 0, fmt.Errorf("daemon didn't report the multiplier it applied")
+	}
+	return result.Multiplier, nil
 }
 
 // startDaemonBackground starts the daemon as a background process
@@ -3,18 +3,25 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/kar98k/internal/config"
 	"github.com/kar98k/internal/daemon"
 	"github.com/kar98k/internal/tui"
+	"github.com/kar98k/internal/worker"
 	"github.com/spf13/cobra"
 )
 
 var (
-	statusJSON  bool
-	statusWatch bool
+	statusJSON      bool
+	statusWatch     bool
+	statusPerTarget bool
+	statusReconnect time.Duration
 )
 
 var statusCmd = &cobra.Command{
@@ -25,13 +32,22 @@ var statusCmd = &cobra.Command{
 Examples:
   kar status          Show current status
   kar status -w       Watch status (refresh every second)
-  kar status --json   Output as JSON`,
+  kar status --json   Output as JSON
+  kar status --per-target
+                      Add per-target P95/P99 and the per-target-averaged view
+
+In watch mode a lost connection to the daemon, e.g. a TCP control
+channel over a flaky network, is retried with backoff for --reconnect
+before the watch gives up. --reconnect 0 exits on the first failure.`,
 	RunE: runStatus,
 }
 
 func init() {
 	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Output as JSON")
 	statusCmd.Flags().BoolVarP(&statusWatch, "watch", "w", false, "Watch mode (refresh every second)")
+	statusCmd.Flags().DurationVar(&statusReconnect, "reconnect", 30*time.Second,
+		"In watch mode, keep reconnecting this long after the daemon stops answering (0 = give up at once)")
+	statusCmd.Flags().BoolVar(&statusPerTarget, "per-target", false, "Show per-target latency and per-target-averaged percentiles")
 	rootCmd.AddCommand(statusCmd)
 }
 
@@ -69,6 +85,17 @@ func showStatus() error {
 	return nil
 }
 
+// watchTimeout bounds one status round trip in watch mode, so a
+// dropped TCP connection shows up as a failure instead of a hang.
+const watchTimeout = 3 * time.Second
+
+// watchBackoff is the wait before reconnect attempt n: 1s doubling to
+// a 5s cap.
+func watchBackoff(n int) time.Duration {
+	d := time.Second << min(n-1, 3)
+	return min(d, 5*time.Second)
+}
+
 func watchStatus() error {
 	// Clear screen
 	fmt.Print("\033[H\033[2J")
@@ -76,16 +103,39 @@ func watchStatus() error {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
+	// lostAt is when the daemon stopped answering; zero while connected.
+	var lostAt time.Time
+	attempt := 0
 	for {
-		// Move cursor to top
-		fmt.Print("\033[H")
-
-		resp, err := daemon.SendCommand(daemon.Command{Type: "status"})
+		resp, err := daemon.SendCommandWithin(daemon.Command{Type: "status"}, watchTimeout)
 		if err != nil {
-			fmt.Println(tui.ErrorStyle.Render("Connection lost. Daemon may have stopped."))
-			return nil
+			if lostAt.IsZero() {
+				lostAt = time.Now()
+			}
+			left := statusReconnect - time.Since(lostAt)
+			if left <= 0 {
+				fmt.Print("\033[H\033[K")
+				fmt.Println(tui.ErrorStyle.Render("Connection lost. Daemon may have stopped."))
+				return nil
+			}
+			attempt++
+			wait := min(watchBackoff(attempt), left)
+			// The first line of the status is blank; the indicator
+			// takes it and the last status stays visible below.
+			fmt.Print("\033[H\033[K")
+			fmt.Print(tui.WarningStyle.Render(fmt.Sprintf("⟳ Reconnecting (attempt %d, next in %s, giving up in %s): %v",
+				attempt, wait.Round(time.Second), left.Round(time.Second), err)))
+			time.Sleep(wait)
+			continue
+		}
+		if !lostAt.IsZero() {
+			lostAt, attempt = time.Time{}, 0
+			fmt.Print("\033[H\033[2J")
 		}
 
+		// Move cursor to top
+		fmt.Print("\033[H")
+
 		statusData, _ := json.Marshal(resp.Data)
 		var status daemon.Status
 		json.Unmarshal(statusData, &status)
@@ -99,6 +149,8 @@ func watchStatus() error {
 }
 
 func printStatus(status daemon.Status) {
+	// Latencies print in the run's report.latency unit (#1213).
+	lat := status.LatencyFormat.Format
 	fmt.Println()
 
 	// Header
@@ -112,7 +164,16 @@ func printStatus(status daemon.Status) {
 
 	// Status indicator
 	var statusIcon, statusText string
-	if status.Triggered {
+	if status.Paused {
+		statusIcon = tui.WarningStyle.Render(tui.TriggerReady)
+		statusText = tui.WarningStyle.Render("PAUSED (kar resume to continue)")
+	} else if status.Triggered && status.AllUnhealthy == config.AllUnhealthyProbe {
+		statusIcon = tui.ErrorStyle.Render(tui.TriggerPulled)
+		statusText = tui.ErrorStyle.Render("ALL TARGETS UNHEALTHY (probing for recovery)")
+	} else if status.Triggered && status.AllUnhealthy != "" {
+		statusIcon = tui.ErrorStyle.Render(tui.TriggerReady)
+		statusText = tui.ErrorStyle.Render("ALL TARGETS UNHEALTHY (generation paused)")
+	} else if status.Triggered {
 		statusIcon = tui.SuccessStyle.Render(tui.TriggerPulled)
 		statusText = tui.SuccessStyle.Render("FIRING")
 	} else if status.Running {
@@ -149,6 +210,89 @@ func printStatus(status daemon.Status) {
 			tui.ValueStyle.Render(status.NextSpikeIn),
 			tui.DimStyle.Render("(auto)")))
 	}
+	if status.SquarePhase != "" {
+		content.WriteString(fmt.Sprintf("  Square: %s %s\n",
+			tui.ValueStyle.Render(status.SquarePhase),
+			tui.DimStyle.Render(fmt.Sprintf("(%s left in phase)", status.SquareLeft))))
+	}
+	if status.SpikesDropped+status.SpikesQueued+status.SpikesSuperimposed > 0 {
+		content.WriteString(tui.DimStyle.Render(fmt.Sprintf(
+			"  Overlapping spikes: %d dropped, %d queued (%d pending), %d superimposed\n",
+			status.SpikesDropped, status.SpikesQueued, status.SpikesPending, status.SpikesSuperimposed)))
+	}
+
+	// Achieved vs requested TPS over the run (#1221).
+	if f := status.Fidelity; f != nil {
+		line := fmt.Sprintf("  Fidelity: %s %s\n",
+			tui.ValueStyle.Render(fmt.Sprintf("%.0f%%", f.Ratio*100)),
+			tui.DimStyle.Render(fmt.Sprintf("(%.0f of %.0f TPS requested, run average)", f.AchievedTPS, f.RequestedTPS)))
+		if f.Low {
+			line = tui.WarningStyle.Render(fmt.Sprintf("  ⚠ Fidelity: %.0f%% of requested TPS, below %.0f%%: kar or the target can't keep up\n", f.Ratio*100, f.Threshold*100))
+		}
+		content.WriteString(line)
+	}
+
+	// A pool that can't keep up (#1222).
+	if s := status.Saturation; s != nil && s.Active {
+		content.WriteString(tui.WarningStyle.Render(fmt.Sprintf(
+			"  ⚠ Generator saturated: all %d workers busy, queue full; requested TPS can't be fed\n", s.PoolSize)))
+		content.WriteString(tui.DimStyle.Render("    raise worker.pool_size (kar scale) or set worker.on_saturation: grow\n"))
+	}
+	if s := status.Saturation; s != nil && s.Grown > 0 {
+		content.WriteString(tui.DimStyle.Render(fmt.Sprintf("  Pool grown %d time(s) on saturation, now %d workers\n", s.Grown, s.PoolSize)))
+	}
+
+	// Live multiplier from the TUI's arrow keys (#1232).
+	if status.Multiplier != 0 {
+		content.WriteString(fmt.Sprintf("  %s %s\n",
+			tui.LabelStyle.Render("Multiplier:"),
+			tui.ValueStyle.Render(fmt.Sprintf("%.1fx", status.Multiplier))))
+	}
+
+	// Per-target rates (#1201). A single unpinned target is the
+	// aggregate line again, so it is only broken out when it adds
+	// something.
+	if len(status.TargetRates) > 1 || (len(status.TargetRates) == 1 && status.TargetRates[0].Pinned) {
+		for _, r := range status.TargetRates {
+			render := tui.DimStyle.Render
+			note := ""
+			if r.Pinned {
+				render = tui.ValueStyle.Render
+				note = "  " + tui.WarningStyle.Render("pinned")
+			} else if r.WeightFactor > 0 && r.WeightFactor < 1 {
+				note = "  " + tui.DimStyle.Render(fmt.Sprintf("weight ×%.2f", r.WeightFactor))
+			}
+			content.WriteString(fmt.Sprintf("    %-14s %s%s\n",
+				tui.LabelStyle.Render(r.Target),
+				render(fmt.Sprintf("%.0f / %.0f TPS", r.AchievedTPS, r.RequestedTPS)),
+				note))
+		}
+	}
+
+	if len(status.TargetNoise) > 0 {
+		names := make([]string, 0, len(status.TargetNoise))
+		for name := range status.TargetNoise {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, fmt.Sprintf("%s ×%.2f", name, status.TargetNoise[name]))
+		}
+		content.WriteString(fmt.Sprintf("  Noise:     %s\n", tui.DimStyle.Render(strings.Join(parts, "  "))))
+	}
+
+	if len(status.Blend) > 0 {
+		parts := make([]string, 0, len(status.Blend))
+		for _, b := range status.Blend {
+			part := fmt.Sprintf("%s %.0f%% ×%.2f = %.0f TPS", b.Name, b.Share*100, b.Multiplier, b.TPS)
+			if b.Spiking {
+				part += " ⚡"
+			}
+			parts = append(parts, part)
+		}
+		content.WriteString(fmt.Sprintf("  Blend:     %s\n", tui.DimStyle.Render(strings.Join(parts, "  "))))
+	}
 
 	if status.ScenarioTotal > 0 {
 		marker := ""
@@ -169,7 +313,7 @@ func printStatus(status daemon.Status) {
 	content.WriteString("\n")
 	content.WriteString(fmt.Sprintf("  Requests:  %s\n", tui.ValueStyle.Render(fmt.Sprintf("%d", status.RequestsSent))))
 	content.WriteString(fmt.Sprintf("  Errors:    %s\n", tui.ErrorStyle.Render(fmt.Sprintf("%d", status.ErrorCount))))
-	content.WriteString(fmt.Sprintf("  Latency:   %s\n", tui.ValueStyle.Render(fmt.Sprintf("%.1fms", status.AvgLatency))))
+	content.WriteString(fmt.Sprintf("  Latency:   %s\n", tui.ValueStyle.Render(lat(status.AvgLatency))))
 
 	// Latency tail. Show raw vs CO-corrected when both have samples.
 	// The corrected values reveal latency hidden by coordinated omission
@@ -177,11 +321,54 @@ func printStatus(status daemon.Status) {
 	// "missed slots" that the raw histogram never saw.
 	if status.LatencyP95Raw > 0 || status.LatencyP95Corrected > 0 {
 		content.WriteString(fmt.Sprintf("  P95:       %s  %s\n",
-			tui.ValueStyle.Render(fmt.Sprintf("%.1fms raw", status.LatencyP95Raw)),
-			tui.DimStyle.Render(fmt.Sprintf("/ %.1fms corrected", status.LatencyP95Corrected))))
+			tui.ValueStyle.Render(lat(status.LatencyP95Raw)+" raw"),
+			tui.DimStyle.Render("/ "+lat(status.LatencyP95Corrected)+" corrected")))
 		content.WriteString(fmt.Sprintf("  P99:       %s  %s\n",
-			tui.ValueStyle.Render(fmt.Sprintf("%.1fms raw", status.LatencyP99Raw)),
-			tui.DimStyle.Render(fmt.Sprintf("/ %.1fms corrected", status.LatencyP99Corrected))))
+			tui.ValueStyle.Render(lat(status.LatencyP99Raw)+" raw"),
+			tui.DimStyle.Render("/ "+lat(status.LatencyP99Corrected)+" corrected")))
+	}
+
+	// For streaming targets the whole-response latency above is stream
+	// lifetime; time to first byte is the real signal (#1185).
+	if status.TTFBP95 > 0 {
+		content.WriteString(fmt.Sprintf("  TTFB:      %s\n",
+			tui.ValueStyle.Render("p95 "+lat(status.TTFBP95)+"  p99 "+lat(status.TTFBP99))))
+	}
+
+	// Pooled percentiles are request-weighted: a high-TPS target
+	// dominates them. The per-target average counts each target once,
+	// so a slow low-volume endpoint shows up. See #1177.
+	if statusPerTarget && len(status.TargetLatency) > 0 {
+		content.WriteString(fmt.Sprintf("  P95 avg:   %s  %s\n",
+			tui.ValueStyle.Render(lat(status.LatencyP95TargetAvg)+" per-target"),
+			tui.DimStyle.Render("/ "+lat(status.LatencyP95Raw)+" pooled")))
+		content.WriteString(fmt.Sprintf("  P99 avg:   %s  %s\n",
+			tui.ValueStyle.Render(lat(status.LatencyP99TargetAvg)+" per-target"),
+			tui.DimStyle.Render("/ "+lat(status.LatencyP99Raw)+" pooled")))
+		for _, tl := range status.TargetLatency {
+			line := fmt.Sprintf("p95 %s  p99 %s  (%d req)", lat(tl.P95Ms), lat(tl.P99Ms), tl.Samples)
+			render := tui.DimStyle.Render
+			if tl.ExpectedMs > 0 {
+				line += "  expected ≤" + lat(tl.ExpectedMs)
+			}
+			if tl.OverExpected() {
+				render = tui.ErrorStyle.Render
+			}
+			content.WriteString(fmt.Sprintf("    %-14s %s\n",
+				tui.LabelStyle.Render(tl.Target), render(line)))
+		}
+	}
+	if statusPerTarget && len(status.SpecStats) > 0 {
+		content.WriteString("  Requests:\n")
+		for _, s := range status.SpecStats {
+			render := tui.DimStyle.Render
+			if s.Errors > 0 {
+				render = tui.ErrorStyle.Render
+			}
+			content.WriteString(fmt.Sprintf("    %-14s %s\n",
+				tui.LabelStyle.Render(s.Target+"/"+s.Spec),
+				render(fmt.Sprintf("%d req  %d err", s.Requests, s.Errors))))
+		}
 	}
 
 	// Drops — render in red when sustained rate is above the warn threshold (1%)
@@ -191,6 +378,114 @@ func printStatus(status daemon.Status) {
 	}
 	content.WriteString(fmt.Sprintf("  Drops:     %s\n",
 		dropRender(fmt.Sprintf("%d (%.2f%% sustained)", status.QueueDrops, status.QueueDropRate*100))))
+	if status.PoolSize > 0 {
+		content.WriteString(fmt.Sprintf("  Workers:   %s\n", tui.ValueStyle.Render(fmt.Sprintf("%d", status.PoolSize))))
+	}
+	// Requests that waited on a max_conns_per_host cap (#1188).
+	if status.ConnQueued > 0 {
+		content.WriteString(fmt.Sprintf("  ConnWait:  %s\n",
+			tui.WarningStyle.Render(fmt.Sprintf("%d requests queued for a connection", status.ConnQueued))))
+	}
+	// kar's own GC pauses overlapping requests (#1196).
+	if g := status.GCImpact; g != nil {
+		render := tui.ValueStyle.Render
+		if g.OverlappedPct >= 1 {
+			render = tui.WarningStyle.Render
+		}
+		content.WriteString(fmt.Sprintf("  GC:        %s\n",
+			render(fmt.Sprintf("%d pauses (%s, max %s), %d requests (%.2f%%) overlapped, ~%s self-inflicted",
+				g.Pauses, lat(g.PauseTotalMs), lat(g.PauseMaxMs), g.Overlapped, g.OverlappedPct, lat(g.SelfInflictedMs)))))
+	}
+	// Warmup requests left out of the percentiles above (#1207).
+	if w := status.Warmup; w != nil {
+		state := "done"
+		if w.Active {
+			state = "in progress"
+		}
+		content.WriteString(fmt.Sprintf("  Warmup:    %s %s\n",
+			tui.ValueStyle.Render(fmt.Sprintf("%d requests excluded (%s)", w.Excluded, state)),
+			tui.DimStyle.Render("p50 "+lat(w.P50Ms)+" p99 "+lat(w.P99Ms))))
+	}
+	// Full vs resumed TLS handshakes (#1257).
+	if t := status.TLS; t != nil {
+		detail := fmt.Sprintf("%.0f%% resumed", float64(t.Resumed)/float64(t.Full+t.Resumed)*100)
+		if t.Warmed > 0 {
+			detail += fmt.Sprintf(", %d hosts warmed up", t.Warmed)
+		}
+		content.WriteString(fmt.Sprintf("  TLS:       %s %s\n",
+			tui.ValueStyle.Render(fmt.Sprintf("%d full, %d resumed handshakes", t.Full, t.Resumed)),
+			tui.DimStyle.Render(detail)))
+	}
+	// Cache hits and misses of cache_bust targets (#1206).
+	for _, c := range status.CacheStats {
+		content.WriteString(fmt.Sprintf("  Cache:     %s %s\n",
+			tui.LabelStyle.Render(c.Target),
+			tui.ValueStyle.Render(fmt.Sprintf("%.1f%% hit (%d/%d), %d varied", c.HitPct, c.Hits, c.Hits+c.Misses, c.Varied))))
+		if c.Hits+c.Misses > 0 {
+			content.WriteString(fmt.Sprintf("             %s\n", tui.DimStyle.Render(fmt.Sprintf(
+				"hit p50 %s p99 %s  miss p50 %s p99 %s", lat(c.HitP50Ms), lat(c.HitP99Ms), lat(c.MissP50Ms), lat(c.MissP99Ms)))))
+		}
+	}
+	// Long-poll targets' held polls (#1218).
+	for _, p := range status.LongPoll {
+		content.WriteString(fmt.Sprintf("  Long poll: %s %s\n",
+			tui.LabelStyle.Render(p.Target),
+			tui.ValueStyle.Render(fmt.Sprintf("%d open, %d polls (%d responded, %d expired, %d errors)", p.Open, p.Polls, p.Responded, p.Expired, p.Errors))))
+		if p.Responded > 0 {
+			content.WriteString(fmt.Sprintf("             %s\n", tui.DimStyle.Render(fmt.Sprintf(
+				"hold p50 %s p95 %s p99 %s", lat(p.HoldP50Ms), lat(p.HoldP95Ms), lat(p.HoldP99Ms)))))
+		}
+	}
+	// kar's own connections per protocol and host (#1210).
+	for _, c := range status.ConnPool {
+		content.WriteString(fmt.Sprintf("  Conns:     %s %s\n",
+			tui.LabelStyle.Render(c.Protocol+" "+c.Host),
+			tui.ValueStyle.Render(fmt.Sprintf("%d open, %d idle, %d in use", c.Open, c.Idle, c.InUse))))
+	}
+	// Streams of http2 targets with an http2 block (#1248).
+	for _, h := range status.HTTP2 {
+		limit := ""
+		if h.MaxStreams > 0 {
+			limit = fmt.Sprintf(" (max %d per conn)", h.MaxStreams)
+		}
+		content.WriteString(fmt.Sprintf("  HTTP/2:    %s %s %s\n",
+			tui.LabelStyle.Render(h.Target),
+			tui.ValueStyle.Render(fmt.Sprintf("%d conns, %d streams%s", h.Connections, h.Streams, limit)),
+			tui.DimStyle.Render(fmt.Sprintf("peak %d/conn, %d waited, %d redials", h.PeakStreams, h.Waited, h.Redials))))
+	}
+	// track_header targets' header, latest value first.
+	for _, h := range status.HeaderTracks {
+		span := fmt.Sprintf("first %s, %d changes in %d samples", h.First, h.Changes, h.Samples)
+		if h.Min != nil {
+			span = fmt.Sprintf("first %s, range %g–%g, %d changes in %d samples", h.First, *h.Min, *h.Max, h.Changes, h.Samples)
+		}
+		content.WriteString(fmt.Sprintf("  Header:    %s %s %s\n",
+			tui.LabelStyle.Render(h.Target),
+			tui.ValueStyle.Render(h.Header+": "+h.Last),
+			tui.DimStyle.Render(span)))
+	}
+	// duplicates targets' check (#1256), flagged once any turned up.
+	for _, d := range status.Duplicates {
+		found := fmt.Sprintf("%d duplicates", d.Duplicates+d.Markers)
+		style := tui.ValueStyle
+		if d.Duplicates+d.Markers > 0 {
+			style = tui.WarningStyle
+		}
+		detail := fmt.Sprintf("%d compared, %d marked", d.Checked, d.Markers)
+		if len(d.Examples) > 0 {
+			detail += ", e.g. " + strings.Join(d.Examples, ", ")
+		}
+		content.WriteString(fmt.Sprintf("  Dupes:     %s %s %s\n",
+			tui.LabelStyle.Render(d.Target), style.Render(found), tui.DimStyle.Render(detail)))
+	}
+	// Latency cliffs safety.cliff caught, the latest shown (#1252).
+	if n := len(status.Cliffs); n > 0 {
+		last := status.Cliffs[n-1]
+		content.WriteString(fmt.Sprintf("  Cliffs:    %s %s\n",
+			tui.WarningStyle.Render(fmt.Sprintf("%d detected", n)),
+			tui.DimStyle.Render(fmt.Sprintf("last %s: p95 %s from %s, %s",
+				last.At.Local().Format("15:04:05"), lat(last.P95Ms), lat(last.BaselineMs), last.Action))))
+	}
 	content.WriteString("\n")
 
 	// Target
@@ -235,7 +530,12 @@ var triggerCmd = &cobra.Command{
 var pauseCmd = &cobra.Command{
 	Use:   "pause",
 	Short: "Pause traffic generation",
-	Long:  `Pause traffic generation without stopping the daemon.`,
+	Long: `Pause traffic generation without stopping the daemon.
+
+Pausing freezes the run rather than resetting it: request counters and
+latency histograms are kept, and the pattern (spike timeline, ramp-up,
+scenario phase) stops where it is. ` + "`kar resume`" + ` or ` + "`kar trigger`" + ` continues
+from the same point.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		resp, err := daemon.SendCommand(daemon.Command{Type: "pause"})
 		if err != nil {
@@ -246,17 +546,21 @@ var pauseCmd = &cobra.Command{
 			fmt.Println()
 			fmt.Println(tui.WarningStyle.Render("  " + tui.TriggerReady + " Traffic paused"))
 			fmt.Println()
+		} else {
+			fmt.Println(tui.ErrorStyle.Render("  " + resp.Message))
 		}
 
 		return nil
 	},
 }
 
-// Resume command — clears an open circuit breaker. Idempotent.
+// Resume command — continues a paused run and clears an open circuit
+// breaker. Idempotent.
 var resumeCmd = &cobra.Command{
 	Use:   "resume",
-	Short: "Resume traffic after a circuit-breaker trip",
-	Long: `Force-clear an open circuit breaker so traffic resumes immediately.
+	Short: "Resume paused traffic or clear a circuit-breaker trip",
+	Long: `Continue a run stopped with ` + "`kar pause`" + `, and force-clear an open circuit
+breaker so traffic resumes immediately.
 
 The circuit breaker (configured under safety.* in the config) auto-pauses
 traffic when error rate or P95 latency stays above thresholds for a sustained
@@ -283,8 +587,196 @@ Idempotent: a no-op when the breaker is already closed or safety is disabled.`,
 	},
 }
 
+// Scale command — resize the worker pool without restarting.
+var scaleCmd = &cobra.Command{
+	Use:   "scale <workers>",
+	Short: "Change the worker pool size at runtime",
+	Long: `Grow or shrink the daemon's worker pool while traffic is flowing.
+
+Use it when the pool is too small to reach the requested TPS. Growing takes
+effect immediately; shrinking lets surplus workers finish their current
+request first. Resizes closer together than worker.min_resize_interval are
+rejected to avoid thrashing.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid worker count %q: must be a positive integer", args[0])
+		}
+		data, _ := json.Marshal(daemon.ScaleRequest{PoolSize: n})
+		resp, err := daemon.SendCommand(daemon.Command{Type: "scale", Data: data})
+		if err != nil {
+			return fmt.Errorf("daemon not running: %w", err)
+		}
+
+		if resp.Success {
+			fmt.Println()
+			fmt.Println(tui.SuccessStyle.Render("  " + resp.Message))
+			fmt.Println()
+		} else {
+			fmt.Println(tui.ErrorStyle.Render("  " + resp.Message))
+		}
+
+		return nil
+	},
+}
+
+// Set-TPS command — pin one target's rate at runtime.
+var setTPSCmd = &cobra.Command{
+	Use:   "set-tps <target> <tps|auto>",
+	Short: "Pin one target's request rate",
+	Long: `Fix one target's requested TPS while the rest keep following the
+pattern. The pool rate becomes the other targets' share plus the pinned
+value, so pinning doesn't starve or flood the others. ` + "`auto`" + ` hands the
+target back to the pattern.
+
+The value is checked against controller.max_tps. Pins apply from the next
+control tick and are not saved; ` + "`kar status`" + ` shows each target's requested
+and achieved TPS, with pinned targets marked. Solo mode only.
+
+Examples:
+  kar set-tps api 250
+  kar set-tps api auto`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req := daemon.SetTPSRequest{Target: args[0]}
+		if args[1] == "auto" {
+			req.Auto = true
+		} else {
+			tps, err := strconv.ParseFloat(args[1], 64)
+			if err != nil || tps < 0 {
+				return fmt.Errorf("invalid TPS %q: must be a non-negative number or \"auto\"", args[1])
+			}
+			req.TPS = tps
+		}
+		data, _ := json.Marshal(req)
+		resp, err := daemon.SendCommand(daemon.Command{Type: "set-tps", Data: data})
+		if err != nil {
+			return fmt.Errorf("daemon not running: %w", err)
+		}
+
+		if resp.Success {
+			fmt.Println()
+			fmt.Println(tui.SuccessStyle.Render("  " + resp.Message))
+			fmt.Println()
+		} else {
+			fmt.Println(tui.ErrorStyle.Render("  " + resp.Message))
+		}
+
+		return nil
+	},
+}
+
+var slowestJSON bool
+
+// Slowest command — the concrete requests behind the tail percentiles.
+var slowestCmd = &cobra.Command{
+	Use:   "slowest",
+	Short: "List the slowest requests of the current run",
+	Long: `Show the slowest individual requests seen so far: target, method, URL,
+status and duration. Percentiles say the tail is bad; these are the
+requests to go and look at.
+
+The number kept is report.slowest (default 10). The same list is written
+to the json and html output sinks when the run ends.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, err := daemon.SendCommand(daemon.Command{Type: "slowest"})
+		if err != nil {
+			return fmt.Errorf("daemon not running: %w", err)
+		}
+		if !resp.Success {
+			fmt.Println(tui.ErrorStyle.Render("  " + resp.Message))
+			return nil
+		}
+
+		if slowestJSON {
+			output, _ := json.MarshalIndent(resp.Data, "", "  ")
+			fmt.Println(string(output))
+			return nil
+		}
+		data, _ := json.Marshal(resp.Data)
+		var slow []worker.SlowRequest
+		json.Unmarshal(data, &slow)
+
+		fmt.Println()
+		if len(slow) == 0 {
+			fmt.Println(tui.DimStyle.Render("  No requests recorded yet"))
+			fmt.Println()
+			return nil
+		}
+		for _, r := range slow {
+			result := r.StatusText()
+			if r.Error != "" {
+				result = tui.ErrorStyle.Render(r.Error)
+			}
+			fmt.Printf("  %s  %s  %s %s %s  %s\n",
+				tui.ValueStyle.Render(fmt.Sprintf("%10s", config.LatencyFormat{}.Format(r.DurationMs))),
+				tui.DimStyle.Render(r.Time.Format("15:04:05")),
+				r.Target, r.Method, r.URL, result)
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+var snapshotOut string
+
+// Snapshot command — the run's result so far, without stopping it.
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Write the current run's results without stopping it",
+	Long: `Capture the run's results so far: the same JSON summary the json output
+sink writes at the end, computed from the live counters and marked
+"partial". Use it to checkpoint a long unattended run.
+
+Without -o the daemon writes to report.snapshot (default
+kar98k-snapshot-{time}.json in its working directory). On Linux and
+macOS, sending the daemon SIGUSR2 does the same.
+
+Examples:
+  kar snapshot
+  kar snapshot -o mid-run.json
+  kar snapshot -o - | jq .latency_p99_raw_ms`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req := daemon.SnapshotRequest{Path: snapshotOut}
+		if req.Path != "" && req.Path != "-" {
+			// The daemon has its own working directory.
+			abs, err := filepath.Abs(req.Path)
+			if err != nil {
+				return err
+			}
+			req.Path = abs
+		}
+		data, _ := json.Marshal(req)
+		resp, err := daemon.SendCommand(daemon.Command{Type: "snapshot", Data: data})
+		if err != nil {
+			return fmt.Errorf("daemon not running: %w", err)
+		}
+		if !resp.Success {
+			fmt.Println(tui.ErrorStyle.Render("  " + resp.Message))
+			return nil
+		}
+
+		if req.Path == "-" {
+			output, _ := json.MarshalIndent(resp.Data, "", "  ")
+			fmt.Println(string(output))
+			return nil
+		}
+		fmt.Println()
+		fmt.Println(tui.SuccessStyle.Render("  " + resp.Message))
+		fmt.Println()
+		return nil
+	},
+}
+
 func init() {
+	slowestCmd.Flags().BoolVar(&slowestJSON, "json", false, "Output as JSON")
+	snapshotCmd.Flags().StringVarP(&snapshotOut, "output", "o", "", "Write the snapshot here instead of report.snapshot (\"-\" for stdout)")
 	rootCmd.AddCommand(triggerCmd)
 	rootCmd.AddCommand(pauseCmd)
 	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(scaleCmd)
+	rootCmd.AddCommand(setTPSCmd)
+	rootCmd.AddCommand(slowestCmd)
+	rootCmd.AddCommand(snapshotCmd)
 }
@@ -2,14 +2,15 @@ package cli
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/kar98k/internal/daemon"
 	"github.com/kar98k/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -27,33 +28,23 @@ func init() {
 }
 
 func runStop(cmd *cobra.Command, args []string) error {
-	pidPath := filepath.Join(os.TempDir(), "kar98k", "kar98k.pid")
-	logPath := filepath.Join(os.TempDir(), "kar98k", "kar98k.log")
+	logPath := daemon.GetLogPath()
 
-	// Read PID file
-	pidData, err := os.ReadFile(pidPath)
-	if err != nil {
+	// RunningPid also clears a PID file and socket left behind by a
+	// crashed run, so stopping twice, or after a crash, is a no-op.
+	pid, running := daemon.RunningPid()
+	if !running {
 		fmt.Println()
 		fmt.Println(tui.WarningStyle.Render("  kar is not running"))
 		fmt.Println()
 		return nil
 	}
 
-	pid, err := strconv.Atoi(strings.TrimSpace(string(pidData)))
-	if err != nil {
-		fmt.Println()
-		fmt.Println(tui.ErrorStyle.Render("  Invalid PID file"))
-		fmt.Println()
-		return nil
-	}
-
-	// Check if process exists
 	process, err := os.FindProcess(pid)
 	if err != nil {
 		fmt.Println()
 		fmt.Println(tui.WarningStyle.Render("  kar is not running"))
 		fmt.Println()
-		os.Remove(pidPath)
 		return nil
 	}
 
@@ -61,9 +52,14 @@ func runStop(cmd *cobra.Command, args []string) error {
 	fmt.Println(tui.InfoStyle.Render("  Stopping kar (PID: " + strconv.Itoa(pid) + ")..."))
 
 	// Send SIGTERM
-	if err := process.Signal(syscall.SIGTERM); err != nil {
-		// Process already finished, clean up PID file
-		os.Remove(pidPath)
+	err = process.Signal(syscall.SIGTERM)
+	if errors.Is(err, syscall.EPERM) {
+		// RunningPid keeps another user's daemon; it isn't ours to stop.
+		return fmt.Errorf("kar (PID %d) belongs to another user; stop it as that user", pid)
+	}
+	if err != nil {
+		// Process already finished, clean up its PID file and socket
+		daemon.RunningPid()
 		fmt.Println(tui.SuccessStyle.Render("  " + tui.CheckMark + " kar stopped (was already finished)"))
 		fmt.Println()
 		showLastSummary(logPath)
@@ -71,12 +67,19 @@ func runStop(cmd *cobra.Command, args []string) error {
 	}
 
 	// Wait for process to exit (max 5 seconds)
+	stopped := false
 	for i := 0; i < 50; i++ {
 		time.Sleep(100 * time.Millisecond)
-		if _, err := os.Stat(pidPath); os.IsNotExist(err) {
+		if _, running := daemon.RunningPid(); !running {
+			stopped = true
 			break
 		}
 	}
+	if !stopped {
+		fmt.Println(tui.WarningStyle.Render("  kar is still draining; run 'kar stop' again to check"))
+		fmt.Println()
+		return nil
+	}
 
 	fmt.Println(tui.SuccessStyle.Render("  " + tui.CheckMark + " kar stopped"))
 	fmt.Println()
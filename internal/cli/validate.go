@@ -64,8 +64,15 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		return reportStructural(path, err)
 	}
 
+	// Expand pattern presets as Load does, so the checks see the
+	// generators they produce. A bad preset is reported below.
+	_ = cfg.ApplyPresets()
+
 	issues := config.ValidateConfig(cfg)
 	if !validateNoReach {
+		// Probe with secret headers filled in; a secret that doesn't
+		// resolve is already an issue above.
+		_ = config.ResolveSecrets(cfg)
 		ctx := context.Background()
 		issues = append(issues, config.CheckReachability(ctx, cfg, validateTimeout)...)
 	}
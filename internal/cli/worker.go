@@ -9,6 +9,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/kar98k/internal/config"
 	"github.com/kar98k/internal/daemon"
 	"github.com/kar98k/internal/rpc"
 	"github.com/spf13/cobra"
@@ -24,6 +25,7 @@ var (
 	workerTLSServerName    string
 	workerAuthToken        string
 	workerAuthTokenEnv     string
+	workerAuthTokenFile    string
 	workerReconnectBackoff time.Duration
 	workerReconnectMax     int
 )
@@ -54,6 +56,7 @@ func init() {
 	workerCmd.Flags().StringVar(&workerTLSServerName, "tls-server-name", "", "TLS server name override")
 	workerCmd.Flags().StringVar(&workerAuthToken, "auth-token", "", "Bearer token to present to master (prefer --auth-token-env)")
 	workerCmd.Flags().StringVar(&workerAuthTokenEnv, "auth-token-env", "KAR_AUTH_TOKEN", "Env var name to read auth token from (takes precedence over --auth-token)")
+	workerCmd.Flags().StringVar(&workerAuthTokenFile, "auth-token-file", "", "File to read auth token from, e.g. a mounted secret (takes precedence over --auth-token-env)")
 	workerCmd.Flags().DurationVar(&workerReconnectBackoff, "reconnect-max-backoff", 30*time.Second, "Maximum backoff between reconnect attempts")
 	workerCmd.Flags().IntVar(&workerReconnectMax, "reconnect-max-attempts", 0, "Max consecutive failed reconnects before exit (0=unlimited)")
 	_ = workerCmd.MarkFlagRequired("master")
@@ -99,13 +102,20 @@ func runWorker(cmd *cobra.Command, args []string) error {
 // buildClientOptions constructs a ClientOptions from CLI flags.
 // Returns zero-value options (plaintext, no auth) when no TLS/auth flags are set.
 func buildClientOptions() (rpc.ClientOptions, error) {
-	// Resolve auth token: env var takes precedence over raw flag.
+	// Resolve auth token: file, then env var, then raw flag.
 	token := workerAuthToken
 	if workerAuthTokenEnv != "" {
 		if v := os.Getenv(workerAuthTokenEnv); v != "" {
 			token = v
 		}
 	}
+	if workerAuthTokenFile != "" {
+		v, err := config.SecretRef{File: workerAuthTokenFile}.Resolve()
+		if err != nil {
+			return rpc.ClientOptions{}, fmt.Errorf("--auth-token-file: %w", err)
+		}
+		token = v
+	}
 
 	// Validate --tls-cert and --tls-client-key are both-or-neither.
 	if (workerTLSCert != "") != (workerTLSClientKey != "") {
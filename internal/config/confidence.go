@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultMinSamples is used when Report.MinSamples is unset: P50 needs
+// 10 requests, P95 100 and P99 500 before they count as reliable.
+const DefaultMinSamples = 5
+
+// Confidence grades a percentile by how many samples back it (#1240).
+type Confidence int
+
+const (
+	// ConfidenceOK has at least MinSamples samples beyond it.
+	ConfidenceOK Confidence = iota
+	// ConfidenceLow has some, but fewer than MinSamples: reported
+	// with a warning.
+	ConfidenceLow
+	// ConfidenceNone has not one sample beyond it, so it is just the
+	// slowest request seen: left out of reports.
+	ConfidenceNone
+)
+
+// TailSamples returns MinSamples with its default applied; -1 means
+// the check is off.
+func (r Report) TailSamples() int {
+	switch {
+	case r.MinSamples < 0:
+		return -1
+	case r.MinSamples == 0:
+		return DefaultMinSamples
+	}
+	return r.MinSamples
+}
+
+// SamplesFor is how many samples in total percentile p (0–100) needs
+// to be reported with confidence; 0 when the check is off.
+func (r Report) SamplesFor(p float64) int64 {
+	tail := r.TailSamples()
+	if tail < 0 || p >= 100 {
+		return 0
+	}
+	return int64(math.Ceil(float64(tail) * 100 / (100 - p)))
+}
+
+// PercentileConfidence grades percentile p (0–100) computed from n
+// samples. The n·(1−p) samples beyond it are what pin it down: P99
+// from 50 samples has half a sample there, so it is noise, while P50
+// from the same 50 has 25.
+func (r Report) PercentileConfidence(p float64, n int64) Confidence {
+	if r.TailSamples() < 0 || p >= 100 {
+		return ConfidenceOK
+	}
+	tail := float64(n) * (100 - p) / 100
+	switch {
+	case tail < 1:
+		return ConfidenceNone
+	case tail < float64(r.TailSamples()):
+		return ConfidenceLow
+	}
+	return ConfidenceOK
+}
+
+// PercentileNote records a percentile reported with less confidence
+// than asked for.
+type PercentileNote struct {
+	Percentile string `json:"percentile"`
+	Samples    int64  `json:"samples"`
+	Needed     int64  `json:"needed"`
+	// Omitted is set when the percentile had no sample beyond it and
+	// was left out of the printed report.
+	Omitted bool `json:"omitted,omitempty"`
+}
+
+// PercentileNotes grades each of percentiles (0–100) computed from n
+// samples and returns a note for every one short of confidence.
+func (r Report) PercentileNotes(n int64, percentiles ...float64) []PercentileNote {
+	var out []PercentileNote
+	for _, p := range percentiles {
+		c := r.PercentileConfidence(p, n)
+		if c == ConfidenceOK {
+			continue
+		}
+		out = append(out, PercentileNote{
+			Percentile: fmt.Sprintf("p%g", p),
+			Samples:    n,
+			Needed:     r.SamplesFor(p),
+			Omitted:    c == ConfidenceNone,
+		})
+	}
+	return out
+}
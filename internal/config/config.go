@@ -1,6 +1,17 @@
 package config
 
-import "time"
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
 
 // Config is the root configuration structure.
 type Config struct {
@@ -18,8 +29,533 @@ type Config struct {
 	// advances through on a wall-clock timeline. When empty, the
 	// controller uses the single Pattern block above as before.
 	Scenarios []Scenario `yaml:"scenarios,omitempty"`
+	// IntentCheck compares the traffic actually generated against the
+	// configured pattern when the run ends (#1186).
+	IntentCheck IntentCheck `yaml:"intent_check,omitempty"`
+	// Output lists the sinks a finished run writes its results to
+	// (#1187). Every sink receives the same result.
+	Output []OutputSink `yaml:"output,omitempty"`
+	// Report shapes the end-of-run report the output sinks write
+	// (#1189).
+	Report Report `yaml:"report,omitempty"`
+	// Hooks points at an optional Starlark script run around every
+	// request (#1200).
+	Hooks Hooks `yaml:"hooks,omitempty"`
+	// PostChecks are HTTP assertions run once after the load (#1241).
+	PostChecks []PostCheck `yaml:"post_checks,omitempty"`
+	// Control chooses the transport of the daemon's control channel,
+	// which `kar status`, `kar stop` and the other commands use
+	// (#1242).
+	Control Control `yaml:"control,omitempty"`
+}
+
+// Control configures the daemon's control channel. The filesystem
+// socket in the runtime directory is the default; an abstract socket
+// (Linux) leaves no file to go stale or need permissions, and a
+// loopback TCP port works where neither fits, e.g. a container whose
+// runtime directory isn't shared with the CLI.
+type Control struct {
+	// Transport is ControlUnix, ControlAbstract or ControlTCP.
+	// Default ControlUnix.
+	Transport string `yaml:"transport,omitempty"`
+	// Address is the TCP listen address. It must be a loopback
+	// address: the channel has no authentication. Default
+	// DefaultControlAddress.
+	Address string `yaml:"address,omitempty"`
+}
+
+// Control transports.
+const (
+	ControlUnix     = "unix"
+	ControlAbstract = "abstract"
+	ControlTCP      = "tcp"
+)
+
+// DefaultControlAddress is the TCP control address when
+// Control.Address is unset.
+const DefaultControlAddress = "127.0.0.1:7787"
+
+// Kind returns Transport with its default applied.
+func (c Control) Kind() string {
+	if c.Transport == "" {
+		return ControlUnix
+	}
+	return c.Transport
+}
+
+// TCPAddress returns Address with its default applied.
+func (c Control) TCPAddress() string {
+	if c.Address == "" {
+		return DefaultControlAddress
+	}
+	return c.Address
+}
+
+// Hooks configures the scripting hooks. The script may define
+// pre_request(req), which can rewrite the outgoing request, and
+// post_response(req, resp), which can override whether it succeeded.
+// Both run on the worker's hot path for every request.
+type Hooks struct {
+	Script string `yaml:"script,omitempty"`
+	// Secrets are exposed to the script as the read-only `secrets`
+	// dict, e.g. an HMAC signing key, so it never has to be written
+	// into the script (#1204).
+	Secrets map[string]SecretRef `yaml:"secrets,omitempty"`
+
+	// ResolvedSecrets holds the values of Secrets once ResolveSecrets
+	// has read them. Runtime only.
+	ResolvedSecrets map[string]string `yaml:"-"`
+}
+
+// Report configures the end-of-run report. Long runs are split into
+// fixed time windows, each with its own percentiles and error rate, so
+// warming, leaks and degradation show up instead of being averaged
+// into one figure.
+type Report struct {
+	// SegmentWindow is the width of each report segment. Default 10m.
+	SegmentWindow time.Duration `yaml:"segment_window,omitempty"`
+	// SLO flags segments that breached any of its thresholds.
+	SLO SLO `yaml:"slo,omitempty"`
+	// Slowest is how many of the slowest individual requests the
+	// report lists (#1191). Default 10.
+	Slowest int `yaml:"slowest,omitempty"`
+	// GCImpact tracks kar's own GC pauses and flags requests that were
+	// in flight during one, so a latency spike caused by the load
+	// generator isn't blamed on the target (#1196).
+	GCImpact bool `yaml:"gc_impact,omitempty"`
+	// Interval is the timeline granularity (#1199): the report's time
+	// slots, in the TUI and the html sink, and the jsonl sink's
+	// timeline rows. Zero keeps each surface's default, 5s in the
+	// report and 1s in the timeline.
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// Regression sets how far a run may fall behind `kar run
+	// --baseline` before --regression-gate fails it (#1203).
+	Regression Regression `yaml:"regression,omitempty"`
+	// Snapshot is where SIGUSR2 and `kar snapshot` write the run's
+	// result so far (#1205). "{time}" is replaced by the snapshot's
+	// timestamp so repeated snapshots don't overwrite each other; "-"
+	// writes to the daemon's stdout. Default DefaultSnapshotPath.
+	Snapshot string `yaml:"snapshot,omitempty"`
+	// Latency sets the unit and precision latencies are printed with
+	// (#1213).
+	Latency LatencyFormat `yaml:"latency,omitempty"`
+	// Retention prunes old timestamped artifacts when the daemon
+	// starts and on `kar clean` (#1224).
+	Retention Retention `yaml:"retention,omitempty"`
+	// ErrorMatrix sets the columns of the report's per-target error
+	// matrix (#1229): ErrorMatrixClass folds HTTP statuses into 4xx
+	// and 5xx, ErrorMatrixStatus keeps every code. gRPC codes and
+	// transport errors are listed by name either way. Default class.
+	ErrorMatrix string `yaml:"error_matrix,omitempty"`
+	// Tags are free-form labels recorded in the run's metadata (#1236),
+	// e.g. the git SHA or build of the target under test. `kar run
+	// --tag` adds to them.
+	Tags map[string]string `yaml:"tags,omitempty"`
+	// MinSamples is how many samples must lie beyond a percentile for
+	// the report to trust it (#1240), so the bar rises with the
+	// percentile: see PercentileConfidence. 0 uses DefaultMinSamples;
+	// -1 reports every percentile as is.
+	MinSamples int `yaml:"min_samples,omitempty"`
+	// SizeLatency breaks each target's latency down by request or
+	// response size (#1258), to show whether bigger payloads are
+	// slower. Off by default.
+	SizeLatency SizeLatency `yaml:"size_latency,omitempty"`
+}
+
+// SizeLatency configures the report's size/latency analysis: each
+// target's successful requests are bucketed by size, with latency
+// percentiles per bucket and the correlation between size and latency.
+type SizeLatency struct {
+	Enabled bool `yaml:"enabled"`
+	// By is the size measured: SizeByResponse (default), SizeByRequest
+	// or SizeByTotal.
+	By string `yaml:"by,omitempty"`
+	// Buckets are the buckets' upper bounds in ascending order, as
+	// ParseSize reads them; larger requests fall in one more bucket
+	// above the last. Default DefaultSizeBuckets.
+	Buckets []string `yaml:"buckets,omitempty"`
+}
+
+// Sizes SizeLatency.By can measure.
+const (
+	SizeByResponse = "response" // bytes read
+	SizeByRequest  = "request"  // bytes written
+	SizeByTotal    = "total"    // both
+)
+
+// DefaultSizeBuckets are the size buckets' upper bounds when
+// report.size_latency sets none.
+var DefaultSizeBuckets = []string{"1KB", "16KB", "256KB", "4MB"}
+
+// Measure returns By with its default filled in.
+func (s SizeLatency) Measure() string {
+	if s.By == "" {
+		return SizeByResponse
+	}
+	return s.By
+}
+
+// Bounds parses Buckets, or DefaultSizeBuckets, into byte counts with
+// their labels. Bounds must be ascending.
+func (s SizeLatency) Bounds() ([]int64, []string, error) {
+	labels := s.Buckets
+	if len(labels) == 0 {
+		labels = DefaultSizeBuckets
+	}
+	bounds := make([]int64, len(labels))
+	for i, l := range labels {
+		n, err := ParseSize(l)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i > 0 && n <= bounds[i-1] {
+			return nil, nil, fmt.Errorf("bucket %s is not above %s", l, labels[i-1])
+		}
+		bounds[i] = n
+	}
+	return bounds, labels, nil
+}
+
+// Error matrix groupings.
+const (
+	ErrorMatrixClass  = "class"
+	ErrorMatrixStatus = "status"
+)
+
+// Retention bounds how many timestamped artifacts (snapshots and
+// output files whose path has "{time}") are kept, per path. Files
+// without a {time} stamp in their name are never touched.
+type Retention struct {
+	// KeepLast keeps the newest N files per path. 0 uses
+	// DefaultKeepLast; -1 keeps all.
+	KeepLast int `yaml:"keep_last,omitempty"`
+	// MaxAge also removes files older than this, however few are
+	// left. 0 = no age limit.
+	MaxAge time.Duration `yaml:"max_age,omitempty"`
+}
+
+// DefaultKeepLast is used when Retention.KeepLast is unset: enough
+// history for a few days of CI without letting the disk fill.
+const DefaultKeepLast = 50
+
+// Keep returns KeepLast with its default applied; 0 means unlimited.
+func (r Retention) Keep() int {
+	switch {
+	case r.KeepLast < 0:
+		return 0
+	case r.KeepLast == 0:
+		return DefaultKeepLast
+	}
+	return r.KeepLast
+}
+
+// Latency display units. Latency is kept in milliseconds everywhere;
+// the unit only changes how it is printed.
+const (
+	LatencyUnitAuto    = "auto" // µs below 1ms, s from 1000ms, ms between
+	LatencyUnitMicros  = "us"
+	LatencyUnitMillis  = "ms"
+	LatencyUnitSeconds = "s"
+)
+
+// DefaultLatencyPrecision is the number of decimals printed when
+// LatencyFormat.Precision is unset.
+const DefaultLatencyPrecision = 2
+
+// LatencyFormat prints millisecond latencies in a display unit, so a
+// 50µs cache read doesn't show as "0.05ms" and a 12s batch call not as
+// "12000.00ms". The zero value auto-scales with two decimals.
+type LatencyFormat struct {
+	Unit      string `yaml:"unit,omitempty" json:"unit,omitempty"`
+	Precision *int   `yaml:"precision,omitempty" json:"precision,omitempty"`
 }
 
+// ValidLatencyUnit reports whether unit is one LatencyFormat knows;
+// empty means auto.
+func ValidLatencyUnit(unit string) bool {
+	switch unit {
+	case "", LatencyUnitAuto, LatencyUnitMicros, LatencyUnitMillis, LatencyUnitSeconds:
+		return true
+	}
+	return false
+}
+
+// Format prints ms in f's unit, e.g. "50.00µs", "12.35ms", "1.20s".
+func (f LatencyFormat) Format(ms float64) string {
+	unit := f.Unit
+	if unit == "" || unit == LatencyUnitAuto {
+		switch a := math.Abs(ms); {
+		case a > 0 && a < 1:
+			unit = LatencyUnitMicros
+		case a >= 1000:
+			unit = LatencyUnitSeconds
+		default:
+			unit = LatencyUnitMillis
+		}
+	}
+	v, suffix := ms, "ms"
+	switch unit {
+	case LatencyUnitMicros:
+		v, suffix = ms*1000, "µs"
+	case LatencyUnitSeconds:
+		v, suffix = ms/1000, "s"
+	}
+	prec := DefaultLatencyPrecision
+	if f.Precision != nil {
+		prec = *f.Precision
+	}
+	return strconv.FormatFloat(v, 'f', prec, 64) + suffix
+}
+
+// DefaultSnapshotPath is used when Report.Snapshot is unset. Relative
+// paths are relative to the daemon's working directory.
+const DefaultSnapshotPath = "kar98k-snapshot-{time}.json"
+
+// TimeLayout is the format "{time}" expands to in artifact paths.
+const TimeLayout = "20060102-150405"
+
+// ExpandTime replaces "{time}" in path with t.
+func ExpandTime(path string, t time.Time) string {
+	return strings.ReplaceAll(path, "{time}", t.Format(TimeLayout))
+}
+
+// SnapshotPath returns the snapshot destination for a snapshot taken
+// at t, with {time} expanded.
+func (r Report) SnapshotPath(t time.Time) string {
+	p := r.Snapshot
+	if p == "" {
+		p = DefaultSnapshotPath
+	}
+	return ExpandTime(p, t)
+}
+
+// ArtifactPatterns returns the paths with a "{time}" stamp kar writes
+// run artifacts to: snapshots and output sinks. report.retention
+// prunes files matching them.
+func (c *Config) ArtifactPatterns() []string {
+	var out []string
+	snap := c.Report.Snapshot
+	if snap == "" {
+		snap = DefaultSnapshotPath
+	}
+	if strings.Contains(snap, "{time}") {
+		out = append(out, snap)
+	}
+	for _, o := range c.Output {
+		if strings.Contains(o.Path, "{time}") && !slices.Contains(out, o.Path) {
+			out = append(out, o.Path)
+		}
+	}
+	return out
+}
+
+// Regression holds the tolerances of the baseline regression gate.
+// Zero fields use the defaults below.
+type Regression struct {
+	// Latency is the allowed relative increase of any latency figure
+	// (0.1 = 10%).
+	Latency float64 `yaml:"latency,omitempty"`
+	// LatencyFloor ignores latency increases smaller than this, so a
+	// 0.4ms → 0.5ms P50 isn't a 25% regression.
+	LatencyFloor time.Duration `yaml:"latency_floor,omitempty"`
+	// ErrorRate is the allowed increase in percentage points.
+	ErrorRate float64 `yaml:"error_rate,omitempty"`
+	// TPS is the allowed relative drop of achieved TPS.
+	TPS float64 `yaml:"tps,omitempty"`
+}
+
+// Regression gate defaults.
+const (
+	DefaultRegressionLatency      = 0.10
+	DefaultRegressionLatencyFloor = time.Millisecond
+	DefaultRegressionErrorRate    = 1.0
+	DefaultRegressionTPS          = 0.10
+)
+
+// WithDefaults fills unset tolerances.
+func (r Regression) WithDefaults() Regression {
+	if r.Latency <= 0 {
+		r.Latency = DefaultRegressionLatency
+	}
+	if r.LatencyFloor <= 0 {
+		r.LatencyFloor = DefaultRegressionLatencyFloor
+	}
+	if r.ErrorRate <= 0 {
+		r.ErrorRate = DefaultRegressionErrorRate
+	}
+	if r.TPS <= 0 {
+		r.TPS = DefaultRegressionTPS
+	}
+	return r
+}
+
+// SLO holds report thresholds. Zero fields are not checked.
+type SLO struct {
+	P95Latency time.Duration `yaml:"p95_latency,omitempty"`
+	P99Latency time.Duration `yaml:"p99_latency,omitempty"`
+	ErrorRate  float64       `yaml:"error_rate,omitempty"` // % (0..100)
+}
+
+// DefaultSegmentWindow is used when Report.SegmentWindow is unset.
+const DefaultSegmentWindow = 10 * time.Minute
+
+// MinReportInterval is the finest timeline granularity accepted.
+const MinReportInterval = 100 * time.Millisecond
+
+// DefaultSlowest is used when Report.Slowest is unset.
+const DefaultSlowest = 10
+
+// SlowestN returns Slowest, or DefaultSlowest when unset.
+func (r Report) SlowestN() int {
+	if r.Slowest <= 0 {
+		return DefaultSlowest
+	}
+	return r.Slowest
+}
+
+// Window returns SegmentWindow, or DefaultSegmentWindow when unset.
+func (r Report) Window() time.Duration {
+	if r.SegmentWindow <= 0 {
+		return DefaultSegmentWindow
+	}
+	return r.SegmentWindow
+}
+
+// DefaultSlotInterval is the report's time slot width when
+// Report.Interval is unset.
+const DefaultSlotInterval = 5 * time.Second
+
+// SlotInterval returns Interval, or DefaultSlotInterval when unset.
+func (r Report) SlotInterval() time.Duration {
+	if r.Interval <= 0 {
+		return DefaultSlotInterval
+	}
+	return r.Interval
+}
+
+// Breaches lists which thresholds a window's figures exceed, e.g.
+// ["p99"]. Latencies are in milliseconds, errorRate a percentage.
+func (s SLO) Breaches(p95Ms, p99Ms, errorRate float64) []string {
+	var out []string
+	if s.P95Latency > 0 && p95Ms > float64(s.P95Latency)/float64(time.Millisecond) {
+		out = append(out, "p95")
+	}
+	if s.P99Latency > 0 && p99Ms > float64(s.P99Latency)/float64(time.Millisecond) {
+		out = append(out, "p99")
+	}
+	if s.ErrorRate > 0 && errorRate > s.ErrorRate {
+		out = append(out, "error_rate")
+	}
+	return out
+}
+
+// OutputSink is one destination for a finished run's results.
+type OutputSink struct {
+	// Type is one of OutputTypes.
+	Type string `yaml:"type"`
+	// Path is the file to write. Required for every type except
+	// prometheus, which accepts Endpoint instead. "{time}" is replaced
+	// by the run's start time, so every run keeps its own file and
+	// report.retention can prune old ones (#1224).
+	Path string `yaml:"path,omitempty"`
+	// Endpoint is a Prometheus Pushgateway URL the final metrics are
+	// pushed to (prometheus only).
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// MaxSize rolls the jsonl trace over to Path.1, Path.2, ... once a
+	// file reaches this size on disk, e.g. "100MB" (#1245). A Path
+	// ending in ".gz" is gzip-compressed as it's written, and the size
+	// counts compressed bytes. Empty keeps a single file. The samples
+	// sink rolls over the same way.
+	MaxSize string `yaml:"max_size,omitempty"`
+	// SampleRate is the fraction of requests the samples sink keeps
+	// (#1260), e.g. 0.1 for one in ten. 0 keeps every request.
+	SampleRate float64 `yaml:"sample_rate,omitempty"`
+	// MaxSamples caps the samples held for the sink; past it, a
+	// reservoir keeps a uniform subset of the whole run. 0 uses
+	// DefaultMaxSamples.
+	MaxSamples int `yaml:"max_samples,omitempty"`
+}
+
+// DefaultMaxSamples is the samples sink's cap when max_samples is
+// unset: a million requests, about 100MB held in memory.
+const DefaultMaxSamples = 1_000_000
+
+// SampleLimits returns SampleRate and MaxSamples with their defaults
+// filled in.
+func (o OutputSink) SampleLimits() (rate float64, maxSamples int) {
+	rate, maxSamples = o.SampleRate, o.MaxSamples
+	if rate == 0 {
+		rate = 1
+	}
+	if maxSamples == 0 {
+		maxSamples = DefaultMaxSamples
+	}
+	return rate, maxSamples
+}
+
+// MaxBytes parses MaxSize; 0 means no limit.
+func (o OutputSink) MaxBytes() (int64, error) {
+	if o.MaxSize == "" {
+		return 0, nil
+	}
+	return ParseSize(o.MaxSize)
+}
+
+// sizeUnits are the suffixes ParseSize accepts, longest first so "MB"
+// isn't read as "B". Units are binary: 1KB is 1024 bytes.
+var sizeUnits = []struct {
+	suffix string
+	mult   int64
+}{
+	{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+}
+
+// ParseSize parses a byte size such as "100MB", "512K" or "4096".
+func ParseSize(s string) (int64, error) {
+	num, mult := strings.ToUpper(strings.TrimSpace(s)), int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(num, u.suffix) {
+			num, mult = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q: want a positive number with an optional KB, MB or GB suffix", s)
+	}
+	return n * mult, nil
+}
+
+// Output sink types.
+const (
+	OutputJSON       = "json"       // machine-readable run summary
+	OutputHTML       = "html"       // human report
+	OutputPrometheus = "prometheus" // final metric values, text exposition format
+	OutputJSONL      = "jsonl"      // per-second timeline, one JSON object per line
+	OutputSamples    = "samples"    // raw per-request latencies, CSV
+)
+
+// OutputTypes lists every supported OutputSink.Type.
+var OutputTypes = []string{OutputJSON, OutputHTML, OutputPrometheus, OutputJSONL, OutputSamples}
+
+// IntentCheck configures the post-run self-check that the generated
+// load matched the configured pattern: spike frequency, spike height
+// and achieved TPS. Deviations are logged at shutdown and printed by
+// `kar run`.
+type IntentCheck struct {
+	Enabled bool `yaml:"enabled"`
+	// Tolerance is the relative deviation allowed before a check is
+	// reported, e.g. 0.2 = 20%. Default 0.2.
+	Tolerance float64 `yaml:"tolerance,omitempty"`
+	// FailOnDeviation makes `kar run` exit non-zero when any check
+	// deviates, for CI pipelines.
+	FailOnDeviation bool `yaml:"fail_on_deviation,omitempty"`
+}
+
+// DefaultIntentTolerance is used when IntentCheck.Tolerance is unset.
+const DefaultIntentTolerance = 0.2
+
 // Master configures the gRPC listen address for distributed master mode.
 // Only used when kar is started with `kar master`.
 type Master struct {
@@ -27,6 +563,12 @@ type Master struct {
 	TLS       *TLSConfig `yaml:"tls,omitempty"`        // nil = plaintext (default)
 	AuthToken string     `yaml:"auth_token,omitempty"` // bearer token; empty = no auth
 	HA        *HAConfig  `yaml:"ha,omitempty"`         // nil = HA disabled (default — k8s/systemd restart is the floor)
+
+	// AuthTokenFile and AuthTokenEnv read the bearer token from a file
+	// or an environment variable at load time instead of keeping it in
+	// the config (#1204). At most one of them and AuthToken is set.
+	AuthTokenFile string `yaml:"auth_token_file,omitempty"`
+	AuthTokenEnv  string `yaml:"auth_token_env,omitempty"`
 }
 
 // HAConfig opts a master into Phase-1 HA. Default backend "memory" is
@@ -61,6 +603,9 @@ type TLSConfig struct {
 // When Inject is non-empty, base_tps is ignored — the inject curve
 // drives the per-tick TPS within this phase. The total of all inject
 // step durations must equal the phase duration.
+//
+// SLO, when set, is asserted against the phase's own requests: the
+// report carries a pass/fail verdict per phase (#1262).
 type Scenario struct {
 	Name     string        `yaml:"name"`
 	Duration time.Duration `yaml:"duration"`
@@ -68,6 +613,7 @@ type Scenario struct {
 	MaxTPS   float64       `yaml:"max_tps,omitempty"`
 	Pattern  *Pattern      `yaml:"pattern,omitempty"`
 	Inject   []InjectStep  `yaml:"inject,omitempty"`
+	SLO      *SLO          `yaml:"slo,omitempty"`
 }
 
 // InjectStepType is the discriminator for the inject DSL. Each value
@@ -117,17 +663,516 @@ type Target struct {
 	Body     string            `yaml:"body,omitempty"`
 	Weight   int               `yaml:"weight"`
 	Timeout  time.Duration     `yaml:"timeout"`
+
+	// ConnectTimeout bounds establishing a connection (DNS and TCP
+	// connect) apart from Timeout, the request timeout, which covers
+	// the whole request including the connect (#1225). A short one fails
+	// fast on an unreachable target while a slow one still gets
+	// Timeout to answer; the two are counted apart as connect and
+	// response timeouts. 0 = only Timeout applies. HTTP only.
+	ConnectTimeout time.Duration `yaml:"connect_timeout,omitempty"`
+
+	// SecretHeaders are headers whose values are read from a file or
+	// environment variable at load time, such as an Authorization
+	// token (#1204). They are merged over Headers.
+	SecretHeaders map[string]SecretRef `yaml:"secret_headers,omitempty"`
+
+	// PropagateDeadline tells the target how long kar will wait, so a
+	// server doing deadline-based load shedding can drop work it
+	// cannot finish in time (#1176). gRPC sends it as grpc-timeout;
+	// HTTP sends DeadlineHeader with the timeout in milliseconds.
+	PropagateDeadline bool   `yaml:"propagate_deadline,omitempty"`
+	DeadlineHeader    string `yaml:"deadline_header,omitempty"` // HTTP only; default "X-Request-Timeout-Ms"
+
+	// TimeoutJitter spreads each request's timeout uniformly over
+	// Timeout×(1±TimeoutJitter), 0–1 (#1243), the way a fleet of real
+	// clients never shares one deadline. With a fixed timeout, the
+	// target's deadline-based shedding fires for every request at the
+	// same moment. The propagated deadline is the jittered one. 0 =
+	// every request gets Timeout.
+	TimeoutJitter float64 `yaml:"timeout_jitter,omitempty"`
+
+	// SuccessCodes lists the status codes counted as success. Empty
+	// keeps the built-in rule (HTTP 2xx/3xx). Requests, when set, turns
+	// the target into a weighted mix of operations, each with its own
+	// method, path and success codes (#1182).
+	SuccessCodes []int         `yaml:"success_codes,omitempty"`
+	Requests     []RequestSpec `yaml:"requests,omitempty"`
+
+	// Methods varies just the method of requests to URL by weight,
+	// e.g. a 9:1 GET/POST mix, each method optionally with its own
+	// body (#1239). It is shorthand for Requests with one spec per
+	// method, named after it, so results and the spec metric label
+	// split by method. Ignored when Requests is set.
+	Methods []WeightedMethod `yaml:"methods,omitempty"`
+
+	// Streaming endpoints (#1185). RecordTTFB measures time to the
+	// first response byte; FirstByteOnly also stops reading there so a
+	// long-lived SSE/chunked stream doesn't hold a worker. HTTP only.
+	RecordTTFB    bool `yaml:"record_ttfb,omitempty"`
+	FirstByteOnly bool `yaml:"first_byte_only,omitempty"`
+
+	// MaxConnsPerHost caps concurrent connections (dialing, active and
+	// idle) to this target's host, like a real client's connection
+	// pool. Requests beyond it wait for a free connection; that wait is
+	// recorded as connection queue wait. 0 = unlimited. HTTP/1.1 only
+	// (#1188).
+	MaxConnsPerHost int `yaml:"max_conns_per_host,omitempty"`
+
+	// HTTP2 tunes an http2 target's connections and streams (#1248).
+	// Unset, every request shares one connection per host, up to the
+	// server's SETTINGS_MAX_CONCURRENT_STREAMS.
+	HTTP2 HTTP2Settings `yaml:"http2,omitempty"`
+
+	// Pipeline writes up to this many requests on one HTTP/1.1
+	// connection before reading their responses (#1230), to stress
+	// servers and proxies that claim pipelining support. 0 or 1 sends
+	// one request at a time as usual. Responses arrive in order, so a
+	// slow one delays those behind it, and a request that times out
+	// takes down its connection and the requests queued on it.
+	// Connections are opened as needed, max_conns_per_host does not
+	// apply. HTTP/1.1 only.
+	Pipeline int `yaml:"pipeline,omitempty"`
+
+	// TLSInsecure overrides worker.tls_insecure for this target: true
+	// skips certificate verification, false enforces it (#1220). Nil
+	// follows the global setting. HTTP/1.1 only; gRPC targets connect
+	// in plaintext.
+	TLSInsecure *bool `yaml:"tls_insecure,omitempty"`
+
+	// Pattern gives the target its own traffic curve, driven by an
+	// independent engine, so one target can spike while another stays
+	// flat (#1195). Its rate is the target's weight share of that
+	// engine's TPS. Nil follows the top-level pattern.
+	Pattern *Pattern `yaml:"pattern,omitempty"`
+
+	// MaxTotalTime is a hard bound on one request end to end — connect,
+	// response and body read — after which it is cancelled and counted
+	// as a deadline-exceeded error (#1197). It is a safety net against
+	// targets that trickle bytes forever, not a tuning knob: zero
+	// derives it from Timeout (see TotalTimeLimit).
+	MaxTotalTime time.Duration `yaml:"max_total_time,omitempty"`
+
+	// CacheBust varies a share of requests so a CDN or caching layer
+	// misses, instead of measuring all-hit latency against one fixed
+	// URL (#1206). Nil sends every request to URL as is.
+	CacheBust *CacheBust `yaml:"cache_bust,omitempty"`
+
+	// TrackHeader names a response header whose value is sampled onto
+	// the run's timeline, to watch target-side state that latency and
+	// status miss, such as X-RateLimit-Remaining draining or Age
+	// growing. It shares the response capture with cache_bust, so a
+	// target can't have both.
+	TrackHeader string `yaml:"track_header,omitempty"`
+
+	// Expect asserts on every response beyond its status class, for
+	// endpoints that report failure in the body, such as a 200 with
+	// {"error": true}. A response that fails it is an error. Nil keeps
+	// the status rule alone.
+	Expect *Expect `yaml:"expect,omitempty"`
+
+	// Duplicates flags responses that repeat an earlier one (#1256),
+	// the mark of duplicate processing under concurrency: a create
+	// endpoint handing out the same ID twice, or answering that the
+	// request was a duplicate. Nil checks nothing.
+	Duplicates *Duplicates `yaml:"duplicates,omitempty"`
+
+	// GRPC names the application method a gRPC target calls, with Body
+	// as its request message in protobuf JSON (#1253). Nil calls the
+	// standard health check.
+	GRPC *GRPCCall `yaml:"grpc,omitempty"`
+
+	// ExpectedLatency is the latency this target is expected to stay
+	// under, such as its SLA. It annotates reports and the live view:
+	// a reference line on the timeline and flagged intervals and
+	// targets whose latency exceeds it (#1209). It doesn't change what
+	// counts as an error. 0 disables the annotation.
+	ExpectedLatency time.Duration `yaml:"expected_latency,omitempty"`
+
+	// DataFile feeds rows of a CSV file into the target's requests
+	// (#1211): each request draws one row and ${column} in the URL,
+	// header values and body becomes that row's value. Nil sends the
+	// target as written. Local pool only, like hooks.
+	DataFile *DataFile `yaml:"data_file,omitempty"`
+
+	// SyntheticBody replaces Body with random bytes whose size follows
+	// a distribution (#1217), for bandwidth and serialization stress
+	// without real payloads. Nil sends Body. HTTP only.
+	SyntheticBody *SyntheticBody `yaml:"synthetic_body,omitempty"`
+
+	// LongPoll makes the target a long-poll (comet) endpoint (#1218):
+	// instead of rate-driven requests it holds a fixed number of polls
+	// open. Nil is an ordinary rate-driven target. HTTP only.
+	LongPoll *LongPoll `yaml:"long_poll,omitempty"`
+
+	// Spec is the name of the RequestSpec this target was resolved
+	// from (see Resolve). Runtime only; empty for plain targets.
+	Spec string `yaml:"-"`
+}
+
+// RequestSpec is one operation in a target's request mix. Unset fields
+// inherit from the owning Target.
+type RequestSpec struct {
+	Name         string            `yaml:"name"`
+	Method       string            `yaml:"method,omitempty"`
+	Path         string            `yaml:"path,omitempty"`    // replaces the target URL's path and query
+	Headers      map[string]string `yaml:"headers,omitempty"` // merged over the target's headers
+	Body         string            `yaml:"body,omitempty"`
+	Weight       int               `yaml:"weight,omitempty"` // default 1
+	SuccessCodes []int             `yaml:"success_codes,omitempty"`
+}
+
+// WeightedMethod is one method in a target's method mix.
+type WeightedMethod struct {
+	Method string `yaml:"method"`
+	Weight int    `yaml:"weight,omitempty"` // default 1
+	Body   string `yaml:"body,omitempty"`   // default: the target's body
+}
+
+// Spec returns the request spec m stands for.
+func (m WeightedMethod) Spec() RequestSpec {
+	method := strings.ToUpper(m.Method)
+	return RequestSpec{Name: method, Method: method, Body: m.Body, Weight: m.Weight}
+}
+
+// Resolve returns a copy of t with spec applied: method, body and
+// success codes are overridden when the spec sets them, headers are
+// merged, and Path replaces the URL's path. Requests and Methods are
+// cleared on the copy so the result describes exactly one operation.
+func (t Target) Resolve(spec *RequestSpec) Target {
+	out := t
+	out.Requests = nil
+	out.Methods = nil
+	if spec == nil {
+		return out
+	}
+	out.Spec = spec.Name
+	if spec.Method != "" {
+		out.Method = spec.Method
+	}
+	if spec.Body != "" {
+		out.Body = spec.Body
+	}
+	if len(spec.SuccessCodes) > 0 {
+		out.SuccessCodes = spec.SuccessCodes
+	}
+	if len(spec.Headers) > 0 {
+		merged := make(map[string]string, len(t.Headers)+len(spec.Headers))
+		for k, v := range t.Headers {
+			merged[k] = v
+		}
+		for k, v := range spec.Headers {
+			merged[k] = v
+		}
+		out.Headers = merged
+	}
+	if spec.Path != "" {
+		if u, err := url.Parse(t.URL); err == nil {
+			if ref, err := url.Parse(spec.Path); err == nil {
+				u.Path, u.RawPath, u.RawQuery = ref.Path, ref.RawPath, ref.RawQuery
+				out.URL = u.String()
+			}
+		}
+	}
+	return out
+}
+
+// IsSuccess reports whether status counts as a successful response
+// for this target: a member of expect.status or SuccessCodes when set,
+// otherwise any HTTP 2xx/3xx, or gRPC OK (code 0) for gRPC and
+// gRPC-Web targets.
+func (t *Target) IsSuccess(status int) bool {
+	if t.Expect != nil && len(t.Expect.Status) > 0 {
+		return slices.Contains(t.Expect.Status, status)
+	}
+	if len(t.SuccessCodes) > 0 {
+		for _, c := range t.SuccessCodes {
+			if c == status {
+				return true
+			}
+		}
+		return false
+	}
+	if t.Protocol.GRPCStatus() {
+		return status == 0
+	}
+	return status >= 200 && status < 400
+}
+
+// GRPCCall is the unary method a gRPC target invokes. Its descriptor
+// comes from Protoset or, without one, the server's reflection service.
+type GRPCCall struct {
+	// Method is the fully-qualified method, package.Service/Method.
+	Method string `yaml:"method"`
+	// Protoset is a FileDescriptorSet file, as written by protoc
+	// --descriptor_set_out --include_imports.
+	Protoset string `yaml:"protoset,omitempty"`
+}
+
+// Expect is a target's response assertions.
+type Expect struct {
+	// Status lists the accepted status codes. It takes the place of
+	// success_codes and the built-in rule.
+	Status []int `yaml:"status,omitempty"`
+	// BodyContains is text the response body must include within its
+	// first ExpectBodyLimit bytes. Setting it turns on body capture,
+	// which the target pays for in a copy per response.
+	BodyContains string `yaml:"body_contains,omitempty"`
+}
+
+// ExpectBodyLimit is how much of a response body expect.body_contains
+// searches.
+const ExpectBodyLimit = 4096
+
+// CaptureBody returns how many leading body bytes the assertions need:
+// ExpectBodyLimit with body_contains set, otherwise 0.
+func (e *Expect) CaptureBody() int {
+	if e == nil || e.BodyContains == "" {
+		return 0
+	}
+	return ExpectBodyLimit
+}
+
+// MatchBody reports whether body, the captured start of a response,
+// satisfies body_contains.
+func (e *Expect) MatchBody(body []byte) bool {
+	if e == nil || e.BodyContains == "" {
+		return true
+	}
+	// Another check may have captured more than ExpectBodyLimit.
+	return bytes.Contains(body[:min(len(body), ExpectBodyLimit)], []byte(e.BodyContains))
+}
+
+// Duplicates is a target's duplicate-response check. A successful
+// response whose Field, or whole body with Body, was seen before is a
+// duplicate, as is any response carrying Marker.
+type Duplicates struct {
+	// Field is the dot-separated path of a JSON field that should be
+	// unique per response, e.g. "id" or "data.order_id"; array
+	// elements are numbered, as in "items.0.id".
+	Field string `yaml:"field,omitempty"`
+	// Body compares whole response bodies, up to DuplicateBodyLimit,
+	// instead of a field.
+	Body bool `yaml:"body,omitempty"`
+	// Marker is text whose presence in a response body, whatever its
+	// status, reports duplicate processing, e.g. "already exists".
+	Marker string `yaml:"marker,omitempty"`
+	// Fail counts duplicates as errors, in the "duplicate" error
+	// class. By default they are only reported.
+	Fail bool `yaml:"fail,omitempty"`
+	// MaxTracked bounds how many distinct values are remembered;
+	// values first seen past it aren't checked. Default
+	// DefaultDuplicatesMaxTracked.
+	MaxTracked int `yaml:"max_tracked,omitempty"`
+}
+
+// DuplicateBodyLimit is how much of a response body the duplicate
+// check captures.
+const DuplicateBodyLimit = 64 << 10
+
+// DefaultDuplicatesMaxTracked is duplicates.max_tracked's default.
+const DefaultDuplicatesMaxTracked = 1_000_000
+
+// CaptureBody returns how many leading body bytes the check needs, 0
+// for a nil check.
+func (d *Duplicates) CaptureBody() int {
+	if d == nil {
+		return 0
+	}
+	return DuplicateBodyLimit
+}
+
+// Tracked returns max_tracked, defaulted.
+func (d *Duplicates) Tracked() int {
+	if d.MaxTracked > 0 {
+		return d.MaxTracked
+	}
+	return DefaultDuplicatesMaxTracked
+}
+
+// CacheBust makes a share of a target's requests cache misses and,
+// given the header the cache reports its result in, splits latency
+// into hits and misses.
+type CacheBust struct {
+	// Fraction is the share of requests varied, 0..1.
+	Fraction float64 `yaml:"fraction"`
+	// Param is the query parameter a varied request carries a random
+	// value in. Default DefaultCacheBustParam.
+	Param string `yaml:"param,omitempty"`
+	// Paths, when set, vary the path instead: a varied request's path
+	// and query are replaced by a random entry. A small set warms up
+	// and starts hitting, like a real hot-object working set.
+	Paths []string `yaml:"paths,omitempty"`
+	// StatusHeader is the response header the cache reports hit or
+	// miss in, e.g. X-Cache or CF-Cache-Status. A value containing
+	// "hit" (any case) is a hit, any other non-empty value a miss.
+	StatusHeader string `yaml:"status_header,omitempty"`
+}
+
+// DataFile is a CSV file whose first row names the columns and whose
+// other rows parameterise a target's requests.
+type DataFile struct {
+	Path string `yaml:"path"`
+	// Order is how rows are drawn: sequential (default, file order),
+	// random (with replacement), shuffle (without replacement,
+	// reshuffled each pass) or weighted (by WeightColumn).
+	Order string `yaml:"order,omitempty"`
+	// Loop starts a sequential file over after its last row. Without
+	// it each row is used once and the target then stops sending, for
+	// inputs that must not repeat such as one-time tokens.
+	Loop bool `yaml:"loop,omitempty"`
+	// WeightColumn names the column of non-negative row weights for
+	// order: weighted.
+	WeightColumn string `yaml:"weight_column,omitempty"`
+}
+
+// LongPoll holds Concurrency polls open against a target, each sent
+// again as soon as the previous one returns. A poll the server holds
+// past the target's timeout is expected, counted as expired rather
+// than as an error, and hold times are reported on their own instead
+// of in the run's latency percentiles.
+type LongPoll struct {
+	// Concurrency is the number of polls held open at once. Required.
+	Concurrency int `yaml:"concurrency"`
+	// Gap is a pause between a poll returning and the next, like a
+	// client's reconnect delay. 0 polls again at once.
+	Gap time.Duration `yaml:"gap,omitempty"`
+}
+
+// SyntheticBody describes the size distribution of a target's random
+// request bodies. Sizes are in bytes.
+type SyntheticBody struct {
+	// Distribution is fixed (default, always Size), uniform (between
+	// MinSize and MaxSize) or lognormal (median Size, spread Sigma).
+	Distribution string `yaml:"distribution,omitempty"`
+	Size         int    `yaml:"size,omitempty"`
+	MinSize      int    `yaml:"min_size,omitempty"`
+	// MaxSize bounds uniform's range and caps lognormal's long tail;
+	// for lognormal it defaults to DefaultSyntheticBodyMax.
+	MaxSize int `yaml:"max_size,omitempty"`
+	// Sigma is the standard deviation of ln(size). Default 1.
+	Sigma float64 `yaml:"sigma,omitempty"`
+	// ContentType is sent unless the target sets its own Content-Type
+	// header. Default application/octet-stream.
+	ContentType string `yaml:"content_type,omitempty"`
+}
+
+// SyntheticBody distributions.
+const (
+	SizeFixed     = "fixed"
+	SizeUniform   = "uniform"
+	SizeLognormal = "lognormal"
+)
+
+// DefaultSyntheticBodyMax caps lognormal body sizes when MaxSize is
+// unset, so the tail can't ask for gigabytes.
+const DefaultSyntheticBodyMax = 16 << 20
+
+// DefaultCacheBustParam is used when CacheBust.Param is unset.
+const DefaultCacheBustParam = "_kar"
+
+// Vary returns rawURL varied by the random value n: its path replaced
+// by Paths[n%len(Paths)], or, without Paths, Param=n appended to its
+// query.
+func (c *CacheBust) Vary(rawURL string, n uint64) string {
+	if len(c.Paths) > 0 {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return rawURL
+		}
+		ref, err := url.Parse(c.Paths[n%uint64(len(c.Paths))])
+		if err != nil {
+			return rawURL
+		}
+		u.Path, u.RawPath, u.RawQuery = ref.Path, ref.RawPath, ref.RawQuery
+		return u.String()
+	}
+	param := c.Param
+	if param == "" {
+		param = DefaultCacheBustParam
+	}
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + param + "=" + strconv.FormatUint(n, 36)
+}
+
+// Hit classifies a StatusHeader value. ok is false when the header was
+// absent.
+func (c *CacheBust) Hit(value string) (hit, ok bool) {
+	if value == "" {
+		return false, false
+	}
+	return strings.Contains(strings.ToLower(value), "hit"), true
 }
 
+// DefaultMaxTotalTime bounds requests on targets with neither timeout
+// nor max_total_time set.
+const DefaultMaxTotalTime = 60 * time.Second
+
+// TotalTimeLimit returns MaxTotalTime, or when unset twice Timeout
+// (so the target's own timeout always fires first), or
+// DefaultMaxTotalTime for a target without a timeout.
+func (t *Target) TotalTimeLimit() time.Duration {
+	switch {
+	case t.MaxTotalTime > 0:
+		return t.MaxTotalTime
+	case t.Timeout > 0:
+		return 2 * t.Timeout
+	default:
+		return DefaultMaxTotalTime
+	}
+}
+
+// DefaultDeadlineHeader is the HTTP header used for deadline
+// propagation when a target does not name one.
+const DefaultDeadlineHeader = "X-Request-Timeout-Ms"
+
 // Protocol represents the supported protocols.
 type Protocol string
 
 const (
-	ProtocolHTTP  Protocol = "http"
-	ProtocolHTTP2 Protocol = "http2"
-	ProtocolGRPC  Protocol = "grpc"
+	ProtocolHTTP    Protocol = "http"
+	ProtocolHTTP2   Protocol = "http2"
+	ProtocolGRPC    Protocol = "grpc"
+	ProtocolGRPCWeb Protocol = "grpc-web"
 )
 
+// KnownProtocols lists every protocol a target can use.
+var KnownProtocols = []Protocol{ProtocolHTTP, ProtocolHTTP2, ProtocolGRPC, ProtocolGRPCWeb}
+
+// Known reports whether p is one of KnownProtocols. Empty, which the
+// loader turns into http, counts as known.
+func (p Protocol) Known() bool {
+	if p == "" {
+		return true
+	}
+	for _, k := range KnownProtocols {
+		if p == k {
+			return true
+		}
+	}
+	return false
+}
+
+// GRPCStatus reports whether the protocol's responses carry gRPC
+// status codes rather than HTTP ones: gRPC, and gRPC-Web (#1226),
+// which frames gRPC calls over plain HTTP.
+func (p Protocol) GRPCStatus() bool {
+	return p == ProtocolGRPC || p == ProtocolGRPCWeb
+}
+
+// StatusText names a response status the way the protocol means it:
+// the gRPC code name, e.g. "Unavailable", for gRPC protocols, where 0
+// is OK rather than "no status", and the number otherwise.
+func (p Protocol) StatusText(status int) string {
+	if p.GRPCStatus() {
+		return codes.Code(status).String()
+	}
+	return strconv.Itoa(status)
+}
+
 // Controller configures the pulse controller.
 type Controller struct {
 	BaseTPS         float64         `yaml:"base_tps"`
@@ -135,6 +1180,109 @@ type Controller struct {
 	RampUpDuration  time.Duration   `yaml:"ramp_up_duration"`
 	Schedule        []ScheduleEntry `yaml:"schedule,omitempty"`
 	ShutdownTimeout time.Duration   `yaml:"shutdown_timeout"`
+	// RampTargetTPS is the rate the ramp-up climbs to, when it should
+	// differ from BaseTPS (#1237). 0 ramps to BaseTPS.
+	RampTargetTPS float64 `yaml:"ramp_target_tps,omitempty"`
+	// RampHold keeps the rate at the ramp target this long after the
+	// ramp before the pattern takes over. Spikes and noise stay off
+	// for the ramp and the hold.
+	RampHold time.Duration `yaml:"ramp_hold,omitempty"`
+	// StartJitter spreads each target's and worker's first request
+	// over a random offset in [0, StartJitter) after the trigger, so
+	// the run doesn't open with a synchronized burst (#1192). 0 = off.
+	StartJitter time.Duration `yaml:"start_jitter,omitempty"`
+	// WarmupRequests and WarmupDuration keep the run's first completed
+	// requests out of the primary latency percentiles while the target
+	// warms up (#1207): JIT compilation, cold caches, connection
+	// pools. With both set, warmup lasts until both are met. 0 = off.
+	WarmupRequests int           `yaml:"warmup_requests,omitempty"`
+	WarmupDuration time.Duration `yaml:"warmup_duration,omitempty"`
+	// FidelityWarn flags the run when achieved ÷ requested TPS drops
+	// below it (#1221). 0 uses DefaultFidelityWarn.
+	FidelityWarn float64 `yaml:"fidelity_warn,omitempty"`
+	// Weighting picks how target weights are applied (#1234). Empty
+	// means WeightingStatic.
+	Weighting Weighting `yaml:"weighting,omitempty"`
+	// Adaptive tunes Weighting: adaptive.
+	Adaptive AdaptiveWeighting `yaml:"adaptive,omitempty"`
+}
+
+// DefaultFidelityWarn is used when Controller.FidelityWarn is unset.
+const DefaultFidelityWarn = 0.9
+
+// RampTarget returns RampTargetTPS, or BaseTPS when it is unset.
+func (c Controller) RampTarget() float64 {
+	if c.RampTargetTPS > 0 {
+		return c.RampTargetTPS
+	}
+	return c.BaseTPS
+}
+
+// Weighting is how the controller applies target weights.
+type Weighting string
+
+const (
+	// WeightingStatic sends traffic in proportion to the configured
+	// weights for the whole run.
+	WeightingStatic Weighting = "static"
+	// WeightingAdaptive scales each weight by a factor recomputed from
+	// the target's recent latency and error rate, shifting traffic
+	// toward the healthier targets the way a latency-aware load
+	// balancer does.
+	WeightingAdaptive Weighting = "adaptive"
+)
+
+// AdaptiveWeighting bounds how fast and how far adaptive weighting may
+// move traffic. Zero values use the defaults below.
+type AdaptiveWeighting struct {
+	// Interval is how often the factors are recomputed, each time from
+	// the requests completed since the last one.
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// MinFactor is the lowest a target's weight can be scaled to, so a
+	// slow target keeps getting enough traffic to be seen recovering.
+	MinFactor float64 `yaml:"min_factor,omitempty"`
+	// MaxStep caps how much a factor moves per interval, which keeps
+	// the weights from oscillating between targets.
+	MaxStep float64 `yaml:"max_step,omitempty"`
+}
+
+// Adaptive weighting defaults.
+const (
+	DefaultAdaptiveInterval  = 5 * time.Second
+	DefaultAdaptiveMinFactor = 0.1
+	DefaultAdaptiveMaxStep   = 0.2
+)
+
+// Every returns Interval or its default.
+func (a AdaptiveWeighting) Every() time.Duration {
+	if a.Interval > 0 {
+		return a.Interval
+	}
+	return DefaultAdaptiveInterval
+}
+
+// Floor returns MinFactor or its default.
+func (a AdaptiveWeighting) Floor() float64 {
+	if a.MinFactor > 0 {
+		return a.MinFactor
+	}
+	return DefaultAdaptiveMinFactor
+}
+
+// Step returns MaxStep or its default.
+func (a AdaptiveWeighting) Step() float64 {
+	if a.MaxStep > 0 {
+		return a.MaxStep
+	}
+	return DefaultAdaptiveMaxStep
+}
+
+// FidelityThreshold returns FidelityWarn or its default.
+func (c Controller) FidelityThreshold() float64 {
+	if c.FidelityWarn > 0 {
+		return c.FidelityWarn
+	}
+	return DefaultFidelityWarn
 }
 
 // ScheduleEntry defines a time-of-day TPS multiplier.
@@ -144,15 +1292,125 @@ type Controller struct {
 // schedule that doesn't set Priority preserves the historical
 // position-based override semantics.
 type ScheduleEntry struct {
-	Hours         []int   `yaml:"hours"`
+	Hours []int `yaml:"hours,omitempty"`
+	// Cron applies the multiplier instead during the minutes a 5-field
+	// cron expression matches, e.g. "30-59 11 * * 1-5" for the half
+	// hour before a weekday lunch (#1259). An entry sets Hours or Cron.
+	Cron          string  `yaml:"cron,omitempty"`
 	TPSMultiplier float64 `yaml:"tps_multiplier"`
 	Priority      int     `yaml:"priority,omitempty"`
 }
 
+// ParseSchedule parses the interactive setup's shorthand for a
+// schedule, comma-separated "hours:multiplier" entries such as
+// "9-17:1.5, 0-5:0.3". Hours are a single hour or an inclusive range;
+// a range that runs past midnight, like 22-2, wraps around. Entries
+// must not share an hour. An empty string is no schedule.
+func ParseSchedule(s string) ([]ScheduleEntry, error) {
+	var out []ScheduleEntry
+	var seen [24]string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		hours, mult, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("schedule entry %q: want hours:multiplier, e.g. 9-17:1.5", part)
+		}
+		m, err := strconv.ParseFloat(strings.TrimSpace(mult), 64)
+		if err != nil || m <= 0 {
+			return nil, fmt.Errorf("schedule entry %q: multiplier must be a positive number", part)
+		}
+		from, to, isRange := strings.Cut(hours, "-")
+		lo, err1 := strconv.Atoi(strings.TrimSpace(from))
+		hi, err2 := lo, error(nil)
+		if isRange {
+			hi, err2 = strconv.Atoi(strings.TrimSpace(to))
+		}
+		if err1 != nil || err2 != nil || lo < 0 || lo > 23 || hi < 0 || hi > 23 {
+			return nil, fmt.Errorf("schedule entry %q: hours must be an hour or a range within 0-23", part)
+		}
+		e := ScheduleEntry{TPSMultiplier: m}
+		for h := lo; ; h = (h + 1) % 24 {
+			if seen[h] != "" {
+				return nil, fmt.Errorf("schedule entry %q: hour %d is already covered by %q", part, h, seen[h])
+			}
+			seen[h] = part
+			e.Hours = append(e.Hours, h)
+			if h == hi {
+				break
+			}
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
 // Pattern configures the traffic pattern engine.
 type Pattern struct {
 	Poisson Poisson `yaml:"poisson"`
 	Noise   Noise   `yaml:"noise"`
+	// Blend overlays independent traffic sources (#1247): each source's
+	// own Poisson and noise shape its multiplier, and the base curve
+	// is their weighted average, e.g. 70% of a steady noisy source and
+	// 30% of a spiky one. Poisson and Noise above still layer over the
+	// blended rate, as do the schedule and manual spikes.
+	Blend []BlendSource `yaml:"blend,omitempty"`
+	// Square alternates the rate between full and reduced on a fixed
+	// duty cycle, e.g. to watch an autoscaler scale out and back in.
+	// The other layers multiply it like the Poisson layer.
+	Square Square `yaml:"square,omitempty"`
+
+	// Preset names a canned shape whose parameters sit next to it;
+	// Load expands it into the fields above (#1249). See
+	// PresetSoakSpike.
+	Preset    string `yaml:"preset,omitempty"`
+	SoakSpike `yaml:",inline"`
+
+	// Seed is the master seed for the pattern's random draws (#1255).
+	// Every source under it, whether the Poisson layer, the noise,
+	// each blend source, each target's own pattern or each scenario
+	// phase, gets its own seed derived from it. The sources stay
+	// independent of each other, and the run's traffic shape
+	// reproduces from this one number. 0 draws the seed from the clock;
+	// the json output records it either way.
+	Seed int64 `yaml:"seed,omitempty"`
+}
+
+// HTTP2Settings are an http2 target's connection settings. kar never
+// accepts server push: its client advertises SETTINGS_ENABLE_PUSH=0.
+type HTTP2Settings struct {
+	// Connections is how many connections to open to the target's
+	// host; requests go round-robin over them. Default 1.
+	Connections int `yaml:"connections,omitempty"`
+	// MaxStreams caps each connection's concurrent streams on kar's
+	// side; requests beyond it wait for a stream. 0 leaves only the
+	// server's limit.
+	MaxStreams int `yaml:"max_streams,omitempty"`
+}
+
+// Set reports whether any setting is given.
+func (h HTTP2Settings) Set() bool {
+	return h.Connections != 0 || h.MaxStreams != 0
+}
+
+// BlendSource is one weighted source in pattern.blend.
+type BlendSource struct {
+	Name string `yaml:"name"`
+	// Weight is the source's share of the rate relative to the other
+	// sources' weights. Default 1.
+	Weight  float64 `yaml:"weight,omitempty"`
+	Poisson Poisson `yaml:"poisson,omitempty"`
+	Noise   Noise   `yaml:"noise,omitempty"`
+}
+
+// Share returns Weight, defaulting to 1.
+func (b BlendSource) Share() float64 {
+	if b.Weight == 0 {
+		return 1
+	}
+	return b.Weight
 }
 
 // Poisson configures Poisson spike generation.
@@ -165,12 +1423,70 @@ type Poisson struct {
 	MaxInterval time.Duration `yaml:"max_interval"`
 	RampUp      time.Duration `yaml:"ramp_up"`
 	RampDown    time.Duration `yaml:"ramp_down"`
+
+	// Overlap decides what happens when a spike arrives while another
+	// is still ramping (#1175). Empty means "sequential".
+	Overlap SpikeOverlap `yaml:"overlap,omitempty"`
+
+	// SpikeCapacity sets the spike peak as a fraction of CapacityTPS,
+	// the measured breaking point (e.g. discovery's), instead of an
+	// absolute multiplier: 0.9 spikes to 90% of capacity whatever the
+	// base TPS (#1215). When set, SpikeFactor is ignored.
+	SpikeCapacity float64 `yaml:"spike_capacity,omitempty"`
+	CapacityTPS   float64 `yaml:"capacity_tps,omitempty"`
+
+	// InitialDelay holds automatic spikes off for this long after the
+	// run starts, so the baseline is measured before the first spike
+	// (#1227). The Poisson process begins once it has passed. Manual
+	// spikes are not held. 0 = spikes may arrive from the start.
+	InitialDelay time.Duration `yaml:"initial_delay,omitempty"`
+}
+
+// Factor returns the spike multiplier over baseTPS: SpikeFactor, or
+// SpikeCapacity × CapacityTPS / baseTPS in capacity mode. A capacity
+// peak at or below baseTPS yields 1 — no spike.
+func (p Poisson) Factor(baseTPS float64) float64 {
+	if p.SpikeCapacity <= 0 {
+		return p.SpikeFactor
+	}
+	if p.CapacityTPS <= 0 || baseTPS <= 0 {
+		return 1
+	}
+	return math.Max(1, p.SpikeCapacity*p.CapacityTPS/baseTPS)
+}
+
+// SpikeOverlap selects how overlapping Poisson spikes are combined.
+type SpikeOverlap string
+
+const (
+	// SpikeOverlapSequential draws the next arrival only once the
+	// running spike has ended, so spikes never overlap. The default.
+	SpikeOverlapSequential SpikeOverlap = "sequential"
+	// SpikeOverlapDrop discards an arrival while a spike is running.
+	SpikeOverlapDrop SpikeOverlap = "drop"
+	// SpikeOverlapQueue defers an arrival until the running spike ends.
+	SpikeOverlapQueue SpikeOverlap = "queue"
+	// SpikeOverlapSuperimpose layers the new spike on top of the running
+	// one; the combined multiplier is 1 + the sum of each spike's excess.
+	SpikeOverlapSuperimpose SpikeOverlap = "superimpose"
+)
+
+// Square is an on/off duty cycle: OnDuration at the full rate, then
+// OffDuration at OffMultiplier times it, repeating. A cycle starts on.
+type Square struct {
+	Enabled     bool          `yaml:"enabled"`
+	OnDuration  time.Duration `yaml:"on_duration"`
+	OffDuration time.Duration `yaml:"off_duration"`
+	// OffMultiplier scales the rate while off, e.g. 0.1 for 10%. 0
+	// drops to the engine's floor of 1 TPS.
+	OffMultiplier float64 `yaml:"off_multiplier"`
 }
 
 // Noise configures micro fluctuations.
 type Noise struct {
 	Enabled   bool      `yaml:"enabled"`
-	Type      NoiseType `yaml:"type,omitempty"` // "spring" (default) or "perlin"
+	Type      NoiseType `yaml:"type,omitempty"`       // "spring" (default) or "perlin"
+	PerTarget bool      `yaml:"per_target,omitempty"` // independent generator per target (#1178)
 	Amplitude float64   `yaml:"amplitude"`
 }
 
@@ -188,6 +1504,202 @@ type Worker struct {
 	QueueSize       int           `yaml:"queue_size"`
 	MaxIdleConns    int           `yaml:"max_idle_conns"`
 	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout"`
+
+	// MinResizeInterval is the minimum gap between two runtime pool
+	// resizes (`kar scale`), so a feedback loop can't thrash workers.
+	MinResizeInterval time.Duration `yaml:"min_resize_interval,omitempty"` // default 10s
+
+	// FaultInject makes kar's own clients fake failures so its error
+	// handling can be checked against a healthy target (#1198). Debug
+	// only: results from such a run describe kar, not the target.
+	FaultInject FaultInject `yaml:"fault_inject,omitempty"`
+
+	// KeepAlive keeps idle connections warm between sparse requests,
+	// per protocol (#1202). Why: at low TPS the gap between requests
+	// can outlast a load balancer's or NAT's idle timeout; the next
+	// request then pays a reconnect that reads as target latency.
+	KeepAlive KeepAlive `yaml:"keep_alive,omitempty"`
+
+	// TLSInsecure skips certificate verification for every target
+	// that doesn't set its own tls_insecure (#1220). Off by default: a
+	// broken certificate on the target is a failure worth seeing.
+	TLSInsecure bool `yaml:"tls_insecure,omitempty"`
+
+	// TLS tunes TLS session resumption and the DNS and TLS warmup
+	// before the trigger (#1257). The zero value is Go's default: no
+	// session cache, so every new connection pays a full handshake.
+	TLS TLSSessions `yaml:"tls,omitempty"`
+
+	// ConnRate caps how many new connections kar opens per second,
+	// across every target and protocol, apart from the request rate
+	// (#1261). A spike then sends more requests over the connections
+	// already open instead of opening a storm of new ones, as a real
+	// client's pool would. ConnBurst is how many may open at once
+	// (default 1). 0 = unlimited.
+	ConnRate  float64 `yaml:"conn_rate,omitempty"`
+	ConnBurst int     `yaml:"conn_burst,omitempty"`
+
+	// OnSaturation is what the pool does once every worker has been
+	// busy with the queue full for a while, so the requested TPS can't
+	// be fed (#1222): "warn" (default) logs and flags it in status,
+	// "grow" also doubles pool_size, up to MaxPoolSize.
+	OnSaturation string `yaml:"on_saturation,omitempty"`
+	// MaxPoolSize caps growth under on_saturation: grow. 0 means four
+	// times pool_size.
+	MaxPoolSize int `yaml:"max_pool_size,omitempty"`
+
+	// StrictProtocol rejects a target whose protocol isn't one of
+	// KnownProtocols when the config loads (#1233), so `protocol:
+	// htttp` fails instead of quietly testing HTTP. Set false to fall
+	// back to HTTP with a logged warning. Nil means true.
+	StrictProtocol *bool `yaml:"strict_protocol,omitempty"`
+}
+
+// StrictProtocols reports whether an unknown target protocol is an
+// error rather than an HTTP fallback.
+func (w Worker) StrictProtocols() bool {
+	return w.StrictProtocol == nil || *w.StrictProtocol
+}
+
+// ConnLimit returns the connection rate limit and its burst; a zero
+// rate means unlimited.
+func (w Worker) ConnLimit() (perSecond float64, burst int) {
+	if w.ConnRate <= 0 {
+		return 0, 0
+	}
+	return w.ConnRate, max(w.ConnBurst, 1)
+}
+
+// Saturation policies for Worker.OnSaturation.
+const (
+	SaturationWarn = "warn"
+	SaturationGrow = "grow"
+)
+
+// PoolCeiling returns the size on_saturation: grow stops at.
+func (w Worker) PoolCeiling() int {
+	if w.MaxPoolSize > 0 {
+		return w.MaxPoolSize
+	}
+	return 4 * w.PoolSize
+}
+
+// SkipTLSVerify reports whether requests to t skip certificate
+// verification, given the global worker.tls_insecure.
+func (t Target) SkipTLSVerify(global bool) bool {
+	if t.TLSInsecure != nil {
+		return *t.TLSInsecure
+	}
+	return global
+}
+
+// TLSSessions configures how kar's HTTP clients reuse TLS sessions.
+type TLSSessions struct {
+	// SessionCache is how many sessions each client keeps to resume
+	// on new connections; a resumed handshake skips the certificate
+	// exchange. 0 keeps none, to measure full handshakes.
+	SessionCache int `yaml:"session_cache,omitempty"`
+	// Warmup resolves every target's host and sends one HEAD request
+	// to each http(s) target before the trigger, so the first
+	// measured requests find a pooled connection and, with a session
+	// cache, a session to resume. Warmup requests aren't counted.
+	Warmup bool `yaml:"warmup,omitempty"`
+}
+
+// KeepAlive holds keep-alive settings per protocol.
+type KeepAlive struct {
+	HTTP  KeepAliveSettings `yaml:"http,omitempty"`
+	HTTP2 KeepAliveSettings `yaml:"http2,omitempty"`
+	GRPC  KeepAliveSettings `yaml:"grpc,omitempty"`
+}
+
+// KeepAliveSettings tunes one protocol's connections. Zero fields keep
+// the client defaults: TCP probes every 30s, gRPC pings every 10s,
+// no HTTP/2 pings.
+type KeepAliveSettings struct {
+	// TCP is the TCP keep-alive probe interval; negative disables it.
+	TCP time.Duration `yaml:"tcp,omitempty"`
+	// Ping pings a connection idle this long (HTTP/2 PING frames, gRPC
+	// keepalive). HTTP/1.1 has no ping and rejects it.
+	Ping time.Duration `yaml:"ping,omitempty"`
+	// PingTimeout drops a connection whose ping isn't answered.
+	PingTimeout time.Duration `yaml:"ping_timeout,omitempty"`
+}
+
+// For returns the settings for proto; an empty protocol is HTTP.
+func (k KeepAlive) For(proto Protocol) KeepAliveSettings {
+	switch proto {
+	case ProtocolHTTP2:
+		return k.HTTP2
+	case ProtocolGRPC:
+		return k.GRPC
+	}
+	return k.HTTP
+}
+
+// FaultInject holds per-request fault probabilities (0..1). A request
+// gets at most one fault.
+type FaultInject struct {
+	Timeout   float64       `yaml:"timeout,omitempty"` // hang until the request's timeout
+	Error     float64       `yaml:"error,omitempty"`   // fake 500 (gRPC: UNAVAILABLE)
+	Slow      float64       `yaml:"slow,omitempty"`    // delay by SlowDelay, then send
+	SlowDelay time.Duration `yaml:"slow_delay,omitempty"`
+}
+
+// DefaultFaultSlowDelay is used when FaultInject.SlowDelay is unset.
+const DefaultFaultSlowDelay = 500 * time.Millisecond
+
+// Enabled reports whether any fault has a non-zero rate.
+func (f FaultInject) Enabled() bool {
+	return f.Timeout > 0 || f.Error > 0 || f.Slow > 0
+}
+
+// Delay returns SlowDelay, or DefaultFaultSlowDelay when unset.
+func (f FaultInject) Delay() time.Duration {
+	if f.SlowDelay <= 0 {
+		return DefaultFaultSlowDelay
+	}
+	return f.SlowDelay
+}
+
+// ParseFaultInject parses the --fault-inject flag, a comma-separated
+// list of kind=value pairs: timeout, error and slow take a probability,
+// delay a duration. Example: "error=0.05,slow=0.1,delay=2s".
+func ParseFaultInject(spec string) (FaultInject, error) {
+	var f FaultInject
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return f, fmt.Errorf("fault %q: want kind=value", part)
+		}
+		if key == "delay" {
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return f, fmt.Errorf("fault delay: %w", err)
+			}
+			f.SlowDelay = d
+			continue
+		}
+		rate, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return f, fmt.Errorf("fault %s: %w", key, err)
+		}
+		switch key {
+		case "timeout":
+			f.Timeout = rate
+		case "error":
+			f.Error = rate
+		case "slow":
+			f.Slow = rate
+		default:
+			return f, fmt.Errorf("unknown fault %q (want timeout, error, slow or delay)", key)
+		}
+	}
+	return f, nil
 }
 
 // Health configures the health checker.
@@ -195,6 +1707,54 @@ type Health struct {
 	Enabled  bool          `yaml:"enabled"`
 	Interval time.Duration `yaml:"interval"`
 	Timeout  time.Duration `yaml:"timeout"`
+
+	// FailureThresholds is the number of consecutive failed checks of a
+	// given cause before a target is marked unhealthy (#1180). Keys are
+	// HealthFailure values; missing keys default to 1, i.e. the first
+	// failure flips the target. Example: {dns: 3, refused: 1}.
+	FailureThresholds map[HealthFailure]int `yaml:"failure_thresholds,omitempty"`
+
+	// OnAllUnhealthy is what the controller does while every target is
+	// unhealthy (#1190): AllUnhealthyPause (default) stops generating
+	// and reports it; AllUnhealthyProbe keeps sending at ProbeTPS so
+	// real traffic notices recovery too.
+	OnAllUnhealthy string  `yaml:"on_all_unhealthy,omitempty"`
+	ProbeTPS       float64 `yaml:"probe_tps,omitempty"`
+}
+
+// Health.OnAllUnhealthy values.
+const (
+	AllUnhealthyPause = "pause"
+	AllUnhealthyProbe = "probe"
+)
+
+// DefaultProbeTPS is used when Health.ProbeTPS is unset.
+const DefaultProbeTPS = 1.0
+
+// UnhealthyAction returns OnAllUnhealthy, defaulting to pause.
+func (h Health) UnhealthyAction() string {
+	if h.OnAllUnhealthy == "" {
+		return AllUnhealthyPause
+	}
+	return h.OnAllUnhealthy
+}
+
+// HealthFailure classifies why a health check failed.
+type HealthFailure string
+
+const (
+	HealthFailureDNS     HealthFailure = "dns"     // name resolution failed
+	HealthFailureRefused HealthFailure = "refused" // TCP connection refused
+	HealthFailureTimeout HealthFailure = "timeout" // no answer within health.timeout
+	HealthFailureTLS     HealthFailure = "tls"     // handshake or certificate error
+	HealthFailureStatus  HealthFailure = "status"  // answered with a non-2xx/3xx status
+	HealthFailureOther   HealthFailure = "other"   // anything else (reset, EOF, ...)
+)
+
+// HealthFailures lists every failure cause, in display order.
+var HealthFailures = []HealthFailure{
+	HealthFailureDNS, HealthFailureRefused, HealthFailureTimeout,
+	HealthFailureTLS, HealthFailureStatus, HealthFailureOther,
 }
 
 // Metrics configures Prometheus metrics.
@@ -202,6 +1762,45 @@ type Metrics struct {
 	Enabled bool   `yaml:"enabled"`
 	Address string `yaml:"address"`
 	Path    string `yaml:"path"`
+	// Addresses are further addresses to serve on besides Address,
+	// e.g. loopback and a pod IP (#1214).
+	Addresses []string `yaml:"addresses,omitempty"`
+	// MaxLabelValues caps the distinct values of each label taken
+	// from the config: target, request spec and connection host
+	// (#1231). Values past it are reported as "other". 0 uses
+	// DefaultMaxLabelValues; -1 lifts the cap.
+	MaxLabelValues int `yaml:"max_label_values,omitempty"`
+}
+
+// DefaultMaxLabelValues is used when Metrics.MaxLabelValues is unset:
+// far more targets than any hand-written config has, few enough that
+// the per-target histograms stay in the tens of thousands of series.
+const DefaultMaxLabelValues = 200
+
+// LabelLimit returns MaxLabelValues with its default applied; -1
+// means unlimited.
+func (m Metrics) LabelLimit() int {
+	switch {
+	case m.MaxLabelValues < 0:
+		return -1
+	case m.MaxLabelValues == 0:
+		return DefaultMaxLabelValues
+	}
+	return m.MaxLabelValues
+}
+
+// ListenAddresses returns Address followed by Addresses, without
+// empty or repeated entries.
+func (m Metrics) ListenAddresses() []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, a := range append([]string{m.Address}, m.Addresses...) {
+		if a != "" && !seen[a] {
+			seen[a] = true
+			out = append(out, a)
+		}
+	}
+	return out
 }
 
 // Safety configures the circuit breaker that pauses traffic when
@@ -216,6 +1815,64 @@ type Safety struct {
 	SustainedFor    time.Duration `yaml:"sustained_for"`               // window the breach must hold for
 	ResumeAfter     time.Duration `yaml:"resume_after,omitempty"`      // 0 disables auto-resume
 	Webhook         string        `yaml:"webhook,omitempty"`           // optional URL pinged on transitions
+
+	// Cliff watches for a latency cliff instead of a fixed threshold
+	// (#1252). It runs whether or not the breaker is enabled.
+	Cliff Cliff `yaml:"cliff,omitempty"`
+}
+
+// Cliff detects a latency cliff: a sudden step up in windowed P95
+// relative to the recent baseline, the sign of a target that just fell
+// over. A static threshold set too high misses it and set too low
+// false-positives on ordinary drift.
+type Cliff struct {
+	Enabled bool `yaml:"enabled"`
+	// Window is how long each P95 sample covers. Default 5s.
+	Window time.Duration `yaml:"window,omitempty"`
+	// Baseline is how many windows before the current one form the
+	// baseline, their median P95. Default 6.
+	Baseline int `yaml:"baseline,omitempty"`
+	// Factor is how far above the baseline a window's P95 must jump to
+	// count as a cliff. Default 2.
+	Factor float64 `yaml:"factor,omitempty"`
+	// MinP95 ignores jumps that end below it, e.g. 1ms → 3ms. 0 keeps
+	// every jump.
+	MinP95 time.Duration `yaml:"min_p95,omitempty"`
+	// Action is alert (default: log, annotate the timeline and ping
+	// safety.webhook), pause (alert and pause traffic until
+	// `kar resume`) or abort (alert and end the run).
+	Action string `yaml:"action,omitempty"`
+}
+
+// Cliff actions.
+const (
+	CliffAlert = "alert"
+	CliffPause = "pause"
+	CliffAbort = "abort"
+)
+
+// Cliff defaults.
+const (
+	DefaultCliffWindow   = 5 * time.Second
+	DefaultCliffBaseline = 6
+	DefaultCliffFactor   = 2.0
+)
+
+// WithDefaults returns c with unset fields defaulted.
+func (c Cliff) WithDefaults() Cliff {
+	if c.Window <= 0 {
+		c.Window = DefaultCliffWindow
+	}
+	if c.Baseline <= 0 {
+		c.Baseline = DefaultCliffBaseline
+	}
+	if c.Factor <= 0 {
+		c.Factor = DefaultCliffFactor
+	}
+	if c.Action == "" {
+		c.Action = CliffAlert
+	}
+	return c
 }
 
 // Dashboard configures the daemon's optional web dashboard. The
@@ -238,8 +1895,71 @@ type Discovery struct {
 	MaxTPS          float64       `yaml:"max_tps"`          // Upper bound (default: 10000)
 	StepDuration    time.Duration `yaml:"step_duration"`    // Duration per TPS step (default: 10s)
 	ConvergenceRate float64       `yaml:"convergence_rate"` // Binary search convergence (default: 0.05 = 5%)
+
+	// Sweep replaces the binary search with SweepSteps evenly spaced
+	// levels from MinTPS to MaxTPS, for a dense capacity curve (#1184).
+	Sweep      bool `yaml:"sweep,omitempty"`
+	SweepSteps int  `yaml:"sweep_steps,omitempty"` // default 10
+
+	// Concurrency sweeps closed-loop concurrency instead of TPS
+	// (#1250): SweepSteps levels from MinConcurrency to MaxConcurrency
+	// requests held in flight, each sent again as soon as the previous
+	// one returns. The result is the knee, the last level before
+	// latency starts rising with added concurrency.
+	Concurrency    bool `yaml:"concurrency,omitempty"`
+	MinConcurrency int  `yaml:"min_concurrency,omitempty"` // default 1
+	MaxConcurrency int  `yaml:"max_concurrency,omitempty"` // default 100
+
+	// Staged splits the binary search into two phases: a coarse sweep
+	// from MinTPS, each level CoarseFactor times the last, with short
+	// CoarseStepDuration steps to bracket the breaking point, then the
+	// binary search with full StepDuration steps inside the bracket
+	// only. Wide ranges converge in a fraction of the time.
+	Staged             bool          `yaml:"staged,omitempty"`
+	CoarseFactor       float64       `yaml:"coarse_factor,omitempty"`        // default 10
+	CoarseStepDuration time.Duration `yaml:"coarse_step_duration,omitempty"` // default 3s
+
+	// TLSInsecure skips certificate verification (#1220).
+	TLSInsecure bool `yaml:"tls_insecure,omitempty"`
+
+	// ConnRetries retries a request up to this many times when it
+	// fails to connect (refused, reset, connect timeout), so a briefly
+	// full accept queue isn't scored as a failure (#1235). 0 fails
+	// immediately. The retry wait counts toward the request's latency.
+	ConnRetries int `yaml:"conn_retries,omitempty"`
+	// ConnErrorTolerance is the percentage of a step's requests that
+	// may still end in connection errors without counting toward
+	// ErrorRateLimit. Past it the errors are sustained, and all of
+	// them count. 0 counts every one.
+	ConnErrorTolerance float64 `yaml:"conn_error_tolerance,omitempty"`
+
+	// Expect asserts on every response as a target's expect does;
+	// failures count toward ErrorRateLimit. Nil scores by status alone.
+	Expect *Expect `yaml:"expect,omitempty"`
+
+	// Duplicates flags responses that repeat an earlier one (#1256),
+	// the mark of duplicate processing under concurrency: a create
+	// endpoint handing out the same ID twice, or answering that the
+	// request was a duplicate. Nil checks nothing.
+	Duplicates *Duplicates `yaml:"duplicates,omitempty"`
 }
 
+// DefaultSweepSteps is the number of levels a discovery sweep tests
+// when SweepSteps is unset.
+const DefaultSweepSteps = 10
+
+// Default bounds of a discovery concurrency sweep.
+const (
+	DefaultMinConcurrency = 1
+	DefaultMaxConcurrency = 100
+)
+
+// Defaults of a staged discovery's coarse phase.
+const (
+	DefaultCoarseFactor       = 10.0
+	DefaultCoarseStepDuration = 3 * time.Second
+)
+
 // DefaultConfig returns a configuration with sensible defaults.
 func DefaultConfig() *Config {
 	return &Config{
@@ -269,6 +1989,8 @@ func DefaultConfig() *Config {
 			QueueSize:       10000,
 			MaxIdleConns:    100,
 			IdleConnTimeout: 90 * time.Second,
+
+			MinResizeInterval: 10 * time.Second,
 		},
 		Health: Health{
 			Enabled:  true,
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSpec is a parsed 5-field cron expression (minute, hour, day of
+// month, month, day of week), as a schedule entry's Cron (#1259). Each
+// field is a bit set of the values it allows.
+type CronSpec struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny record a "*" day field: when both day fields
+	// are restricted, cron matches a day that satisfies either.
+	domAny, dowAny bool
+}
+
+// cronField describes one field's range and the names it accepts.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var cronFields = [5]cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	// 7 is Sunday too, folded into 0 below.
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// ParseCron parses a standard 5-field cron expression such as
+// "30-59 11 * * 1-5". Fields take "*", numbers, ranges, lists and
+// steps ("*/15", "0-30/10"); months and weekdays also take their
+// three-letter names.
+func ParseCron(expr string) (CronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return CronSpec{}, fmt.Errorf("cron %q: want 5 fields (minute hour day-of-month month day-of-week), got %d", expr, len(fields))
+	}
+	var sets [5]uint64
+	for i, f := range fields {
+		set, err := cronFields[i].parse(f)
+		if err != nil {
+			return CronSpec{}, fmt.Errorf("cron %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+	return CronSpec{
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		domAny: fields[2] == "*", dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parse reads one field into its bit set.
+func (f cronField) parse(s string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: bad step in %q", f.name, part)
+			}
+			step = n
+		}
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(to); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s: range %q runs backwards", f.name, rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// value reads a single number or name of the field.
+func (f cronField) value(s string) (int, error) {
+	for i, n := range f.names {
+		if strings.EqualFold(s, n) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %q is not within %d-%d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Matches reports whether t's minute matches the spec.
+func (c CronSpec) Matches(t time.Time) bool {
+	return c.minute&(1<<t.Minute()) != 0 && c.hour&(1<<t.Hour()) != 0 &&
+		c.month&(1<<int(t.Month())) != 0 && c.dayMatches(t)
+}
+
+func (c CronSpec) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first minute after t that matches, or the zero
+// time when none does within five years (e.g. "0 0 30 2 *").
+func (c CronSpec) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
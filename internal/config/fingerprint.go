@@ -0,0 +1,116 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RunFingerprint identifies the load a config generates (#1219), so a
+// result is read against the settings that produced it and two runs
+// are only compared when they used the same ones. Hash covers every
+// load-shaping setting; the other fields are the ones worth reading.
+type RunFingerprint struct {
+	Hash    string   `json:"hash"`
+	BaseTPS float64  `json:"base_tps"`
+	MaxTPS  float64  `json:"max_tps"`
+	Pattern string   `json:"pattern"`
+	Targets []string `json:"targets"`
+}
+
+// fingerprinted is the part of Config that shapes the load. Where the
+// results go (output, report, metrics, dashboard) and how a cluster is
+// wired (master) stay out, so moving a report path doesn't change the
+// fingerprint.
+type fingerprinted struct {
+	Targets    []Target   `yaml:"targets"`
+	Controller Controller `yaml:"controller"`
+	Pattern    Pattern    `yaml:"pattern"`
+	Worker     Worker     `yaml:"worker"`
+	Health     Health     `yaml:"health"`
+	Safety     Safety     `yaml:"safety"`
+	Scenarios  []Scenario `yaml:"scenarios"`
+	Hooks      Hooks      `yaml:"hooks"`
+}
+
+// Fingerprint returns the run fingerprint of the effective config, as
+// loaded and defaulted. Resolved secret values are left out: rotating
+// a token doesn't make a different test, and the hash shouldn't depend
+// on one. So is pattern.seed (#1255): pinning it to reproduce a run
+// keeps comparing against that run.
+func (c *Config) Fingerprint() RunFingerprint {
+	f := fingerprinted{
+		Targets:    make([]Target, len(c.Targets)),
+		Controller: c.Controller,
+		Pattern:    c.Pattern,
+		Worker:     c.Worker,
+		Health:     c.Health,
+		Safety:     c.Safety,
+		Scenarios:  c.Scenarios,
+		Hooks:      c.Hooks,
+	}
+	f.Pattern.Seed = 0
+	for i, t := range c.Targets {
+		if len(t.SecretHeaders) > 0 {
+			headers := make(map[string]string, len(t.Headers))
+			for k, v := range t.Headers {
+				if _, secret := t.SecretHeaders[k]; !secret {
+					headers[k] = v
+				}
+			}
+			t.Headers = headers
+		}
+		f.Targets[i] = t
+	}
+
+	// yaml.v3 sorts map keys, so equal configs marshal identically.
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		data = []byte(err.Error())
+	}
+	sum := sha256.Sum256(data)
+
+	fp := RunFingerprint{
+		Hash:    hex.EncodeToString(sum[:8]),
+		BaseTPS: c.Controller.BaseTPS,
+		MaxTPS:  c.Controller.MaxTPS,
+		Pattern: c.patternSummary(),
+	}
+	for _, t := range c.Targets {
+		fp.Targets = append(fp.Targets, strings.TrimSpace(t.Name+" "+t.Method+" "+t.URL))
+	}
+	return fp
+}
+
+// patternSummary is a one-line description of the traffic shape.
+func (c *Config) patternSummary() string {
+	if len(c.Scenarios) > 0 {
+		names := make([]string, len(c.Scenarios))
+		for i, s := range c.Scenarios {
+			names[i] = s.Name
+		}
+		return "scenarios " + strings.Join(names, " → ")
+	}
+	var parts []string
+	if p := c.Pattern.Poisson; p.Enabled {
+		every := fmt.Sprintf("λ=%g", p.Lambda)
+		if p.Interval > 0 {
+			every = "every " + p.Interval.String()
+		}
+		parts = append(parts, fmt.Sprintf("poisson %s ×%.2g", every, p.Factor(c.Controller.BaseTPS)))
+	}
+	if n := c.Pattern.Noise; n.Enabled {
+		kind := n.Type
+		if kind == "" {
+			kind = NoiseTypeSpring
+		}
+		parts = append(parts, fmt.Sprintf("noise %s ±%g%%", kind, n.Amplitude*100))
+	}
+	if len(parts) == 0 {
+		return "flat"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 
@@ -19,13 +20,39 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	if err := cfg.ApplyPresets(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	if err := validate(cfg); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	if err := ResolveSecrets(cfg); err != nil {
+		return nil, fmt.Errorf("resolving secrets: %w", err)
+	}
+
 	return cfg, nil
 }
 
+// Save writes cfg to path as YAML that Load reads back into the same
+// configuration.
+func Save(cfg *Config, path string) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
 // validate checks the configuration for errors.
 func validate(cfg *Config) error {
 	if len(cfg.Targets) == 0 {
@@ -42,6 +69,10 @@ func validate(cfg *Config) error {
 		if t.Protocol == "" {
 			cfg.Targets[i].Protocol = ProtocolHTTP
 		}
+		if !t.Protocol.Known() && cfg.Worker.StrictProtocols() {
+			return fmt.Errorf("target[%d] %q: unknown protocol %q (use one of %v, or set worker.strict_protocol: false to fall back to http)",
+				i, t.Name, t.Protocol, KnownProtocols)
+		}
 		if t.Method == "" {
 			cfg.Targets[i].Method = "GET"
 		}
@@ -51,6 +82,11 @@ func validate(cfg *Config) error {
 		if t.Timeout <= 0 {
 			cfg.Targets[i].Timeout = 30_000_000_000 // 30s in nanoseconds
 		}
+		// A malformed body template fails the load rather than going
+		// out as literal braces (#1251).
+		if iss := validateBodyTemplates(fmt.Sprintf("target[%d]", i), t); len(iss) > 0 {
+			return fmt.Errorf("%s: %s", iss[0].Path, iss[0].Message)
+		}
 	}
 
 	if cfg.Controller.BaseTPS <= 0 {
@@ -60,11 +96,22 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("controller.max_tps must be >= base_tps")
 	}
 
+	// A cron expression that doesn't parse would leave its entry never
+	// matching under `kar run`; fail the load instead (#1259).
+	for i, e := range cfg.Controller.Schedule {
+		if e.Cron == "" {
+			continue
+		}
+		if _, err := ParseCron(e.Cron); err != nil {
+			return fmt.Errorf("controller.schedule[%d].cron: %w", i, err)
+		}
+	}
+
 	if cfg.Pattern.Poisson.Enabled {
-		if cfg.Pattern.Poisson.Lambda <= 0 {
-			return fmt.Errorf("pattern.poisson.lambda must be positive")
+		if cfg.Pattern.Poisson.Lambda <= 0 && cfg.Pattern.Poisson.Interval <= 0 {
+			return fmt.Errorf("pattern.poisson.lambda or interval must be positive")
 		}
-		if cfg.Pattern.Poisson.SpikeFactor < 1 {
+		if cfg.Pattern.Poisson.SpikeCapacity <= 0 && cfg.Pattern.Poisson.SpikeFactor < 1 {
 			return fmt.Errorf("pattern.poisson.spike_factor must be >= 1")
 		}
 	}
@@ -79,5 +126,13 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("worker.pool_size must be positive")
 	}
 
+	// The control channel is the daemon's only access control; don't
+	// start one open to the network (#1242).
+	for _, iss := range validateControl(cfg.Control) {
+		if iss.Severity == SeverityError {
+			return fmt.Errorf("%s: %s", iss.Path, iss.Message)
+		}
+	}
+
 	return nil
 }
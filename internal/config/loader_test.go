@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSave_RoundTripsThroughLoad(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].Timeout = 30 * time.Second
+	cfg.Controller.BaseTPS = 250
+	cfg.Controller.MaxTPS = 900
+	cfg.Controller.Schedule = []ScheduleEntry{{Hours: []int{9, 10, 11}, TPSMultiplier: 1.5}}
+	cfg.Pattern.Poisson.Interval = 2 * time.Minute
+	cfg.Pattern.Poisson.Lambda = 0
+	cfg.Pattern.Noise.Amplitude = 0.2
+
+	path := filepath.Join(t.TempDir(), "kar.yaml")
+	if err := Save(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Targets, cfg.Targets) {
+		t.Errorf("targets = %+v, want %+v", got.Targets, cfg.Targets)
+	}
+	if !reflect.DeepEqual(got.Controller, cfg.Controller) {
+		t.Errorf("controller = %+v, want %+v", got.Controller, cfg.Controller)
+	}
+	if !reflect.DeepEqual(got.Pattern, cfg.Pattern) {
+		t.Errorf("pattern = %+v, want %+v", got.Pattern, cfg.Pattern)
+	}
+}
+
+func TestLoad_BadCronFails(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Controller.Schedule = []ScheduleEntry{
+		{Hours: []int{9}, TPSMultiplier: 1.5},
+		{Cron: "30-59 11 * *", TPSMultiplier: 2},
+	}
+	path := filepath.Join(t.TempDir(), "kar.yaml")
+	if err := Save(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "controller.schedule[1].cron") {
+		t.Fatalf("Load = %v, want the bad cron entry named", err)
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	got, err := ParseSchedule("9-11:1.5, 3:0.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ScheduleEntry{
+		{Hours: []int{9, 10, 11}, TPSMultiplier: 1.5},
+		{Hours: []int{3}, TPSMultiplier: 0.3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseSchedule = %+v, want %+v", got, want)
+	}
+	if got, err := ParseSchedule("  "); err != nil || got != nil {
+		t.Fatalf("empty schedule = %+v, %v", got, err)
+	}
+	got, err = ParseSchedule("22-2:0.5, 3-4:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []ScheduleEntry{
+		{Hours: []int{22, 23, 0, 1, 2}, TPSMultiplier: 0.5},
+		{Hours: []int{3, 4}, TPSMultiplier: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrap-around schedule = %+v, want %+v", got, want)
+	}
+	for _, bad := range []string{"9-17", "9-17:x", "9-17:-1", "24:1", "a-3:1", "9-17:1.5, 12:2", "22-2:1, 1:1", "5-24:1"} {
+		if _, err := ParseSchedule(bad); err == nil {
+			t.Errorf("ParseSchedule(%q) should fail", bad)
+		}
+	}
+}
+
+func TestLoadPlan_AppliesStagesAsScenarios(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.yaml")
+	plan := `stages:
+  - name: warmup
+    duration: 30s
+    base_tps: 10
+  - name: steady
+    duration: 2m
+    base_tps: 100
+    slo:
+      p95_latency: 200ms
+      error_rate: 1
+`
+	if err := os.WriteFile(path, []byte(plan), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := goodConfig()
+	if err := cfg.ApplyPlan(p); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Scenarios) != 2 || cfg.Scenarios[0].SLO != nil {
+		t.Fatalf("scenarios = %+v", cfg.Scenarios)
+	}
+	if slo := cfg.Scenarios[1].SLO; slo == nil || slo.P95Latency != 200*time.Millisecond || slo.ErrorRate != 1 {
+		t.Fatalf("steady SLO = %+v", slo)
+	}
+	if got := ValidateConfig(cfg); HasErrors(got) {
+		t.Fatalf("plan should validate, got %+v", got)
+	}
+
+	cfg.Scenarios[1].SLO.ErrorRate = 150
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("expected an error for a stage error_rate over 100")
+	}
+
+	if err := os.WriteFile(path, []byte("stages:\n  - name: x\n    duration: 1m\n    slo: {p95: 1s}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPlan(path); err == nil {
+		t.Fatal("expected an unknown SLO field to fail the plan")
+	}
+}
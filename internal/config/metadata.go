@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// RunMetadata records what produced a result and where (#1236), so an
+// archived report can be traced back to the kar build, host and run
+// that made it. Tags carry whatever else the operator wants on record,
+// such as the git SHA of the build under test.
+type RunMetadata struct {
+	Version   string            `json:"version"`
+	GitCommit string            `json:"git_commit,omitempty"`
+	Host      string            `json:"host,omitempty"`
+	OS        string            `json:"os"`
+	Arch      string            `json:"arch"`
+	GoVersion string            `json:"go_version"`
+	Started   time.Time         `json:"started"`
+	Ended     time.Time         `json:"ended,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+}
+
+// NewRunMetadata describes a run starting now on this host. An unknown
+// commit is left out rather than recorded as "unknown".
+func NewRunMetadata(version, commit string, tags map[string]string) RunMetadata {
+	host, _ := os.Hostname()
+	if commit == "unknown" {
+		commit = ""
+	}
+	return RunMetadata{
+		Version:   version,
+		GitCommit: commit,
+		Host:      host,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		GoVersion: runtime.Version(),
+		Started:   time.Now(),
+		Tags:      tags,
+	}
+}
+
+// ParseTags reads --tag values of the form key=value into base, which
+// may be nil; a flag overrides a report.tags entry with the same key.
+func ParseTags(base map[string]string, flags []string) (map[string]string, error) {
+	if len(flags) == 0 {
+		return base, nil
+	}
+	out := make(map[string]string, len(base)+len(flags))
+	for k, v := range base {
+		out[k] = v
+	}
+	for _, f := range flags {
+		k, v, ok := strings.Cut(f, "=")
+		if k = strings.TrimSpace(k); !ok || k == "" {
+			return nil, fmt.Errorf("invalid tag %q: want key=value", f)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Plan is a test plan (#1262): the ordered stages of a run (warmup,
+// ramp, steady, spike, soak, cooldown, ...) with each stage's load and
+// the SLO it must meet. A stage is a scenario phase; `kar run --plan`
+// runs them in place of the config's own scenarios and ends when the
+// last one does.
+type Plan struct {
+	Stages []Scenario `yaml:"stages"`
+}
+
+// LoadPlan reads a test plan from path. Unknown fields are rejected so
+// a misspelt SLO key fails instead of asserting nothing.
+func LoadPlan(path string) (*Plan, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	var p Plan
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	if len(p.Stages) == 0 {
+		return nil, fmt.Errorf("plan %s has no stages", path)
+	}
+	return &p, nil
+}
+
+// ApplyPlan replaces c's scenarios with the plan's stages and expands
+// their pattern presets. Validate c afterwards: stages are checked as
+// scenarios[i].
+func (c *Config) ApplyPlan(p *Plan) error {
+	c.Scenarios = p.Stages
+	for i := range c.Scenarios {
+		if pat := c.Scenarios[i].Pattern; pat != nil {
+			if err := pat.ApplyPreset(nil); err != nil {
+				return fmt.Errorf("stages[%d].pattern: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// PostCheck is an HTTP assertion run once after the load (#1241), to
+// verify side effects a response-time SLO can't see: that the target
+// counted every request, or didn't leak goroutines. A failed check
+// fails `kar run`.
+type PostCheck struct {
+	Name    string            `yaml:"name"`
+	URL     string            `yaml:"url"`
+	Method  string            `yaml:"method,omitempty"` // default GET
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+	// Timeout bounds the request. Default DefaultPostCheckTimeout.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// Status lists the accepted status codes; empty accepts 2xx.
+	Status []int `yaml:"status,omitempty"`
+	// Contains is text the response body must include.
+	Contains string `yaml:"contains,omitempty"`
+	// JSON compares fields of a JSON response body.
+	JSON []JSONCheck `yaml:"json,omitempty"`
+}
+
+// JSONCheck compares one field of a JSON body. Path is dot-separated
+// object keys and array indexes, e.g. "stats.requests_total" or
+// "workers.0.state". Numbers compare numerically; anything else only
+// supports == and !=, against the value's JSON text for objects and
+// arrays.
+type JSONCheck struct {
+	Path  string `yaml:"path"`
+	Op    string `yaml:"op,omitempty"` // default ==
+	Value string `yaml:"value"`
+}
+
+// JSONCheck operators.
+var JSONCheckOps = []string{"==", "!=", ">", ">=", "<", "<="}
+
+// DefaultPostCheckTimeout is used when PostCheck.Timeout is unset.
+const DefaultPostCheckTimeout = 10 * time.Second
+
+// Wait returns Timeout with its default applied.
+func (c PostCheck) Wait() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultPostCheckTimeout
+	}
+	return c.Timeout
+}
+
+// Verb returns Method with its default applied.
+func (c PostCheck) Verb() string {
+	if c.Method == "" {
+		return "GET"
+	}
+	return strings.ToUpper(c.Method)
+}
+
+// Accepts reports whether status passes the check's status rule.
+func (c PostCheck) Accepts(status int) bool {
+	if len(c.Status) == 0 {
+		return status >= 200 && status < 300
+	}
+	for _, s := range c.Status {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Operator returns Op with its default applied.
+func (j JSONCheck) Operator() string {
+	if j.Op == "" {
+		return "=="
+	}
+	return j.Op
+}
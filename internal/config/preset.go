@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pattern presets.
+const (
+	// PresetSoakSpike holds the baseline and spikes on a fixed period
+	// (#1249).
+	PresetSoakSpike = "soak-spike"
+)
+
+// SoakSpike is the soak-spike preset: hold Baseline for the whole run
+// and spike to SpikeFactor × it every SpikeEvery, on a fixed clock
+// rather than at Poisson-random times. Load expands it into
+// pattern.poisson with min_interval = max_interval = SpikeEvery, which
+// pins every arrival to the period, and turns noise off.
+type SoakSpike struct {
+	// Baseline is the TPS held between spikes. Default
+	// controller.base_tps; top-level pattern only.
+	Baseline      float64       `yaml:"baseline,omitempty"`
+	SpikeFactor   float64       `yaml:"spike_factor,omitempty"`
+	SpikeEvery    time.Duration `yaml:"spike_every,omitempty"`
+	SpikeDuration time.Duration `yaml:"spike_duration,omitempty"`
+}
+
+// set reports whether any preset parameter is given.
+func (s SoakSpike) set() bool {
+	return s != SoakSpike{}
+}
+
+// soakSpikeRampUp is the share of spike_duration spent ramping up; the
+// rest decays back to the baseline.
+const soakSpikeRampUp = 0.25
+
+// ApplyPreset expands a preset into the pattern's generators. baseTPS
+// is where Baseline goes, nil outside the top-level pattern. A pattern
+// without a preset is left as is.
+func (p *Pattern) ApplyPreset(baseTPS *float64) error {
+	switch p.Preset {
+	case "":
+		if p.SoakSpike.set() {
+			return fmt.Errorf("baseline, spike_factor, spike_every and spike_duration need preset: %s", PresetSoakSpike)
+		}
+		return nil
+	case PresetSoakSpike:
+	default:
+		return fmt.Errorf("unknown preset %q (use %s)", p.Preset, PresetSoakSpike)
+	}
+
+	s := p.SoakSpike
+	switch {
+	case s.SpikeFactor < 1:
+		return fmt.Errorf("%s: spike_factor must be >= 1", PresetSoakSpike)
+	case s.SpikeEvery <= 0:
+		return fmt.Errorf("%s: spike_every is required", PresetSoakSpike)
+	case s.SpikeDuration <= 0:
+		return fmt.Errorf("%s: spike_duration is required", PresetSoakSpike)
+	case s.SpikeDuration >= s.SpikeEvery:
+		return fmt.Errorf("%s: spike_duration (%s) must be shorter than spike_every (%s)", PresetSoakSpike, s.SpikeDuration, s.SpikeEvery)
+	case s.Baseline < 0:
+		return fmt.Errorf("%s: baseline must be positive", PresetSoakSpike)
+	case s.Baseline > 0 && baseTPS == nil:
+		return fmt.Errorf("%s: baseline is only supported on the top-level pattern", PresetSoakSpike)
+	}
+	if s.Baseline > 0 {
+		*baseTPS = s.Baseline
+	}
+
+	rampUp := time.Duration(float64(s.SpikeDuration) * soakSpikeRampUp)
+	p.Poisson = Poisson{
+		Enabled:     true,
+		Lambda:      1 / s.SpikeEvery.Seconds(),
+		SpikeFactor: s.SpikeFactor,
+		MinInterval: s.SpikeEvery,
+		MaxInterval: s.SpikeEvery,
+		RampUp:      rampUp,
+		RampDown:    s.SpikeDuration - rampUp,
+		// Space arrivals from one spike's start to the next, not from
+		// its end; spike_duration < spike_every, so none is dropped.
+		Overlap: SpikeOverlapDrop,
+	}
+	p.Noise = Noise{}
+	p.Blend = nil
+	return nil
+}
+
+// ApplyPresets expands the presets of the top-level pattern, targets'
+// patterns and scenario phases' patterns.
+func (c *Config) ApplyPresets() error {
+	if err := c.Pattern.ApplyPreset(&c.Controller.BaseTPS); err != nil {
+		return fmt.Errorf("pattern: %w", err)
+	}
+	for i := range c.Targets {
+		if p := c.Targets[i].Pattern; p != nil {
+			if err := p.ApplyPreset(nil); err != nil {
+				return fmt.Errorf("targets[%d].pattern: %w", i, err)
+			}
+		}
+	}
+	for i := range c.Scenarios {
+		if p := c.Scenarios[i].Pattern; p != nil {
+			if err := p.ApplyPreset(nil); err != nil {
+				return fmt.Errorf("scenarios[%d].pattern: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
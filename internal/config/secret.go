@@ -0,0 +1,172 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// RedactedValue replaces secret values wherever a config is echoed
+// back.
+const RedactedValue = "<redacted>"
+
+// SecretRef points at a secret kept out of the config file (#1204): a
+// file, such as a mounted Kubernetes or Docker secret, or an
+// environment variable. Exactly one of File and Env is set. Secrets
+// are read once, at load time.
+type SecretRef struct {
+	File string `yaml:"file,omitempty"`
+	Env  string `yaml:"env,omitempty"`
+}
+
+// Resolve reads the secret. A trailing newline, which most editors and
+// `echo` add, is dropped. Errors name where the secret was looked for,
+// never its value.
+func (r SecretRef) Resolve() (string, error) {
+	switch {
+	case r.File != "" && r.Env != "":
+		return "", errors.New("set only one of file and env")
+	case r.File != "":
+		data, err := os.ReadFile(r.File)
+		if err != nil {
+			return "", err
+		}
+		v := strings.TrimRight(string(data), "\r\n")
+		if v == "" {
+			return "", fmt.Errorf("%s is empty", r.File)
+		}
+		return v, nil
+	case r.Env != "":
+		v := os.Getenv(r.Env)
+		if v == "" {
+			return "", fmt.Errorf("environment variable %s is not set", r.Env)
+		}
+		return v, nil
+	}
+	return "", errors.New("one of file or env is required")
+}
+
+// ResolveSecrets reads every secret reference in cfg into the field it
+// stands for: master.auth_token_file/auth_token_env into
+// Master.AuthToken, targets[].secret_headers into the target's Headers
+// and hooks.secrets into Hooks.ResolvedSecrets. Load calls it; commands
+// that build a Config by hand call it themselves.
+func ResolveSecrets(cfg *Config) error {
+	if ref := cfg.Master.authTokenRef(); ref != nil {
+		v, err := ref.Resolve()
+		if err != nil {
+			return fmt.Errorf("master auth token: %w", err)
+		}
+		cfg.Master.AuthToken = v
+	}
+
+	for i := range cfg.Targets {
+		t := &cfg.Targets[i]
+		if len(t.SecretHeaders) == 0 {
+			continue
+		}
+		// A fresh map: Headers may be shared with a caller's copy.
+		headers := make(map[string]string, len(t.Headers)+len(t.SecretHeaders))
+		for k, v := range t.Headers {
+			headers[k] = v
+		}
+		for _, k := range sortedKeys(t.SecretHeaders) {
+			v, err := t.SecretHeaders[k].Resolve()
+			if err != nil {
+				return fmt.Errorf("target %q secret header %s: %w", t.Name, k, err)
+			}
+			headers[k] = v
+		}
+		t.Headers = headers
+	}
+
+	if len(cfg.Hooks.Secrets) > 0 {
+		resolved := make(map[string]string, len(cfg.Hooks.Secrets))
+		for _, k := range sortedKeys(cfg.Hooks.Secrets) {
+			v, err := cfg.Hooks.Secrets[k].Resolve()
+			if err != nil {
+				return fmt.Errorf("hooks secret %s: %w", k, err)
+			}
+			resolved[k] = v
+		}
+		cfg.Hooks.ResolvedSecrets = resolved
+	}
+	return nil
+}
+
+// authTokenRef returns the configured reference for the auth token, or
+// nil when it is inline or unset.
+func (m Master) authTokenRef() *SecretRef {
+	if m.AuthTokenFile == "" && m.AuthTokenEnv == "" {
+		return nil
+	}
+	return &SecretRef{File: m.AuthTokenFile, Env: m.AuthTokenEnv}
+}
+
+// sensitiveHeaders are masked by Redacted even when set inline.
+var sensitiveHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"x-api-key":           true,
+	"x-auth-token":        true,
+}
+
+// Redacted returns a copy of cfg that is safe to print, log or save:
+// the auth token, secret headers, well-known credential headers and
+// resolved hook secrets are replaced by RedactedValue. cfg is not
+// modified.
+func (cfg *Config) Redacted() *Config {
+	out := *cfg
+	if out.Master.AuthToken != "" {
+		out.Master.AuthToken = RedactedValue
+	}
+
+	out.Targets = make([]Target, len(cfg.Targets))
+	for i, t := range cfg.Targets {
+		t.Headers = redactHeaders(t.Headers, t.SecretHeaders)
+		if len(t.Requests) > 0 {
+			reqs := make([]RequestSpec, len(t.Requests))
+			for j, r := range t.Requests {
+				r.Headers = redactHeaders(r.Headers, nil)
+				reqs[j] = r
+			}
+			t.Requests = reqs
+		}
+		out.Targets[i] = t
+	}
+
+	if len(cfg.Hooks.ResolvedSecrets) > 0 {
+		masked := make(map[string]string, len(cfg.Hooks.ResolvedSecrets))
+		for k := range cfg.Hooks.ResolvedSecrets {
+			masked[k] = RedactedValue
+		}
+		out.Hooks.ResolvedSecrets = masked
+	}
+	return &out
+}
+
+func redactHeaders(headers map[string]string, secret map[string]SecretRef) map[string]string {
+	if len(headers) == 0 {
+		return headers
+	}
+	out := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if _, ok := secret[k]; ok || sensitiveHeaders[strings.ToLower(k)] {
+			v = RedactedValue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func sortedKeys(m map[string]SecretRef) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad_ResolvesSecrets(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KAR_TEST_API_KEY", "Bearer abc")
+	t.Setenv("KAR_TEST_SIGNING_KEY", "sign")
+
+	path := filepath.Join(dir, "kar.yaml")
+	yml := `
+targets:
+  - name: api
+    url: http://localhost:8080/
+    headers:
+      Accept: application/json
+    secret_headers:
+      Authorization: {env: KAR_TEST_API_KEY}
+master:
+  auth_token_file: ` + tokenFile + `
+hooks:
+  secrets:
+    signing_key: {env: KAR_TEST_SIGNING_KEY}
+`
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Master.AuthToken != "s3cret" {
+		t.Errorf("auth token = %q, want the file contents without the newline", cfg.Master.AuthToken)
+	}
+	h := cfg.Targets[0].Headers
+	if h["Authorization"] != "Bearer abc" || h["Accept"] != "application/json" {
+		t.Errorf("headers = %v", h)
+	}
+	if cfg.Hooks.ResolvedSecrets["signing_key"] != "sign" {
+		t.Errorf("hook secrets = %v", cfg.Hooks.ResolvedSecrets)
+	}
+
+	r := cfg.Redacted()
+	if r.Master.AuthToken != RedactedValue || r.Targets[0].Headers["Authorization"] != RedactedValue ||
+		r.Hooks.ResolvedSecrets["signing_key"] != RedactedValue {
+		t.Errorf("secrets not redacted: %+v %v", r.Master, r.Targets[0].Headers)
+	}
+	if r.Targets[0].Headers["Accept"] != "application/json" {
+		t.Errorf("non-secret header redacted: %v", r.Targets[0].Headers)
+	}
+	if cfg.Master.AuthToken != "s3cret" || h["Authorization"] != "Bearer abc" {
+		t.Error("Redacted modified the original config")
+	}
+}
+
+func TestLoad_UnresolvedSecretFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kar.yaml")
+	yml := `
+targets:
+  - name: api
+    url: http://localhost:8080/
+master:
+  auth_token_env: KAR_TEST_UNSET_TOKEN
+`
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "KAR_TEST_UNSET_TOKEN") {
+		t.Fatalf("err = %v, want it to name the unset variable", err)
+	}
+}
+
+func TestValidateConfig_Secrets(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Master.AuthToken = "inline-token"
+	issues := ValidateConfig(cfg)
+	if HasErrors(issues) {
+		t.Fatalf("inline token should only warn: %+v", issues)
+	}
+	for _, iss := range issues {
+		if strings.Contains(iss.Message, "inline-token") {
+			t.Fatalf("issue echoes the secret: %+v", iss)
+		}
+	}
+
+	cfg.Master.AuthTokenEnv = "KAR_TEST_TOKEN"
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("expected error for both auth_token and auth_token_env")
+	}
+
+	cfg = goodConfig()
+	cfg.Targets[0].SecretHeaders = map[string]SecretRef{
+		"Authorization": {File: "/nonexistent/token"},
+	}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("expected error for a missing secret file")
+	}
+	cfg.Targets[0].SecretHeaders["Authorization"] = SecretRef{File: "/a", Env: "B"}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("expected error for a secret with both file and env")
+	}
+}
+
+func TestFingerprint_StableAndIgnoresSecretsAndOutputs(t *testing.T) {
+	a := goodConfig()
+	base := a.Fingerprint()
+	if base.Hash == "" || base.BaseTPS != a.Controller.BaseTPS || len(base.Targets) != 1 {
+		t.Fatalf("unexpected fingerprint %+v", base)
+	}
+	if again := goodConfig().Fingerprint(); again.Hash != base.Hash {
+		t.Fatalf("equal configs hashed differently: %s vs %s", base.Hash, again.Hash)
+	}
+
+	// A resolved secret value must not leak into or move the hash.
+	b := goodConfig()
+	b.Targets[0].SecretHeaders = map[string]SecretRef{"Authorization": {Env: "X"}}
+	b.Targets[0].Headers = map[string]string{"Authorization": "Bearer one"}
+	c := goodConfig()
+	c.Targets[0].SecretHeaders = b.Targets[0].SecretHeaders
+	c.Targets[0].Headers = map[string]string{"Authorization": "Bearer two"}
+	if b.Fingerprint().Hash != c.Fingerprint().Hash {
+		t.Fatal("rotating a secret changed the fingerprint")
+	}
+
+	d := goodConfig()
+	d.Output = []OutputSink{{Type: OutputJSON, Path: "/tmp/elsewhere.json"}}
+	if d.Fingerprint().Hash != base.Hash {
+		t.Fatal("output path changed the fingerprint")
+	}
+
+	e := goodConfig()
+	e.Controller.BaseTPS *= 2
+	if e.Fingerprint().Hash == base.Hash {
+		t.Fatal("base_tps change kept the fingerprint")
+	}
+}
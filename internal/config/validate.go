@@ -3,10 +3,21 @@ package config
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/kar98k/internal/dataset"
+	"github.com/kar98k/internal/template"
+	"github.com/kar98k/pkg/protocol"
 )
 
 // Severity classifies how seriously an issue should be treated.
@@ -58,294 +69,1923 @@ func ValidateConfig(cfg *Config) []Issue {
 	out = append(out, validateController(cfg)...)
 	out = append(out, validatePattern(cfg)...)
 	out = append(out, validateWorker(cfg)...)
+	out = append(out, validateFaultInject(cfg.Worker.FaultInject)...)
+	out = append(out, validateKeepAlive(cfg.Worker.KeepAlive)...)
 	out = append(out, validateSchedule(cfg)...)
 	out = append(out, validateScenarios(cfg)...)
 	out = append(out, validateSafety(cfg)...)
+	out = append(out, validateHealth(cfg)...)
+	out = append(out, validateIntentCheck(cfg)...)
+	out = append(out, validateOutput(cfg)...)
+	out = append(out, validateReport(cfg)...)
+	out = append(out, validateHooks(cfg)...)
+	out = append(out, validateSecrets(cfg)...)
+	out = append(out, validateMetrics(cfg)...)
+	out = append(out, validatePostChecks(cfg)...)
+	out = append(out, validateControl(cfg.Control)...)
 
 	return out
 }
 
-// validateSafety checks the optional circuit-breaker block. When
-// disabled we skip; when enabled at least one threshold must be set
-// and SustainedFor must be positive.
-func validateSafety(cfg *Config) []Issue {
-	s := cfg.Safety
-	if !s.Enabled {
-		return nil
+// validateControl checks the control channel (#1242): a known
+// transport, abstract sockets only where they exist, and TCP only on
+// loopback since anyone who can connect can stop the run.
+func validateControl(c Control) []Issue {
+	switch c.Kind() {
+	case ControlUnix:
+	case ControlAbstract:
+		if runtime.GOOS != "linux" {
+			return []Issue{{
+				Path:       "control.transport",
+				Severity:   SeverityError,
+				Message:    "abstract sockets are Linux only",
+				Suggestion: "use unix or tcp",
+			}}
+		}
+	case ControlTCP:
+		host, _, err := net.SplitHostPort(c.TCPAddress())
+		if err != nil {
+			return []Issue{{Path: "control.address", Severity: SeverityError, Message: fmt.Sprintf("invalid address %q: %v", c.Address, err)}}
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return []Issue{{
+				Path:       "control.address",
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("control address %s is not loopback; the control channel has no authentication", c.Address),
+				Suggestion: "use 127.0.0.1:PORT",
+			}}
+		}
+	default:
+		return []Issue{{
+			Path:     "control.transport",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("unknown transport %q; want unix, abstract or tcp", c.Transport),
+		}}
+	}
+	if c.Address != "" && c.Kind() != ControlTCP {
+		return []Issue{{Path: "control.address", Severity: SeverityWarning, Message: "address only applies to the tcp transport"}}
 	}
+	return nil
+}
+
+// validatePostChecks checks each post_checks entry (#1241): a unique
+// name, an http(s) URL and comparisons that can be evaluated.
+func validatePostChecks(cfg *Config) []Issue {
 	var out []Issue
-	if s.ErrorRateAbove == 0 && s.P95LatencyAbove == 0 {
-		out = append(out, Issue{
-			Path:     "safety",
-			Severity: SeverityError,
-			Message:  "safety.enabled = true but no thresholds set (error_rate_above or p95_latency_above)",
-		})
+	seen := make(map[string]bool)
+	for i, c := range cfg.PostChecks {
+		path := fmt.Sprintf("post_checks[%d]", i)
+		switch {
+		case c.Name == "":
+			out = append(out, Issue{Path: path + ".name", Severity: SeverityError, Message: "name is required"})
+		case seen[c.Name]:
+			out = append(out, Issue{Path: path + ".name", Severity: SeverityError, Message: fmt.Sprintf("duplicate post check name %q", c.Name)})
+		}
+		seen[c.Name] = true
+		if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			out = append(out, Issue{
+				Path:       path + ".url",
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("url must be an absolute http(s) URL, got %q", c.URL),
+				Suggestion: "e.g. http://localhost:8080/api/stats",
+			})
+		}
+		if c.Timeout < 0 {
+			out = append(out, Issue{Path: path + ".timeout", Severity: SeverityError, Message: "timeout must be non-negative"})
+		}
+		for _, code := range c.Status {
+			if code < 100 || code > 599 {
+				out = append(out, Issue{Path: path + ".status", Severity: SeverityError, Message: fmt.Sprintf("%d is not an HTTP status code", code)})
+			}
+		}
+		for j, jc := range c.JSON {
+			jpath := fmt.Sprintf("%s.json[%d]", path, j)
+			if jc.Path == "" {
+				out = append(out, Issue{Path: jpath + ".path", Severity: SeverityError, Message: "path is required"})
+			}
+			op := jc.Operator()
+			if !slices.Contains(JSONCheckOps, op) {
+				out = append(out, Issue{
+					Path:       jpath + ".op",
+					Severity:   SeverityError,
+					Message:    fmt.Sprintf("unknown operator %q", op),
+					Suggestion: "use one of " + strings.Join(JSONCheckOps, " "),
+				})
+				continue
+			}
+			if _, err := strconv.ParseFloat(jc.Value, 64); err != nil && op != "==" && op != "!=" {
+				out = append(out, Issue{
+					Path:     jpath + ".value",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("%s needs a number, got %q", op, jc.Value),
+				})
+			}
+		}
 	}
-	if s.ErrorRateAbove < 0 || s.ErrorRateAbove > 100 {
-		out = append(out, Issue{
-			Path:     "safety.error_rate_above",
+	return out
+}
+
+// validateMetrics checks the label cap and warns when the config alone
+// has more targets or request specs than it admits (#1231).
+func validateMetrics(cfg *Config) []Issue {
+	m := cfg.Metrics
+	if m.MaxLabelValues < -1 {
+		return []Issue{{
+			Path:     "metrics.max_label_values",
 			Severity: SeverityError,
-			Message:  "error_rate_above must be in [0, 100]",
-		})
+			Message:  fmt.Sprintf("max_label_values must be a count, or -1 for no cap; got %d", m.MaxLabelValues),
+		}}
 	}
-	if s.SustainedFor <= 0 {
-		out = append(out, Issue{
-			Path:     "safety.sustained_for",
-			Severity: SeverityError,
-			Message:  "sustained_for must be > 0 — single-sample trips cause flapping",
-		})
+	limit := m.LabelLimit()
+	if limit < 0 {
+		return nil
+	}
+	specs := 0
+	for _, t := range cfg.Targets {
+		if len(t.Requests) > 0 {
+			specs += len(t.Requests)
+		} else {
+			specs += len(t.Methods)
+		}
 	}
-	if s.Webhook != "" {
-		if _, err := url.Parse(s.Webhook); err != nil {
+	var out []Issue
+	for _, c := range []struct {
+		label string
+		n     int
+	}{{"target", len(cfg.Targets)}, {"spec", specs}} {
+		if c.n > limit {
 			out = append(out, Issue{
-				Path:     "safety.webhook",
-				Severity: SeverityError,
-				Message:  fmt.Sprintf("webhook URL parse failed: %v", err),
+				Path:       "metrics.max_label_values",
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("%d %ss but only %d get their own %s label; the rest are reported as \"other\"", c.n, c.label, limit, c.label),
+				Suggestion: fmt.Sprintf("raise max_label_values to %d, or -1 for no cap", c.n),
 			})
 		}
 	}
 	return out
 }
 
-// validateScenarios checks the optional `scenarios:` array. Each phase
-// must have a name, a positive duration, and (if set) sane TPS bounds.
-// Names must be unique so dashboards / metrics can label phases without
-// ambiguity.
-func validateScenarios(cfg *Config) []Issue {
-	if len(cfg.Scenarios) == 0 {
-		return nil
+// validateIntentCheck bounds the relative tolerance and flags a
+// pointless fail_on_deviation.
+func validateIntentCheck(cfg *Config) []Issue {
+	ic := cfg.IntentCheck
+	var out []Issue
+	if ic.Tolerance < 0 || ic.Tolerance >= 1 {
+		out = append(out, Issue{
+			Path:     "intent_check.tolerance",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("tolerance must be in [0, 1), got %v", ic.Tolerance),
+		})
+	}
+	if ic.FailOnDeviation && !ic.Enabled {
+		out = append(out, Issue{
+			Path:       "intent_check.fail_on_deviation",
+			Severity:   SeverityInfo,
+			Message:    "fail_on_deviation has no effect while intent_check is disabled",
+			Suggestion: "set intent_check.enabled: true",
+		})
 	}
+	return out
+}
 
+// validateOutput checks each output sink has a known type and a
+// destination, and that no two sinks write the same file.
+func validateOutput(cfg *Config) []Issue {
 	var out []Issue
-	seen := make(map[string]bool)
-	for i, s := range cfg.Scenarios {
-		path := fmt.Sprintf("scenarios[%d]", i)
-
-		if s.Name == "" {
+	paths := make(map[string]int)
+	samples := -1 // the samples sink's index
+	for i, o := range cfg.Output {
+		path := fmt.Sprintf("output[%d]", i)
+		known := false
+		for _, t := range OutputTypes {
+			if o.Type == t {
+				known = true
+			}
+		}
+		if !known {
 			out = append(out, Issue{
-				Path:     path + ".name",
-				Severity: SeverityError,
-				Message:  "scenario name is required",
+				Path:       path + ".type",
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("unknown output type %q", o.Type),
+				Suggestion: "use one of: " + strings.Join(OutputTypes, ", "),
 			})
-		} else if seen[s.Name] {
+			continue
+		}
+		if o.Endpoint != "" && o.Type != OutputPrometheus {
 			out = append(out, Issue{
-				Path:     path + ".name",
+				Path:     path + ".endpoint",
 				Severity: SeverityError,
-				Message:  fmt.Sprintf("duplicate scenario name %q — names must be unique", s.Name),
+				Message:  fmt.Sprintf("endpoint is only supported by the prometheus sink, not %s", o.Type),
 			})
-		} else {
-			seen[s.Name] = true
 		}
-
-		if s.Duration <= 0 {
+		if o.MaxSize != "" {
+			if o.Type != OutputJSONL && o.Type != OutputSamples {
+				out = append(out, Issue{
+					Path:     path + ".max_size",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("max_size is only supported by the jsonl and samples sinks, not %s", o.Type),
+				})
+			} else if _, err := o.MaxBytes(); err != nil {
+				out = append(out, Issue{
+					Path:       path + ".max_size",
+					Severity:   SeverityError,
+					Message:    err.Error(),
+					Suggestion: `e.g. max_size: "100MB"`,
+				})
+			}
+		}
+		if o.Type == OutputSamples {
+			if samples >= 0 {
+				out = append(out, Issue{
+					Path:     path + ".type",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("only one samples sink is supported, output[%d] is already one", samples),
+				})
+			}
+			samples = i
+			if o.SampleRate < 0 || o.SampleRate > 1 {
+				out = append(out, Issue{
+					Path:       path + ".sample_rate",
+					Severity:   SeverityError,
+					Message:    fmt.Sprintf("sample_rate must be within 0-1, got %g", o.SampleRate),
+					Suggestion: "e.g. sample_rate: 0.1 keeps one request in ten",
+				})
+			}
+			if o.MaxSamples < 0 {
+				out = append(out, Issue{
+					Path:     path + ".max_samples",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("max_samples must not be negative, got %d", o.MaxSamples),
+				})
+			}
+		} else if o.SampleRate != 0 || o.MaxSamples != 0 {
 			out = append(out, Issue{
-				Path:     path + ".duration",
+				Path:     path,
 				Severity: SeverityError,
-				Message:  "duration must be > 0",
+				Message:  fmt.Sprintf("sample_rate and max_samples are only supported by the samples sink, not %s", o.Type),
 			})
 		}
-
-		if s.MaxTPS > 0 && s.BaseTPS > s.MaxTPS {
+		if o.Path == "" && o.Endpoint == "" {
 			out = append(out, Issue{
-				Path:     path + ".max_tps",
+				Path:     path + ".path",
 				Severity: SeverityError,
-				Message:  fmt.Sprintf("base_tps (%.0f) exceeds max_tps (%.0f)", s.BaseTPS, s.MaxTPS),
+				Message:  fmt.Sprintf("%s sink needs a path", o.Type),
 			})
+			continue
+		}
+		if o.Path != "" {
+			if j, dup := paths[o.Path]; dup {
+				out = append(out, Issue{
+					Path:     path + ".path",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("path %q is already written by output[%d]", o.Path, j),
+				})
+			}
+			paths[o.Path] = i
 		}
-
-		out = append(out, validateInject(path, s)...)
 	}
-
 	return out
 }
 
-// validateInject sanity-checks one phase's injection curve. The total
-// duration of all steps must equal the phase duration so users don't
-// silently leave a gap (the runner would otherwise hold the last step's
-// TPS forever, surprising the operator).
-func validateInject(path string, s Scenario) []Issue {
-	if len(s.Inject) == 0 {
+// validateHooks checks the hooks script exists. Its contents are only
+// compiled when the daemon starts.
+func validateHooks(cfg *Config) []Issue {
+	script := cfg.Hooks.Script
+	if script == "" {
 		return nil
 	}
+	if _, err := os.Stat(script); err != nil {
+		return []Issue{{
+			Path:     "hooks.script",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("hooks script: %v", err),
+		}}
+	}
+	return []Issue{{
+		Path:       "hooks.script",
+		Severity:   SeverityInfo,
+		Message:    "hooks run on every request and cap per-worker throughput",
+		Suggestion: "check achieved TPS against a run without hooks",
+	}}
+}
 
+// validateSecrets checks that every secret reference resolves (#1204).
+// Messages name the file or variable, never the value.
+func validateSecrets(cfg *Config) []Issue {
 	var out []Issue
-	var total time.Duration
-	for i, step := range s.Inject {
-		stepPath := fmt.Sprintf("%s.inject[%d]", path, i)
-		if step.Duration <= 0 {
+	check := func(path string, ref SecretRef) {
+		if _, err := ref.Resolve(); err != nil {
 			out = append(out, Issue{
-				Path:     stepPath + ".duration",
+				Path:     path,
 				Severity: SeverityError,
-				Message:  "step duration must be > 0",
+				Message:  fmt.Sprintf("secret: %v", err),
 			})
 		}
-		switch step.Type {
-		case InjectNothingFor:
-			// no extra fields required
-		case InjectConstantTPS:
-			if step.TPS < 0 {
+	}
+
+	m := cfg.Master
+	set := 0
+	for _, v := range []string{m.AuthToken, m.AuthTokenFile, m.AuthTokenEnv} {
+		if v != "" {
+			set++
+		}
+	}
+	switch {
+	case set > 1:
+		out = append(out, Issue{
+			Path:     "master.auth_token",
+			Severity: SeverityError,
+			Message:  "set only one of auth_token, auth_token_file and auth_token_env",
+		})
+	case m.AuthToken != "":
+		out = append(out, Issue{
+			Path:       "master.auth_token",
+			Severity:   SeverityWarning,
+			Message:    "auth token is written inline in the config",
+			Suggestion: "use auth_token_file or auth_token_env to keep it out of the file",
+		})
+	case m.AuthTokenFile != "":
+		check("master.auth_token_file", *m.authTokenRef())
+	case m.AuthTokenEnv != "":
+		check("master.auth_token_env", *m.authTokenRef())
+	}
+
+	for i, t := range cfg.Targets {
+		for _, k := range sortedKeys(t.SecretHeaders) {
+			path := fmt.Sprintf("targets[%d].secret_headers.%s", i, k)
+			check(path, t.SecretHeaders[k])
+			if _, dup := t.Headers[k]; dup {
 				out = append(out, Issue{
-					Path:     stepPath + ".tps",
-					Severity: SeverityError,
-					Message:  "constant_tps requires tps >= 0",
+					Path:     path,
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("%q is also set in headers; the secret value wins", k),
 				})
 			}
-		case InjectRampTPS, InjectHeavisideTPS:
-			if step.From < 0 || step.To < 0 {
+		}
+	}
+
+	if len(cfg.Hooks.Secrets) > 0 && cfg.Hooks.Script == "" {
+		out = append(out, Issue{
+			Path:     "hooks.secrets",
+			Severity: SeverityInfo,
+			Message:  "hooks.secrets is unused without hooks.script",
+		})
+	}
+	for _, k := range sortedKeys(cfg.Hooks.Secrets) {
+		check("hooks.secrets."+k, cfg.Hooks.Secrets[k])
+	}
+	return out
+}
+
+// maxSlowest is where report.slowest starts to cost on the hot path.
+const maxSlowest = 1000
+
+// maxReportSlots is the timeline length report.interval warns above.
+const maxReportSlots = 100000
+
+// validateReport checks the segment window, outlier count and SLO
+// thresholds.
+func validateReport(cfg *Config) []Issue {
+	r := cfg.Report
+	var out []Issue
+	switch {
+	case r.SegmentWindow < 0:
+		out = append(out, Issue{
+			Path:     "report.segment_window",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("segment_window must be positive, got %v", r.SegmentWindow),
+		})
+	case r.SegmentWindow > 0 && r.SegmentWindow < time.Minute:
+		// Each segment keeps its own HdrHistogram; second-sized windows
+		// over a multi-hour run add up.
+		out = append(out, Issue{
+			Path:       "report.segment_window",
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("segment_window %v keeps one histogram per window; long runs will hold many", r.SegmentWindow),
+			Suggestion: "use 1m or more",
+		})
+	}
+	if r.MinSamples < -1 {
+		out = append(out, Issue{
+			Path:     "report.min_samples",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("min_samples must be a count, or -1 to report every percentile; got %d", r.MinSamples),
+		})
+	}
+	if r.Slowest < 0 {
+		out = append(out, Issue{
+			Path:     "report.slowest",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("slowest must be >= 0, got %d", r.Slowest),
+		})
+	} else if r.Slowest > maxSlowest {
+		out = append(out, Issue{
+			Path:       "report.slowest",
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("slowest %d is large; every request slower than the current floor takes a lock", r.Slowest),
+			Suggestion: fmt.Sprintf("keep it at %d or below", maxSlowest),
+		})
+	}
+	switch {
+	case r.Interval < 0 || (r.Interval > 0 && r.Interval < MinReportInterval):
+		out = append(out, Issue{
+			Path:     "report.interval",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("interval must be at least %v, got %v", MinReportInterval, r.Interval),
+		})
+	case r.Interval > 0:
+		// With scenarios the run length is known up front; warn when the
+		// timeline would grow past what a report can usefully show.
+		var total time.Duration
+		for _, s := range cfg.Scenarios {
+			total += s.Duration
+		}
+		if slots := int64(total / r.Interval); slots > maxReportSlots {
+			out = append(out, Issue{
+				Path:       "report.interval",
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("interval %v over a %v run is %d timeline slots", r.Interval, total, slots),
+				Suggestion: fmt.Sprintf("use %v or more", (total/maxReportSlots).Round(time.Second)+time.Second),
+			})
+		}
+	}
+	out = append(out, validateSLO("report.slo", r.SLO)...)
+	if g := r.Regression; g.Latency < 0 || g.LatencyFloor < 0 || g.ErrorRate < 0 || g.TPS < 0 {
+		out = append(out, Issue{
+			Path:     "report.regression",
+			Severity: SeverityError,
+			Message:  "regression tolerances must not be negative",
+		})
+	} else if g.TPS >= 1 {
+		out = append(out, Issue{
+			Path:     "report.regression.tps",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("tps tolerance %v allows any drop; it is a fraction (0.1 = 10%%)", g.TPS),
+		})
+	}
+	if r.Snapshot != "" && r.Snapshot != "-" {
+		if dir := filepath.Dir(r.Snapshot); dir != "." {
+			if _, err := os.Stat(dir); err != nil {
 				out = append(out, Issue{
-					Path:     stepPath,
+					Path:     "report.snapshot",
 					Severity: SeverityError,
-					Message:  fmt.Sprintf("%s requires from/to >= 0", step.Type),
+					Message:  fmt.Sprintf("snapshot directory: %v", err),
 				})
 			}
-		case "":
+		}
+		if !strings.Contains(r.Snapshot, "{time}") {
 			out = append(out, Issue{
-				Path:     stepPath + ".type",
-				Severity: SeverityError,
-				Message:  "inject step type is required (nothing_for | constant_tps | ramp_tps | heaviside_tps)",
+				Path:       "report.snapshot",
+				Severity:   SeverityInfo,
+				Message:    "every snapshot overwrites the previous one",
+				Suggestion: "add {time} to the path to keep them all",
 			})
+		}
+	}
+	if r.Retention.KeepLast < -1 {
+		out = append(out, Issue{
+			Path:     "report.retention.keep_last",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("keep_last must be a count, or -1 to keep everything; got %d", r.Retention.KeepLast),
+		})
+	}
+	if r.Retention.MaxAge < 0 {
+		out = append(out, Issue{
+			Path:     "report.retention.max_age",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("max_age must not be negative, got %v", r.Retention.MaxAge),
+		})
+	}
+	if !ValidLatencyUnit(r.Latency.Unit) {
+		out = append(out, Issue{
+			Path:       "report.latency.unit",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("unknown latency unit %q", r.Latency.Unit),
+			Suggestion: "use auto, us, ms or s",
+		})
+	}
+	if p := r.Latency.Precision; p != nil && (*p < 0 || *p > maxLatencyPrecision) {
+		out = append(out, Issue{
+			Path:     "report.latency.precision",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("precision must be between 0 and %d decimals, got %d", maxLatencyPrecision, *p),
+		})
+	}
+	switch r.ErrorMatrix {
+	case "", ErrorMatrixClass, ErrorMatrixStatus:
+	default:
+		out = append(out, Issue{
+			Path:       "report.error_matrix",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("unknown error matrix grouping %q", r.ErrorMatrix),
+			Suggestion: "use class or status",
+		})
+	}
+	if _, ok := r.Tags[""]; ok {
+		out = append(out, Issue{
+			Path:     "report.tags",
+			Severity: SeverityError,
+			Message:  "tag names must not be empty",
+		})
+	}
+	if sl := r.SizeLatency; sl.Enabled {
+		switch sl.Measure() {
+		case SizeByResponse, SizeByRequest, SizeByTotal:
 		default:
 			out = append(out, Issue{
-				Path:     stepPath + ".type",
+				Path:     "report.size_latency.by",
 				Severity: SeverityError,
-				Message:  fmt.Sprintf("unknown inject step type %q", step.Type),
+				Message:  fmt.Sprintf("by must be %q, %q or %q, got %q", SizeByResponse, SizeByRequest, SizeByTotal, sl.By),
 			})
 		}
-		total += step.Duration
+		if _, _, err := sl.Bounds(); err != nil {
+			out = append(out, Issue{
+				Path:       "report.size_latency.buckets",
+				Severity:   SeverityError,
+				Message:    err.Error(),
+				Suggestion: "list ascending upper bounds, e.g. [1KB, 64KB, 1MB]",
+			})
+		}
+	}
+	return out
+}
+
+// maxLatencyPrecision is past anything the histograms resolve.
+const maxLatencyPrecision = 6
+
+// validateHealth checks per-cause failure thresholds: keys must name a
+// known cause and counts must be at least 1. It also checks the
+// all-targets-unhealthy policy.
+func validateHealth(cfg *Config) []Issue {
+	var out []Issue
+	switch cfg.Health.OnAllUnhealthy {
+	case "", AllUnhealthyPause, AllUnhealthyProbe:
+	default:
+		out = append(out, Issue{
+			Path:       "health.on_all_unhealthy",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("unknown on_all_unhealthy %q", cfg.Health.OnAllUnhealthy),
+			Suggestion: "use pause or probe",
+		})
+	}
+	if cfg.Health.ProbeTPS < 0 {
+		out = append(out, Issue{
+			Path:     "health.probe_tps",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("probe_tps must be >= 0, got %v", cfg.Health.ProbeTPS),
+		})
+	} else if cfg.Health.ProbeTPS > 0 && cfg.Health.UnhealthyAction() != AllUnhealthyProbe {
+		out = append(out, Issue{
+			Path:       "health.probe_tps",
+			Severity:   SeverityInfo,
+			Message:    "probe_tps only applies when on_all_unhealthy is probe",
+			Suggestion: "set on_all_unhealthy: probe",
+		})
+	}
+	known := make(map[HealthFailure]bool, len(HealthFailures))
+	for _, f := range HealthFailures {
+		known[f] = true
+	}
+	for cause, n := range cfg.Health.FailureThresholds {
+		path := fmt.Sprintf("health.failure_thresholds.%s", cause)
+		if !known[cause] {
+			out = append(out, Issue{
+				Path:       path,
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("unknown failure cause %q is ignored", cause),
+				Suggestion: fmt.Sprintf("use one of %v", HealthFailures),
+			})
+		}
+		if n < 1 {
+			out = append(out, Issue{
+				Path:     path,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("threshold must be >= 1, got %d", n),
+			})
+		}
+	}
+	return out
+}
+
+// validateSafety checks the optional circuit-breaker block. When
+// disabled we skip; when enabled at least one threshold must be set
+// and SustainedFor must be positive.
+func validateSafety(cfg *Config) []Issue {
+	s := cfg.Safety
+	out := validateCliff(s.Cliff)
+	if !s.Enabled {
+		if s.Cliff.Enabled {
+			out = append(out, validateWebhook(s.Webhook)...)
+		}
+		return out
+	}
+	if s.ErrorRateAbove == 0 && s.P95LatencyAbove == 0 {
+		out = append(out, Issue{
+			Path:     "safety",
+			Severity: SeverityError,
+			Message:  "safety.enabled = true but no thresholds set (error_rate_above or p95_latency_above)",
+		})
+	}
+	if s.ErrorRateAbove < 0 || s.ErrorRateAbove > 100 {
+		out = append(out, Issue{
+			Path:     "safety.error_rate_above",
+			Severity: SeverityError,
+			Message:  "error_rate_above must be in [0, 100]",
+		})
+	}
+	if s.SustainedFor <= 0 {
+		out = append(out, Issue{
+			Path:     "safety.sustained_for",
+			Severity: SeverityError,
+			Message:  "sustained_for must be > 0 — single-sample trips cause flapping",
+		})
+	}
+	return append(out, validateWebhook(s.Webhook)...)
+}
+
+func validateWebhook(webhook string) []Issue {
+	if webhook == "" {
+		return nil
+	}
+	if _, err := url.Parse(webhook); err != nil {
+		return []Issue{{
+			Path:     "safety.webhook",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("webhook URL parse failed: %v", err),
+		}}
+	}
+	return nil
+}
+
+// validateCliff checks safety.cliff (#1252).
+func validateCliff(c Cliff) []Issue {
+	if !c.Enabled {
+		return nil
+	}
+	var out []Issue
+	if c.Window < 0 {
+		out = append(out, Issue{Path: "safety.cliff.window", Severity: SeverityError, Message: "window must be >= 0"})
+	} else if c.Window > 0 && c.Window < time.Second {
+		out = append(out, Issue{
+			Path:       "safety.cliff.window",
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("a %s window holds few requests, so its P95 is noisy", c.Window),
+			Suggestion: "use a window of a few seconds",
+		})
+	}
+	if c.Baseline < 0 {
+		out = append(out, Issue{Path: "safety.cliff.baseline", Severity: SeverityError, Message: "baseline must be >= 0"})
+	}
+	if c.Factor != 0 && c.Factor <= 1 {
+		out = append(out, Issue{
+			Path:     "safety.cliff.factor",
+			Severity: SeverityError,
+			Message:  "factor must be > 1: a cliff is a jump above the baseline",
+		})
+	}
+	if c.MinP95 < 0 {
+		out = append(out, Issue{Path: "safety.cliff.min_p95", Severity: SeverityError, Message: "min_p95 must be >= 0"})
+	}
+	switch c.Action {
+	case "", CliffAlert, CliffPause, CliffAbort:
+	default:
+		out = append(out, Issue{
+			Path:     "safety.cliff.action",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("unknown action %q (use %s, %s or %s)", c.Action, CliffAlert, CliffPause, CliffAbort),
+		})
+	}
+	return out
+}
+
+// validateScenarios checks the optional `scenarios:` array. Each phase
+// must have a name, a positive duration, and (if set) sane TPS bounds.
+// Names must be unique so dashboards / metrics can label phases without
+// ambiguity.
+func validateScenarios(cfg *Config) []Issue {
+	if len(cfg.Scenarios) == 0 {
+		return nil
+	}
+
+	var out []Issue
+	seen := make(map[string]bool)
+	for i, s := range cfg.Scenarios {
+		path := fmt.Sprintf("scenarios[%d]", i)
+
+		if s.Name == "" {
+			out = append(out, Issue{
+				Path:     path + ".name",
+				Severity: SeverityError,
+				Message:  "scenario name is required",
+			})
+		} else if seen[s.Name] {
+			out = append(out, Issue{
+				Path:     path + ".name",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("duplicate scenario name %q — names must be unique", s.Name),
+			})
+		} else {
+			seen[s.Name] = true
+		}
+
+		if s.Duration <= 0 {
+			out = append(out, Issue{
+				Path:     path + ".duration",
+				Severity: SeverityError,
+				Message:  "duration must be > 0",
+			})
+		}
+
+		if s.MaxTPS > 0 && s.BaseTPS > s.MaxTPS {
+			out = append(out, Issue{
+				Path:     path + ".max_tps",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("base_tps (%.0f) exceeds max_tps (%.0f)", s.BaseTPS, s.MaxTPS),
+			})
+		}
+
+		out = append(out, validateInject(path, s)...)
+		if s.SLO != nil {
+			out = append(out, validateSLO(path+".slo", *s.SLO)...)
+		}
+	}
+
+	return out
+}
+
+// validateSLO checks the SLO block at path: report.slo or a phase's.
+func validateSLO(path string, slo SLO) []Issue {
+	var out []Issue
+	if slo.P95Latency < 0 || slo.P99Latency < 0 {
+		out = append(out, Issue{
+			Path:     path,
+			Severity: SeverityError,
+			Message:  "SLO latencies must not be negative",
+		})
+	}
+	if slo.ErrorRate < 0 || slo.ErrorRate > 100 {
+		out = append(out, Issue{
+			Path:     path + ".error_rate",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("error_rate is a percentage in [0, 100], got %v", slo.ErrorRate),
+		})
+	}
+	return out
+}
+
+// validateInject sanity-checks one phase's injection curve. The total
+// duration of all steps must equal the phase duration so users don't
+// silently leave a gap (the runner would otherwise hold the last step's
+// TPS forever, surprising the operator).
+func validateInject(path string, s Scenario) []Issue {
+	if len(s.Inject) == 0 {
+		return nil
+	}
+
+	var out []Issue
+	var total time.Duration
+	for i, step := range s.Inject {
+		stepPath := fmt.Sprintf("%s.inject[%d]", path, i)
+		if step.Duration <= 0 {
+			out = append(out, Issue{
+				Path:     stepPath + ".duration",
+				Severity: SeverityError,
+				Message:  "step duration must be > 0",
+			})
+		}
+		switch step.Type {
+		case InjectNothingFor:
+			// no extra fields required
+		case InjectConstantTPS:
+			if step.TPS < 0 {
+				out = append(out, Issue{
+					Path:     stepPath + ".tps",
+					Severity: SeverityError,
+					Message:  "constant_tps requires tps >= 0",
+				})
+			}
+		case InjectRampTPS, InjectHeavisideTPS:
+			if step.From < 0 || step.To < 0 {
+				out = append(out, Issue{
+					Path:     stepPath,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("%s requires from/to >= 0", step.Type),
+				})
+			}
+		case "":
+			out = append(out, Issue{
+				Path:     stepPath + ".type",
+				Severity: SeverityError,
+				Message:  "inject step type is required (nothing_for | constant_tps | ramp_tps | heaviside_tps)",
+			})
+		default:
+			out = append(out, Issue{
+				Path:     stepPath + ".type",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("unknown inject step type %q", step.Type),
+			})
+		}
+		total += step.Duration
+	}
+
+	if s.Duration > 0 && total != s.Duration {
+		out = append(out, Issue{
+			Path:     path + ".inject",
+			Severity: SeverityError,
+			Message: fmt.Sprintf("inject steps total %s but phase duration is %s — they must match",
+				total, s.Duration),
+		})
+	}
+
+	return out
+}
+
+func validateTargets(cfg *Config) []Issue {
+	var out []Issue
+	if len(cfg.Targets) == 0 {
+		return []Issue{{
+			Path:     "targets",
+			Severity: SeverityError,
+			Message:  "at least one target is required",
+		}}
+	}
+
+	seen := make(map[string]int)
+	for i, t := range cfg.Targets {
+		path := fmt.Sprintf("targets[%d]", i)
+		if t.Name == "" {
+			out = append(out, Issue{Path: path + ".name", Severity: SeverityError, Message: "name is required"})
+		} else if prev, ok := seen[t.Name]; ok {
+			out = append(out, Issue{
+				Path:     path + ".name",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("duplicate name %q (also at targets[%d])", t.Name, prev),
+			})
+		} else {
+			seen[t.Name] = i
+		}
+		if t.URL == "" {
+			out = append(out, Issue{Path: path + ".url", Severity: SeverityError, Message: "url is required"})
+		} else if _, err := url.Parse(t.URL); err != nil {
+			out = append(out, Issue{
+				Path:     path + ".url",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("invalid URL: %v", err),
+			})
+		}
+		if !t.Protocol.Known() {
+			iss := Issue{
+				Path:       path + ".protocol",
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("unknown protocol %q", t.Protocol),
+				Suggestion: fmt.Sprintf("use one of %v", KnownProtocols),
+			}
+			if !cfg.Worker.StrictProtocols() {
+				iss.Severity = SeverityWarning
+				iss.Message += "; requests fall back to http (worker.strict_protocol: false)"
+			}
+			out = append(out, iss)
+		}
+		if t.Weight < 0 {
+			out = append(out, Issue{
+				Path:     path + ".weight",
+				Severity: SeverityError,
+				Message:  "weight must be non-negative",
+			})
+		}
+		if t.Timeout < 0 {
+			out = append(out, Issue{
+				Path:     path + ".timeout",
+				Severity: SeverityError,
+				Message:  "timeout must be non-negative",
+			})
+		}
+		switch {
+		case t.ConnectTimeout < 0:
+			out = append(out, Issue{
+				Path:     path + ".connect_timeout",
+				Severity: SeverityError,
+				Message:  "connect_timeout must be non-negative",
+			})
+		case t.ConnectTimeout > 0 && t.Protocol == ProtocolGRPC:
+			out = append(out, Issue{
+				Path:     path + ".connect_timeout",
+				Severity: SeverityWarning,
+				Message:  "connect_timeout is ignored for gRPC targets",
+			})
+		case t.ConnectTimeout > 0 && t.Timeout > 0 && t.ConnectTimeout >= t.Timeout:
+			out = append(out, Issue{
+				Path:     path + ".connect_timeout",
+				Severity: SeverityWarning,
+				Message: fmt.Sprintf("connect_timeout (%s) is not below timeout (%s); a slow connect is reported as a response timeout",
+					t.ConnectTimeout, t.Timeout),
+				Suggestion: "set connect_timeout well below timeout, e.g. 1s-3s",
+			})
+		}
+		switch {
+		case t.MaxTotalTime < 0:
+			out = append(out, Issue{
+				Path:     path + ".max_total_time",
+				Severity: SeverityError,
+				Message:  "max_total_time must be non-negative",
+			})
+		case t.MaxTotalTime > 0 && t.Timeout > t.MaxTotalTime:
+			out = append(out, Issue{
+				Path:     path + ".max_total_time",
+				Severity: SeverityWarning,
+				Message: fmt.Sprintf("max_total_time (%s) is shorter than timeout (%s); the timeout never fires",
+					t.MaxTotalTime, t.Timeout),
+			})
+		}
+		switch {
+		case t.ExpectedLatency < 0:
+			out = append(out, Issue{
+				Path:     path + ".expected_latency",
+				Severity: SeverityError,
+				Message:  "expected_latency must be non-negative",
+			})
+		case t.ExpectedLatency > 0 && t.Timeout > 0 && t.ExpectedLatency >= t.Timeout:
+			out = append(out, Issue{
+				Path:     path + ".expected_latency",
+				Severity: SeverityWarning,
+				Message: fmt.Sprintf("expected_latency (%s) is not below timeout (%s); no successful request can exceed it",
+					t.ExpectedLatency, t.Timeout),
+			})
+		}
+		if t.PropagateDeadline && t.Timeout == 0 {
+			out = append(out, Issue{
+				Path:       path + ".propagate_deadline",
+				Severity:   SeverityWarning,
+				Message:    "propagate_deadline has no effect without a timeout",
+				Suggestion: "set timeout so there is a deadline to propagate",
+			})
+		}
+		switch {
+		case t.TimeoutJitter < 0 || t.TimeoutJitter >= 1:
+			out = append(out, Issue{
+				Path:     path + ".timeout_jitter",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("timeout_jitter must be in [0, 1), got %v", t.TimeoutJitter),
+			})
+		case t.TimeoutJitter > 0 && t.Timeout == 0:
+			out = append(out, Issue{
+				Path:     path + ".timeout_jitter",
+				Severity: SeverityWarning,
+				Message:  "timeout_jitter has no effect without a timeout",
+			})
+		}
+		if t.DeadlineHeader != "" && !t.PropagateDeadline {
+			out = append(out, Issue{
+				Path:       path + ".deadline_header",
+				Severity:   SeverityInfo,
+				Message:    "deadline_header is ignored while propagate_deadline is false",
+				Suggestion: "set propagate_deadline: true",
+			})
+		}
+		if (t.RecordTTFB || t.FirstByteOnly) && t.Protocol == ProtocolGRPC {
+			out = append(out, Issue{
+				Path:     path + ".record_ttfb",
+				Severity: SeverityWarning,
+				Message:  "record_ttfb / first_byte_only are HTTP-only and ignored for gRPC targets",
+			})
+		}
+		if t.Protocol == ProtocolGRPC {
+			for k := range t.Headers {
+				if lk := strings.ToLower(k); strings.HasPrefix(lk, "grpc-") || strings.HasPrefix(lk, ":") {
+					out = append(out, Issue{
+						Path:     fmt.Sprintf("%s.headers.%s", path, k),
+						Severity: SeverityWarning,
+						Message:  fmt.Sprintf("%q is a reserved gRPC header and is dropped from call metadata", k),
+					})
+				}
+			}
+		}
+		if cb := t.CacheBust; cb != nil {
+			if cb.Fraction < 0 || cb.Fraction > 1 {
+				out = append(out, Issue{
+					Path:     path + ".cache_bust.fraction",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("fraction must be in [0, 1], got %v", cb.Fraction),
+				})
+			}
+			for j, p := range cb.Paths {
+				if !strings.HasPrefix(p, "/") {
+					out = append(out, Issue{
+						Path:     fmt.Sprintf("%s.cache_bust.paths[%d]", path, j),
+						Severity: SeverityError,
+						Message:  fmt.Sprintf("path %q must start with /", p),
+					})
+				}
+			}
+			if cb.Param != "" && len(cb.Paths) > 0 {
+				out = append(out, Issue{
+					Path:     path + ".cache_bust.param",
+					Severity: SeverityInfo,
+					Message:  "param is ignored when paths is set",
+				})
+			}
+			if t.Protocol == ProtocolGRPC {
+				out = append(out, Issue{
+					Path:     path + ".cache_bust",
+					Severity: SeverityWarning,
+					Message:  "cache_bust is HTTP-only and ignored for gRPC targets",
+				})
+			}
+		}
+		if t.DataFile != nil {
+			out = append(out, validateDataFile(path, t)...)
+		}
+		if t.SyntheticBody != nil {
+			out = append(out, validateSyntheticBody(path, t)...)
+		}
+		out = append(out, validateBodyTemplates(path, t)...)
+		if t.TrackHeader != "" {
+			switch {
+			case t.CacheBust != nil && t.CacheBust.StatusHeader != "":
+				out = append(out, Issue{
+					Path:     path + ".track_header",
+					Severity: SeverityError,
+					Message:  "track_header can't be combined with cache_bust.status_header",
+				})
+			case t.Protocol.GRPCStatus():
+				out = append(out, Issue{
+					Path:     path + ".track_header",
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("track_header is HTTP-only and ignored for %s targets", t.Protocol),
+				})
+			}
+		}
+		if t.Expect != nil {
+			out = append(out, validateExpect(path, t)...)
+		}
+		if t.Duplicates != nil {
+			out = append(out, validateDuplicates(path, t)...)
+		}
+		if t.GRPC != nil {
+			out = append(out, validateGRPCCall(path, t)...)
+		}
+		if lp := t.LongPoll; lp != nil {
+			if lp.Concurrency <= 0 {
+				out = append(out, Issue{
+					Path:     path + ".long_poll.concurrency",
+					Severity: SeverityError,
+					Message:  "concurrency, the number of polls held open, must be > 0",
+				})
+			}
+			if lp.Gap < 0 {
+				out = append(out, Issue{Path: path + ".long_poll.gap", Severity: SeverityError, Message: "gap must be >= 0"})
+			}
+			if t.Protocol == ProtocolGRPC {
+				out = append(out, Issue{
+					Path:     path + ".long_poll",
+					Severity: SeverityError,
+					Message:  "long_poll is HTTP-only",
+				})
+			}
+		}
+		switch {
+		case t.MaxConnsPerHost < 0:
+			out = append(out, Issue{
+				Path:     path + ".max_conns_per_host",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("max_conns_per_host must be >= 0, got %d", t.MaxConnsPerHost),
+			})
+		case t.MaxConnsPerHost > 0 && t.Protocol != ProtocolHTTP && t.Protocol != "":
+			out = append(out, Issue{
+				Path:     path + ".max_conns_per_host",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("max_conns_per_host only applies to http targets; %s multiplexes requests over shared connections", t.Protocol),
+			})
+			if t.Protocol == ProtocolHTTP2 && !t.HTTP2.Set() {
+				out[len(out)-1].Suggestion = "use http2.connections and http2.max_streams"
+			}
+		}
+		out = append(out, validateHTTP2(path+".http2", t)...)
+		switch {
+		case t.Pipeline < 0:
+			out = append(out, Issue{
+				Path:     path + ".pipeline",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("pipeline must be >= 0, got %d", t.Pipeline),
+			})
+		case t.Pipeline > 1 && t.Protocol != ProtocolHTTP && t.Protocol != "":
+			out = append(out, Issue{
+				Path:     path + ".pipeline",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("pipeline is HTTP/1.1-only; %s multiplexes requests instead", t.Protocol),
+			})
+		case t.Pipeline > 1:
+			if t.MaxConnsPerHost > 0 {
+				out = append(out, Issue{
+					Path:     path + ".max_conns_per_host",
+					Severity: SeverityWarning,
+					Message:  "max_conns_per_host is ignored for pipelined targets, which open a connection whenever the open ones are full",
+				})
+			}
+			if t.FirstByteOnly || t.LongPoll != nil {
+				out = append(out, Issue{
+					Path:     path + ".pipeline",
+					Severity: SeverityWarning,
+					Message:  "pipelined responses are always read in full; a streaming response blocks every request queued behind it",
+				})
+			}
+			methods := []string{t.Method}
+			for _, spec := range t.Requests {
+				methods = append(methods, spec.Method)
+			}
+			for _, m := range t.Methods {
+				methods = append(methods, m.Method)
+			}
+			for _, m := range methods {
+				if m = strings.ToUpper(m); m != "" && m != "GET" && m != "HEAD" && m != "OPTIONS" {
+					out = append(out, Issue{
+						Path:       path + ".pipeline",
+						Severity:   SeverityWarning,
+						Message:    fmt.Sprintf("pipelining %s requests: if the connection drops, the server may have applied requests kar counts as failed", m),
+						Suggestion: "RFC 9112 advises pipelining only idempotent methods",
+					})
+					break
+				}
+			}
+		}
+		switch {
+		case t.TLSInsecure != nil && t.Protocol == ProtocolGRPC:
+			out = append(out, Issue{
+				Path:     path + ".tls_insecure",
+				Severity: SeverityWarning,
+				Message:  "tls_insecure is ignored for gRPC targets, which connect in plaintext",
+			})
+		case t.SkipTLSVerify(cfg.Worker.TLSInsecure) && strings.HasPrefix(t.URL, "https://"):
+			out = append(out, Issue{
+				Path:       path + ".tls_insecure",
+				Severity:   SeverityWarning,
+				Message:    "certificate verification is off: an expired, self-signed or mismatched certificate won't fail requests",
+				Suggestion: "only skip verification for test endpoints you control",
+			})
+		}
+		if t.Pattern != nil {
+			out = append(out, validatePatternAt(path+".pattern", *t.Pattern, cfg.Controller.BaseTPS)...)
+			if t.Pattern.Noise.PerTarget {
+				out = append(out, Issue{
+					Path:     path + ".pattern.noise.per_target",
+					Severity: SeverityInfo,
+					Message:  "per_target has no effect inside a target's own pattern",
+				})
+			}
+		}
+		out = append(out, validateSuccessCodes(path+".success_codes", t.Protocol, t.SuccessCodes)...)
+		specSeen := make(map[string]bool)
+		for j, r := range t.Requests {
+			rpath := fmt.Sprintf("%s.requests[%d]", path, j)
+			switch {
+			case r.Name == "":
+				out = append(out, Issue{Path: rpath + ".name", Severity: SeverityError, Message: "name is required"})
+			case specSeen[r.Name]:
+				out = append(out, Issue{
+					Path:     rpath + ".name",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("duplicate request name %q", r.Name),
+				})
+			}
+			specSeen[r.Name] = true
+			if r.Weight < 0 {
+				out = append(out, Issue{Path: rpath + ".weight", Severity: SeverityError, Message: "weight must be non-negative"})
+			}
+			out = append(out, validateSuccessCodes(rpath+".success_codes", t.Protocol, r.SuccessCodes)...)
+		}
+		out = append(out, validateMethods(path, t)...)
+	}
+	return out
+}
+
+// validateMethods checks a target's method mix (#1239): each method
+// named once, with a usable weight.
+func validateMethods(path string, t Target) []Issue {
+	if len(t.Methods) == 0 {
+		return nil
+	}
+	if len(t.Requests) > 0 {
+		return []Issue{{
+			Path:       path + ".methods",
+			Severity:   SeverityWarning,
+			Message:    "methods is ignored when requests is set",
+			Suggestion: "give each request spec its own method instead",
+		}}
+	}
+	var out []Issue
+	if t.Protocol.GRPCStatus() {
+		out = append(out, Issue{
+			Path:     path + ".methods",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("methods has no effect on %s targets", t.Protocol),
+		})
+	}
+	seen := make(map[string]bool)
+	for j, m := range t.Methods {
+		mpath := fmt.Sprintf("%s.methods[%d]", path, j)
+		name := strings.ToUpper(m.Method)
+		switch {
+		case name == "":
+			out = append(out, Issue{Path: mpath + ".method", Severity: SeverityError, Message: "method is required"})
+		case seen[name]:
+			out = append(out, Issue{
+				Path:       mpath + ".method",
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("duplicate method %q", name),
+				Suggestion: "use requests to send one method with several bodies",
+			})
+		}
+		seen[name] = true
+		if m.Weight < 0 {
+			out = append(out, Issue{Path: mpath + ".weight", Severity: SeverityError, Message: "weight must be non-negative"})
+		}
+	}
+	return out
+}
+
+// validateExpect checks a target's response assertions.
+func validateExpect(path string, t Target) []Issue {
+	e := t.Expect
+	path += ".expect"
+	var out []Issue
+	if len(e.Status) == 0 && e.BodyContains == "" {
+		out = append(out, Issue{
+			Path:       path,
+			Severity:   SeverityWarning,
+			Message:    "expect asserts nothing",
+			Suggestion: "set status, body_contains or both",
+		})
+	}
+	lo, hi := 100, 599
+	if t.Protocol.GRPCStatus() {
+		lo, hi = 0, 16
+	}
+	for i, code := range e.Status {
+		if code < lo || code > hi {
+			out = append(out, Issue{
+				Path:     fmt.Sprintf("%s.status[%d]", path, i),
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%d is not a %s status code (%d..%d)", code, t.Protocol, lo, hi),
+			})
+		}
+	}
+	if len(e.Status) > 0 && len(t.SuccessCodes) > 0 {
+		out = append(out, Issue{
+			Path:     path + ".status",
+			Severity: SeverityWarning,
+			Message:  "expect.status takes the place of success_codes, which is ignored",
+		})
+	}
+	if e.BodyContains != "" {
+		switch {
+		case t.Protocol.GRPCStatus():
+			out = append(out, Issue{
+				Path:     path + ".body_contains",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("body_contains is HTTP-only; %s responses aren't captured", t.Protocol),
+			})
+		case t.LongPoll != nil:
+			out = append(out, Issue{
+				Path:     path + ".body_contains",
+				Severity: SeverityWarning,
+				Message:  "long polls only check expect.status; body_contains is ignored",
+			})
+		case t.FirstByteOnly:
+			out = append(out, Issue{
+				Path:     path + ".body_contains",
+				Severity: SeverityWarning,
+				Message:  "with first_byte_only set, only the body's first chunk is searched",
+			})
+		}
+	}
+	return out
+}
+
+// validateDuplicates checks a target's duplicate-response check.
+func validateDuplicates(path string, t Target) []Issue {
+	d := t.Duplicates
+	path += ".duplicates"
+	var out []Issue
+	switch {
+	case d.Field == "" && !d.Body && d.Marker == "":
+		out = append(out, Issue{
+			Path:       path,
+			Severity:   SeverityError,
+			Message:    "duplicates checks nothing",
+			Suggestion: "set field, body or marker",
+		})
+	case d.Field != "" && d.Body:
+		out = append(out, Issue{
+			Path:     path + ".body",
+			Severity: SeverityError,
+			Message:  "field and body are two ways to compare responses; set one",
+		})
+	}
+	if d.Field != "" && slices.Contains(strings.Split(d.Field, "."), "") {
+		out = append(out, Issue{
+			Path:     path + ".field",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("field %q has an empty path segment", d.Field),
+		})
+	}
+	if d.MaxTracked < 0 {
+		out = append(out, Issue{
+			Path:     path + ".max_tracked",
+			Severity: SeverityError,
+			Message:  "max_tracked must be >= 0",
+		})
+	}
+	switch {
+	case t.Protocol.GRPCStatus():
+		out = append(out, Issue{
+			Path:     path,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("duplicates is HTTP-only; %s responses aren't captured", t.Protocol),
+		})
+	case t.LongPoll != nil:
+		out = append(out, Issue{
+			Path:     path,
+			Severity: SeverityWarning,
+			Message:  "long polls aren't checked for duplicates; duplicates is ignored",
+		})
+	case t.FirstByteOnly:
+		out = append(out, Issue{
+			Path:     path,
+			Severity: SeverityWarning,
+			Message:  "with first_byte_only set, only the body's first chunk is compared",
+		})
+	}
+	return out
+}
+
+// validateGRPCCall checks a gRPC target's method and, given a
+// protoset, that it describes the method and the body encodes as its
+// request. Bodies that vary per request are left to the run.
+func validateGRPCCall(path string, t Target) []Issue {
+	g := t.GRPC
+	path += ".grpc"
+	if t.Protocol != ProtocolGRPC {
+		msg := fmt.Sprintf("grpc is for gRPC targets, not %s", t.Protocol)
+		if t.Protocol == ProtocolGRPCWeb {
+			msg = "gRPC-Web targets name the method in the URL path, not grpc.method"
+		}
+		return []Issue{{Path: path, Severity: SeverityError, Message: msg}}
+	}
+	if g.Method == "" {
+		return []Issue{{Path: path + ".method", Severity: SeverityError, Message: "method is required, as package.Service/Method"}}
+	}
+	if _, _, err := protocol.SplitGRPCMethod(g.Method); err != nil {
+		return []Issue{{Path: path + ".method", Severity: SeverityError, Message: err.Error()}}
+	}
+	if g.Protoset == "" {
+		return nil
+	}
+	var body []byte
+	if !template.Has(t.Body) && t.DataFile == nil {
+		body = []byte(t.Body)
+	}
+	if err := protocol.ValidateGRPCCall(g.Protoset, g.Method, body); err != nil {
+		return []Issue{{Path: path, Severity: SeverityError, Message: err.Error()}}
+	}
+	return nil
+}
+
+// validateBodyTemplates parses every body t can send — its own and
+// its request specs' and methods' — as a template (#1251), so a typo
+// fails here instead of going out as literal braces.
+func validateBodyTemplates(path string, t Target) []Issue {
+	type body struct{ path, text string }
+	bodies := []body{{path + ".body", t.Body}}
+	for j, r := range t.Requests {
+		bodies = append(bodies, body{fmt.Sprintf("%s.requests[%d].body", path, j), r.Body})
+	}
+	for j, m := range t.Methods {
+		bodies = append(bodies, body{fmt.Sprintf("%s.methods[%d].body", path, j), m.Body})
+	}
+	var out []Issue
+	for _, b := range bodies {
+		if !template.Has(b.text) {
+			continue
+		}
+		if _, err := template.Parse(b.text); err != nil {
+			out = append(out, Issue{
+				Path:       b.path,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("invalid body template: %v", err),
+				Suggestion: "placeholders are {{uuid}}, {{counter}}, {{randInt min max}} and {{now}}",
+			})
+		}
+	}
+	return out
+}
+
+// validateSyntheticBody checks a target's synthetic_body (#1217): a
+// known distribution with the sizes it needs.
+func validateSyntheticBody(path string, t Target) []Issue {
+	var out []Issue
+	sb := t.SyntheticBody
+	spath := path + ".synthetic_body"
+	if sb.Size < 0 || sb.MinSize < 0 || sb.MaxSize < 0 {
+		out = append(out, Issue{Path: spath, Severity: SeverityError, Message: "sizes must be >= 0"})
+	}
+	switch sb.Distribution {
+	case "", SizeFixed:
+		if sb.Size <= 0 {
+			out = append(out, Issue{Path: spath + ".size", Severity: SeverityError, Message: "size is required for a fixed distribution"})
+		}
+	case SizeUniform:
+		if sb.MaxSize <= 0 || sb.MinSize > sb.MaxSize {
+			out = append(out, Issue{
+				Path:     spath + ".max_size",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("uniform needs 0 <= min_size <= max_size and max_size > 0, got %d..%d", sb.MinSize, sb.MaxSize),
+			})
+		}
+	case SizeLognormal:
+		if sb.Size <= 0 {
+			out = append(out, Issue{Path: spath + ".size", Severity: SeverityError, Message: "size, the median, is required for a lognormal distribution"})
+		}
+		if sb.Sigma < 0 {
+			out = append(out, Issue{Path: spath + ".sigma", Severity: SeverityError, Message: "sigma must be >= 0"})
+		}
+		if sb.MaxSize > 0 && sb.MinSize > sb.MaxSize {
+			out = append(out, Issue{Path: spath + ".min_size", Severity: SeverityError, Message: "min_size must be <= max_size"})
+		}
+	default:
+		out = append(out, Issue{
+			Path:       spath + ".distribution",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("unknown distribution %q", sb.Distribution),
+			Suggestion: "use fixed, uniform or lognormal",
+		})
+	}
+	if t.Body != "" {
+		out = append(out, Issue{Path: path + ".body", Severity: SeverityWarning, Message: "body is ignored when synthetic_body is set"})
+	}
+	if t.Protocol == ProtocolGRPC {
+		out = append(out, Issue{Path: spath, Severity: SeverityWarning, Message: "synthetic_body is HTTP-only and ignored for gRPC targets"})
+	}
+	return out
+}
+
+// validateDataFile checks a target's data_file (#1211): the file loads,
+// the order is known, weights parse, and every ${column} placeholder
+// in the target and its request specs names a column.
+func validateDataFile(path string, t Target) []Issue {
+	var out []Issue
+	df := t.DataFile
+	dpath := path + ".data_file"
+	if df.Path == "" {
+		return []Issue{{Path: dpath + ".path", Severity: SeverityError, Message: "path is required"}}
+	}
+	set, err := dataset.Load(df.Path)
+	if err != nil {
+		return []Issue{{Path: dpath + ".path", Severity: SeverityError, Message: err.Error()}}
+	}
+	if _, err := dataset.NewSource(set, df.Order, df.Loop, df.WeightColumn, 0); err != nil {
+		p := dpath + ".order"
+		if df.Order == dataset.OrderWeighted {
+			p = dpath + ".weight_column"
+		}
+		out = append(out, Issue{
+			Path:       p,
+			Severity:   SeverityError,
+			Message:    err.Error(),
+			Suggestion: "order is one of sequential, random, shuffle, weighted; weighted needs weight_column",
+		})
+	}
+	if df.WeightColumn != "" && df.Order != dataset.OrderWeighted {
+		out = append(out, Issue{
+			Path:     dpath + ".weight_column",
+			Severity: SeverityInfo,
+			Message:  "weight_column is ignored unless order is weighted",
+		})
+	}
+	if df.Loop && df.Order != "" && df.Order != dataset.OrderSequential {
+		out = append(out, Issue{
+			Path:     dpath + ".loop",
+			Severity: SeverityInfo,
+			Message:  fmt.Sprintf("loop only applies to sequential order; %s never runs out", df.Order),
+		})
+	}
+
+	fields := map[string]string{"url": t.URL, "body": t.Body}
+	for k, v := range t.Headers {
+		fields["headers."+k] = v
+	}
+	for j, r := range t.Requests {
+		rp := fmt.Sprintf("requests[%d]", j)
+		fields[rp+".path"] = r.Path
+		fields[rp+".body"] = r.Body
+		for k, v := range r.Headers {
+			fields[rp+".headers."+k] = v
+		}
+	}
+	for j, m := range t.Methods {
+		fields[fmt.Sprintf("methods[%d].body", j)] = m.Body
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, name := range dataset.Placeholders(fields[k]) {
+			if !set.Has(name) {
+				out = append(out, Issue{
+					Path:       path + "." + k,
+					Severity:   SeverityError,
+					Message:    fmt.Sprintf("${%s} is not a column of %s", name, df.Path),
+					Suggestion: "columns: " + strings.Join(set.Columns, ", "),
+				})
+			}
+		}
+	}
+	return out
+}
+
+// validateSuccessCodes range-checks success codes against the target
+// protocol: HTTP status 100–599, gRPC status 0–16.
+func validateSuccessCodes(path string, proto Protocol, codes []int) []Issue {
+	lo, hi, kind := 100, 599, "HTTP status"
+	if proto.GRPCStatus() {
+		lo, hi, kind = 0, 16, "gRPC status"
+	}
+	var out []Issue
+	for _, c := range codes {
+		if c < lo || c > hi {
+			out = append(out, Issue{
+				Path:     path,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%d is not a valid %s code (%d–%d)", c, kind, lo, hi),
+			})
+		}
+	}
+	return out
+}
+
+func validateController(cfg *Config) []Issue {
+	var out []Issue
+	if cfg.Controller.BaseTPS <= 0 {
+		out = append(out, Issue{
+			Path:     "controller.base_tps",
+			Severity: SeverityError,
+			Message:  "base_tps must be positive",
+		})
+	}
+	if cfg.Controller.MaxTPS < cfg.Controller.BaseTPS {
+		out = append(out, Issue{
+			Path:     "controller.max_tps",
+			Severity: SeverityError,
+			Message: fmt.Sprintf("max_tps (%.0f) must be >= base_tps (%.0f)",
+				cfg.Controller.MaxTPS, cfg.Controller.BaseTPS),
+		})
+	}
+	if cfg.Controller.StartJitter < 0 {
+		out = append(out, Issue{
+			Path:     "controller.start_jitter",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("start_jitter must not be negative, got %v", cfg.Controller.StartJitter),
+		})
+	}
+	if cfg.Controller.WarmupRequests < 0 {
+		out = append(out, Issue{
+			Path:     "controller.warmup_requests",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("warmup_requests must not be negative, got %d", cfg.Controller.WarmupRequests),
+		})
+	}
+	if cfg.Controller.WarmupDuration < 0 {
+		out = append(out, Issue{
+			Path:     "controller.warmup_duration",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("warmup_duration must not be negative, got %v", cfg.Controller.WarmupDuration),
+		})
 	}
-
-	if s.Duration > 0 && total != s.Duration {
+	if fw := cfg.Controller.FidelityWarn; fw < 0 || fw > 1 {
 		out = append(out, Issue{
-			Path:     path + ".inject",
+			Path:     "controller.fidelity_warn",
 			Severity: SeverityError,
-			Message: fmt.Sprintf("inject steps total %s but phase duration is %s — they must match",
-				total, s.Duration),
+			Message:  fmt.Sprintf("fidelity_warn is a fraction of the requested TPS, between 0 and 1; got %g", fw),
+		})
+	}
+	out = append(out, validateRamp(cfg.Controller)...)
+	out = append(out, validateWeighting(cfg)...)
+	// A warmup longer than the whole scripted run leaves nothing to
+	// measure.
+	var total time.Duration
+	for _, s := range cfg.Scenarios {
+		total += s.Duration
+	}
+	if total > 0 && cfg.Controller.WarmupDuration >= total {
+		out = append(out, Issue{
+			Path:     "controller.warmup_duration",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("warmup_duration %v covers the whole %v scenario run", cfg.Controller.WarmupDuration, total),
 		})
 	}
-
 	return out
 }
 
-func validateTargets(cfg *Config) []Issue {
+// validateRamp checks the ramp-up target and hold (#1237).
+func validateRamp(c Controller) []Issue {
 	var out []Issue
-	if len(cfg.Targets) == 0 {
-		return []Issue{{
-			Path:     "targets",
+	if c.RampTargetTPS < 0 {
+		out = append(out, Issue{
+			Path:     "controller.ramp_target_tps",
 			Severity: SeverityError,
-			Message:  "at least one target is required",
-		}}
+			Message:  fmt.Sprintf("ramp_target_tps must not be negative, got %g", c.RampTargetTPS),
+		})
+	} else if c.MaxTPS > 0 && c.RampTargetTPS > c.MaxTPS {
+		out = append(out, Issue{
+			Path:     "controller.ramp_target_tps",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("ramp_target_tps (%.0f) exceeds max_tps (%.0f)", c.RampTargetTPS, c.MaxTPS),
+		})
+	}
+	if c.RampHold < 0 {
+		out = append(out, Issue{
+			Path:     "controller.ramp_hold",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("ramp_hold must not be negative, got %v", c.RampHold),
+		})
+	}
+	if c.RampUpDuration <= 0 && (c.RampTargetTPS > 0 || c.RampHold > 0) {
+		out = append(out, Issue{
+			Path:       "controller.ramp_up_duration",
+			Severity:   SeverityWarning,
+			Message:    "ramp_target_tps and ramp_hold have no effect without ramp_up_duration",
+			Suggestion: "set controller.ramp_up_duration",
+		})
 	}
+	return out
+}
 
-	seen := make(map[string]int)
-	for i, t := range cfg.Targets {
-		path := fmt.Sprintf("targets[%d]", i)
-		if t.Name == "" {
-			out = append(out, Issue{Path: path + ".name", Severity: SeverityError, Message: "name is required"})
-		} else if prev, ok := seen[t.Name]; ok {
-			out = append(out, Issue{
-				Path:     path + ".name",
-				Severity: SeverityError,
-				Message:  fmt.Sprintf("duplicate name %q (also at targets[%d])", t.Name, prev),
-			})
-		} else {
-			seen[t.Name] = i
-		}
-		if t.URL == "" {
-			out = append(out, Issue{Path: path + ".url", Severity: SeverityError, Message: "url is required"})
-		} else if _, err := url.Parse(t.URL); err != nil {
-			out = append(out, Issue{
-				Path:     path + ".url",
-				Severity: SeverityError,
-				Message:  fmt.Sprintf("invalid URL: %v", err),
-			})
-		}
-		if t.Weight < 0 {
+func validateWeighting(cfg *Config) []Issue {
+	var out []Issue
+	c := cfg.Controller
+	switch c.Weighting {
+	case "", WeightingStatic, WeightingAdaptive:
+	default:
+		return append(out, Issue{
+			Path:       "controller.weighting",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("unknown weighting %q", c.Weighting),
+			Suggestion: fmt.Sprintf("use %q or %q", WeightingStatic, WeightingAdaptive),
+		})
+	}
+	a := c.Adaptive
+	if a.Interval < 0 {
+		out = append(out, Issue{
+			Path:     "controller.adaptive.interval",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("interval must not be negative, got %v", a.Interval),
+		})
+	}
+	if a.MinFactor < 0 || a.MinFactor > 1 {
+		out = append(out, Issue{
+			Path:     "controller.adaptive.min_factor",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("min_factor scales a weight down, between 0 and 1; got %g", a.MinFactor),
+		})
+	}
+	if a.MaxStep < 0 || a.MaxStep > 1 {
+		out = append(out, Issue{
+			Path:     "controller.adaptive.max_step",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("max_step must be between 0 and 1, got %g", a.MaxStep),
+		})
+	}
+	if c.Weighting != WeightingAdaptive {
+		if a != (AdaptiveWeighting{}) {
 			out = append(out, Issue{
-				Path:     path + ".weight",
-				Severity: SeverityError,
-				Message:  "weight must be non-negative",
+				Path:       "controller.adaptive",
+				Severity:   SeverityWarning,
+				Message:    "adaptive settings have no effect without weighting: adaptive",
+				Suggestion: "set controller.weighting: adaptive",
 			})
 		}
-		if t.Timeout < 0 {
+		return out
+	}
+	// Targets with their own pattern get an explicit rate, which
+	// adaptive weighting leaves alone.
+	for i, t := range cfg.Targets {
+		if t.Pattern != nil {
 			out = append(out, Issue{
-				Path:     path + ".timeout",
-				Severity: SeverityError,
-				Message:  "timeout must be non-negative",
+				Path:     fmt.Sprintf("targets[%d].pattern", i),
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("target %q has its own pattern; with per-target patterns, weighting: adaptive is ignored for the whole run", t.Name),
 			})
+			break
 		}
 	}
 	return out
 }
 
-func validateController(cfg *Config) []Issue {
+// validateHTTP2 checks a target's http2 block (#1248).
+func validateHTTP2(path string, t Target) []Issue {
+	h := t.HTTP2
+	if !h.Set() {
+		return nil
+	}
 	var out []Issue
-	if cfg.Controller.BaseTPS <= 0 {
+	if h.Connections < 0 {
 		out = append(out, Issue{
-			Path:     "controller.base_tps",
+			Path:     path + ".connections",
 			Severity: SeverityError,
-			Message:  "base_tps must be positive",
+			Message:  fmt.Sprintf("connections must be >= 0, got %d", h.Connections),
 		})
 	}
-	if cfg.Controller.MaxTPS < cfg.Controller.BaseTPS {
+	if h.MaxStreams < 0 {
 		out = append(out, Issue{
-			Path:     "controller.max_tps",
+			Path:     path + ".max_streams",
 			Severity: SeverityError,
-			Message: fmt.Sprintf("max_tps (%.0f) must be >= base_tps (%.0f)",
-				cfg.Controller.MaxTPS, cfg.Controller.BaseTPS),
+			Message:  fmt.Sprintf("max_streams must be >= 0, got %d", h.MaxStreams),
+		})
+	}
+	if proto := t.Protocol; proto != ProtocolHTTP2 {
+		if proto == "" {
+			proto = ProtocolHTTP
+		}
+		out = append(out, Issue{
+			Path:       path,
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("http2 settings are ignored for %s targets", proto),
+			Suggestion: "set protocol: http2",
 		})
 	}
 	return out
 }
 
 func validatePattern(cfg *Config) []Issue {
+	return append(validatePatternAt("pattern", cfg.Pattern, cfg.Controller.BaseTPS), validatePreset(cfg)...)
+}
+
+// validatePreset checks the top-level pattern preset (#1249). Load has
+// already expanded it; a config built in code gets the same checks.
+func validatePreset(cfg *Config) []Issue {
+	pat, base := cfg.Pattern, cfg.Controller.BaseTPS
+	if err := pat.ApplyPreset(&base); err != nil {
+		return []Issue{{Path: "pattern.preset", Severity: SeverityError, Message: err.Error()}}
+	}
+	if pat.Preset != PresetSoakSpike {
+		return nil
+	}
+	if peak := base * pat.SpikeFactor; cfg.Controller.MaxTPS > 0 && peak > cfg.Controller.MaxTPS {
+		return []Issue{{
+			Path:       "controller.max_tps",
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("soak-spike peaks at %.0f TPS but max_tps is %.0f; spikes will be cut flat", peak, cfg.Controller.MaxTPS),
+			Suggestion: fmt.Sprintf("raise max_tps to at least %.0f", peak),
+		}}
+	}
+	return nil
+}
+
+// validatePatternAt checks one pattern block; path is its location so
+// per-target patterns (#1195) report as targets[i].pattern. baseTPS is
+// what a capacity-relative spike (#1215) is measured against.
+func validatePatternAt(path string, pat Pattern, baseTPS float64) []Issue {
 	var out []Issue
-	p := cfg.Pattern.Poisson
+	p := pat.Poisson
 	if p.Enabled {
 		if p.Lambda <= 0 && p.Interval <= 0 {
 			out = append(out, Issue{
-				Path:     "pattern.poisson.lambda",
+				Path:     path + ".poisson.lambda",
 				Severity: SeverityError,
 				Message:  "either lambda or interval must be set when Poisson is enabled",
 			})
 		}
 		if p.Lambda > poissonLambdaWarn {
 			out = append(out, Issue{
-				Path:     "pattern.poisson.lambda",
+				Path:     path + ".poisson.lambda",
 				Severity: SeverityWarning,
 				Message: fmt.Sprintf("lambda=%.4g implies ~%.1f spikes/sec, likely a typo",
 					p.Lambda, p.Lambda),
 				Suggestion: fmt.Sprintf("for occasional spikes, lambda <= %.2f is more typical", poissonLambdaWarn),
 			})
 		}
-		if p.SpikeFactor < 1 {
+		switch {
+		case p.SpikeCapacity < 0:
+			out = append(out, Issue{
+				Path:     path + ".poisson.spike_capacity",
+				Severity: SeverityError,
+				Message:  "spike_capacity must be a positive fraction of capacity_tps",
+			})
+		case p.SpikeCapacity > 0 && p.CapacityTPS <= 0:
+			out = append(out, Issue{
+				Path:       path + ".poisson.capacity_tps",
+				Severity:   SeverityError,
+				Message:    "spike_capacity needs capacity_tps, the measured breaking point",
+				Suggestion: "run `kar discover` and copy its capacity_tps",
+			})
+		case p.SpikeCapacity > 0:
+			if p.SpikeCapacity > 1 {
+				out = append(out, Issue{
+					Path:     path + ".poisson.spike_capacity",
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("spike_capacity %.2f spikes past the measured capacity", p.SpikeCapacity),
+				})
+			}
+			if baseTPS > 0 && p.Factor(baseTPS) <= 1 {
+				out = append(out, Issue{
+					Path:     path + ".poisson.spike_capacity",
+					Severity: SeverityWarning,
+					Message: fmt.Sprintf("spike peak %.0f TPS (%.0f%% of capacity %.0f) is not above base_tps %.0f; spikes will be flat",
+						p.SpikeCapacity*p.CapacityTPS, p.SpikeCapacity*100, p.CapacityTPS, baseTPS),
+				})
+			}
+		case p.SpikeFactor < 1:
 			out = append(out, Issue{
-				Path:     "pattern.poisson.spike_factor",
+				Path:     path + ".poisson.spike_factor",
 				Severity: SeverityError,
 				Message:  "spike_factor must be >= 1",
 			})
 		}
 		if p.MinInterval > 0 && p.MaxInterval > 0 && p.MinInterval > p.MaxInterval {
 			out = append(out, Issue{
-				Path:     "pattern.poisson",
+				Path:     path + ".poisson",
 				Severity: SeverityError,
 				Message: fmt.Sprintf("min_interval (%s) must be <= max_interval (%s)",
 					p.MinInterval, p.MaxInterval),
 			})
 		}
+		if p.InitialDelay < 0 {
+			out = append(out, Issue{
+				Path:     path + ".poisson.initial_delay",
+				Severity: SeverityError,
+				Message:  "initial_delay must be >= 0",
+			})
+		}
+		switch p.Overlap {
+		case "", SpikeOverlapSequential, SpikeOverlapDrop, SpikeOverlapQueue, SpikeOverlapSuperimpose:
+		default:
+			out = append(out, Issue{
+				Path:     path + ".poisson.overlap",
+				Severity: SeverityWarning,
+				Message: fmt.Sprintf("unknown overlap mode %q (will fall back to %q)",
+					p.Overlap, SpikeOverlapSequential),
+				Suggestion: fmt.Sprintf("use %q, %q, %q or %q",
+					SpikeOverlapSequential, SpikeOverlapDrop, SpikeOverlapQueue, SpikeOverlapSuperimpose),
+			})
+		}
+		if p.Overlap == SpikeOverlapDrop {
+			if p.MinInterval > 0 && p.MinInterval < p.RampUp+p.RampDown {
+				out = append(out, Issue{
+					Path:     path + ".poisson.min_interval",
+					Severity: SeverityInfo,
+					Message: fmt.Sprintf("min_interval (%s) is shorter than ramp_up+ramp_down (%s); overlapping spikes will be dropped",
+						p.MinInterval, p.RampUp+p.RampDown),
+					Suggestion: `set overlap: "queue" or "superimpose" to keep them`,
+				})
+			}
+		}
+	}
+
+	if sq := pat.Square; sq.Enabled {
+		if sq.OnDuration <= 0 {
+			out = append(out, Issue{
+				Path:     path + ".square.on_duration",
+				Severity: SeverityError,
+				Message:  "on_duration must be > 0 when square is enabled",
+			})
+		}
+		if sq.OffDuration <= 0 {
+			out = append(out, Issue{
+				Path:     path + ".square.off_duration",
+				Severity: SeverityError,
+				Message:  "off_duration must be > 0 when square is enabled",
+			})
+		}
+		switch {
+		case sq.OffMultiplier < 0:
+			out = append(out, Issue{
+				Path:     path + ".square.off_multiplier",
+				Severity: SeverityError,
+				Message:  "off_multiplier must be >= 0",
+			})
+		case sq.OffMultiplier >= 1:
+			out = append(out, Issue{
+				Path:       path + ".square.off_multiplier",
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("off_multiplier %.2f doesn't lower the rate while off", sq.OffMultiplier),
+				Suggestion: "off_multiplier is a fraction of the rate; 0.1 means 10%",
+			})
+		}
 	}
 
-	n := cfg.Pattern.Noise
+	n := pat.Noise
 	if n.Enabled {
 		switch {
 		case n.Amplitude < 0:
 			out = append(out, Issue{
-				Path:     "pattern.noise.amplitude",
+				Path:     path + ".noise.amplitude",
 				Severity: SeverityError,
 				Message:  "amplitude must be >= 0",
 			})
 		case n.Amplitude > 1:
 			out = append(out, Issue{
-				Path:     "pattern.noise.amplitude",
+				Path:     path + ".noise.amplitude",
 				Severity: SeverityError,
 				Message: fmt.Sprintf("amplitude %.2f > 1 would multiply TPS by a negative factor",
 					n.Amplitude),
@@ -354,7 +1994,7 @@ func validatePattern(cfg *Config) []Issue {
 		}
 		if n.Type != "" && n.Type != NoiseTypeSpring && n.Type != NoiseTypePerlin {
 			out = append(out, Issue{
-				Path:     "pattern.noise.type",
+				Path:     path + ".noise.type",
 				Severity: SeverityWarning,
 				Message: fmt.Sprintf("unknown noise type %q (will fall back to %q)",
 					n.Type, NoiseTypeSpring),
@@ -362,6 +2002,145 @@ func validatePattern(cfg *Config) []Issue {
 			})
 		}
 	}
+	return append(out, validateBlend(path+".blend", pat.Blend, baseTPS)...)
+}
+
+// validateBlend checks pattern.blend (#1247). Each source is a pattern
+// of its own and gets the same checks.
+func validateBlend(path string, srcs []BlendSource, baseTPS float64) []Issue {
+	var out []Issue
+	seen := make(map[string]bool)
+	for i, b := range srcs {
+		at := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case b.Name == "":
+			out = append(out, Issue{
+				Path:     at + ".name",
+				Severity: SeverityError,
+				Message:  "blend source needs a name; status reports each source by it",
+			})
+		case seen[b.Name]:
+			out = append(out, Issue{
+				Path:     at + ".name",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("duplicate blend source %q", b.Name),
+			})
+		}
+		seen[b.Name] = true
+		if b.Weight < 0 {
+			out = append(out, Issue{
+				Path:     at + ".weight",
+				Severity: SeverityError,
+				Message:  "weight must be >= 0",
+			})
+		}
+		if b.Noise.PerTarget {
+			out = append(out, Issue{
+				Path:       at + ".noise.per_target",
+				Severity:   SeverityWarning,
+				Message:    "per_target is ignored inside a blend source",
+				Suggestion: "set per_target on the top-level pattern.noise",
+			})
+		}
+		out = append(out, validatePatternAt(at, Pattern{Poisson: b.Poisson, Noise: b.Noise}, baseTPS)...)
+	}
+	if len(srcs) == 1 {
+		out = append(out, Issue{
+			Path:       path,
+			Severity:   SeverityInfo,
+			Message:    "a blend of one source is the same as setting its poisson and noise on the pattern",
+			Suggestion: "add a second source, or move this one's settings up",
+		})
+	}
+	return out
+}
+
+// validateFaultInject range-checks fault rates and always flags an
+// enabled injection, so nobody ships a run with synthetic failures in
+// it by accident.
+func validateFaultInject(f FaultInject) []Issue {
+	if !f.Enabled() && f.SlowDelay == 0 {
+		return nil
+	}
+	var out []Issue
+	for _, r := range []struct {
+		name string
+		rate float64
+	}{{"timeout", f.Timeout}, {"error", f.Error}, {"slow", f.Slow}} {
+		if r.rate < 0 || r.rate > 1 {
+			out = append(out, Issue{
+				Path:     "worker.fault_inject." + r.name,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s rate must be within 0..1, got %g", r.name, r.rate),
+			})
+		}
+	}
+	if sum := f.Timeout + f.Error + f.Slow; sum > 1 {
+		out = append(out, Issue{
+			Path:     "worker.fault_inject",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("fault rates sum to %g; a request gets at most one fault, so they must sum to <= 1", sum),
+		})
+	}
+	if f.SlowDelay < 0 {
+		out = append(out, Issue{
+			Path:     "worker.fault_inject.slow_delay",
+			Severity: SeverityError,
+			Message:  "slow_delay must be non-negative",
+		})
+	}
+	if f.Enabled() {
+		out = append(out, Issue{
+			Path:       "worker.fault_inject",
+			Severity:   SeverityWarning,
+			Message:    "fault injection is on: kar fakes failures itself, results do not describe the target",
+			Suggestion: "remove worker.fault_inject for a real test",
+		})
+	}
+	return out
+}
+
+// grpcMinPing is the floor grpc-go silently raises keepalive pings to.
+const grpcMinPing = 10 * time.Second
+
+// validateKeepAlive checks each protocol's keep-alive settings.
+func validateKeepAlive(k KeepAlive) []Issue {
+	var out []Issue
+	for _, p := range []struct {
+		name string
+		s    KeepAliveSettings
+	}{{"http", k.HTTP}, {"http2", k.HTTP2}, {"grpc", k.GRPC}} {
+		path := "worker.keep_alive." + p.name
+		if p.s.Ping < 0 || p.s.PingTimeout < 0 {
+			out = append(out, Issue{
+				Path:     path,
+				Severity: SeverityError,
+				Message:  "ping and ping_timeout must not be negative",
+			})
+		}
+		if p.name == "http" && p.s.Ping > 0 {
+			out = append(out, Issue{
+				Path:       path + ".ping",
+				Severity:   SeverityError,
+				Message:    "HTTP/1.1 has no ping frame",
+				Suggestion: "use tcp to keep idle HTTP/1.1 connections alive",
+			})
+		}
+		if p.s.PingTimeout > 0 && p.s.Ping == 0 && p.name != "grpc" {
+			out = append(out, Issue{
+				Path:     path + ".ping_timeout",
+				Severity: SeverityInfo,
+				Message:  "ping_timeout has no effect without ping",
+			})
+		}
+		if p.name == "grpc" && p.s.Ping > 0 && p.s.Ping < grpcMinPing {
+			out = append(out, Issue{
+				Path:     path + ".ping",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("grpc-go raises keepalive pings below %s to %s", grpcMinPing, grpcMinPing),
+			})
+		}
+	}
 	return out
 }
 
@@ -392,6 +2171,63 @@ func validateWorker(cfg *Config) []Issue {
 				cfg.Worker.QueueSize, cfg.Worker.PoolSize),
 		})
 	}
+	switch cfg.Worker.OnSaturation {
+	case "", SaturationWarn, SaturationGrow:
+	default:
+		out = append(out, Issue{
+			Path:     "worker.on_saturation",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("on_saturation must be %q or %q, got %q", SaturationWarn, SaturationGrow, cfg.Worker.OnSaturation),
+		})
+	}
+	switch m := cfg.Worker.MaxPoolSize; {
+	case m < 0:
+		out = append(out, Issue{
+			Path:     "worker.max_pool_size",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("max_pool_size must not be negative, got %d", m),
+		})
+	case m > 0 && m < cfg.Worker.PoolSize:
+		out = append(out, Issue{
+			Path:     "worker.max_pool_size",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("max_pool_size (%d) is below pool_size (%d)", m, cfg.Worker.PoolSize),
+		})
+	case m > 0 && cfg.Worker.OnSaturation != SaturationGrow:
+		out = append(out, Issue{
+			Path:     "worker.max_pool_size",
+			Severity: SeverityInfo,
+			Message:  "max_pool_size only applies with on_saturation: grow",
+		})
+	}
+	if r := cfg.Worker.ConnRate; r < 0 {
+		out = append(out, Issue{
+			Path:     "worker.conn_rate",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("conn_rate must not be negative, got %g", r),
+		})
+	}
+	switch b := cfg.Worker.ConnBurst; {
+	case b < 0:
+		out = append(out, Issue{
+			Path:     "worker.conn_burst",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("conn_burst must not be negative, got %d", b),
+		})
+	case b > 0 && cfg.Worker.ConnRate == 0:
+		out = append(out, Issue{
+			Path:     "worker.conn_burst",
+			Severity: SeverityInfo,
+			Message:  "conn_burst only applies with conn_rate",
+		})
+	}
+	if n := cfg.Worker.TLS.SessionCache; n < 0 {
+		out = append(out, Issue{
+			Path:     "worker.tls.session_cache",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("session_cache must not be negative, got %d", n),
+		})
+	}
 	return out
 }
 
@@ -406,6 +2242,29 @@ func validateSchedule(cfg *Config) []Issue {
 	// can make it explicit (see #48 for explicit priority).
 	hourEntries := make(map[int][]int) // hour → entry indices
 	for i, e := range cfg.Controller.Schedule {
+		switch {
+		case e.Cron != "" && len(e.Hours) > 0:
+			out = append(out, Issue{
+				Path:     fmt.Sprintf("controller.schedule[%d]", i),
+				Severity: SeverityError,
+				Message:  "set hours or cron, not both",
+			})
+		case e.Cron != "":
+			if _, err := ParseCron(e.Cron); err != nil {
+				out = append(out, Issue{
+					Path:       fmt.Sprintf("controller.schedule[%d].cron", i),
+					Severity:   SeverityError,
+					Message:    err.Error(),
+					Suggestion: `e.g. "30-59 11 * * 1-5" for 11:30-11:59 on weekdays`,
+				})
+			}
+		case len(e.Hours) == 0:
+			out = append(out, Issue{
+				Path:     fmt.Sprintf("controller.schedule[%d]", i),
+				Severity: SeverityWarning,
+				Message:  "entry covers no time: set hours or cron",
+			})
+		}
 		for _, h := range e.Hours {
 			if h < 0 || h > 23 {
 				out = append(out, Issue{
@@ -459,7 +2318,7 @@ func validateSchedule(cfg *Config) []Issue {
 			})
 		} else {
 			out = append(out, Issue{
-				Path:     "controller.schedule",
+				Path:       "controller.schedule",
 				Severity:   SeverityWarning,
 				Message:    fmt.Sprintf("hour %d appears in entries %v with no explicit priority — later entry silently wins", h, idxs),
 				Suggestion: "set `priority:` on the entry that should win to make the override explicit",
@@ -1,9 +1,17 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 // goodConfig returns a minimal Config that passes ValidateConfig.
@@ -128,6 +136,65 @@ func TestValidateConfig_ScheduleHourOverlapWithoutPriorityWarns(t *testing.T) {
 	}
 }
 
+func TestValidateConfig_ScheduleCron(t *testing.T) {
+	for _, tc := range []struct {
+		e       ScheduleEntry
+		wantErr bool
+	}{
+		{ScheduleEntry{Cron: "30-59 11 * * mon-fri", TPSMultiplier: 2}, false},
+		{ScheduleEntry{Cron: "*/15 * * * *", TPSMultiplier: 2}, false},
+		{ScheduleEntry{Cron: "30 11 * *", TPSMultiplier: 2}, true},
+		{ScheduleEntry{Cron: "60 * * * *", TPSMultiplier: 2}, true},
+		{ScheduleEntry{Cron: "30-10 * * * *", TPSMultiplier: 2}, true},
+		{ScheduleEntry{Cron: "0 12 * * *", Hours: []int{12}, TPSMultiplier: 2}, true},
+	} {
+		cfg := goodConfig()
+		cfg.Controller.Schedule = []ScheduleEntry{tc.e}
+		if got := HasErrors(ValidateConfig(cfg)); got != tc.wantErr {
+			t.Errorf("%+v: errors = %v, want %v", tc.e, got, tc.wantErr)
+		}
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	c, err := ParseCron("30-59 11 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-01-05 is a Monday.
+	at := func(day, h, m int) time.Time { return time.Date(2026, 1, day, h, m, 0, 0, time.UTC) }
+	for _, tc := range []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(5, 11, 30), true},
+		{at(5, 11, 59), true},
+		{at(5, 11, 29), false},
+		{at(5, 12, 0), false},
+		{at(10, 11, 45), false}, // Saturday
+	} {
+		if got := c.Matches(tc.t); got != tc.want {
+			t.Errorf("Matches(%v) = %v, want %v", tc.t, got, tc.want)
+		}
+	}
+	if got, want := c.Next(at(5, 12, 0)), at(6, 11, 30); !got.Equal(want) {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+	if got, want := c.Next(at(9, 11, 59)), at(12, 11, 30); !got.Equal(want) {
+		t.Errorf("Next over the weekend = %v, want %v", got, want)
+	}
+
+	// Both day fields restricted: either one matches.
+	c, _ = ParseCron("0 0 1 * sun")
+	if !c.Matches(at(1, 0, 0)) || !c.Matches(at(4, 0, 0)) || c.Matches(at(5, 0, 0)) {
+		t.Error("day of month or day of week should match")
+	}
+	c, _ = ParseCron("0 0 30 feb *")
+	if got := c.Next(at(1, 0, 0)); !got.IsZero() {
+		t.Errorf("Next of Feb 30 = %v, want none", got)
+	}
+}
+
 // When at least one overlapping entry sets Priority, the override is
 // intentional and should drop back to info severity.
 func TestValidateConfig_ScheduleHourOverlapWithPriorityIsInfo(t *testing.T) {
@@ -280,6 +347,24 @@ func TestValidateConfig_SafetyOutOfRangeErrorRateIsError(t *testing.T) {
 	}
 }
 
+func TestValidateConfig_CliffChecksWithoutSafetyEnabled(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Safety.Cliff = Cliff{Enabled: true, Action: CliffAbort}
+	if iss := ValidateConfig(cfg); HasErrors(iss) {
+		t.Fatalf("cliff with defaults should pass: %+v", iss)
+	}
+	cfg.Safety.Cliff = Cliff{Enabled: true, Factor: 1, Action: "stop"}
+	var paths []string
+	for _, is := range ValidateConfig(cfg) {
+		if is.Severity == SeverityError {
+			paths = append(paths, is.Path)
+		}
+	}
+	if len(paths) != 2 || paths[0] != "safety.cliff.factor" || paths[1] != "safety.cliff.action" {
+		t.Fatalf("errors = %v, want factor and action", paths)
+	}
+}
+
 func TestValidateConfig_HourOutOfRangeIsError(t *testing.T) {
 	cfg := goodConfig()
 	cfg.Controller.Schedule = []ScheduleEntry{{Hours: []int{25}, TPSMultiplier: 1.0}}
@@ -317,6 +402,23 @@ func TestValidateConfig_PoissonMinMaxIntervalSwapped(t *testing.T) {
 	}
 }
 
+func TestValidateConfig_PropagateDeadlineWithoutTimeoutWarns(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].PropagateDeadline = true
+	cfg.Targets[0].Timeout = 0
+	issues := ValidateConfig(cfg)
+
+	found := false
+	for _, iss := range issues {
+		if iss.Severity == SeverityWarning && strings.HasSuffix(iss.Path, ".propagate_deadline") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected propagate_deadline warning, got %+v", issues)
+	}
+}
+
 func TestHasErrors(t *testing.T) {
 	if HasErrors(nil) {
 		t.Fatalf("nil slice should not have errors")
@@ -328,3 +430,1245 @@ func TestHasErrors(t *testing.T) {
 		t.Fatalf("error severity should be reported")
 	}
 }
+
+func TestValidateConfig_HealthFailureThresholds(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Health.FailureThresholds = map[HealthFailure]int{
+		HealthFailureDNS: 3,
+		"resolver":       2,
+	}
+	issues := ValidateConfig(cfg)
+	if HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+	found := false
+	for _, iss := range issues {
+		if iss.Severity == SeverityWarning && iss.Path == "health.failure_thresholds.resolver" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected unknown-cause warning, got %+v", issues)
+	}
+
+	cfg.Health.FailureThresholds[HealthFailureDNS] = 0
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatalf("threshold 0 should be an error")
+	}
+}
+
+func TestValidateConfig_RequestSpecs(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].Requests = []RequestSpec{
+		{Name: "list"},
+		{Name: "remove", Method: "DELETE", SuccessCodes: []int{404}},
+	}
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+
+	cfg.Targets[0].Requests = append(cfg.Targets[0].Requests, RequestSpec{Name: "list", Weight: -1})
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatalf("duplicate spec name and negative weight should be errors")
+	}
+
+	cfg = goodConfig()
+	cfg.Targets[0].SuccessCodes = []int{0}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatalf("HTTP success code 0 should be an error")
+	}
+	cfg.Targets[0].Protocol = ProtocolGRPC
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("gRPC success code 0 should be valid: %+v", issues)
+	}
+}
+
+func TestTargetResolve(t *testing.T) {
+	base := Target{
+		Name:     "orders",
+		URL:      "http://orders:8080/orders?page=1",
+		Method:   "GET",
+		Headers:  map[string]string{"Auth": "x"},
+		Requests: []RequestSpec{{Name: "list"}},
+	}
+	spec := &RequestSpec{
+		Name:         "remove",
+		Method:       "DELETE",
+		Path:         "/orders/42",
+		Headers:      map[string]string{"X-Trace": "1"},
+		SuccessCodes: []int{404},
+	}
+	got := base.Resolve(spec)
+	if got.Spec != "remove" || got.Method != "DELETE" || got.Requests != nil {
+		t.Fatalf("unexpected resolve: %+v", got)
+	}
+	if got.URL != "http://orders:8080/orders/42" {
+		t.Fatalf("URL = %q", got.URL)
+	}
+	if got.Headers["Auth"] != "x" || got.Headers["X-Trace"] != "1" {
+		t.Fatalf("headers not merged: %v", got.Headers)
+	}
+	if _, ok := base.Headers["X-Trace"]; ok {
+		t.Fatalf("resolve mutated the base target's headers")
+	}
+	if !got.IsSuccess(404) || got.IsSuccess(200) {
+		t.Fatalf("IsSuccess should follow the spec's codes")
+	}
+	if !base.IsSuccess(204) || base.IsSuccess(404) {
+		t.Fatalf("default IsSuccess should accept 2xx/3xx only")
+	}
+}
+
+func TestValidateConfig_Output(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Output = []OutputSink{
+		{Type: OutputJSON, Path: "run.json"},
+		{Type: OutputHTML, Path: "run.html"},
+		{Type: OutputPrometheus, Endpoint: "http://pushgateway:9091"},
+		{Type: OutputJSONL, Path: "run.jsonl.gz", MaxSize: "100MB"},
+	}
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+
+	for _, bad := range []OutputSink{
+		{Type: "csv", Path: "run.csv"},
+		{Type: OutputJSON},
+		{Type: OutputJSON, Endpoint: "http://example"},
+		{Type: OutputHTML, Path: "run.json"},
+		{Type: OutputJSON, Path: "run.json", MaxSize: "1MB"},
+		{Type: OutputJSONL, Path: "run.jsonl", MaxSize: "lots"},
+		{Type: OutputJSONL, Path: "run.jsonl", MaxSize: "0MB"},
+	} {
+		cfg := goodConfig()
+		cfg.Output = []OutputSink{{Type: OutputJSON, Path: "run.json"}, bad}
+		if !HasErrors(ValidateConfig(cfg)) {
+			t.Errorf("sink %+v should be an error", bad)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{"4096": 4096, "512K": 512 << 10, "100MB": 100 << 20, "2 gb": 2 << 30, "10B": 10} {
+		if got, err := ParseSize(in); err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "MB", "-1MB", "1.5MB", "10TB"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) should fail", in)
+		}
+	}
+}
+
+func TestValidateConfig_MaxConnsPerHost(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].MaxConnsPerHost = 50
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+	cfg.Targets[0].MaxConnsPerHost = -1
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatalf("negative max_conns_per_host should be an error")
+	}
+
+	cfg = goodConfig()
+	cfg.Targets[0].Protocol = ProtocolGRPC
+	cfg.Targets[0].MaxConnsPerHost = 10
+	var warned bool
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Severity == SeverityWarning && strings.HasSuffix(iss.Path, "max_conns_per_host") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatalf("max_conns_per_host on gRPC should warn")
+	}
+}
+
+func TestValidateConfig_Pipeline(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].Pipeline = 8
+	if issues := ValidateConfig(cfg); len(issues) != 0 {
+		t.Fatalf("pipelined GET should pass cleanly, got %+v", issues)
+	}
+
+	cfg.Targets[0].Method = "POST"
+	var warned bool
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Severity == SeverityWarning && strings.HasSuffix(iss.Path, ".pipeline") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatalf("pipelining POST should warn")
+	}
+
+	cfg = goodConfig()
+	cfg.Targets[0].Protocol = ProtocolHTTP2
+	cfg.Targets[0].Pipeline = 4
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatalf("pipeline on http2 should be an error")
+	}
+}
+
+func TestValidateConfig_Report(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Report = Report{
+		SegmentWindow: 5 * time.Minute,
+		SLO:           SLO{P99Latency: 500 * time.Millisecond, ErrorRate: 1},
+	}
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+	cfg.Report.SLO.ErrorRate = 150
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatalf("error_rate above 100%% should be an error")
+	}
+}
+
+func TestSLOBreaches(t *testing.T) {
+	slo := SLO{P95Latency: 100 * time.Millisecond, ErrorRate: 1}
+	if got := slo.Breaches(90, 900, 0.5); len(got) != 0 {
+		t.Fatalf("unset p99 must not be checked, got %v", got)
+	}
+	got := slo.Breaches(120, 0, 2)
+	if len(got) != 2 || got[0] != "p95" || got[1] != "error_rate" {
+		t.Fatalf("Breaches = %v, want [p95 error_rate]", got)
+	}
+}
+
+func TestValidateConfig_OnAllUnhealthy(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Health.OnAllUnhealthy = AllUnhealthyProbe
+	cfg.Health.ProbeTPS = 5
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+	cfg.Health.OnAllUnhealthy = "retry"
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatalf("unknown on_all_unhealthy should be an error")
+	}
+}
+
+func TestValidateConfig_GRPCReservedMetadata(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].Protocol = ProtocolGRPC
+	cfg.Targets[0].Headers = map[string]string{"Authorization": "Bearer x", "grpc-timeout": "1S"}
+	var warned []string
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Severity == SeverityWarning && strings.Contains(iss.Path, ".headers.") {
+			warned = append(warned, iss.Path)
+		}
+	}
+	if len(warned) != 1 || !strings.HasSuffix(warned[0], "grpc-timeout") {
+		t.Fatalf("want one warning for grpc-timeout, got %v", warned)
+	}
+}
+
+func TestValidateConfig_TargetPattern(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].Pattern = &Pattern{Poisson: Poisson{Enabled: true, Lambda: 0.01, SpikeFactor: 3}}
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+	cfg.Targets[0].Pattern.Poisson.SpikeFactor = 0.5
+	var path string
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Severity == SeverityError {
+			path = iss.Path
+		}
+	}
+	if path != "targets[0].pattern.poisson.spike_factor" {
+		t.Fatalf("error path = %q, want targets[0].pattern.poisson.spike_factor", path)
+	}
+}
+
+func TestTargetTotalTimeLimit(t *testing.T) {
+	cases := []struct {
+		target Target
+		want   time.Duration
+	}{
+		{Target{}, DefaultMaxTotalTime},
+		{Target{Timeout: 3 * time.Second}, 6 * time.Second},
+		{Target{Timeout: 3 * time.Second, MaxTotalTime: 10 * time.Second}, 10 * time.Second},
+	}
+	for _, c := range cases {
+		if got := c.target.TotalTimeLimit(); got != c.want {
+			t.Errorf("TotalTimeLimit(%+v) = %s, want %s", c.target, got, c.want)
+		}
+	}
+
+	cfg := goodConfig()
+	cfg.Targets[0].Timeout = 5 * time.Second
+	cfg.Targets[0].MaxTotalTime = time.Second
+	var warned bool
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Severity == SeverityWarning && strings.HasSuffix(iss.Path, "max_total_time") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatal("max_total_time below timeout should warn")
+	}
+}
+
+func TestValidateConfig_ExpectedLatency(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].ExpectedLatency = -time.Millisecond
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("negative expected_latency should be an error")
+	}
+
+	cfg.Targets[0].Timeout = time.Second
+	cfg.Targets[0].ExpectedLatency = 2 * time.Second
+	var warned bool
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Severity == SeverityWarning && strings.HasSuffix(iss.Path, "expected_latency") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatal("expected_latency at or above timeout should warn")
+	}
+
+	cfg.Targets[0].ExpectedLatency = 200 * time.Millisecond
+	for _, iss := range ValidateConfig(cfg) {
+		if strings.HasSuffix(iss.Path, "expected_latency") {
+			t.Fatalf("unexpected issue: %+v", iss)
+		}
+	}
+}
+
+func TestValidateConfig_DataFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	if err := os.WriteFile(path, []byte("id,w\n1,1\n2,3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	errorAt := func(cfg *Config, suffix string) bool {
+		for _, iss := range ValidateConfig(cfg) {
+			if iss.Severity == SeverityError && strings.HasSuffix(iss.Path, suffix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	cfg := goodConfig()
+	cfg.Targets[0].URL = "http://localhost:8080/users/${id}"
+	cfg.Targets[0].DataFile = &DataFile{Path: path, Order: "weighted", WeightColumn: "w"}
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+
+	cfg.Targets[0].Body = `{"name": "${name}"}`
+	if !errorAt(cfg, "targets[0].body") {
+		t.Fatal("placeholder naming no column should be an error")
+	}
+
+	cfg = goodConfig()
+	cfg.Targets[0].DataFile = &DataFile{Path: path, Order: "weighted"}
+	if !errorAt(cfg, "data_file.weight_column") {
+		t.Fatal("weighted order without weight_column should be an error")
+	}
+	cfg.Targets[0].DataFile = &DataFile{Path: path, Order: "zigzag"}
+	if !errorAt(cfg, "data_file.order") {
+		t.Fatal("unknown order should be an error")
+	}
+	cfg.Targets[0].DataFile = &DataFile{Path: path + ".missing"}
+	if !errorAt(cfg, "data_file.path") {
+		t.Fatal("missing file should be an error")
+	}
+}
+
+func TestParseFaultInject(t *testing.T) {
+	f, err := ParseFaultInject("error=0.05, slow=0.1,delay=2s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Error != 0.05 || f.Slow != 0.1 || f.Timeout != 0 || f.Delay() != 2*time.Second {
+		t.Fatalf("parsed %+v", f)
+	}
+	for _, bad := range []string{"error", "crash=0.1", "slow=x", "delay=soon"} {
+		if _, err := ParseFaultInject(bad); err == nil {
+			t.Errorf("ParseFaultInject(%q) should fail", bad)
+		}
+	}
+
+	cfg := goodConfig()
+	cfg.Worker.FaultInject = FaultInject{Error: 0.7, Timeout: 0.5}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("fault rates summing above 1 should be an error")
+	}
+	cfg.Worker.FaultInject = FaultInject{Error: 0.1}
+	var warned bool
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Severity == SeverityWarning && iss.Path == "worker.fault_inject" {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatal("enabled fault injection should always warn")
+	}
+}
+
+func TestValidateConfig_ReportInterval(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Report.Interval = time.Second
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+	cfg.Report.Interval = 10 * time.Millisecond
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("interval below the minimum should be an error")
+	}
+
+	// A 6-hour soak at 100ms is 216k slots.
+	cfg.Report.Interval = 100 * time.Millisecond
+	cfg.Scenarios = []Scenario{{Name: "soak", Duration: 6 * time.Hour}}
+	var warned bool
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Severity == SeverityWarning && iss.Path == "report.interval" {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatal("an interval producing >100k slots should warn")
+	}
+}
+
+func TestPoisson_SpikeCapacity(t *testing.T) {
+	p := Poisson{SpikeFactor: 3, SpikeCapacity: 0.9, CapacityTPS: 1000}
+	if got := p.Factor(300); got != 3 {
+		t.Errorf("Factor = %v, want 0.9*1000/300 = 3", got)
+	}
+	if got := p.Factor(2000); got != 1 {
+		t.Errorf("Factor above capacity = %v, want 1", got)
+	}
+	p.SpikeCapacity = 0
+	if got := p.Factor(300); got != 3 {
+		t.Errorf("Factor without spike_capacity = %v, want spike_factor", got)
+	}
+
+	cfg := goodConfig()
+	cfg.Pattern.Poisson.Enabled = true
+	cfg.Pattern.Poisson.SpikeFactor = 0
+	cfg.Pattern.Poisson.SpikeCapacity = 0.9
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("spike_capacity without capacity_tps should be an error")
+	}
+	cfg.Pattern.Poisson.CapacityTPS = cfg.Controller.BaseTPS * 10
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+}
+
+func TestValidateConfig_SyntheticBody(t *testing.T) {
+	for name, sb := range map[string]SyntheticBody{
+		"fixed without size":     {},
+		"uniform reversed range": {Distribution: SizeUniform, MinSize: 200, MaxSize: 100},
+		"lognormal without size": {Distribution: SizeLognormal, MaxSize: 100},
+		"unknown distribution":   {Distribution: "pareto", Size: 10},
+	} {
+		cfg := goodConfig()
+		cfg.Targets[0].SyntheticBody = &sb
+		if !HasErrors(ValidateConfig(cfg)) {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	cfg := goodConfig()
+	cfg.Targets[0].SyntheticBody = &SyntheticBody{Distribution: SizeLognormal, Size: 4096, Sigma: 0.8}
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+}
+
+func TestValidateConfig_LongPoll(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].LongPoll = &LongPoll{}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("long_poll without concurrency should be an error")
+	}
+	cfg.Targets[0].LongPoll.Concurrency = 50
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+}
+
+func TestValidateConfig_TLSInsecure(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].URL = "https://api.example.com/"
+	for _, is := range ValidateConfig(cfg) {
+		if strings.HasSuffix(is.Path, ".tls_insecure") {
+			t.Fatalf("verification on by default, got %+v", is)
+		}
+	}
+
+	cfg.Worker.TLSInsecure = true
+	found := false
+	for _, is := range ValidateConfig(cfg) {
+		found = found || (is.Path == "targets[0].tls_insecure" && is.Severity == SeverityWarning)
+	}
+	if !found {
+		t.Fatal("expected a warning for skipped verification on an https target")
+	}
+
+	verify := false
+	cfg.Targets[0].TLSInsecure = &verify
+	for _, is := range ValidateConfig(cfg) {
+		if strings.HasSuffix(is.Path, ".tls_insecure") {
+			t.Fatalf("target re-enabled verification, got %+v", is)
+		}
+	}
+}
+
+func TestValidateConfig_OnSaturation(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Worker.OnSaturation = "panic"
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("expected an error for an unknown on_saturation policy")
+	}
+
+	cfg = goodConfig()
+	cfg.Worker.OnSaturation = SaturationGrow
+	cfg.Worker.MaxPoolSize = cfg.Worker.PoolSize / 2
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("expected an error for max_pool_size below pool_size")
+	}
+
+	cfg.Worker.MaxPoolSize = 0
+	if got := ValidateConfig(cfg); HasErrors(got) {
+		t.Fatalf("grow with the default ceiling should pass, got %+v", got)
+	}
+	if c := cfg.Worker.PoolCeiling(); c != 4*cfg.Worker.PoolSize {
+		t.Fatalf("PoolCeiling = %d, want 4×pool_size", c)
+	}
+}
+
+func TestValidateConfig_ConnRate(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Worker.ConnRate = -1
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("expected an error for a negative conn_rate")
+	}
+
+	cfg = goodConfig()
+	cfg.Worker.ConnRate = 50
+	if got := ValidateConfig(cfg); HasErrors(got) {
+		t.Fatalf("conn_rate 50 should pass, got %+v", got)
+	}
+	if r, b := cfg.Worker.ConnLimit(); r != 50 || b != 1 {
+		t.Fatalf("ConnLimit = %g, %d; want 50, 1", r, b)
+	}
+
+	cfg = goodConfig()
+	cfg.Worker.ConnBurst = 10
+	got := ValidateConfig(cfg)
+	found := false
+	for _, iss := range got {
+		if iss.Path == "worker.conn_burst" && iss.Severity == SeverityInfo {
+			found = true
+		}
+	}
+	if HasErrors(got) || !found {
+		t.Fatalf("conn_burst without conn_rate should be an info note, got %+v", got)
+	}
+}
+
+func TestValidateConfig_ConnectTimeout(t *testing.T) {
+	cases := []struct {
+		name     string
+		mutate   func(*Target)
+		severity Severity
+		wantNone bool
+	}{
+		{"below timeout", func(t *Target) { t.Timeout = 10 * time.Second; t.ConnectTimeout = time.Second }, "", true},
+		{"negative", func(t *Target) { t.ConnectTimeout = -time.Second }, SeverityError, false},
+		{"not below timeout", func(t *Target) { t.Timeout = time.Second; t.ConnectTimeout = time.Second }, SeverityWarning, false},
+		{"grpc", func(t *Target) { t.Protocol = ProtocolGRPC; t.ConnectTimeout = time.Second }, SeverityWarning, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := goodConfig()
+			tc.mutate(&cfg.Targets[0])
+			var found *Issue
+			for _, is := range ValidateConfig(cfg) {
+				if strings.HasSuffix(is.Path, ".connect_timeout") {
+					is := is
+					found = &is
+				}
+			}
+			switch {
+			case tc.wantNone && found != nil:
+				t.Fatalf("unexpected issue: %+v", *found)
+			case !tc.wantNone && (found == nil || found.Severity != tc.severity):
+				t.Fatalf("issue = %+v, want severity %s", found, tc.severity)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_HooksScript(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Hooks.Script = "/nonexistent/hooks.star"
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("expected error for a missing hooks script")
+	}
+}
+
+func TestValidateConfig_KeepAlive(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Worker.KeepAlive = KeepAlive{
+		HTTP:  KeepAliveSettings{TCP: 15 * time.Second},
+		HTTP2: KeepAliveSettings{Ping: 20 * time.Second},
+		GRPC:  KeepAliveSettings{Ping: 30 * time.Second, PingTimeout: 5 * time.Second},
+	}
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("valid keep_alive rejected: %+v", issues)
+	}
+
+	cfg.Worker.KeepAlive.HTTP.Ping = time.Minute
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("expected error for an HTTP/1.1 ping")
+	}
+
+	cfg.Worker.KeepAlive.HTTP.Ping = 0
+	cfg.Worker.KeepAlive.GRPC.Ping = 2 * time.Second
+	found := false
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Path == "worker.keep_alive.grpc.ping" && iss.Severity == SeverityWarning {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected a warning for a gRPC ping below grpc-go's floor")
+	}
+}
+
+func TestValidateConfig_RegressionTolerances(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Report.Regression = Regression{Latency: 0.2, ErrorRate: 0.5}
+	if HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("valid tolerances rejected")
+	}
+	cfg.Report.Regression.TPS = -0.1
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("expected error for a negative tolerance")
+	}
+	if got := (Regression{Latency: 0.2}).WithDefaults(); got.Latency != 0.2 || got.TPS != DefaultRegressionTPS || got.LatencyFloor != time.Millisecond {
+		t.Fatalf("WithDefaults = %+v", got)
+	}
+}
+
+func TestValidateConfig_ReportSnapshot(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Report.Snapshot = "/nonexistent/dir/snap-{time}.json"
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("expected error for a missing snapshot directory")
+	}
+	cfg.Report.Snapshot = "-"
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("stdout snapshot rejected: %+v", issues)
+	}
+	if got := (Report{}).SnapshotPath(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)); got != "kar98k-snapshot-20260102-030405.json" {
+		t.Fatalf("default snapshot path = %q", got)
+	}
+}
+
+func TestValidateConfig_OutputSamples(t *testing.T) {
+	for _, tc := range []struct {
+		o       []OutputSink
+		wantErr bool
+	}{
+		{[]OutputSink{{Type: OutputSamples, Path: "s.csv.gz", SampleRate: 0.1, MaxSamples: 1000, MaxSize: "10MB"}}, false},
+		{[]OutputSink{{Type: OutputSamples, Path: "s.csv", SampleRate: 1.5}}, true},
+		{[]OutputSink{{Type: OutputSamples, Path: "s.csv", MaxSamples: -1}}, true},
+		{[]OutputSink{{Type: OutputJSON, Path: "r.json", SampleRate: 0.5}}, true},
+		{[]OutputSink{{Type: OutputSamples, Path: "a.csv"}, {Type: OutputSamples, Path: "b.csv"}}, true},
+	} {
+		cfg := goodConfig()
+		cfg.Output = tc.o
+		if got := HasErrors(ValidateConfig(cfg)); got != tc.wantErr {
+			t.Errorf("%+v: errors = %v, want %v", tc.o, got, tc.wantErr)
+		}
+	}
+}
+
+func TestValidateConfig_ReportSizeLatency(t *testing.T) {
+	for _, tc := range []struct {
+		s       SizeLatency
+		wantErr bool
+	}{
+		{SizeLatency{Enabled: true}, false},
+		{SizeLatency{Enabled: true, By: SizeByTotal, Buckets: []string{"4KB", "1MB"}}, false},
+		{SizeLatency{Enabled: true, By: "body"}, true},
+		{SizeLatency{Enabled: true, Buckets: []string{"1MB", "4KB"}}, true},
+		{SizeLatency{Enabled: true, Buckets: []string{"lots"}}, true},
+		{SizeLatency{By: "body"}, false},
+	} {
+		cfg := goodConfig()
+		cfg.Report.SizeLatency = tc.s
+		if got := HasErrors(ValidateConfig(cfg)); got != tc.wantErr {
+			t.Errorf("%+v: errors = %v, want %v", tc.s, got, tc.wantErr)
+		}
+	}
+}
+
+func TestLatencyFormat(t *testing.T) {
+	one := 1
+	for _, c := range []struct {
+		f    LatencyFormat
+		ms   float64
+		want string
+	}{
+		{LatencyFormat{}, 0.05, "50.00µs"},
+		{LatencyFormat{}, 12.345, "12.35ms"},
+		{LatencyFormat{}, 1200, "1.20s"},
+		{LatencyFormat{}, 0, "0.00ms"},
+		{LatencyFormat{Unit: LatencyUnitMillis}, 0.05, "0.05ms"},
+		{LatencyFormat{Unit: LatencyUnitMicros, Precision: &one}, 2.5, "2500.0µs"},
+		{LatencyFormat{Unit: LatencyUnitSeconds, Precision: &one}, 250, "0.2s"},
+	} {
+		if got := c.f.Format(c.ms); got != c.want {
+			t.Errorf("%+v.Format(%v) = %q, want %q", c.f, c.ms, got, c.want)
+		}
+	}
+
+	cfg := goodConfig()
+	cfg.Report.Latency = LatencyFormat{Unit: "ns"}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("unknown latency unit should be an error")
+	}
+	many := 9
+	cfg.Report.Latency = LatencyFormat{Unit: LatencyUnitMicros, Precision: &many}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("precision past the limit should be an error")
+	}
+}
+
+func TestValidateConfig_ErrorMatrix(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Report.ErrorMatrix = ErrorMatrixStatus
+	if HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("status grouping should be valid")
+	}
+	cfg.Report.ErrorMatrix = "code"
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("unknown error matrix grouping should be an error")
+	}
+}
+
+func TestValidateConfig_MaxLabelValues(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Metrics.MaxLabelValues = -2
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("max_label_values below -1 should be an error")
+	}
+
+	cfg = goodConfig()
+	cfg.Metrics.MaxLabelValues = 1
+	cfg.Targets = append(cfg.Targets, Target{Name: "web", URL: "http://localhost:8081/", Protocol: ProtocolHTTP, Method: "GET", Weight: 1})
+	var warned bool
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Severity == SeverityWarning && iss.Path == "metrics.max_label_values" {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatal("more targets than max_label_values should warn")
+	}
+	if got := (Metrics{}).LabelLimit(); got != DefaultMaxLabelValues {
+		t.Fatalf("default LabelLimit = %d, want %d", got, DefaultMaxLabelValues)
+	}
+}
+
+func TestValidateConfig_UnknownProtocol(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].Protocol = "htttp"
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("unknown protocol should be an error")
+	}
+
+	lenient := false
+	cfg.Worker.StrictProtocol = &lenient
+	issues := ValidateConfig(cfg)
+	if HasErrors(issues) {
+		t.Fatalf("unknown protocol with strict_protocol off should only warn, got %+v", issues)
+	}
+	var warned bool
+	for _, iss := range issues {
+		if iss.Path == "targets[0].protocol" && iss.Severity == SeverityWarning {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatal("unknown protocol with strict_protocol off should warn")
+	}
+}
+
+func TestLoad_UnknownProtocolFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kar.yaml")
+	yml := `
+targets:
+  - name: api
+    url: http://localhost:8080/
+    protocol: htttp
+`
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "htttp") {
+		t.Fatalf("err = %v, want it to name the unknown protocol", err)
+	}
+
+	yml += "worker:\n  strict_protocol: false\n"
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Fatalf("strict_protocol: false should load, got %v", err)
+	}
+}
+
+func TestMetricsListenAddresses(t *testing.T) {
+	m := Metrics{Address: ":9090", Addresses: []string{"127.0.0.1:9091", ":9090", ""}}
+	if got := m.ListenAddresses(); len(got) != 2 || got[0] != ":9090" || got[1] != "127.0.0.1:9091" {
+		t.Fatalf("ListenAddresses = %q", got)
+	}
+}
+
+func TestCacheBust_Vary(t *testing.T) {
+	cb := &CacheBust{}
+	if got := cb.Vary("http://cdn/a?x=1", 35); got != "http://cdn/a?x=1&_kar=z" {
+		t.Errorf("query vary = %q", got)
+	}
+	cb.Paths = []string{"/b", "/c?v=2"}
+	if got := cb.Vary("http://cdn/a?x=1", 1); got != "http://cdn/c?v=2" {
+		t.Errorf("path vary = %q", got)
+	}
+	if hit, ok := cb.Hit("TCP_HIT"); !hit || !ok {
+		t.Error("TCP_HIT should be a hit")
+	}
+	if hit, ok := cb.Hit("EXPIRED"); hit || !ok {
+		t.Error("EXPIRED should be a miss")
+	}
+	if _, ok := cb.Hit(""); ok {
+		t.Error("a missing header should be unclassified")
+	}
+}
+
+func TestValidateConfig_CacheBust(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].CacheBust = &CacheBust{Fraction: 0.3, Paths: []string{"/a"}, StatusHeader: "X-Cache"}
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("valid cache_bust rejected: %+v", issues)
+	}
+	cfg.Targets[0].CacheBust = &CacheBust{Fraction: 1.5, Paths: []string{"a"}}
+	n := 0
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Severity == SeverityError && strings.Contains(iss.Path, "cache_bust") {
+			n++
+		}
+	}
+	if n != 2 {
+		t.Fatalf("want errors for fraction and path, got %d", n)
+	}
+}
+
+func TestValidateConfig_Weighting(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Controller.Weighting = "fastest"
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("unknown weighting should be an error")
+	}
+
+	cfg = goodConfig()
+	cfg.Controller.Weighting = WeightingAdaptive
+	cfg.Controller.Adaptive.MinFactor = 1.5
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("min_factor above 1 should be an error")
+	}
+
+	cfg = goodConfig()
+	cfg.Controller.Adaptive.MaxStep = 0.1
+	var warned bool
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Severity == SeverityWarning && iss.Path == "controller.adaptive" {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatal("adaptive settings under static weighting should warn")
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	got, err := ParseTags(map[string]string{"env": "staging", "sha": "old"}, []string{"sha=abc123", "note=a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"env": "staging", "sha": "abc123", "note": "a=b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("tags = %v, want %v", got, want)
+	}
+	for _, bad := range []string{"novalue", "=x"} {
+		if _, err := ParseTags(nil, []string{bad}); err == nil {
+			t.Errorf("ParseTags(%q) should fail", bad)
+		}
+	}
+}
+
+func TestValidateConfig_RampTarget(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Controller.RampTargetTPS = cfg.Controller.MaxTPS + 1
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("ramp_target_tps above max_tps should be an error")
+	}
+
+	cfg = goodConfig()
+	cfg.Controller.RampUpDuration = 0
+	cfg.Controller.RampHold = time.Minute
+	var warned bool
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Severity == SeverityWarning && iss.Path == "controller.ramp_up_duration" {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatal("ramp_hold without ramp_up_duration should warn")
+	}
+}
+
+func TestValidateConfig_Methods(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].Methods = []WeightedMethod{{Method: "get", Weight: 9}, {Method: "POST", Body: `{}`}}
+	if iss := ValidateConfig(cfg); HasErrors(iss) {
+		t.Fatalf("valid method mix rejected: %+v", iss)
+	}
+
+	cfg.Targets[0].Methods = append(cfg.Targets[0].Methods, WeightedMethod{Method: "GET"})
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("a method listed twice should be an error")
+	}
+
+	cfg = goodConfig()
+	cfg.Targets[0].Methods = []WeightedMethod{{Method: "GET", Weight: -1}}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("a negative weight should be an error")
+	}
+}
+
+func TestValidateConfig_BodyTemplate(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].Body = `{"id": "{{uuid}}", "seq": {{counter}}}`
+	if iss := ValidateConfig(cfg); HasErrors(iss) {
+		t.Fatalf("valid body template rejected: %+v", iss)
+	}
+
+	cfg.Targets[0].Methods = []WeightedMethod{{Method: "POST", Body: `{"n": {{randInt 1}}}`}}
+	iss := ValidateConfig(cfg)
+	if !HasErrors(iss) {
+		t.Fatal("a malformed method body template should be an error")
+	}
+	if iss[0].Path != "targets[0].methods[0].body" {
+		t.Fatalf("issue path = %q", iss[0].Path)
+	}
+}
+
+func TestValidateConfig_Expect(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].Expect = &Expect{Status: []int{200, 201}, BodyContains: "ok"}
+	if iss := ValidateConfig(cfg); HasErrors(iss) {
+		t.Fatalf("valid expect rejected: %+v", iss)
+	}
+	cfg.Targets[0].Expect = &Expect{Status: []int{200, 42}}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("status 42 should be an error for an HTTP target")
+	}
+	cfg.Targets[0].Protocol = ProtocolGRPC
+	cfg.Targets[0].Expect = &Expect{Status: []int{0, 5}}
+	if iss := ValidateConfig(cfg); HasErrors(iss) {
+		t.Fatalf("gRPC codes rejected: %+v", iss)
+	}
+	cfg.Targets[0].Expect = &Expect{BodyContains: "ok"}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("body_contains on a gRPC target should be an error")
+	}
+}
+
+func TestValidateConfig_Duplicates(t *testing.T) {
+	for _, tc := range []struct {
+		d       Duplicates
+		wantErr bool
+	}{
+		{Duplicates{Field: "data.id", Marker: "already exists", Fail: true}, false},
+		{Duplicates{Body: true}, false},
+		{Duplicates{Fail: true}, true},
+		{Duplicates{Field: "id", Body: true}, true},
+		{Duplicates{Field: "data..id"}, true},
+		{Duplicates{Body: true, MaxTracked: -1}, true},
+	} {
+		cfg := goodConfig()
+		d := tc.d
+		cfg.Targets[0].Duplicates = &d
+		if got := HasErrors(ValidateConfig(cfg)); got != tc.wantErr {
+			t.Errorf("%+v: errors = %v, want %v", tc.d, got, tc.wantErr)
+		}
+	}
+
+	cfg := goodConfig()
+	cfg.Targets[0].Protocol = ProtocolGRPC
+	cfg.Targets[0].Duplicates = &Duplicates{Marker: "duplicate"}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("duplicates on a gRPC target should be an error")
+	}
+}
+
+func TestValidateConfig_GRPCCall(t *testing.T) {
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(grpc_health_v1.File_grpc_health_v1_health_proto),
+	}}
+	b, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protoset := filepath.Join(t.TempDir(), "health.protoset")
+	if err := os.WriteFile(protoset, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		protocol Protocol
+		call     GRPCCall
+		body     string
+		wantErr  bool
+	}{
+		{"reflection", ProtocolGRPC, GRPCCall{Method: "pkg.Svc/Do"}, `{}`, false},
+		{"protoset", ProtocolGRPC, GRPCCall{Method: "/grpc.health.v1.Health/Check", Protoset: protoset}, `{"service": "db"}`, false},
+		{"no method", ProtocolGRPC, GRPCCall{}, "", true},
+		{"not qualified", ProtocolGRPC, GRPCCall{Method: "Check"}, "", true},
+		{"missing protoset", ProtocolGRPC, GRPCCall{Method: "a.B/C", Protoset: protoset + ".gone"}, "", true},
+		{"unknown method", ProtocolGRPC, GRPCCall{Method: "grpc.health.v1.Health/Nope", Protoset: protoset}, "", true},
+		{"streaming method", ProtocolGRPC, GRPCCall{Method: "grpc.health.v1.Health/Watch", Protoset: protoset}, "", true},
+		{"body field unknown", ProtocolGRPC, GRPCCall{Method: "grpc.health.v1.Health/Check", Protoset: protoset}, `{"svc": "db"}`, true},
+		{"templated body left to the run", ProtocolGRPC, GRPCCall{Method: "grpc.health.v1.Health/Check", Protoset: protoset}, `{"svc": "{{uuid}}"}`, false},
+		{"gRPC-Web", ProtocolGRPCWeb, GRPCCall{Method: "a.B/C"}, "", true},
+	}
+	for _, tc := range cases {
+		cfg := goodConfig()
+		cfg.Targets[0].Protocol = tc.protocol
+		cfg.Targets[0].Body = tc.body
+		cfg.Targets[0].GRPC = &tc.call
+		if iss := ValidateConfig(cfg); HasErrors(iss) != tc.wantErr {
+			t.Errorf("%s: errors = %v, want %v: %+v", tc.name, HasErrors(iss), tc.wantErr, iss)
+		}
+	}
+}
+
+func TestExpect_MatchBody(t *testing.T) {
+	var none *Expect
+	if !none.MatchBody(nil) || none.CaptureBody() != 0 {
+		t.Fatal("nil expect should accept anything and capture nothing")
+	}
+	e := &Expect{BodyContains: `"ok"`}
+	if !e.MatchBody([]byte(`{"ok": true}`)) || e.MatchBody([]byte(`{"error": true}`)) {
+		t.Fatal("body_contains mismatch")
+	}
+	if e.CaptureBody() != ExpectBodyLimit {
+		t.Fatalf("capture = %d", e.CaptureBody())
+	}
+}
+
+func TestValidateConfig_TrackHeader(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].TrackHeader = "X-RateLimit-Remaining"
+	if iss := ValidateConfig(cfg); HasErrors(iss) {
+		t.Fatalf("track_header rejected: %+v", iss)
+	}
+	cfg.Targets[0].CacheBust = &CacheBust{StatusHeader: "X-Cache"}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("track_header with cache_bust.status_header should be an error")
+	}
+}
+
+func TestPercentileConfidence_ScalesWithPercentile(t *testing.T) {
+	var r Report // DefaultMinSamples beyond each percentile
+	for _, c := range []struct {
+		p    float64
+		n    int64
+		want Confidence
+	}{
+		{50, 50, ConfidenceOK},
+		{95, 50, ConfidenceLow},
+		{99, 50, ConfidenceNone},
+		{99, 499, ConfidenceLow},
+		{99, 500, ConfidenceOK},
+	} {
+		if got := r.PercentileConfidence(c.p, c.n); got != c.want {
+			t.Errorf("P%g from %d samples = %v, want %v", c.p, c.n, got, c.want)
+		}
+	}
+	if got := r.SamplesFor(99); got != 500 {
+		t.Errorf("SamplesFor(99) = %d, want 500", got)
+	}
+	if notes := (Report{MinSamples: -1}).PercentileNotes(3, 50, 99); notes != nil {
+		t.Errorf("min_samples -1 should report every percentile, got %+v", notes)
+	}
+}
+
+func TestValidateConfig_PostChecks(t *testing.T) {
+	cfg := goodConfig()
+	cfg.PostChecks = []PostCheck{{
+		Name: "counted",
+		URL:  "http://localhost:8080/api/stats",
+		JSON: []JSONCheck{{Path: "requests_total", Op: ">=", Value: "1000"}, {Path: "state", Value: "idle"}},
+	}}
+	if iss := ValidateConfig(cfg); HasErrors(iss) {
+		t.Fatalf("valid post check rejected: %+v", iss)
+	}
+
+	for name, c := range map[string]PostCheck{
+		"relative url":     {Name: "a", URL: "/api/stats"},
+		"unknown operator": {Name: "a", URL: "http://x/", JSON: []JSONCheck{{Path: "n", Op: "=~", Value: "1"}}},
+		"ordered string":   {Name: "a", URL: "http://x/", JSON: []JSONCheck{{Path: "n", Op: ">", Value: "many"}}},
+		"bad status":       {Name: "a", URL: "http://x/", Status: []int{42}},
+	} {
+		cfg := goodConfig()
+		cfg.PostChecks = []PostCheck{c}
+		if !HasErrors(ValidateConfig(cfg)) {
+			t.Errorf("%s should be an error", name)
+		}
+	}
+}
+
+func TestValidateConfig_Control(t *testing.T) {
+	for _, c := range []Control{{}, {Transport: ControlTCP}, {Transport: ControlTCP, Address: "localhost:9000"}} {
+		cfg := goodConfig()
+		cfg.Control = c
+		if iss := ValidateConfig(cfg); HasErrors(iss) {
+			t.Errorf("%+v rejected: %+v", c, iss)
+		}
+	}
+	for _, c := range []Control{{Transport: "pipe"}, {Transport: ControlTCP, Address: "0.0.0.0:7787"}} {
+		cfg := goodConfig()
+		cfg.Control = c
+		if !HasErrors(ValidateConfig(cfg)) {
+			t.Errorf("%+v should be an error", c)
+		}
+	}
+}
+
+func TestValidateConfig_TimeoutJitter(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].TimeoutJitter = 0.1
+	if iss := ValidateConfig(cfg); HasErrors(iss) {
+		t.Fatalf("timeout_jitter 0.1 rejected: %+v", iss)
+	}
+	cfg.Targets[0].TimeoutJitter = 1
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatal("timeout_jitter 1 should be an error")
+	}
+}
+
+func TestValidateConfig_Blend(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Pattern.Blend = []BlendSource{
+		{Name: "steady", Weight: 7, Noise: Noise{Enabled: true, Amplitude: 0.1}},
+		{Name: "spiky", Weight: 3, Poisson: Poisson{Enabled: true, Lambda: 0.01, SpikeFactor: 4}},
+	}
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+
+	for name, bad := range map[string]BlendSource{
+		"unnamed":         {Weight: 1},
+		"duplicate":       {Name: "steady"},
+		"negative weight": {Name: "x", Weight: -1},
+		"bad source":      {Name: "x", Poisson: Poisson{Enabled: true, Lambda: 0.01, SpikeFactor: 0.5}},
+	} {
+		cfg := goodConfig()
+		cfg.Pattern.Blend = []BlendSource{{Name: "steady"}, bad}
+		if !HasErrors(ValidateConfig(cfg)) {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestValidateConfig_HTTP2Settings(t *testing.T) {
+	cfg := goodConfig()
+	cfg.Targets[0].Protocol = ProtocolHTTP2
+	cfg.Targets[0].HTTP2 = HTTP2Settings{Connections: 4, MaxStreams: 100}
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+
+	cfg.Targets[0].HTTP2 = HTTP2Settings{Connections: -1}
+	if !HasErrors(ValidateConfig(cfg)) {
+		t.Fatalf("negative connections should be an error")
+	}
+
+	cfg = goodConfig()
+	cfg.Targets[0].Protocol = ProtocolHTTP
+	cfg.Targets[0].HTTP2 = HTTP2Settings{MaxStreams: 10}
+	var warned bool
+	for _, iss := range ValidateConfig(cfg) {
+		if iss.Path == "targets[0].http2" && iss.Severity == SeverityWarning {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatalf("http2 settings on an http target should warn")
+	}
+}
+
+func TestLoad_SoakSpikePreset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kar.yaml")
+	yml := `
+targets:
+  - name: api
+    url: http://localhost:8080/
+controller:
+  base_tps: 50
+  max_tps: 1000
+pattern:
+  preset: soak-spike
+  baseline: 200
+  spike_factor: 3
+  spike_every: 15m
+  spike_duration: 2m
+`
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := cfg.Pattern.Poisson
+	if cfg.Controller.BaseTPS != 200 || !p.Enabled || p.SpikeFactor != 3 ||
+		p.MinInterval != 15*time.Minute || p.MaxInterval != 15*time.Minute ||
+		p.RampUp+p.RampDown != 2*time.Minute {
+		t.Fatalf("preset not expanded: base %v, poisson %+v", cfg.Controller.BaseTPS, p)
+	}
+	if cfg.Pattern.Noise.Enabled {
+		t.Fatal("preset should turn the default noise off")
+	}
+	if issues := ValidateConfig(cfg); HasErrors(issues) {
+		t.Fatalf("unexpected error: %+v", issues)
+	}
+
+	for name, pat := range map[string]Pattern{
+		"unknown preset":  {Preset: "soak"},
+		"no preset":       {SoakSpike: SoakSpike{SpikeEvery: time.Minute}},
+		"no period":       {Preset: PresetSoakSpike, SoakSpike: SoakSpike{SpikeFactor: 2, SpikeDuration: time.Minute}},
+		"overlong spikes": {Preset: PresetSoakSpike, SoakSpike: SoakSpike{SpikeFactor: 2, SpikeEvery: time.Minute, SpikeDuration: time.Minute}},
+	} {
+		cfg := goodConfig()
+		cfg.Pattern = pat
+		if !HasErrors(ValidateConfig(cfg)) {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
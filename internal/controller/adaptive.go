@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/worker"
+)
+
+// targetWindowPool is implemented by pools that can report each
+// target's recent latency and errors (solo mode), which adaptive
+// weighting needs (#1234).
+type targetWindowPool interface {
+	TargetWindow() map[string]worker.TargetHealth
+}
+
+// adaptiveMinSamples is how many requests a target must complete in a
+// window before its factor moves; fewer is noise, not a trend.
+const adaptiveMinSamples = 10
+
+// adaptLoop recomputes the picker's weight factors every interval
+// under weighting: adaptive.
+func (c *Controller) adaptLoop(ctx context.Context, pool targetWindowPool) {
+	ticker := time.NewTicker(c.cfg.Adaptive.Every())
+	defer ticker.Stop()
+
+	pool.TargetWindow() // start the first window now, not at pool start
+	var factors map[string]float64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			window := pool.TargetWindow()
+			if c.paused.Load() {
+				continue
+			}
+			// Why: the per-target pattern path thins picks by each
+			// target's own rate, assuming picks follow the weights.
+			// Skewing them would undo that, so an explicit rate (a
+			// pattern or a `kar set-tps` pin) wins over adaptation.
+			if c.patterns.Load() != nil {
+				if factors != nil {
+					factors = nil
+					c.picker.SetFactors(nil)
+				}
+				continue
+			}
+			factors = nextFactors(factors, window, c.cfg.Adaptive)
+			c.picker.SetFactors(factors)
+		}
+	}
+}
+
+// nextFactors moves each target's weight factor toward its health
+// relative to the healthiest target: success ratio over mean latency,
+// so a target twice as slow as the best drifts toward half its weight
+// and one failing every request toward the floor. Each factor moves
+// at most cfg.Step() per call and stays within [cfg.Floor(), 1];
+// targets without enough samples in window keep their factor.
+func nextFactors(prev map[string]float64, window map[string]worker.TargetHealth, cfg config.AdaptiveWeighting) map[string]float64 {
+	scores := make(map[string]float64, len(window))
+	var best float64
+	for name, h := range window {
+		if h.Requests < adaptiveMinSamples {
+			continue
+		}
+		lat := math.Max(h.MeanLatency.Seconds(), time.Millisecond.Seconds())
+		s := (1 - float64(h.Errors)/float64(h.Requests)) / lat
+		scores[name] = s
+		best = math.Max(best, s)
+	}
+
+	next := make(map[string]float64, len(prev)+len(scores))
+	for name, f := range prev {
+		next[name] = f
+	}
+	// Every target failing everything gives no direction to move in.
+	if best <= 0 {
+		return next
+	}
+	floor, step := cfg.Floor(), cfg.Step()
+	for name, s := range scores {
+		cur, ok := next[name]
+		if !ok {
+			cur = 1
+		}
+		ideal := math.Max(floor, s/best)
+		next[name] = math.Min(1, math.Max(floor, cur+math.Max(-step, math.Min(step, ideal-cur))))
+	}
+	return next
+}
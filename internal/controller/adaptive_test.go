@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/worker"
+)
+
+func TestNextFactors_ShiftsTowardHealthyTargetsWithinBounds(t *testing.T) {
+	cfg := config.AdaptiveWeighting{MinFactor: 0.2, MaxStep: 0.25}
+	window := map[string]worker.TargetHealth{
+		"fast":    {Requests: 100, MeanLatency: 10 * time.Millisecond},
+		"slow":    {Requests: 100, MeanLatency: 40 * time.Millisecond},
+		"failing": {Requests: 100, Errors: 100, MeanLatency: time.Millisecond},
+		"quiet":   {Requests: 3, MeanLatency: time.Second},
+	}
+
+	// The first step is capped at max_step.
+	f := nextFactors(nil, window, cfg)
+	want := map[string]float64{"fast": 1, "slow": 0.75, "failing": 0.75}
+	for name, w := range want {
+		if math.Abs(f[name]-w) > 1e-9 {
+			t.Fatalf("step 1: %s = %v, want %v (all: %v)", name, f[name], w, f)
+		}
+	}
+	if _, ok := f["quiet"]; ok {
+		t.Fatalf("a target under the sample minimum must keep its factor: %v", f)
+	}
+
+	// Repeated windows settle at score ratio and the floor.
+	for i := 0; i < 10; i++ {
+		f = nextFactors(f, window, cfg)
+	}
+	want = map[string]float64{"fast": 1, "slow": 0.25, "failing": 0.2}
+	for name, w := range want {
+		if math.Abs(f[name]-w) > 1e-9 {
+			t.Fatalf("settled: %s = %v, want %v (all: %v)", name, f[name], w, f)
+		}
+	}
+
+	// Recovery climbs back no faster than max_step either.
+	window["slow"] = worker.TargetHealth{Requests: 100, MeanLatency: 10 * time.Millisecond}
+	f = nextFactors(f, window, cfg)
+	if math.Abs(f["slow"]-0.5) > 1e-9 {
+		t.Fatalf("recovery step = %v, want 0.5", f["slow"])
+	}
+}
+
+func TestNextFactors_AllFailingKeepsFactors(t *testing.T) {
+	prev := map[string]float64{"a": 0.5, "b": 1}
+	window := map[string]worker.TargetHealth{
+		"a": {Requests: 50, Errors: 50, MeanLatency: time.Millisecond},
+		"b": {Requests: 50, Errors: 50, MeanLatency: time.Millisecond},
+	}
+	f := nextFactors(prev, window, config.AdaptiveWeighting{})
+	if f["a"] != 0.5 || f["b"] != 1 {
+		t.Fatalf("factors moved with no healthy reference: %v", f)
+	}
+}
@@ -215,29 +215,34 @@ func (b *CircuitBreaker) publishStateLocked() {
 }
 
 // fireWebhook posts a small JSON payload to the operator-supplied URL
-// when the breaker transitions. Best-effort: failures are logged and
-// dropped so a flaky webhook doesn't block the breaker logic. Run
-// from a goroutine in trip/resumeLocked so we don't block tick under
-// b.mu.
+// when the breaker transitions. Run from a goroutine in
+// trip/resumeLocked so we don't block tick under b.mu.
 func (b *CircuitBreaker) fireWebhook(transition, reason string) {
-	if b.cfg.Webhook == "" {
-		return
-	}
-	payload, _ := json.Marshal(map[string]any{
+	postWebhook("breaker", b.cfg.Webhook, map[string]any{
 		"transition": transition,
 		"reason":     reason,
 		"at":         time.Now().UTC().Format(time.RFC3339),
 	})
-	req, err := http.NewRequest("POST", b.cfg.Webhook, bytes.NewReader(payload))
+}
+
+// postWebhook posts payload as JSON to url. Best-effort: failures are
+// logged under tag and dropped so a flaky webhook doesn't block the
+// caller's logic. An empty url is a no-op.
+func postWebhook(tag, url string, payload map[string]any) {
+	if url == "" {
+		return
+	}
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
 	if err != nil {
-		log.Printf("[breaker] webhook build failed: %v", err)
+		log.Printf("[%s] webhook build failed: %v", tag, err)
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
 	client := &http.Client{Timeout: 5 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Printf("[breaker] webhook POST failed: %v", err)
+		log.Printf("[%s] webhook POST failed: %v", tag, err)
 		return
 	}
 	resp.Body.Close()
@@ -63,7 +63,7 @@ func (f *fakePool) setP95Ms(v float64) {
 // the breaker tripped exactly once.
 func TestCircuitBreaker_TripsOnSustainedErrorRate(t *testing.T) {
 	pool := &fakePool{}
-	metrics := health.NewMetricsWithRegistry(prometheus.NewRegistry())
+	metrics := health.NewMetrics(prometheus.NewRegistry())
 	cfg := config.Safety{
 		Enabled:        true,
 		ErrorRateAbove: 50,
@@ -92,7 +92,7 @@ func TestCircuitBreaker_TripsOnSustainedErrorRate(t *testing.T) {
 // trip the breaker.
 func TestCircuitBreaker_DoesNotTripOnTransientSpike(t *testing.T) {
 	pool := &fakePool{}
-	metrics := health.NewMetricsWithRegistry(prometheus.NewRegistry())
+	metrics := health.NewMetrics(prometheus.NewRegistry())
 	cfg := config.Safety{
 		Enabled:        true,
 		ErrorRateAbove: 50,
@@ -121,7 +121,7 @@ func TestCircuitBreaker_DoesNotTripOnTransientSpike(t *testing.T) {
 // through trip → recovery → auto-resume.
 func TestCircuitBreaker_AutoResumesAfterRecovery(t *testing.T) {
 	pool := &fakePool{}
-	metrics := health.NewMetricsWithRegistry(prometheus.NewRegistry())
+	metrics := health.NewMetrics(prometheus.NewRegistry())
 	cfg := config.Safety{
 		Enabled:        true,
 		ErrorRateAbove: 50,
@@ -159,7 +159,7 @@ func TestCircuitBreaker_AutoResumesAfterRecovery(t *testing.T) {
 // current pool metrics.
 func TestCircuitBreaker_ManualResumeClearsOpenState(t *testing.T) {
 	pool := &fakePool{}
-	metrics := health.NewMetricsWithRegistry(prometheus.NewRegistry())
+	metrics := health.NewMetrics(prometheus.NewRegistry())
 	cfg := config.Safety{
 		Enabled:        true,
 		ErrorRateAbove: 50,
@@ -190,7 +190,7 @@ func TestCircuitBreaker_ManualResumeClearsOpenState(t *testing.T) {
 // latency check works the same way as error rate.
 func TestCircuitBreaker_LatencyThresholdAlsoTrips(t *testing.T) {
 	pool := &fakePool{}
-	metrics := health.NewMetricsWithRegistry(prometheus.NewRegistry())
+	metrics := health.NewMetrics(prometheus.NewRegistry())
 	cfg := config.Safety{
 		Enabled:         true,
 		P95LatencyAbove: 500 * time.Millisecond,
@@ -214,7 +214,7 @@ func TestCircuitBreaker_LatencyThresholdAlsoTrips(t *testing.T) {
 // state transitions happen.
 func TestCircuitBreaker_DisabledIsNoOp(t *testing.T) {
 	pool := &fakePool{}
-	metrics := health.NewMetricsWithRegistry(prometheus.NewRegistry())
+	metrics := health.NewMetrics(prometheus.NewRegistry())
 	cfg := config.Safety{Enabled: false}
 	b := newCircuitBreaker(cfg, pool, metrics)
 
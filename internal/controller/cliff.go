@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/health"
+)
+
+// cliffMinRequests is the fewest requests a window needs for its P95
+// to be compared or kept in the baseline; thinner windows (a pause, the
+// ramp's first seconds) are skipped.
+const cliffMinRequests = 20
+
+// cliffPool is the slice of *worker.Pool the cliff detector reads.
+type cliffPool interface {
+	EnableLatencyWindow()
+	LatencyWindow() (p95Ms float64, requests int64)
+}
+
+// CliffEvent is one detected latency cliff (#1252).
+type CliffEvent struct {
+	At         time.Time `json:"at"`
+	P95Ms      float64   `json:"p95_ms"`
+	BaselineMs float64   `json:"baseline_ms"`
+	Action     string    `json:"action"`
+}
+
+// CliffDetector watches the pool's windowed P95 for a sudden step up
+// relative to its recent baseline, the median P95 of the last
+// cfg.Baseline windows. A cliff is reported once; the baseline then
+// starts over, so the new level has to settle into a baseline of its
+// own before a further step can fire again.
+type CliffDetector struct {
+	cfg     config.Cliff
+	pool    cliffPool
+	metrics *health.Metrics
+	webhook string
+	onCliff func(CliffEvent)
+
+	mu       sync.Mutex
+	baseline []float64 // oldest first, at most cfg.Baseline
+	events   []CliffEvent
+}
+
+func newCliffDetector(cfg config.Cliff, pool cliffPool, metrics *health.Metrics, webhook string) *CliffDetector {
+	return &CliffDetector{cfg: cfg.WithDefaults(), pool: pool, metrics: metrics, webhook: webhook}
+}
+
+// Run samples the pool every window until ctx ends.
+func (d *CliffDetector) Run(ctx context.Context) {
+	d.pool.EnableLatencyWindow()
+	d.pool.LatencyWindow() // start the first window now
+	ticker := time.NewTicker(d.cfg.Window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p95, n := d.pool.LatencyWindow()
+			if ev, ok := d.observe(p95, n); ok {
+				d.fire(ev)
+			}
+		}
+	}
+}
+
+// observe feeds one window and reports whether it is a cliff.
+func (d *CliffDetector) observe(p95Ms float64, requests int64) (CliffEvent, bool) {
+	if requests < cliffMinRequests {
+		return CliffEvent{}, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.baseline) == d.cfg.Baseline {
+		base := median(d.baseline)
+		minMs := float64(d.cfg.MinP95) / float64(time.Millisecond)
+		if p95Ms >= base*d.cfg.Factor && p95Ms >= minMs {
+			ev := CliffEvent{At: time.Now(), P95Ms: p95Ms, BaselineMs: base, Action: d.cfg.Action}
+			d.events = append(d.events, ev)
+			d.baseline = d.baseline[:0]
+			return ev, true
+		}
+		d.baseline = d.baseline[1:]
+	}
+	d.baseline = append(d.baseline, p95Ms)
+	return CliffEvent{}, false
+}
+
+// fire alerts on ev and hands it to the attached reaction.
+func (d *CliffDetector) fire(ev CliffEvent) {
+	log.Printf("[cliff] P95 jumped to %.1fms from a %.1fms baseline (%.1f×); action %s",
+		ev.P95Ms, ev.BaselineMs, ev.P95Ms/ev.BaselineMs, ev.Action)
+	if d.metrics != nil {
+		d.metrics.IncLatencyCliffs()
+	}
+	go postWebhook("cliff", d.webhook, map[string]any{
+		"transition":  "cliff",
+		"reason":      "P95 latency cliff",
+		"p95_ms":      ev.P95Ms,
+		"baseline_ms": ev.BaselineMs,
+		"action":      ev.Action,
+		"at":          ev.At.UTC().Format(time.RFC3339),
+	})
+	if d.onCliff != nil {
+		d.onCliff(ev)
+	}
+}
+
+// Events returns the cliffs detected so far, oldest first.
+func (d *CliffDetector) Events() []CliffEvent {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]CliffEvent(nil), d.events...)
+}
+
+func median(xs []float64) float64 {
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
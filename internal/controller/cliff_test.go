@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+func newTestCliff(cfg config.Cliff) *CliffDetector {
+	return newCliffDetector(cfg, nil, nil, "")
+}
+
+func TestCliff_FiresOnStepAboveBaseline(t *testing.T) {
+	d := newTestCliff(config.Cliff{Enabled: true, Baseline: 3, Factor: 2, Action: config.CliffPause})
+	for _, p95 := range []float64{10, 12, 11} {
+		if _, ok := d.observe(p95, 100); ok {
+			t.Fatalf("fired while filling the baseline at %.0fms", p95)
+		}
+	}
+	if _, ok := d.observe(21, 100); ok {
+		t.Fatal("fired below factor × baseline (22ms)")
+	}
+	// Baseline is now 12, 11, 21: median 12.
+	ev, ok := d.observe(30, 100)
+	if !ok {
+		t.Fatal("no cliff at 30ms over a 12ms baseline")
+	}
+	if ev.BaselineMs != 12 || ev.P95Ms != 30 || ev.Action != config.CliffPause {
+		t.Fatalf("event = %+v", ev)
+	}
+	// The baseline starts over at the new level.
+	for _, p95 := range []float64{30, 31, 29, 32} {
+		if _, ok := d.observe(p95, 100); ok {
+			t.Fatalf("fired again at %.0fms after the reset", p95)
+		}
+	}
+	if got := len(d.Events()); got != 1 {
+		t.Fatalf("events = %d, want 1", got)
+	}
+}
+
+func TestCliff_SkipsThinWindows(t *testing.T) {
+	d := newTestCliff(config.Cliff{Enabled: true, Baseline: 2, Factor: 2})
+	d.observe(10, 100)
+	d.observe(10, 100)
+	if _, ok := d.observe(500, cliffMinRequests-1); ok {
+		t.Fatal("fired on a window below cliffMinRequests")
+	}
+	if _, ok := d.observe(500, cliffMinRequests); !ok {
+		t.Fatal("no cliff once the window is full enough")
+	}
+}
+
+func TestCliff_RespectsMinP95(t *testing.T) {
+	d := newTestCliff(config.Cliff{Enabled: true, Baseline: 2, Factor: 2, MinP95: 50 * time.Millisecond})
+	d.observe(1, 100)
+	d.observe(1, 100)
+	if _, ok := d.observe(10, 100); ok {
+		t.Fatal("fired on 10ms, below min_p95")
+	}
+	d.observe(1, 100) // baseline 10ms, 1ms: median 5.5ms
+	if _, ok := d.observe(60, 100); !ok {
+		t.Fatal("no cliff at 60ms over a 5.5ms baseline")
+	}
+}
+
+func TestCliff_EventsNilSafe(t *testing.T) {
+	var d *CliffDetector
+	if d.Events() != nil {
+		t.Fatal("nil detector returned events")
+	}
+}
@@ -3,7 +3,11 @@ package controller
 import (
 	"context"
 	"log"
+	"math"
+	"math/rand"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kar98k/internal/config"
@@ -41,6 +45,111 @@ type PoolFacade interface {
 // compile-time assertion that *worker.Pool satisfies PoolFacade.
 var _ PoolFacade = (*worker.Pool)(nil)
 
+// targetLatencyPool is implemented by pools that keep per-target
+// latency (solo mode). The master registry only sees pooled worker
+// histograms, so this is an optional capability rather than part of
+// PoolFacade. See #1177.
+type targetLatencyPool interface {
+	TargetLatencies() []worker.TargetLatency
+	TargetAvgLatencyPercentile(percentile float64) float64
+}
+
+// specStatsPool is implemented by pools that break requests down by
+// request-mix spec (#1182). Optional for the same reason as
+// targetLatencyPool.
+type specStatsPool interface {
+	SpecStats() []worker.SpecStat
+}
+
+// connPoolStatsPool is implemented by pools that can report their
+// connection pools (#1210).
+type connPoolStatsPool interface {
+	ConnPoolStats() []worker.ConnPoolStat
+}
+
+// cacheStatsPool is implemented by pools that split cache_bust
+// targets' latency into hits and misses (#1206).
+type cacheStatsPool interface {
+	CacheStats() []worker.CacheStat
+}
+
+// headerTrackPool is implemented by pools that sample track_header
+// targets' response header.
+type headerTrackPool interface {
+	HeaderTracks() []worker.HeaderTrack
+}
+
+// duplicatesPool is implemented by pools that check duplicates
+// targets' responses (#1256).
+type duplicatesPool interface {
+	Duplicates() []worker.DuplicateStat
+}
+
+// tlsPool is implemented by pools that count their clients' TLS
+// handshakes (#1257).
+type tlsPool interface {
+	TLSHandshakes() *worker.TLSStat
+}
+
+// http2StatsPool is implemented by pools that report http2 targets'
+// streams (#1248).
+type http2StatsPool interface {
+	HTTP2Stats() []worker.HTTP2Stat
+}
+
+// warmupPool is implemented by pools that keep warmup requests out of
+// their percentiles (#1207).
+type warmupPool interface {
+	Warmup() *worker.WarmupStats
+}
+
+// saturationPool is implemented by pools that can tell when their
+// workers can't keep up with the requested rate (#1222).
+type saturationPool interface {
+	Saturation() worker.Saturation
+}
+
+// ttfbPool is implemented by pools that keep a time-to-first-byte
+// histogram (#1185).
+type ttfbPool interface {
+	TTFBPercentile(percentile float64) float64
+}
+
+// achievedTPSPool is implemented by pools that measure their own
+// completed-request rate (solo mode).
+type achievedTPSPool interface {
+	AchievedTPS() float64
+}
+
+// pausablePool is implemented by pools that can hold execution without
+// losing their rate setting (solo mode). Pools without it — the master
+// registry — are paused by broadcasting a zero rate instead.
+type pausablePool interface {
+	Pause()
+	Resume()
+}
+
+// connLimitPool is implemented by pools that give targets with
+// max_conns_per_host their own capped client (#1188), or with their
+// own tls_insecure their own TLS settings (#1220). Other pools get the
+// shared per-protocol client.
+type connLimitPool interface {
+	ClientFor(t config.Target) protocol.Client
+	ConnQueued() int64
+}
+
+// targetTPSPool is implemented by pools that count completed requests
+// per target (#1201).
+type targetTPSPool interface {
+	TargetAchievedTPS() map[string]float64
+}
+
+// gcImpactPool is implemented by pools that track kar's own GC pauses
+// against requests (#1196).
+type gcImpactPool interface {
+	GCImpact() *worker.GCImpact
+}
+
 // Controller orchestrates traffic generation.
 type Controller struct {
 	cfg       config.Controller
@@ -52,13 +161,73 @@ type Controller struct {
 	metrics   *health.Metrics
 	scenarios *ScenarioRunner
 	breaker   *CircuitBreaker
+	cliff     *CliffDetector
 	submitter Submitter
 	picker    *targets.Picker
 
+	// patterns holds per-target engines for targets with their own
+	// pattern (#1195) and runtime rate pins (#1201); nil when every
+	// target follows engine. Set once at construction or by the first
+	// SetTargetTPS, hence atomic.
+	patterns atomic.Pointer[targetPatterns]
+
+	// rateBits is the last pool rate updateTPS set, as float64 bits.
+	rateBits atomic.Uint64
+
+	// multBits is the live rate multiplier from set-tps (#1232) as
+	// float64 bits, 0 for none. updateTPS applies it on top of the
+	// pattern, spikes and pins.
+	multBits atomic.Uint64
+
+	// rampBits is the ramp-up's current rate as float64 bits while the
+	// ramp or its hold runs, 0 otherwise (#1237). updateTPS applies it
+	// in place of the pattern.
+	rampBits atomic.Uint64
+
+	// fidelity compares rateBits against the pool's achieved rate
+	// over the run (#1221).
+	fidelity fidelityTracker
+
+	// paused is the operator pause from `kar pause` (#1183). It is
+	// separate from the circuit breaker so an auto-resume can't undo it.
+	paused atomic.Bool
+
+	// allUnhealthy is set while the health checker reports every
+	// target down; healthPolicy decides what generation does then
+	// (#1190).
+	allUnhealthy atomic.Bool
+	healthPolicy config.Health
+
+	// startAt and startDelay implement controller.start_jitter
+	// (#1192): a target gets no jobs until startDelay[name] has passed
+	// since startAt. Written once in Start, read-only afterwards.
+	startAt    time.Time
+	startDelay map[string]time.Duration
+
+	// longPoll are the targets with long_poll, driven by the pool's
+	// pollers rather than by submitted jobs.
+	longPoll []config.Target
+
+	// onPanic salvages the run when a controller goroutine panics
+	// (#1216); nil lets the panic crash the process as before.
+	onPanic PanicHandler
+
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 }
 
+// longPollPool is implemented by pools that can hold long-poll
+// targets' polls open (#1218).
+type longPollPool interface {
+	RunLongPoll(ctx context.Context, t config.Target, client protocol.Client)
+	LongPollStats() []worker.LongPollStat
+}
+
+// PanicHandler is told about a panic in one of the controller's
+// goroutines, named by component. That goroutine is gone, so the run
+// can't go on; the handler's job is to save what was gathered.
+type PanicHandler func(component string, v any, stack []byte)
+
 // NewController creates a new controller. Pass submitter to choose
 // between solo (LocalSubmitter) and master (NoopSubmitter) job
 // generation. A nil submitter defaults to LocalSubmitter so existing
@@ -72,9 +241,13 @@ func NewController(
 	metrics *health.Metrics,
 	submitter Submitter,
 ) *Controller {
+	// Long-poll targets (#1218) hold polls open instead of taking a
+	// share of the rate, so every rate computation leaves them out.
+	tgts, polled := splitLongPoll(tgts)
 	c := &Controller{
 		cfg:       cfg,
 		targets:   tgts,
+		longPoll:  polled,
 		engine:    engine,
 		scheduler: NewScheduler(cfg.Schedule),
 		pool:      pool,
@@ -82,6 +255,11 @@ func NewController(
 		metrics:   metrics,
 		picker:    targets.New(tgts),
 	}
+	var seed int64
+	if engine != nil {
+		seed = engine.Seed()
+	}
+	c.patterns.Store(newTargetPatterns(tgts, cfg.BaseTPS, cfg.MaxTPS, seed))
 	if submitter == nil {
 		submitter = &LocalSubmitter{c: c}
 	} else if ls, ok := submitter.(*LocalSubmitter); ok && ls.c == nil {
@@ -90,9 +268,27 @@ func NewController(
 		ls.c = c
 	}
 	c.submitter = submitter
+	if engine != nil {
+		// Why: targets with their own pattern are driven by their own
+		// engine, so they stay out of the global per-target noise mean.
+		engine.SetTargets(globalTargets(tgts))
+	}
 	return c
 }
 
+// splitLongPoll separates the long_poll targets from the rate-driven
+// ones.
+func splitLongPoll(tgts []config.Target) (rated, polled []config.Target) {
+	for _, t := range tgts {
+		if t.LongPoll != nil {
+			polled = append(polled, t)
+		} else {
+			rated = append(rated, t)
+		}
+	}
+	return rated, polled
+}
+
 // AttachScenarios opts the controller into multi-phase mode. Pass an
 // empty/nil slice to keep the existing single-pattern behaviour. The
 // runner starts when Controller.Start is called.
@@ -108,6 +304,12 @@ func (c *Controller) AttachScenarios(scenarios []config.Scenario, defaultPattern
 	c.scenarios = r
 }
 
+// ScenariosDone is closed once the scenario timeline has completed.
+// Without scenarios it never closes.
+func (c *Controller) ScenariosDone() <-chan struct{} {
+	return c.scenarios.Done()
+}
+
 // AttachSafety opts the controller into circuit-breaker mode. When
 // safety.Enabled is false the call is a no-op and the breaker
 // goroutine never runs. pool must be the concrete *worker.Pool; the
@@ -119,6 +321,73 @@ func (c *Controller) AttachSafety(safety config.Safety, pool *worker.Pool) {
 	c.breaker = NewCircuitBreaker(safety, pool, c.metrics)
 }
 
+// AttachCliff opts the controller into latency cliff detection
+// (#1252); onCliff, which may be nil, runs on each cliff after it is
+// logged and alerted. A no-op unless safety.cliff.enabled. Like the
+// breaker it needs the local pool's latencies, so skip it in master
+// mode.
+func (c *Controller) AttachCliff(safety config.Safety, pool *worker.Pool, onCliff func(CliffEvent)) {
+	if !safety.Cliff.Enabled || pool == nil {
+		return
+	}
+	c.cliff = newCliffDetector(safety.Cliff, pool, c.metrics, safety.Webhook)
+	c.cliff.onCliff = onCliff
+}
+
+// AttachHealthPolicy sets what happens while every target is
+// unhealthy. Without it the controller pauses generation.
+func (c *Controller) AttachHealthPolicy(h config.Health) {
+	c.healthPolicy = h
+}
+
+// AttachPanicHandler routes panics in the controller's goroutines to
+// h instead of crashing the process outright.
+func (c *Controller) AttachPanicHandler(h PanicHandler) {
+	c.onPanic = h
+}
+
+// guard is deferred at the top of each controller goroutine. It only
+// recovers when a handler is attached, so without one the panic
+// propagates untouched.
+func (c *Controller) guard(component string) {
+	if c.onPanic == nil {
+		return
+	}
+	if v := recover(); v != nil {
+		c.onPanic(component, v, debug.Stack())
+	}
+}
+
+// probing reports whether unhealthy targets should still get traffic
+// because every target is down and the policy is to probe.
+func (c *Controller) probing() bool {
+	return c.allUnhealthy.Load() && c.healthPolicy.UnhealthyAction() == config.AllUnhealthyProbe
+}
+
+// checkAllUnhealthy refreshes allUnhealthy from the checker and logs
+// transitions, so "firing but nothing happens" is never silent.
+func (c *Controller) checkAllUnhealthy() bool {
+	all := c.checker != nil && c.checker.AllUnhealthy()
+	if was := c.allUnhealthy.Swap(all); was != all {
+		switch {
+		case !all:
+			log.Printf("[controller] targets recovered, resuming normal generation")
+		case c.healthPolicy.UnhealthyAction() == config.AllUnhealthyProbe:
+			log.Printf("[controller] WARNING: all targets unhealthy, probing at %.1f TPS until one recovers", c.probeTPS())
+		default:
+			log.Printf("[controller] WARNING: all targets unhealthy, generation paused until one recovers")
+		}
+	}
+	return all
+}
+
+func (c *Controller) probeTPS() float64 {
+	if c.healthPolicy.ProbeTPS > 0 {
+		return c.healthPolicy.ProbeTPS
+	}
+	return config.DefaultProbeTPS
+}
+
 // ManualResume forwards to the breaker so `kar resume` can clear an
 // open circuit. No-op when safety is disabled or the breaker is
 // already closed.
@@ -132,19 +401,98 @@ func (c *Controller) BreakerOpen() (bool, time.Time) {
 	return c.breaker.State()
 }
 
+// Pause freezes traffic generation without tearing anything down:
+// cumulative counters and histograms are kept, and the pattern and
+// scenario timelines stop so Resume continues from the same phase.
+// Idempotent.
+func (c *Controller) Pause() {
+	if !c.paused.CompareAndSwap(false, true) {
+		return
+	}
+	c.engine.Freeze()
+	c.patterns.Load().freeze()
+	c.scenarios.Pause()
+	if pp, ok := c.pool.(pausablePool); ok {
+		pp.Pause()
+	} else {
+		c.pool.SetRate(0)
+	}
+	c.metrics.SetSpikeActive(false)
+	log.Printf("[controller] paused")
+}
+
+// Resume undoes Pause. The pool stays held if the circuit breaker
+// tripped in the meantime; the breaker releases it as usual.
+// Idempotent.
+func (c *Controller) Resume() {
+	if !c.paused.CompareAndSwap(true, false) {
+		return
+	}
+	if c.rampBits.Load() == 0 {
+		c.engine.Thaw()
+		c.patterns.Load().thaw()
+	}
+	c.scenarios.Resume()
+	if pp, ok := c.pool.(pausablePool); ok {
+		if open, _ := c.BreakerOpen(); !open {
+			pp.Resume()
+		}
+	}
+	log.Printf("[controller] resumed")
+}
+
+// IsPaused reports whether an operator pause is in effect.
+func (c *Controller) IsPaused() bool {
+	return c.paused.Load()
+}
+
 // Start begins traffic generation.
 func (c *Controller) Start(ctx context.Context) {
 	ctx, c.cancel = context.WithCancel(ctx)
 
+	c.engine.Start()
+	c.patterns.Load().start()
+	if c.cfg.RampUpDuration > 0 {
+		// Why: the spike timeline stays still until the ramp and hold
+		// are over, so the first spike can't land on a deliberate ramp.
+		c.rampBits.Store(math.Float64bits(1))
+		c.engine.Freeze()
+		c.patterns.Load().freeze()
+	}
+
+	if c.cfg.StartJitter > 0 {
+		c.startAt = time.Now()
+		c.startDelay = startDelays(c.targets, c.cfg.StartJitter, rand.Int63n)
+	}
+
 	// Ramp-up phase
 	if c.cfg.RampUpDuration > 0 {
 		c.wg.Add(1)
-		go c.rampUp(ctx)
+		go func() {
+			defer c.guard("ramp_up")
+			c.rampUp(ctx)
+		}()
 	}
 
 	// Main control loop
 	c.wg.Add(1)
-	go c.controlLoop(ctx)
+	go func() {
+		defer c.guard("control_loop")
+		c.controlLoop(ctx)
+	}()
+
+	// Adaptive weighting needs per-target windows, which only a local
+	// pool keeps; the master registry keeps the configured weights.
+	if c.cfg.Weighting == config.WeightingAdaptive {
+		if wp, ok := c.pool.(targetWindowPool); ok {
+			c.wg.Add(1)
+			go func() {
+				defer c.wg.Done()
+				defer c.guard("adaptive_weighting")
+				c.adaptLoop(ctx, wp)
+			}()
+		}
+	}
 
 	// Job generation strategy — LocalSubmitter runs the per-ms loop in
 	// solo mode; NoopSubmitter returns immediately in master mode where
@@ -152,6 +500,7 @@ func (c *Controller) Start(ctx context.Context) {
 	c.wg.Add(1)
 	go func() {
 		defer c.wg.Done()
+		defer c.guard("submitter")
 		c.submitter.Run(ctx)
 	}()
 
@@ -160,18 +509,46 @@ func (c *Controller) Start(ctx context.Context) {
 		c.wg.Add(1)
 		go func() {
 			defer c.wg.Done()
+			defer c.guard("scenarios")
 			c.scenarios.Run(ctx)
 		}()
 	}
 
+	// Long-poll targets: a fixed number of polls held open each.
+	if lp, ok := c.pool.(longPollPool); ok {
+		for _, t := range c.longPoll {
+			c.wg.Add(1)
+			go func() {
+				defer c.wg.Done()
+				defer c.guard("long_poll")
+				client := c.pool.GetClient(t.Protocol)
+				if cp, ok := c.pool.(connLimitPool); ok {
+					client = cp.ClientFor(t)
+				}
+				lp.RunLongPoll(ctx, t, client)
+			}()
+		}
+	} else if len(c.longPoll) > 0 {
+		log.Printf("[controller] WARNING: %d long_poll target(s) get no traffic: long polls run on the local pool only", len(c.longPoll))
+	}
+
 	// Circuit breaker watcher (safety mode only).
 	if c.breaker != nil {
 		c.wg.Add(1)
 		go func() {
 			defer c.wg.Done()
+			defer c.guard("breaker")
 			c.breaker.Run(ctx)
 		}()
 	}
+	if c.cliff != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			defer c.guard("cliff")
+			c.cliff.Run(ctx)
+		}()
+	}
 
 	log.Printf("[controller] started with base TPS %.0f, max TPS %.0f", c.cfg.BaseTPS, c.cfg.MaxTPS)
 }
@@ -180,34 +557,70 @@ func (c *Controller) Start(ctx context.Context) {
 func (c *Controller) rampUp(ctx context.Context) {
 	defer c.wg.Done()
 
-	startTime := time.Now()
-	startTPS := 1.0
-	targetTPS := c.cfg.BaseTPS
+	// Why: elapsed only advances while unpaused, so a pause mid-ramp
+	// resumes the ramp instead of jumping to its end.
+	var elapsed time.Duration
+	last := time.Now()
+	targetTPS := c.cfg.RampTarget()
+	holding := false
 
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
-	log.Printf("[controller] starting ramp-up over %s", c.cfg.RampUpDuration)
+	log.Printf("[controller] starting ramp-up to %.0f TPS over %s", targetTPS, c.cfg.RampUpDuration)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			elapsed := time.Since(startTime)
-			if elapsed >= c.cfg.RampUpDuration {
-				c.pool.SetRate(targetTPS)
-				log.Printf("[controller] ramp-up complete at %.0f TPS", targetTPS)
+		case now := <-ticker.C:
+			if c.paused.Load() {
+				last = now
+				continue
+			}
+			elapsed += now.Sub(last)
+			last = now
+			tps, done := rampRate(elapsed, c.cfg.RampUpDuration, c.cfg.RampHold, targetTPS)
+			if done {
+				c.endRamp()
+				log.Printf("[controller] ramp-up complete; the pattern takes over")
 				return
 			}
-
-			progress := float64(elapsed) / float64(c.cfg.RampUpDuration)
-			currentTPS := startTPS + (targetTPS-startTPS)*progress
-			c.pool.SetRate(currentTPS)
+			if !holding && elapsed >= c.cfg.RampUpDuration {
+				holding = true
+				log.Printf("[controller] ramp-up reached %.0f TPS; holding for %s", targetTPS, c.cfg.RampHold)
+			}
+			c.rampBits.Store(math.Float64bits(tps))
+			c.pool.SetRate(tps)
 		}
 	}
 }
 
+// rampRate is the ramp-up's rate after elapsed: linear from 1 TPS to
+// target over ramp, then target for hold. done is set once both have
+// passed.
+func rampRate(elapsed, ramp, hold time.Duration, target float64) (tps float64, done bool) {
+	const startTPS = 1.0
+	switch {
+	case elapsed >= ramp+hold:
+		return target, true
+	case elapsed >= ramp:
+		return target, false
+	}
+	return startTPS + (target-startTPS)*float64(elapsed)/float64(ramp), false
+}
+
+// endRamp hands the rate back to the pattern and restarts the spike
+// timelines frozen for the ramp, unless an operator pause still holds
+// them.
+func (c *Controller) endRamp() {
+	c.rampBits.Store(0)
+	if !c.paused.Load() {
+		c.engine.Thaw()
+		c.patterns.Load().thaw()
+	}
+}
+
 // controlLoop periodically updates the target TPS.
 func (c *Controller) controlLoop(ctx context.Context) {
 	defer c.wg.Done()
@@ -215,29 +628,56 @@ func (c *Controller) controlLoop(ctx context.Context) {
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
+	var ticks int
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			if c.paused.Load() {
+				continue
+			}
 			c.updateTPS()
+			if ticks++; ticks%10 == 0 {
+				c.sampleFidelity()
+			}
 		}
 	}
 }
 
 // updateTPS calculates and applies the current target TPS.
 func (c *Controller) updateTPS() {
+	// The ramp-up sets the rate itself until it is done (#1237).
+	if r := c.rampBits.Load(); r != 0 {
+		c.rateBits.Store(r)
+		return
+	}
+
 	// Get schedule multiplier
 	schedMult := c.scheduler.GetMultiplier()
 
 	// Calculate TPS using pattern engine
 	tps := c.engine.CalculateTPS(schedMult)
+	patterns := c.patterns.Load()
+	if patterns != nil {
+		tps = patterns.update(c.engine, tps, schedMult)
+	}
+	if mult := c.Multiplier(); mult != 1 {
+		tps *= mult
+		if c.cfg.MaxTPS > 0 {
+			tps = math.Min(tps, c.cfg.MaxTPS)
+		}
+	}
+	if c.checkAllUnhealthy() && c.probing() && tps > c.probeTPS() {
+		tps = c.probeTPS()
+	}
 
 	// Update pool rate
 	c.pool.SetRate(tps)
+	c.rateBits.Store(math.Float64bits(tps))
 
 	// Update spike metric
-	c.metrics.SetSpikeActive(c.engine.IsSpiking())
+	c.metrics.SetSpikeActive(c.engine.IsSpiking() || patterns.spiking())
 }
 
 // generateLoop continuously submits jobs to the worker pool.
@@ -254,11 +694,24 @@ func (c *Controller) generateLoop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			if c.paused.Load() {
+				continue
+			}
 			c.submitJobs(ctx)
 		}
 	}
 }
 
+// startDelays draws each target's first-request offset uniformly from
+// [0, window). randN is rand.Int63n, injectable for tests.
+func startDelays(tgts []config.Target, window time.Duration, randN func(int64) int64) map[string]time.Duration {
+	delays := make(map[string]time.Duration, len(tgts))
+	for _, t := range tgts {
+		delays[t.Name] = time.Duration(randN(int64(window)))
+	}
+	return delays
+}
+
 // submitJobs submits jobs to the worker pool.
 func (c *Controller) submitJobs(ctx context.Context) {
 	// Submit multiple jobs per tick to keep the pool fed
@@ -275,15 +728,38 @@ func (c *Controller) submitJobs(ctx context.Context) {
 			continue
 		}
 
-		// Skip unhealthy targets
-		if c.checker != nil && !c.checker.IsHealthy(target.Name) {
+		if d := c.startDelay[target.Name]; d > 0 && time.Since(c.startAt) < d {
+			continue
+		}
+
+		// Per-target noise (#1178): thin each pick by its target's
+		// current noise so target i is sent at a rate proportional to
+		// weight_i × noise_i. The engine already folded the weighted
+		// mean into the pool rate, so the totals still add up. With
+		// per-target patterns (#1195) accept covers noise as well.
+		if patterns := c.patterns.Load(); patterns != nil {
+			if !patterns.accept(target.Name) {
+				continue
+			}
+		} else if n := c.engine.TargetNoise(target.Name); n != 1.0 {
+			if rand.Float64()*c.engine.TargetNoiseCeiling() > n {
+				continue
+			}
+		}
+
+		// Skip unhealthy targets, unless all of them are down and the
+		// policy is to keep probing.
+		if c.checker != nil && !c.checker.IsHealthy(target.Name) && !c.probing() {
 			continue
 		}
 
 		job := worker.Job{
-			Target: *target,
+			Target: c.picker.PickRequest(target),
 			Client: c.pool.GetClient(target.Protocol),
 		}
+		if cp, ok := c.pool.(connLimitPool); ok {
+			job.Client = cp.ClientFor(*target)
+		}
 
 		if !c.pool.Submit(job) {
 			// Queue full, back off
@@ -322,11 +798,69 @@ type Status struct {
 	LatencyP99Raw       float64
 	LatencyP95Corrected float64
 	LatencyP99Corrected float64
+	// LatencyP9xTargetAvg average each target's own raw percentile
+	// with equal weight, as opposed to the pooled (request-weighted)
+	// Raw values above. TargetLatency holds the per-target breakdown.
+	// All are zero when the pool does not track per-target latency.
+	LatencyP95TargetAvg float64
+	LatencyP99TargetAvg float64
+	TargetLatency       []worker.TargetLatency
 	PatternStatus       pattern.Status
 	// Scenario describes the active phase when scenarios mode is on.
 	// Total == 0 means scenarios mode is disabled and the single
 	// top-level pattern is in effect.
 	Scenario ScenarioStatus
+	// SpecStats is the per-request-spec success/error breakdown for
+	// targets that define `requests:` (#1182).
+	SpecStats []worker.SpecStat
+	// TTFBP95/P99 are time-to-first-byte percentiles in milliseconds;
+	// zero unless a target sets record_ttfb or first_byte_only.
+	TTFBP95 float64
+	TTFBP99 float64
+	// AchievedTPS is completed requests in the last second; zero when
+	// the pool doesn't measure it.
+	AchievedTPS float64
+	// ConnQueued counts requests that blocked waiting for a connection
+	// on a max_conns_per_host target.
+	ConnQueued int64
+	// AllUnhealthy is set while every target is unhealthy; generation
+	// is then paused or probing per health.on_all_unhealthy.
+	AllUnhealthy bool
+	// GCImpact summarises kar's own GC pauses and the requests they
+	// overlapped; nil unless report.gc_impact is on.
+	GCImpact *worker.GCImpact
+	// TargetRates is each target's requested and achieved TPS, in
+	// config order.
+	TargetRates []TargetRate
+	// Multiplier is the live rate multiplier set with set-tps, 1 when
+	// unset (#1232).
+	Multiplier float64
+	// CacheStats is the hit/miss breakdown of cache_bust targets.
+	CacheStats []worker.CacheStat
+	// LongPoll is the long_poll targets' polls and hold times.
+	LongPoll []worker.LongPollStat
+	// ConnPool is kar's connections per protocol and host.
+	ConnPool []worker.ConnPoolStat
+	// HTTP2 is the streams of http2 targets with an http2 block.
+	HTTP2 []worker.HTTP2Stat
+	// HeaderTracks is the track_header targets' header so far.
+	HeaderTracks []worker.HeaderTrack
+	// Duplicates is the duplicates targets' check so far.
+	Duplicates []worker.DuplicateStat
+	// TLS is the full and resumed TLS handshakes so far; nil before
+	// the first.
+	TLS *worker.TLSStat
+	// Cliffs are the latency cliffs detected so far.
+	Cliffs []CliffEvent
+	// Warmup describes the requests excluded as warmup; nil unless
+	// controller.warmup_requests or warmup_duration is set.
+	Warmup *worker.WarmupStats
+	// Fidelity is achieved ÷ requested TPS over the run; nil until the
+	// first second or when the pool doesn't measure its own rate.
+	Fidelity *Fidelity
+	// Saturation says whether the pool's workers keep up; nil when the
+	// pool can't tell.
+	Saturation *worker.Saturation
 }
 
 // GetStatus returns the current status.
@@ -337,6 +871,7 @@ func (c *Controller) GetStatus() Status {
 		BaseTPS:             c.cfg.BaseTPS,
 		MaxTPS:              c.cfg.MaxTPS,
 		ScheduleMultiplier:  schedInfo.CurrentMultiplier,
+		Multiplier:          c.Multiplier(),
 		CurrentHour:         schedInfo.CurrentHour,
 		ActiveWorkers:       c.pool.Active(),
 		QueueSize:           c.pool.QueueSize(),
@@ -347,6 +882,7 @@ func (c *Controller) GetStatus() Status {
 		LatencyP95Corrected: c.pool.LatencyPercentile(95, true),
 		LatencyP99Corrected: c.pool.LatencyPercentile(99, true),
 		PatternStatus:       c.engine.GetStatus(),
+		AllUnhealthy:        c.allUnhealthy.Load(),
 	}
 	// Why: in master mode AttachScenarios is sometimes skipped, leaving
 	// c.scenarios nil. Calling Status on the nil pointer is safe today
@@ -354,5 +890,57 @@ func (c *Controller) GetStatus() Status {
 	if c.scenarios != nil {
 		st.Scenario = c.scenarios.Status()
 	}
+	if tp, ok := c.pool.(targetLatencyPool); ok {
+		st.LatencyP95TargetAvg = tp.TargetAvgLatencyPercentile(95)
+		st.LatencyP99TargetAvg = tp.TargetAvgLatencyPercentile(99)
+		st.TargetLatency = tp.TargetLatencies()
+	}
+	if sp, ok := c.pool.(specStatsPool); ok {
+		st.SpecStats = sp.SpecStats()
+	}
+	if ap, ok := c.pool.(achievedTPSPool); ok {
+		st.AchievedTPS = ap.AchievedTPS()
+	}
+	if tp, ok := c.pool.(ttfbPool); ok {
+		st.TTFBP95 = tp.TTFBPercentile(95)
+		st.TTFBP99 = tp.TTFBPercentile(99)
+	}
+	if cp, ok := c.pool.(connLimitPool); ok {
+		st.ConnQueued = cp.ConnQueued()
+	}
+	if gp, ok := c.pool.(gcImpactPool); ok {
+		st.GCImpact = gp.GCImpact()
+	}
+	if cp, ok := c.pool.(cacheStatsPool); ok {
+		st.CacheStats = cp.CacheStats()
+	}
+	if cp, ok := c.pool.(connPoolStatsPool); ok {
+		st.ConnPool = cp.ConnPoolStats()
+	}
+	if hp, ok := c.pool.(http2StatsPool); ok {
+		st.HTTP2 = hp.HTTP2Stats()
+	}
+	if hp, ok := c.pool.(headerTrackPool); ok {
+		st.HeaderTracks = hp.HeaderTracks()
+	}
+	if dp, ok := c.pool.(duplicatesPool); ok {
+		st.Duplicates = dp.Duplicates()
+	}
+	if tp, ok := c.pool.(tlsPool); ok {
+		st.TLS = tp.TLSHandshakes()
+	}
+	st.Cliffs = c.cliff.Events()
+	if lp, ok := c.pool.(longPollPool); ok && len(c.longPoll) > 0 {
+		st.LongPoll = lp.LongPollStats()
+	}
+	if wp, ok := c.pool.(warmupPool); ok {
+		st.Warmup = wp.Warmup()
+	}
+	st.TargetRates = c.targetRates()
+	st.Fidelity = c.Fidelity()
+	if sp, ok := c.pool.(saturationPool); ok {
+		sat := sp.Saturation()
+		st.Saturation = &sat
+	}
 	return st
 }
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"log"
+	"math"
+	"sync"
+)
+
+// Fidelity is how much of the requested load the run delivered
+// (#1221): achieved TPS ÷ requested TPS, both averaged over the
+// seconds traffic was meant to flow. A low ratio means kar's workers
+// or the target couldn't keep up, and the latency figures describe a
+// lighter load than the one configured.
+type Fidelity struct {
+	RequestedTPS float64 `json:"requested_tps"`
+	AchievedTPS  float64 `json:"achieved_tps"`
+	Ratio        float64 `json:"ratio"`
+	// Threshold is controller.fidelity_warn; Low is Ratio below it.
+	Threshold float64 `json:"threshold"`
+	Low       bool    `json:"low,omitempty"`
+}
+
+// Percent is Ratio as a percentage.
+func (f Fidelity) Percent() float64 { return f.Ratio * 100 }
+
+// fidelityMinSeconds is how many samples the ratio needs before it is
+// judged, so the first second's empty pipeline doesn't raise a warning.
+const fidelityMinSeconds = 10
+
+// fidelityTracker sums requested and achieved TPS, one sample a second.
+type fidelityTracker struct {
+	mu        sync.Mutex
+	requested float64
+	achieved  float64
+	seconds   int
+	warned    bool
+}
+
+// sampleFidelity records the last second: the rate the control loop
+// asked for against what the pool completed. Seconds with generation
+// held (all targets unhealthy, pause policy) ask for nothing and are
+// skipped. Pools that don't measure their own rate — the master
+// registry — leave fidelity unknown.
+func (c *Controller) sampleFidelity() {
+	ap, ok := c.pool.(achievedTPSPool)
+	if !ok || (c.allUnhealthy.Load() && !c.probing()) {
+		return
+	}
+	requested := math.Float64frombits(c.rateBits.Load())
+	if requested <= 0 {
+		return
+	}
+
+	f := &c.fidelity
+	f.mu.Lock()
+	f.requested += requested
+	f.achieved += ap.AchievedTPS()
+	f.seconds++
+	ratio := f.achieved / f.requested
+	warn := !f.warned && f.seconds >= fidelityMinSeconds && ratio < c.cfg.FidelityThreshold()
+	if warn {
+		f.warned = true
+	}
+	f.mu.Unlock()
+
+	c.metrics.SetTPSFidelity(ratio)
+	if warn {
+		log.Printf("[controller] WARNING: delivering %.0f%% of the requested TPS: kar's workers or the target can't keep up", ratio*100)
+	}
+}
+
+// Fidelity returns the run's fidelity so far, or nil before there is
+// anything to judge.
+func (c *Controller) Fidelity() *Fidelity {
+	f := &c.fidelity
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.seconds == 0 {
+		return nil
+	}
+	n := float64(f.seconds)
+	out := &Fidelity{
+		RequestedTPS: f.requested / n,
+		AchievedTPS:  f.achieved / n,
+		Ratio:        f.achieved / f.requested,
+		Threshold:    c.cfg.FidelityThreshold(),
+	}
+	out.Low = f.seconds >= fidelityMinSeconds && out.Ratio < out.Threshold
+	return out
+}
@@ -30,7 +30,7 @@ func ForecastTimeline(
 			cfg.Pattern,
 			cfg.Controller.BaseTPS,
 			cfg.Controller.MaxTPS,
-			sched.GetMultiplierForHour,
+			sched.MultiplierAt,
 			start,
 			duration,
 			resolution,
@@ -80,7 +80,7 @@ func forecastScenarios(
 			phasePat,
 			phaseBaseTPS,
 			phaseMaxTPS,
-			sched.GetMultiplierForHour,
+			sched.MultiplierAt,
 			cursor,
 			sc.Duration,
 			resolution,
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/health"
+	"github.com/kar98k/internal/pattern"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestStartDelays_WithinWindow(t *testing.T) {
+	tgts := []config.Target{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	window := time.Second
+	// Deterministic "random" offsets: 0, window/2, window-1.
+	draws := []int64{0, int64(window / 2), int64(window) - 1}
+	i := 0
+	delays := startDelays(tgts, window, func(n int64) int64 {
+		if n != int64(window) {
+			t.Fatalf("randN called with %d, want the window", n)
+		}
+		d := draws[i]
+		i++
+		return d
+	})
+	if delays["a"] != 0 || delays["b"] != window/2 || delays["c"] != window-time.Nanosecond {
+		t.Fatalf("delays = %v", delays)
+	}
+}
+
+func TestSubmitJobs_HoldsTargetsUntilTheirStartDelay(t *testing.T) {
+	tgts := []config.Target{{Name: "late", URL: "http://late", Protocol: config.ProtocolHTTP, Weight: 1}}
+	pool := &ratePool{}
+	metrics := health.NewMetrics(prometheus.NewRegistry())
+	engine := pattern.NewEngine(config.Pattern{}, 10, 10)
+	c := NewController(config.Controller{BaseTPS: 10, MaxTPS: 10}, tgts, engine, pool, nil, metrics, NoopSubmitter{})
+
+	c.startAt = time.Now()
+	c.startDelay = map[string]time.Duration{"late": time.Hour}
+	c.submitJobs(context.Background())
+	if pool.jobs != 0 {
+		t.Fatalf("submitted %d jobs before the target's start delay", pool.jobs)
+	}
+
+	c.startAt = time.Now().Add(-2 * time.Hour)
+	c.submitJobs(context.Background())
+	if pool.jobs == 0 {
+		t.Fatal("target never started after its delay passed")
+	}
+}
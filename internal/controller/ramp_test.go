@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/health"
+	"github.com/kar98k/internal/pattern"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRampRate_RampsThenHolds(t *testing.T) {
+	ramp, hold := 10*time.Second, 5*time.Second
+	cases := []struct {
+		elapsed time.Duration
+		tps     float64
+		done    bool
+	}{
+		{0, 1, false},
+		{5 * time.Second, 250.5, false},
+		{10 * time.Second, 500, false},
+		{14 * time.Second, 500, false},
+		{15 * time.Second, 500, true},
+	}
+	for _, tc := range cases {
+		tps, done := rampRate(tc.elapsed, ramp, hold, 500)
+		if math.Abs(tps-tc.tps) > 1e-9 || done != tc.done {
+			t.Errorf("after %v: (%v, %v), want (%v, %v)", tc.elapsed, tps, done, tc.tps, tc.done)
+		}
+	}
+}
+
+func TestUpdateTPS_LeavesRateToRamp(t *testing.T) {
+	pool := &ratePool{}
+	engine := pattern.NewEngine(config.Pattern{}, 100, 1000)
+	c := NewController(config.Controller{BaseTPS: 100, MaxTPS: 1000, RampUpDuration: time.Minute, RampTargetTPS: 400},
+		nil, engine, pool, nil, health.NewMetrics(prometheus.NewRegistry()), NoopSubmitter{})
+
+	c.rampBits.Store(math.Float64bits(42))
+	pool.SetRate(42)
+	c.updateTPS()
+	if pool.rate != 42 {
+		t.Fatalf("control loop overrode the ramp: pool rate %v", pool.rate)
+	}
+
+	c.endRamp()
+	c.updateTPS()
+	if pool.rate != 100 {
+		t.Fatalf("after the ramp the pattern should set the rate, got %v", pool.rate)
+	}
+	if got := c.cfg.RampTarget(); got != 400 {
+		t.Fatalf("RampTarget = %v, want 400", got)
+	}
+}
@@ -34,6 +34,17 @@ type ScenarioRunner struct {
 	phaseStart  time.Time
 	transitions int64
 	stopped     bool
+
+	// Operator pause (#1183). While paused the phase clock stands
+	// still: Run holds the boundary timer and runInjection stops
+	// advancing. notify wakes Run when the state flips.
+	paused   bool
+	pausedAt time.Time
+	notify   chan struct{}
+
+	// done is closed when the last phase's duration has run out, so a
+	// test plan can end the run there (#1262).
+	done chan struct{}
 }
 
 // scenarioDefaults captures the top-level fallback so a phase that
@@ -63,6 +74,60 @@ func NewScenarioRunner(
 			pattern: defaultPattern,
 		},
 		current: -1,
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// Done is closed once the timeline completes: the last phase's
+// duration has run out. Cancelling Run doesn't close it. Nil-safe: a
+// nil runner's channel never closes.
+func (r *ScenarioRunner) Done() <-chan struct{} {
+	if r == nil {
+		return nil
+	}
+	return r.done
+}
+
+// Pause stops the phase clock. Nil-safe and idempotent.
+func (r *ScenarioRunner) Pause() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if !r.paused {
+		r.paused = true
+		r.pausedAt = time.Now()
+	}
+	r.mu.Unlock()
+	r.wake()
+}
+
+// Resume restarts the phase clock, crediting the paused span back to
+// the current phase. Nil-safe and idempotent.
+func (r *ScenarioRunner) Resume() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if r.paused {
+		r.paused = false
+		r.phaseStart = r.phaseStart.Add(time.Since(r.pausedAt))
+	}
+	r.mu.Unlock()
+	r.wake()
+}
+
+func (r *ScenarioRunner) isPaused() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.paused
+}
+
+func (r *ScenarioRunner) wake() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
 	}
 }
 
@@ -113,6 +178,8 @@ func (r *ScenarioRunner) Run(ctx context.Context) {
 	go r.runInjection(phaseCtx, 0)
 	timer := time.NewTimer(r.scenarios[0].Duration)
 	defer timer.Stop()
+	deadline := time.Now().Add(r.scenarios[0].Duration)
+	var remaining time.Duration // > 0 while the timer is held for a pause
 
 	for {
 		select {
@@ -120,12 +187,22 @@ func (r *ScenarioRunner) Run(ctx context.Context) {
 			cancelPhase()
 			r.markStopped()
 			return
+		case <-r.notify:
+			if paused := r.isPaused(); paused && remaining == 0 {
+				timer.Stop()
+				remaining = max(time.Until(deadline), time.Nanosecond)
+			} else if !paused && remaining > 0 {
+				deadline = time.Now().Add(remaining)
+				timer.Reset(remaining)
+				remaining = 0
+			}
 		case <-timer.C:
 			next := r.nextIndex()
 			if next < 0 {
 				cancelPhase()
 				log.Printf("[scenarios] timeline complete — last phase remains active")
 				r.markStopped()
+				close(r.done)
 				return
 			}
 			cancelPhase()
@@ -133,6 +210,7 @@ func (r *ScenarioRunner) Run(ctx context.Context) {
 			r.applyPhase(next)
 			go r.runInjection(phaseCtx, next)
 			timer.Reset(r.scenarios[next].Duration)
+			deadline = time.Now().Add(r.scenarios[next].Duration)
 		}
 	}
 }
@@ -155,7 +233,8 @@ func (r *ScenarioRunner) runInjection(phaseCtx context.Context, idx int) {
 	if len(steps) == 0 {
 		return
 	}
-	start := time.Now()
+	var elapsed time.Duration
+	last := time.Now()
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -163,8 +242,12 @@ func (r *ScenarioRunner) runInjection(phaseCtx context.Context, idx int) {
 		select {
 		case <-phaseCtx.Done():
 			return
-		case <-ticker.C:
-			tps := injectTPSAt(steps, time.Since(start))
+		case now := <-ticker.C:
+			if !r.isPaused() {
+				elapsed += now.Sub(last)
+			}
+			last = now
+			tps := injectTPSAt(steps, elapsed)
 			r.engine.SetBaseTPS(tps)
 		}
 	}
@@ -263,11 +346,15 @@ func (r *ScenarioRunner) Status() ScenarioStatus {
 		return ScenarioStatus{Total: len(r.scenarios)}
 	}
 	s := r.scenarios[r.current]
+	elapsed := time.Since(r.phaseStart)
+	if r.paused {
+		elapsed = r.pausedAt.Sub(r.phaseStart)
+	}
 	return ScenarioStatus{
 		Name:        s.Name,
 		Index:       r.current + 1,
 		Total:       len(r.scenarios),
-		Elapsed:     time.Since(r.phaseStart),
+		Elapsed:     elapsed,
 		Duration:    s.Duration,
 		Transitions: r.transitions,
 		Done:        r.stopped,
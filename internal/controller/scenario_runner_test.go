@@ -185,7 +185,7 @@ func TestScenarioRunner_NoOpWhenEmpty(t *testing.T) {
 // duplicate-registration panics cannot occur across test cases.
 func freshScenarioMetrics(t *testing.T) *health.Metrics {
 	t.Helper()
-	return health.NewMetricsWithRegistry(prometheus.NewRegistry())
+	return health.NewMetrics(prometheus.NewRegistry())
 }
 
 // gaugeValue reads a Prometheus gauge without the HTTP scrape path.
@@ -281,3 +281,70 @@ func TestScenarioRunner_MetricsGaugeMidRunAndZeroAfter(t *testing.T) {
 		t.Fatalf("post-timeline: ScenarioPhaseIndex = %v, want 0", got)
 	}
 }
+
+// TestScenarioRunner_PauseHoldsPhaseClock checks that a pause longer
+// than the remaining phase does not advance the timeline, and that the
+// phase finishes its remaining time after Resume (#1183).
+func TestScenarioRunner_PauseHoldsPhaseClock(t *testing.T) {
+	eng := pattern.NewEngine(config.Pattern{}, 100, 1000)
+	scenarios := []config.Scenario{
+		{Name: "first", Duration: 60 * time.Millisecond, BaseTPS: 20},
+		{Name: "second", Duration: time.Second, BaseTPS: 80},
+	}
+	runner := NewScenarioRunner(scenarios, eng, 100, 1000, config.Pattern{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go runner.Run(ctx)
+
+	time.Sleep(30 * time.Millisecond)
+	runner.Pause()
+	elapsed := runner.Status().Elapsed
+
+	time.Sleep(100 * time.Millisecond)
+	if st := runner.Status(); st.Name != "first" || st.Elapsed != elapsed {
+		t.Fatalf("paused status = %+v, want phase first frozen at %v", st, elapsed)
+	}
+
+	runner.Resume()
+	time.Sleep(15 * time.Millisecond)
+	if st := runner.Status(); st.Name != "first" {
+		t.Fatalf("phase advanced too early after resume: %+v", st)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if st := runner.Status(); st.Name != "second" {
+		t.Fatalf("phase did not advance after remaining time: %+v", st)
+	}
+}
+
+// TestScenarioRunner_DoneClosesOnCompletionOnly verifies Done closes
+// when the last phase runs out, not when Run is cancelled early.
+func TestScenarioRunner_DoneClosesOnCompletionOnly(t *testing.T) {
+	eng := pattern.NewEngine(config.Pattern{}, 100, 1000)
+	scenarios := []config.Scenario{
+		{Name: "a", Duration: 20 * time.Millisecond},
+		{Name: "b", Duration: 20 * time.Millisecond},
+	}
+	runner := NewScenarioRunner(scenarios, eng, 100, 1000, config.Pattern{})
+	runner.Run(context.Background())
+	select {
+	case <-runner.Done():
+	default:
+		t.Fatal("Done not closed after the timeline completed")
+	}
+
+	runner = NewScenarioRunner(scenarios, eng, 100, 1000, config.Pattern{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	runner.Run(ctx)
+	select {
+	case <-runner.Done():
+		t.Fatal("Done closed by a cancelled run")
+	default:
+	}
+
+	var nilRunner *ScenarioRunner
+	if nilRunner.Done() != nil {
+		t.Fatal("nil runner's Done should be a nil channel")
+	}
+}
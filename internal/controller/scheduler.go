@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"slices"
 	"time"
 
 	"github.com/kar98k/internal/config"
@@ -9,50 +10,73 @@ import (
 // Scheduler provides time-of-day based TPS multipliers.
 type Scheduler struct {
 	schedule []config.ScheduleEntry
+	// crons holds each entry's parsed Cron, nil for Hours entries and
+	// for expressions that don't parse (config.Load rejects those).
+	crons   []*config.CronSpec
+	hasCron bool
 }
 
 // NewScheduler creates a new scheduler with the given schedule.
 func NewScheduler(schedule []config.ScheduleEntry) *Scheduler {
-	return &Scheduler{
+	s := &Scheduler{
 		schedule: schedule,
+		crons:    make([]*config.CronSpec, len(schedule)),
 	}
+	for i, e := range schedule {
+		if e.Cron == "" {
+			continue
+		}
+		if spec, err := config.ParseCron(e.Cron); err == nil {
+			s.crons[i] = &spec
+			s.hasCron = true
+		}
+	}
+	return s
 }
 
-// GetMultiplier returns the TPS multiplier for the current hour.
+// GetMultiplier returns the TPS multiplier for the current time.
 func (s *Scheduler) GetMultiplier() float64 {
-	return s.GetMultiplierForHour(time.Now().Hour())
+	return s.MultiplierAt(time.Now())
 }
 
 // GetMultiplierForHour returns the TPS multiplier for a specific hour.
+// Only Hours entries take part: a cron entry needs the full time, see
+// MultiplierAt.
 //
 // Selection rule: among entries whose hours include the target hour,
 // the one with the highest Priority wins. Ties fall back to "later
 // entry wins" — preserving the historical position-based behaviour
 // for schedules that don't set Priority.
 func (s *Scheduler) GetMultiplierForHour(hour int) float64 {
-	if len(s.schedule) == 0 {
-		return 1.0
-	}
-
 	// Normalize hour to 0-23
 	hour = ((hour % 24) + 24) % 24
+	return s.pick(func(i int) bool { return slices.Contains(s.schedule[i].Hours, hour) })
+}
 
+// MultiplierAt returns the TPS multiplier at t: Hours entries match
+// t's hour, cron entries t's minute, and the same selection rule as
+// GetMultiplierForHour picks among them.
+func (s *Scheduler) MultiplierAt(t time.Time) float64 {
+	hour := t.Hour()
+	return s.pick(func(i int) bool {
+		if c := s.crons[i]; c != nil {
+			return c.Matches(t)
+		}
+		return slices.Contains(s.schedule[i].Hours, hour)
+	})
+}
+
+// pick returns the multiplier of the winning entry among those
+// matches accepts, 1.0 when there is none.
+func (s *Scheduler) pick(matches func(i int) bool) float64 {
 	winnerIdx := -1
 	for i := range s.schedule {
-		entry := s.schedule[i]
-		matches := false
-		for _, h := range entry.Hours {
-			if h == hour {
-				matches = true
-				break
-			}
-		}
-		if !matches {
+		if !matches(i) {
 			continue
 		}
 		// Higher priority wins; equal priority falls back to "later wins"
 		// because the iteration is left-to-right.
-		if winnerIdx < 0 || entry.Priority >= s.schedule[winnerIdx].Priority {
+		if winnerIdx < 0 || s.schedule[i].Priority >= s.schedule[winnerIdx].Priority {
 			winnerIdx = i
 		}
 	}
@@ -69,36 +93,93 @@ type ScheduleInfo struct {
 	CurrentMultiplier float64
 	NextChangeHour    int
 	NextMultiplier    float64
+	// NextChange is when the multiplier next changes, zero when it
+	// doesn't within a year. With cron entries it falls on a minute.
+	NextChange time.Time
 }
 
+// scheduleHorizon bounds how far ahead GetInfo looks for a change.
+const scheduleHorizon = 366 * 24 * time.Hour
+
 // GetInfo returns current schedule information.
 func (s *Scheduler) GetInfo() ScheduleInfo {
-	currentHour := time.Now().Hour()
-	currentMult := s.GetMultiplierForHour(currentHour)
-
-	// Find next hour with different multiplier
-	nextChangeHour := -1
-	nextMult := currentMult
-
-	for i := 1; i <= 24; i++ {
-		testHour := (currentHour + i) % 24
-		testMult := s.GetMultiplierForHour(testHour)
-		if testMult != currentMult {
-			nextChangeHour = testHour
-			nextMult = testMult
+	return s.infoAt(time.Now())
+}
+
+func (s *Scheduler) infoAt(now time.Time) ScheduleInfo {
+	info := ScheduleInfo{
+		CurrentHour:       now.Hour(),
+		CurrentMultiplier: s.MultiplierAt(now),
+		NextChangeHour:    -1,
+	}
+	info.NextMultiplier = info.CurrentMultiplier
+
+	if !s.hasCron {
+		// Find next hour with different multiplier
+		for i := 1; i <= 24; i++ {
+			testHour := (info.CurrentHour + i) % 24
+			testMult := s.GetMultiplierForHour(testHour)
+			if testMult != info.CurrentMultiplier {
+				info.NextChangeHour = testHour
+				info.NextMultiplier = testMult
+				info.NextChange = time.Date(now.Year(), now.Month(), now.Day(), now.Hour()+i, 0, 0, 0, now.Location())
+				break
+			}
+		}
+		return info
+	}
+
+	// The multiplier can only change where an entry starts or stops
+	// matching: walk those edges rather than every minute.
+	limit := now.Add(scheduleHorizon)
+	for t := s.nextEdge(now); !t.IsZero() && t.Before(limit); t = s.nextEdge(t) {
+		if m := s.MultiplierAt(t); m != info.CurrentMultiplier {
+			info.NextChangeHour = t.Hour()
+			info.NextMultiplier = m
+			info.NextChange = t
 			break
 		}
 	}
+	return info
+}
+
+// nextEdge returns the first minute after t at which some entry starts
+// or stops matching, zero when none does.
+func (s *Scheduler) nextEdge(t time.Time) time.Time {
+	var edge time.Time
+	earliest := func(c time.Time) {
+		if !c.IsZero() && (edge.IsZero() || c.Before(edge)) {
+			edge = c
+		}
+	}
+	for i, c := range s.crons {
+		switch {
+		case c != nil && c.Matches(t):
+			earliest(cronEnd(c, t))
+		case c != nil:
+			earliest(c.Next(t))
+		case len(s.schedule[i].Hours) > 0:
+			earliest(time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location()))
+		}
+	}
+	return edge
+}
 
-	return ScheduleInfo{
-		CurrentHour:       currentHour,
-		CurrentMultiplier: currentMult,
-		NextChangeHour:    nextChangeHour,
-		NextMultiplier:    nextMult,
+// cronEnd returns the first minute after t that c doesn't match,
+// looking a week ahead: a cron that matches longer than that, like
+// "* * * * *", is treated as never ending.
+func cronEnd(c *config.CronSpec, t time.Time) time.Time {
+	t = t.Truncate(time.Minute)
+	for i := 1; i <= 7*24*60; i++ {
+		if u := t.Add(time.Duration(i) * time.Minute); !c.Matches(u) {
+			return u
+		}
 	}
+	return time.Time{}
 }
 
-// GetAllMultipliers returns multipliers for all 24 hours.
+// GetAllMultipliers returns multipliers for all 24 hours, from Hours
+// entries only, as GetMultiplierForHour.
 func (s *Scheduler) GetAllMultipliers() [24]float64 {
 	var multipliers [24]float64
 	for h := 0; h < 24; h++ {
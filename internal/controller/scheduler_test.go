@@ -2,6 +2,7 @@ package controller
 
 import (
 	"testing"
+	"time"
 
 	"github.com/kar98k/internal/config"
 )
@@ -112,3 +113,53 @@ func TestGetAllMultipliers_ReflectsPriorityAcross24Hours(t *testing.T) {
 		t.Fatalf("hour 20 = %v, want 1.0 (no entry → identity)", mult[20])
 	}
 }
+
+// TestMultiplierAt_CronEntriesMatchByMinute covers cron entries: they
+// apply from the minute their expression matches, and win over an
+// Hours entry on priority like any other.
+func TestMultiplierAt_CronEntriesMatchByMinute(t *testing.T) {
+	s := NewScheduler([]config.ScheduleEntry{
+		{Hours: []int{9, 10, 11, 12, 13, 14, 15, 16, 17}, TPSMultiplier: 1.5},
+		{Cron: "30-59 11 * * *", TPSMultiplier: 3.0, Priority: 10},
+	})
+	at := func(h, m int) time.Time { return time.Date(2026, 1, 5, h, m, 0, 0, time.UTC) }
+	if got := s.MultiplierAt(at(11, 29)); got != 1.5 {
+		t.Fatalf("11:29 = %v, want 1.5", got)
+	}
+	if got := s.MultiplierAt(at(11, 30)); got != 3.0 {
+		t.Fatalf("11:30 = %v, want 3.0", got)
+	}
+	if got := s.MultiplierAt(at(20, 0)); got != 1.0 {
+		t.Fatalf("20:00 = %v, want 1.0", got)
+	}
+	if got := s.GetMultiplierForHour(11); got != 1.5 {
+		t.Fatalf("hour 11 = %v, want 1.5 (cron entries need the full time)", got)
+	}
+}
+
+// TestInfoAt_NextChangeFollowsCron checks GetInfo's next change lands
+// on the minute a cron entry starts or stops, not the next hour.
+func TestInfoAt_NextChangeFollowsCron(t *testing.T) {
+	s := NewScheduler([]config.ScheduleEntry{
+		{Hours: []int{9, 10, 11, 12}, TPSMultiplier: 1.5},
+		{Cron: "30-59 11 * * *", TPSMultiplier: 3.0, Priority: 10},
+	})
+	at := func(h, m int) time.Time { return time.Date(2026, 1, 5, h, m, 0, 0, time.UTC) }
+
+	info := s.infoAt(at(11, 5))
+	if info.CurrentMultiplier != 1.5 || info.NextMultiplier != 3.0 || !info.NextChange.Equal(at(11, 30)) {
+		t.Fatalf("info at 11:05 = %+v, want 3.0 from 11:30", info)
+	}
+	info = s.infoAt(at(11, 40))
+	if info.CurrentMultiplier != 3.0 || info.NextMultiplier != 1.5 || !info.NextChange.Equal(at(12, 0)) || info.NextChangeHour != 12 {
+		t.Fatalf("info at 11:40 = %+v, want 1.5 from 12:00", info)
+	}
+	info = s.infoAt(at(12, 10))
+	if info.NextMultiplier != 1.0 || !info.NextChange.Equal(at(13, 0)) {
+		t.Fatalf("info at 12:10 = %+v, want 1.0 from 13:00", info)
+	}
+
+	if info := NewScheduler([]config.ScheduleEntry{{Cron: "* * * * *", TPSMultiplier: 2}}).infoAt(at(0, 0)); info.NextChangeHour != -1 || !info.NextChange.IsZero() {
+		t.Fatalf("constant cron info = %+v, want no change", info)
+	}
+}
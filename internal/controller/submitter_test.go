@@ -4,6 +4,11 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/health"
+	"github.com/kar98k/internal/pattern"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func TestNoopSubmitter_ReturnsImmediately(t *testing.T) {
@@ -54,3 +59,83 @@ func TestLocalSubmitter_NilSafeRun(t *testing.T) {
 	s.Run(context.Background()) // nil receiver
 	(&LocalSubmitter{}).Run(context.Background()) // nil c
 }
+
+// panicSubmitter stands in for a buggy job generator.
+type panicSubmitter struct{}
+
+func (panicSubmitter) Run(context.Context) { panic("boom") }
+
+func TestController_PanicGoesToHandler(t *testing.T) {
+	engine := pattern.NewEngine(config.Pattern{}, 100, 1000)
+	c := NewController(config.Controller{BaseTPS: 100, MaxTPS: 1000}, nil, engine, &ratePool{}, nil,
+		health.NewMetrics(prometheus.NewRegistry()), panicSubmitter{})
+	got := make(chan string, 1)
+	c.AttachPanicHandler(func(component string, v any, stack []byte) {
+		if v == "boom" && len(stack) > 0 {
+			got <- component
+		}
+	})
+	c.Start(context.Background())
+	defer c.Stop()
+
+	select {
+	case component := <-got:
+		if component != "submitter" {
+			t.Fatalf("component = %q, want submitter", component)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("panic handler was not called")
+	}
+}
+
+func TestController_LongPollTargetsTakeNoRateShare(t *testing.T) {
+	tgts := []config.Target{
+		{Name: "api", Weight: 1},
+		{Name: "events", Weight: 1, LongPoll: &config.LongPoll{Concurrency: 4}},
+	}
+	engine := pattern.NewEngine(config.Pattern{}, 100, 1000)
+	c := NewController(config.Controller{BaseTPS: 100, MaxTPS: 1000}, tgts, engine, &ratePool{}, nil,
+		health.NewMetrics(prometheus.NewRegistry()), NoopSubmitter{})
+	for i := 0; i < 100; i++ {
+		if got := c.picker.Pick(); got == nil || got.Name != "api" {
+			t.Fatalf("picked %+v, want only the rate-driven target", got)
+		}
+	}
+	if len(c.longPoll) != 1 || c.longPoll[0].Name != "events" {
+		t.Fatalf("longPoll = %+v", c.longPoll)
+	}
+}
+
+// achievedPool reports a fixed achieved rate, like a saturated pool.
+type achievedPool struct {
+	ratePool
+	achieved float64
+}
+
+func (a *achievedPool) AchievedTPS() float64 { return a.achieved }
+
+func TestController_FidelityFlagsUnderDelivery(t *testing.T) {
+	engine := pattern.NewEngine(config.Pattern{}, 100, 1000)
+	pool := &achievedPool{achieved: 50}
+	c := NewController(config.Controller{BaseTPS: 100, MaxTPS: 1000}, nil, engine, pool, nil,
+		health.NewMetrics(prometheus.NewRegistry()), NoopSubmitter{})
+	if c.Fidelity() != nil {
+		t.Fatal("fidelity before the first sample")
+	}
+
+	c.updateTPS()
+	for i := 0; i < fidelityMinSeconds-1; i++ {
+		c.sampleFidelity()
+	}
+	f := c.Fidelity()
+	if f == nil || f.Ratio != 0.5 || f.RequestedTPS != 100 || f.AchievedTPS != 50 {
+		t.Fatalf("fidelity = %+v, want 50 of 100 TPS", f)
+	}
+	if f.Low {
+		t.Fatal("judged before fidelityMinSeconds samples")
+	}
+	c.sampleFidelity()
+	if f := c.Fidelity(); !f.Low || f.Threshold != config.DefaultFidelityWarn {
+		t.Fatalf("fidelity = %+v, want low against the default threshold", f)
+	}
+}
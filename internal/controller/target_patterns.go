@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/pattern"
+)
+
+// targetPatterns drives targets that carry their own pattern (#1195).
+// Each such target gets an independent engine; the rest follow the
+// global engine. The pool rate becomes the sum of every target's
+// weight share times its own curve, and submitJobs thins picks by
+// accept so each target receives its share of that total.
+//
+// It follows the per-target noise approach (#1178): weights decide the
+// pick, thinning reshapes it. A nil *targetPatterns means no target has
+// its own pattern and every method is a no-op.
+//
+// Targets pinned by `kar set-tps` (#1201) go through the same thinning:
+// a pinned rate replaces the target's curve, and the pool rate grows or
+// shrinks by the difference.
+type targetPatterns struct {
+	engines map[string]*pattern.Engine
+	share   map[string]float64 // weight / total weight, positive weights only
+
+	mu     sync.RWMutex
+	tps    map[string]float64 // latest per-target curve value, global scale
+	peak   float64
+	pinned map[string]float64 // absolute TPS set at runtime
+}
+
+// newTargetPatterns returns nil unless at least one target sets a
+// pattern. Target engines share the controller's base and max TPS. A
+// target pattern without its own seed derives one from seed, the
+// global engine's, and its name (#1255); 0 seeds each from the clock.
+func newTargetPatterns(tgts []config.Target, baseTPS, maxTPS float64, seed int64) *targetPatterns {
+	engines := make(map[string]*pattern.Engine)
+	for _, t := range tgts {
+		if t.Pattern != nil {
+			p := *t.Pattern
+			if p.Seed == 0 && seed != 0 {
+				p.Seed = pattern.DeriveSeed(seed, "target/"+t.Name)
+			}
+			engines[t.Name] = pattern.NewEngine(p, baseTPS, maxTPS)
+		}
+	}
+	if len(engines) == 0 {
+		return nil
+	}
+	tp := newTargetShares(tgts)
+	if tp != nil {
+		tp.engines = engines
+	}
+	return tp
+}
+
+// newTargetShares returns a targetPatterns with no engines, every
+// target following the global one. The controller switches to it when
+// the first target is pinned. Nil when no target has a positive weight.
+func newTargetShares(tgts []config.Target) *targetPatterns {
+	var total float64
+	for _, t := range tgts {
+		if t.Weight > 0 {
+			total += float64(t.Weight)
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	share := make(map[string]float64, len(tgts))
+	for _, t := range tgts {
+		if t.Weight > 0 {
+			share[t.Name] = float64(t.Weight) / total
+		}
+	}
+	return &targetPatterns{share: share}
+}
+
+// globalTargets returns the targets that follow the global engine.
+func globalTargets(tgts []config.Target) []config.Target {
+	out := make([]config.Target, 0, len(tgts))
+	for _, t := range tgts {
+		if t.Pattern == nil {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// update samples every target engine and returns the combined rate.
+// globalTPS is the global engine's output; targets following it keep
+// their per-target noise relative to the global mean.
+func (tp *targetPatterns) update(global *pattern.Engine, globalTPS, schedMult float64) float64 {
+	mean := global.TargetNoiseMean()
+	tps := make(map[string]float64, len(tp.share))
+	var total, peak float64
+	tp.mu.RLock()
+	pinned := tp.pinned
+	tp.mu.RUnlock()
+	for name, share := range tp.share {
+		r := globalTPS * global.TargetNoise(name) / mean
+		if e, ok := tp.engines[name]; ok {
+			r = e.CalculateTPS(schedMult)
+		}
+		if pin, ok := pinned[name]; ok {
+			// Scale up by the share so share*r, its part of the
+			// pool rate, is exactly the pinned value.
+			r = pin / share
+		}
+		tps[name] = r
+		total += share * r
+		if r > peak {
+			peak = r
+		}
+	}
+
+	tp.mu.Lock()
+	tp.tps = tps
+	tp.peak = peak
+	tp.mu.Unlock()
+	return total
+}
+
+// accept reports whether a pick of name should be submitted: with
+// probability tps[name]/peak, so picks end up proportional to
+// weight × curve. Always true before the first update.
+func (tp *targetPatterns) accept(name string) bool {
+	tp.mu.RLock()
+	r, peak := tp.tps[name], tp.peak
+	tp.mu.RUnlock()
+	if peak <= 0 {
+		return true
+	}
+	return rand.Float64()*peak <= r
+}
+
+// pin fixes name's rate at tps until unpin. The pinned map is
+// replaced, never mutated, so update can read it without holding mu.
+func (tp *targetPatterns) pin(name string, tps float64) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	next := make(map[string]float64, len(tp.pinned)+1)
+	for k, v := range tp.pinned {
+		next[k] = v
+	}
+	next[name] = tps
+	tp.pinned = next
+}
+
+// unpin hands name back to its curve. It reports whether name was
+// pinned.
+func (tp *targetPatterns) unpin(name string) bool {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if _, ok := tp.pinned[name]; !ok {
+		return false
+	}
+	next := make(map[string]float64, len(tp.pinned))
+	for k, v := range tp.pinned {
+		if k != name {
+			next[k] = v
+		}
+	}
+	tp.pinned = next
+	return true
+}
+
+// requested returns each target's part of the pool rate as of the
+// last update, and the pinned targets.
+func (tp *targetPatterns) requested() (map[string]float64, map[string]float64) {
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+	out := make(map[string]float64, len(tp.tps))
+	for name, r := range tp.tps {
+		out[name] = tp.share[name] * r
+	}
+	return out, tp.pinned
+}
+
+// spiking reports whether any target engine is in a spike.
+func (tp *targetPatterns) spiking() bool {
+	if tp == nil {
+		return false
+	}
+	for _, e := range tp.engines {
+		if e.IsSpiking() {
+			return true
+		}
+	}
+	return false
+}
+
+// start restarts every target engine's timeline at the run's start.
+func (tp *targetPatterns) start() {
+	if tp == nil {
+		return
+	}
+	for _, e := range tp.engines {
+		e.Start()
+	}
+}
+
+// freeze and thaw pause and resume every target engine's timeline.
+func (tp *targetPatterns) freeze() {
+	if tp == nil {
+		return
+	}
+	for _, e := range tp.engines {
+		e.Freeze()
+	}
+}
+
+func (tp *targetPatterns) thaw() {
+	if tp == nil {
+		return
+	}
+	for _, e := range tp.engines {
+		e.Thaw()
+	}
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/health"
+	"github.com/kar98k/internal/pattern"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNewTargetPatterns_NilWithoutTargetPatterns(t *testing.T) {
+	tgts := []config.Target{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}}
+	if tp := newTargetPatterns(tgts, 10, 100, 0); tp != nil {
+		t.Fatalf("newTargetPatterns = %+v, want nil when no target sets a pattern", tp)
+	}
+}
+
+func TestNewTargetPatterns_DerivesSeedsFromGlobal(t *testing.T) {
+	tgts := []config.Target{
+		{Name: "a", Weight: 1, Pattern: &config.Pattern{}},
+		{Name: "b", Weight: 1, Pattern: &config.Pattern{}},
+		{Name: "pinned", Weight: 1, Pattern: &config.Pattern{Seed: 9}},
+	}
+	tp := newTargetPatterns(tgts, 10, 100, 7)
+	if a := tp.engines["a"].Seed(); a != pattern.DeriveSeed(7, "target/a") || a == tp.engines["b"].Seed() {
+		t.Fatalf("target seeds a=%d b=%d", a, tp.engines["b"].Seed())
+	}
+	if got := tp.engines["pinned"].Seed(); got != 9 {
+		t.Fatalf("a target's own seed was replaced: %d", got)
+	}
+}
+
+func TestTargetPatterns_UpdateCombinesIndependentCurves(t *testing.T) {
+	tgts := []config.Target{
+		{Name: "own", Weight: 1, Pattern: &config.Pattern{}},
+		{Name: "global", Weight: 1},
+	}
+	tp := newTargetPatterns(tgts, 100, 1000, 0)
+	global := pattern.NewEngine(config.Pattern{}, 40, 1000)
+	global.SetTargets(globalTargets(tgts))
+
+	// "own" follows its own flat engine at base 100; "global" follows
+	// whatever the global engine produced (40). Equal weights, so the
+	// pool rate is the mean.
+	total := tp.update(global, 40, 1.0)
+	if math.Abs(total-70) > 1e-9 {
+		t.Fatalf("combined rate = %v, want 70", total)
+	}
+
+	const picks = 20000
+	var own, glob int
+	for i := 0; i < picks; i++ {
+		if tp.accept("own") {
+			own++
+		}
+		if tp.accept("global") {
+			glob++
+		}
+	}
+	if own != picks {
+		t.Fatalf("peak target accepted %d/%d picks, want all", own, picks)
+	}
+	if ratio := float64(glob) / picks; math.Abs(ratio-0.4) > 0.03 {
+		t.Fatalf("global target acceptance = %.3f, want ~0.4 (40/100)", ratio)
+	}
+}
+
+func TestUpdateTPS_UsesTargetPatterns(t *testing.T) {
+	tgts := []config.Target{
+		{Name: "steady", URL: "http://steady", Protocol: config.ProtocolHTTP, Weight: 3},
+		{Name: "spiky", URL: "http://spiky", Protocol: config.ProtocolHTTP, Weight: 1, Pattern: &config.Pattern{}},
+	}
+	pool := &ratePool{}
+	metrics := health.NewMetrics(prometheus.NewRegistry())
+	engine := pattern.NewEngine(config.Pattern{}, 20, 1000)
+	c := NewController(config.Controller{BaseTPS: 100, MaxTPS: 1000}, tgts, engine, pool, nil, metrics, NoopSubmitter{})
+
+	c.updateTPS()
+	// steady: 3/4 × 20 (global engine), spiky: 1/4 × 100 (own engine).
+	if want := 0.75*20 + 0.25*100; math.Abs(pool.rate-want) > 1e-9 {
+		t.Fatalf("pool rate = %v, want %v", pool.rate, want)
+	}
+}
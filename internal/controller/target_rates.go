@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+)
+
+// TargetRate is one target's slice of the traffic (#1201).
+type TargetRate struct {
+	Target string `json:"target"`
+	// RequestedTPS is the target's part of the current pool rate;
+	// AchievedTPS is what it completed in the last second (zero when
+	// the pool doesn't count per target).
+	RequestedTPS float64 `json:"requested_tps"`
+	AchievedTPS  float64 `json:"achieved_tps"`
+	// Pinned is set while `kar set-tps` fixes the target's rate.
+	Pinned bool `json:"pinned,omitempty"`
+	// WeightFactor is what adaptive weighting currently scales the
+	// target's weight by (#1234); zero when weighting is static.
+	WeightFactor float64 `json:"weight_factor,omitempty"`
+}
+
+// SetTargetTPS pins one target's rate at tps until ClearTargetTPS,
+// applied from the next control tick (100ms). The
+// other targets keep following their curves and the pool rate becomes
+// their share plus tps, so a pinned target is neither starved by nor
+// starving the rest. tps is not capped by the engine; it is checked
+// against max_tps on its own.
+func (c *Controller) SetTargetTPS(name string, tps float64) error {
+	if err := c.pinnable(name); err != nil {
+		return err
+	}
+	if tps < 0 || math.IsNaN(tps) || math.IsInf(tps, 0) {
+		return fmt.Errorf("invalid TPS %v", tps)
+	}
+	if c.cfg.MaxTPS > 0 && tps > c.cfg.MaxTPS {
+		return fmt.Errorf("%.0f TPS exceeds controller.max_tps (%.0f)", tps, c.cfg.MaxTPS)
+	}
+	// Why: without per-target patterns the controller runs the cheaper
+	// noise-thinning path; switch to share-based thinning on first pin.
+	// It samples the same global curve, so unpinned targets don't move.
+	if c.patterns.Load() == nil {
+		c.patterns.CompareAndSwap(nil, newTargetShares(c.targets))
+	}
+	c.patterns.Load().pin(name, tps)
+	return nil
+}
+
+// ClearTargetTPS hands a pinned target back to its curve. It reports
+// whether the target was pinned.
+func (c *Controller) ClearTargetTPS(name string) (bool, error) {
+	if err := c.pinnable(name); err != nil {
+		return false, err
+	}
+	tp := c.patterns.Load()
+	return tp != nil && tp.unpin(name), nil
+}
+
+// Live multiplier bounds (#1232).
+const (
+	MinMultiplier = 0.1
+	MaxMultiplier = 10.0
+)
+
+// SetMultiplier scales the whole rate by mult from the next control
+// tick, on top of the pattern, spikes and pinned targets, still capped
+// at max_tps. mult is clamped to [MinMultiplier, MaxMultiplier]; the
+// multiplier applied is returned.
+func (c *Controller) SetMultiplier(mult float64) (float64, error) {
+	if mult <= 0 || math.IsNaN(mult) || math.IsInf(mult, 0) {
+		return 0, fmt.Errorf("invalid multiplier %v", mult)
+	}
+	mult = math.Max(MinMultiplier, math.Min(MaxMultiplier, mult))
+	c.multBits.Store(math.Float64bits(mult))
+	return mult, nil
+}
+
+// Multiplier returns the live rate multiplier, 1 when none is set.
+func (c *Controller) Multiplier() float64 {
+	if b := c.multBits.Load(); b != 0 {
+		return math.Float64frombits(b)
+	}
+	return 1
+}
+
+// pinnable rejects unknown targets and weight-0 ones, which are never
+// picked and so can't be given a rate.
+func (c *Controller) pinnable(name string) error {
+	for _, t := range c.targets {
+		if t.Name != name {
+			continue
+		}
+		if t.Weight <= 0 {
+			return fmt.Errorf("target %q has weight 0 and never receives traffic", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown target %q", name)
+}
+
+// targetRates builds the per-target breakdown for Status.
+func (c *Controller) targetRates() []TargetRate {
+	var requested, pinned map[string]float64
+	if tp := c.patterns.Load(); tp != nil {
+		requested, pinned = tp.requested()
+	} else {
+		// Plain path: weight share of the pool rate, skewed by
+		// per-target noise when it is on and by adaptive weighting.
+		tps := math.Float64frombits(c.rateBits.Load())
+		mean := c.engine.TargetNoiseMean()
+		shares := c.picker.Shares()
+		requested = make(map[string]float64, len(c.targets))
+		for _, t := range c.targets {
+			if share, ok := shares[t.Name]; ok {
+				requested[t.Name] = tps * share * c.engine.TargetNoise(t.Name) / mean
+			}
+		}
+	}
+	factors := c.picker.Factors()
+	var achieved map[string]float64
+	if tp, ok := c.pool.(targetTPSPool); ok {
+		achieved = tp.TargetAchievedTPS()
+	}
+
+	out := make([]TargetRate, 0, len(c.targets))
+	for _, t := range c.targets {
+		_, isPinned := pinned[t.Name]
+		out = append(out, TargetRate{
+			Target:       t.Name,
+			RequestedTPS: requested[t.Name],
+			AchievedTPS:  achieved[t.Name],
+			Pinned:       isPinned,
+			WeightFactor: factors[t.Name],
+		})
+	}
+	return out
+}
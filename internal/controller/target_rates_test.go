@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/health"
+	"github.com/kar98k/internal/pattern"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func pinController(t *testing.T) (*Controller, *ratePool) {
+	t.Helper()
+	tgts := []config.Target{
+		{Name: "api", URL: "http://api", Protocol: config.ProtocolHTTP, Weight: 3},
+		{Name: "auth", URL: "http://auth", Protocol: config.ProtocolHTTP, Weight: 1},
+		{Name: "off", URL: "http://off", Protocol: config.ProtocolHTTP, Weight: 0},
+	}
+	pool := &ratePool{}
+	metrics := health.NewMetrics(prometheus.NewRegistry())
+	engine := pattern.NewEngine(config.Pattern{}, 100, 1000)
+	return NewController(config.Controller{BaseTPS: 100, MaxTPS: 1000}, tgts, engine, pool, nil, metrics, NoopSubmitter{}), pool
+}
+
+func TestSetTargetTPS_PinsOneTargetAndKeepsTheRest(t *testing.T) {
+	c, pool := pinController(t)
+
+	c.updateTPS()
+	rates := c.GetStatus().TargetRates
+	if len(rates) != 3 || math.Abs(rates[0].RequestedTPS-75) > 1e-9 || math.Abs(rates[1].RequestedTPS-25) > 1e-9 {
+		t.Fatalf("weight-share rates = %+v, want api 75, auth 25", rates)
+	}
+
+	if err := c.SetTargetTPS("auth", 200); err != nil {
+		t.Fatal(err)
+	}
+	c.updateTPS()
+	// api keeps its 3/4 of the 100 TPS curve; auth adds its pin.
+	if want := 75.0 + 200; math.Abs(pool.rate-want) > 1e-9 {
+		t.Fatalf("pool rate = %v, want %v", pool.rate, want)
+	}
+	rates = c.GetStatus().TargetRates
+	if !rates[1].Pinned || math.Abs(rates[1].RequestedTPS-200) > 1e-9 || rates[0].Pinned {
+		t.Fatalf("rates after pin = %+v", rates)
+	}
+
+	// Picks come 3:1 from the weights; thinning must turn that into
+	// 75:200.
+	tp := c.patterns.Load()
+	var api, auth int
+	for i := 0; i < 40000; i++ {
+		if i%4 == 0 {
+			if tp.accept("auth") {
+				auth++
+			}
+		} else if tp.accept("api") {
+			api++
+		}
+	}
+	if ratio := float64(auth) / float64(api); math.Abs(ratio-200.0/75) > 0.15 {
+		t.Fatalf("auth/api submitted = %.2f, want ~%.2f", ratio, 200.0/75)
+	}
+
+	if was, err := c.ClearTargetTPS("auth"); err != nil || !was {
+		t.Fatalf("ClearTargetTPS = %v, %v", was, err)
+	}
+	c.updateTPS()
+	if math.Abs(pool.rate-100) > 1e-9 {
+		t.Fatalf("pool rate after unpin = %v, want 100", pool.rate)
+	}
+}
+
+func TestSetTargetTPS_Rejects(t *testing.T) {
+	c, _ := pinController(t)
+	cases := map[string]struct {
+		target string
+		tps    float64
+	}{
+		"unknown target": {"nope", 10},
+		"weight 0":       {"off", 10},
+		"max_tps":        {"api", 5000},
+		"negative":       {"api", -1},
+	}
+	for name, tc := range cases {
+		if err := c.SetTargetTPS(tc.target, tc.tps); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if c.patterns.Load() != nil {
+		t.Fatal("rejected pins should leave the plain path in place")
+	}
+	if _, err := c.ClearTargetTPS("nope"); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Fatalf("ClearTargetTPS(unknown) = %v", err)
+	}
+}
+
+func TestSetMultiplier_ScalesTheRateUpToMaxTPS(t *testing.T) {
+	c, pool := pinController(t)
+
+	if got, err := c.SetMultiplier(2.5); err != nil || got != 2.5 {
+		t.Fatalf("SetMultiplier(2.5) = %v, %v", got, err)
+	}
+	c.updateTPS()
+	if math.Abs(pool.rate-250) > 1e-9 || c.GetStatus().Multiplier != 2.5 {
+		t.Fatalf("pool rate = %v, multiplier %v; want 250 at 2.5x", pool.rate, c.GetStatus().Multiplier)
+	}
+
+	// Clamped to 10x, and 10x of 100 TPS plus a pin is over max_tps.
+	if got, _ := c.SetMultiplier(50); got != MaxMultiplier {
+		t.Fatalf("SetMultiplier(50) applied %v, want %v", got, MaxMultiplier)
+	}
+	if err := c.SetTargetTPS("auth", 200); err != nil {
+		t.Fatal(err)
+	}
+	c.updateTPS()
+	if pool.rate != 1000 {
+		t.Fatalf("pool rate = %v, want max_tps 1000", pool.rate)
+	}
+
+	for _, bad := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, err := c.SetMultiplier(bad); err == nil {
+			t.Errorf("SetMultiplier(%v) accepted", bad)
+		}
+	}
+	if c.Multiplier() != MaxMultiplier {
+		t.Fatalf("a rejected multiplier changed it to %v", c.Multiplier())
+	}
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/health"
+	"github.com/kar98k/internal/pattern"
+	"github.com/kar98k/internal/worker"
+	"github.com/kar98k/pkg/protocol"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ratePool is a PoolFacade that records the last rate and every
+// submitted job.
+type ratePool struct {
+	mu   sync.Mutex
+	rate float64
+	jobs int
+}
+
+func (r *ratePool) SetRate(tps float64) { r.mu.Lock(); r.rate = tps; r.mu.Unlock() }
+func (r *ratePool) SetPhase(string)     {}
+func (r *ratePool) Submit(worker.Job) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs++
+	return r.jobs < 5
+}
+func (r *ratePool) GetClient(config.Protocol) protocol.Client   { return nil }
+func (r *ratePool) Active() int                                 { return 0 }
+func (r *ratePool) QueueSize() int                              { return 0 }
+func (r *ratePool) TotalDrops() int64                           { return 0 }
+func (r *ratePool) DropRate() float64                           { return 0 }
+func (r *ratePool) LatencyPercentile(_ float64, _ bool) float64 { return 0 }
+
+// downController wires a controller to a checker whose only target
+// refuses connections, and waits until the checker marks it down.
+func downController(t *testing.T, h config.Health) (*Controller, *ratePool) {
+	t.Helper()
+	tgts := []config.Target{{Name: "down", URL: "http://127.0.0.1:1", Protocol: config.ProtocolHTTP, Weight: 1}}
+	metrics := health.NewMetrics(prometheus.NewRegistry())
+	h.Enabled = true
+	h.Interval = 10 * time.Millisecond
+	h.Timeout = 100 * time.Millisecond
+	checker := health.NewChecker(h, tgts, metrics)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	checker.Start(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !checker.AllUnhealthy() {
+		if time.Now().After(deadline) {
+			t.Fatal("checker never marked the target unhealthy")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	pool := &ratePool{}
+	engine := pattern.NewEngine(config.Pattern{}, 100, 1000)
+	c := NewController(config.Controller{BaseTPS: 100, MaxTPS: 1000}, tgts, engine, pool, checker, metrics, NoopSubmitter{})
+	c.AttachHealthPolicy(h)
+	return c, pool
+}
+
+func TestAllUnhealthy_PauseStopsGeneration(t *testing.T) {
+	c, pool := downController(t, config.Health{})
+	c.updateTPS()
+	if !c.GetStatus().AllUnhealthy {
+		t.Fatal("status should report all targets unhealthy")
+	}
+	c.submitJobs(context.Background())
+	if pool.jobs != 0 {
+		t.Fatalf("paused policy submitted %d jobs", pool.jobs)
+	}
+}
+
+func TestAllUnhealthy_ProbeKeepsLowRate(t *testing.T) {
+	c, pool := downController(t, config.Health{OnAllUnhealthy: config.AllUnhealthyProbe, ProbeTPS: 2})
+	c.updateTPS()
+	if pool.rate != 2 {
+		t.Fatalf("rate = %v, want probe_tps 2", pool.rate)
+	}
+	c.submitJobs(context.Background())
+	if pool.jobs == 0 {
+		t.Fatal("probe policy should still submit jobs to unhealthy targets")
+	}
+}
@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+// ControlFile names the daemon's control endpoint when it isn't the
+// socket file (#1242): the CLI doesn't read the config, so this is how
+// `kar status` finds an abstract socket or a TCP port.
+const ControlFile = "kar98k.ctl"
+
+// GetControlPath returns the full path to the control endpoint file.
+func GetControlPath() string {
+	return filepath.Join(GetRuntimeDir(), ControlFile)
+}
+
+// endpoint is a control channel address as net.Listen and net.Dial
+// take it.
+type endpoint struct {
+	network, address string
+}
+
+func (e endpoint) String() string {
+	if e.network == "tcp" {
+		return "tcp://" + e.address
+	}
+	return e.address
+}
+
+// isFile reports whether e is a socket file that must be removed.
+func (e endpoint) isFile() bool {
+	return e.network == "unix" && !strings.HasPrefix(e.address, "@")
+}
+
+// listenEndpoint is where a daemon with cfg serves control requests.
+// The abstract name is derived from the runtime directory, so daemons
+// kept apart by XDG_RUNTIME_DIR stay apart.
+func listenEndpoint(cfg config.Control) endpoint {
+	switch cfg.Kind() {
+	case config.ControlAbstract:
+		sum := sha256.Sum256([]byte(GetRuntimeDir()))
+		return endpoint{"unix", "@kar98k-" + hex.EncodeToString(sum[:6])}
+	case config.ControlTCP:
+		return endpoint{"tcp", cfg.TCPAddress()}
+	}
+	return endpoint{"unix", GetSocketPath()}
+}
+
+// controlEndpoint is where the running daemon, if any, serves control
+// requests: the one named in the control file, else the socket file.
+func controlEndpoint() endpoint {
+	if data, err := os.ReadFile(GetControlPath()); err == nil {
+		if network, address, ok := strings.Cut(strings.TrimSpace(string(data)), " "); ok {
+			return endpoint{network, address}
+		}
+	}
+	return endpoint{"unix", GetSocketPath()}
+}
+
+// publish records e in the control file for clients, or removes a
+// stale file when e is the default socket file they fall back to.
+func (e endpoint) publish() error {
+	if e.isFile() {
+		os.Remove(GetControlPath())
+		return nil
+	}
+	if err := os.WriteFile(GetControlPath(), []byte(e.network+" "+e.address+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write control file: %w", err)
+	}
+	return nil
+}
+
+// removeControlFiles removes the socket file and control file a
+// daemon leaves behind.
+func removeControlFiles() {
+	os.Remove(GetSocketPath())
+	os.Remove(GetControlPath())
+}
+
+// dialControl connects to the running daemon's control channel.
+func dialControl() (net.Conn, error) {
+	return dialControlTimeout(0)
+}
+
+// dialControlTimeout is dialControl giving up after timeout; 0 waits
+// as long as the OS does.
+func dialControlTimeout(timeout time.Duration) (net.Conn, error) {
+	e := controlEndpoint()
+	return net.DialTimeout(e.network, e.address, timeout)
+}
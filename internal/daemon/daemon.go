@@ -3,17 +3,23 @@ package daemon
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/kar98k/internal/config"
 	"github.com/kar98k/internal/controller"
 	"github.com/kar98k/internal/dashboard"
 	"github.com/kar98k/internal/health"
+	"github.com/kar98k/internal/hooks"
+	"github.com/kar98k/internal/output"
 	"github.com/kar98k/internal/pattern"
 	"github.com/kar98k/internal/rpc"
 	"github.com/kar98k/internal/worker"
@@ -23,6 +29,7 @@ const (
 	SocketName = "kar98k.sock"
 	PidFile    = "kar98k.pid"
 	LogFile    = "kar98k.log"
+	CmdFile    = "kar98k.cmd"
 )
 
 // Mode selects the daemon's operating mode.
@@ -52,10 +59,17 @@ type Status struct {
 	IsSpiking           bool      `json:"is_spiking"`
 	SpikeKind           string    `json:"spike_kind"`    // "none" | "auto" | "manual"
 	NextSpikeIn         string    `json:"next_spike_in"` // e.g. "2m13s", or "" while spiking
+	SpikesDropped       int64     `json:"spikes_dropped,omitempty"`
+	SpikesQueued        int64     `json:"spikes_queued,omitempty"`
+	SpikesSuperimposed  int64     `json:"spikes_superimposed,omitempty"`
+	SpikesPending       int       `json:"spikes_pending,omitempty"`
+	SquarePhase         string    `json:"square_phase,omitempty"` // "on" | "off", "" without pattern.square
+	SquareLeft          string    `json:"square_left,omitempty"`
 	TargetURL           string    `json:"target_url"`
 	Protocol            string    `json:"protocol"`
 	QueueDrops          int64     `json:"queue_drops"`
 	QueueDropRate       float64   `json:"queue_drop_rate"`
+	PoolSize            int       `json:"pool_size,omitempty"`
 	// Scenario fields are zero unless the loaded config defines a
 	// `scenarios:` array. Total == 0 means single-pattern mode.
 	ScenarioName     string `json:"scenario_name,omitempty"`
@@ -64,6 +78,72 @@ type Status struct {
 	ScenarioElapsed  string `json:"scenario_elapsed,omitempty"`
 	ScenarioDuration string `json:"scenario_duration,omitempty"`
 	ScenarioDone     bool   `json:"scenario_done,omitempty"`
+	// Per-target-averaged percentiles weight every target equally;
+	// the latency_*_raw fields are pooled across all requests (#1177).
+	LatencyP95TargetAvg float64                `json:"latency_p95_target_avg_ms,omitempty"`
+	LatencyP99TargetAvg float64                `json:"latency_p99_target_avg_ms,omitempty"`
+	TargetLatency       []worker.TargetLatency `json:"target_latency,omitempty"`
+	// TargetNoise is each target's current noise multiplier when
+	// pattern.noise.per_target is on (#1178).
+	TargetNoise map[string]float64 `json:"target_noise,omitempty"`
+	// Blend is each pattern.blend source's share and part of the
+	// current TPS (#1247).
+	Blend []pattern.BlendShare `json:"blend,omitempty"`
+	// SpecStats breaks requests down per target request spec (#1182).
+	SpecStats []worker.SpecStat `json:"spec_stats,omitempty"`
+	// Paused is set by `kar pause` while Triggered stays true: the run
+	// is frozen, not reset (#1183).
+	Paused bool `json:"paused,omitempty"`
+	// Time to first byte for streaming targets (#1185).
+	TTFBP95 float64 `json:"ttfb_p95_ms,omitempty"`
+	TTFBP99 float64 `json:"ttfb_p99_ms,omitempty"`
+	// ConnQueued counts requests that blocked on a target's
+	// max_conns_per_host (#1188).
+	ConnQueued int64 `json:"conn_queued,omitempty"`
+	// AllUnhealthy is the health.on_all_unhealthy action ("pause" or
+	// "probe") while every target is unhealthy, empty otherwise (#1190).
+	AllUnhealthy string `json:"all_unhealthy,omitempty"`
+	// GCImpact is kar's own GC pause overlap with requests, set when
+	// report.gc_impact is on (#1196).
+	GCImpact *worker.GCImpact `json:"gc_impact,omitempty"`
+	// TargetRates is each target's requested and achieved TPS, with
+	// any `kar set-tps` pin (#1201).
+	TargetRates []controller.TargetRate `json:"target_rates,omitempty"`
+	// Multiplier is the live rate multiplier set with set-tps, omitted
+	// while it is 1 (#1232).
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// CacheStats splits cache_bust targets' latency into hits and
+	// misses (#1206).
+	CacheStats []worker.CacheStat `json:"cache_stats,omitempty"`
+	// LongPoll is the long_poll targets' polls and hold times (#1218).
+	LongPoll []worker.LongPollStat `json:"long_poll,omitempty"`
+	// Fidelity is achieved ÷ requested TPS over the run (#1221).
+	Fidelity *controller.Fidelity `json:"fidelity,omitempty"`
+	// Saturation flags a worker pool that can't feed the requested
+	// TPS (#1222).
+	Saturation *worker.Saturation `json:"saturation,omitempty"`
+	// ConnPool is kar's own connections per protocol and host (#1210).
+	ConnPool []worker.ConnPoolStat `json:"conn_pool,omitempty"`
+	// HTTP2 is the streams of http2 targets with an http2 block
+	// (#1248).
+	HTTP2 []worker.HTTP2Stat `json:"http2,omitempty"`
+	// HeaderTracks is each track_header target's header so far.
+	HeaderTracks []worker.HeaderTrack `json:"header_tracks,omitempty"`
+	// Duplicates is each duplicates target's check so far (#1256).
+	Duplicates []worker.DuplicateStat `json:"duplicates,omitempty"`
+	// TLS is kar's full and resumed TLS handshakes so far (#1257).
+	TLS *worker.TLSStat `json:"tls,omitempty"`
+	// Cliffs are the latency cliffs safety.cliff detected (#1252).
+	Cliffs []controller.CliffEvent `json:"cliffs,omitempty"`
+	// Warmup counts the requests kept out of the percentiles above
+	// while the target warmed up (#1207).
+	Warmup *worker.WarmupStats `json:"warmup,omitempty"`
+	// Annotations are the notes added with the "annotate" control
+	// method, oldest first (#1212).
+	Annotations []output.Annotation `json:"annotations,omitempty"`
+	// LatencyFormat is report.latency, so `kar status` prints
+	// latencies the way the run's config asks (#1213).
+	LatencyFormat config.LatencyFormat `json:"latency_format"`
 }
 
 // Command represents a command sent to the daemon
@@ -98,16 +178,54 @@ type Daemon struct {
 	// after dashboard init in Start(). Nil in solo/worker mode.
 	workerSnapshotFn func() []dashboard.WorkerRow
 
-	status     Status
-	mu         sync.RWMutex
-	ctx        context.Context
-	cancel     context.CancelFunc
-	listener   net.Listener
-	socketPath string
-	logFile    *os.File
+	status   Status
+	mu       sync.RWMutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	listener net.Listener
+	control  endpoint
+	logFile  *os.File
+	stopOnce sync.Once
+	// crashOnce keeps goroutines panicking together from writing the
+	// partial report twice (#1216).
+	crashOnce sync.Once
+
+	// intentSamples is the per-second timeline the post-run intent
+	// check reads (#1186). Only recorded when intent_check is enabled
+	// or a jsonl output sink is configured.
+	intentMu      sync.Mutex
+	intentSamples []pattern.IntentSample
+
+	// sinks receive the run's results at shutdown (#1187); outputErrs
+	// records the ones that failed.
+	sinks      []output.ResultSink
+	outputErrs []error
+	// final is the result computed at Stop, kept for FinalResult.
+	final *output.Result
+	// fingerprint identifies the config the run used (#1219); set at
+	// Start, stamped on every result.
+	fingerprint *config.RunFingerprint
+
+	// hooks is the loaded hooks.script (#1200), handed to the local
+	// pool. Distributed workers don't run it.
+	hooks *hooks.Hooks
+	// data holds the targets' data_file row sources (#1211), for the
+	// local pool like hooks.
+	data worker.DataSources
+	// spikeSchedule, when set, replaces the Poisson draw of the global
+	// pattern with a recorded spike timeline (#1228).
+	spikeSchedule []pattern.SpikeEvent
+
+	// version and commit identify the kar build (SetBuildInfo); meta
+	// is the run's metadata, built in Start (#1236).
+	version, commit string
+	meta            config.RunMetadata
 }
 
-// GetRuntimeDir returns the runtime directory for kar98k
+// GetRuntimeDir returns the runtime directory for kar98k. Every command
+// that reads or writes the pid, socket, log or command file must go
+// through it: `kar start` and `kar stop` once resolved the directory
+// separately and missed each other under XDG_RUNTIME_DIR (#1208).
 func GetRuntimeDir() string {
 	// Use XDG_RUNTIME_DIR if available, otherwise use /tmp
 	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
@@ -131,13 +249,56 @@ func GetLogPath() string {
 	return filepath.Join(GetRuntimeDir(), LogFile)
 }
 
+// GetCmdPath returns the full path to the file `kar spike` hands
+// commands to an interactive `kar start` session through
+func GetCmdPath() string {
+	return filepath.Join(GetRuntimeDir(), CmdFile)
+}
+
+// RunningPid reads the pid file and reports whether that process is
+// still alive. A pid file left behind by a killed or crashed run is
+// removed together with its socket, so it can't block the next start
+// or confuse the next stop.
+func RunningPid() (int, bool) {
+	data, err := os.ReadFile(GetPidPath())
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err == nil && pid > 0 && processAlive(pid) {
+		return pid, true
+	}
+	os.Remove(GetPidPath())
+	removeControlFiles()
+	return 0, false
+}
+
+// processAlive probes pid with signal 0; on Unix FindProcess always
+// succeeds. Only "no such process" means it is gone: EPERM is a live
+// process owned by another user, which the os.TempDir() fallback
+// runtime dir, shared by all users, makes possible.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || !(errors.Is(err, os.ErrProcessDone) || errors.Is(err, syscall.ESRCH))
+}
+
 // New creates a new daemon instance operating in the given mode.
 func New(cfg *config.Config, mode Mode) (*Daemon, error) {
+	if mode == ModeMaster {
+		if err := checkMasterTLS(cfg); err != nil {
+			return nil, err
+		}
+	}
 	runtimeDir := GetRuntimeDir()
 	if err := os.MkdirAll(runtimeDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create runtime directory: %w", err)
+		return nil, fmt.Errorf("failed to create runtime directory %s: %w (set XDG_RUNTIME_DIR to a directory you can write)", runtimeDir, err)
 	}
 
+	rotateLog(GetLogPath())
 	logFile, err := os.OpenFile(GetLogPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
@@ -146,12 +307,12 @@ func New(cfg *config.Config, mode Mode) (*Daemon, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	d := &Daemon{
-		cfg:        cfg,
-		mode:       mode,
-		ctx:        ctx,
-		cancel:     cancel,
-		socketPath: GetSocketPath(),
-		logFile:    logFile,
+		cfg:     cfg,
+		mode:    mode,
+		ctx:     ctx,
+		cancel:  cancel,
+		control: listenEndpoint(cfg.Control),
+		logFile: logFile,
 		status: Status{
 			Running: true,
 		},
@@ -160,27 +321,142 @@ func New(cfg *config.Config, mode Mode) (*Daemon, error) {
 	return d, nil
 }
 
+// checkMasterTLS refuses tls_insecure in master mode (#1220): workers
+// don't receive worker settings or per-target TLS overrides from the
+// master, so they always verify certificates and the setting would be
+// silently dropped.
+func checkMasterTLS(cfg *config.Config) error {
+	if cfg.Worker.TLSInsecure {
+		return fmt.Errorf("worker.tls_insecure is not supported in master mode: distributed workers always verify certificates")
+	}
+	for _, t := range cfg.Targets {
+		if t.TLSInsecure != nil {
+			return fmt.Errorf("targets[%s].tls_insecure is not supported in master mode: distributed workers always verify certificates", t.Name)
+		}
+	}
+	return nil
+}
+
+// ReplaySpikes makes the run replay events, a recorded spike schedule,
+// instead of drawing spikes at random (#1228). Call before Start.
+func (d *Daemon) ReplaySpikes(events []pattern.SpikeEvent) {
+	d.spikeSchedule = events
+}
+
+// SetBuildInfo records the kar version and git commit the run's
+// metadata names (#1236). Call before Start.
+func (d *Daemon) SetBuildInfo(version, commit string) {
+	d.version, d.commit = version, commit
+}
+
 // Start starts the daemon
-func (d *Daemon) Start() error {
+func (d *Daemon) Start() (err error) {
 	d.log("Starting kar98k daemon (mode=%d)...", d.mode)
 
+	// The daemon's own registry (#1214): a second daemon, a discovery
+	// run or a test in the same process registers its metrics apart.
+	d.metrics = health.NewMetrics(health.NewRegistry())
+	d.metrics.SetLabelLimit(d.cfg.Metrics.LabelLimit())
+	fp := d.cfg.Fingerprint()
+	d.fingerprint = &fp
+	d.metrics.SetConfigInfo(fp.Hash)
+	if d.version == "" {
+		d.version = "dev"
+	}
+	d.meta = config.NewRunMetadata(d.version, d.commit, d.cfg.Report.Tags)
+	d.metrics.SetRunInfo(d.meta.Version, d.meta.GitCommit, d.meta.Host)
+
+	// Prune old timestamped artifacts before this run adds its own
+	// (#1224).
+	d.pruneArtifacts()
+
+	// Build output sinks first so a bad `output:` entry fails the
+	// start rather than surfacing after the run. {time} in a path is
+	// this run's start.
+	outs := make([]config.OutputSink, len(d.cfg.Output))
+	now := time.Now()
+	for i, o := range d.cfg.Output {
+		o.Path = config.ExpandTime(o.Path, now)
+		outs[i] = o
+	}
+	sinks, err := output.Build(outs, d.metrics.Gatherer())
+	if err != nil {
+		return err
+	}
+	d.sinks = sinks
+
+	// Same for the hooks script: a syntax error should stop the start.
+	if d.cfg.Hooks.Script != "" {
+		h, err := hooks.Load(d.cfg.Hooks.Script, d.cfg.Hooks.ResolvedSecrets)
+		if err != nil {
+			return err
+		}
+		d.hooks = h
+	}
+
+	// And for data files (#1211): a missing CSV fails here, not per
+	// request.
+	if d.data, err = worker.LoadDataSources(d.cfg.Targets); err != nil {
+		return err
+	}
+
+	// Check what the start needs, in order, before leaving anything
+	// behind (#1238): a writable runtime directory, no live daemon, a
+	// free control socket and free metrics addresses.
+	if err := checkRuntimeDir(GetRuntimeDir()); err != nil {
+		return err
+	}
+	// Refuse to take over from a live daemon; RunningPid clears stale
+	// files. `kar start` writes its own pid before starting the daemon
+	// in-process, so our own pid is fine.
+	if pid, ok := RunningPid(); ok && pid != os.Getpid() {
+		return fmt.Errorf("kar98k is already running (pid %d); stop it with `kar stop` first", pid)
+	}
+	if IsRunning() {
+		return fmt.Errorf("control socket %s is in use by another process; stop it with `kar stop` or remove the socket", controlEndpoint())
+	}
+	if d.cfg.Metrics.Enabled {
+		srv := health.NewServer(d.cfg.Metrics, d.metrics.Gatherer())
+		if err := srv.Listen(); err != nil {
+			return metricsListenError(err)
+		}
+		d.metricsServer = srv
+	}
+
+	// From here on a failure undoes what was already set up, so the
+	// next start isn't blocked by a stale pid file or socket.
+	defer func() {
+		if err != nil {
+			d.abortStart()
+		}
+	}()
+
 	// Write PID file
 	if err := os.WriteFile(GetPidPath(), []byte(fmt.Sprintf("%d", os.Getpid())), 0644); err != nil {
 		return fmt.Errorf("failed to write pid file: %w", err)
 	}
 
-	// Remove existing socket if present
-	os.Remove(d.socketPath)
+	// Remove a stale socket; a live one was ruled out above.
+	removeControlFiles()
 
-	// Create Unix socket
-	var err error
-	d.listener, err = net.Listen("unix", d.socketPath)
+	// Create the control channel (#1242)
+	d.listener, err = net.Listen(d.control.network, d.control.address)
 	if err != nil {
-		return fmt.Errorf("failed to create socket: %w", err)
+		if d.control.network == "tcp" {
+			return fmt.Errorf("failed to listen on control address %s: %w (set control.address to a free loopback port)", d.control.address, err)
+		}
+		return fmt.Errorf("failed to create control socket %s: %w", d.control, err)
+	}
+	if err := d.control.publish(); err != nil {
+		return err
 	}
 
-	d.metrics = health.NewMetrics()
 	d.engine = pattern.NewEngine(d.cfg.Pattern, d.cfg.Controller.BaseTPS, d.cfg.Controller.MaxTPS)
+	d.log("Pattern seed %d (set pattern.seed to reproduce)", d.engine.Seed())
+	if d.spikeSchedule != nil {
+		d.engine.ReplaySpikes(d.spikeSchedule)
+		d.log("Replaying %d recorded spike(s) instead of the Poisson draw", len(d.spikeSchedule))
+	}
 
 	if d.mode == ModeMaster {
 		if err := d.startMaster(); err != nil {
@@ -204,9 +480,8 @@ func (d *Daemon) Start() error {
 		d.dashboard.Start()
 	}
 
-	// Metrics server
-	if d.cfg.Metrics.Enabled {
-		d.metricsServer = health.NewServer(d.cfg.Metrics)
+	// Metrics server, bound above
+	if d.metricsServer != nil {
 		go func() {
 			if err := d.metricsServer.Start(); err != nil {
 				d.log("Metrics server error: %v", err)
@@ -220,18 +495,80 @@ func (d *Daemon) Start() error {
 		d.status.Protocol = string(d.cfg.Targets[0].Protocol)
 	}
 
+	d.watchSnapshotSignal()
 	d.log("Daemon started, waiting for trigger...")
 	go d.acceptConnections()
 	return nil
 }
 
+// checkRuntimeDir reports whether the pid file and control socket can
+// be created in dir, before Start creates either.
+func checkRuntimeDir(dir string) error {
+	f, err := os.CreateTemp(dir, ".probe-*")
+	if err != nil {
+		return fmt.Errorf("runtime directory %s is not writable: %w (set XDG_RUNTIME_DIR to a directory you can write)", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
+
+// metricsListenError turns a failed metrics bind into an error naming
+// the address and the setting to change.
+func metricsListenError(err error) error {
+	addr := "metrics address"
+	var op *net.OpError
+	if errors.As(err, &op) && op.Addr != nil {
+		addr = op.Addr.String()
+	}
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return fmt.Errorf("metrics address %s is already in use: stop whatever listens there or set metrics.address to a free port (or metrics.enabled: false)", addr)
+	}
+	return fmt.Errorf("failed to listen on metrics address %s: %w", addr, err)
+}
+
+// abortStart releases what a failed Start had already set up.
+func (d *Daemon) abortStart() {
+	if d.grpcServer != nil {
+		d.grpcServer.Stop()
+	}
+	if d.registry != nil {
+		d.registry.Stop()
+	}
+	d.cancel()
+	if d.metricsServer != nil {
+		d.metricsServer.Stop(context.Background())
+	}
+	if d.listener != nil {
+		d.listener.Close()
+	}
+	removeControlFiles()
+	os.Remove(GetPidPath())
+}
+
 // startSolo initialises the single-process (default) path.
 func (d *Daemon) startSolo() {
 	d.pool = worker.NewPool(d.cfg.Worker, d.metrics)
+	d.pool.SetSegmentWindow(d.cfg.Report.Window())
+	d.pool.SetSlotInterval(d.cfg.Report.SlotInterval())
+	d.pool.SetSlowestN(d.cfg.Report.SlowestN())
+	d.pool.SetGCImpact(d.cfg.Report.GCImpact)
+	d.pool.SetSizeLatency(d.cfg.Report.SizeLatency)
+	if o, ok := d.samplesSink(); ok {
+		d.pool.SetSampleCapture(o.SampleLimits())
+	}
+	d.pool.SetStartJitter(d.cfg.Controller.StartJitter)
+	d.pool.SetWarmup(d.cfg.Controller.WarmupRequests, d.cfg.Controller.WarmupDuration)
+	d.pool.SetHooks(d.hooks)
+	d.pool.SetDataSources(d.data)
 	d.checker = health.NewChecker(d.cfg.Health, d.cfg.Targets, d.metrics)
+	d.checker.SetTLSInsecure(d.cfg.Worker.TLSInsecure)
 	d.ctrl = controller.NewController(d.cfg.Controller, d.cfg.Targets, d.engine, d.pool, d.checker, d.metrics, &controller.LocalSubmitter{})
 	d.ctrl.AttachScenarios(d.cfg.Scenarios, d.cfg.Pattern)
 	d.ctrl.AttachSafety(d.cfg.Safety, d.pool)
+	d.ctrl.AttachCliff(d.cfg.Safety, d.pool, d.onCliff)
+	d.ctrl.AttachHealthPolicy(d.cfg.Health)
+	d.ctrl.AttachPanicHandler(d.crash)
 }
 
 // startMaster initialises the distributed-master path: gRPC server +
@@ -239,8 +576,11 @@ func (d *Daemon) startSolo() {
 func (d *Daemon) startMaster() error {
 	d.registry = rpc.NewWorkerRegistry(rpc.WithMetrics(d.metrics))
 	d.checker = health.NewChecker(d.cfg.Health, d.cfg.Targets, d.metrics)
+	d.checker.SetTLSInsecure(d.cfg.Worker.TLSInsecure)
 	d.ctrl = controller.NewController(d.cfg.Controller, d.cfg.Targets, d.engine, d.registry, d.checker, d.metrics, controller.NoopSubmitter{})
 	d.ctrl.AttachScenarios(d.cfg.Scenarios, d.cfg.Pattern)
+	d.ctrl.AttachHealthPolicy(d.cfg.Health)
+	d.ctrl.AttachPanicHandler(d.crash)
 
 	listen := d.cfg.Master.Listen
 	if listen == "" {
@@ -311,11 +651,17 @@ func (d *Daemon) startMaster() error {
 	return nil
 }
 
-// Trigger starts traffic generation
+// Trigger starts traffic generation. The controller, pool and checker
+// are started exactly once per daemon; triggering a paused daemon
+// resumes it instead, so counters and the pattern phase carry on.
 func (d *Daemon) Trigger() {
 	d.mu.Lock()
 	if d.status.Triggered {
+		paused := d.status.Paused
 		d.mu.Unlock()
+		if paused {
+			d.Resume()
+		}
 		return
 	}
 	d.status.Triggered = true
@@ -326,6 +672,12 @@ func (d *Daemon) Trigger() {
 	d.log("Base TPS: %.0f, Max TPS: %.0f", d.cfg.Controller.BaseTPS, d.cfg.Controller.MaxTPS)
 
 	if d.pool != nil {
+		if d.cfg.Worker.TLS.Warmup {
+			d.pool.Warm(d.ctx, d.cfg.Targets)
+			if st := d.pool.TLSHandshakes(); st != nil {
+				d.log("Warmed up targets: %d TLS handshakes (%d resumed)", st.Full+st.Resumed, st.Resumed)
+			}
+		}
 		d.pool.Start(d.ctx)
 	}
 	d.checker.Start(d.ctx)
@@ -335,13 +687,36 @@ func (d *Daemon) Trigger() {
 	go d.monitorEvents()
 }
 
-// Pause pauses traffic generation
-func (d *Daemon) Pause() {
+// Pause freezes traffic generation. Nothing is torn down: cumulative
+// metrics stay, and the pattern and scenario clocks stop so Resume
+// picks up from the same point (#1183). A no-op before the trigger.
+func (d *Daemon) Pause() bool {
 	d.mu.Lock()
-	d.status.Triggered = false
+	if !d.status.Triggered || d.status.Paused {
+		d.mu.Unlock()
+		return false
+	}
+	d.status.Paused = true
 	d.mu.Unlock()
 
+	d.ctrl.Pause()
 	d.log("Traffic generation paused")
+	return true
+}
+
+// Resume continues a paused run. Returns false when not paused.
+func (d *Daemon) Resume() bool {
+	d.mu.Lock()
+	if !d.status.Paused {
+		d.mu.Unlock()
+		return false
+	}
+	d.status.Paused = false
+	d.mu.Unlock()
+
+	d.ctrl.Resume()
+	d.log("Traffic generation resumed")
+	return true
 }
 
 // GetStatus returns the current status
@@ -350,6 +725,8 @@ func (d *Daemon) GetStatus() Status {
 	defer d.mu.RUnlock()
 
 	status := d.status
+	status.Annotations = append([]output.Annotation(nil), d.status.Annotations...)
+	status.LatencyFormat = d.cfg.Report.Latency
 	if status.StartTime.IsZero() == false {
 		status.Uptime = time.Since(status.StartTime).Round(time.Second).String()
 	}
@@ -361,14 +738,56 @@ func (d *Daemon) GetStatus() Status {
 		status.IsSpiking = ctrlStatus.PatternStatus.PoissonSpiking
 		status.QueueDrops = ctrlStatus.QueueDrops
 		status.QueueDropRate = ctrlStatus.QueueDropRate
+		if d.pool != nil {
+			status.PoolSize = d.pool.PoolSize()
+		}
 		status.SpikeKind = string(ctrlStatus.PatternStatus.SpikeKind)
 		if !ctrlStatus.PatternStatus.PoissonSpiking && ctrlStatus.PatternStatus.NextSpikeIn > 0 {
 			status.NextSpikeIn = ctrlStatus.PatternStatus.NextSpikeIn.Round(time.Second).String()
 		}
+		status.SpikesDropped = ctrlStatus.PatternStatus.SpikeOverlap.Dropped
+		status.SpikesQueued = ctrlStatus.PatternStatus.SpikeOverlap.Queued
+		status.SpikesSuperimposed = ctrlStatus.PatternStatus.SpikeOverlap.Superimposed
+		status.SpikesPending = ctrlStatus.PatternStatus.PendingSpikes
+		if ps := ctrlStatus.PatternStatus; ps.SquareEnabled {
+			status.SquarePhase = "off"
+			if ps.SquareOn {
+				status.SquarePhase = "on"
+			}
+			status.SquareLeft = ps.SquareLeft.Round(time.Second).String()
+		}
 		status.LatencyP95Raw = ctrlStatus.LatencyP95Raw
 		status.LatencyP99Raw = ctrlStatus.LatencyP99Raw
 		status.LatencyP95Corrected = ctrlStatus.LatencyP95Corrected
 		status.LatencyP99Corrected = ctrlStatus.LatencyP99Corrected
+		status.LatencyP95TargetAvg = ctrlStatus.LatencyP95TargetAvg
+		status.LatencyP99TargetAvg = ctrlStatus.LatencyP99TargetAvg
+		status.TargetLatency = withExpectedLatency(ctrlStatus.TargetLatency, d.cfg.Targets)
+		status.TargetNoise = ctrlStatus.PatternStatus.TargetNoise
+		status.Blend = ctrlStatus.PatternStatus.Blend
+		status.SpecStats = ctrlStatus.SpecStats
+		status.TTFBP95 = ctrlStatus.TTFBP95
+		status.TTFBP99 = ctrlStatus.TTFBP99
+		status.ConnQueued = ctrlStatus.ConnQueued
+		status.GCImpact = ctrlStatus.GCImpact
+		status.TargetRates = ctrlStatus.TargetRates
+		if ctrlStatus.Multiplier != 1 {
+			status.Multiplier = ctrlStatus.Multiplier
+		}
+		status.CacheStats = ctrlStatus.CacheStats
+		status.LongPoll = ctrlStatus.LongPoll
+		status.Fidelity = ctrlStatus.Fidelity
+		status.Saturation = ctrlStatus.Saturation
+		status.ConnPool = ctrlStatus.ConnPool
+		status.HTTP2 = ctrlStatus.HTTP2
+		status.HeaderTracks = ctrlStatus.HeaderTracks
+		status.Duplicates = ctrlStatus.Duplicates
+		status.TLS = ctrlStatus.TLS
+		status.Cliffs = ctrlStatus.Cliffs
+		status.Warmup = ctrlStatus.Warmup
+		if ctrlStatus.AllUnhealthy {
+			status.AllUnhealthy = d.cfg.Health.UnhealthyAction()
+		}
 
 		if sc := ctrlStatus.Scenario; sc.Total > 0 {
 			status.ScenarioName = sc.Name
@@ -383,8 +802,29 @@ func (d *Daemon) GetStatus() Status {
 	return status
 }
 
-// Stop stops the daemon
+// withExpectedLatency fills in each target's expected_latency (#1209).
+// The pool only knows targets by name, so the daemon joins it in here.
+func withExpectedLatency(lat []worker.TargetLatency, targets []config.Target) []worker.TargetLatency {
+	for i := range lat {
+		for _, t := range targets {
+			if t.Name == lat[i].Target && t.ExpectedLatency > 0 {
+				lat[i].ExpectedMs = float64(t.ExpectedLatency) / float64(time.Millisecond)
+				break
+			}
+		}
+	}
+	return lat
+}
+
+// Stop stops the daemon. It is safe to call more than once and from
+// several goroutines: a SIGTERM and a `kar stop` racing each other, or a
+// caller's deferred Stop after the control socket already stopped it,
+// shut down once and the later calls wait for that to finish.
 func (d *Daemon) Stop() {
+	d.stopOnce.Do(d.stop)
+}
+
+func (d *Daemon) stop() {
 	d.log("Stopping daemon...")
 
 	// gRPC server must stop before controller so in-flight RPCs finish.
@@ -402,6 +842,15 @@ func (d *Daemon) Stop() {
 	if d.ctrl != nil {
 		d.ctrl.Stop()
 	}
+	if d.cfg.IntentCheck.Enabled {
+		devs := d.IntentDeviations()
+		for _, dev := range devs {
+			d.log("INTENT: %s deviated — expected %s, observed %s: %s", dev.Check, dev.Expected, dev.Observed, dev.Message)
+		}
+		if len(devs) == 0 {
+			d.log("INTENT: generated load matched the configured pattern")
+		}
+	}
 	if d.checker != nil {
 		d.checker.Stop()
 	}
@@ -409,6 +858,7 @@ func (d *Daemon) Stop() {
 		d.pool.Drain(d.cfg.Controller.ShutdownTimeout)
 		d.pool.Stop()
 	}
+	d.writeOutputs()
 	if d.metricsServer != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
@@ -418,14 +868,13 @@ func (d *Daemon) Stop() {
 		d.listener.Close()
 	}
 
-	os.Remove(d.socketPath)
+	removeControlFiles()
 	os.Remove(GetPidPath())
 
+	d.log("Daemon stopped")
 	if d.logFile != nil {
 		d.logFile.Close()
 	}
-
-	d.log("Daemon stopped")
 }
 
 func (d *Daemon) acceptConnections() {
@@ -446,53 +895,309 @@ func (d *Daemon) acceptConnections() {
 
 func (d *Daemon) handleConnection(conn net.Conn) {
 	defer conn.Close()
+	defer d.recoverControl()
 
 	decoder := json.NewDecoder(conn)
 	encoder := json.NewEncoder(conn)
 
-	var cmd Command
-	if err := decoder.Decode(&cmd); err != nil {
+	var req envelope
+	if err := decoder.Decode(&req); err != nil {
 		encoder.Encode(Response{Success: false, Message: err.Error()})
 		return
 	}
+	if req.JSONRPC != "" {
+		d.serveRPC(req, decoder, encoder)
+		return
+	}
 
-	var resp Response
+	resp := d.dispatch(req.Type, req.Data)
+	encoder.Encode(resp)
+	if req.Type == "stop" {
+		d.stopAndExit()
+	}
+}
 
-	switch cmd.Type {
+// dispatch runs one control command. The legacy Command envelope and
+// JSON-RPC calls (#1212) both end up here, so the two can't drift.
+func (d *Daemon) dispatch(cmd string, data json.RawMessage) Response {
+	switch cmd {
 	case "status":
-		resp = Response{Success: true, Data: d.GetStatus()}
+		return Response{Success: true, Data: d.GetStatus()}
 
 	case "trigger":
 		d.Trigger()
-		resp = Response{Success: true, Message: "Trigger pulled!"}
+		return Response{Success: true, Message: "Trigger pulled!"}
 
 	case "pause":
-		d.Pause()
-		resp = Response{Success: true, Message: "Traffic paused"}
+		if d.Pause() {
+			return Response{Success: true, Message: "Traffic paused"}
+		}
+		return Response{Success: false, Message: "Not firing — nothing to pause"}
 
 	case "resume":
-		// Force-clear an open circuit breaker. Idempotent: a no-op
-		// when the breaker is already closed or safety is disabled.
+		// Resume an operator pause, then force-clear an open circuit
+		// breaker. Both steps are idempotent.
+		msg := "Resume signalled (clears any tripped circuit breaker)"
+		if d.Resume() {
+			msg = "Traffic resumed"
+		}
 		if d.ctrl != nil {
 			d.ctrl.ManualResume()
 		}
-		resp = Response{Success: true, Message: "Resume signalled (clears any tripped circuit breaker)"}
+		return Response{Success: true, Message: msg}
+
+	case "spike":
+		return d.handleSpike(data)
+
+	case "scale":
+		return d.handleScale(data)
+
+	case "set-tps":
+		return d.handleSetTPS(data)
+
+	case "slowest":
+		if d.pool == nil {
+			return Response{Success: false, Message: "no local worker pool (master mode?)"}
+		}
+		return Response{Success: true, Data: d.pool.Slowest()}
+
+	case "errors":
+		return d.handleErrors()
+
+	case "annotate":
+		return d.handleAnnotate(data)
+
+	case "snapshot":
+		return d.handleSnapshot(data)
+
+	case "version":
+		return Response{Success: true, Data: VersionInfo{Protocol: ProtocolVersion, Methods: Methods()}}
 
 	case "stop":
-		resp = Response{Success: true, Message: "Stopping daemon..."}
-		encoder.Encode(resp)
+		// The caller answers first, then calls stopAndExit.
+		return Response{Success: true, Message: "Stopping daemon..."}
+
+	default:
+		return Response{Success: false, Message: "Unknown command: " + cmd}
+	}
+}
+
+// cliffExitCode is the exit status of a run that safety.cliff aborted.
+const cliffExitCode = 3
+
+// onCliff reacts to a latency cliff (#1252): it marks the timeline and,
+// per safety.cliff.action, pauses traffic or ends the run with results
+// written, exiting cliffExitCode.
+func (d *Daemon) onCliff(ev controller.CliffEvent) {
+	text := fmt.Sprintf("latency cliff: P95 %.1fms, up from a %.1fms baseline", ev.P95Ms, ev.BaselineMs)
+	d.mu.Lock()
+	d.status.Annotations = append(d.status.Annotations, output.Annotation{Time: ev.At, Text: text})
+	d.mu.Unlock()
+	d.log("CLIFF: %s", text)
+
+	switch ev.Action {
+	case config.CliffPause:
+		if d.Pause() {
+			d.log("CLIFF: traffic paused; `kar resume` to continue")
+		}
+	case config.CliffAbort:
+		d.log("CLIFF: aborting the run")
+		// Not inline: Stop waits for the controller goroutine we're on.
 		go func() {
-			time.Sleep(100 * time.Millisecond)
 			d.Stop()
-			os.Exit(0)
+			exit(cliffExitCode)
 		}()
-		return
+	}
+}
 
-	default:
-		resp = Response{Success: false, Message: "Unknown command: " + cmd.Type}
+// stopAndExit shuts the daemon down after the "stop" reply had a
+// moment to reach the client.
+func (d *Daemon) stopAndExit() {
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		d.Stop()
+		os.Exit(0)
+	}()
+}
+
+// SpikeRequest is the payload of the "spike" command. Zero fields fall
+// back to pattern.poisson's spike_factor and ramp_up + ramp_down.
+type SpikeRequest struct {
+	Factor   float64 `json:"factor,omitempty"`
+	Duration string  `json:"duration,omitempty"` // e.g. "30s"
+}
+
+// handleSpike starts a manual spike, the socket counterpart of
+// `kar spike` signalling a foreground `kar start`.
+func (d *Daemon) handleSpike(data json.RawMessage) Response {
+	var req SpikeRequest
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &req); err != nil {
+			return Response{Success: false, Message: "invalid spike request: " + err.Error()}
+		}
+	}
+	var dur time.Duration
+	if req.Duration != "" {
+		var err error
+		if dur, err = time.ParseDuration(req.Duration); err != nil || dur < 0 {
+			return Response{Success: false, Message: fmt.Sprintf("invalid spike duration %q", req.Duration)}
+		}
+	}
+	if req.Factor < 0 {
+		return Response{Success: false, Message: "spike factor must be positive"}
+	}
+	if d.engine == nil {
+		return Response{Success: false, Message: "no pattern engine (worker mode?)"}
 	}
+	d.mu.RLock()
+	firing := d.status.Triggered && !d.status.Paused
+	d.mu.RUnlock()
+	if !firing {
+		return Response{Success: false, Message: "Not firing — trigger or resume first"}
+	}
+	d.engine.TriggerManualSpike(req.Factor, dur)
+	d.log("Manual spike triggered (factor %g, duration %s)", req.Factor, dur)
+	return Response{Success: true, Message: "Manual spike triggered"}
+}
 
-	encoder.Encode(resp)
+// ErrorsReport is the "errors" command's answer: run totals, the
+// sustained rate the circuit breaker watches, and the request specs
+// that failed.
+type ErrorsReport struct {
+	Requests   int64             `json:"requests"`
+	Errors     int64             `json:"errors"`
+	ErrorRate  float64           `json:"error_rate"`  // percentage, whole run
+	RecentRate float64           `json:"recent_rate"` // percentage, last few seconds
+	Specs      []worker.SpecStat `json:"specs,omitempty"`
+}
+
+func (d *Daemon) handleErrors() Response {
+	if d.pool == nil {
+		return Response{Success: false, Message: "no local worker pool (master mode?)"}
+	}
+	r := ErrorsReport{RecentRate: d.pool.ErrorRate() * 100}
+	r.Requests, r.Errors = d.pool.Totals()
+	r.ErrorRate = safeErrorRate(r.Requests, r.Errors)
+	for _, s := range d.pool.SpecStats() {
+		if s.Errors > 0 {
+			r.Specs = append(r.Specs, s)
+		}
+	}
+	return Response{Success: true, Data: r}
+}
+
+// AnnotateRequest is the payload of the "annotate" command.
+type AnnotateRequest struct {
+	Text string `json:"text"`
+}
+
+// handleAnnotate marks the run's timeline with a note, e.g. "deployed
+// v2", kept in the status and the run's result.
+func (d *Daemon) handleAnnotate(data json.RawMessage) Response {
+	var req AnnotateRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return Response{Success: false, Message: "invalid annotate request: " + err.Error()}
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Text == "" {
+		return Response{Success: false, Message: "annotation text is required"}
+	}
+	a := output.Annotation{Time: time.Now(), Text: req.Text}
+	d.mu.Lock()
+	d.status.Annotations = append(d.status.Annotations, a)
+	d.mu.Unlock()
+	d.log("ANNOTATION: %s", req.Text)
+	return Response{Success: true, Message: "Annotation added", Data: a}
+}
+
+// ScaleRequest is the payload of the "scale" command.
+type ScaleRequest struct {
+	PoolSize int `json:"pool_size"`
+}
+
+// handleScale resizes the local worker pool (#1181). Only solo mode
+// has a local pool; in master mode workers size themselves.
+func (d *Daemon) handleScale(data json.RawMessage) Response {
+	var req ScaleRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return Response{Success: false, Message: "invalid scale request: " + err.Error()}
+	}
+	if d.pool == nil {
+		return Response{Success: false, Message: "no local worker pool (master mode?)"}
+	}
+	if err := d.pool.SetPoolSize(req.PoolSize); err != nil {
+		return Response{Success: false, Message: err.Error()}
+	}
+	d.log("Worker pool resized to %d", req.PoolSize)
+	return Response{Success: true, Message: fmt.Sprintf("Worker pool size set to %d", req.PoolSize)}
+}
+
+// SetTPSRequest is the payload of the "set-tps" command. Auto clears
+// the target's pin instead of setting one. Without a target,
+// Multiplier scales the whole rate instead (#1232).
+type SetTPSRequest struct {
+	Target     string  `json:"target"`
+	TPS        float64 `json:"tps"`
+	Auto       bool    `json:"auto,omitempty"`
+	Multiplier float64 `json:"multiplier,omitempty"`
+}
+
+// SetTPSResult is the data of a multiplier set-tps reply: the
+// multiplier the controller applied, after clamping.
+type SetTPSResult struct {
+	Multiplier float64 `json:"multiplier"`
+}
+
+// handleSetTPS pins or unpins one target's rate (#1201). Solo mode
+// only: master-mode workers pick targets themselves, so the master can
+// only set their combined rate.
+func (d *Daemon) handleSetTPS(data json.RawMessage) Response {
+	var req SetTPSRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return Response{Success: false, Message: "invalid set-tps request: " + err.Error()}
+	}
+	if req.Target == "" && req.Multiplier != 0 {
+		return d.setMultiplier(req.Multiplier)
+	}
+	if d.pool == nil || d.ctrl == nil {
+		return Response{Success: false, Message: "per-target rates need a local worker pool (master mode?)"}
+	}
+	if req.Auto {
+		was, err := d.ctrl.ClearTargetTPS(req.Target)
+		if err != nil {
+			return Response{Success: false, Message: err.Error()}
+		}
+		if !was {
+			return Response{Success: true, Message: fmt.Sprintf("%s already follows the pattern", req.Target)}
+		}
+		d.log("Target %s rate unpinned", req.Target)
+		return Response{Success: true, Message: fmt.Sprintf("%s follows the pattern again", req.Target)}
+	}
+	if err := d.ctrl.SetTargetTPS(req.Target, req.TPS); err != nil {
+		return Response{Success: false, Message: err.Error()}
+	}
+	d.log("Target %s rate pinned at %.1f TPS", req.Target, req.TPS)
+	return Response{Success: true, Message: fmt.Sprintf("%s pinned at %g TPS", req.Target, req.TPS)}
+}
+
+// setMultiplier applies the live rate multiplier the TUI's arrow keys
+// send (#1232). It scales the combined rate, so master mode takes it
+// too.
+func (d *Daemon) setMultiplier(mult float64) Response {
+	if d.ctrl == nil {
+		return Response{Success: false, Message: "no controller running"}
+	}
+	was := d.ctrl.Multiplier()
+	applied, err := d.ctrl.SetMultiplier(mult)
+	if err != nil {
+		return Response{Success: false, Message: err.Error()}
+	}
+	d.log("Rate multiplier %.1fx -> %.1fx", was, applied)
+	return Response{
+		Success: true,
+		Message: fmt.Sprintf("Rate multiplier set to %gx", applied),
+		Data:    SetTPSResult{Multiplier: applied},
+	}
 }
 
 func (d *Daemon) log(format string, args ...interface{}) {
@@ -557,6 +1262,7 @@ func safeErrorRate(reqs, errs int64) float64 {
 
 // monitorEvents monitors and logs traffic events
 func (d *Daemon) monitorEvents() {
+	defer d.guard("monitor")
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
@@ -631,6 +1337,8 @@ func (d *Daemon) monitorEvents() {
 					status.QueueDropRate*100)
 			}
 
+			d.recordIntentSample(status)
+
 			lastSpiking = isSpiking
 			lastTPS = currentTPS
 			lastErrorCount = currentErrors
@@ -639,9 +1347,82 @@ func (d *Daemon) monitorEvents() {
 	}
 }
 
+// recordIntentSample appends one second of the run to the intent
+// timeline, which also feeds the jsonl output sink. Paused seconds are
+// skipped so a pause doesn't read as a missing spike.
+func (d *Daemon) recordIntentSample(st controller.Status) {
+	if !d.cfg.IntentCheck.Enabled && !d.wantsTimeline() {
+		return
+	}
+	d.mu.RLock()
+	paused := d.status.Paused
+	d.mu.RUnlock()
+	if paused {
+		return
+	}
+
+	ps := st.PatternStatus
+	target := ps.BaseTPS * st.ScheduleMultiplier * ps.BlendMultiplier * ps.PoissonMultiplier * ps.SquareMultiplier * ps.NoiseMultiplier
+	if ps.MaxTPS > 0 && target > ps.MaxTPS {
+		target = ps.MaxTPS
+	}
+	d.intentMu.Lock()
+	d.intentSamples = append(d.intentSamples, pattern.IntentSample{
+		Time:        time.Now(),
+		TargetTPS:   target,
+		AchievedTPS: st.AchievedTPS,
+		Spiking:     ps.PoissonSpiking,
+		Manual:      ps.SpikeKind == pattern.SpikeKindManual,
+		Headers:     headerValues(st.HeaderTracks),
+	})
+	d.intentMu.Unlock()
+}
+
+// headerValues is the timeline's view of the tracked headers: each
+// one's latest value.
+func headerValues(tracks []worker.HeaderTrack) []pattern.HeaderValue {
+	if len(tracks) == 0 {
+		return nil
+	}
+	out := make([]pattern.HeaderValue, len(tracks))
+	for i, t := range tracks {
+		out[i] = pattern.HeaderValue{Target: t.Target, Header: t.Header, Value: t.Last}
+	}
+	return out
+}
+
+// ScenariosDone is closed once the last scenario phase has run its
+// duration, for `kar run --plan` to end the run there (#1262). It never
+// closes without scenarios or before Start.
+func (d *Daemon) ScenariosDone() <-chan struct{} {
+	if d.ctrl == nil {
+		return nil
+	}
+	return d.ctrl.ScenariosDone()
+}
+
+// IntentDeviations runs the post-run intent check over the samples
+// recorded so far. Nil when intent_check is disabled or nothing
+// deviated. With scenarios configured the pattern changes per phase,
+// so only the achieved-TPS check runs.
+func (d *Daemon) IntentDeviations() []pattern.IntentDeviation {
+	ic := d.cfg.IntentCheck
+	if !ic.Enabled {
+		return nil
+	}
+	pat := d.cfg.Pattern
+	if len(d.cfg.Scenarios) > 0 {
+		pat = config.Pattern{}
+	}
+	d.intentMu.Lock()
+	samples := append([]pattern.IntentSample(nil), d.intentSamples...)
+	d.intentMu.Unlock()
+	return pattern.CheckIntent(pat, d.cfg.Controller.BaseTPS, d.cfg.Controller.MaxTPS, samples, ic.Tolerance)
+}
+
 // IsRunning checks if a daemon is already running
 func IsRunning() bool {
-	conn, err := net.Dial("unix", GetSocketPath())
+	conn, err := dialControl()
 	if err != nil {
 		return false
 	}
@@ -651,11 +1432,22 @@ func IsRunning() bool {
 
 // SendCommand sends a command to the running daemon
 func SendCommand(cmd Command) (*Response, error) {
-	conn, err := net.Dial("unix", GetSocketPath())
+	return SendCommandWithin(cmd, 0)
+}
+
+// SendCommandWithin is SendCommand failing once the round trip takes
+// longer than timeout, so a client polling over a TCP control channel
+// notices a dropped connection instead of blocking on it (#1246). 0
+// means no limit.
+func SendCommandWithin(cmd Command, timeout time.Duration) (*Response, error) {
+	conn, err := dialControlTimeout(timeout)
 	if err != nil {
 		return nil, fmt.Errorf("daemon not running: %w", err)
 	}
 	defer conn.Close()
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
 
 	encoder := json.NewEncoder(conn)
 	decoder := json.NewDecoder(conn)
@@ -0,0 +1,557 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/worker"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// newTestDaemon returns a solo daemon with every network listener
+// beyond the control socket switched off.
+func newTestDaemon(t *testing.T) *Daemon {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.Metrics.Enabled = false
+	cfg.Dashboard.Enabled = false
+	d, err := New(cfg, ModeSolo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestNew_MasterRejectsTLSInsecure(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	insecure := true
+
+	cfg := config.DefaultConfig()
+	cfg.Worker.TLSInsecure = true
+	if _, err := New(cfg, ModeMaster); err == nil || !strings.Contains(err.Error(), "worker.tls_insecure") {
+		t.Fatalf("global tls_insecure: err = %v", err)
+	}
+	cfg = config.DefaultConfig()
+	cfg.Targets = []config.Target{{Name: "api", URL: "https://api", TLSInsecure: &insecure}}
+	if _, err := New(cfg, ModeMaster); err == nil || !strings.Contains(err.Error(), ".tls_insecure") {
+		t.Fatalf("per-target tls_insecure: err = %v", err)
+	}
+	// Solo mode applies both itself.
+	if _, err := New(cfg, ModeSolo); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDaemon_StartStopRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	if got, want := GetRuntimeDir(), filepath.Join(dir, "kar98k"); got != want {
+		t.Fatalf("runtime dir = %q, want %q", got, want)
+	}
+
+	for round := 0; round < 2; round++ {
+		d := newTestDaemon(t)
+		if err := d.Start(); err != nil {
+			t.Fatalf("round %d: start: %v", round, err)
+		}
+		if pid, ok := RunningPid(); !ok || pid != os.Getpid() {
+			t.Fatalf("round %d: RunningPid = %d, %v", round, pid, ok)
+		}
+		resp, err := SendCommand(Command{Type: "status"})
+		if err != nil || !resp.Success {
+			t.Fatalf("round %d: status over the control socket: %v %+v", round, err, resp)
+		}
+
+		d.Stop()
+		d.Stop() // idempotent
+		if _, err := os.Stat(GetPidPath()); !os.IsNotExist(err) {
+			t.Fatalf("round %d: pid file left behind: %v", round, err)
+		}
+		if _, err := os.Stat(GetSocketPath()); !os.IsNotExist(err) {
+			t.Fatalf("round %d: socket left behind: %v", round, err)
+		}
+		if IsRunning() {
+			t.Fatalf("round %d: daemon still answering after stop", round)
+		}
+	}
+}
+
+func TestRunningPid_ClearsStaleFiles(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := os.MkdirAll(GetRuntimeDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"not-a-pid", fmt.Sprint(1 << 30)} {
+		if err := os.WriteFile(GetPidPath(), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(GetSocketPath(), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := RunningPid(); ok {
+			t.Fatalf("pid file %q reported as running", content)
+		}
+		if _, err := os.Stat(GetPidPath()); !os.IsNotExist(err) {
+			t.Fatalf("stale pid file %q not removed", content)
+		}
+		if _, err := os.Stat(GetSocketPath()); !os.IsNotExist(err) {
+			t.Fatalf("stale socket for pid file %q not removed", content)
+		}
+	}
+
+	// A stale file must not stop the next daemon from starting.
+	if err := os.WriteFile(GetPidPath(), []byte(fmt.Sprint(1<<30)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d := newTestDaemon(t)
+	if err := d.Start(); err != nil {
+		t.Fatalf("start over a stale pid file: %v", err)
+	}
+	d.Stop()
+}
+
+func TestRunningPid_KeepsAnotherUsersProcess(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := os.MkdirAll(GetRuntimeDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// pid 1 is alive and, unless the test runs as root, answers signal
+	// 0 with EPERM, like a daemon of another user sharing the dir.
+	if err := os.WriteFile(GetPidPath(), []byte("1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(GetSocketPath(), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if pid, ok := RunningPid(); !ok || pid != 1 {
+		t.Fatalf("RunningPid = %d, %v; want 1 running", pid, ok)
+	}
+	for _, path := range []string{GetPidPath(), GetSocketPath()} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("%s removed for a live process: %v", path, err)
+		}
+	}
+}
+
+func TestWithExpectedLatency(t *testing.T) {
+	lat := []worker.TargetLatency{
+		{Target: "api", P95Ms: 120},
+		{Target: "static", P95Ms: 5},
+	}
+	targets := []config.Target{
+		{Name: "api", ExpectedLatency: 100 * time.Millisecond},
+		{Name: "static"},
+	}
+	lat = withExpectedLatency(lat, targets)
+	if lat[0].ExpectedMs != 100 || !lat[0].OverExpected() {
+		t.Fatalf("api = %+v, want expected 100ms and over it", lat[0])
+	}
+	if lat[1].ExpectedMs != 0 || lat[1].OverExpected() {
+		t.Fatalf("static = %+v, want no expected latency", lat[1])
+	}
+}
+
+func TestRPC_OverControlSocket(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	d := newTestDaemon(t)
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Stop()
+
+	raw, err := Call("version", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v VersionInfo
+	if err := json.Unmarshal(raw, &v); err != nil || v.Protocol != ProtocolVersion || len(v.Methods) != len(rpcMethods) {
+		t.Fatalf("version = %s, %v", raw, err)
+	}
+
+	if _, err := Call("annotate", json.RawMessage(`{"text": "deployed v2"}`)); err != nil {
+		t.Fatal(err)
+	}
+	raw, err = Call("status", json.RawMessage("null"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st Status
+	json.Unmarshal(raw, &st)
+	if len(st.Annotations) != 1 || st.Annotations[0].Text != "deployed v2" {
+		t.Fatalf("annotations = %+v", st.Annotations)
+	}
+
+	for method, want := range map[string]int{
+		"pause":    CodeFailed, // not firing
+		"spike":    CodeFailed,
+		"teleport": CodeMethodNotFound,
+	} {
+		var rerr *RPCError
+		if _, err := Call(method, nil); !errors.As(err, &rerr) || rerr.Code != want {
+			t.Errorf("%s: err = %v, want code %d", method, err, want)
+		}
+	}
+	var rerr *RPCError
+	if _, err := Call("setrate", json.RawMessage(`["api", 5]`)); !errors.As(err, &rerr) || rerr.Code != CodeInvalidParams {
+		t.Errorf("positional params: err = %v, want code %d", err, CodeInvalidParams)
+	}
+}
+
+func TestSetTPS_Multiplier(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	d := newTestDaemon(t)
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Stop()
+	d.Trigger()
+
+	data, _ := json.Marshal(SetTPSRequest{Multiplier: 1.5})
+	resp, err := SendCommand(Command{Type: "set-tps", Data: data})
+	if err != nil || !resp.Success {
+		t.Fatalf("set-tps multiplier = %+v, %v", resp, err)
+	}
+	raw, _ := json.Marshal(resp.Data)
+	var result SetTPSResult
+	if err := json.Unmarshal(raw, &result); err != nil || result.Multiplier != 1.5 {
+		t.Fatalf("reply data = %s, want multiplier 1.5", raw)
+	}
+	if st := d.GetStatus(); st.Multiplier != 1.5 {
+		t.Fatalf("status multiplier = %v, want 1.5", st.Multiplier)
+	}
+
+	raw, err = Call("setrate", json.RawMessage(`{"multiplier": 0.01}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if json.Unmarshal(raw, &result); result.Multiplier != 0.1 {
+		t.Fatalf("setrate 0.01 = %s, want clamped to 0.1", raw)
+	}
+}
+
+func TestRPC_SeveralRequestsPerConnection(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	d := newTestDaemon(t)
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Stop()
+
+	conn, err := net.Dial("unix", GetSocketPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// The notification (no id) is served but not answered, so the next
+	// answer read is the version call's.
+	fmt.Fprintln(conn, `{"jsonrpc": "2.0", "method": "annotate", "params": {"text": "quiet"}}`)
+	fmt.Fprintln(conn, `{"jsonrpc": "2.0", "id": "a", "method": "version"}`)
+	fmt.Fprintln(conn, `{"jsonrpc": "2.0", "id": 7, "method": "errors"}`)
+
+	dec := json.NewDecoder(conn)
+	for _, id := range []string{`"a"`, `7`} {
+		var resp RPCResponse
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if string(resp.ID) != id || resp.Error != nil {
+			t.Fatalf("response = %+v, want id %s and a result", resp, id)
+		}
+	}
+	if st := d.GetStatus(); len(st.Annotations) != 1 || st.Annotations[0].Text != "quiet" {
+		t.Fatalf("notification not served: %+v", st.Annotations)
+	}
+}
+
+func TestDaemon_ServesOwnRegistryOnEveryMetricsAddress(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	addrs := []string{freeAddr(t), freeAddr(t)}
+	d := newTestDaemon(t)
+	d.cfg.Metrics = config.Metrics{Enabled: true, Address: addrs[0], Addresses: addrs[1:], Path: "/metrics"}
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Stop()
+
+	for _, addr := range addrs {
+		var body []byte
+		for i := 0; i < 50; i++ {
+			resp, err := http.Get("http://" + addr + "/metrics")
+			if err == nil {
+				body, _ = io.ReadAll(resp.Body)
+				resp.Body.Close()
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if !strings.Contains(string(body), "kar98k_current_tps") || !strings.Contains(string(body), "go_goroutines") {
+			t.Fatalf("%s/metrics missing kar98k or runtime metrics:\n%.300s", addr, body)
+		}
+	}
+}
+
+func TestDaemon_StartFailsOnBoundMetricsPort(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	d := newTestDaemon(t)
+	d.cfg.Metrics = config.Metrics{Enabled: true, Address: busy.Addr().String(), Path: "/metrics"}
+	err = d.Start()
+	if err == nil {
+		d.Stop()
+		t.Fatal("start succeeded with the metrics port taken")
+	}
+	if !strings.Contains(err.Error(), busy.Addr().String()) || !strings.Contains(err.Error(), "already in use") {
+		t.Fatalf("error doesn't name the taken address: %v", err)
+	}
+	if _, err := os.Stat(GetPidPath()); !os.IsNotExist(err) {
+		t.Fatalf("pid file left behind: %v", err)
+	}
+	if _, err := os.Stat(GetSocketPath()); !os.IsNotExist(err) {
+		t.Fatalf("socket left behind: %v", err)
+	}
+
+	// With the port free again a fresh daemon starts.
+	busy.Close()
+	d = newTestDaemon(t)
+	d.cfg.Metrics.Enabled = false
+	if err := d.Start(); err != nil {
+		t.Fatalf("start after the failed one: %v", err)
+	}
+	d.Stop()
+}
+
+// freeAddr returns a loopback address nothing is listening on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestDaemon_CrashWritesPartialReport(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	code := -1
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	out := filepath.Join(t.TempDir(), "result.json")
+	d := newTestDaemon(t)
+	d.cfg.Output = []config.OutputSink{{Type: config.OutputJSON, Path: out}}
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Stop()
+
+	d.crash("control_loop", "boom", nil)
+	if code != 2 {
+		t.Fatalf("exit code = %d, want 2", code)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("no partial report: %v", err)
+	}
+	if !strings.Contains(string(data), `"partial": true`) {
+		t.Fatalf("report not marked partial:\n%s", data)
+	}
+	var m dto.Metric
+	d.metrics.PanicsTotal.WithLabelValues("control_loop").Write(&m)
+	if m.GetCounter().GetValue() != 1 {
+		t.Fatalf("panics_total = %v, want 1", m.GetCounter().GetValue())
+	}
+}
+
+func TestDaemon_ResultCarriesRunMetadata(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	d := newTestDaemon(t)
+	d.cfg.Report.Tags = map[string]string{"target_sha": "abc123"}
+	d.SetBuildInfo("v1.2.3", "unknown")
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Stop()
+
+	md := d.Result().Metadata
+	if md == nil {
+		t.Fatal("result has no metadata")
+	}
+	if md.Version != "v1.2.3" || md.GitCommit != "" || md.Tags["target_sha"] != "abc123" {
+		t.Fatalf("metadata = %+v", md)
+	}
+	if md.OS == "" || md.GoVersion == "" || md.Started.IsZero() || md.Ended.Before(md.Started) {
+		t.Fatalf("metadata missing platform or times: %+v", md)
+	}
+}
+
+func TestDaemon_RunsPostChecksAfterLoad(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stats" {
+			fmt.Fprintf(w, `{"requests_total": %d, "state": "idle"}`, hits.Load())
+			return
+		}
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	d := newTestDaemon(t)
+	d.cfg.Targets = []config.Target{{Name: "api", URL: srv.URL + "/work", Protocol: config.ProtocolHTTP, Method: "GET", Weight: 1, Timeout: time.Second}}
+	d.cfg.PostChecks = []config.PostCheck{
+		{Name: "counted", URL: srv.URL + "/stats", JSON: []config.JSONCheck{
+			{Path: "requests_total", Op: ">=", Value: "1"},
+			{Path: "state", Value: "idle"},
+		}},
+		{Name: "too-many", URL: srv.URL + "/stats", JSON: []config.JSONCheck{{Path: "requests_total", Op: ">", Value: "1e9"}}},
+	}
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	d.Trigger()
+	for i := 0; i < 100 && hits.Load() == 0; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	d.Stop()
+
+	got := d.FinalResult().PostChecks
+	if len(got) != 2 {
+		t.Fatalf("post checks = %+v", got)
+	}
+	if !got[0].Passed || got[0].Status != 200 {
+		t.Fatalf("counted should pass: %+v", got[0])
+	}
+	if got[1].Passed || len(got[1].Failures) != 1 || !strings.Contains(got[1].Failures[0], "want > 1e9") {
+		t.Fatalf("too-many should fail naming the comparison: %+v", got[1])
+	}
+}
+
+func TestDaemon_ControlTransports(t *testing.T) {
+	transports := []config.Control{{Transport: config.ControlTCP, Address: freeAddr(t)}}
+	if runtime.GOOS == "linux" {
+		transports = append(transports, config.Control{Transport: config.ControlAbstract})
+	}
+	for _, ctl := range transports {
+		t.Run(ctl.Transport, func(t *testing.T) {
+			t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+			d := newTestDaemon(t)
+			d.cfg.Control = ctl
+			d.control = listenEndpoint(ctl)
+			if err := d.Start(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(GetSocketPath()); !os.IsNotExist(err) {
+				t.Fatalf("%s transport created a socket file: %v", ctl.Transport, err)
+			}
+			resp, err := SendCommand(Command{Type: "status"})
+			if err != nil || !resp.Success {
+				t.Fatalf("status over %s: %v %+v", ctl.Transport, err, resp)
+			}
+
+			d.Stop()
+			if _, err := os.Stat(GetControlPath()); !os.IsNotExist(err) {
+				t.Fatalf("control file left behind: %v", err)
+			}
+			if IsRunning() {
+				t.Fatal("daemon still answering after stop")
+			}
+		})
+	}
+}
+
+func TestSendCommandWithin_GivesUpOnSilentDaemon(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	// Accept and never answer, like a peer behind a dropped connection.
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	if err := os.MkdirAll(GetRuntimeDir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := (endpoint{"tcp", ln.Addr().String()}).publish(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if _, err := SendCommandWithin(Command{Type: "status"}, 200*time.Millisecond); err == nil {
+		t.Fatal("expected a timeout from a daemon that never answers")
+	}
+	if waited := time.Since(start); waited > 2*time.Second {
+		t.Fatalf("gave up after %s, want about the 200ms timeout", waited)
+	}
+}
+
+func TestDaemon_ManifestDescribesThePlan(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	d := newTestDaemon(t)
+	d.cfg.Controller.BaseTPS, d.cfg.Controller.MaxTPS = 100, 400
+	d.cfg.Pattern = config.Pattern{}
+	d.cfg.Targets = []config.Target{
+		{Name: "api", URL: "http://api/", Protocol: config.ProtocolHTTP, Weight: 3},
+		{Name: "static", URL: "http://static/", Protocol: config.ProtocolHTTP, Weight: 1,
+			Methods: []config.WeightedMethod{{Method: "get"}, {Method: "HEAD"}}},
+	}
+	d.cfg.Scenarios = []config.Scenario{{Name: "soak", Duration: 10 * time.Minute, BaseTPS: 200}}
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Stop()
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := d.WriteManifest(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Duration != "10m0s" || len(m.Phases) != 1 || m.Phases[0].BaseTPS != 200 || m.Phases[0].MaxTPS != 400 {
+		t.Fatalf("phases = %+v, duration %q", m.Phases, m.Duration)
+	}
+	if m.ExpectedPeakTPS < 200 || m.ExpectedPeakTPS > 400 {
+		t.Fatalf("expected peak %v outside the phase's base..max", m.ExpectedPeakTPS)
+	}
+	if len(m.Targets) != 2 || m.Targets[0].Share != 0.75 || m.Targets[0].PeakTPS != 0.75*m.ExpectedPeakTPS {
+		t.Fatalf("targets = %+v", m.Targets)
+	}
+	if got := m.Targets[1].Requests; len(got) != 2 || got[0] != "GET" {
+		t.Fatalf("method mix not listed: %v", got)
+	}
+	if m.Config.Hash == "" || m.Metadata.Version == "" {
+		t.Fatalf("manifest missing fingerprint or metadata: %+v %+v", m.Config, m.Metadata)
+	}
+}
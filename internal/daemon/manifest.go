@@ -0,0 +1,187 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/controller"
+)
+
+// manifestResolution is the forecast step the manifest's expected
+// TPS figures are computed at: fine enough to catch a short spike.
+const manifestResolution = 10 * time.Second
+
+// defaultManifestWindow is how far ahead a run without scenarios, which
+// runs until stopped, is forecast; the same day the dashboard shows.
+const defaultManifestWindow = 24 * time.Hour
+
+// Manifest describes what a run is about to do, written before any
+// traffic flows (#1244), so an orchestrator can check or approve the
+// plan apart from executing it.
+type Manifest struct {
+	Created  time.Time             `json:"created"`
+	Metadata config.RunMetadata    `json:"metadata"`
+	Config   config.RunFingerprint `json:"config"`
+	Mode     string                `json:"mode"`
+
+	BaseTPS float64         `json:"base_tps"`
+	MaxTPS  float64         `json:"max_tps"`
+	Ramp    *ManifestRamp   `json:"ramp,omitempty"`
+	Phases  []ManifestPhase `json:"phases,omitempty"`
+	// Duration is the scenarios' total; empty when the run goes on
+	// until stopped.
+	Duration string `json:"duration,omitempty"`
+
+	// ExpectedPeakTPS and ExpectedMeanTPS come from the same forecast
+	// as `kar simulate`, over ForecastWindow. Poisson spikes are drawn
+	// at random, so the live run's spikes land elsewhere; MaxTPS bounds
+	// them either way.
+	ExpectedPeakTPS float64 `json:"expected_peak_tps"`
+	ExpectedMeanTPS float64 `json:"expected_mean_tps"`
+	ForecastWindow  string  `json:"forecast_window"`
+
+	Targets    []ManifestTarget `json:"targets"`
+	PostChecks []string         `json:"post_checks,omitempty"`
+	// Outputs are the output sinks as type:path (or type:endpoint).
+	Outputs []string `json:"outputs,omitempty"`
+}
+
+// ManifestRamp is the ramp-up the run starts with.
+type ManifestRamp struct {
+	Duration  string  `json:"duration"`
+	TargetTPS float64 `json:"target_tps"`
+	Hold      string  `json:"hold,omitempty"`
+}
+
+// ManifestPhase is one scenario phase.
+type ManifestPhase struct {
+	Name     string  `json:"name"`
+	Duration string  `json:"duration"`
+	BaseTPS  float64 `json:"base_tps"`
+	MaxTPS   float64 `json:"max_tps"`
+}
+
+// ManifestTarget is one target and its share of the load.
+type ManifestTarget struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Protocol string `json:"protocol"`
+	Method   string `json:"method,omitempty"`
+	Weight   int    `json:"weight"`
+	// Share is the target's fraction of the top-level TPS by weight;
+	// PeakTPS is that fraction of ExpectedPeakTPS. A target with its
+	// own pattern is driven apart and has neither.
+	Share      float64  `json:"share,omitempty"`
+	PeakTPS    float64  `json:"peak_tps,omitempty"`
+	OwnPattern bool     `json:"own_pattern,omitempty"`
+	Requests   []string `json:"requests,omitempty"`
+}
+
+// Manifest describes the run. Call after Start, which resolves the
+// metadata and fingerprint it carries.
+func (d *Daemon) Manifest() *Manifest {
+	cfg := d.cfg
+	m := &Manifest{
+		Created:  time.Now(),
+		Metadata: d.meta,
+		Mode:     "solo",
+		BaseTPS:  cfg.Controller.BaseTPS,
+		MaxTPS:   cfg.Controller.MaxTPS,
+	}
+	if d.fingerprint != nil {
+		m.Config = *d.fingerprint
+	}
+	if d.mode == ModeMaster {
+		m.Mode = "master"
+	}
+	if c := cfg.Controller; c.RampUpDuration > 0 {
+		m.Ramp = &ManifestRamp{Duration: c.RampUpDuration.String(), TargetTPS: c.RampTarget()}
+		if c.RampHold > 0 {
+			m.Ramp.Hold = c.RampHold.String()
+		}
+	}
+
+	window := defaultManifestWindow
+	if len(cfg.Scenarios) > 0 {
+		window = 0
+		for _, sc := range cfg.Scenarios {
+			phase := ManifestPhase{Name: sc.Name, Duration: sc.Duration.String(), BaseTPS: cfg.Controller.BaseTPS, MaxTPS: cfg.Controller.MaxTPS}
+			if sc.BaseTPS > 0 {
+				phase.BaseTPS = sc.BaseTPS
+			}
+			if sc.MaxTPS > 0 {
+				phase.MaxTPS = sc.MaxTPS
+			}
+			m.Phases = append(m.Phases, phase)
+			window += sc.Duration
+		}
+		m.Duration = window.String()
+	}
+	m.ForecastWindow = window.String()
+	sched := controller.NewScheduler(cfg.Controller.Schedule)
+	pts := controller.ForecastTimeline(cfg, sched, m.Created, window, manifestResolution, 0)
+	var sum float64
+	for _, p := range pts {
+		sum += p.TPS
+		m.ExpectedPeakTPS = max(m.ExpectedPeakTPS, p.TPS)
+	}
+	if len(pts) > 0 {
+		m.ExpectedMeanTPS = sum / float64(len(pts))
+	}
+
+	var total int
+	for _, t := range cfg.Targets {
+		if t.Pattern == nil && t.Weight > 0 {
+			total += t.Weight
+		}
+	}
+	for _, t := range cfg.Targets {
+		mt := ManifestTarget{
+			Name:       t.Name,
+			URL:        t.URL,
+			Protocol:   string(t.Protocol),
+			Method:     t.Method,
+			Weight:     t.Weight,
+			OwnPattern: t.Pattern != nil,
+		}
+		if !mt.OwnPattern && total > 0 && t.Weight > 0 {
+			mt.Share = float64(t.Weight) / float64(total)
+			mt.PeakTPS = mt.Share * m.ExpectedPeakTPS
+		}
+		for _, r := range t.Requests {
+			mt.Requests = append(mt.Requests, r.Name)
+		}
+		if len(t.Requests) == 0 {
+			for _, wm := range t.Methods {
+				mt.Requests = append(mt.Requests, wm.Spec().Name)
+			}
+		}
+		m.Targets = append(m.Targets, mt)
+	}
+	for _, pc := range cfg.PostChecks {
+		m.PostChecks = append(m.PostChecks, pc.Name)
+	}
+	for _, o := range cfg.Output {
+		dest := o.Path
+		if dest == "" {
+			dest = o.Endpoint
+		}
+		m.Outputs = append(m.Outputs, o.Type+":"+dest)
+	}
+	return m
+}
+
+// WriteManifest writes the run's Manifest to path as JSON.
+func (d *Daemon) WriteManifest(path string) error {
+	data, err := json.MarshalIndent(d.Manifest(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/health"
+	"github.com/kar98k/internal/output"
+	"github.com/kar98k/internal/report"
+)
+
+// outputTimeout bounds the whole fan-out at shutdown so an unreachable
+// Pushgateway can't hang `kar stop`.
+const outputTimeout = 10 * time.Second
+
+// wantsTimeline reports whether any sink exports the per-second trace.
+func (d *Daemon) wantsTimeline() bool {
+	for _, o := range d.cfg.Output {
+		if o.Type == config.OutputJSONL {
+			return true
+		}
+	}
+	return false
+}
+
+// samplesSink returns the samples sink's config, if there is one.
+func (d *Daemon) samplesSink() (config.OutputSink, bool) {
+	for _, o := range d.cfg.Output {
+		if o.Type == config.OutputSamples {
+			return o, true
+		}
+	}
+	return config.OutputSink{}, false
+}
+
+// Result snapshots the run for the output sinks. Call it after the
+// pool has drained so the totals are final.
+func (d *Daemon) Result() *output.Result {
+	st := d.GetStatus()
+	r := &output.Result{
+		Started:    st.StartTime,
+		Ended:      time.Now(),
+		P95Raw:     st.LatencyP95Raw,
+		P99Raw:     st.LatencyP99Raw,
+		P95Corr:    st.LatencyP95Corrected,
+		P99Corr:    st.LatencyP99Corrected,
+		TTFBP95:    st.TTFBP95,
+		TTFBP99:    st.TTFBP99,
+		AvgLatency: st.AvgLatency,
+		Targets:    st.TargetLatency,
+		Specs:      st.SpecStats,
+		Intent:     d.IntentDeviations(),
+		GCImpact:   st.GCImpact,
+		Cache:      st.CacheStats,
+		LongPoll:   st.LongPoll,
+		HTTP2:      st.HTTP2,
+		Fidelity:   st.Fidelity,
+		Warmup:     st.Warmup,
+
+		P95TargetAvg: st.LatencyP95TargetAvg,
+		P99TargetAvg: st.LatencyP99TargetAvg,
+		HeaderTracks: st.HeaderTracks,
+		Duplicates:   st.Duplicates,
+		TLS:          st.TLS,
+		Cliffs:       st.Cliffs,
+		Annotations:  st.Annotations,
+		Latency:      d.cfg.Report.Latency,
+		Config:       d.fingerprint,
+	}
+	if d.meta.Version != "" {
+		meta := d.meta
+		if !r.Started.IsZero() {
+			meta.Started = r.Started
+		}
+		meta.Ended = r.Ended
+		r.Metadata = &meta
+	}
+	if st.Saturation != nil {
+		r.SaturatedSeconds = st.Saturation.Seconds
+	}
+	if d.engine != nil {
+		r.Spikes = d.engine.SpikeSchedule()
+		r.Seed = d.engine.Seed()
+	}
+	if !r.Started.IsZero() {
+		elapsed := r.Ended.Sub(r.Started)
+		r.Duration = elapsed.Round(time.Second).String()
+		if d.pool != nil {
+			r.Segments = output.Segments(d.pool.Segments(), d.cfg.Report.SLO)
+			if len(d.cfg.Scenarios) > 0 {
+				r.Stages = output.Stages(d.pool.Stages(), d.cfg.Scenarios)
+			}
+			r.Slowest = d.pool.Slowest()
+			d.fillReport(r)
+			r.SizeLatency = d.pool.SizeLatency()
+			if _, ok := d.samplesSink(); ok {
+				var seen int64
+				r.Samples, seen = d.pool.Samples()
+				r.SampleStats = &output.SampleStats{Kept: len(r.Samples), Seen: seen}
+			}
+			r.ErrorMatrix = output.NewErrorMatrix(d.pool.ErrorCounts(), d.cfg.Report.ErrorMatrix)
+			r.Requests, r.Errors = d.pool.Totals()
+			samples := r.Requests
+			if r.Warmup != nil {
+				samples -= r.Warmup.Excluded
+			}
+			r.LowConfidence = d.cfg.Report.PercentileNotes(samples, 95, 99)
+			r.ErrorRate = safeErrorRate(r.Requests, r.Errors)
+			if secs := elapsed.Seconds(); secs > 0 {
+				r.AchievedTPS = float64(r.Requests) / secs
+			}
+		}
+	}
+	d.intentMu.Lock()
+	r.Timeline = append(r.Timeline, d.intentSamples...)
+	d.intentMu.Unlock()
+	r.Timeline = output.AggregateTimeline(r.Timeline, d.cfg.Report.Interval)
+	return r
+}
+
+// fillReport adds the figures only the html report shows (#1260): the
+// full latency range, its histogram, the status codes and the
+// timeline.
+func (d *Daemon) fillReport(r *output.Result) {
+	r.MinLatency, r.MaxLatency = d.pool.LatencyRange()
+	r.P50Raw = d.pool.LatencyPercentile(50, false)
+	r.StatusCodes = d.pool.StatusCodes()
+	upper := make([]float64, len(report.LatencyBuckets))
+	for i, b := range report.LatencyBuckets {
+		upper[i] = b.Below
+	}
+	for i, n := range d.pool.LatencyCounts(upper) {
+		r.LatencyDist = append(r.LatencyDist, report.LatencyBucket{Label: report.LatencyBuckets[i].Label, Count: n})
+	}
+	r.Slots = d.pool.TimeSlots()
+	r.SlotInterval = d.pool.SlotInterval()
+}
+
+// writeOutputs fans the result out to every configured sink. Failures
+// are logged and kept for OutputErrors, never fatal: the run itself
+// already happened.
+func (d *Daemon) writeOutputs() {
+	d.final = d.Result()
+	d.final.PostChecks = d.runPostChecks()
+	d.emit(d.final)
+}
+
+// runPostChecks runs post_checks once the load has drained (#1241). A
+// daemon stopped before the trigger sent no load to check.
+func (d *Daemon) runPostChecks() []health.PostCheckResult {
+	d.mu.RLock()
+	triggered := d.status.Triggered
+	d.mu.RUnlock()
+	if !triggered || len(d.cfg.PostChecks) == 0 {
+		return nil
+	}
+	results := health.RunPostChecks(context.Background(), d.cfg.PostChecks, d.cfg.Worker.TLSInsecure)
+	for _, r := range results {
+		if r.Passed {
+			d.log("POST CHECK: %s passed", r.Name)
+			continue
+		}
+		d.log("POST CHECK: %s failed: %s", r.Name, strings.Join(r.Failures, "; "))
+	}
+	return results
+}
+
+// emit hands r to every sink.
+func (d *Daemon) emit(r *output.Result) {
+	if len(d.sinks) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), outputTimeout)
+	defer cancel()
+	for _, s := range d.sinks {
+		if err := s.Write(ctx, r); err != nil {
+			d.log("OUTPUT: %s failed: %v", s.Name(), err)
+			d.outputErrs = append(d.outputErrs, fmt.Errorf("%s: %w", s.Name(), err))
+			continue
+		}
+		d.log("OUTPUT: wrote %s", s.Name())
+	}
+}
+
+// FinalResult returns the result Stop handed to the sinks, for
+// callers that compare it against a baseline (#1203). Nil before Stop.
+func (d *Daemon) FinalResult() *output.Result {
+	return d.final
+}
+
+// OutputErrors returns the sinks that failed during Stop.
+func (d *Daemon) OutputErrors() []error {
+	return d.outputErrs
+}
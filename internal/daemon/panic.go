@@ -0,0 +1,50 @@
+package daemon
+
+import (
+	"os"
+	"runtime/debug"
+)
+
+// exit is os.Exit, swapped out by tests of the crash path.
+var exit = os.Exit
+
+// recoverControl is deferred by each control connection. A panic while
+// serving one command closes that connection and leaves the run alone.
+func (d *Daemon) recoverControl() {
+	if v := recover(); v != nil {
+		d.log("PANIC in control connection: %v\n%s", v, debug.Stack())
+		d.metrics.RecordPanic("control")
+	}
+}
+
+// guard is deferred by the daemon's own run goroutines; their panics
+// are fatal, so it hands them to crash.
+func (d *Daemon) guard(component string) {
+	if v := recover(); v != nil {
+		d.crash(component, v, debug.Stack())
+	}
+}
+
+// crash handles a panic the run can't survive (#1216): the goroutine
+// that drove it is gone. It logs the stack, counts the panic, hands the
+// output sinks a partial result of whatever was gathered and exits
+// non-zero. It does not run Stop, whose orderly teardown waits on the
+// dead goroutine.
+func (d *Daemon) crash(component string, v any, stack []byte) {
+	d.log("PANIC in %s: %v\n%s", component, v, stack)
+	d.metrics.RecordPanic(component)
+	d.crashOnce.Do(func() {
+		d.log("PANIC: writing partial results and exiting")
+		d.final = d.SnapshotResult()
+		d.emit(d.final)
+		if d.listener != nil {
+			d.listener.Close()
+		}
+		removeControlFiles()
+		os.Remove(GetPidPath())
+		if d.logFile != nil {
+			d.logFile.Close()
+		}
+		exit(2)
+	})
+}
@@ -0,0 +1,32 @@
+package daemon
+
+import (
+	"os"
+	"time"
+
+	"github.com/kar98k/internal/output"
+)
+
+// LogMaxSize is the size past which the daemon log is rotated at start
+// (#1224). The previous log is kept as kar98k.log.1.
+const LogMaxSize = 10 << 20
+
+// rotateLog moves path aside to path.1, replacing an older one, once
+// it has grown past LogMaxSize.
+func rotateLog(path string) {
+	if fi, err := os.Stat(path); err == nil && fi.Size() > LogMaxSize {
+		os.Rename(path, path+".1")
+	}
+}
+
+// pruneArtifacts applies report.retention to the run artifacts earlier
+// runs left: timestamped snapshots and output files.
+func (d *Daemon) pruneArtifacts() {
+	removed, err := output.Prune(d.cfg.ArtifactPatterns(), d.cfg.Report.Retention, time.Now(), false)
+	if len(removed) > 0 {
+		d.log("Retention: removed %d old artifact(s) (report.retention)", len(removed))
+	}
+	if err != nil {
+		d.log("WARNING: retention: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ProtocolVersion is the version of the JSON-RPC control protocol
+// (#1212). Methods may be added within a version; renaming or removing
+// one, or changing what it returns, bumps it.
+const ProtocolVersion = 1
+
+// rpcMethods maps each JSON-RPC method to the control command serving
+// it. The names are the stable contract; the commands behind them are
+// the legacy envelope's and may change.
+var rpcMethods = map[string]string{
+	"status":   "status",
+	"trigger":  "trigger",
+	"pause":    "pause",
+	"resume":   "resume",
+	"stop":     "stop",
+	"spike":    "spike",
+	"setrate":  "set-tps",
+	"scale":    "scale",
+	"top":      "slowest",
+	"errors":   "errors",
+	"annotate": "annotate",
+	"snapshot": "snapshot",
+	"version":  "version",
+}
+
+// JSON-RPC 2.0 error codes. Failures of a known method, e.g. pausing a
+// run that isn't firing, use CodeFailed.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeFailed         = -32000
+)
+
+// Methods returns the JSON-RPC method names, sorted.
+func Methods() []string {
+	out := make([]string, 0, len(rpcMethods))
+	for m := range rpcMethods {
+		out = append(out, m)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// VersionInfo is the "version" method's result.
+type VersionInfo struct {
+	Protocol int      `json:"protocol"`
+	Methods  []string `json:"methods"`
+}
+
+// envelope is any message on the control socket: a JSON-RPC request
+// when JSONRPC is set, a legacy Command otherwise.
+type envelope struct {
+	JSONRPC string          `json:"jsonrpc,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+
+	Type string          `json:"type,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// RPCRequest is a JSON-RPC 2.0 request. Params, if any, is an object.
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// RPCResponse is a JSON-RPC 2.0 response: Result on success, Error
+// otherwise.
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC 2.0 error object.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// serveRPC answers first and every further JSON-RPC request on the
+// connection, so a controller can keep one connection open. Requests
+// without an id are notifications and get no answer.
+func (d *Daemon) serveRPC(first envelope, dec *json.Decoder, enc *json.Encoder) {
+	req := first
+	for {
+		resp, stop := d.callRPC(req)
+		if len(req.ID) > 0 {
+			enc.Encode(resp)
+		}
+		if stop {
+			d.stopAndExit()
+			return
+		}
+
+		req = envelope{}
+		if err := dec.Decode(&req); err != nil {
+			if _, bad := err.(*json.SyntaxError); bad {
+				enc.Encode(rpcError(nil, CodeParseError, err.Error()))
+			}
+			return
+		}
+	}
+}
+
+// callRPC runs one JSON-RPC request. stop is set when it stopped the
+// daemon.
+func (d *Daemon) callRPC(req envelope) (resp RPCResponse, stop bool) {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcError(req.ID, CodeInvalidRequest, `want "jsonrpc": "2.0" and a method`), false
+	}
+	cmd, ok := rpcMethods[req.Method]
+	if !ok {
+		return rpcError(req.ID, CodeMethodNotFound, "unknown method "+req.Method), false
+	}
+	params := bytes.TrimSpace(req.Params)
+	if bytes.Equal(params, []byte("null")) {
+		params = nil
+	}
+	if len(params) > 0 && params[0] != '{' {
+		return rpcError(req.ID, CodeInvalidParams, "params must be an object"), false
+	}
+
+	r := d.dispatch(cmd, params)
+	if !r.Success {
+		return rpcError(req.ID, CodeFailed, r.Message), false
+	}
+	var result any = r.Data
+	if result == nil {
+		result = map[string]string{"message": r.Message}
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return rpcError(req.ID, CodeFailed, err.Error()), false
+	}
+	return RPCResponse{JSONRPC: "2.0", ID: req.ID, Result: raw}, cmd == "stop"
+}
+
+func rpcError(id json.RawMessage, code int, msg string) RPCResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return RPCResponse{JSONRPC: "2.0", ID: id, Error: &RPCError{Code: code, Message: msg}}
+}
+
+// Call makes one JSON-RPC call to the running daemon. A method that
+// fails comes back as an *RPCError.
+func Call(method string, params json.RawMessage) (json.RawMessage, error) {
+	conn, err := dialControl()
+	if err != nil {
+		return nil, fmt.Errorf("daemon not running: %w", err)
+	}
+	defer conn.Close()
+
+	req := RPCRequest{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: method, Params: params}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	var resp RPCResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Result, nil
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kar98k/internal/output"
+)
+
+// SnapshotRequest is the payload of the "snapshot" command. An empty
+// Path writes to report.snapshot; "-" returns the result in the
+// response instead of writing it, for the caller to print.
+type SnapshotRequest struct {
+	Path string `json:"path,omitempty"`
+}
+
+// SnapshotResult is the result of the run so far (#1205): what the
+// output sinks get at Stop, computed from the live counters, so the
+// run carries on undisturbed.
+func (d *Daemon) SnapshotResult() *output.Result {
+	r := d.Result()
+	r.Partial = true
+	return r
+}
+
+// Snapshot writes SnapshotResult to path, or to report.snapshot when
+// path is empty, and returns where it went. "-" writes to stdout.
+func (d *Daemon) Snapshot(path string) (string, error) {
+	if path == "" {
+		path = d.cfg.Report.SnapshotPath(time.Now())
+	}
+	data, err := json.MarshalIndent(d.SnapshotResult(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return path, err
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// snapshot serves SIGUSR2 and the "snapshot" command, logging the
+// outcome either way.
+func (d *Daemon) snapshot(path string) Response {
+	written, err := d.Snapshot(path)
+	if err != nil {
+		d.log("SNAPSHOT: failed: %v", err)
+		return Response{Success: false, Message: "snapshot failed: " + err.Error()}
+	}
+	d.log("SNAPSHOT: wrote %s", written)
+	return Response{Success: true, Message: fmt.Sprintf("Snapshot written to %s", written), Data: written}
+}
+
+// handleSnapshot serves the "snapshot" command.
+func (d *Daemon) handleSnapshot(data json.RawMessage) Response {
+	var req SnapshotRequest
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &req); err != nil {
+			return Response{Success: false, Message: "invalid snapshot request: " + err.Error()}
+		}
+	}
+	if req.Path == "-" {
+		return Response{Success: true, Data: d.SnapshotResult()}
+	}
+	return d.snapshot(req.Path)
+}
@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/output"
+)
+
+func TestSnapshot_WritesPartialResult(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Report.Snapshot = filepath.Join(t.TempDir(), "snap-{time}.json")
+	d := &Daemon{cfg: cfg}
+
+	path, err := d.Snapshot("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(path, "{time}") {
+		t.Fatalf("path %q still holds the {time} placeholder", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r output.Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Partial {
+		t.Error("snapshot should be marked partial")
+	}
+
+	resp := d.handleSnapshot(json.RawMessage(`{"path":"-"}`))
+	if res, ok := resp.Data.(*output.Result); !resp.Success || !ok || !res.Partial {
+		t.Fatalf(`"-" should return the result, got %+v`, resp)
+	}
+	if resp := d.handleSnapshot(json.RawMessage(`{"path":"/nonexistent/dir/snap.json"}`)); resp.Success {
+		t.Fatal("unwritable path should fail")
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchSnapshotSignal takes a snapshot on every SIGUSR2 until the
+// daemon stops (#1205).
+func (d *Daemon) watchSnapshotSignal() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR2)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ch:
+				d.snapshot("")
+			case <-d.ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,7 @@
+//go:build windows
+
+package daemon
+
+// watchSnapshotSignal is a no-op: Windows has no SIGUSR2, so snapshots
+// are taken with `kar snapshot` only (#1205).
+func (d *Daemon) watchSnapshotSignal() {}
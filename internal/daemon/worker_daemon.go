@@ -307,21 +307,25 @@ func (w *WorkerDaemon) Start(masterAddr string) error {
 	}
 	w.cfg = poolCfg
 
-	w.metrics = health.NewMetrics()
+	w.metrics = health.NewMetrics(health.NewRegistry())
 	pool := worker.NewPool(w.cfg, w.metrics)
 	pool.Start(ctx)
 
 	// Why: build the three protocol clients exactly once so MaxIdleConns
-	// and HTTP/2 connection reuse actually take effect.
+	// and HTTP/2 connection reuse actually take effect. Certificates are
+	// always verified: the master sends no tls_insecure and refuses to
+	// start with one set (#1220).
 	clientCfg := protocol.ClientConfig{
 		MaxIdleConns:    w.cfg.MaxIdleConns,
 		IdleConnTimeout: w.cfg.IdleConnTimeout,
-		TLSInsecure:     true,
+		Plaintext:       true,
+		TLSInsecure:     false,
 	}
 	w.clientByProto = map[config.Protocol]protocol.Client{
-		config.ProtocolHTTP:  protocol.NewHTTPClient(clientCfg),
-		config.ProtocolHTTP2: protocol.NewHTTP2Client(clientCfg),
-		config.ProtocolGRPC:  protocol.NewGRPCClient(clientCfg),
+		config.ProtocolHTTP:    protocol.NewHTTPClient(clientCfg),
+		config.ProtocolHTTP2:   protocol.NewHTTP2Client(clientCfg),
+		config.ProtocolGRPC:    protocol.NewGRPCClient(clientCfg),
+		config.ProtocolGRPCWeb: protocol.NewGRPCWebClient(clientCfg),
 	}
 
 	// Health checker -- each worker checks its own targets locally.
@@ -0,0 +1,258 @@
+// Package dataset feeds rows of a CSV file into requests (#1211). Each
+// request draws one row and ${column} placeholders in its URL, headers
+// and body are replaced with that row's values.
+package dataset
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Orders a Source can hand out rows in.
+const (
+	OrderSequential = "sequential" // file order; once, or looping
+	OrderRandom     = "random"     // uniform, with replacement
+	OrderShuffle    = "shuffle"    // without replacement, reshuffled each pass
+	OrderWeighted   = "weighted"   // with replacement, by a weight column
+)
+
+// Set is a loaded CSV file: a header row naming the columns, then at
+// least one data row.
+type Set struct {
+	Columns []string
+	Rows    [][]string
+	index   map[string]int
+}
+
+// Load reads the CSV file at path.
+func Load(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%s: need a header row and at least one data row", path)
+	}
+	s := &Set{Columns: records[0], Rows: records[1:], index: make(map[string]int, len(records[0]))}
+	for i, c := range s.Columns {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			return nil, fmt.Errorf("%s: column %d has no name", path, i+1)
+		}
+		if _, dup := s.index[c]; dup {
+			return nil, fmt.Errorf("%s: duplicate column %q", path, c)
+		}
+		s.Columns[i] = c
+		s.index[c] = i
+	}
+	return s, nil
+}
+
+// Len returns the number of data rows.
+func (s *Set) Len() int { return len(s.Rows) }
+
+// Has reports whether the set has a column named name.
+func (s *Set) Has(name string) bool {
+	_, ok := s.index[name]
+	return ok
+}
+
+// Weights parses column as non-negative row weights.
+func (s *Set) Weights(column string) ([]float64, error) {
+	i, ok := s.index[column]
+	if !ok {
+		return nil, fmt.Errorf("no column %q", column)
+	}
+	weights := make([]float64, len(s.Rows))
+	var total float64
+	for r, row := range s.Rows {
+		w, err := strconv.ParseFloat(strings.TrimSpace(row[i]), 64)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("row %d: weight %q is not a non-negative number", r+1, row[i])
+		}
+		weights[r] = w
+		total += w
+	}
+	if total == 0 {
+		return nil, errors.New("all weights are zero")
+	}
+	return weights, nil
+}
+
+// Row is one data row.
+type Row struct {
+	set    *Set
+	values []string
+}
+
+// Expand replaces every ${column} in s with the row's value. Unknown
+// columns are left as written; config validation rejects them.
+func (r Row) Expand(s string) string {
+	if r.set == nil || !strings.Contains(s, "${") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			break
+		}
+		name := s[start+2 : start+end]
+		b.WriteString(s[:start])
+		if i, ok := r.set.index[name]; ok {
+			b.WriteString(r.values[i])
+		} else {
+			b.WriteString(s[start : start+end+1])
+		}
+		s = s[start+end+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// ExpandURL is Expand for URLs. It also matches placeholders whose
+// braces URL parsing percent-encoded, $%7Bcolumn%7D, as a request
+// spec's path comes out. Values are inserted as written, so encode
+// them in the file where a URL needs it.
+func (r Row) ExpandURL(s string) string {
+	if strings.Contains(s, "$%7B") {
+		s = decodeBraces(s)
+	}
+	return r.Expand(s)
+}
+
+// decodeBraces rewrites $%7Bname%7D to ${name}.
+func decodeBraces(s string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "$%7B")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start:], "%7D")
+		if end < 0 {
+			break
+		}
+		b.WriteString(s[:start])
+		b.WriteString("${" + s[start+4:start+end] + "}")
+		s = s[start+end+3:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// Placeholders returns the column names ${...} placeholders in s refer
+// to, in order of appearance.
+func Placeholders(s string) []string {
+	var names []string
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			return names
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return names
+		}
+		names = append(names, s[start+2:start+end])
+		s = s[start+end+1:]
+	}
+}
+
+// Source hands out a Set's rows in one order. It is safe for
+// concurrent use.
+type Source struct {
+	set   *Set
+	order string
+	loop  bool
+
+	next int64 // sequential: index of the next row
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	perm   []int     // shuffle: current pass
+	pos    int       // shuffle: position in perm
+	cumSum []float64 // weighted: cumulative weights
+}
+
+// NewSource returns a Source over set. loop only applies to
+// OrderSequential; weightColumn only to OrderWeighted. An empty order
+// is OrderSequential.
+func NewSource(set *Set, order string, loop bool, weightColumn string, seed int64) (*Source, error) {
+	s := &Source{set: set, order: order, loop: loop, rng: rand.New(rand.NewSource(seed))}
+	switch order {
+	case "", OrderSequential:
+		s.order = OrderSequential
+	case OrderRandom:
+	case OrderShuffle:
+		s.perm = s.rng.Perm(len(set.Rows))
+	case OrderWeighted:
+		weights, err := set.Weights(weightColumn)
+		if err != nil {
+			return nil, fmt.Errorf("weight_column: %w", err)
+		}
+		s.cumSum = make([]float64, len(weights))
+		var sum float64
+		for i, w := range weights {
+			sum += w
+			s.cumSum[i] = sum
+		}
+	default:
+		return nil, fmt.Errorf("unknown order %q", order)
+	}
+	return s, nil
+}
+
+// Next returns the next row. ok is false once a non-looping
+// sequential source has handed out every row.
+func (s *Source) Next() (row Row, ok bool) {
+	n := len(s.set.Rows)
+	var i int
+	switch s.order {
+	case OrderSequential:
+		next := atomic.AddInt64(&s.next, 1) - 1
+		if next >= int64(n) && !s.loop {
+			return Row{}, false
+		}
+		i = int(next % int64(n))
+	case OrderRandom:
+		s.mu.Lock()
+		i = s.rng.Intn(n)
+		s.mu.Unlock()
+	case OrderShuffle:
+		s.mu.Lock()
+		if s.pos == len(s.perm) {
+			s.perm = s.rng.Perm(n)
+			s.pos = 0
+		}
+		i = s.perm[s.pos]
+		s.pos++
+		s.mu.Unlock()
+	case OrderWeighted:
+		s.mu.Lock()
+		x := s.rng.Float64() * s.cumSum[n-1]
+		s.mu.Unlock()
+		// Row i covers [cumSum[i-1], cumSum[i]); zero-weight rows
+		// cover nothing and are never picked.
+		i = sort.Search(n, func(j int) bool { return s.cumSum[j] > x })
+	}
+	return Row{set: s.set, values: s.set.Rows[i]}, true
+}
@@ -0,0 +1,129 @@
+package dataset
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCSV(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func loadSet(t *testing.T, content string) *Set {
+	t.Helper()
+	set, err := Load(writeCSV(t, content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+func TestLoad_Errors(t *testing.T) {
+	for name, content := range map[string]string{
+		"header only":      "id,name\n",
+		"ragged row":       "id,name\n1\n",
+		"duplicate column": "id,id\n1,2\n",
+		"unnamed column":   "id,\n1,2\n",
+	} {
+		if _, err := Load(writeCSV(t, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRow_Expand(t *testing.T) {
+	set := loadSet(t, "id,name\n42,ann\n")
+	src, err := NewSource(set, OrderSequential, false, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, _ := src.Next()
+
+	if got := row.Expand(`{"id": ${id}, "name": "${name}", "x": "${missing}"}`); got != `{"id": 42, "name": "ann", "x": "${missing}"}` {
+		t.Errorf("Expand = %s", got)
+	}
+	if got := row.ExpandURL("http://h/users/$%7Bid%7D?n=${name}"); got != "http://h/users/42?n=ann" {
+		t.Errorf("ExpandURL = %s", got)
+	}
+	if got := Placeholders("/a/${id}/${name}/${"); len(got) != 2 || got[0] != "id" || got[1] != "name" {
+		t.Errorf("Placeholders = %v", got)
+	}
+}
+
+func TestSource_SequentialOnceAndLoop(t *testing.T) {
+	set := loadSet(t, "id\n1\n2\n3\n")
+
+	once, _ := NewSource(set, "", false, "", 1)
+	var got []string
+	for {
+		row, ok := once.Next()
+		if !ok {
+			break
+		}
+		got = append(got, row.Expand("${id}"))
+		if len(got) > 3 {
+			t.Fatal("sequential source without loop repeated a row")
+		}
+	}
+	if len(got) != 3 || got[0] != "1" || got[2] != "3" {
+		t.Fatalf("rows = %v, want 1 2 3", got)
+	}
+
+	loop, _ := NewSource(set, OrderSequential, true, "", 1)
+	for i := 0; i < 7; i++ {
+		row, ok := loop.Next()
+		if want := []string{"1", "2", "3"}[i%3]; !ok || row.Expand("${id}") != want {
+			t.Fatalf("draw %d = %q, %v; want %s", i, row.Expand("${id}"), ok, want)
+		}
+	}
+}
+
+func TestSource_ShuffleUsesEveryRowOncePerPass(t *testing.T) {
+	set := loadSet(t, "id\na\nb\nc\nd\n")
+	src, _ := NewSource(set, OrderShuffle, false, "", 7)
+	for pass := 0; pass < 3; pass++ {
+		seen := make(map[string]bool)
+		for i := 0; i < 4; i++ {
+			row, ok := src.Next()
+			if !ok {
+				t.Fatal("shuffle should never run out")
+			}
+			seen[row.Expand("${id}")] = true
+		}
+		if len(seen) != 4 {
+			t.Fatalf("pass %d drew %v, want every row once", pass, seen)
+		}
+	}
+}
+
+func TestSource_Weighted(t *testing.T) {
+	set := loadSet(t, "id,w\nnever,0\nrare,1\ncommon,9\n")
+	src, err := NewSource(set, OrderWeighted, false, "w", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		row, _ := src.Next()
+		counts[row.Expand("${id}")]++
+	}
+	if counts["never"] != 0 {
+		t.Errorf("zero-weight row drawn %d times", counts["never"])
+	}
+	if counts["common"] < 8500 || counts["common"] > 9500 {
+		t.Errorf("common drawn %d of 10000, want about 9000", counts["common"])
+	}
+
+	if _, err := NewSource(set, OrderWeighted, false, "id", 1); err == nil {
+		t.Error("non-numeric weight column should fail")
+	}
+	if _, err := NewSource(set, "zigzag", false, "", 1); err == nil {
+		t.Error("unknown order should fail")
+	}
+}
@@ -2,6 +2,8 @@ package discovery
 
 import (
 	"math"
+	"math/rand"
+	"sort"
 	"testing"
 )
 
@@ -112,6 +114,41 @@ func TestAnalyzer_TakeSnapshot(t *testing.T) {
 	}
 }
 
+// TestAnalyzer_PercentilesTrackExactValues checks the histogram's
+// percentiles against the exact ones of a long-tailed step: within 1%,
+// as the binary search's stability decision needs (#1263).
+func TestAnalyzer_PercentilesTrackExactValues(t *testing.T) {
+	a := NewAnalyzer()
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]float64, 200_000)
+	for i := range samples {
+		samples[i] = 5 + rng.ExpFloat64()*40
+		a.RecordLatency(samples[i], false)
+	}
+	sort.Float64s(samples)
+	exact := func(p float64) float64 { return samples[int(math.Ceil(p/100*float64(len(samples))))-1] }
+
+	s := a.TakeSnapshot()
+	for _, c := range []struct {
+		name     string
+		got, p   float64
+		fromSnap float64
+	}{
+		{"P95", a.GetP95Latency(), 95, s.P95Latency},
+		{"P99", a.GetP99Latency(), 99, s.P99Latency},
+	} {
+		want := exact(c.p)
+		closeEnough(t, c.name, c.got, want, want*0.01)
+		if c.fromSnap != c.got {
+			t.Fatalf("snapshot %s = %v, getter = %v", c.name, c.fromSnap, c.got)
+		}
+	}
+
+	a.ResetWindow()
+	a.RecordLatency(7, false)
+	closeEnough(t, "P99 after ResetWindow", a.GetP99Latency(), 7, 0.01)
+}
+
 func TestAnalyzer_EmptyReturnsZero(t *testing.T) {
 	a := NewAnalyzer()
 	if got := a.GetP95Latency(); got != 0 {
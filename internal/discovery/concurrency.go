@@ -0,0 +1,182 @@
+package discovery
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// Knee detection: a level is past the knee once its p95 exceeds the
+// lowest level's by kneeLatencyGrowth and by at least kneeMinRiseMs,
+// so a sub-millisecond baseline doesn't call noise a knee.
+const (
+	kneeLatencyGrowth = 1.5
+	kneeMinRiseMs     = 1.0
+)
+
+// ConcurrencyLevels returns up to n evenly spaced concurrency levels
+// from lo to hi inclusive, rounded and without repeats. n < 2 falls
+// back to config.DefaultSweepSteps.
+func ConcurrencyLevels(lo, hi, n int) []int {
+	var levels []int
+	for _, f := range SweepLevels(float64(lo), float64(hi), n) {
+		l := int(math.Round(f))
+		if len(levels) == 0 || l > levels[len(levels)-1] {
+			levels = append(levels, l)
+		}
+	}
+	return levels
+}
+
+// concurrencySweep tests ConcurrencyLevels in order, recording every
+// step. The knee is found afterwards from the whole curve; see Knee.
+// Returns false when cancelled.
+func (c *Controller) concurrencySweep(ctx context.Context) bool {
+	lo, hi := c.cfg.MinConcurrency, c.cfg.MaxConcurrency
+	if lo <= 0 {
+		lo = config.DefaultMinConcurrency
+	}
+	if hi <= 0 {
+		hi = config.DefaultMaxConcurrency
+	}
+	levels := ConcurrencyLevels(lo, hi, c.cfg.SweepSteps)
+	for i, n := range levels {
+		c.updateStatus(fmt.Sprintf("Concurrency %d/%d at %d in flight", i+1, len(levels), n))
+
+		stepResult := c.runConcurrencyStep(ctx, n)
+		if stepResult == nil {
+			c.mu.Lock()
+			c.state = StateFailed
+			c.mu.Unlock()
+			return false
+		}
+
+		c.mu.Lock()
+		c.stepsCompleted++
+		c.steps = append(c.steps, *stepResult)
+		c.progress = min(float64(i+1)/float64(len(levels))*100, 99)
+		c.mu.Unlock()
+
+		log.Printf("[discovery] concurrency %d/%d: in_flight=%d achieved=%.0f stable=%v p95=%.1fms p99=%.1fms err=%.2f%%",
+			i+1, len(levels), n, stepResult.AchievedTPS, stepResult.Stable,
+			stepResult.P95Latency, stepResult.P99Latency, stepResult.ErrorRate)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	knee := Knee(c.steps)
+	if knee < 0 {
+		return true
+	}
+	c.kneeConcurrency = c.steps[knee].Concurrency
+	c.lastStableTPS = c.steps[knee].AchievedTPS
+	if knee+1 < len(c.steps) {
+		c.breakingTPS = c.steps[knee+1].AchievedTPS
+	}
+	return true
+}
+
+// runConcurrencyStep holds n requests in flight for the step duration,
+// each worker sending its next request as soon as the last returns.
+func (c *Controller) runConcurrencyStep(ctx context.Context, n int) *StepResult {
+	c.analyzer.ResetWindow()
+
+	req := &protocol.Request{
+		URL:     c.cfg.TargetURL,
+		Method:  c.cfg.Method,
+		Timeout: 5 * time.Second,
+
+		CaptureBody: c.cfg.Expect.CaptureBody(),
+	}
+
+	stepCtx, cancel := context.WithTimeout(ctx, c.cfg.StepDuration)
+	defer cancel()
+
+	startRequests := atomic.LoadInt64(&c.totalRequests)
+	startErrors := atomic.LoadInt64(&c.totalErrors)
+	startConn := atomic.LoadInt64(&c.totalConnErrors)
+	startRetries := atomic.LoadInt64(&c.totalRetries)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for stepCtx.Err() == nil {
+				c.sendRequest(stepCtx, req)
+			}
+		}()
+	}
+
+	step := c.collectStep(ctx, stepCtx, 0, c.cfg.StepDuration, startRequests, startErrors, startConn, startRetries)
+	// Requests cut short by the step's end would count toward the next.
+	wg.Wait()
+	if step != nil {
+		step.Concurrency = n
+	}
+	return step
+}
+
+// Knee returns the index of the last step, in sweep order, before
+// latency starts rising with concurrency: every step up to it is
+// stable and keeps its p95 within kneeLatencyGrowth of the first
+// step's. Returns -1 when the first step is already unstable.
+func Knee(steps []StepResult) int {
+	if len(steps) == 0 || !steps[0].Stable {
+		return -1
+	}
+	limit := max(steps[0].P95Latency*kneeLatencyGrowth, steps[0].P95Latency+kneeMinRiseMs)
+	knee := 0
+	for i, s := range steps[1:] {
+		if !s.Stable || s.P95Latency > limit {
+			break
+		}
+		knee = i + 1
+	}
+	return knee
+}
+
+// concurrencyCurveHeader is the column layout of
+// WriteConcurrencyCurveCSV, in WriteCurveCSV's units.
+var concurrencyCurveHeader = []string{
+	"concurrency", "achieved_tps", "p95_ms", "p99_ms", "error_rate", "requests", "errors", "stable", "knee",
+}
+
+// WriteConcurrencyCurveCSV writes one row per concurrency level in
+// sweep order, marking the knee, so the output plots directly as a
+// concurrency/latency curve.
+func WriteConcurrencyCurveCSV(w io.Writer, steps []StepResult) error {
+	knee := Knee(steps)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(concurrencyCurveHeader); err != nil {
+		return err
+	}
+	for i, s := range steps {
+		row := []string{
+			strconv.Itoa(s.Concurrency),
+			strconv.FormatFloat(s.AchievedTPS, 'f', 1, 64),
+			strconv.FormatFloat(s.P95Latency, 'f', 3, 64),
+			strconv.FormatFloat(s.P99Latency, 'f', 3, 64),
+			strconv.FormatFloat(s.ErrorRate, 'f', 3, 64),
+			strconv.FormatInt(s.TotalRequests, 10),
+			strconv.FormatInt(s.TotalErrors, 10),
+			strconv.FormatBool(s.Stable),
+			strconv.FormatBool(i == knee),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
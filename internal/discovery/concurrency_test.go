@@ -0,0 +1,127 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/health"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// queueClient serves cap requests at a time, each taking service; the
+// rest queue, so latency rises once more than cap are in flight.
+type queueClient struct {
+	slots   chan struct{}
+	service time.Duration
+}
+
+func (c *queueClient) Do(ctx context.Context, _ *protocol.Request) *protocol.Response {
+	start := time.Now()
+	select {
+	case c.slots <- struct{}{}:
+	case <-ctx.Done():
+		return &protocol.Response{Error: ctx.Err(), Duration: time.Since(start)}
+	}
+	time.Sleep(c.service)
+	<-c.slots
+	return &protocol.Response{StatusCode: 200, Duration: time.Since(start)}
+}
+func (*queueClient) Close() error { return nil }
+
+func TestConcurrencySweep_FindsKnee(t *testing.T) {
+	c := NewController(config.Discovery{
+		Concurrency:    true,
+		MinConcurrency: 1,
+		MaxConcurrency: 7,
+		SweepSteps:     3,
+		StepDuration:   300 * time.Millisecond,
+		LatencyLimitMs: 1000,
+		ErrorRateLimit: 5,
+	}, health.NewMetrics(health.NewRegistry()))
+	c.client = &queueClient{slots: make(chan struct{}, 4), service: 10 * time.Millisecond}
+
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for c.GetState() == StateRunning {
+		time.Sleep(10 * time.Millisecond)
+	}
+	r := c.GetResult()
+	if r == nil {
+		t.Fatal("no result")
+	}
+	var levels []int
+	for _, s := range r.Steps {
+		levels = append(levels, s.Concurrency)
+	}
+	if !slices.Equal(levels, []int{1, 4, 7}) {
+		t.Fatalf("levels = %v, want [1 4 7]", levels)
+	}
+	if r.KneeConcurrency != 4 {
+		t.Fatalf("knee = %d, want 4 (p95 by level: %.1f %.1f %.1f)",
+			r.KneeConcurrency, r.Steps[0].P95Latency, r.Steps[1].P95Latency, r.Steps[2].P95Latency)
+	}
+	if r.SustainedTPS != r.Steps[1].AchievedTPS {
+		t.Fatalf("sustained = %.0f, want the knee's throughput %.0f", r.SustainedTPS, r.Steps[1].AchievedTPS)
+	}
+}
+
+func TestConcurrencyLevels_RoundsWithoutRepeats(t *testing.T) {
+	if got := ConcurrencyLevels(1, 100, 5); !slices.Equal(got, []int{1, 26, 51, 75, 100}) {
+		t.Fatalf("got %v", got)
+	}
+	if got := ConcurrencyLevels(1, 3, 10); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("narrow range: got %v", got)
+	}
+}
+
+func TestKnee(t *testing.T) {
+	cases := []struct {
+		name string
+		p95  []float64
+		want int
+	}{
+		{"rises at the third level", []float64{10, 11, 20, 12}, 1},
+		{"flat curve", []float64{10, 11, 12}, 2},
+		{"sub-millisecond noise", []float64{0.2, 0.5, 0.9}, 2},
+	}
+	for _, tc := range cases {
+		var steps []StepResult
+		for _, p := range tc.p95 {
+			steps = append(steps, StepResult{P95Latency: p, Stable: true})
+		}
+		if got := Knee(steps); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+	if got := Knee([]StepResult{{P95Latency: 10}}); got != -1 {
+		t.Errorf("unstable first level: got %d, want -1", got)
+	}
+	if got := Knee([]StepResult{{P95Latency: 10, Stable: true}, {P95Latency: 11, ErrorRate: 9}}); got != 0 {
+		t.Errorf("unstable second level: got %d, want 0", got)
+	}
+}
+
+func TestWriteConcurrencyCurveCSV_MarksKnee(t *testing.T) {
+	steps := []StepResult{
+		{Concurrency: 1, AchievedTPS: 100, P95Latency: 10, P99Latency: 12, TotalRequests: 1000, Stable: true},
+		{Concurrency: 8, AchievedTPS: 790, P95Latency: 11, P99Latency: 15, TotalRequests: 7900, Stable: true},
+		{Concurrency: 16, AchievedTPS: 800, P95Latency: 20, P99Latency: 30, TotalRequests: 8000, Stable: true},
+	}
+	var buf bytes.Buffer
+	if err := WriteConcurrencyCurveCSV(&buf, steps); err != nil {
+		t.Fatal(err)
+	}
+	want := "concurrency,achieved_tps,p95_ms,p99_ms,error_rate,requests,errors,stable,knee\n" +
+		"1,100.0,10.000,12.000,0.000,1000,0,true,false\n" +
+		"8,790.0,11.000,15.000,0.000,7900,0,true,true\n" +
+		"16,800.0,20.000,30.000,0.000,8000,0,true,false\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, strings.TrimSpace(want))
+	}
+}
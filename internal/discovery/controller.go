@@ -2,10 +2,13 @@ package discovery
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/kar98k/internal/config"
@@ -43,10 +46,25 @@ type Controller struct {
 	lastStableTPS  float64
 	breakingTPS    float64
 	stepsCompleted int
+	steps          []StepResult
+
+	// kneeConcurrency is set by a concurrency sweep (#1250).
+	kneeConcurrency int
+
+	// searchSpan is the range the binary search started from and
+	// progressBase the progress made before it; a staged discovery's
+	// fine phase starts from the coarse bracket.
+	searchSpan   float64
+	progressBase float64
+	// bracketLow and bracketHigh are a staged discovery's coarse
+	// bracket.
+	bracketLow, bracketHigh float64
 
 	// Request tracking
-	totalRequests int64
-	totalErrors   int64
+	totalRequests   int64
+	totalErrors     int64
+	totalConnErrors int64
+	totalRetries    int64
 
 	// Progress tracking
 	progress  float64
@@ -63,7 +81,11 @@ func NewController(cfg config.Discovery, metrics *health.Metrics) *Controller {
 	clientCfg := protocol.ClientConfig{
 		MaxIdleConns:    100,
 		IdleConnTimeout: 90 * time.Second,
-		TLSInsecure:     true,
+		TLSInsecure:     cfg.TLSInsecure,
+		Plaintext:       true,
+	}
+	if cfg.TLSInsecure {
+		log.Printf("[discovery] WARNING: TLS certificate verification is OFF: certificate errors won't fail requests")
 	}
 
 	var client protocol.Client
@@ -72,6 +94,8 @@ func NewController(cfg config.Discovery, metrics *health.Metrics) *Controller {
 		client = protocol.NewHTTP2Client(clientCfg)
 	case config.ProtocolGRPC:
 		client = protocol.NewGRPCClient(clientCfg)
+	case config.ProtocolGRPCWeb:
+		client = protocol.NewGRPCWebClient(clientCfg)
 	default:
 		client = protocol.NewHTTPClient(clientCfg)
 	}
@@ -117,7 +141,12 @@ func (c *Controller) Start(ctx context.Context) error {
 	c.currentTPS = c.cfg.MinTPS
 	c.lastStableTPS = 0
 	c.breakingTPS = 0
+	c.kneeConcurrency = 0
+	c.searchSpan = c.cfg.MaxTPS - c.cfg.MinTPS
+	c.progressBase = 0
+	c.bracketLow, c.bracketHigh = 0, 0
 	c.stepsCompleted = 0
+	c.steps = nil
 	c.analyzer.Reset()
 	c.mu.Unlock()
 
@@ -204,7 +233,40 @@ func (c *Controller) run(ctx context.Context) {
 
 	c.updateStatus("Starting discovery...")
 
-	// Binary search loop
+	if c.cfg.Sweep {
+		if !c.sweep(ctx) {
+			return
+		}
+		c.finish()
+		return
+	}
+
+	if c.cfg.Concurrency {
+		if !c.concurrencySweep(ctx) {
+			return
+		}
+		c.finish()
+		return
+	}
+
+	if c.cfg.Staged {
+		if !c.staged(ctx) {
+			return
+		}
+		c.finish()
+		return
+	}
+
+	if !c.binarySearch(ctx, "") {
+		return
+	}
+	c.finish()
+}
+
+// binarySearch narrows [lowTPS, highTPS] from currentTPS until it
+// converges, tagging each step with phase. Returns false when
+// cancelled.
+func (c *Controller) binarySearch(ctx context.Context, phase string) bool {
 	for {
 		select {
 		case <-ctx.Done():
@@ -212,24 +274,26 @@ func (c *Controller) run(ctx context.Context) {
 			c.mu.Lock()
 			c.state = StateFailed
 			c.mu.Unlock()
-			return
+			return false
 		default:
 		}
 
 		// Check convergence
 		if c.hasConverged() {
-			break
+			return true
 		}
 
 		// Run a step at the current TPS
-		stepResult := c.runStep(ctx)
+		stepResult := c.runStep(ctx, c.cfg.StepDuration)
 		if stepResult == nil {
 			// Context cancelled or error
-			return
+			return false
 		}
+		stepResult.Phase = phase
 
 		c.mu.Lock()
 		c.stepsCompleted++
+		c.steps = append(c.steps, *stepResult)
 
 		if stepResult.Stable {
 			// System is stable at this TPS, try higher
@@ -239,7 +303,7 @@ func (c *Controller) run(ctx context.Context) {
 			if c.currentTPS >= c.highTPS {
 				// Reached max, we're done
 				c.mu.Unlock()
-				break
+				return true
 			}
 
 			// Binary search: try midpoint between current and high
@@ -259,12 +323,70 @@ func (c *Controller) run(ctx context.Context) {
 		c.updateProgress()
 		c.mu.Unlock()
 
-		log.Printf("[discovery] step %d: tps=%.0f stable=%v p95=%.1fms err=%.2f%% range=[%.0f-%.0f]",
+		log.Printf("[discovery] step %d: tps=%.0f stable=%v p95=%.1fms err=%.2f%% conn_err=%d retries=%d range=[%.0f-%.0f]",
 			c.stepsCompleted, stepResult.TPS, stepResult.Stable, stepResult.P95Latency,
-			stepResult.ErrorRate, c.lowTPS, c.highTPS)
+			stepResult.ErrorRate, stepResult.ConnErrors, stepResult.Retries, c.lowTPS, c.highTPS)
 	}
+}
+
+// sweep tests SweepLevels in order, recording every step. The
+// sustained TPS is the highest level below the first unstable one and
+// the breaking TPS is that first unstable level. Returns false when
+// cancelled.
+func (c *Controller) sweep(ctx context.Context) bool {
+	levels := SweepLevels(c.cfg.MinTPS, c.cfg.MaxTPS, c.cfg.SweepSteps)
+	for i, tps := range levels {
+		c.mu.Lock()
+		c.currentTPS = tps
+		c.updateStatusLocked(fmt.Sprintf("Sweep %d/%d at %.0f TPS", i+1, len(levels), tps))
+		c.mu.Unlock()
 
-	// Generate final result
+		stepResult := c.runStep(ctx, c.cfg.StepDuration)
+		if stepResult == nil {
+			c.mu.Lock()
+			c.state = StateFailed
+			c.mu.Unlock()
+			return false
+		}
+
+		c.mu.Lock()
+		c.stepsCompleted++
+		c.steps = append(c.steps, *stepResult)
+		if stepResult.Stable && c.breakingTPS == 0 {
+			c.lastStableTPS = tps
+		} else if !stepResult.Stable && c.breakingTPS == 0 {
+			c.breakingTPS = tps
+		}
+		c.progress = float64(i+1) / float64(len(levels)) * 100
+		if c.progress > 99 {
+			c.progress = 99
+		}
+		c.mu.Unlock()
+
+		log.Printf("[discovery] sweep %d/%d: tps=%.0f achieved=%.0f stable=%v p95=%.1fms p99=%.1fms err=%.2f%% conn_err=%d retries=%d",
+			i+1, len(levels), tps, stepResult.AchievedTPS, stepResult.Stable,
+			stepResult.P95Latency, stepResult.P99Latency, stepResult.ErrorRate,
+			stepResult.ConnErrors, stepResult.Retries)
+	}
+	return true
+}
+
+// SweepLevels returns n evenly spaced TPS levels from lo to hi
+// inclusive. n < 2 falls back to config.DefaultSweepSteps.
+func SweepLevels(lo, hi float64, n int) []float64 {
+	if n < 2 {
+		n = config.DefaultSweepSteps
+	}
+	levels := make([]float64, n)
+	step := (hi - lo) / float64(n-1)
+	for i := range levels {
+		levels[i] = lo + step*float64(i)
+	}
+	return levels
+}
+
+// finish builds the final Result from the search state.
+func (c *Controller) finish() {
 	c.mu.Lock()
 	snapshot := c.analyzer.TakeSnapshot()
 
@@ -286,6 +408,9 @@ func (c *Controller) run(ctx context.Context) {
 		time.Since(c.startTime),
 		c.stepsCompleted,
 	)
+	c.result.Steps = c.steps
+	c.result.KneeConcurrency = c.kneeConcurrency
+	c.result.BracketLow, c.result.BracketHigh = c.bracketLow, c.bracketHigh
 	c.state = StateCompleted
 	c.progress = 100
 
@@ -304,8 +429,8 @@ func (c *Controller) run(ctx context.Context) {
 	}
 }
 
-// runStep runs a single TPS test step.
-func (c *Controller) runStep(ctx context.Context) *StepResult {
+// runStep runs a single TPS test step lasting d.
+func (c *Controller) runStep(ctx context.Context, d time.Duration) *StepResult {
 	c.mu.Lock()
 	tps := c.currentTPS
 	c.mu.Unlock()
@@ -318,14 +443,18 @@ func (c *Controller) runStep(ctx context.Context) *StepResult {
 		URL:     c.cfg.TargetURL,
 		Method:  c.cfg.Method,
 		Timeout: 5 * time.Second,
+
+		CaptureBody: c.cfg.Expect.CaptureBody(),
 	}
 
 	// Submit jobs for the step duration
-	stepCtx, cancel := context.WithTimeout(ctx, c.cfg.StepDuration)
+	stepCtx, cancel := context.WithTimeout(ctx, d)
 	defer cancel()
 
 	startRequests := atomic.LoadInt64(&c.totalRequests)
 	startErrors := atomic.LoadInt64(&c.totalErrors)
+	startConn := atomic.LoadInt64(&c.totalConnErrors)
+	startRetries := atomic.LoadInt64(&c.totalRetries)
 
 	// Calculate interval between requests
 	interval := time.Second / time.Duration(tps)
@@ -348,13 +477,23 @@ func (c *Controller) runStep(ctx context.Context) *StepResult {
 		}
 	}()
 
-	// Wait for step to complete, collecting metrics
+	return c.collectStep(ctx, stepCtx, tps, d, startRequests, startErrors, startConn, startRetries)
+}
+
+// collectStep waits out stepCtx, a step lasting d, reporting progress,
+// and returns the step's result measured from the given counter
+// values, or nil when discovery is cancelled.
+func (c *Controller) collectStep(ctx, stepCtx context.Context, tps float64, d time.Duration, startRequests, startErrors, startConn, startRetries int64) *StepResult {
+	start := time.Now()
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-stepCtx.Done():
+			if ctx.Err() != nil {
+				return nil
+			}
 			// Step complete, analyze results
 			snapshot := c.analyzer.TakeSnapshot()
 
@@ -363,28 +502,25 @@ func (c *Controller) runStep(ctx context.Context) *StepResult {
 
 			stepRequests := endRequests - startRequests
 			stepErrors := endErrors - startErrors
-
-			var errorRate float64
-			if stepRequests > 0 {
-				errorRate = float64(stepErrors) / float64(stepRequests) * 100
-			}
+			stepConn := atomic.LoadInt64(&c.totalConnErrors) - startConn
+			errorRate := stepErrorRate(stepRequests, stepErrors, stepConn, c.cfg.ConnErrorTolerance)
 
 			stable := c.isStable(snapshot.P95Latency, errorRate)
 
 			return &StepResult{
 				TPS:           tps,
 				P95Latency:    snapshot.P95Latency,
+				P99Latency:    snapshot.P99Latency,
+				AchievedTPS:   float64(stepRequests) / d.Seconds(),
 				ErrorRate:     errorRate,
 				Stable:        stable,
-				Duration:      c.cfg.StepDuration,
+				Duration:      d,
 				TotalRequests: stepRequests,
 				TotalErrors:   stepErrors,
+				ConnErrors:    stepConn,
+				Retries:       atomic.LoadInt64(&c.totalRetries) - startRetries,
 			}
 
-		case <-ctx.Done():
-			// Discovery cancelled
-			return nil
-
 		case <-ticker.C:
 			// Update progress callback
 			snapshot := c.analyzer.TakeSnapshot()
@@ -392,28 +528,92 @@ func (c *Controller) runStep(ctx context.Context) *StepResult {
 			endErrors := atomic.LoadInt64(&c.totalErrors)
 			stepRequests := endRequests - startRequests
 			stepErrors := endErrors - startErrors
-			var errorRate float64
-			if stepRequests > 0 {
-				errorRate = float64(stepErrors) / float64(stepRequests) * 100
+			stepConn := atomic.LoadInt64(&c.totalConnErrors) - startConn
+			errorRate := stepErrorRate(stepRequests, stepErrors, stepConn, c.cfg.ConnErrorTolerance)
+			current := tps
+			if c.cfg.Concurrency {
+				// A closed loop has no target rate; report what it achieves.
+				current = float64(stepRequests) / time.Since(start).Seconds()
 			}
-			c.notifyProgress(tps, snapshot.P95Latency, errorRate)
+			c.notifyProgress(current, snapshot.P95Latency, errorRate)
 		}
 	}
 }
 
+// connRetryBackoff is the wait before the first connection retry; it
+// doubles on each further one.
+const connRetryBackoff = 10 * time.Millisecond
+
 // sendRequest sends a single request and records metrics.
 func (c *Controller) sendRequest(ctx context.Context, req *protocol.Request) {
+	start := time.Now()
 	resp := c.client.Do(ctx, req)
+	retries := 0
+	for ; retries < c.cfg.ConnRetries && isConnError(resp); retries++ {
+		select {
+		case <-ctx.Done():
+		case <-time.After(connRetryBackoff << retries):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		atomic.AddInt64(&c.totalRetries, 1)
+		resp = c.client.Do(ctx, req)
+	}
 
-	// Record latency in milliseconds
-	latencyMs := resp.Duration.Seconds() * 1000
+	// Record latency in milliseconds. After a retry it runs from the
+	// first attempt, as a client that retries would see it.
+	latency := resp.Duration
+	if retries > 0 {
+		latency = time.Since(start)
+	}
+	latencyMs := latency.Seconds() * 1000
 	isError := resp.StatusCode >= 400 || resp.StatusCode == 0
+	if c.cfg.Protocol.GRPCStatus() {
+		// gRPC status codes: OK is 0.
+		isError = resp.Error != nil || resp.StatusCode != 0
+	}
+	if e := c.cfg.Expect; e != nil && resp.Error == nil {
+		if len(e.Status) > 0 {
+			isError = !slices.Contains(e.Status, resp.StatusCode)
+		}
+		isError = isError || !e.MatchBody(resp.Body)
+	}
 
 	c.analyzer.RecordLatency(latencyMs, isError)
 	atomic.AddInt64(&c.totalRequests, 1)
 	if isError {
 		atomic.AddInt64(&c.totalErrors, 1)
+		if isConnError(resp) {
+			atomic.AddInt64(&c.totalConnErrors, 1)
+		}
+	}
+}
+
+// isConnError reports whether resp failed before reaching the server:
+// refused, reset, or a connect timeout. Those are what a briefly full
+// accept queue produces (#1235); DNS and TLS failures, and requests
+// that timed out waiting for an answer, are not.
+func isConnError(resp *protocol.Response) bool {
+	if resp.Error == nil || resp.StatusCode != 0 {
+		return false
+	}
+	return errors.Is(resp.Error, protocol.ErrConnectTimeout) ||
+		errors.Is(resp.Error, syscall.ECONNRESET) ||
+		health.ClassifyError(resp.Error) == config.HealthFailureRefused
+}
+
+// stepErrorRate is a step's error rate in percent. Connection errors
+// are left out while they stay within tolerance percent of the
+// requests — a transient blip — and all count once they exceed it.
+func stepErrorRate(requests, errs, connErrs int64, tolerance float64) float64 {
+	if requests <= 0 {
+		return 0
+	}
+	if tolerance > 0 && float64(connErrs)/float64(requests)*100 <= tolerance {
+		errs -= connErrs
 	}
+	return float64(errs) / float64(requests) * 100
 }
 
 // isStable checks if the system is stable based on latency and error rate.
@@ -439,12 +639,13 @@ func (c *Controller) hasConverged() bool {
 
 // updateProgress calculates and updates the progress percentage.
 func (c *Controller) updateProgress() {
-	// Estimate progress based on how narrow the search range has become
-	initialRange := c.cfg.MaxTPS - c.cfg.MinTPS
+	// Estimate progress based on how narrow the search range has
+	// become, past what an earlier phase already covered.
+	initialRange := c.searchSpan
 	currentRange := c.highTPS - c.lowTPS
 
 	if initialRange > 0 {
-		c.progress = (1 - currentRange/initialRange) * 100
+		c.progress = c.progressBase + (1-currentRange/initialRange)*(100-c.progressBase)
 		if c.progress > 99 {
 			c.progress = 99
 		}
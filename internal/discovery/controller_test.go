@@ -0,0 +1,112 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/health"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// flakyClient refuses the first n connections, then answers 200.
+type flakyClient struct{ n int }
+
+func (c *flakyClient) Do(context.Context, *protocol.Request) *protocol.Response {
+	if c.n > 0 {
+		c.n--
+		return &protocol.Response{Error: fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED), Duration: time.Millisecond}
+	}
+	return &protocol.Response{StatusCode: 200, Duration: time.Millisecond}
+}
+func (*flakyClient) Close() error { return nil }
+
+func TestSendRequest_RetriesConnectionErrors(t *testing.T) {
+	c := NewController(config.Discovery{ConnRetries: 2}, health.NewMetrics(health.NewRegistry()))
+	c.client = &flakyClient{n: 2}
+	c.sendRequest(context.Background(), &protocol.Request{})
+	if c.totalErrors != 0 || c.totalRetries != 2 {
+		t.Fatalf("errors=%d retries=%d, want 0 and 2", c.totalErrors, c.totalRetries)
+	}
+
+	// Out of retries, the refusal is an error, and a connection one.
+	c.client = &flakyClient{n: 3}
+	c.sendRequest(context.Background(), &protocol.Request{})
+	if c.totalErrors != 1 || c.totalConnErrors != 1 {
+		t.Fatalf("errors=%d conn=%d, want 1 and 1", c.totalErrors, c.totalConnErrors)
+	}
+}
+
+// fixedClient answers every request with a copy of resp.
+type fixedClient struct{ resp protocol.Response }
+
+func (c *fixedClient) Do(context.Context, *protocol.Request) *protocol.Response {
+	r := c.resp
+	return &r
+}
+func (*fixedClient) Close() error { return nil }
+
+func TestSendRequest_ExpectFailuresAreErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		resp protocol.Response
+		want int64
+	}{
+		{"body matches", protocol.Response{StatusCode: 200, Body: []byte(`{"ok":true}`)}, 0},
+		{"200 with an error body", protocol.Response{StatusCode: 200, Body: []byte(`{"error":true}`)}, 1},
+		{"unexpected status", protocol.Response{StatusCode: 204, Body: []byte(`"ok"`)}, 1},
+	}
+	for _, tc := range cases {
+		c := NewController(config.Discovery{
+			Expect: &config.Expect{Status: []int{200, 201}, BodyContains: `"ok"`},
+		}, health.NewMetrics(health.NewRegistry()))
+		c.client = &fixedClient{resp: tc.resp}
+		c.sendRequest(context.Background(), &protocol.Request{})
+		if c.totalErrors != tc.want {
+			t.Errorf("%s: errors = %d, want %d", tc.name, c.totalErrors, tc.want)
+		}
+	}
+}
+
+func TestIsConnError(t *testing.T) {
+	cases := []struct {
+		resp protocol.Response
+		want bool
+	}{
+		{protocol.Response{Error: fmt.Errorf("dial: %w", syscall.ECONNREFUSED)}, true},
+		{protocol.Response{Error: fmt.Errorf("read: %w", syscall.ECONNRESET)}, true},
+		{protocol.Response{Error: fmt.Errorf("%w: dial", protocol.ErrConnectTimeout)}, true},
+		{protocol.Response{Error: context.DeadlineExceeded}, false},
+		{protocol.Response{StatusCode: 503}, false},
+	}
+	for i, tc := range cases {
+		if got := isConnError(&tc.resp); got != tc.want {
+			t.Errorf("case %d (%v): got %v, want %v", i, tc.resp.Error, got, tc.want)
+		}
+	}
+}
+
+func TestStepErrorRate_ToleratesTransientConnErrors(t *testing.T) {
+	cases := []struct {
+		name      string
+		errs, con int64
+		tolerance float64
+		want      float64
+	}{
+		{"no tolerance counts all", 3, 2, 0, 3},
+		{"within tolerance drops conn errors", 3, 2, 2, 1},
+		{"past tolerance counts all", 5, 4, 2, 5},
+	}
+	for _, tc := range cases {
+		if got := stepErrorRate(100, tc.errs, tc.con, tc.tolerance); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if got := stepErrorRate(0, 0, 0, 1); got != 0 {
+		t.Errorf("empty step: got %v", got)
+	}
+}
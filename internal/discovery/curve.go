@@ -0,0 +1,45 @@
+package discovery
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// curveHeader is the column layout of WriteCurveCSV. Latencies are in
+// milliseconds and error_rate is a percentage, matching StepResult.
+var curveHeader = []string{
+	"tps", "achieved_tps", "p95_ms", "p99_ms", "error_rate", "requests", "errors", "stable",
+}
+
+// WriteCurveCSV writes one row per tested level, sorted by target TPS,
+// so the output plots directly as a load/latency curve. Binary search
+// may test a level more than once; each run gets its own row.
+func WriteCurveCSV(w io.Writer, steps []StepResult) error {
+	sorted := make([]StepResult, len(steps))
+	copy(sorted, steps)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].TPS < sorted[j].TPS })
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(curveHeader); err != nil {
+		return err
+	}
+	for _, s := range sorted {
+		row := []string{
+			strconv.FormatFloat(s.TPS, 'f', 1, 64),
+			strconv.FormatFloat(s.AchievedTPS, 'f', 1, 64),
+			strconv.FormatFloat(s.P95Latency, 'f', 3, 64),
+			strconv.FormatFloat(s.P99Latency, 'f', 3, 64),
+			strconv.FormatFloat(s.ErrorRate, 'f', 3, 64),
+			strconv.FormatInt(s.TotalRequests, 10),
+			strconv.FormatInt(s.TotalErrors, 10),
+			strconv.FormatBool(s.Stable),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
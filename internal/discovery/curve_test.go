@@ -0,0 +1,52 @@
+package discovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteCurveCSV_SortsByTPS(t *testing.T) {
+	// Binary search order: mid, then lower, then in between.
+	steps := []StepResult{
+		{TPS: 500, AchievedTPS: 430, P95Latency: 900, P99Latency: 1500, ErrorRate: 7.5, TotalRequests: 4300, TotalErrors: 322},
+		{TPS: 250, AchievedTPS: 250, P95Latency: 40, P99Latency: 80, TotalRequests: 2500, Stable: true},
+		{TPS: 375, AchievedTPS: 371, P95Latency: 120, P99Latency: 300, ErrorRate: 0.5, TotalRequests: 3710, TotalErrors: 18, Stable: true},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCurveCSV(&buf, steps); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"tps,achieved_tps,p95_ms,p99_ms,error_rate,requests,errors,stable",
+		"250.0,250.0,40.000,80.000,0.000,2500,0,true",
+		"375.0,371.0,120.000,300.000,0.500,3710,18,true",
+		"500.0,430.0,900.000,1500.000,7.500,4300,322,false",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines:\n%s", len(lines), buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+	if steps[0].TPS != 500 {
+		t.Fatalf("WriteCurveCSV reordered the caller's slice")
+	}
+}
+
+func TestSweepLevels(t *testing.T) {
+	got := SweepLevels(100, 1000, 4)
+	want := []float64{100, 400, 700, 1000}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("SweepLevels = %v, want %v", got, want)
+		}
+	}
+	if n := len(SweepLevels(10, 100, 0)); n != 10 {
+		t.Fatalf("default sweep length = %d, want 10", n)
+	}
+}
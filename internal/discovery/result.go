@@ -22,6 +22,23 @@ type Result struct {
 	// StepsCompleted is the number of binary search steps completed.
 	StepsCompleted int
 
+	// Steps is every tested level in the order it ran. Binary search
+	// visits levels out of order; WriteCurveCSV sorts them by TPS.
+	Steps []StepResult
+
+	// KneeConcurrency is, for a concurrency sweep, the most requests in
+	// flight before latency starts rising (#1250); SustainedTPS is the
+	// throughput there and BreakingTPS the next level's. 0 otherwise,
+	// or when even the lowest level was unstable.
+	KneeConcurrency int
+
+	// BracketLow and BracketHigh are the TPS range a staged discovery's
+	// coarse phase bracketed the breaking point in, the last stable and
+	// first unstable coarse levels; the fine phase searched only
+	// there. Both 0 otherwise. BracketHigh is 0 when every coarse
+	// level held, leaving nothing to search.
+	BracketLow, BracketHigh float64
+
 	// Recommendation provides suggested configuration values.
 	Recommendation Recommendation
 }
@@ -40,12 +57,27 @@ type Recommendation struct {
 
 // StepResult holds the result of a single TPS step test.
 type StepResult struct {
-	// TPS is the TPS tested in this step.
+	// TPS is the TPS tested in this step; 0 in a concurrency sweep.
 	TPS float64
 
+	// Concurrency is the requests held in flight in a concurrency
+	// sweep step; 0 in a TPS step.
+	Concurrency int
+
+	// Phase is PhaseCoarse or PhaseFine in a staged discovery, empty
+	// otherwise.
+	Phase string
+
 	// P95Latency is the P95 latency during this step (in milliseconds).
 	P95Latency float64
 
+	// P99Latency is the P99 latency during this step (in milliseconds).
+	P99Latency float64
+
+	// AchievedTPS is completed requests per second over the step. It
+	// falls short of TPS once the target (or the sender) saturates.
+	AchievedTPS float64
+
 	// ErrorRate is the error rate during this step (percentage).
 	ErrorRate float64
 
@@ -60,6 +92,13 @@ type StepResult struct {
 
 	// TotalErrors is the total errors during this step.
 	TotalErrors int64
+
+	// ConnErrors is how many of TotalErrors failed to connect. Within
+	// conn_error_tolerance they are left out of ErrorRate (#1235).
+	ConnErrors int64
+
+	// Retries is how many connection retries the step made.
+	Retries int64
 }
 
 // NewResult creates a new Result with recommendations based on discovered values.
@@ -0,0 +1,96 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/kar98k/internal/config"
+)
+
+// Phases of a staged discovery, as StepResult.Phase.
+const (
+	PhaseCoarse = "coarse"
+	PhaseFine   = "fine"
+)
+
+// coarseProgress is the share of the progress bar the coarse phase
+// fills; the fine phase's longer steps take the rest.
+const coarseProgress = 30.0
+
+// CoarseLevels returns the coarse phase's levels: lo, then each level
+// factor times the last, ending at hi. factor <= 1 falls back to
+// config.DefaultCoarseFactor.
+func CoarseLevels(lo, hi, factor float64) []float64 {
+	if factor <= 1 {
+		factor = config.DefaultCoarseFactor
+	}
+	var levels []float64
+	// The tolerance keeps float error from adding a level a hair
+	// below hi.
+	for l := lo; l < hi*(1-1e-9); l *= factor {
+		levels = append(levels, l)
+	}
+	return append(levels, hi)
+}
+
+// staged brackets the breaking point with short CoarseLevels steps,
+// then binary-searches the bracket with full-length steps. Returns
+// false when cancelled.
+func (c *Controller) staged(ctx context.Context) bool {
+	d := c.cfg.CoarseStepDuration
+	if d <= 0 {
+		d = config.DefaultCoarseStepDuration
+	}
+	levels := CoarseLevels(c.cfg.MinTPS, c.cfg.MaxTPS, c.cfg.CoarseFactor)
+	for i, tps := range levels {
+		c.mu.Lock()
+		c.currentTPS = tps
+		c.updateStatusLocked(fmt.Sprintf("Coarse %d/%d at %.0f TPS", i+1, len(levels), tps))
+		c.mu.Unlock()
+
+		stepResult := c.runStep(ctx, d)
+		if stepResult == nil {
+			c.mu.Lock()
+			c.state = StateFailed
+			c.mu.Unlock()
+			return false
+		}
+		stepResult.Phase = PhaseCoarse
+
+		c.mu.Lock()
+		c.stepsCompleted++
+		c.steps = append(c.steps, *stepResult)
+		c.progress = float64(i+1) / float64(len(levels)) * coarseProgress
+		if stepResult.Stable {
+			c.lastStableTPS = tps
+		} else {
+			c.breakingTPS = tps
+		}
+		c.mu.Unlock()
+
+		log.Printf("[discovery] coarse %d/%d: tps=%.0f achieved=%.0f stable=%v p95=%.1fms err=%.2f%% conn_err=%d retries=%d",
+			i+1, len(levels), tps, stepResult.AchievedTPS, stepResult.Stable,
+			stepResult.P95Latency, stepResult.ErrorRate, stepResult.ConnErrors, stepResult.Retries)
+		if !stepResult.Stable {
+			break
+		}
+	}
+
+	c.mu.Lock()
+	c.bracketLow, c.bracketHigh = c.lastStableTPS, c.breakingTPS
+	if c.breakingTPS == 0 || c.lastStableTPS == 0 {
+		// Every level held, or the first didn't: nothing to search.
+		c.mu.Unlock()
+		return true
+	}
+	c.lowTPS, c.highTPS = c.lastStableTPS, c.breakingTPS
+	c.currentTPS = (c.lowTPS + c.highTPS) / 2
+	c.searchSpan = c.highTPS - c.lowTPS
+	c.progressBase = coarseProgress
+	c.updateStatusLocked(fmt.Sprintf("Bracketed %.0f-%.0f TPS, trying %.0f", c.lowTPS, c.highTPS, c.currentTPS))
+	c.mu.Unlock()
+
+	log.Printf("[discovery] coarse phase bracketed the breaking point in [%.0f-%.0f] TPS", c.bracketLow, c.bracketHigh)
+	return c.binarySearch(ctx, PhaseFine)
+}
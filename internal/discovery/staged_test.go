@@ -0,0 +1,120 @@
+package discovery
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/health"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// capacityClient fails every request while the controller tests a
+// level above capacity.
+type capacityClient struct {
+	c        *Controller
+	capacity float64
+}
+
+func (cc *capacityClient) Do(context.Context, *protocol.Request) *protocol.Response {
+	if cc.c.GetCurrentTPS() > cc.capacity {
+		return &protocol.Response{StatusCode: 503, Duration: time.Millisecond}
+	}
+	return &protocol.Response{StatusCode: 200, Duration: time.Millisecond}
+}
+func (*capacityClient) Close() error { return nil }
+
+func TestStaged_BracketsThenSearchesBracket(t *testing.T) {
+	c := NewController(config.Discovery{
+		Staged:             true,
+		MinTPS:             100,
+		MaxTPS:             100000,
+		CoarseStepDuration: 40 * time.Millisecond,
+		StepDuration:       60 * time.Millisecond,
+		ConvergenceRate:    0.05,
+		LatencyLimitMs:     1000,
+		ErrorRateLimit:     5,
+	}, health.NewMetrics(health.NewRegistry()))
+	c.client = &capacityClient{c: c, capacity: 3000}
+
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for c.GetState() == StateRunning {
+		time.Sleep(10 * time.Millisecond)
+	}
+	r := c.GetResult()
+	if r == nil {
+		t.Fatal("no result")
+	}
+
+	var coarse []float64
+	for i, s := range r.Steps {
+		switch s.Phase {
+		case PhaseCoarse:
+			if i != len(coarse) {
+				t.Fatalf("coarse step %d ran after the fine phase began", i)
+			}
+			coarse = append(coarse, s.TPS)
+			if s.Duration != 40*time.Millisecond {
+				t.Fatalf("coarse step lasted %s", s.Duration)
+			}
+		case PhaseFine:
+			if s.TPS <= 1000 || s.TPS >= 10000 {
+				t.Fatalf("fine step at %.0f TPS, outside the bracket", s.TPS)
+			}
+			if s.Duration != 60*time.Millisecond {
+				t.Fatalf("fine step lasted %s", s.Duration)
+			}
+		default:
+			t.Fatalf("step %d has phase %q", i, s.Phase)
+		}
+	}
+	if !slices.Equal(coarse, []float64{100, 1000, 10000}) {
+		t.Fatalf("coarse levels = %v, want 100 1000 10000", coarse)
+	}
+	if r.BracketLow != 1000 || r.BracketHigh != 10000 {
+		t.Fatalf("bracket = [%.0f, %.0f]", r.BracketLow, r.BracketHigh)
+	}
+	if r.SustainedTPS > 3000 || r.SustainedTPS < 3000*0.95 {
+		t.Fatalf("sustained = %.0f, want just under 3000", r.SustainedTPS)
+	}
+}
+
+func TestStaged_AllCoarseLevelsHold(t *testing.T) {
+	c := NewController(config.Discovery{
+		Staged:             true,
+		MinTPS:             10,
+		MaxTPS:             50,
+		CoarseStepDuration: 20 * time.Millisecond,
+		StepDuration:       time.Hour, // a fine step would hang the test
+		LatencyLimitMs:     1000,
+		ErrorRateLimit:     5,
+	}, health.NewMetrics(health.NewRegistry()))
+	c.client = &capacityClient{c: c, capacity: 1e9}
+
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for c.GetState() == StateRunning {
+		time.Sleep(10 * time.Millisecond)
+	}
+	r := c.GetResult()
+	if r.SustainedTPS != 50 || len(r.Steps) != 2 || r.BracketHigh != 0 {
+		t.Fatalf("sustained=%.0f steps=%d bracket high=%.0f, want 50, 2 coarse steps, none", r.SustainedTPS, len(r.Steps), r.BracketHigh)
+	}
+}
+
+func TestCoarseLevels(t *testing.T) {
+	if got := CoarseLevels(10, 10000, 10); !slices.Equal(got, []float64{10, 100, 1000, 10000}) {
+		t.Fatalf("got %v", got)
+	}
+	if got := CoarseLevels(10, 5000, 0); !slices.Equal(got, []float64{10, 100, 1000, 5000}) {
+		t.Fatalf("default factor, uneven max: got %v", got)
+	}
+	if got := CoarseLevels(1, 8, 2); !slices.Equal(got, []float64{1, 2, 4, 8}) {
+		t.Fatalf("factor 2: got %v", got)
+	}
+}
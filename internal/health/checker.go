@@ -2,13 +2,20 @@ package health
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/kar98k/internal/config"
 	"github.com/kar98k/pkg/protocol"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -19,8 +26,27 @@ type Checker struct {
 	metrics  *Metrics
 	clients  map[config.Protocol]protocol.Client
 	statuses map[string]bool
-	mu       sync.RWMutex
-	cancel   context.CancelFunc
+	// failures tracks the current run of consecutive failures per
+	// target. A run of one cause is only acted on once it reaches that
+	// cause's threshold; a different cause starts a new run. See #1180.
+	failures map[string]failureRun
+	// causes holds the failure cause that made each unhealthy target
+	// unhealthy. It is kept while the target stays down, whatever
+	// later checks fail with, and cleared once it recovers.
+	causes map[string]config.HealthFailure
+	mu     sync.RWMutex
+	cancel context.CancelFunc
+
+	// tlsInsecure is the global worker.tls_insecure; flipped is the
+	// HTTP/1.1 client for targets that override it (#1220).
+	tlsInsecure bool
+	flipped     protocol.Client
+}
+
+// failureRun is a streak of consecutive failed checks with one cause.
+type failureRun struct {
+	reason config.HealthFailure
+	count  int
 }
 
 // NewChecker creates a new health checker.
@@ -31,9 +57,17 @@ func NewChecker(cfg config.Health, targets []config.Target, metrics *Metrics) *C
 		metrics:  metrics,
 		clients:  make(map[config.Protocol]protocol.Client),
 		statuses: make(map[string]bool),
+		failures: make(map[string]failureRun),
+		causes:   make(map[string]config.HealthFailure),
 	}
 }
 
+// SetTLSInsecure sets the global certificate verification policy the
+// checks follow, worker.tls_insecure. Call before Start.
+func (c *Checker) SetTLSInsecure(skip bool) {
+	c.tlsInsecure = skip
+}
+
 // Start begins periodic health checking.
 func (c *Checker) Start(ctx context.Context) {
 	if !c.cfg.Enabled {
@@ -46,17 +80,22 @@ func (c *Checker) Start(ctx context.Context) {
 	clientCfg := protocol.ClientConfig{
 		MaxIdleConns:    10,
 		IdleConnTimeout: 30 * time.Second,
-		TLSInsecure:     true,
+		TLSInsecure:     c.tlsInsecure,
+		Plaintext:       true,
 	}
 
 	c.clients[config.ProtocolHTTP] = protocol.NewHTTPClient(clientCfg)
 	c.clients[config.ProtocolHTTP2] = protocol.NewHTTP2Client(clientCfg)
 	c.clients[config.ProtocolGRPC] = protocol.NewGRPCClient(clientCfg)
+	c.clients[config.ProtocolGRPCWeb] = protocol.NewGRPCWebClient(clientCfg)
+	flippedCfg := clientCfg
+	flippedCfg.TLSInsecure = !c.tlsInsecure
+	c.flipped = protocol.NewHTTPClient(flippedCfg)
 
 	// Initialize all targets as healthy
 	for _, t := range c.targets {
 		c.statuses[t.Name] = true
-		c.metrics.SetTargetHealth(t.Name, true)
+		c.metrics.SetTargetHealth(t.Name, true, "")
 	}
 
 	go c.run(ctx)
@@ -98,6 +137,9 @@ func (c *Checker) checkTarget(ctx context.Context, target config.Target) {
 	if !ok {
 		client = c.clients[config.ProtocolHTTP]
 	}
+	if (target.Protocol == config.ProtocolHTTP || target.Protocol == "") && target.SkipTLSVerify(c.tlsInsecure) != c.tlsInsecure {
+		client = c.flipped
+	}
 
 	req := &protocol.Request{
 		URL:     target.URL,
@@ -111,32 +153,138 @@ func (c *Checker) checkTarget(ctx context.Context, target config.Target) {
 
 	resp := client.Do(checkCtx, req)
 
-	healthy := resp.Error == nil && resp.StatusCode >= 200 && resp.StatusCode < 400
+	reason := classifyFailure(resp, target.Protocol.GRPCStatus())
 
 	c.mu.Lock()
 	prevStatus := c.statuses[target.Name]
+	healthy := prevStatus
+	run := c.failures[target.Name]
+	if reason == "" {
+		healthy = true
+		run = failureRun{}
+	} else {
+		if run.reason != reason {
+			run = failureRun{reason: reason}
+		}
+		run.count++
+		// Below threshold the previous verdict stands: a resolver
+		// blip doesn't pull a healthy target out of rotation.
+		if run.count >= c.threshold(reason) {
+			healthy = false
+		}
+	}
+	switch {
+	case healthy:
+		delete(c.causes, target.Name)
+	case c.causes[target.Name] == "":
+		c.causes[target.Name] = reason
+	}
+	cause := c.causes[target.Name]
+	c.failures[target.Name] = run
 	c.statuses[target.Name] = healthy
 	c.mu.Unlock()
 
-	c.metrics.SetTargetHealth(target.Name, healthy)
+	if reason != "" {
+		c.metrics.IncHealthCheckFailure(target.Name, reason)
+	}
+	c.metrics.SetTargetHealth(target.Name, healthy, cause)
 
 	// Log status changes
 	if prevStatus != healthy {
 		if healthy {
 			log.Printf("[health] target %s is now healthy", target.Name)
 		} else {
-			log.Printf("[health] target %s is now unhealthy: %v", target.Name, resp.Error)
+			detail := resp.Error
+			if detail == nil {
+				detail = fmt.Errorf("status %s", target.Protocol.StatusText(resp.StatusCode))
+			}
+			log.Printf("[health] target %s is now unhealthy (%s after %d check(s)): %v",
+				target.Name, reason, run.count, detail)
+		}
+	}
+}
+
+// threshold returns how many consecutive failures of reason it takes
+// to mark a target unhealthy. Unconfigured causes default to 1.
+func (c *Checker) threshold(reason config.HealthFailure) int {
+	if n := c.cfg.FailureThresholds[reason]; n > 0 {
+		return n
+	}
+	return 1
+}
+
+// classifyFailure maps a health-check response to its failure cause,
+// or "" when the check passed. grpcStatus says StatusCode is a gRPC
+// code, where OK is 0.
+func classifyFailure(resp *protocol.Response, grpcStatus bool) config.HealthFailure {
+	err := resp.Error
+	if err == nil {
+		ok := resp.StatusCode >= 200 && resp.StatusCode < 400
+		if grpcStatus {
+			ok = resp.StatusCode == 0
+		}
+		if ok {
+			return ""
 		}
+		return config.HealthFailureStatus
 	}
+	return ClassifyError(err)
 }
 
-// IsHealthy returns whether a target is currently healthy.
+// ClassifyError names the cause of a transport error: dns, refused,
+// timeout, tls or other. The report's error matrix uses it too (#1229).
+func ClassifyError(err error) config.HealthFailure {
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuth x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return config.HealthFailureDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return config.HealthFailureRefused
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return config.HealthFailureTimeout
+	case errors.As(err, &certErr), errors.As(err, &unknownAuth),
+		errors.As(err, &hostErr), errors.As(err, &recordErr):
+		return config.HealthFailureTLS
+	}
+	return config.HealthFailureOther
+}
+
+// IsHealthy returns whether a target is currently healthy. With health
+// checking disabled every target counts as healthy.
 func (c *Checker) IsHealthy(targetName string) bool {
+	if !c.cfg.Enabled {
+		return true
+	}
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.statuses[targetName]
 }
 
+// AllUnhealthy reports whether checking is running and every target is
+// currently marked unhealthy (#1190).
+func (c *Checker) AllUnhealthy() bool {
+	if !c.cfg.Enabled {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.statuses) == 0 {
+		return false
+	}
+	for _, t := range c.targets {
+		if c.statuses[t.Name] {
+			return false
+		}
+	}
+	return true
+}
+
 // GetHealthyTargets returns a slice of healthy targets.
 func (c *Checker) GetHealthyTargets() []config.Target {
 	c.mu.RLock()
@@ -160,19 +308,25 @@ func (c *Checker) Stop() {
 	for _, client := range c.clients {
 		client.Close()
 	}
+	if c.flipped != nil {
+		c.flipped.Close()
+	}
 }
 
-// Server serves Prometheus metrics and health endpoints.
+// Server serves Prometheus metrics and health endpoints on one or more
+// addresses.
 type Server struct {
-	server *http.Server
+	servers   []*http.Server
+	listeners []net.Listener
 }
 
-// NewServer creates a new metrics/health HTTP server.
-func NewServer(cfg config.Metrics) *Server {
+// NewServer creates a new metrics/health HTTP server serving g, the
+// registry of the run's Metrics.
+func NewServer(cfg config.Metrics, g prometheus.Gatherer) *Server {
 	mux := http.NewServeMux()
 
 	// Prometheus metrics endpoint
-	mux.Handle(cfg.Path, promhttp.Handler())
+	mux.Handle(cfg.Path, promhttp.HandlerFor(g, promhttp.HandlerOpts{}))
 
 	// Liveness probe
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
@@ -186,21 +340,66 @@ func NewServer(cfg config.Metrics) *Server {
 		w.Write([]byte("ok"))
 	})
 
-	return &Server{
-		server: &http.Server{
-			Addr:    cfg.Address,
-			Handler: mux,
-		},
+	s := &Server{}
+	for _, addr := range cfg.ListenAddresses() {
+		s.servers = append(s.servers, &http.Server{Addr: addr, Handler: mux})
 	}
+	return s
 }
 
-// Start begins serving metrics.
+// Listen binds every address without serving yet, so a caller can
+// fail its start on an address already in use instead of finding out
+// from a log line (#1238). On error nothing stays bound.
+func (s *Server) Listen() error {
+	if s.listeners != nil {
+		return nil
+	}
+	lns := make([]net.Listener, 0, len(s.servers))
+	for _, srv := range s.servers {
+		ln, err := net.Listen("tcp", srv.Addr)
+		if err != nil {
+			for _, l := range lns {
+				l.Close()
+			}
+			return err
+		}
+		lns = append(lns, ln)
+	}
+	s.listeners = lns
+	return nil
+}
+
+// Start serves on every address and blocks until they have all
+// stopped. It calls Listen first if the caller hasn't.
 func (s *Server) Start() error {
-	log.Printf("[metrics] starting server on %s", s.server.Addr)
-	return s.server.ListenAndServe()
+	if err := s.Listen(); err != nil {
+		return err
+	}
+	errs := make(chan error, len(s.servers))
+	for i, srv := range s.servers {
+		log.Printf("[metrics] starting server on %s", srv.Addr)
+		go func(srv *http.Server, ln net.Listener) { errs <- srv.Serve(ln) }(srv, s.listeners[i])
+	}
+	for range s.servers {
+		if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	}
+	return nil
 }
 
 // Stop gracefully stops the server.
 func (s *Server) Stop(ctx context.Context) error {
-	return s.server.Shutdown(ctx)
+	var first error
+	for _, srv := range s.servers {
+		if err := srv.Shutdown(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+	// Listeners bound by Listen but never served aren't the servers'
+	// to close.
+	for _, ln := range s.listeners {
+		ln.Close()
+	}
+	return first
 }
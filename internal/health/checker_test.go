@@ -0,0 +1,151 @@
+package health
+
+import (
+	"context"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/pkg/protocol"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// scriptedClient answers each check with the next queued response.
+type scriptedClient struct{ resps []*protocol.Response }
+
+func (c *scriptedClient) Do(context.Context, *protocol.Request) *protocol.Response {
+	r := c.resps[0]
+	c.resps = c.resps[1:]
+	return r
+}
+func (c *scriptedClient) Close() error { return nil }
+
+var (
+	okResp      = &protocol.Response{StatusCode: 200}
+	dnsResp     = &protocol.Response{Error: &net.DNSError{Err: "no such host", Name: "api", IsNotFound: true}}
+	refusedResp = &protocol.Response{Error: &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}
+	timeoutResp = &protocol.Response{Error: context.DeadlineExceeded}
+)
+
+// newTestChecker returns a started-looking checker for one target,
+// "api", answered by client.
+func newTestChecker(t *testing.T, thresholds map[config.HealthFailure]int, client protocol.Client) *Checker {
+	t.Helper()
+	cfg := config.Health{Enabled: true, Timeout: time.Second, FailureThresholds: thresholds}
+	target := config.Target{Name: "api", URL: "http://api/health", Protocol: config.ProtocolHTTP}
+	c := NewChecker(cfg, []config.Target{target}, NewMetrics(prometheus.NewRegistry()))
+	c.clients[config.ProtocolHTTP] = client
+	c.statuses["api"] = true
+	return c
+}
+
+// check runs one health check of "api" and returns the verdict and
+// the cause the unhealthy_reason gauge reports, "" for none.
+func check(t *testing.T, c *Checker) (bool, config.HealthFailure) {
+	t.Helper()
+	c.checkTarget(context.Background(), c.targets[0])
+	var cause config.HealthFailure
+	for _, r := range config.HealthFailures {
+		var m dto.Metric
+		if err := c.metrics.TargetUnhealthyReason.WithLabelValues("api", string(r)).Write(&m); err != nil {
+			t.Fatalf("gauge Write: %v", err)
+		}
+		if m.GetGauge().GetValue() == 1 {
+			if cause != "" {
+				t.Fatalf("both %s and %s reported", cause, r)
+			}
+			cause = r
+		}
+	}
+	return c.IsHealthy("api"), cause
+}
+
+func TestChecker_RetriesDNSUpToItsThreshold(t *testing.T) {
+	client := &scriptedClient{resps: []*protocol.Response{dnsResp, dnsResp, okResp, dnsResp, dnsResp, dnsResp}}
+	c := newTestChecker(t, map[config.HealthFailure]int{config.HealthFailureDNS: 3}, client)
+
+	for i := 0; i < 2; i++ {
+		if healthy, _ := check(t, c); !healthy {
+			t.Fatalf("DNS failure %d of 3 took the target down", i+1)
+		}
+	}
+	if healthy, _ := check(t, c); !healthy {
+		t.Fatal("a passing check should leave it healthy")
+	}
+	// The pass reset the streak: it takes three more.
+	for i := 0; i < 2; i++ {
+		if healthy, _ := check(t, c); !healthy {
+			t.Fatalf("DNS failure %d after the reset took the target down", i+1)
+		}
+	}
+	if healthy, cause := check(t, c); healthy || cause != config.HealthFailureDNS {
+		t.Fatalf("after 3 DNS failures: healthy=%v cause=%q, want down for dns", healthy, cause)
+	}
+}
+
+func TestChecker_RefusedMarksDownImmediately(t *testing.T) {
+	client := &scriptedClient{resps: []*protocol.Response{refusedResp}}
+	c := newTestChecker(t, map[config.HealthFailure]int{config.HealthFailureDNS: 3}, client)
+
+	if healthy, cause := check(t, c); healthy || cause != config.HealthFailureRefused {
+		t.Fatalf("healthy=%v cause=%q, want down for refused on the first check", healthy, cause)
+	}
+}
+
+func TestChecker_MixedCausesKeepTheFirstVerdictsCause(t *testing.T) {
+	client := &scriptedClient{resps: []*protocol.Response{
+		// Streaks of different causes don't add up...
+		dnsResp, dnsResp, timeoutResp,
+		// ...until the timeout streak reaches 2.
+		timeoutResp,
+		// Still down, now failing for other causes.
+		dnsResp, dnsResp, dnsResp, refusedResp,
+		okResp,
+	}}
+	c := newTestChecker(t, map[config.HealthFailure]int{
+		config.HealthFailureDNS:     3,
+		config.HealthFailureTimeout: 2,
+	}, client)
+
+	for i := 0; i < 3; i++ {
+		if healthy, _ := check(t, c); !healthy {
+			t.Fatalf("check %d: dns, dns, timeout shouldn't take the target down", i+1)
+		}
+	}
+	if healthy, cause := check(t, c); healthy || cause != config.HealthFailureTimeout {
+		t.Fatalf("healthy=%v cause=%q, want down for timeout", healthy, cause)
+	}
+	for i := 0; i < 4; i++ {
+		if healthy, cause := check(t, c); healthy || cause != config.HealthFailureTimeout {
+			t.Fatalf("check %d while down: healthy=%v cause=%q, want still down for timeout", i+1, healthy, cause)
+		}
+	}
+	if healthy, cause := check(t, c); !healthy || cause != "" {
+		t.Fatalf("after recovering: healthy=%v cause=%q", healthy, cause)
+	}
+}
+
+func TestClassifyFailure(t *testing.T) {
+	for _, c := range []struct {
+		resp *protocol.Response
+		grpc bool
+		want config.HealthFailure
+	}{
+		{okResp, false, ""},
+		{&protocol.Response{StatusCode: 503}, false, config.HealthFailureStatus},
+		{&protocol.Response{StatusCode: 0}, true, ""},
+		{&protocol.Response{StatusCode: 14}, true, config.HealthFailureStatus},
+		{dnsResp, false, config.HealthFailureDNS},
+		{refusedResp, false, config.HealthFailureRefused},
+		{timeoutResp, false, config.HealthFailureTimeout},
+		{&protocol.Response{Error: os.ErrClosed}, false, config.HealthFailureOther},
+	} {
+		if got := classifyFailure(c.resp, c.grpc); got != c.want {
+			t.Errorf("classifyFailure(%+v, grpc=%v) = %q, want %q", c.resp, c.grpc, got, c.want)
+		}
+	}
+}
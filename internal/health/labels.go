@@ -0,0 +1,61 @@
+package health
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OtherLabel replaces label values past the cardinality cap.
+const OtherLabel = "other"
+
+// labelGuard caps how many distinct values one label takes across the
+// metrics (#1231). The first limit values keep their name; later ones
+// are all reported as OtherLabel, so a config with thousands of
+// targets or request specs can't blow up the scrape.
+type labelGuard struct {
+	name      string
+	limit     *atomic.Int64
+	collapsed *prometheus.CounterVec
+
+	seen   sync.Map // value -> the label it is reported under
+	mu     sync.Mutex
+	count  int64
+	warned bool
+}
+
+// value returns v, or OtherLabel when v turned up after the guard was
+// full. Values seen before cost a single map lookup.
+func (g *labelGuard) value(v string) string {
+	return g.keyed(v, v)
+}
+
+// keyed is value for labels only unique together with another one,
+// such as a spec name within its target: key counts against the cap
+// and v is what gets reported.
+func (g *labelGuard) keyed(key, v string) string {
+	if l, ok := g.seen.Load(key); ok {
+		return l.(string)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if l, ok := g.seen.Load(key); ok {
+		return l.(string)
+	}
+	label := v
+	if limit := g.limit.Load(); limit >= 0 && g.count >= limit {
+		label = OtherLabel
+		g.collapsed.WithLabelValues(g.name).Inc()
+		if !g.warned {
+			g.warned = true
+			log.Printf("[metrics] WARNING: more than %d distinct %s label values; %q and later ones are reported as %q (metrics.max_label_values)",
+				limit, g.name, v, OtherLabel)
+		}
+	} else {
+		g.count++
+	}
+	g.seen.Store(key, label)
+	return label
+}
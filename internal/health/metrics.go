@@ -1,7 +1,11 @@
 package health
 
 import (
+	"sync/atomic"
+
+	"github.com/kar98k/internal/config"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
@@ -19,12 +23,76 @@ type Metrics struct {
 	SpikeActive      prometheus.Gauge
 	TargetHealth     *prometheus.GaugeVec
 
+	// SpecRequestsTotal breaks requests down by request-mix spec (#1182).
+	SpecRequestsTotal *prometheus.CounterVec
+	// TTFBDuration is time to first response byte for targets with
+	// record_ttfb or first_byte_only (#1185).
+	TTFBDuration *prometheus.HistogramVec
+	// Connection pool pressure for targets with max_conns_per_host
+	// (#1188). ConnWaitDuration is time spent obtaining a connection;
+	// ConnQueuedTotal counts requests that waited for one to be freed.
+	ConnWaitDuration *prometheus.HistogramVec
+	ConnQueuedTotal  *prometheus.CounterVec
+	// DeadlineExceededTotal counts requests cut off by a target's
+	// max_total_time (#1197).
+	DeadlineExceededTotal *prometheus.CounterVec
+	// TimeoutsTotal counts requests that timed out, by phase (#1225):
+	// "connect" when connect_timeout ran out before a connection was
+	// up, "response" when timeout ran out after.
+	TimeoutsTotal *prometheus.CounterVec
+	// InjectedFaultsTotal counts failures faked by worker.fault_inject
+	// (#1198), so a test of kar's error handling can be checked
+	// against what was injected.
+	InjectedFaultsTotal *prometheus.CounterVec
+	// HookErrorsTotal counts pre_request/post_response hook failures
+	// (#1200); each fails the request it ran for.
+	HookErrorsTotal *prometheus.CounterVec
+	// GeneratorSaturated is 1 while every worker is busy and the
+	// queue full, so kar can't feed the requested TPS (#1222).
+	GeneratorSaturated prometheus.Gauge
+	// TPSFidelity is achieved ÷ requested TPS over the run (#1221).
+	TPSFidelity prometheus.Gauge
+	// ConfigInfo is always 1; its fingerprint label identifies the
+	// config the run used (#1219), so exported metrics can be told
+	// apart by settings.
+	ConfigInfo *prometheus.GaugeVec
+	// RunInfo is always 1; its labels name the kar build and host that
+	// produced the run (#1236).
+	RunInfo *prometheus.GaugeVec
+	// PanicsTotal counts panics kar recovered from its own goroutines
+	// (#1216), by component: worker, control or controller.
+	PanicsTotal *prometheus.CounterVec
+	// CacheDuration is latency split by the cache status a cache_bust
+	// target's responses report, "hit" or "miss" (#1206).
+	CacheDuration *prometheus.HistogramVec
+	// LongPollHold is how long long_poll targets held each poll, by
+	// outcome: responded, expired or error (#1218). LongPollsActive is
+	// the polls held open right now.
+	LongPollHold    *prometheus.HistogramVec
+	LongPollsActive *prometheus.GaugeVec
+	// ConnPoolConnections is kar's own connection pool per protocol and
+	// host, by state: open, idle and in_use (#1210).
+	ConnPoolConnections *prometheus.GaugeVec
+	// kar's own GC pauses (#1196). GCOverlappedTotal counts requests
+	// in flight during a pause; GCSelfLatencySeconds sums the overlap,
+	// an estimate of latency kar added to its own measurements.
+	GCOverlappedTotal    prometheus.Counter
+	GCSelfLatencySeconds prometheus.Counter
+
+	// Health failure classification (#1180). TargetUnhealthyReason is 1
+	// for the cause that last marked a target unhealthy and 0 for the
+	// rest; HealthCheckFailuresTotal counts every failed check by cause.
+	TargetUnhealthyReason    *prometheus.GaugeVec
+	HealthCheckFailuresTotal *prometheus.CounterVec
+
 	// Scenario phase metrics (issue #63).
 	ScenarioPhaseIndex            prometheus.Gauge
 	ScenarioPhaseTransitionsTotal *prometheus.CounterVec
 
 	// Circuit breaker state (issue #59). 0 = closed, 1 = open.
 	CircuitBreakerState prometheus.Gauge
+	// LatencyCliffsTotal counts latency cliffs detected (#1252).
+	LatencyCliffsTotal prometheus.Counter
 
 	// Per-worker labelled variants (issue #70). Coexist with aggregate metrics above.
 	ObservedTPSPerWorker  *prometheus.GaugeVec
@@ -39,19 +107,48 @@ type Metrics struct {
 	// (#74) tail-streams histograms to standby to bound this.
 	HAFailoverTotal           prometheus.Counter
 	HAFailoverPercentileGapMs prometheus.Gauge
+
+	// LabelsCollapsedTotal counts label values reported as OtherLabel
+	// because their label hit metrics.max_label_values (#1231).
+	LabelsCollapsedTotal *prometheus.CounterVec
+
+	// Cardinality guards for the labels whose values come from the
+	// config: target names, request specs and connection hosts.
+	labelLimit atomic.Int64
+	targets    *labelGuard
+	specs      *labelGuard
+	hosts      *labelGuard
+
+	registry *prometheus.Registry
+}
+
+// Gatherer returns the registry the metrics are registered on, for the
+// metrics server and the prometheus output sink.
+func (m *Metrics) Gatherer() prometheus.Gatherer {
+	return m.registry
 }
 
-// NewMetrics creates and registers all Prometheus metrics on the default registry.
-func NewMetrics() *Metrics {
-	return NewMetricsWithRegistry(prometheus.DefaultRegisterer)
+// NewRegistry returns a registry with the Go runtime and process
+// collectors, the extras /metrics served when everything sat on the
+// global default registry.
+func NewRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
 }
 
-// NewMetricsWithRegistry creates and registers all Prometheus metrics on the
-// supplied registerer. Tests use a fresh registry to avoid duplicate-registration
-// panics from promauto.
-func NewMetricsWithRegistry(reg prometheus.Registerer) *Metrics {
+// NewMetrics creates and registers all Prometheus metrics on reg. Each
+// daemon, discovery run or test brings its own registry, so several
+// metric sets can live in one process without promauto panicking on
+// duplicate registration (#1214).
+func NewMetrics(reg *prometheus.Registry) *Metrics {
 	f := promauto.With(reg)
-	return &Metrics{
+	m := &Metrics{
+		registry: reg,
+
 		RequestsTotal: f.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: "kar98k",
@@ -69,6 +166,158 @@ func NewMetricsWithRegistry(reg prometheus.Registerer) *Metrics {
 			},
 			[]string{"target", "protocol"},
 		),
+		TTFBDuration: f.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "kar98k",
+				Name:      "ttfb_seconds",
+				Help:      "Time to first response byte",
+				Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+			},
+			[]string{"target"},
+		),
+		ConnWaitDuration: f.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "kar98k",
+				Name:      "conn_wait_seconds",
+				Help:      "Time spent obtaining a connection for targets with max_conns_per_host",
+				Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 16),
+			},
+			[]string{"target"},
+		),
+		ConnQueuedTotal: f.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "kar98k",
+				Name:      "conn_queued_total",
+				Help:      "Requests that blocked waiting for a connection held by another request",
+			},
+			[]string{"target"},
+		),
+		DeadlineExceededTotal: f.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "kar98k",
+				Name:      "deadline_exceeded_total",
+				Help:      "Requests cancelled for exceeding the target's max_total_time",
+			},
+			[]string{"target"},
+		),
+		TimeoutsTotal: f.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "kar98k",
+				Name:      "timeouts_total",
+				Help:      "Requests that timed out, by phase: connect or response",
+			},
+			[]string{"target", "phase"},
+		),
+		InjectedFaultsTotal: f.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "kar98k",
+				Name:      "injected_faults_total",
+				Help:      "Failures faked by kar itself via worker.fault_inject, by kind",
+			},
+			[]string{"kind"},
+		),
+		HookErrorsTotal: f.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "kar98k",
+				Name:      "hook_errors_total",
+				Help:      "Scripting hook failures by hook (pre_request or post_response)",
+			},
+			[]string{"hook"},
+		),
+		GeneratorSaturated: f.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "kar98k",
+				Name:      "generator_saturated",
+				Help:      "1 while every worker is busy and the queue is full, so the requested TPS can't be fed",
+			},
+		),
+		TPSFidelity: f.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "kar98k",
+				Name:      "tps_fidelity",
+				Help:      "Achieved TPS divided by requested TPS, averaged over the run",
+			},
+		),
+		ConfigInfo: f.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "kar98k",
+				Name:      "config_info",
+				Help:      "Always 1; the fingerprint label identifies the run's load-shaping config",
+			},
+			[]string{"fingerprint"},
+		),
+		RunInfo: f.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "kar98k",
+				Name:      "run_info",
+				Help:      "Always 1; the labels identify the kar build and host that ran the load",
+			},
+			[]string{"version", "git_commit", "host"},
+		),
+		PanicsTotal: f.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "kar98k",
+				Name:      "panics_total",
+				Help:      "Panics recovered in kar's own goroutines by component",
+			},
+			[]string{"component"},
+		),
+		CacheDuration: f.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "kar98k",
+				Name:      "cache_request_duration_seconds",
+				Help:      "Request latency by the cache status the response reported (hit or miss)",
+				Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+			},
+			[]string{"target", "cache"},
+		),
+		LongPollHold: f.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "kar98k",
+				Name:      "long_poll_hold_seconds",
+				Help:      "Time a long_poll target held each poll before answering, by outcome (responded, expired, error)",
+				Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
+			},
+			[]string{"target", "outcome"},
+		),
+		LongPollsActive: f.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "kar98k",
+				Name:      "long_polls_active",
+				Help:      "Polls a long_poll target currently holds open",
+			},
+			[]string{"target"},
+		),
+		ConnPoolConnections: f.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "kar98k",
+				Name:      "conn_pool_connections",
+				Help:      "kar's connections per protocol and host by state (open, idle, in_use); in_use counts in-flight requests for multiplexed protocols",
+			},
+			[]string{"protocol", "host", "state"},
+		),
+		GCOverlappedTotal: f.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "kar98k",
+				Name:      "gc_overlapped_requests_total",
+				Help:      "Requests that were in flight during one of kar's own GC pauses",
+			},
+		),
+		GCSelfLatencySeconds: f.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "kar98k",
+				Name:      "gc_self_latency_seconds_total",
+				Help:      "Summed overlap between requests and kar's own GC pauses",
+			},
+		),
+		SpecRequestsTotal: f.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "kar98k",
+				Name:      "spec_requests_total",
+				Help:      "Requests per target request-mix spec, by result",
+			},
+			[]string{"target", "spec", "result"},
+		),
 		RequestsInFlight: f.NewGauge(
 			prometheus.GaugeOpts{
 				Namespace: "kar98k",
@@ -133,6 +382,22 @@ func NewMetricsWithRegistry(reg prometheus.Registerer) *Metrics {
 			},
 			[]string{"target"},
 		),
+		TargetUnhealthyReason: f.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "kar98k",
+				Name:      "target_unhealthy_reason",
+				Help:      "1 for the failure cause that marked a target unhealthy, 0 otherwise",
+			},
+			[]string{"target", "reason"},
+		),
+		HealthCheckFailuresTotal: f.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "kar98k",
+				Name:      "health_check_failures_total",
+				Help:      "Failed health checks by target and failure cause",
+			},
+			[]string{"target", "reason"},
+		),
 		ScenarioPhaseIndex: f.NewGauge(
 			prometheus.GaugeOpts{
 				Namespace: "kar98k",
@@ -155,6 +420,13 @@ func NewMetricsWithRegistry(reg prometheus.Registerer) *Metrics {
 				Help:      "Circuit breaker state — 0 = closed (traffic flowing), 1 = open (traffic paused)",
 			},
 		),
+		LatencyCliffsTotal: f.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "kar98k",
+				Name:      "latency_cliffs_total",
+				Help:      "Sudden step-ups in windowed P95 latency over its recent baseline",
+			},
+		),
 		ObservedTPSPerWorker: f.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: "kar98k",
@@ -201,13 +473,39 @@ func NewMetricsWithRegistry(reg prometheus.Registerer) *Metrics {
 				Help:      "Bounded staleness of the standby's percentile snapshot at last failover (Phase 1: 0 — standby has no replica)",
 			},
 		),
+		LabelsCollapsedTotal: f.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "kar98k",
+				Name:      "metric_labels_collapsed_total",
+				Help:      "Distinct label values reported as \"other\" because the label reached metrics.max_label_values",
+			},
+			[]string{"label"},
+		),
 	}
+	m.labelLimit.Store(config.DefaultMaxLabelValues)
+	m.targets = m.newLabelGuard("target")
+	m.specs = m.newLabelGuard("spec")
+	m.hosts = m.newLabelGuard("host")
+	return m
 }
 
-// RecordRequest records metrics for a completed request.
-func (m *Metrics) RecordRequest(target, protocol string, statusCode int, durationSeconds float64) {
+func (m *Metrics) newLabelGuard(name string) *labelGuard {
+	return &labelGuard{name: name, limit: &m.labelLimit, collapsed: m.LabelsCollapsedTotal}
+}
+
+// SetLabelLimit caps the distinct values of each config-derived label
+// (target, spec, host) at n; later values are reported as OtherLabel.
+// Negative lifts the cap. Values already admitted keep their series.
+func (m *Metrics) SetLabelLimit(n int) {
+	m.labelLimit.Store(int64(n))
+}
+
+// RecordRequest records metrics for a completed request. success is
+// decided by the caller from the target's success codes.
+func (m *Metrics) RecordRequest(target, protocol string, success bool, durationSeconds float64) {
+	target = m.targets.value(target)
 	status := "success"
-	if statusCode >= 400 || statusCode == 0 {
+	if !success {
 		status = "error"
 	}
 
@@ -215,6 +513,122 @@ func (m *Metrics) RecordRequest(target, protocol string, statusCode int, duratio
 	m.RequestDuration.WithLabelValues(target, protocol).Observe(durationSeconds)
 }
 
+// RecordTTFB observes one time-to-first-byte sample.
+func (m *Metrics) RecordTTFB(target string, seconds float64) {
+	target = m.targets.value(target)
+	m.TTFBDuration.WithLabelValues(target).Observe(seconds)
+}
+
+// RecordDeadlineExceeded counts a request cut off by max_total_time.
+func (m *Metrics) RecordDeadlineExceeded(target string) {
+	target = m.targets.value(target)
+	m.DeadlineExceededTotal.WithLabelValues(target).Inc()
+}
+
+// RecordTimeout counts a timed-out request; phase is "connect" or
+// "response".
+func (m *Metrics) RecordTimeout(target, phase string) {
+	target = m.targets.value(target)
+	m.TimeoutsTotal.WithLabelValues(target, phase).Inc()
+}
+
+// RecordInjectedFault counts one fault faked by fault injection.
+func (m *Metrics) RecordInjectedFault(kind string) {
+	m.InjectedFaultsTotal.WithLabelValues(kind).Inc()
+}
+
+// RecordHookError counts one failed scripting hook call.
+func (m *Metrics) RecordHookError(hook string) {
+	m.HookErrorsTotal.WithLabelValues(hook).Inc()
+}
+
+// SetGeneratorSaturated flags whether the worker pool is saturated.
+func (m *Metrics) SetGeneratorSaturated(saturated bool) {
+	if saturated {
+		m.GeneratorSaturated.Set(1)
+	} else {
+		m.GeneratorSaturated.Set(0)
+	}
+}
+
+// SetTPSFidelity sets the run's achieved ÷ requested TPS ratio.
+func (m *Metrics) SetTPSFidelity(ratio float64) {
+	m.TPSFidelity.Set(ratio)
+}
+
+// SetConfigInfo publishes the run's config fingerprint.
+func (m *Metrics) SetConfigInfo(fingerprint string) {
+	m.ConfigInfo.WithLabelValues(fingerprint).Set(1)
+}
+
+// SetRunInfo publishes the run's build and host.
+func (m *Metrics) SetRunInfo(version, commit, host string) {
+	m.RunInfo.WithLabelValues(version, commit, host).Set(1)
+}
+
+// RecordPanic counts one recovered panic.
+func (m *Metrics) RecordPanic(component string) {
+	m.PanicsTotal.WithLabelValues(component).Inc()
+}
+
+// RecordCacheResult observes a response's latency under its cache
+// status.
+func (m *Metrics) RecordCacheResult(target string, hit bool, seconds float64) {
+	target = m.targets.value(target)
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.CacheDuration.WithLabelValues(target, result).Observe(seconds)
+}
+
+// RecordLongPoll observes one finished poll's hold time.
+func (m *Metrics) RecordLongPoll(target, outcome string, seconds float64) {
+	target = m.targets.value(target)
+	m.LongPollHold.WithLabelValues(target, outcome).Observe(seconds)
+}
+
+// AddLongPollsActive moves a long_poll target's open-poll gauge by delta.
+func (m *Metrics) AddLongPollsActive(target string, delta float64) {
+	target = m.targets.value(target)
+	m.LongPollsActive.WithLabelValues(target).Add(delta)
+}
+
+// SetConnPool sets one protocol/host's connection pool gauges.
+func (m *Metrics) SetConnPool(protocol, host string, open, idle, inUse int64) {
+	host = m.hosts.value(host)
+	m.ConnPoolConnections.WithLabelValues(protocol, host, "open").Set(float64(open))
+	m.ConnPoolConnections.WithLabelValues(protocol, host, "idle").Set(float64(idle))
+	m.ConnPoolConnections.WithLabelValues(protocol, host, "in_use").Set(float64(inUse))
+}
+
+// RecordGCOverlap counts a request that overlapped kar's own GC
+// pauses by the given total.
+func (m *Metrics) RecordGCOverlap(seconds float64) {
+	m.GCOverlappedTotal.Inc()
+	m.GCSelfLatencySeconds.Add(seconds)
+}
+
+// RecordConnWait observes one connection acquisition; queued marks a
+// request that blocked on an exhausted connection limit.
+func (m *Metrics) RecordConnWait(target string, seconds float64, queued bool) {
+	target = m.targets.value(target)
+	m.ConnWaitDuration.WithLabelValues(target).Observe(seconds)
+	if queued {
+		m.ConnQueuedTotal.WithLabelValues(target).Inc()
+	}
+}
+
+// RecordSpecRequest counts one request of a target's request-mix spec.
+func (m *Metrics) RecordSpecRequest(target, spec string, success bool) {
+	target, spec = m.targets.value(target), m.specs.keyed(target+"\x00"+spec, spec)
+	result := "success"
+	if !success {
+		result = "error"
+	}
+	m.SpecRequestsTotal.WithLabelValues(target, spec, result).Inc()
+}
+
 // SetCurrentTPS updates the current TPS metric.
 func (m *Metrics) SetCurrentTPS(tps float64) {
 	m.CurrentTPS.Set(tps)
@@ -254,13 +668,30 @@ func (m *Metrics) SetSpikeActive(active bool) {
 	}
 }
 
-// SetTargetHealth updates the health status for a target.
-func (m *Metrics) SetTargetHealth(target string, healthy bool) {
+// SetTargetHealth updates the health status for a target. reason is
+// the failure cause when unhealthy and ignored otherwise; exactly one
+// reason series per target is 1 while it is unhealthy, none while it
+// is healthy.
+func (m *Metrics) SetTargetHealth(target string, healthy bool, reason config.HealthFailure) {
+	target = m.targets.value(target)
 	if healthy {
 		m.TargetHealth.WithLabelValues(target).Set(1)
 	} else {
 		m.TargetHealth.WithLabelValues(target).Set(0)
 	}
+	for _, r := range config.HealthFailures {
+		v := 0.0
+		if !healthy && r == reason {
+			v = 1
+		}
+		m.TargetUnhealthyReason.WithLabelValues(target, string(r)).Set(v)
+	}
+}
+
+// IncHealthCheckFailure counts one failed health check.
+func (m *Metrics) IncHealthCheckFailure(target string, reason config.HealthFailure) {
+	target = m.targets.value(target)
+	m.HealthCheckFailuresTotal.WithLabelValues(target, string(reason)).Inc()
 }
 
 // SetScenarioPhaseIndex sets the current 1-based phase index gauge.
@@ -281,6 +712,11 @@ func (m *Metrics) SetCircuitBreakerState(v float64) {
 	m.CircuitBreakerState.Set(v)
 }
 
+// IncLatencyCliffs counts one detected latency cliff.
+func (m *Metrics) IncLatencyCliffs() {
+	m.LatencyCliffsTotal.Inc()
+}
+
 // IncHAFailover increments the master HA failover counter. Call from
 // HALeaseManager.OnLost or graceful-transfer handlers (#72).
 func (m *Metrics) IncHAFailover() {
@@ -0,0 +1,200 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+// maxPostCheckBody caps how much of a post check response is read; a
+// stats endpoint is small, a mistaken URL may not be.
+const maxPostCheckBody = 4 << 20
+
+// PostCheckResult is the outcome of one post check (#1241).
+type PostCheckResult struct {
+	Name      string  `json:"name"`
+	URL       string  `json:"url"`
+	Passed    bool    `json:"passed"`
+	Status    int     `json:"status,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+	// Failures says what didn't hold, one entry per failed assertion.
+	Failures []string `json:"failures,omitempty"`
+}
+
+// RunPostChecks sends each check once, in order, and evaluates its
+// assertions. tlsInsecure is worker.tls_insecure.
+func RunPostChecks(ctx context.Context, checks []config.PostCheck, tlsInsecure bool) []PostCheckResult {
+	if len(checks) == 0 {
+		return nil
+	}
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: tlsInsecure},
+	}}
+	defer client.CloseIdleConnections()
+
+	out := make([]PostCheckResult, len(checks))
+	for i, c := range checks {
+		out[i] = runPostCheck(ctx, client, c)
+	}
+	return out
+}
+
+func runPostCheck(ctx context.Context, client *http.Client, c config.PostCheck) PostCheckResult {
+	res := PostCheckResult{Name: c.Name, URL: c.URL}
+	fail := func(format string, args ...any) {
+		res.Failures = append(res.Failures, fmt.Sprintf(format, args...))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Wait())
+	defer cancel()
+	var body io.Reader
+	if c.Body != "" {
+		body = strings.NewReader(c.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, c.Verb(), c.URL, body)
+	if err != nil {
+		fail("building request: %v", err)
+		return res
+	}
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		fail("request failed: %v", err)
+		return res
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPostCheckBody))
+	resp.Body.Close()
+	res.LatencyMs = float64(time.Since(start)) / float64(time.Millisecond)
+	res.Status = resp.StatusCode
+	if err != nil {
+		fail("reading body: %v", err)
+		return res
+	}
+
+	if !c.Accepts(resp.StatusCode) {
+		want := "2xx"
+		if len(c.Status) > 0 {
+			want = fmt.Sprint(c.Status)
+		}
+		fail("status %d, want %s", resp.StatusCode, want)
+	}
+	if c.Contains != "" && !strings.Contains(string(data), c.Contains) {
+		fail("body doesn't contain %q", c.Contains)
+	}
+	if len(c.JSON) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var doc any
+		if err := dec.Decode(&doc); err != nil {
+			fail("body is not JSON: %v", err)
+		} else {
+			for _, jc := range c.JSON {
+				if msg := checkJSON(doc, jc); msg != "" {
+					res.Failures = append(res.Failures, msg)
+				}
+			}
+		}
+	}
+	res.Passed = len(res.Failures) == 0
+	return res
+}
+
+// checkJSON evaluates jc against doc and describes the failure, or
+// returns "" when it holds.
+func checkJSON(doc any, jc config.JSONCheck) string {
+	got, ok := lookupJSON(doc, jc.Path)
+	if !ok {
+		return fmt.Sprintf("%s is missing", jc.Path)
+	}
+	op := jc.Operator()
+	if n, isNum := got.(json.Number); isNum {
+		have, err1 := n.Float64()
+		want, err2 := strconv.ParseFloat(jc.Value, 64)
+		if err1 == nil && err2 == nil {
+			if compareNumbers(have, op, want) {
+				return ""
+			}
+			return fmt.Sprintf("%s = %s, want %s %s", jc.Path, n, op, jc.Value)
+		}
+	}
+	text := jsonText(got)
+	switch op {
+	case "==":
+		if text == jc.Value {
+			return ""
+		}
+	case "!=":
+		if text != jc.Value {
+			return ""
+		}
+	default:
+		return fmt.Sprintf("%s = %s is not a number, can't compare with %s", jc.Path, text, op)
+	}
+	return fmt.Sprintf("%s = %s, want %s %s", jc.Path, text, op, jc.Value)
+}
+
+// lookupJSON follows a dot-separated path of object keys and array
+// indexes.
+func lookupJSON(doc any, path string) (any, bool) {
+	cur := doc
+	for _, key := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func compareNumbers(have float64, op string, want float64) bool {
+	switch op {
+	case "==":
+		return have == want
+	case "!=":
+		return have != want
+	case ">":
+		return have > want
+	case ">=":
+		return have >= want
+	case "<":
+		return have < want
+	case "<=":
+		return have <= want
+	}
+	return false
+}
+
+// jsonText is v as the YAML value it is compared with: strings bare,
+// everything else as JSON.
+func jsonText(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,257 @@
+// Package hooks runs the optional Starlark pre-request and
+// post-response hooks (#1200): an escape hatch for request signing,
+// correlation IDs and custom response validation that the structured
+// config can't express.
+//
+// A hooks script defines either or both of:
+//
+//	def pre_request(req):
+//	    # req: dict with target, url, method, headers (dict), body.
+//	    # Mutate it in place, or return a new dict.
+//	    req["headers"]["X-Request-Id"] = uuid()
+//
+//	def post_response(req, resp):
+//	    # resp: struct with status, duration_ms, bytes, error.
+//	    # Return True/False to override success, None to keep it.
+//	    return resp.status != 200 or resp.bytes > 0
+//
+// Secrets configured under hooks.secrets are available as the
+// read-only dict `secrets`, e.g. hmac_sha256(secrets["signing_key"], body).
+//
+// The script's globals are frozen after loading, so the functions are
+// called concurrently from every worker, each call on its own thread.
+package hooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kar98k/pkg/protocol"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// Verdict is a post_response hook's ruling on a request.
+type Verdict int
+
+const (
+	// Keep leaves the target's own success rule in charge.
+	Keep Verdict = iota
+	// Success and Failure override it.
+	Success
+	Failure
+)
+
+// Hooks is a loaded hooks script.
+type Hooks struct {
+	pre  starlark.Callable
+	post starlark.Callable
+}
+
+// Load executes the script at path and picks up its hook functions.
+// secrets, already resolved from the config, become the script's
+// `secrets` dict. A script defining neither hook is an error: it would
+// silently do nothing.
+func Load(path string, secrets map[string]string) (*Hooks, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading hooks script: %w", err)
+	}
+	predeclared := make(starlark.StringDict, len(builtins)+1)
+	for k, v := range builtins {
+		predeclared[k] = v
+	}
+	sd := starlark.NewDict(len(secrets))
+	for k, v := range secrets {
+		sd.SetKey(starlark.String(k), starlark.String(v))
+	}
+	sd.Freeze()
+	predeclared["secrets"] = sd
+
+	thread := &starlark.Thread{Name: "hooks-load"}
+	globals, err := starlark.ExecFile(thread, path, data, predeclared)
+	if err != nil {
+		return nil, fmt.Errorf("executing hooks script: %w", err)
+	}
+	globals.Freeze()
+
+	h := &Hooks{}
+	if fn, ok := globals["pre_request"].(starlark.Callable); ok {
+		h.pre = fn
+	}
+	if fn, ok := globals["post_response"].(starlark.Callable); ok {
+		h.post = fn
+	}
+	if h.pre == nil && h.post == nil {
+		return nil, fmt.Errorf("%s defines neither pre_request nor post_response", path)
+	}
+	return h, nil
+}
+
+// PreRequest runs pre_request, if defined, and writes its changes back
+// into req. req.Headers is replaced, never mutated, because it is
+// shared with the target config.
+func (h *Hooks) PreRequest(target string, req *protocol.Request) error {
+	if h == nil || h.pre == nil {
+		return nil
+	}
+	d := requestDict(target, req)
+	out, err := starlark.Call(newThread("pre_request"), h.pre, starlark.Tuple{d}, nil)
+	if err != nil {
+		return fmt.Errorf("pre_request: %w", err)
+	}
+	switch v := out.(type) {
+	case starlark.NoneType:
+	case *starlark.Dict:
+		d = v
+	default:
+		return fmt.Errorf("pre_request must return None or a dict, got %s", out.Type())
+	}
+	return applyRequest(d, req)
+}
+
+// PostResponse runs post_response, if defined.
+func (h *Hooks) PostResponse(target string, req *protocol.Request, resp *protocol.Response) (Verdict, error) {
+	if h == nil || h.post == nil {
+		return Keep, nil
+	}
+	var errVal starlark.Value = starlark.None
+	if resp.Error != nil {
+		errVal = starlark.String(resp.Error.Error())
+	}
+	r := starlarkstruct.FromStringDict(starlark.String("response"), starlark.StringDict{
+		"status":      starlark.MakeInt(resp.StatusCode),
+		"duration_ms": starlark.Float(float64(resp.Duration) / float64(time.Millisecond)),
+		"bytes":       starlark.MakeInt64(resp.BytesRead),
+		"error":       errVal,
+	})
+	out, err := starlark.Call(newThread("post_response"), h.post, starlark.Tuple{requestDict(target, req), r}, nil)
+	if err != nil {
+		return Keep, fmt.Errorf("post_response: %w", err)
+	}
+	switch v := out.(type) {
+	case starlark.NoneType:
+		return Keep, nil
+	case starlark.Bool:
+		if v {
+			return Success, nil
+		}
+		return Failure, nil
+	}
+	return Keep, fmt.Errorf("post_response must return None or a bool, got %s", out.Type())
+}
+
+func newThread(name string) *starlark.Thread {
+	return &starlark.Thread{
+		Name:  name,
+		Print: func(_ *starlark.Thread, msg string) { fmt.Fprintln(os.Stderr, "[hooks] "+msg) },
+	}
+}
+
+func requestDict(target string, req *protocol.Request) *starlark.Dict {
+	headers := starlark.NewDict(len(req.Headers))
+	for k, v := range req.Headers {
+		headers.SetKey(starlark.String(k), starlark.String(v))
+	}
+	d := starlark.NewDict(5)
+	d.SetKey(starlark.String("target"), starlark.String(target))
+	d.SetKey(starlark.String("url"), starlark.String(req.URL))
+	d.SetKey(starlark.String("method"), starlark.String(req.Method))
+	d.SetKey(starlark.String("headers"), headers)
+	d.SetKey(starlark.String("body"), starlark.String(req.Body))
+	return d
+}
+
+func applyRequest(d *starlark.Dict, req *protocol.Request) error {
+	str := func(key string) (string, bool, error) {
+		v, found, _ := d.Get(starlark.String(key))
+		if !found {
+			return "", false, nil
+		}
+		s, ok := starlark.AsString(v)
+		if !ok {
+			return "", false, fmt.Errorf("req[%q] must be a string, got %s", key, v.Type())
+		}
+		return s, true, nil
+	}
+	if s, ok, err := str("url"); err != nil {
+		return err
+	} else if ok {
+		req.URL = s
+	}
+	if s, ok, err := str("method"); err != nil {
+		return err
+	} else if ok {
+		req.Method = s
+	}
+	if s, ok, err := str("body"); err != nil {
+		return err
+	} else if ok {
+		req.Body = []byte(s)
+	}
+
+	v, found, _ := d.Get(starlark.String("headers"))
+	if !found {
+		return nil
+	}
+	hd, ok := v.(*starlark.Dict)
+	if !ok {
+		return fmt.Errorf(`req["headers"] must be a dict, got %s`, v.Type())
+	}
+	headers := make(map[string]string, hd.Len())
+	for _, item := range hd.Items() {
+		k, kok := starlark.AsString(item[0])
+		val, vok := starlark.AsString(item[1])
+		if !kok || !vok {
+			return errors.New(`req["headers"] keys and values must be strings`)
+		}
+		headers[k] = val
+	}
+	req.Headers = headers
+	return nil
+}
+
+// builtins are the helpers hook scripts get for signing and
+// correlation.
+var builtins = starlark.StringDict{
+	"now_ms":      starlark.NewBuiltin("now_ms", nowMsBuiltin),
+	"uuid":        starlark.NewBuiltin("uuid", uuidBuiltin),
+	"hmac_sha256": starlark.NewBuiltin("hmac_sha256", hmacBuiltin),
+}
+
+func nowMsBuiltin(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+	return starlark.MakeInt64(time.Now().UnixMilli()), nil
+}
+
+func uuidBuiltin(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	h := hex.EncodeToString(b[:])
+	return starlark.String(h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]), nil
+}
+
+func hmacBuiltin(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, msg string
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 2, &key, &msg); err != nil {
+		return nil, err
+	}
+	m := hmac.New(sha256.New, []byte(key))
+	m.Write([]byte(msg))
+	return starlark.String(hex.EncodeToString(m.Sum(nil))), nil
+}
@@ -0,0 +1,149 @@
+package hooks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kar98k/pkg/protocol"
+)
+
+func load(t *testing.T, src string) (*Hooks, error) {
+	return loadWithSecrets(t, src, nil)
+}
+
+func loadWithSecrets(t *testing.T, src string, secrets map[string]string) (*Hooks, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hooks.star")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path, secrets)
+}
+
+func mustLoad(t *testing.T, src string) *Hooks {
+	t.Helper()
+	h, err := load(t, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestLoad_RequiresAHook(t *testing.T) {
+	if _, err := load(t, "x = 1\n"); err == nil {
+		t.Fatal("script with no hooks should fail to load")
+	}
+	if _, err := load(t, "def pre_request(req)\n"); err == nil {
+		t.Fatal("syntax error should fail to load")
+	}
+}
+
+func TestPreRequest_MutatesInPlace(t *testing.T) {
+	h := mustLoad(t, `
+def pre_request(req):
+    req["url"] = req["url"] + "?t=" + req["target"]
+    req["headers"]["X-Id"] = uuid()
+    req["body"] = "signed"
+`)
+	shared := map[string]string{"A": "1"}
+	req := &protocol.Request{URL: "http://x/", Method: "GET", Headers: shared}
+	if err := h.PreRequest("api", req); err != nil {
+		t.Fatal(err)
+	}
+	if req.URL != "http://x/?t=api" || string(req.Body) != "signed" {
+		t.Fatalf("request not rewritten: %+v", req)
+	}
+	if len(req.Headers["X-Id"]) != 36 || req.Headers["A"] != "1" {
+		t.Fatalf("headers = %v", req.Headers)
+	}
+	if len(shared) != 1 {
+		t.Fatalf("shared header map was mutated: %v", shared)
+	}
+}
+
+func TestPreRequest_ReadsSecrets(t *testing.T) {
+	h, err := loadWithSecrets(t, `
+def pre_request(req):
+    req["headers"]["X-Sig"] = hmac_sha256(secrets["key"], req["body"])
+    if req["target"] == "mutate":
+        secrets["key"] = "x"
+`, map[string]string{"key": "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &protocol.Request{Body: []byte("msg")}
+	if err := h.PreRequest("api", req); err != nil {
+		t.Fatal(err)
+	}
+	// hmac_sha256("k", "msg")
+	if got := req.Headers["X-Sig"]; got != "bf1a0c1242929b6464a6c0a9ac6298a67e09bd1cd4ef1f182ce0141691fc17a0" {
+		t.Fatalf("X-Sig = %q", got)
+	}
+	if err := h.PreRequest("mutate", &protocol.Request{}); err == nil {
+		t.Fatal("secrets should be read-only")
+	}
+}
+
+func TestPreRequest_ReturnedDictAndBadTypes(t *testing.T) {
+	h := mustLoad(t, `
+def pre_request(req):
+    if req["target"] == "bad":
+        req["method"] = 1
+        return None
+    return {"method": "POST"}
+`)
+	req := &protocol.Request{URL: "http://x/", Method: "GET"}
+	if err := h.PreRequest("ok", req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "POST" || req.URL != "http://x/" {
+		t.Fatalf("request = %+v, want only the method replaced", req)
+	}
+	if err := h.PreRequest("bad", req); err == nil {
+		t.Fatal("non-string method should be an error")
+	}
+}
+
+func TestPostResponse_Verdicts(t *testing.T) {
+	h := mustLoad(t, `
+def post_response(req, resp):
+    if resp.error != None:
+        return None
+    if resp.status == 500:
+        fail("boom")
+    return resp.duration_ms < 100
+`)
+	req := &protocol.Request{URL: "http://x/"}
+	cases := []struct {
+		resp *protocol.Response
+		want Verdict
+		err  bool
+	}{
+		{&protocol.Response{StatusCode: 200, Duration: 10 * time.Millisecond}, Success, false},
+		{&protocol.Response{StatusCode: 200, Duration: time.Second}, Failure, false},
+		{&protocol.Response{Error: errors.New("refused")}, Keep, false},
+		{&protocol.Response{StatusCode: 500}, Keep, true},
+	}
+	for i, c := range cases {
+		got, err := h.PostResponse("api", req, c.resp)
+		if (err != nil) != c.err {
+			t.Fatalf("case %d: err = %v", i, err)
+		}
+		if got != c.want {
+			t.Fatalf("case %d: verdict = %v, want %v", i, got, c.want)
+		}
+	}
+}
+
+func TestNilHooksAreNoops(t *testing.T) {
+	var h *Hooks
+	if err := h.PreRequest("api", &protocol.Request{}); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := h.PostResponse("api", &protocol.Request{}, &protocol.Response{}); v != Keep || err != nil {
+		t.Fatalf("nil hooks = %v, %v", v, err)
+	}
+}
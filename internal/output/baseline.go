@@ -0,0 +1,146 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kar98k/internal/config"
+)
+
+// Delta is one metric of a run compared against a baseline (#1203).
+type Delta struct {
+	Metric   string  `json:"metric"`
+	Unit     string  `json:"unit"` // "ms", "%" or "tps"
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+	// Change is (Current-Baseline)/Baseline, or 0 with no baseline.
+	// Error rates change in percentage points, see Current-Baseline.
+	Change    float64 `json:"change"`
+	Regressed bool    `json:"regressed"`
+}
+
+// LoadBaseline reads a result written by the json sink.
+func LoadBaseline(path string) (*Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s is not a kar JSON result: %w", path, err)
+	}
+	return &r, nil
+}
+
+// WriteBaseline stores r at path in the json sink's format, so the
+// file doubles as an ordinary run summary.
+func WriteBaseline(path string, r *Result) error {
+	return (&jsonSink{path: path}).Write(context.Background(), r)
+}
+
+// Compare lines cur up against base. Latencies regress when they grow
+// by more than tol.Latency and by more than tol.LatencyFloor; the error
+// rate when it grows by more than tol.ErrorRate points; achieved TPS
+// when it drops by more than tol.TPS. Metrics missing from the
+// baseline are listed but can't regress.
+func Compare(base, cur *Result, tol config.Regression) []Delta {
+	tol = tol.WithDefaults()
+	floorMs := float64(tol.LatencyFloor) / 1e6
+
+	var out []Delta
+	latency := func(metric string, b, c float64) {
+		if b == 0 && c == 0 {
+			return
+		}
+		d := Delta{Metric: metric, Unit: "ms", Baseline: b, Current: c, Change: change(b, c)}
+		d.Regressed = b > 0 && d.Change > tol.Latency && c-b > floorMs
+		out = append(out, d)
+	}
+
+	latency("avg latency", base.AvgLatency, cur.AvgLatency)
+	latency("p95 latency", base.P95Raw, cur.P95Raw)
+	latency("p99 latency", base.P99Raw, cur.P99Raw)
+	latency("p95 corrected", base.P95Corr, cur.P95Corr)
+	latency("p99 corrected", base.P99Corr, cur.P99Corr)
+	latency("p95 ttfb", base.TTFBP95, cur.TTFBP95)
+	latency("p99 ttfb", base.TTFBP99, cur.TTFBP99)
+
+	// Per target, so a slow endpoint hidden by a fast high-volume one
+	// still trips the gate.
+	if len(cur.Targets) > 1 {
+		baseTargets := make(map[string][2]float64, len(base.Targets))
+		for _, t := range base.Targets {
+			baseTargets[t.Target] = [2]float64{t.P95Ms, t.P99Ms}
+		}
+		for _, t := range cur.Targets {
+			b := baseTargets[t.Target]
+			latency("p95 "+t.Target, b[0], t.P95Ms)
+			latency("p99 "+t.Target, b[1], t.P99Ms)
+		}
+	}
+
+	errDelta := Delta{Metric: "error rate", Unit: "%", Baseline: base.ErrorRate, Current: cur.ErrorRate,
+		Change: change(base.ErrorRate, cur.ErrorRate)}
+	errDelta.Regressed = cur.ErrorRate-base.ErrorRate > tol.ErrorRate
+	out = append(out, errDelta)
+
+	tps := Delta{Metric: "achieved TPS", Unit: "tps", Baseline: base.AchievedTPS, Current: cur.AchievedTPS,
+		Change: change(base.AchievedTPS, cur.AchievedTPS)}
+	tps.Regressed = base.AchievedTPS > 0 && -tps.Change > tol.TPS
+	out = append(out, tps)
+	return out
+}
+
+// ConfigMismatch explains how cur's config differs from base's, or
+// returns nil when their fingerprints match or either result predates
+// fingerprints (#1219). Deltas between such runs measure the change of
+// settings as much as any change in the target.
+func ConfigMismatch(base, cur *Result) []string {
+	b, c := base.Config, cur.Config
+	if b == nil || c == nil || b.Hash == c.Hash {
+		return nil
+	}
+	out := []string{fmt.Sprintf("config fingerprint %s → %s", b.Hash, c.Hash)}
+	if b.BaseTPS != c.BaseTPS || b.MaxTPS != c.MaxTPS {
+		out = append(out, fmt.Sprintf("TPS base/max %g/%g → %g/%g", b.BaseTPS, b.MaxTPS, c.BaseTPS, c.MaxTPS))
+	}
+	if b.Pattern != c.Pattern {
+		out = append(out, fmt.Sprintf("pattern %q → %q", b.Pattern, c.Pattern))
+	}
+	had := make(map[string]bool, len(b.Targets))
+	for _, t := range b.Targets {
+		had[t] = true
+	}
+	for _, t := range c.Targets {
+		if !had[t] {
+			out = append(out, "target added or changed: "+t)
+		}
+		delete(had, t)
+	}
+	for _, t := range b.Targets {
+		if had[t] {
+			out = append(out, "target removed or changed: "+t)
+		}
+	}
+	return out
+}
+
+// Regressions counts the regressed deltas.
+func Regressions(ds []Delta) int {
+	n := 0
+	for _, d := range ds {
+		if d.Regressed {
+			n++
+		}
+	}
+	return n
+}
+
+func change(base, cur float64) float64 {
+	if base == 0 {
+		return 0
+	}
+	return (cur - base) / base
+}
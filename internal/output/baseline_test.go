@@ -0,0 +1,100 @@
+package output
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/worker"
+)
+
+func TestCompare_FlagsRegressionsBeyondTolerance(t *testing.T) {
+	base := &Result{AvgLatency: 10, P95Raw: 50, P99Raw: 0.4, ErrorRate: 0.5, AchievedTPS: 100}
+	cur := &Result{AvgLatency: 10.5, P95Raw: 60, P99Raw: 0.6, ErrorRate: 2, AchievedTPS: 85}
+	deltas := Compare(base, cur, config.Regression{})
+
+	got := make(map[string]Delta)
+	for _, d := range deltas {
+		got[d.Metric] = d
+	}
+	cases := map[string]bool{
+		"avg latency":  false, // +5%, inside 10%
+		"p95 latency":  true,  // +20%
+		"p99 latency":  false, // +50%, but only 0.2ms: below the 1ms floor
+		"error rate":   true,  // +1.5 points
+		"achieved TPS": true,  // -15%
+	}
+	for metric, want := range cases {
+		d, ok := got[metric]
+		if !ok {
+			t.Fatalf("%s missing from %+v", metric, deltas)
+		}
+		if d.Regressed != want {
+			t.Errorf("%s regressed = %v, want %v (%+v)", metric, d.Regressed, want, d)
+		}
+	}
+	if _, ok := got["p95 ttfb"]; ok {
+		t.Fatal("metrics absent from both runs should be skipped")
+	}
+	if n := Regressions(deltas); n != 3 {
+		t.Fatalf("Regressions = %d, want 3", n)
+	}
+
+	loose := config.Regression{Latency: 0.5, ErrorRate: 2, TPS: 0.2}
+	if n := Regressions(Compare(base, cur, loose)); n != 0 {
+		t.Fatalf("Regressions with loose tolerance = %d, want 0", n)
+	}
+}
+
+func TestCompare_PerTargetAndNewMetrics(t *testing.T) {
+	base := &Result{AchievedTPS: 10, Targets: []worker.TargetLatency{{Target: "api", P95Ms: 10, P99Ms: 20}}}
+	cur := &Result{AchievedTPS: 10, TTFBP95: 5, Targets: []worker.TargetLatency{
+		{Target: "api", P95Ms: 10, P99Ms: 20},
+		{Target: "auth", P95Ms: 500, P99Ms: 900}, // not in the baseline
+	}}
+	deltas := Compare(base, cur, config.Regression{})
+	if n := Regressions(deltas); n != 0 {
+		t.Fatalf("new metrics must not regress, got %+v", deltas)
+	}
+	cur.Targets[0].P99Ms = 40
+	if n := Regressions(Compare(base, cur, config.Regression{})); n != 1 {
+		t.Fatalf("slow target should regress, got %d", n)
+	}
+}
+
+func TestBaseline_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	r := testResult()
+	r.P95Raw = 12.5
+	if err := WriteBaseline(path, r); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.P95Raw != 12.5 || got.Requests != 600 || !got.Started.Equal(r.Started) {
+		t.Fatalf("round trip = %+v", got)
+	}
+	if _, err := LoadBaseline(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("missing baseline should fail")
+	}
+}
+
+func TestConfigMismatch(t *testing.T) {
+	fp := func(hash string, base float64, targets ...string) *config.RunFingerprint {
+		return &config.RunFingerprint{Hash: hash, BaseTPS: base, MaxTPS: 1000, Pattern: "flat", Targets: targets}
+	}
+	same := &Result{Config: fp("aa", 100, "api GET http://x")}
+	if got := ConfigMismatch(same, &Result{Config: fp("aa", 100, "api GET http://x")}); got != nil {
+		t.Fatalf("matching fingerprints reported %v", got)
+	}
+	if got := ConfigMismatch(&Result{}, same); got != nil {
+		t.Fatalf("baseline without a fingerprint reported %v", got)
+	}
+
+	got := ConfigMismatch(same, &Result{Config: fp("bb", 200, "api GET http://x", "auth POST http://y")})
+	if len(got) != 3 {
+		t.Fatalf("want hash, tps and target lines, got %v", got)
+	}
+}
@@ -0,0 +1,66 @@
+package output
+
+import (
+	"context"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/report"
+)
+
+// htmlSink renders the run through report.WriteHTML, the renderer
+// `kar start` uses too (#1260), so both reports read the same.
+type htmlSink struct{ path string }
+
+func (s *htmlSink) Name() string { return "html:" + s.path }
+
+func (s *htmlSink) Write(_ context.Context, r *Result) error {
+	return report.WriteHTML(r.Report(), s.path)
+}
+
+// Report lays the run out as a report.ReportData: the figures the TUI's
+// report shows, with r itself as the run-only part. Its latency table
+// is raw, as in the TUI; the corrected P95 and P99 stay on the cards.
+func (r *Result) Report() report.ReportData {
+	d := report.ReportData{
+		TotalRequests: r.Requests,
+		TotalErrors:   r.Errors,
+		AvgTPS:        r.AchievedTPS,
+		MinLatency:    r.MinLatency,
+		MaxLatency:    r.MaxLatency,
+		AvgLatency:    r.AvgLatency,
+		P50Latency:    r.P50Raw,
+		P95Latency:    r.P95Raw,
+		P99Latency:    r.P99Raw,
+		Interval:      r.SlotInterval,
+		Latency:       r.Latency,
+		LatencyDist:   r.LatencyDist,
+		StatusCodes:   r.StatusCodes,
+		Confidence:    r.LowConfidence,
+		Run:           r,
+	}
+	if !r.Started.IsZero() {
+		d.TotalDuration = r.Ended.Sub(r.Started)
+	}
+	if r.Requests > 0 {
+		d.SuccessRate = 100 - r.ErrorRate
+	}
+	if r.Metadata != nil {
+		d.Metadata = *r.Metadata
+	} else {
+		d.Metadata = config.RunMetadata{Started: r.Started, Ended: r.Ended}
+	}
+	for _, s := range r.Slots {
+		slot := report.TimeSlot{
+			Time:       s.Start,
+			Requests:   s.Requests,
+			Errors:     s.Errors,
+			AvgLatency: s.AvgLatencyMs,
+		}
+		if r.SlotInterval > 0 {
+			slot.TPS = float64(s.Requests) / r.SlotInterval.Seconds()
+		}
+		d.PeakTPS = max(d.PeakTPS, slot.TPS)
+		d.TimeSlots = append(d.TimeSlots, slot)
+	}
+	return d
+}
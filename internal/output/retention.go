@@ -0,0 +1,84 @@
+package output
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+// Artifact is one timestamped file a run left behind (#1224).
+type Artifact struct {
+	Path string
+	Time time.Time
+}
+
+// FindArtifacts lists the files matching pattern, a path with one
+// "{time}" placeholder in its file name, newest first. Only files whose name carries a
+// valid timestamp where {time} stands match, so a pattern can't pick
+// up anything kar didn't write.
+func FindArtifacts(pattern string) ([]Artifact, error) {
+	dir, file := filepath.Split(pattern)
+	prefix, suffix, ok := strings.Cut(file, "{time}")
+	if !ok || strings.Contains(dir, "{time}") {
+		return nil, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, prefix+"*"+suffix))
+	if err != nil {
+		return nil, err
+	}
+	var out []Artifact
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), prefix), suffix)
+		t, err := time.ParseInLocation(config.TimeLayout, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		out = append(out, Artifact{Path: m, Time: t})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.After(out[j].Time) })
+	return out, nil
+}
+
+// Expired returns the artifacts r doesn't keep: all but the newest
+// r.Keep(), and any older than r.MaxAge. arts must be newest first.
+func Expired(arts []Artifact, r config.Retention, now time.Time) []Artifact {
+	var out []Artifact
+	for i, a := range arts {
+		tooMany := r.Keep() > 0 && i >= r.Keep()
+		tooOld := r.MaxAge > 0 && now.Sub(a.Time) > r.MaxAge
+		if tooMany || tooOld {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Prune removes the expired artifacts of every pattern and returns the
+// paths it removed. With dryRun it only reports them. Files that are
+// already gone are not an error.
+func Prune(patterns []string, r config.Retention, now time.Time, dryRun bool) ([]string, error) {
+	var removed []string
+	var errs []error
+	for _, p := range patterns {
+		arts, err := FindArtifacts(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, a := range Expired(arts, r, now) {
+			if !dryRun {
+				if err := os.Remove(a.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+					errs = append(errs, err)
+					continue
+				}
+			}
+			removed = append(removed, a.Path)
+		}
+	}
+	return removed, errors.Join(errs...)
+}
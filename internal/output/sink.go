@@ -0,0 +1,498 @@
+// Package output fans a finished run's results out to the sinks listed
+// under `output:` in the config (#1187). Each exporter implements
+// ResultSink; the daemon builds one Result at shutdown and hands it to
+// every sink.
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/controller"
+	"github.com/kar98k/internal/health"
+	"github.com/kar98k/internal/pattern"
+	"github.com/kar98k/internal/report"
+	"github.com/kar98k/internal/worker"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Result is everything a sink can export about one run. Latencies are
+// in milliseconds.
+type Result struct {
+	Started     time.Time `json:"started"`
+	Ended       time.Time `json:"ended"`
+	Duration    string    `json:"duration"`
+	Requests    int64     `json:"requests"`
+	Errors      int64     `json:"errors"`
+	ErrorRate   float64   `json:"error_rate"` // percentage
+	AchievedTPS float64   `json:"achieved_tps"`
+	AvgLatency  float64   `json:"avg_latency_ms"`
+	P95Raw      float64   `json:"latency_p95_raw_ms"`
+	P99Raw      float64   `json:"latency_p99_raw_ms"`
+	P95Corr     float64   `json:"latency_p95_corrected_ms"`
+	P99Corr     float64   `json:"latency_p99_corrected_ms"`
+	// P95TargetAvg and P99TargetAvg average each target's own raw
+	// percentile, every target weighted equally; P95Raw and P99Raw pool
+	// all requests, so the busiest target dominates them (#1177).
+	P95TargetAvg float64 `json:"latency_p95_target_avg_ms,omitempty"`
+	P99TargetAvg float64 `json:"latency_p99_target_avg_ms,omitempty"`
+	// MinLatency, MaxLatency and P50Raw complete the report's latency
+	// table (#1260).
+	MinLatency float64 `json:"min_latency_ms,omitempty"`
+	MaxLatency float64 `json:"max_latency_ms,omitempty"`
+	P50Raw     float64 `json:"latency_p50_raw_ms,omitempty"`
+	TTFBP95    float64 `json:"ttfb_p95_ms,omitempty"`
+	TTFBP99    float64 `json:"ttfb_p99_ms,omitempty"`
+	// LowConfidence notes the percentiles above computed from too few
+	// samples to trust (#1240). The values are kept; the printed
+	// report leaves out the Omitted ones.
+	LowConfidence []config.PercentileNote `json:"low_confidence,omitempty"`
+	// Partial marks a snapshot taken while the run was still going
+	// (#1205).
+	Partial bool `json:"partial,omitempty"`
+	// Config fingerprints the settings the run used (#1219), so it is
+	// only compared against runs that used the same ones.
+	Config *config.RunFingerprint `json:"config,omitempty"`
+	// Metadata names the kar build, host and tags behind the run
+	// (#1236).
+	Metadata *config.RunMetadata `json:"metadata,omitempty"`
+
+	Targets []worker.TargetLatency    `json:"target_latency,omitempty"`
+	Specs   []worker.SpecStat         `json:"spec_stats,omitempty"`
+	Intent  []pattern.IntentDeviation `json:"intent_deviations,omitempty"`
+	// Segments split the run into fixed windows (#1189).
+	Segments []Segment `json:"segments,omitempty"`
+	// Stages are the scenario phases, each judged against its own SLO
+	// (#1262).
+	Stages []Stage `json:"stages,omitempty"`
+	// Slowest lists the slowest individual requests (#1191).
+	Slowest []worker.SlowRequest `json:"slowest,omitempty"`
+	// SizeLatency is each target's latency by payload size, when
+	// report.size_latency is on (#1258).
+	SizeLatency []worker.SizeLatencyStat `json:"size_latency,omitempty"`
+	// GCImpact estimates latency kar's own GC added (#1196).
+	GCImpact *worker.GCImpact `json:"gc_impact,omitempty"`
+	// Cache splits cache_bust targets into hits and misses (#1206).
+	Cache []worker.CacheStat `json:"cache,omitempty"`
+	// LongPoll is the long_poll targets' polls and hold times (#1218).
+	LongPoll []worker.LongPollStat `json:"long_poll,omitempty"`
+	// HTTP2 is the streams of http2 targets with an http2 block at the
+	// end of the run (#1248).
+	HTTP2 []worker.HTTP2Stat `json:"http2,omitempty"`
+	// HeaderTracks is each track_header target's header over the run;
+	// its per-second values are on the jsonl timeline.
+	HeaderTracks []worker.HeaderTrack `json:"header_tracks,omitempty"`
+	// Duplicates is each duplicates target's check (#1256).
+	Duplicates []worker.DuplicateStat `json:"duplicates,omitempty"`
+	// TLS is the run's full and resumed TLS handshakes (#1257),
+	// warmup included.
+	TLS *worker.TLSStat `json:"tls,omitempty"`
+	// Cliffs are the latency cliffs safety.cliff detected (#1252).
+	Cliffs []controller.CliffEvent `json:"cliffs,omitempty"`
+	// Fidelity is how much of the requested TPS the run delivered
+	// (#1221).
+	Fidelity *controller.Fidelity `json:"fidelity,omitempty"`
+	// SaturatedSeconds is how long every worker was busy with the
+	// queue full (#1222).
+	SaturatedSeconds int64 `json:"saturated_seconds,omitempty"`
+	// Warmup describes the requests left out of the latency figures
+	// above (#1207).
+	Warmup *worker.WarmupStats `json:"warmup,omitempty"`
+	// Annotations are operator notes added during the run (#1212).
+	Annotations []Annotation `json:"annotations,omitempty"`
+	// Spikes is the run's realized spike schedule (#1228); `kar run
+	// --spike-schedule` replays it from this file.
+	Spikes []pattern.SpikeEvent `json:"spikes,omitempty"`
+	// Seed is the pattern's master seed (#1255); setting it as
+	// pattern.seed reproduces the run's traffic shape.
+	Seed int64 `json:"seed,omitempty"`
+	// ErrorMatrix is the failed requests per target and status or error
+	// class (#1229).
+	ErrorMatrix *ErrorMatrix `json:"error_matrix,omitempty"`
+	// PostChecks are the post_checks run after the load (#1241).
+	PostChecks []health.PostCheckResult `json:"post_checks,omitempty"`
+	// StatusCodes counts responses by HTTP status code (#1260).
+	StatusCodes map[int]int64 `json:"status_codes,omitempty"`
+	// LatencyDist is the report's latency histogram, one bucket per
+	// report.LatencyBuckets entry (#1260).
+	LatencyDist []report.LatencyBucket `json:"latency_distribution,omitempty"`
+	// Slots is the html report's timeline, one slot per SlotInterval
+	// (#1260); like Timeline, it is left out of the JSON summary.
+	Slots        []worker.TimeSlot `json:"-"`
+	SlotInterval time.Duration     `json:"-"`
+	// Latency is how the html sink prints latencies (report.latency,
+	// #1213). The JSON always carries milliseconds.
+	Latency config.LatencyFormat `json:"-"`
+	// Timeline is the target/achieved TPS trace, one row per second or
+	// per report.interval. Only the jsonl sink writes it; it is left
+	// out of the JSON summary.
+	Timeline []pattern.IntentSample `json:"-"`
+	// Samples are the raw requests the samples sink exports (#1260),
+	// in completion order; likewise left out of the JSON summary.
+	Samples []worker.LatencySample `json:"-"`
+	// SampleStats says how many requests Samples holds and how many it
+	// was drawn from; nil without a samples sink.
+	SampleStats *SampleStats `json:"samples,omitempty"`
+}
+
+// SampleStats summarises the samples sink's export. Seen exceeds Kept
+// once max_samples thinned the requests sample_rate let through.
+type SampleStats struct {
+	Kept int   `json:"kept"`
+	Seen int64 `json:"seen"`
+}
+
+// Omitted reports whether percentile p ("p95") had too few samples to
+// be printed.
+func (r *Result) Omitted(p string) bool {
+	for _, n := range r.LowConfidence {
+		if n.Percentile == p {
+			return n.Omitted
+		}
+	}
+	return false
+}
+
+// Annotation is a timestamped note on the run, e.g. a deploy that
+// happened while it was going.
+type Annotation struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// Segment is one report window plus the SLO thresholds it breached.
+type Segment struct {
+	worker.Segment
+	Breaches []string `json:"breaches,omitempty"`
+}
+
+// Segments pairs each window with its SLO breaches.
+func Segments(segs []worker.Segment, slo config.SLO) []Segment {
+	out := make([]Segment, len(segs))
+	for i, s := range segs {
+		out[i] = Segment{Segment: s, Breaches: slo.Breaches(s.P95Ms, s.P99Ms, s.ErrorRate)}
+	}
+	return out
+}
+
+// Stage is one scenario phase's figures and its SLO verdict.
+// Breaches names what failed: "p95", "p99", "error_rate", or "no
+// requests" / "not run" for a phase with an SLO but nothing to judge.
+type Stage struct {
+	worker.StageStat
+	SLO      *config.SLO `json:"slo,omitempty"`
+	Breaches []string    `json:"breaches,omitempty"`
+	Passed   bool        `json:"passed"`
+}
+
+// Stages judges each configured phase by its stats, in config order.
+// A phase without an SLO passes; a phase the run never reached is
+// listed with no requests.
+func Stages(stats []worker.StageStat, scenarios []config.Scenario) []Stage {
+	byName := make(map[string]worker.StageStat, len(stats))
+	for _, st := range stats {
+		byName[st.Name] = st
+	}
+	out := make([]Stage, len(scenarios))
+	for i, sc := range scenarios {
+		st, ran := byName[sc.Name]
+		if !ran {
+			st.Name = sc.Name
+		}
+		s := Stage{StageStat: st, SLO: sc.SLO}
+		switch {
+		case sc.SLO == nil:
+		case !ran:
+			s.Breaches = []string{"not run"}
+		case st.Requests == 0:
+			s.Breaches = []string{"no requests"}
+		default:
+			s.Breaches = sc.SLO.Breaches(st.P95Ms, st.P99Ms, st.ErrorRate)
+		}
+		s.Passed = len(s.Breaches) == 0
+		out[i] = s
+	}
+	return out
+}
+
+// ErrorMatrix counts failed requests with one row per target and one
+// column per status or error class.
+type ErrorMatrix struct {
+	Classes []string   `json:"classes"`
+	Rows    []ErrorRow `json:"rows"`
+}
+
+// ErrorRow is one target's failed requests by class.
+type ErrorRow struct {
+	Target string           `json:"target"`
+	Errors int64            `json:"errors"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+// NewErrorMatrix lays counts, sorted by target as Pool.ErrorCounts
+// returns them, out as a matrix, grouped per
+// report.error_matrix: by default HTTP statuses fold into their class
+// ("503" into "5xx"); config.ErrorMatrixStatus keeps each code. Nil
+// when nothing failed.
+func NewErrorMatrix(counts []worker.ErrorCount, grouping string) *ErrorMatrix {
+	if len(counts) == 0 {
+		return nil
+	}
+	m := &ErrorMatrix{}
+	seen := make(map[string]bool)
+	for _, c := range counts {
+		class := c.Class
+		if grouping != config.ErrorMatrixStatus {
+			if code, err := strconv.Atoi(class); err == nil {
+				class = fmt.Sprintf("%dxx", code/100)
+			}
+		}
+		if !seen[class] {
+			seen[class] = true
+			m.Classes = append(m.Classes, class)
+		}
+		if n := len(m.Rows); n == 0 || m.Rows[n-1].Target != c.Target {
+			m.Rows = append(m.Rows, ErrorRow{Target: c.Target, Counts: make(map[string]int64)})
+		}
+		row := &m.Rows[len(m.Rows)-1]
+		row.Errors += c.Count
+		row.Counts[class] += c.Count
+	}
+	sort.Strings(m.Classes)
+	return m
+}
+
+// AggregateTimeline averages per-second samples into interval-wide
+// rows (report.interval, #1199). Each row is stamped with its window
+// start and counts as spiking if any second in it was. Intervals of a
+// second or less return samples unchanged.
+func AggregateTimeline(samples []pattern.IntentSample, interval time.Duration) []pattern.IntentSample {
+	if interval <= time.Second || len(samples) == 0 {
+		return samples
+	}
+	origin := samples[0].Time
+	var out []pattern.IntentSample
+	var n int
+	for _, s := range samples {
+		start := origin.Add(s.Time.Sub(origin) / interval * interval)
+		if len(out) == 0 || !out[len(out)-1].Time.Equal(start) {
+			if n > 0 {
+				last := &out[len(out)-1]
+				last.TargetTPS /= float64(n)
+				last.AchievedTPS /= float64(n)
+			}
+			out = append(out, pattern.IntentSample{Time: start})
+			n = 0
+		}
+		row := &out[len(out)-1]
+		row.TargetTPS += s.TargetTPS
+		row.AchievedTPS += s.AchievedTPS
+		row.Spiking = row.Spiking || s.Spiking
+		if s.Headers != nil {
+			row.Headers = s.Headers // latest in the window
+		}
+		row.Manual = row.Manual || s.Manual
+		n++
+	}
+	last := &out[len(out)-1]
+	last.TargetTPS /= float64(n)
+	last.AchievedTPS /= float64(n)
+	return out
+}
+
+// ResultSink is one destination for a finished run.
+type ResultSink interface {
+	// Name identifies the sink in logs, e.g. "json:run.json".
+	Name() string
+	Write(ctx context.Context, r *Result) error
+}
+
+// New builds the sink for one config entry. g supplies the metric
+// families for the prometheus sink.
+func New(cfg config.OutputSink, g prometheus.Gatherer) (ResultSink, error) {
+	switch cfg.Type {
+	case config.OutputJSON:
+		return &jsonSink{path: cfg.Path}, nil
+	case config.OutputHTML:
+		return &htmlSink{path: cfg.Path}, nil
+	case config.OutputJSONL:
+		maxSize, err := cfg.MaxBytes()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonlSink{path: cfg.Path, maxSize: maxSize}, nil
+	case config.OutputSamples:
+		maxSize, err := cfg.MaxBytes()
+		if err != nil {
+			return nil, err
+		}
+		return &samplesSink{path: cfg.Path, maxSize: maxSize}, nil
+	case config.OutputPrometheus:
+		return &promSink{path: cfg.Path, endpoint: cfg.Endpoint, gatherer: g}, nil
+	}
+	return nil, fmt.Errorf("unknown output type %q", cfg.Type)
+}
+
+// Build returns one sink per config entry, failing on the first
+// invalid one so a typo surfaces before the run rather than after it.
+func Build(cfgs []config.OutputSink, g prometheus.Gatherer) ([]ResultSink, error) {
+	sinks := make([]ResultSink, 0, len(cfgs))
+	for i, c := range cfgs {
+		s, err := New(c, g)
+		if err != nil {
+			return nil, fmt.Errorf("output[%d]: %w", i, err)
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+type jsonSink struct{ path string }
+
+func (s *jsonSink) Name() string { return "json:" + s.path }
+
+func (s *jsonSink) Write(_ context.Context, r *Result) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, append(data, '\n'), 0644)
+}
+
+type jsonlSink struct {
+	path    string
+	maxSize int64
+}
+
+func (s *jsonlSink) Name() string { return "jsonl:" + s.path }
+
+func (s *jsonlSink) Write(_ context.Context, r *Result) error {
+	w, err := newRollingWriter(s.path, s.maxSize, nil)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for _, sample := range r.Timeline {
+		if err := enc.Encode(sample); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// samplesHeader names the samples sink's CSV columns. Latencies are
+// milliseconds, as in the JSON summary.
+const samplesHeader = "time,target,spec,status,success,warmup,latency_ms,ttfb_ms,bytes_in,bytes_out\n"
+
+// samplesSink writes the raw requests as CSV (#1260), one row per
+// request, through the same rolling, optionally gzipped writer as the
+// jsonl trace. Each file starts with the header, so every part loads
+// on its own.
+type samplesSink struct {
+	path    string
+	maxSize int64
+}
+
+func (s *samplesSink) Name() string { return "samples:" + s.path }
+
+func (s *samplesSink) Write(_ context.Context, r *Result) error {
+	w, err := newRollingWriter(s.path, s.maxSize, []byte(samplesHeader))
+	if err != nil {
+		return err
+	}
+	var row bytes.Buffer
+	cw := csv.NewWriter(&row)
+	ms := func(d time.Duration) string { return strconv.FormatFloat(float64(d)/1e6, 'f', 3, 64) }
+	for _, sm := range r.Samples {
+		row.Reset()
+		cw.Write([]string{
+			sm.Time.UTC().Format(time.RFC3339Nano),
+			sm.Target,
+			sm.Spec,
+			strconv.Itoa(sm.Status),
+			strconv.FormatBool(sm.Success),
+			strconv.FormatBool(sm.Warmup),
+			ms(sm.Duration),
+			ms(sm.TTFB),
+			strconv.FormatInt(sm.BytesIn, 10),
+			strconv.FormatInt(sm.BytesOut, 10),
+		})
+		cw.Flush()
+		// One Write per row, so rolling over never splits one.
+		if _, err := w.Write(row.Bytes()); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// promSink writes the final metric values in the Prometheus text
+// exposition format to a file, pushes them to a Pushgateway, or both.
+type promSink struct {
+	path     string
+	endpoint string
+	gatherer prometheus.Gatherer
+}
+
+func (s *promSink) Name() string {
+	if s.path != "" {
+		return "prometheus:" + s.path
+	}
+	return "prometheus:" + s.endpoint
+}
+
+func (s *promSink) Write(ctx context.Context, _ *Result) error {
+	mfs, err := s.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+	if s.path != "" {
+		if err := os.WriteFile(s.path, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	if s.endpoint != "" {
+		return push(ctx, s.endpoint, buf.Bytes())
+	}
+	return nil
+}
+
+// push PUTs the exposition to the Pushgateway under job="kar98k",
+// replacing whatever the previous run pushed.
+func push(ctx context.Context, endpoint string, body []byte) error {
+	url := strings.TrimSuffix(endpoint, "/") + "/metrics/job/kar98k"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("pushgateway returned %s", resp.Status)
+	}
+	return nil
+}
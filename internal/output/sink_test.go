@@ -0,0 +1,597 @@
+package output
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/pattern"
+	"github.com/kar98k/internal/worker"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func testResult() *Result {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &Result{
+		Started:  start,
+		Ended:    start.Add(time.Minute),
+		Duration: "1m0s",
+		Requests: 600,
+		Errors:   6,
+		Segments: Segments([]worker.Segment{
+			{Start: start, End: start.Add(30 * time.Second), Requests: 300, P99Ms: 40},
+			{Start: start.Add(30 * time.Second), End: start.Add(time.Minute), Requests: 300, Errors: 6, ErrorRate: 2, P99Ms: 900},
+		}, config.SLO{P99Latency: 500 * time.Millisecond}),
+		Timeline: []pattern.IntentSample{
+			{Time: start, TargetTPS: 10, AchievedTPS: 9},
+			{Time: start.Add(time.Second), TargetTPS: 30, AchievedTPS: 28, Spiking: true},
+		},
+	}
+}
+
+func testRegistry(t *testing.T) *prometheus.Registry {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "kar98k_test_total", Help: "test"})
+	c.Add(3)
+	reg.MustRegister(c)
+	return reg
+}
+
+func TestBuild_AllSinksFromOneResult(t *testing.T) {
+	dir := t.TempDir()
+	cfgs := []config.OutputSink{
+		{Type: config.OutputJSON, Path: filepath.Join(dir, "run.json")},
+		{Type: config.OutputHTML, Path: filepath.Join(dir, "run.html")},
+		{Type: config.OutputPrometheus, Path: filepath.Join(dir, "run.prom")},
+		{Type: config.OutputJSONL, Path: filepath.Join(dir, "run.jsonl")},
+	}
+	sinks, err := Build(cfgs, testRegistry(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testResult()
+	for _, s := range sinks {
+		if err := s.Write(context.Background(), r); err != nil {
+			t.Fatalf("%s: %v", s.Name(), err)
+		}
+	}
+
+	var summary Result
+	data, _ := os.ReadFile(cfgs[0].Path)
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("json summary: %v", err)
+	}
+	if summary.Requests != 600 || summary.Errors != 6 || len(summary.Segments) != 2 {
+		t.Fatalf("json summary = %+v", summary)
+	}
+	if strings.Contains(string(data), "target_tps") {
+		t.Fatalf("timeline leaked into the json summary")
+	}
+
+	html, _ := os.ReadFile(cfgs[1].Path)
+	if !strings.Contains(string(html), ">600<") {
+		t.Fatalf("html report missing request count")
+	}
+	if strings.Count(string(html), `class="breach"`) != 1 {
+		t.Fatalf("html report should highlight exactly the one breaching segment")
+	}
+
+	prom, _ := os.ReadFile(cfgs[2].Path)
+	if !strings.Contains(string(prom), "kar98k_test_total 3") {
+		t.Fatalf("prometheus dump = %q", prom)
+	}
+
+	f, _ := os.Open(cfgs[3].Path)
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	var lines int
+	for sc.Scan() {
+		var s pattern.IntentSample
+		if err := json.Unmarshal(sc.Bytes(), &s); err != nil {
+			t.Fatalf("jsonl line %d: %v", lines, err)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Fatalf("jsonl lines = %d, want 2", lines)
+	}
+}
+
+func TestJSONL_CompressesAndRollsOver(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "trace.jsonl.gz")
+	// A leftover from a longer earlier run must not survive.
+	os.WriteFile(path+".9", []byte("stale"), 0644)
+	os.WriteFile(path+".1", []byte("stale"), 0644)
+
+	r := testResult()
+	start := r.Timeline[0].Time
+	r.Timeline = nil
+	for i := range 5000 {
+		r.Timeline = append(r.Timeline, pattern.IntentSample{Time: start.Add(time.Duration(i) * time.Second), TargetTPS: float64(i)})
+	}
+	s, err := New(config.OutputSink{Type: config.OutputJSONL, Path: path, MaxSize: "8KB"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(path + "*")
+	if len(files) < 3 {
+		t.Fatalf("trace should have rolled over more than once: %v", files)
+	}
+	var next float64
+	for i := 0; ; i++ {
+		name := path
+		if i > 0 {
+			name = fmt.Sprintf("%s.%d", path, i)
+		}
+		f, err := os.Open(name)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		sc := bufio.NewScanner(zr)
+		for sc.Scan() {
+			var sample pattern.IntentSample
+			if err := json.Unmarshal(sc.Bytes(), &sample); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if sample.TargetTPS != next {
+				t.Fatalf("%s: sample %v out of order, want %v", name, sample.TargetTPS, next)
+			}
+			next++
+		}
+		f.Close()
+	}
+	if next != 5000 {
+		t.Fatalf("read %v samples back, want 5000", next)
+	}
+	if _, err := os.Stat(path + ".9"); !os.IsNotExist(err) {
+		t.Fatalf("stale rolled-over file kept")
+	}
+}
+
+func TestSamples_WritesCSVWithHeaderPerFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "samples.csv")
+	r := testResult()
+	for i := range 2000 {
+		r.Samples = append(r.Samples, worker.LatencySample{
+			Time: r.Started.Add(time.Duration(i) * time.Millisecond), Target: "api,v2", Status: 200, Success: true,
+			Duration: time.Duration(i) * time.Microsecond, BytesIn: 512,
+		})
+	}
+	s, err := New(config.OutputSink{Type: config.OutputSamples, Path: path, MaxSize: "16KB"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows int
+	for i := 0; ; i++ {
+		name := path
+		if i > 0 {
+			name = fmt.Sprintf("%s.%d", path, i)
+		}
+		f, err := os.Open(name)
+		if os.IsNotExist(err) {
+			if i < 2 {
+				t.Fatalf("samples should have rolled over, stopped at %s", name)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs, err := csv.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if strings.Join(recs[0], ",")+"\n" != samplesHeader {
+			t.Fatalf("%s starts with %v, want the header", name, recs[0])
+		}
+		for _, rec := range recs[1:] {
+			if want := strconv.FormatFloat(float64(rows)/1000, 'f', 3, 64); rec[1] != "api,v2" || rec[6] != want {
+				t.Fatalf("%s: row %d = %v, want latency %s", name, rows, rec, want)
+			}
+			rows++
+		}
+	}
+	if rows != 2000 {
+		t.Fatalf("read %d rows back, want 2000", rows)
+	}
+}
+
+func TestHTML_FlagsTargetsOverExpectedLatency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.html")
+	sinks, err := Build([]config.OutputSink{{Type: config.OutputHTML, Path: path}}, testRegistry(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testResult()
+	r.Segments = nil
+	r.Targets = []worker.TargetLatency{
+		{Target: "api", Samples: 300, P95Ms: 120, P99Ms: 200, ExpectedMs: 100},
+		{Target: "static", Samples: 300, P95Ms: 5, P99Ms: 9},
+	}
+	if err := sinks[0].Write(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	html, _ := os.ReadFile(path)
+	if strings.Count(string(html), `class="breach"`) != 1 {
+		t.Fatalf("html report should flag only the target over its expected latency")
+	}
+	if !strings.Contains(string(html), "100.00ms") {
+		t.Fatalf("html report missing the expected latency column")
+	}
+}
+
+func TestHTML_ShowsPooledAndPerTargetPercentiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.html")
+	sinks, err := Build([]config.OutputSink{{Type: config.OutputHTML, Path: path}}, testRegistry(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testResult()
+	r.Targets = []worker.TargetLatency{
+		{Target: "api", Samples: 990, P95Ms: 10, P99Ms: 20},
+		{Target: "slow", Samples: 10, P95Ms: 400, P99Ms: 600},
+	}
+	r.P95Raw, r.P99Raw = 11, 25
+	r.P95TargetAvg, r.P99TargetAvg = 205, 310
+	if err := sinks[0].Write(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	html, _ := os.ReadFile(path)
+	for _, want := range []string{
+		"<td>Pooled</td><td>11.00ms</td><td>25.00ms</td>",
+		"<td>Per-target average</td><td>205.00ms</td><td>310.00ms</td>",
+	} {
+		if !strings.Contains(string(html), want) {
+			t.Fatalf("html report missing %q", want)
+		}
+	}
+
+	data, _ := json.Marshal(r)
+	if !strings.Contains(string(data), `"latency_p95_target_avg_ms":205`) || !strings.Contains(string(data), `"latency_p99_target_avg_ms":310`) {
+		t.Fatalf("json summary missing the per-target averages: %s", data)
+	}
+}
+
+func TestHTML_PrintsLatencyInConfiguredUnit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.html")
+	sinks, err := Build([]config.OutputSink{{Type: config.OutputHTML, Path: path}}, testRegistry(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testResult()
+	r.P95Corr = 0.042
+	if err := sinks[0].Write(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	html, _ := os.ReadFile(path)
+	if !strings.Contains(string(html), "42.00µs") {
+		t.Fatal("auto unit should print a sub-millisecond P95 in µs")
+	}
+
+	r.Latency = config.LatencyFormat{Unit: config.LatencyUnitMillis}
+	if err := sinks[0].Write(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	html, _ = os.ReadFile(path)
+	if !strings.Contains(string(html), "0.04ms") {
+		t.Fatal("ms unit should print the P95 in ms")
+	}
+}
+
+func TestHTML_OmitsPercentilesWithTooFewSamples(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.html")
+	sinks, err := Build([]config.OutputSink{{Type: config.OutputHTML, Path: path}}, testRegistry(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testResult()
+	r.P95Corr, r.P99Corr = 31.5, 77.25
+	r.LowConfidence = config.Report{}.PercentileNotes(50, 95, 99)
+	if err := sinks[0].Write(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	html := string(data)
+	if !strings.Contains(html, "31.50ms") || strings.Contains(html, "77.25ms") {
+		t.Fatal("P95 from 50 samples should print, P99 should not")
+	}
+	if !strings.Contains(html, "p95 is low confidence: 50 samples, 100 needed") || !strings.Contains(html, "p99 left out") {
+		t.Fatal("html report should explain the low-confidence percentiles")
+	}
+}
+
+func TestSegments_FlagsSLOBreaches(t *testing.T) {
+	segs := Segments([]worker.Segment{
+		{P95Ms: 80, P99Ms: 120},
+		{P95Ms: 80, P99Ms: 120, ErrorRate: 3},
+	}, config.SLO{P99Latency: 200 * time.Millisecond, ErrorRate: 1})
+	if len(segs[0].Breaches) != 0 {
+		t.Fatalf("healthy window flagged: %v", segs[0].Breaches)
+	}
+	if len(segs[1].Breaches) != 1 || segs[1].Breaches[0] != "error_rate" {
+		t.Fatalf("breaches = %v, want [error_rate]", segs[1].Breaches)
+	}
+}
+
+func TestStages_JudgesEachPhaseAgainstItsSLO(t *testing.T) {
+	slo := &config.SLO{P95Latency: 100 * time.Millisecond}
+	stages := Stages([]worker.StageStat{
+		{Name: "warmup", Requests: 10, P95Ms: 500},
+		{Name: "steady", Requests: 10, P95Ms: 80},
+		{Name: "spike", Requests: 10, P95Ms: 150},
+	}, []config.Scenario{
+		{Name: "warmup"},
+		{Name: "steady", SLO: slo},
+		{Name: "spike", SLO: slo},
+		{Name: "soak", SLO: slo},
+	})
+	want := []struct {
+		passed   bool
+		breaches string
+	}{{true, ""}, {true, ""}, {false, "p95"}, {false, "not run"}}
+	if len(stages) != len(want) {
+		t.Fatalf("got %d stages, want %d", len(stages), len(want))
+	}
+	for i, w := range want {
+		if got := strings.Join(stages[i].Breaches, ","); stages[i].Passed != w.passed || got != w.breaches {
+			t.Errorf("%s: passed=%v breaches=%q, want %v %q", stages[i].Name, stages[i].Passed, got, w.passed, w.breaches)
+		}
+	}
+}
+
+func TestNewErrorMatrix_GroupsByClassOrStatus(t *testing.T) {
+	counts := []worker.ErrorCount{
+		{Target: "api", Class: "500", Count: 3},
+		{Target: "api", Class: "503", Count: 4},
+		{Target: "api", Class: "timeout", Count: 2},
+		{Target: "rpc", Class: "Unavailable", Count: 5},
+	}
+
+	m := NewErrorMatrix(counts, "")
+	if want := []string{"5xx", "Unavailable", "timeout"}; !reflect.DeepEqual(m.Classes, want) {
+		t.Fatalf("classes = %v, want %v", m.Classes, want)
+	}
+	if len(m.Rows) != 2 || m.Rows[0].Errors != 9 || m.Rows[0].Counts["5xx"] != 7 || m.Rows[1].Counts["Unavailable"] != 5 {
+		t.Fatalf("rows = %+v", m.Rows)
+	}
+
+	m = NewErrorMatrix(counts, config.ErrorMatrixStatus)
+	if m.Rows[0].Counts["500"] != 3 || m.Rows[0].Counts["503"] != 4 {
+		t.Fatalf("status grouping should keep each code, got %+v", m.Rows[0].Counts)
+	}
+
+	if NewErrorMatrix(nil, "") != nil {
+		t.Fatal("a run without errors should have no matrix")
+	}
+
+	path := filepath.Join(t.TempDir(), "run.html")
+	sinks, err := Build([]config.OutputSink{{Type: config.OutputHTML, Path: path}}, testRegistry(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testResult()
+	r.ErrorMatrix = m
+	if err := sinks[0].Write(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	html, _ := os.ReadFile(path)
+	if !strings.Contains(string(html), "<th>503</th>") || !strings.Contains(string(html), "<td>—</td>") {
+		t.Fatal("html report missing the error matrix")
+	}
+}
+
+func TestPrometheusSink_Push(t *testing.T) {
+	var gotPath, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("method = %s, want PUT", r.Method)
+		}
+		gotPath = r.URL.Path
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+	}))
+	defer srv.Close()
+
+	s, err := New(config.OutputSink{Type: config.OutputPrometheus, Endpoint: srv.URL + "/"}, testRegistry(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(context.Background(), testResult()); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/metrics/job/kar98k" {
+		t.Fatalf("push path = %q", gotPath)
+	}
+	if !strings.Contains(gotBody, "kar98k_test_total 3") {
+		t.Fatalf("push body = %q", gotBody)
+	}
+}
+
+func TestPostResult_RetriesAndCarriesVerdict(t *testing.T) {
+	defer func(b time.Duration) { webhookBackoff = b }(webhookBackoff)
+	webhookBackoff = time.Millisecond
+
+	var calls int
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	r := testResult()
+	r.P99Raw = 900
+	v := NewVerdict(r, config.SLO{P99Latency: 500 * time.Millisecond}, []string{"post checks failed: 1 of 1"})
+	if v.Passed || !reflect.DeepEqual(v.SLOBreaches, []string{"p99"}) {
+		t.Fatalf("verdict = %+v", v)
+	}
+	if err := PostResult(context.Background(), srv.URL, r, v); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want a retry after the 502", calls)
+	}
+	verdict, _ := got["verdict"].(map[string]any)
+	if verdict["passed"] != false || got["requests"] != float64(600) {
+		t.Fatalf("payload = %v", got)
+	}
+	if _, ok := got["timeline"]; ok {
+		t.Fatal("payload carries the timeline")
+	}
+}
+
+func TestPostResult_DoesNotRetryClientErrors(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	if err := PostResult(context.Background(), srv.URL, testResult(), Verdict{Passed: true}); err == nil || calls != 1 {
+		t.Fatalf("err = %v after %d calls, want one failed call", err, calls)
+	}
+}
+
+func TestNew_UnknownType(t *testing.T) {
+	if _, err := Build([]config.OutputSink{{Type: "csv", Path: "x"}}, prometheus.NewRegistry()); err == nil {
+		t.Fatal("expected an error for an unknown sink type")
+	}
+}
+
+func TestAggregateTimeline(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var samples []pattern.IntentSample
+	for i := 0; i < 5; i++ {
+		samples = append(samples, pattern.IntentSample{
+			Time:        start.Add(time.Duration(i) * time.Second),
+			TargetTPS:   float64(10 * (i + 1)),
+			AchievedTPS: float64(10 * (i + 1)),
+			Spiking:     i == 1,
+			Headers:     []pattern.HeaderValue{{Target: "api", Header: "Age", Value: strconv.Itoa(i)}},
+		})
+	}
+
+	if got := AggregateTimeline(samples, time.Second); len(got) != 5 {
+		t.Fatalf("1s interval should keep every sample, got %d", len(got))
+	}
+	got := AggregateTimeline(samples, 2*time.Second)
+	if len(got) != 3 {
+		t.Fatalf("got %d rows, want 3: %+v", len(got), got)
+	}
+	if got[0].TargetTPS != 15 || !got[0].Spiking || !got[0].Time.Equal(start) {
+		t.Fatalf("row 0 = %+v, want avg 15, spiking, at start", got[0])
+	}
+	if got[1].TargetTPS != 35 || got[1].Spiking {
+		t.Fatalf("row 1 = %+v, want avg 35, not spiking", got[1])
+	}
+	if got[1].Headers[0].Value != "3" {
+		t.Fatalf("row 1 headers = %+v, want the window's latest Age, 3", got[1].Headers)
+	}
+	if got[2].AchievedTPS != 50 || !got[2].Time.Equal(start.Add(4*time.Second)) {
+		t.Fatalf("row 2 = %+v, want a lone 50 at +4s", got[2])
+	}
+}
+
+func TestPrune_KeepsNewestAndSkipsUnstamped(t *testing.T) {
+	dir := t.TempDir()
+	pattern := filepath.Join(dir, "run-{time}.json")
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	var stamped []string
+	for i := 0; i < 4; i++ {
+		p := config.ExpandTime(pattern, now.Add(-time.Duration(i)*24*time.Hour))
+		stamped = append(stamped, p)
+		if err := os.WriteFile(p, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Same prefix and suffix, but not a timestamp kar wrote.
+	other := filepath.Join(dir, "run-final.json")
+	if err := os.WriteFile(other, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Prune([]string{pattern}, config.Retention{KeepLast: 3}, now, true)
+	if err != nil || len(removed) != 1 || removed[0] != stamped[3] {
+		t.Fatalf("dry run removed %v (err %v), want only the oldest", removed, err)
+	}
+	if _, err := os.Stat(stamped[3]); err != nil {
+		t.Fatal("dry run deleted a file")
+	}
+
+	removed, err = Prune([]string{pattern}, config.Retention{KeepLast: -1, MaxAge: 36 * time.Hour}, now, false)
+	if err != nil || len(removed) != 2 {
+		t.Fatalf("removed %v (err %v), want the two older than 36h", removed, err)
+	}
+	for _, p := range []string{stamped[0], stamped[1], other} {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("%s should be kept: %v", p, err)
+		}
+	}
+}
+
+func TestResult_Report(t *testing.T) {
+	r := testResult()
+	r.ErrorRate = 1
+	r.AchievedTPS = 10
+	r.P95Raw, r.P99Raw = 80, 120
+	r.StatusCodes = map[int]int64{200: 594, 503: 6}
+	r.SlotInterval = 30 * time.Second
+	r.Slots = []worker.TimeSlot{
+		{Start: r.Started, Requests: 300, AvgLatencyMs: 20},
+		{Start: r.Started.Add(30 * time.Second), Requests: 300, Errors: 6, AvgLatencyMs: 60},
+	}
+
+	d := r.Report()
+	if d.TotalRequests != 600 || d.TotalErrors != 6 || d.SuccessRate != 99 || d.TotalDuration != time.Minute {
+		t.Fatalf("totals = %+v", d)
+	}
+	if d.P95Latency != 80 || d.P99Latency != 120 {
+		t.Fatalf("report percentiles should be the raw ones, got %v / %v", d.P95Latency, d.P99Latency)
+	}
+	if len(d.TimeSlots) != 2 || d.TimeSlots[1].TPS != 10 || d.TimeSlots[1].Errors != 6 || d.PeakTPS != 10 {
+		t.Fatalf("slots = %+v, peak %v", d.TimeSlots, d.PeakTPS)
+	}
+	if d.Run != r || d.StatusCodes[503] != 6 {
+		t.Fatalf("report should carry the run and its status codes: %+v", d)
+	}
+}
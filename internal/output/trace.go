@@ -0,0 +1,154 @@
+package output
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// rollingWriter writes the jsonl trace (#1245). A path ending in ".gz"
+// is gzip-compressed as it's written, and with max > 0 the trace rolls
+// over to path.1, path.2, ... once a file holds max bytes on disk, so a
+// long high-TPS run never leaves one unwieldy file.
+//
+// Every Write is taken as one whole record and the size is checked
+// after it, so a record is never split across files. json.Encoder
+// writes each value in a single call. Compressed sizes trail the input
+// by whatever deflate still buffers, so a gzip file can overshoot max
+// by a block.
+type rollingWriter struct {
+	path   string
+	max    int64
+	gzip   bool
+	header []byte
+	index  int
+
+	f   *os.File
+	buf *bufio.Writer
+	n   *countingWriter
+	gz  *gzip.Writer
+	out io.Writer
+}
+
+// header, when set, starts every file, e.g. the samples sink's CSV
+// column names.
+func newRollingWriter(path string, maxSize int64, header []byte) (*rollingWriter, error) {
+	w := &rollingWriter{path: path, max: maxSize, gzip: strings.HasSuffix(path, ".gz"), header: header}
+	// Files an earlier run rolled over to would read as part of this
+	// trace.
+	if err := removeRolled(path); err != nil {
+		return nil, err
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// removeRolled deletes path.1, path.2, ... .
+func removeRolled(path string) error {
+	dir, base := filepath.Split(path)
+	entries, err := os.ReadDir(filepath.Clean(dir + "."))
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		suffix, ok := strings.CutPrefix(e.Name(), base+".")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(suffix); err != nil || n < 1 {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *rollingWriter) name(i int) string {
+	if i == 0 {
+		return w.path
+	}
+	return fmt.Sprintf("%s.%d", w.path, i)
+}
+
+func (w *rollingWriter) open() error {
+	f, err := os.Create(w.name(w.index))
+	if err != nil {
+		return err
+	}
+	w.f = f
+	w.buf = bufio.NewWriter(f)
+	w.n = &countingWriter{w: w.buf}
+	w.out = w.n
+	if w.gzip {
+		w.gz = gzip.NewWriter(w.n)
+		w.out = w.gz
+	}
+	if len(w.header) > 0 {
+		_, err = w.out.Write(w.header)
+	}
+	return err
+}
+
+func (w *rollingWriter) Write(p []byte) (int, error) {
+	if w.f == nil {
+		// Open the next file lazily so a trace that ends exactly at
+		// the limit leaves no empty file behind.
+		w.index++
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.out.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if w.max > 0 && w.n.n >= w.max {
+		if err := w.closeFile(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (w *rollingWriter) closeFile() error {
+	var err error
+	if w.gz != nil {
+		err = w.gz.Close()
+	}
+	if ferr := w.buf.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	w.f, w.gz = nil, nil
+	return err
+}
+
+func (w *rollingWriter) Close() error {
+	if w.f == nil {
+		return nil
+	}
+	return w.closeFile()
+}
+
+// countingWriter counts the bytes passed through to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,114 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+// Bounds on `kar run --result-webhook` (#1254): each attempt gets
+// webhookTimeout, and a failed one is retried after webhookBackoff,
+// doubling, up to webhookAttempts in all.
+const (
+	webhookTimeout  = 10 * time.Second
+	webhookAttempts = 3
+)
+
+var webhookBackoff = time.Second
+
+// Verdict is a run's pass/fail outcome as the result webhook reports
+// it.
+type Verdict struct {
+	Passed bool `json:"passed"`
+	// SLOBreaches are the report.slo thresholds the whole run's
+	// figures exceed, e.g. ["p99"].
+	SLOBreaches []string `json:"slo_breaches,omitempty"`
+	// Failures are the gates the run failed: regression gate, intent
+	// check, post checks.
+	Failures []string `json:"failures,omitempty"`
+}
+
+// NewVerdict judges r against slo, with the same raw percentiles the
+// per-segment breaches use, and the gate failures the caller already
+// found. A run that completed no requests doesn't pass.
+func NewVerdict(r *Result, slo config.SLO, failures []string) Verdict {
+	v := Verdict{Failures: failures}
+	if r == nil || r.Requests == 0 {
+		v.Failures = append(v.Failures, "the run completed no requests")
+	} else {
+		v.SLOBreaches = slo.Breaches(r.P95Raw, r.P99Raw, r.ErrorRate)
+	}
+	v.Passed = len(v.SLOBreaches) == 0 && len(v.Failures) == 0
+	return v
+}
+
+// webhookPayload is the JSON summary, as the json sink writes it, with
+// the verdict alongside.
+type webhookPayload struct {
+	Verdict Verdict `json:"verdict"`
+	*Result
+}
+
+// ValidateWebhookURL checks a --result-webhook URL before the run, so
+// a typo fails in seconds rather than after it.
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", raw)
+	}
+	return nil
+}
+
+// PostResult POSTs r and v as JSON to endpoint. Connection failures,
+// 429s and 5xx responses are retried with backoff; any other non-2xx
+// response is final.
+func PostResult(ctx context.Context, endpoint string, r *Result, v Verdict) error {
+	body, err := json.Marshal(webhookPayload{Verdict: v, Result: r})
+	if err != nil {
+		return err
+	}
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := postOnce(ctx, endpoint, body)
+		if err == nil || !retry || attempt == webhookAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// postOnce makes one attempt and reports whether a failure is worth
+// retrying.
+func postOnce(ctx context.Context, endpoint string, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+			fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return false, nil
+}
@@ -0,0 +1,95 @@
+package pattern
+
+import (
+	"github.com/kar98k/internal/config"
+)
+
+// blendSource is one pattern.blend source (#1247) with its own spike
+// and noise generators.
+type blendSource struct {
+	name    string
+	share   float64
+	poisson *PoissonSpike
+	noise   NoiseGenerator
+}
+
+// BlendShare is one blend source's part of the current rate.
+type BlendShare struct {
+	Name string `json:"name"`
+	// Share is the source's weight over the sum of all weights.
+	Share float64 `json:"share"`
+	// Multiplier is the source's own Poisson × noise multiplier.
+	Multiplier float64 `json:"multiplier"`
+	Spiking    bool    `json:"spiking,omitempty"`
+	// TPS is the source's part of the engine's current TPS; the parts
+	// add up to it.
+	TPS float64 `json:"tps"`
+}
+
+// blendShares normalizes the sources' weights to sum to 1.
+func blendShares(srcs []config.BlendSource) []float64 {
+	var total float64
+	for _, s := range srcs {
+		total += s.Share()
+	}
+	out := make([]float64, len(srcs))
+	for i, s := range srcs {
+		if total > 0 {
+			out[i] = s.Share() / total
+		}
+	}
+	return out
+}
+
+// newBlend builds the sources, each seeded from seed and its name.
+func newBlend(srcs []config.BlendSource, baseTPS float64, seed int64) []*blendSource {
+	if len(srcs) == 0 {
+		return nil
+	}
+	shares := blendShares(srcs)
+	out := make([]*blendSource, len(srcs))
+	for i, s := range srcs {
+		p := NewPoissonSpikeWithSeed(s.Poisson, DeriveSeed(seed, "blend/"+s.Name+"/poisson"))
+		p.SetBaseTPS(baseTPS)
+		out[i] = &blendSource{
+			name:    s.Name,
+			share:   shares[i],
+			poisson: p,
+			noise:   NewNoiseGeneratorWithSeed(s.Noise, DeriveSeed(seed, "blend/"+s.Name+"/noise")),
+		}
+	}
+	return out
+}
+
+// sampleBlend samples every source and returns the share-weighted
+// multiplier with each source's own multiplier. No sources blend to 1.
+func sampleBlend(srcs []*blendSource) (float64, []float64) {
+	if len(srcs) == 0 {
+		return 1, nil
+	}
+	var mult float64
+	parts := make([]float64, len(srcs))
+	for i, s := range srcs {
+		parts[i] = s.poisson.Multiplier() * s.noise.Multiplier()
+		mult += s.share * parts[i]
+	}
+	return mult, parts
+}
+
+// blendStatus splits tps, the engine's rate, across the sources by
+// their share of mult, the blended multiplier that produced it.
+func blendStatus(srcs []*blendSource, parts []float64, mult, tps float64) []BlendShare {
+	out := make([]BlendShare, len(srcs))
+	for i, s := range srcs {
+		out[i] = BlendShare{
+			Name:       s.name,
+			Share:      s.share,
+			Multiplier: parts[i],
+			Spiking:    s.poisson.IsSpiking(),
+		}
+		if mult > 0 {
+			out[i].TPS = tps * s.share * parts[i] / mult
+		}
+	}
+	return out
+}
@@ -1,6 +1,8 @@
 package pattern
 
 import (
+	"cmp"
+	"fmt"
 	"sync"
 	"time"
 
@@ -9,21 +11,186 @@ import (
 
 // Engine combines all traffic pattern generators.
 type Engine struct {
-	poisson *PoissonSpike
-	noise   NoiseGenerator
-	baseTPS float64
-	maxTPS  float64
-	mu      sync.RWMutex
+	poisson  *PoissonSpike
+	square   *Square
+	noise    NoiseGenerator
+	noiseCfg config.Noise
+	baseTPS  float64
+	maxTPS   float64
+	blend    []*blendSource
+	mu       sync.RWMutex
+
+	// seed is the master seed (#1255), fixed at construction. patSeed
+	// is the current pattern's: the master's at first, then each
+	// ReplacePattern derives the next phase's, counting them in
+	// phases. Both guarded by mu.
+	seed    int64
+	patSeed int64
+	phases  int
+
+	// blendMult and blendNow are the blend's multiplier and per-source
+	// split from the latest CalculateTPS, for GetStatus. Guarded by
+	// bMu for the same reason as tnMu.
+	bMu       sync.Mutex
+	blendMult float64
+	blendNow  []BlendShare
+
+	// Per-target noise (#1178). When noise.per_target is set and
+	// SetTargets has been called, each target gets its own generator
+	// and the global noise multiplier becomes their weight-averaged
+	// value. targetNoiseNow caches the latest sample per target so the
+	// job generator can bias selection without re-sampling. Guarded by
+	// tnMu, not mu, because CalculateTPS samples under a read lock.
+	tnMu           sync.Mutex
+	targets        []config.Target
+	targetNoise    map[string]NoiseGenerator
+	targetNoiseNow map[string]float64
 }
 
-// NewEngine creates a new pattern engine.
+// NewEngine creates a new pattern engine, seeded from cfg.Seed or, when
+// that's 0, the clock.
 func NewEngine(cfg config.Pattern, baseTPS, maxTPS float64) *Engine {
+	seed := masterSeed(cfg.Seed)
+	poisson := NewPoissonSpikeWithSeed(cfg.Poisson, DeriveSeed(seed, "poisson"))
+	poisson.SetBaseTPS(baseTPS)
 	return &Engine{
-		poisson: NewPoissonSpike(cfg.Poisson),
-		noise:   NewNoiseGenerator(cfg.Noise),
-		baseTPS: baseTPS,
-		maxTPS:  maxTPS,
+		poisson:   poisson,
+		square:    NewSquare(cfg.Square),
+		noise:     NewNoiseGeneratorWithSeed(cfg.Noise, DeriveSeed(seed, "noise")),
+		noiseCfg:  cfg.Noise,
+		baseTPS:   baseTPS,
+		maxTPS:    maxTPS,
+		blend:     newBlend(cfg.Blend, baseTPS, seed),
+		blendMult: 1,
+		seed:      seed,
+		patSeed:   seed,
+	}
+}
+
+// Seed returns the master seed the engine was built with, for
+// reproducing the run with pattern.seed.
+func (e *Engine) Seed() int64 {
+	return e.seed
+}
+
+// SetTargets tells the engine which targets traffic is spread across.
+// It only matters when noise.per_target is enabled: each target then
+// gets an independently seeded noise generator so their fluctuations
+// decorrelate instead of moving in lockstep.
+func (e *Engine) SetTargets(tgts []config.Target) {
+	e.mu.RLock()
+	cfg, seed := e.noiseCfg, e.patSeed
+	e.mu.RUnlock()
+
+	e.tnMu.Lock()
+	defer e.tnMu.Unlock()
+	e.targets = tgts
+	e.buildTargetNoise(cfg, seed)
+}
+
+// buildTargetNoise (re)creates per-target generators, each seeded from
+// seed and the target's name. Caller holds tnMu.
+func (e *Engine) buildTargetNoise(cfg config.Noise, seed int64) {
+	e.targetNoise = nil
+	e.targetNoiseNow = nil
+	if !cfg.Enabled || !cfg.PerTarget || len(e.targets) == 0 {
+		return
+	}
+
+	e.targetNoise = make(map[string]NoiseGenerator, len(e.targets))
+	e.targetNoiseNow = make(map[string]float64, len(e.targets))
+	for _, t := range e.targets {
+		e.targetNoise[t.Name] = NewNoiseGeneratorWithSeed(cfg, DeriveSeed(seed, "target-noise/"+t.Name))
+		e.targetNoiseNow[t.Name] = 1.0
+	}
+}
+
+// noiseMultiplier samples the noise layer. With per-target noise it
+// advances every target's generator and returns the weight-averaged
+// multiplier, so the total rate equals the sum of per-target rates.
+//
+// Lock order is mu before tnMu (GetStatus holds mu while snapshotting),
+// so the global generator is read before tnMu is taken.
+func (e *Engine) noiseMultiplier() float64 {
+	e.mu.RLock()
+	noise := e.noise
+	e.mu.RUnlock()
+
+	e.tnMu.Lock()
+	defer e.tnMu.Unlock()
+
+	if len(e.targetNoise) == 0 {
+		return noise.Multiplier()
+	}
+	for name, gen := range e.targetNoise {
+		e.targetNoiseNow[name] = gen.Multiplier()
+	}
+	return e.targetNoiseMean()
+}
+
+// targetNoiseMean is the weight-averaged latest per-target multiplier.
+// Caller holds tnMu.
+func (e *Engine) targetNoiseMean() float64 {
+	var sum, weight float64
+	for _, t := range e.targets {
+		m, ok := e.targetNoiseNow[t.Name]
+		if !ok || t.Weight <= 0 {
+			continue
+		}
+		sum += m * float64(t.Weight)
+		weight += float64(t.Weight)
+	}
+	if weight == 0 {
+		return 1.0
+	}
+	return sum / weight
+}
+
+// TargetNoise returns the latest per-target noise multiplier, or 1.0
+// when per-target noise is off or the target is unknown.
+func (e *Engine) TargetNoise(name string) float64 {
+	e.tnMu.Lock()
+	defer e.tnMu.Unlock()
+	if m, ok := e.targetNoiseNow[name]; ok {
+		return m
+	}
+	return 1.0
+}
+
+// TargetNoiseMean returns the weight-averaged latest per-target noise
+// multiplier, or 1.0 when per-target noise is off. Dividing TargetNoise
+// by it gives a target's rate relative to the engine's overall rate.
+func (e *Engine) TargetNoiseMean() float64 {
+	e.tnMu.Lock()
+	defer e.tnMu.Unlock()
+	if len(e.targetNoiseNow) == 0 {
+		return 1.0
+	}
+	return e.targetNoiseMean()
+}
+
+// TargetNoiseCeiling is the largest multiplier any per-target generator
+// can return (1 + amplitude). Callers biasing selection by TargetNoise
+// divide by it to get an acceptance probability in (0, 1].
+func (e *Engine) TargetNoiseCeiling() float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return 1.0 + e.noiseCfg.Amplitude
+}
+
+// targetNoiseSnapshot copies the latest per-target multipliers and
+// their weighted mean. ok is false when per-target noise is off.
+func (e *Engine) targetNoiseSnapshot() (perTarget map[string]float64, mean float64, ok bool) {
+	e.tnMu.Lock()
+	defer e.tnMu.Unlock()
+	if len(e.targetNoiseNow) == 0 {
+		return nil, 1.0, false
+	}
+	out := make(map[string]float64, len(e.targetNoiseNow))
+	for k, v := range e.targetNoiseNow {
+		out[k] = v
 	}
+	return out, e.targetNoiseMean(), true
 }
 
 // CalculateTPS computes the current target TPS based on all pattern generators.
@@ -31,17 +198,26 @@ func (e *Engine) CalculateTPS(scheduleMultiplier float64) float64 {
 	e.mu.RLock()
 	baseTPS := e.baseTPS
 	maxTPS := e.maxTPS
+	blend := e.blend
+	square := e.square
 	e.mu.RUnlock()
 
 	// Start with base TPS and apply schedule multiplier
 	tps := baseTPS * scheduleMultiplier
 
+	// Blend the pattern.blend sources into the base curve
+	blendMult, parts := sampleBlend(blend)
+	tps *= blendMult
+
 	// Apply Poisson spike multiplier
 	poissonMult := e.poisson.Multiplier()
 	tps *= poissonMult
 
+	// Apply the square wave's on/off phase
+	tps *= square.Multiplier()
+
 	// Apply noise multiplier
-	noiseMult := e.noise.Multiplier()
+	noiseMult := e.noiseMultiplier()
 	tps *= noiseMult
 
 	// Clamp to max TPS
@@ -54,6 +230,12 @@ func (e *Engine) CalculateTPS(scheduleMultiplier float64) float64 {
 		tps = 1
 	}
 
+	if len(blend) > 0 {
+		e.bMu.Lock()
+		e.blendMult = blendMult
+		e.blendNow = blendStatus(blend, parts, blendMult, tps)
+		e.bMu.Unlock()
+	}
 	return tps
 }
 
@@ -61,6 +243,10 @@ func (e *Engine) CalculateTPS(scheduleMultiplier float64) float64 {
 func (e *Engine) SetBaseTPS(tps float64) {
 	e.mu.Lock()
 	e.baseTPS = tps
+	e.poisson.SetBaseTPS(tps)
+	for _, b := range e.blend {
+		b.poisson.SetBaseTPS(tps)
+	}
 	e.mu.Unlock()
 }
 
@@ -75,13 +261,98 @@ func (e *Engine) SetMaxTPS(tps float64) {
 // engine keeps running. Used by the scenario runner so multi-stage
 // scripts (warmup → baseline → spike-train → cooldown) can reshape
 // the traffic curve without tearing down the engine.
+//
+// Each call is the next phase, seeded from cfg.Seed, or the engine's
+// master seed when that's 0, and the phase's number. Phases that
+// share a pattern still draw different spike timelines.
 func (e *Engine) ReplacePattern(cfg config.Pattern) {
-	poisson := NewPoissonSpike(cfg.Poisson)
-	noise := NewNoiseGenerator(cfg.Noise)
 	e.mu.Lock()
+	e.phases++
+	seed := DeriveSeed(cmp.Or(cfg.Seed, e.seed), fmt.Sprintf("phase/%d", e.phases))
+	e.patSeed = seed
+	poisson := NewPoissonSpikeWithSeed(cfg.Poisson, DeriveSeed(seed, "poisson"))
+	poisson.SetBaseTPS(e.baseTPS)
 	e.poisson = poisson
-	e.noise = noise
+	e.square = NewSquare(cfg.Square)
+	e.noise = NewNoiseGeneratorWithSeed(cfg.Noise, DeriveSeed(seed, "noise"))
+	e.noiseCfg = cfg.Noise
+	e.blend = newBlend(cfg.Blend, e.baseTPS, seed)
 	e.mu.Unlock()
+
+	e.bMu.Lock()
+	e.blendMult, e.blendNow = 1, nil
+	e.bMu.Unlock()
+
+	e.tnMu.Lock()
+	e.buildTargetNoise(cfg.Noise, seed)
+	e.tnMu.Unlock()
+}
+
+// Start marks the start of the run. The engine is usually built before
+// the trigger; restarting the spike timeline here makes
+// poisson.initial_delay count from the run's start, and the square
+// wave's first on phase begin with it.
+func (e *Engine) Start() {
+	for _, p := range e.spikeGenerators() {
+		p.Start()
+	}
+	e.currentSquare().Start()
+}
+
+func (e *Engine) currentSquare() *Square {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.square
+}
+
+// spikeGenerators returns the top-level Poisson generator followed by
+// each blend source's.
+func (e *Engine) spikeGenerators() []*PoissonSpike {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	out := []*PoissonSpike{e.poisson}
+	for _, b := range e.blend {
+		out = append(out, b.poisson)
+	}
+	return out
+}
+
+// ReplaySpikes replaces the Poisson draw with a recorded spike
+// schedule (#1228). A later ReplacePattern, as scenario phases do,
+// returns to drawing. Blend sources keep drawing: only the top-level
+// spikes are recorded.
+func (e *Engine) ReplaySpikes(events []SpikeEvent) {
+	e.mu.RLock()
+	poisson := e.poisson
+	e.mu.RUnlock()
+	poisson.Replay(events)
+}
+
+// SpikeSchedule returns the spike arrivals of the current pattern so
+// far, for ReplaySpikes.
+func (e *Engine) SpikeSchedule() []SpikeEvent {
+	e.mu.RLock()
+	poisson := e.poisson
+	e.mu.RUnlock()
+	return poisson.Schedule()
+}
+
+// Freeze pauses the spike timeline and the square wave; Thaw resumes
+// them where they left off. Noise is memoryless enough that it needs
+// neither. See #1183.
+func (e *Engine) Freeze() {
+	for _, p := range e.spikeGenerators() {
+		p.Freeze()
+	}
+	e.currentSquare().Freeze()
+}
+
+// Thaw resumes a timeline stopped by Freeze.
+func (e *Engine) Thaw() {
+	for _, p := range e.spikeGenerators() {
+		p.Thaw()
+	}
+	e.currentSquare().Thaw()
 }
 
 // GetBaseTPS returns the current base TPS.
@@ -103,6 +374,12 @@ func (e *Engine) IsSpiking() bool {
 	return e.poisson.IsSpiking()
 }
 
+// IsSquareOn returns whether the square wave is in its on phase; true
+// when pattern.square is off.
+func (e *Engine) IsSquareOn() bool {
+	return e.currentSquare().IsOn()
+}
+
 // TriggerManualSpike triggers a manual spike with optional custom factor and duration.
 func (e *Engine) TriggerManualSpike(factor float64, duration time.Duration) {
 	e.poisson.TriggerManualSpike(factor, duration)
@@ -142,6 +419,25 @@ type Status struct {
 	// NextSpikeIn is the duration until the next scheduled (auto)
 	// spike. Zero while a spike is currently active.
 	NextSpikeIn time.Duration
+	// SpikeOverlap counts arrivals that collided with a running spike,
+	// by how pattern.poisson.overlap resolved them. PendingSpikes is
+	// the current queue depth under overlap=queue.
+	SpikeOverlap  SpikeOverlapStats
+	PendingSpikes int
+	// TargetNoise is each target's latest noise multiplier when
+	// noise.per_target is on; nil otherwise.
+	TargetNoise map[string]float64
+	// BlendMultiplier is pattern.blend's weighted multiplier, 1 without
+	// a blend, and Blend each source's part of CurrentTPS (#1247).
+	BlendMultiplier float64
+	Blend           []BlendShare
+	// SquareOn is whether pattern.square is in its on phase, and
+	// SquareLeft how long the phase has left; SquareMultiplier is the
+	// phase's multiplier, 1 while on or without a square wave.
+	SquareEnabled    bool
+	SquareOn         bool
+	SquareMultiplier float64
+	SquareLeft       time.Duration
 }
 
 // GetStatus returns the current status of the pattern engine.
@@ -149,8 +445,20 @@ func (e *Engine) GetStatus() Status {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
+	noiseMult := e.noise.Multiplier()
+	targetNoise, mean, perTarget := e.targetNoiseSnapshot()
+	if perTarget {
+		noiseMult = mean
+	}
+
+	e.bMu.Lock()
+	blendMult, blend := e.blendMult, e.blendNow
+	e.bMu.Unlock()
+
+	squareMult, squareOn, squareLeft := e.square.phase()
+
 	// Calculate current TPS (with schedule multiplier = 1.0)
-	currentTPS := e.baseTPS * e.poisson.Multiplier() * e.noise.Multiplier()
+	currentTPS := e.baseTPS * blendMult * e.poisson.Multiplier() * squareMult * noiseMult
 	if currentTPS > e.maxTPS {
 		currentTPS = e.maxTPS
 	}
@@ -172,8 +480,17 @@ func (e *Engine) GetStatus() Status {
 		PoissonSpiking:    spiking,
 		PoissonMultiplier: e.poisson.Multiplier(),
 		NoiseEnabled:      e.noise.Enabled(),
-		NoiseMultiplier:   e.noise.Multiplier(),
+		NoiseMultiplier:   noiseMult,
 		SpikeKind:         kind,
 		NextSpikeIn:       e.poisson.NextSpikeIn(),
+		SpikeOverlap:      e.poisson.OverlapStats(),
+		PendingSpikes:     e.poisson.PendingSpikes(),
+		TargetNoise:       targetNoise,
+		BlendMultiplier:   blendMult,
+		Blend:             blend,
+		SquareEnabled:     e.square.cfg.Enabled,
+		SquareOn:          squareOn,
+		SquareMultiplier:  squareMult,
+		SquareLeft:        squareLeft,
 	}
 }
@@ -1,6 +1,7 @@
 package pattern
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -92,3 +93,207 @@ func TestEngineStatus_AutoSpikeReportsAutoKind(t *testing.T) {
 			st.SpikeKind, SpikeKindAuto)
 	}
 }
+
+func perTargetNoiseEngine(typ config.NoiseType) *Engine {
+	e := NewEngine(config.Pattern{
+		Poisson: quietPoisson(),
+		Noise:   config.Noise{Enabled: true, Type: typ, Amplitude: 0.2, PerTarget: true},
+	}, 100, 1000)
+	e.SetTargets([]config.Target{
+		{Name: "a", Weight: 1},
+		{Name: "b", Weight: 1},
+	})
+	return e
+}
+
+func TestEngine_PerTargetNoiseDecorrelates(t *testing.T) {
+	for _, typ := range []config.NoiseType{config.NoiseTypeSpring, config.NoiseTypePerlin} {
+		e := perTargetNoiseEngine(typ)
+
+		differ := false
+		for i := 0; i < 200 && !differ; i++ {
+			e.CalculateTPS(1)
+			differ = e.TargetNoise("a") != e.TargetNoise("b")
+		}
+		if !differ {
+			t.Fatalf("%s: per-target noise moved in lockstep", typ)
+		}
+	}
+}
+
+func TestEngine_PerTargetNoiseIsWeightedMean(t *testing.T) {
+	e := perTargetNoiseEngine(config.NoiseTypeSpring)
+	for i := 0; i < 50; i++ {
+		e.CalculateTPS(1)
+	}
+
+	st := e.GetStatus()
+	if len(st.TargetNoise) != 2 {
+		t.Fatalf("TargetNoise = %v, want two entries", st.TargetNoise)
+	}
+	want := (st.TargetNoise["a"] + st.TargetNoise["b"]) / 2
+	if diff := st.NoiseMultiplier - want; diff > 1e-9 || diff < -1e-9 {
+		t.Fatalf("NoiseMultiplier = %v, want weighted mean %v", st.NoiseMultiplier, want)
+	}
+}
+
+func TestEngine_PerTargetNoiseOffLeavesStatusNil(t *testing.T) {
+	e := newTestEngine()
+	e.SetTargets([]config.Target{{Name: "a", Weight: 1}})
+	if st := e.GetStatus(); st.TargetNoise != nil {
+		t.Fatalf("TargetNoise = %v, want nil when per_target is off", st.TargetNoise)
+	}
+	if got := e.TargetNoise("a"); got != 1.0 {
+		t.Fatalf("TargetNoise(a) = %v, want 1.0", got)
+	}
+}
+
+func TestEngine_SpikeCapacityFollowsBaseTPS(t *testing.T) {
+	p := quietPoisson()
+	p.SpikeCapacity = 0.5
+	p.CapacityTPS = 1000
+	e := NewEngine(config.Pattern{Poisson: p}, 100, 2000)
+
+	e.TriggerManualSpike(0, time.Minute)
+	if got := e.poisson.active[0].factor; got != 5 {
+		t.Fatalf("spike factor = %v, want 5 (50%% of 1000 over base 100)", got)
+	}
+
+	e.SetBaseTPS(250)
+	e.TriggerManualSpike(0, time.Minute)
+	if got := e.poisson.active[0].factor; got != 2 {
+		t.Fatalf("spike factor after SetBaseTPS = %v, want 2", got)
+	}
+}
+
+func TestEngine_BlendWeighsSourcesAndSplitsStatus(t *testing.T) {
+	e := NewEngine(config.Pattern{Blend: []config.BlendSource{
+		{Name: "steady", Weight: 3},
+		{Name: "burst", Weight: 1, Poisson: quietPoisson()},
+	}}, 100, 1000)
+	e.blend[1].poisson.TriggerManualSpike(5, time.Second)
+	time.Sleep(150 * time.Millisecond)
+
+	tps := e.CalculateTPS(1)
+	st := e.GetStatus()
+	if len(st.Blend) != 2 || st.Blend[0].Share != 0.75 || st.Blend[1].Share != 0.25 {
+		t.Fatalf("blend status = %+v", st.Blend)
+	}
+	steady, burst := st.Blend[0], st.Blend[1]
+	if steady.Multiplier != 1 || burst.Multiplier <= 1 || !burst.Spiking || steady.Spiking {
+		t.Fatalf("only the burst source should spike: %+v", st.Blend)
+	}
+	if want := 100 * (0.75 + 0.25*burst.Multiplier); math.Abs(tps-want) > 1e-9 {
+		t.Fatalf("tps = %v, want %v", tps, want)
+	}
+	// A spike in one source moves only its own part of the rate.
+	if math.Abs(steady.TPS-75) > 1e-9 || math.Abs(steady.TPS+burst.TPS-tps) > 1e-9 {
+		t.Fatalf("parts %v + %v don't split %v", steady.TPS, burst.TPS, tps)
+	}
+	if st.PoissonSpiking || math.Abs(st.BlendMultiplier-tps/100) > 1e-9 {
+		t.Fatalf("status = %+v", st)
+	}
+
+	e.ReplacePattern(config.Pattern{})
+	if tps := e.CalculateTPS(1); tps != 100 || e.GetStatus().Blend != nil {
+		t.Fatalf("replacing the pattern should drop the blend, tps = %v", tps)
+	}
+}
+
+// firstArrival is how far into p's timeline its first spike lands.
+func firstArrival(p *PoissonSpike) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.nextSpikeTime.Sub(p.origin)
+}
+
+func TestEngine_SeedReproducesAndIsolatesSources(t *testing.T) {
+	poisson := config.Poisson{Enabled: true, Lambda: 0.01, SpikeFactor: 2}
+	cfg := config.Pattern{Seed: 42, Poisson: poisson, Blend: []config.BlendSource{
+		{Name: "a", Poisson: poisson},
+		{Name: "b", Poisson: poisson},
+	}}
+	e1, e2 := NewEngine(cfg, 100, 1000), NewEngine(cfg, 100, 1000)
+	if e1.Seed() != 42 {
+		t.Fatalf("Seed() = %d, want 42", e1.Seed())
+	}
+	for i := range e1.blend {
+		if a, b := firstArrival(e1.blend[i].poisson), firstArrival(e2.blend[i].poisson); a != b {
+			t.Fatalf("source %d: arrivals %s and %s from one seed", i, a, b)
+		}
+	}
+	if firstArrival(e1.blend[0].poisson) == firstArrival(e1.blend[1].poisson) ||
+		firstArrival(e1.poisson) == firstArrival(e1.blend[0].poisson) {
+		t.Fatal("sources with the same config share a spike timeline")
+	}
+
+	// Phases reusing a pattern draw anew, the same way in both engines.
+	first := firstArrival(e1.poisson)
+	e1.ReplacePattern(cfg)
+	e2.ReplacePattern(cfg)
+	if got := firstArrival(e1.poisson); got == first || got != firstArrival(e2.poisson) {
+		t.Fatalf("phase arrival %s, first phase %s, other engine %s", got, first, firstArrival(e2.poisson))
+	}
+
+	if NewEngine(config.Pattern{}, 100, 1000).Seed() == 0 {
+		t.Fatal("an unseeded engine should draw a seed from the clock")
+	}
+}
+
+func TestSquareAt_AlternatesPhases(t *testing.T) {
+	cfg := config.Square{Enabled: true, OnDuration: 10 * time.Second, OffDuration: 5 * time.Second, OffMultiplier: 0.2}
+	cases := []struct {
+		elapsed time.Duration
+		mult    float64
+		on      bool
+		left    time.Duration
+	}{
+		{0, 1, true, 10 * time.Second},
+		{9 * time.Second, 1, true, time.Second},
+		{10 * time.Second, 0.2, false, 5 * time.Second},
+		{14 * time.Second, 0.2, false, time.Second},
+		{15 * time.Second, 1, true, 10 * time.Second},
+	}
+	for _, c := range cases {
+		mult, on, left := SquareAt(cfg, c.elapsed)
+		if mult != c.mult || on != c.on || left != c.left {
+			t.Errorf("SquareAt(%s) = %v, %v, %s; want %v, %v, %s", c.elapsed, mult, on, left, c.mult, c.on, c.left)
+		}
+	}
+	if mult, on, _ := SquareAt(config.Square{}, 12*time.Second); mult != 1 || !on {
+		t.Fatalf("a disabled square wave should stay on at 1, got %v, %v", mult, on)
+	}
+}
+
+func TestEngine_SquareStartsOnAndFreezes(t *testing.T) {
+	e := NewEngine(config.Pattern{Square: config.Square{
+		Enabled: true, OnDuration: time.Hour, OffDuration: time.Hour, OffMultiplier: 0.5,
+	}}, 100, 1000)
+	if !e.IsSquareOn() || e.CalculateTPS(1) != 100 {
+		t.Fatal("the first cycle should start on")
+	}
+
+	// Move the timeline into the off phase.
+	e.square.mu.Lock()
+	e.square.origin = e.square.origin.Add(-90 * time.Minute)
+	e.square.mu.Unlock()
+	if e.IsSquareOn() || e.CalculateTPS(1) != 50 {
+		t.Fatalf("off phase: on = %v, tps = %v", e.IsSquareOn(), e.CalculateTPS(1))
+	}
+	st := e.GetStatus()
+	if !st.SquareEnabled || st.SquareOn || st.SquareMultiplier != 0.5 || st.SquareLeft <= 0 || st.SquareLeft > 30*time.Minute {
+		t.Fatalf("status = %+v", st)
+	}
+
+	e.Freeze()
+	left := e.GetStatus().SquareLeft
+	time.Sleep(20 * time.Millisecond)
+	if got := e.GetStatus().SquareLeft; got != left {
+		t.Fatalf("frozen phase moved from %s to %s", left, got)
+	}
+	e.Thaw()
+
+	if st := NewEngine(config.Pattern{}, 100, 1000).GetStatus(); st.SquareEnabled || st.SquareMultiplier != 1 {
+		t.Fatalf("no square wave: status = %+v", st)
+	}
+}
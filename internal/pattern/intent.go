@@ -0,0 +1,202 @@
+package pattern
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+// IntentSample is one observed instant of a live run: what the engine
+// asked for and what the pool actually completed. The daemon records
+// one per second while traffic is flowing.
+type IntentSample struct {
+	Time        time.Time `json:"time"`
+	TargetTPS   float64   `json:"target_tps"`
+	AchievedTPS float64   `json:"achieved_tps"`
+	Spiking     bool      `json:"spiking"`
+	Manual      bool      `json:"manual,omitempty"` // the active spike was operator-triggered
+	// Headers is the last value of each track_header target's header
+	// as of this sample.
+	Headers []HeaderValue `json:"headers,omitempty"`
+}
+
+// HeaderValue is a tracked response header's value on the timeline.
+type HeaderValue struct {
+	Target string `json:"target"`
+	Header string `json:"header"`
+	Value  string `json:"value"`
+}
+
+// IntentDeviation is one way the observed run differs from the
+// configured pattern by more than the tolerance.
+type IntentDeviation struct {
+	Check    string `json:"check"` // spike_rate | spike_factor | achieved_tps
+	Expected string `json:"expected"`
+	Observed string `json:"observed"`
+	Message  string `json:"message"`
+}
+
+// intentMinExpectedSpikes is the smallest expected spike count for
+// which a frequency comparison is meaningful; below it Poisson noise
+// dominates and the check is skipped.
+const intentMinExpectedSpikes = 3
+
+// CheckIntent compares samples against cfg and returns every check
+// that deviates by more than tolerance (relative, e.g. 0.2). It is
+// pure: the same samples always give the same answer. Manual spikes
+// are excluded from the spike checks since they don't come from the
+// configured process.
+func CheckIntent(cfg config.Pattern, baseTPS, maxTPS float64, samples []IntentSample, tolerance float64) []IntentDeviation {
+	if len(samples) < 2 {
+		return nil
+	}
+	if tolerance <= 0 {
+		tolerance = config.DefaultIntentTolerance
+	}
+	var out []IntentDeviation
+	spikes := spikeEpisodes(samples, baseTPS)
+	duration := samples[len(samples)-1].Time.Sub(samples[0].Time)
+
+	if p := cfg.Poisson; p.Enabled {
+		p.SpikeFactor = p.Factor(baseTPS)
+		if mean := meanSpikeInterval(p); mean > 0 {
+			if overlapMode(p.Overlap) == config.SpikeOverlapSequential {
+				// The next arrival is drawn once the spike has ended.
+				mean += p.RampUp + p.RampDown
+			}
+			expected := duration.Seconds() / mean.Seconds()
+			// Allow the larger of the tolerance and two Poisson
+			// standard deviations, so short runs don't cry wolf.
+			slack := math.Max(tolerance*expected, 2*math.Sqrt(expected))
+			if expected >= intentMinExpectedSpikes && math.Abs(float64(len(spikes))-expected) > slack {
+				msg := "spike count outside the expected range for the configured interval"
+				if m := overlapMode(p.Overlap); float64(len(spikes)) < expected && (m == config.SpikeOverlapDrop || m == config.SpikeOverlapQueue) {
+					msg += "; arrivals during a running spike are merged or dropped under overlap=" + string(m)
+				}
+				out = append(out, IntentDeviation{
+					Check:    "spike_rate",
+					Expected: fmt.Sprintf("%.1f spikes in %s", expected, duration.Round(time.Second)),
+					Observed: fmt.Sprintf("%d spikes", len(spikes)),
+					Message:  msg,
+				})
+			}
+		}
+
+		if len(spikes) > 0 && p.SpikeFactor > 1 {
+			ratios := make([]float64, len(spikes))
+			clamped := 0
+			for i, s := range spikes {
+				ratios[i] = s.peak / s.baseline
+				if maxTPS > 0 && s.peak >= maxTPS*0.99 {
+					clamped++
+				}
+			}
+			sort.Float64s(ratios)
+			median := ratios[len(ratios)/2]
+			if median < p.SpikeFactor*(1-tolerance) {
+				msg := "spikes peaked lower than the configured factor"
+				if clamped*2 >= len(spikes) {
+					msg += " — likely MaxTPS-clamped (raise controller.max_tps)"
+				}
+				out = append(out, IntentDeviation{
+					Check:    "spike_factor",
+					Expected: fmt.Sprintf("%.2fx", p.SpikeFactor),
+					Observed: fmt.Sprintf("%.2fx median peak", median),
+					Message:  msg,
+				})
+			}
+		}
+	}
+
+	var peakTarget, peakAchieved float64
+	for _, s := range samples {
+		peakTarget = math.Max(peakTarget, s.TargetTPS)
+		peakAchieved = math.Max(peakAchieved, s.AchievedTPS)
+	}
+	// Why: peakAchieved == 0 means the pool doesn't report achieved
+	// TPS (master mode), not that nothing was sent.
+	if peakTarget > 0 && peakAchieved > 0 && peakAchieved < peakTarget*(1-tolerance) {
+		out = append(out, IntentDeviation{
+			Check:    "achieved_tps",
+			Expected: fmt.Sprintf("peak %.0f TPS", peakTarget),
+			Observed: fmt.Sprintf("peak %.0f TPS", peakAchieved),
+			Message:  "the pool could not keep up with the requested rate — check queue drops and worker.pool_size",
+		})
+	}
+	return out
+}
+
+type spikeEpisode struct {
+	peak, baseline float64
+}
+
+// spikeEpisodes groups consecutive auto-spiking samples into spikes.
+// Each spike's baseline is the mean target TPS of up to ten
+// non-spiking samples before it, falling back to baseTPS.
+func spikeEpisodes(samples []IntentSample, baseTPS float64) []spikeEpisode {
+	var out []spikeEpisode
+	var quiet []float64
+	in := false
+	for _, s := range samples {
+		auto := s.Spiking && !s.Manual
+		switch {
+		case auto && !in:
+			base := baseTPS
+			if len(quiet) > 0 {
+				sum := 0.0
+				for _, v := range quiet {
+					sum += v
+				}
+				base = sum / float64(len(quiet))
+			}
+			if base <= 0 {
+				base = 1
+			}
+			out = append(out, spikeEpisode{peak: s.TargetTPS, baseline: base})
+			in = true
+		case auto:
+			out[len(out)-1].peak = math.Max(out[len(out)-1].peak, s.TargetTPS)
+		case !s.Spiking:
+			in = false
+			quiet = append(quiet, s.TargetTPS)
+			if len(quiet) > 10 {
+				quiet = quiet[1:]
+			}
+		default:
+			in = false
+		}
+	}
+	return out
+}
+
+// meanSpikeInterval is the expected gap between arrivals once the
+// exponential draw is clamped to [min_interval, max_interval]:
+// E[clamp(X)] = a + (e^{-λa} − e^{-λb}) / λ for X ~ Exp(λ).
+func meanSpikeInterval(p config.Poisson) time.Duration {
+	lambda := p.Lambda
+	if p.Interval > 0 {
+		lambda = 1.0 / p.Interval.Seconds()
+	}
+	if lambda <= 0 {
+		return 0
+	}
+	a := p.MinInterval.Seconds()
+	tail := math.Exp(-lambda * a)
+	if b := p.MaxInterval.Seconds(); b > 0 && b > a {
+		tail -= math.Exp(-lambda * b)
+	}
+	return time.Duration((a + tail/lambda) * float64(time.Second))
+}
+
+// overlapMode returns the overlap policy m selects. Empty and unknown
+// values fall back to sequential, matching the validator's warning.
+func overlapMode(m config.SpikeOverlap) config.SpikeOverlap {
+	switch m {
+	case config.SpikeOverlapDrop, config.SpikeOverlapQueue, config.SpikeOverlapSuperimpose:
+		return m
+	}
+	return config.SpikeOverlapSequential
+}
@@ -0,0 +1,100 @@
+package pattern
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+// intentRun builds a one-sample-per-second timeline of n seconds at
+// base TPS with a spike of the given peak every `every` seconds,
+// lasting 5s. achieved scales the achieved TPS relative to target.
+func intentRun(n, every int, base, peak, achieved float64) []IntentSample {
+	t0 := time.Unix(0, 0)
+	out := make([]IntentSample, n)
+	for i := range out {
+		s := IntentSample{Time: t0.Add(time.Duration(i) * time.Second), TargetTPS: base}
+		if every > 0 && i > 0 && i%every < 5 {
+			s.Spiking = true
+			s.TargetTPS = peak
+		}
+		s.AchievedTPS = s.TargetTPS * achieved
+		out[i] = s
+	}
+	return out
+}
+
+func intentPattern(interval time.Duration, factor float64) config.Pattern {
+	return config.Pattern{Poisson: config.Poisson{
+		Enabled:     true,
+		Interval:    interval,
+		SpikeFactor: factor,
+	}}
+}
+
+func hasCheck(devs []IntentDeviation, check string) bool {
+	for _, d := range devs {
+		if d.Check == check {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCheckIntent_MatchingRunIsClean(t *testing.T) {
+	samples := intentRun(600, 60, 100, 300, 1)
+	if devs := CheckIntent(intentPattern(time.Minute, 3), 100, 1000, samples, 0.2); len(devs) != 0 {
+		t.Fatalf("unexpected deviations: %+v", devs)
+	}
+}
+
+func TestCheckIntent_ClampedSpikeFactor(t *testing.T) {
+	// Configured 3x but MaxTPS caps peaks at 140.
+	samples := intentRun(600, 60, 100, 140, 1)
+	devs := CheckIntent(intentPattern(time.Minute, 3), 100, 140, samples, 0.2)
+	if !hasCheck(devs, "spike_factor") {
+		t.Fatalf("expected spike_factor deviation, got %+v", devs)
+	}
+	for _, d := range devs {
+		if d.Check == "spike_factor" && d.Observed != "1.40x median peak" {
+			t.Fatalf("observed = %q", d.Observed)
+		}
+	}
+}
+
+func TestCheckIntent_TooFewSpikes(t *testing.T) {
+	// Configured one per minute, observed one per five minutes.
+	samples := intentRun(1800, 300, 100, 300, 1)
+	devs := CheckIntent(intentPattern(time.Minute, 3), 100, 1000, samples, 0.2)
+	if !hasCheck(devs, "spike_rate") {
+		t.Fatalf("expected spike_rate deviation, got %+v", devs)
+	}
+}
+
+func TestCheckIntent_AchievedBelowTarget(t *testing.T) {
+	samples := intentRun(120, 0, 100, 0, 0.5)
+	devs := CheckIntent(config.Pattern{}, 100, 1000, samples, 0.2)
+	if !hasCheck(devs, "achieved_tps") {
+		t.Fatalf("expected achieved_tps deviation, got %+v", devs)
+	}
+	// No achieved figures at all (master mode) skips the check.
+	for i := range samples {
+		samples[i].AchievedTPS = 0
+	}
+	if devs := CheckIntent(config.Pattern{}, 100, 1000, samples, 0.2); len(devs) != 0 {
+		t.Fatalf("expected no deviations without achieved TPS, got %+v", devs)
+	}
+}
+
+func TestMeanSpikeInterval_Clamped(t *testing.T) {
+	p := config.Poisson{Lambda: 1.0 / 60}
+	if got := meanSpikeInterval(p); got.Round(time.Second) != time.Minute {
+		t.Fatalf("unclamped mean = %v, want 1m", got)
+	}
+	// A floor well above the mean dominates.
+	p.MinInterval = 10 * time.Minute
+	if got := meanSpikeInterval(p); got < 10*time.Minute || got > 11*time.Minute {
+		t.Fatalf("clamped mean = %v, want just over 10m", got)
+	}
+}
@@ -26,6 +26,24 @@ func NewNoiseGenerator(cfg config.Noise) NoiseGenerator {
 	}
 }
 
+// NewNoiseGeneratorWithSeed is NewNoiseGenerator with an explicit seed.
+// Generators built from different seeds fluctuate independently, which
+// is what per-target noise needs: spring noise seeds its RNG, Perlin
+// noise shifts its phase (its waveform is otherwise deterministic, so
+// two instances started together would move in lockstep).
+func NewNoiseGeneratorWithSeed(cfg config.Noise, seed int64) NoiseGenerator {
+	switch cfg.Type {
+	case config.NoiseTypePerlin:
+		p := NewPerlinNoise(cfg)
+		p.offset = float64(uint64(seed)%1_000_000) / 10
+		return p
+	default:
+		n := NewNoise(cfg)
+		n.rng = rand.New(rand.NewSource(seed))
+		return n
+	}
+}
+
 // Noise generates micro fluctuations using a spring-damper system.
 type Noise struct {
 	cfg config.Noise
@@ -102,6 +120,7 @@ func (n *Noise) Multiplier() float64 {
 type PerlinNoise struct {
 	cfg       config.Noise
 	startTime time.Time
+	offset    float64 // phase shift in seconds; see NewNoiseGeneratorWithSeed
 	mu        sync.Mutex
 }
 
@@ -128,7 +147,7 @@ func (p *PerlinNoise) Multiplier() float64 {
 	defer p.mu.Unlock()
 
 	// Time-based noise with multiple octaves
-	t := time.Since(p.startTime).Seconds()
+	t := time.Since(p.startTime).Seconds() + p.offset
 
 	// Simplified multi-octave noise
 	noise := p.octaveNoise(t, 3, 0.5)
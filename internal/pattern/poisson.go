@@ -9,122 +9,366 @@ import (
 	"github.com/kar98k/internal/config"
 )
 
+// spikeState is one spike on the runtime timeline — either active
+// (start <= now < end) or waiting in the queue for its turn.
+type spikeState struct {
+	start, peak, end time.Time
+	factor           float64
+	manual           bool
+
+	// rampUp/rampDown are kept so a queued spike can be re-anchored
+	// to the moment it actually starts.
+	rampUp, rampDown time.Duration
+}
+
+// SpikeOverlapStats counts how often a spike arrived while another was
+// still running, broken down by what the overlap policy did with it.
+type SpikeOverlapStats struct {
+	Dropped      int64 // arrivals discarded (overlap=drop, or a full queue) or spikes preempted by a manual one
+	Queued       int64 // arrivals deferred until the running spike ended (overlap=queue)
+	Superimposed int64 // arrivals layered on top of a running spike (overlap=superimpose)
+}
+
+// MaxPendingSpikes bounds the overlap=queue backlog. Spikes arriving
+// faster than they finish, e.g. with a short min_interval and long
+// ramps, would otherwise queue for the whole run; once it is full an
+// arrival is dropped and counted as such.
+const MaxPendingSpikes = 8
+
 // PoissonSpike generates traffic spikes using Poisson distribution.
+//
+// By default (overlap=sequential) the next arrival is drawn once the
+// running spike has ended, so spikes never overlap. The other overlap
+// modes make arrivals a true Poisson process, scheduled the moment the
+// previous one fires; an arrival can then land while a spike is still
+// running, and cfg.Overlap decides what happens.
 type PoissonSpike struct {
 	cfg config.Poisson
 	rng *rand.Rand
 	mu  sync.Mutex
 
-	// Spike state
-	spiking       bool
-	spikeStart    time.Time
-	spikePeak     time.Time
-	spikeEnd      time.Time
+	// active holds running spikes. Under sequential/drop/queue it has
+	// at most one entry; under superimpose every overlapping spike lives here.
+	active []spikeState
+	// pending holds spikes waiting for the active one to finish
+	// (overlap=queue only), at most MaxPendingSpikes. FIFO.
+	pending       []spikeState
 	nextSpikeTime time.Time
 
-	// Manual spike state
-	manualSpike       bool
-	manualSpikeFactor float64
-	manualSpikeDuration time.Duration
+	overlap SpikeOverlapStats
+
+	// baseTPS is what a capacity-relative spike_capacity (#1215) is
+	// turned into a multiplier against.
+	baseTPS float64
+
+	// frozenAt is non-zero while the generator is paused; the timeline
+	// reads it instead of the wall clock so nothing ages (#1183).
+	frozenAt time.Time
+
+	// origin is the start of the timeline, moved forward by paused
+	// spans like every other timestamp. recorded holds every arrival
+	// since, relative to origin; replay, when set, supplies the
+	// arrivals instead of the Poisson draw (#1228).
+	origin     time.Time
+	recorded   []SpikeEvent
+	replay     []SpikeEvent
+	replayNext int
 }
 
 // NewPoissonSpike creates a new Poisson spike generator.
 func NewPoissonSpike(cfg config.Poisson) *PoissonSpike {
+	return NewPoissonSpikeWithSeed(cfg, time.Now().UnixNano())
+}
+
+// NewPoissonSpikeWithSeed is NewPoissonSpike with an explicit seed: the
+// same seed draws the same arrivals relative to the timeline's start.
+func NewPoissonSpikeWithSeed(cfg config.Poisson, seed int64) *PoissonSpike {
 	// If interval is set, convert to lambda (lambda = 1/interval_seconds)
 	if cfg.Interval > 0 {
 		cfg.Lambda = 1.0 / cfg.Interval.Seconds()
 	}
 
 	p := &PoissonSpike{
-		cfg: cfg,
-		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+		cfg:    cfg,
+		rng:    rand.New(rand.NewSource(seed)),
+		origin: time.Now(),
 	}
-	p.scheduleNextSpike()
+	p.scheduleNextSpike(p.origin.Add(cfg.InitialDelay))
 	return p
 }
 
+// Start restarts the arrival timeline at now, for a generator built
+// ahead of the run: the first arrival is drawn anew, no sooner than
+// InitialDelay from now, or replay starts over. Arrivals recorded
+// before are discarded.
+func (p *PoissonSpike) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.origin = p.clock()
+	p.recorded = nil
+	p.replayNext = 0
+	if p.replay != nil {
+		p.scheduleReplay()
+		return
+	}
+	p.scheduleNextSpike(p.origin.Add(p.cfg.InitialDelay))
+}
+
+// Replay makes events, in arrival order, the generator's arrivals in
+// place of the Poisson draw, from the start of the timeline. Once they
+// run out no further spikes arrive; manual spikes still can.
+func (p *PoissonSpike) Replay(events []SpikeEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.replay = append([]SpikeEvent{}, events...)
+	p.replayNext = 0
+	p.scheduleReplay()
+}
+
+// scheduleReplay points nextSpikeTime at the next replayed arrival.
+// Caller holds p.mu.
+func (p *PoissonSpike) scheduleReplay() {
+	if p.replayNext < len(p.replay) {
+		p.nextSpikeTime = p.origin.Add(p.replay[p.replayNext].At)
+	}
+}
+
+// arrivalsDue reports whether automatic arrivals are still to come.
+// Caller holds p.mu.
+func (p *PoissonSpike) arrivalsDue() bool {
+	if p.replay != nil {
+		return p.replayNext < len(p.replay)
+	}
+	return p.cfg.Enabled
+}
+
+// Schedule returns every spike arrival so far, automatic and manual,
+// in the form Replay takes.
+func (p *PoissonSpike) Schedule() []SpikeEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]SpikeEvent(nil), p.recorded...)
+}
+
+// record logs an arrival at t. Caller holds p.mu.
+func (p *PoissonSpike) record(t time.Time, s spikeState) {
+	p.recorded = append(p.recorded, SpikeEvent{
+		At:       t.Sub(p.origin),
+		Factor:   s.factor,
+		RampUp:   s.rampUp,
+		RampDown: s.rampDown,
+		Manual:   s.manual,
+	})
+}
+
 // TriggerManualSpike triggers a manual spike with optional custom factor and duration.
 // If factor is 0, uses the configured spike_factor.
 // If duration is 0, uses the configured ramp_up + ramp_down.
+//
+// Manual spikes go through the same overlap policy as automatic ones,
+// except under overlap=drop: an operator asking for a spike expects it
+// now, so the manual spike preempts whatever is running and the
+// displaced spike is counted as dropped.
 func (p *PoissonSpike) TriggerManualSpike(factor float64, duration time.Duration) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	if factor == 0 {
-		factor = p.cfg.SpikeFactor
+		factor = p.cfg.Factor(p.baseTPS)
 	}
 	if duration == 0 {
 		duration = p.cfg.RampUp + p.cfg.RampDown
 	}
 
-	p.manualSpike = true
-	p.manualSpikeFactor = factor
-	p.manualSpikeDuration = duration
+	now := p.clock()
+	p.expire(now)
+
+	s := spikeState{
+		factor:   factor,
+		manual:   true,
+		rampUp:   duration / 3, // 1/3 for ramp up
+		rampDown: duration - duration/3,
+	}
+	p.record(now, s)
+	p.admitManual(s, now)
+}
 
-	now := time.Now()
-	p.spiking = true
-	p.spikeStart = now
-	p.spikePeak = now.Add(duration / 3)        // 1/3 for ramp up
-	p.spikeEnd = now.Add(duration)
+// admitManual admits a manual spike, preempting under overlap=drop
+// and sequential. Caller holds p.mu.
+func (p *PoissonSpike) admitManual(s spikeState, now time.Time) {
+	if m := p.overlapMode(); len(p.active) > 0 && (m == config.SpikeOverlapDrop || m == config.SpikeOverlapSequential) {
+		p.overlap.Dropped += int64(len(p.active))
+		p.active = p.active[:0]
+	}
+	p.admit(s, now)
+}
+
+// SetBaseTPS sets the base TPS a spike_capacity fraction is measured
+// against. Spikes already running keep their factor.
+func (p *PoissonSpike) SetBaseTPS(tps float64) {
+	p.mu.Lock()
+	p.baseTPS = tps
+	p.mu.Unlock()
 }
 
 // IsManualSpike returns whether a manual spike is currently active.
 func (p *PoissonSpike) IsManualSpike() bool {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	return p.manualSpike && p.spiking
+	for _, s := range p.active {
+		if s.manual {
+			return true
+		}
+	}
+	return false
 }
 
 // Multiplier returns the current TPS multiplier based on spike state.
 func (p *PoissonSpike) Multiplier() float64 {
-	if !p.cfg.Enabled && !p.manualSpike {
-		return 1.0
-	}
-
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	now := time.Now()
+	now := p.clock()
+	p.expire(now)
 
-	// Check if current spike has ended
-	if p.spiking && now.After(p.spikeEnd) {
-		p.spiking = false
-		p.manualSpike = false
-		p.scheduleNextSpike()
+	// Fire every arrival that has come due. Looping (rather than a
+	// single if) keeps the arrival process honest when Multiplier is
+	// polled less often than spikes arrive. Each spike is placed as of
+	// its own arrival, so after a stall the backlog plays out as it
+	// would have rather than all starting now.
+	for p.arrivalsDue() {
+		if p.drawsAfterSpike() && len(p.active) > 0 {
+			// The next arrival is drawn when the running spike ends.
+			if p.expire(now); len(p.active) > 0 {
+				break
+			}
+			continue
+		}
+		arrival := p.nextSpikeTime
+		if now.Before(arrival) {
+			break
+		}
+		p.expire(arrival)
+		if p.replay != nil {
+			e := p.replay[p.replayNext]
+			p.replayNext++
+			p.scheduleReplay()
+			s := spikeState{factor: e.Factor, rampUp: e.RampUp, rampDown: e.RampDown, manual: e.Manual}
+			p.record(arrival, s)
+			if s.manual {
+				p.admitManual(s, arrival)
+			} else {
+				p.admit(s, arrival)
+			}
+			continue
+		}
+		if !p.drawsAfterSpike() {
+			p.scheduleNextSpike(arrival)
+		}
+		s := spikeState{
+			factor:   p.cfg.Factor(p.baseTPS),
+			rampUp:   p.cfg.RampUp,
+			rampDown: p.cfg.RampDown,
+		}
+		p.record(arrival, s)
+		p.admit(s, arrival)
 	}
+	p.expire(now)
 
-	// Check if we should start a new automatic spike (only if not in manual spike)
-	if p.cfg.Enabled && !p.spiking && !p.manualSpike && now.After(p.nextSpikeTime) {
-		p.startSpike(now)
+	if len(p.active) == 0 {
+		return 1.0
 	}
 
-	if !p.spiking {
-		return 1.0
+	// Superimpose sums each spike's excess over baseline; with a single
+	// active spike this reduces to that spike's own multiplier.
+	mult := 1.0
+	for _, s := range p.active {
+		mult += calculateSpikeMultiplier(s, now) - 1.0
 	}
+	return mult
+}
 
-	return p.calculateSpikeMultiplier(now)
+// overlapMode returns the configured policy.
+func (p *PoissonSpike) overlapMode() config.SpikeOverlap {
+	return overlapMode(p.cfg.Overlap)
 }
 
-// startSpike initiates a new spike event.
-func (p *PoissonSpike) startSpike(now time.Time) {
-	p.spiking = true
-	p.spikeStart = now
-	p.spikePeak = now.Add(p.cfg.RampUp)
-	p.spikeEnd = p.spikePeak.Add(p.cfg.RampDown)
+// drawsAfterSpike reports whether the next arrival is drawn when the
+// running spike ends (overlap=sequential) rather than when the last
+// one fired. Replayed arrivals keep their recorded times.
+func (p *PoissonSpike) drawsAfterSpike() bool {
+	return p.replay == nil && p.overlapMode() == config.SpikeOverlapSequential
 }
 
-// calculateSpikeMultiplier computes the multiplier based on spike phase.
-func (p *PoissonSpike) calculateSpikeMultiplier(now time.Time) float64 {
-	// Use manual spike factor if this is a manual spike
-	spikeFactor := p.cfg.SpikeFactor
-	if p.manualSpike && p.manualSpikeFactor > 0 {
-		spikeFactor = p.manualSpikeFactor
+// admit places a newly arrived spike according to the overlap policy.
+// Caller holds p.mu and has already expired finished spikes.
+func (p *PoissonSpike) admit(s spikeState, now time.Time) {
+	if len(p.active) == 0 {
+		p.active = append(p.active, anchor(s, now))
+		return
 	}
 
-	if now.Before(p.spikePeak) {
+	switch p.overlapMode() {
+	case config.SpikeOverlapQueue:
+		if len(p.pending) >= MaxPendingSpikes {
+			p.overlap.Dropped++
+			return
+		}
+		p.pending = append(p.pending, s)
+		p.overlap.Queued++
+	case config.SpikeOverlapSuperimpose:
+		p.active = append(p.active, anchor(s, now))
+		p.overlap.Superimposed++
+	default:
+		p.overlap.Dropped++
+	}
+}
+
+// expire removes finished spikes and, under overlap=queue, promotes the
+// next pending spike so it starts exactly when its predecessor ended.
+// Under overlap=sequential the next arrival is drawn from that end.
+// Caller holds p.mu.
+func (p *PoissonSpike) expire(now time.Time) {
+	for {
+		kept := p.active[:0]
+		var lastEnd time.Time
+		for _, s := range p.active {
+			if now.Before(s.end) {
+				kept = append(kept, s)
+			} else if s.end.After(lastEnd) {
+				lastEnd = s.end
+			}
+		}
+		p.active = kept
+
+		if len(p.active) == 0 && !lastEnd.IsZero() && p.drawsAfterSpike() {
+			p.scheduleNextSpike(lastEnd)
+		}
+		if len(p.active) > 0 || len(p.pending) == 0 {
+			return
+		}
+		next := anchor(p.pending[0], lastEnd)
+		p.pending = p.pending[1:]
+		p.active = append(p.active, next)
+	}
+}
+
+// anchor fixes a spike's start/peak/end timestamps at the given start.
+func anchor(s spikeState, start time.Time) spikeState {
+	s.start = start
+	s.peak = start.Add(s.rampUp)
+	s.end = s.peak.Add(s.rampDown)
+	return s
+}
+
+// calculateSpikeMultiplier computes the multiplier based on spike phase.
+func calculateSpikeMultiplier(s spikeState, now time.Time) float64 {
+	spikeFactor := s.factor
+
+	if now.Before(s.peak) {
 		// Ramp-up phase: linear increase to spike factor
-		elapsed := now.Sub(p.spikeStart).Seconds()
-		total := p.spikePeak.Sub(p.spikeStart).Seconds()
+		elapsed := now.Sub(s.start).Seconds()
+		total := s.peak.Sub(s.start).Seconds()
 		if total == 0 {
 			total = 1
 		}
@@ -133,8 +377,8 @@ func (p *PoissonSpike) calculateSpikeMultiplier(now time.Time) float64 {
 	}
 
 	// Ramp-down phase: exponential decay back to 1.0
-	elapsed := now.Sub(p.spikePeak).Seconds()
-	total := p.spikeEnd.Sub(p.spikePeak).Seconds()
+	elapsed := now.Sub(s.peak).Seconds()
+	total := s.end.Sub(s.peak).Seconds()
 	if total == 0 {
 		total = 1
 	}
@@ -145,9 +389,10 @@ func (p *PoissonSpike) calculateSpikeMultiplier(now time.Time) float64 {
 	return 1.0 + (spikeFactor-1.0)*decay
 }
 
-// scheduleNextSpike calculates when the next spike should occur.
+// scheduleNextSpike calculates when the next spike should occur,
+// measured from the previous arrival.
 // Uses inverse transform sampling: t = -ln(U) / lambda
-func (p *PoissonSpike) scheduleNextSpike() {
+func (p *PoissonSpike) scheduleNextSpike(from time.Time) {
 	// Generate exponentially distributed inter-arrival time
 	u := p.rng.Float64()
 	if u == 0 {
@@ -162,11 +407,56 @@ func (p *PoissonSpike) scheduleNextSpike() {
 	if interval < minSec {
 		interval = minSec
 	}
-	if interval > maxSec {
+	if maxSec > 0 && interval > maxSec {
 		interval = maxSec
 	}
+	// Guard against a zero-length interval spinning the arrival loop.
+	if interval <= 0 {
+		interval = 1
+	}
+
+	p.nextSpikeTime = from.Add(time.Duration(interval * float64(time.Second)))
+}
+
+// clock returns the timeline's notion of now. Callers hold p.mu.
+func (p *PoissonSpike) clock() time.Time {
+	if !p.frozenAt.IsZero() {
+		return p.frozenAt
+	}
+	return time.Now()
+}
 
-	p.nextSpikeTime = time.Now().Add(time.Duration(interval * float64(time.Second)))
+// Freeze stops the spike timeline at the current instant. Until Thaw,
+// no arrivals fire and running spikes hold their multiplier.
+func (p *PoissonSpike) Freeze() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.frozenAt.IsZero() {
+		p.frozenAt = time.Now()
+	}
+}
+
+// Thaw restarts a frozen timeline, moving the next arrival and every
+// active or queued spike forward by the frozen span so a spike half-way
+// up its ramp continues from the same point instead of having aged out.
+func (p *PoissonSpike) Thaw() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.frozenAt.IsZero() {
+		return
+	}
+	d := time.Since(p.frozenAt)
+	p.frozenAt = time.Time{}
+
+	p.nextSpikeTime = p.nextSpikeTime.Add(d)
+	p.origin = p.origin.Add(d)
+	for _, spikes := range [][]spikeState{p.active, p.pending} {
+		for i := range spikes {
+			spikes[i].start = spikes[i].start.Add(d)
+			spikes[i].peak = spikes[i].peak.Add(d)
+			spikes[i].end = spikes[i].end.Add(d)
+		}
+	}
 }
 
 // NextSpikeIn returns the duration until the next spike.
@@ -174,15 +464,32 @@ func (p *PoissonSpike) NextSpikeIn() time.Duration {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.spiking {
+	if len(p.active) > 0 || !p.arrivalsDue() {
 		return 0
 	}
-	return time.Until(p.nextSpikeTime)
+	return p.nextSpikeTime.Sub(p.clock())
 }
 
 // IsSpiking returns whether a spike is currently active.
 func (p *PoissonSpike) IsSpiking() bool {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	return p.spiking
+	p.expire(p.clock())
+	return len(p.active) > 0
+}
+
+// OverlapStats returns how many spike arrivals collided with a running
+// spike since the generator was created.
+func (p *PoissonSpike) OverlapStats() SpikeOverlapStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.overlap
+}
+
+// PendingSpikes returns the number of spikes waiting in the queue
+// (always 0 unless overlap=queue).
+func (p *PoissonSpike) PendingSpikes() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pending)
 }
@@ -0,0 +1,364 @@
+package pattern
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+// overlapSpike builds a spike with a 10s ramp-up and 20s ramp-down so
+// tests can reason about exact timestamps without touching the clock.
+func overlapSpike(factor float64) spikeState {
+	return spikeState{factor: factor, rampUp: 10 * time.Second, rampDown: 20 * time.Second}
+}
+
+func newOverlapSpike(mode config.SpikeOverlap) *PoissonSpike {
+	cfg := quietPoisson()
+	cfg.Overlap = mode
+	return NewPoissonSpike(cfg)
+}
+
+func TestPoissonOverlap_DropDiscardsArrival(t *testing.T) {
+	p := newOverlapSpike(config.SpikeOverlapDrop)
+	t0 := time.Now()
+
+	p.admit(overlapSpike(3), t0)
+	p.admit(overlapSpike(3), t0.Add(5*time.Second))
+
+	if len(p.active) != 1 || len(p.pending) != 0 {
+		t.Fatalf("active=%d pending=%d, want 1/0", len(p.active), len(p.pending))
+	}
+	if got := p.overlap.Dropped; got != 1 {
+		t.Fatalf("Dropped = %d, want 1", got)
+	}
+}
+
+func TestPoissonOverlap_EmptyModeIsSequential(t *testing.T) {
+	cfg := config.Poisson{
+		Enabled:     true,
+		Lambda:      1,
+		SpikeFactor: 3,
+		MinInterval: time.Second,
+		MaxInterval: 2 * time.Second,
+		RampUp:      time.Second,
+		RampDown:    2 * time.Second,
+	}
+	p := NewPoissonSpike(cfg)
+	backdate(p, time.Minute)
+	p.Multiplier()
+
+	// Each arrival is drawn from the end of the spike before it, as
+	// before overlap modes existed: none overlaps, none is dropped.
+	evs := p.Schedule()
+	if len(evs) < 10 {
+		t.Fatalf("%d spikes in a minute, want about one every 4s", len(evs))
+	}
+	for i := 1; i < len(evs); i++ {
+		prevEnd := evs[i-1].At + evs[i-1].RampUp + evs[i-1].RampDown
+		if gap := evs[i].At - prevEnd; gap < cfg.MinInterval || gap > cfg.MaxInterval {
+			t.Fatalf("spike %d arrived %s after spike %d ended, want within [%s, %s]",
+				i, gap, i-1, cfg.MinInterval, cfg.MaxInterval)
+		}
+	}
+	if st := p.OverlapStats(); st != (SpikeOverlapStats{}) {
+		t.Fatalf("overlap stats = %+v, want none", st)
+	}
+}
+
+func TestPoissonOverlap_BacklogPlaysOutAtArrivalTimes(t *testing.T) {
+	for _, mode := range []config.SpikeOverlap{config.SpikeOverlapQueue, config.SpikeOverlapSuperimpose} {
+		p := NewPoissonSpike(config.Poisson{
+			Enabled:     true,
+			Lambda:      1,
+			SpikeFactor: 3,
+			MinInterval: time.Second,
+			MaxInterval: 2 * time.Second,
+			RampUp:      100 * time.Millisecond,
+			RampDown:    100 * time.Millisecond,
+			Overlap:     mode,
+		})
+		// A minute of arrivals falls due at once, as after a stall.
+		// Spikes 200ms long a second apart never overlapped, so none
+		// may be stacked or queued now.
+		backdate(p, time.Minute)
+		if m := p.Multiplier(); m > 3 {
+			t.Fatalf("%s: multiplier = %v after a stall, want at most one spike's 3", mode, m)
+		}
+		if n := len(p.Schedule()); n < 30 {
+			t.Fatalf("%s: %d arrivals recorded, want a minute's worth", mode, n)
+		}
+		if st, pending := p.OverlapStats(), p.PendingSpikes(); st != (SpikeOverlapStats{}) || pending != 0 {
+			t.Fatalf("%s: overlap stats = %+v, pending = %d; want none", mode, st, pending)
+		}
+	}
+}
+
+func TestPoissonOverlap_QueueStartsWhenPreviousEnds(t *testing.T) {
+	p := newOverlapSpike(config.SpikeOverlapQueue)
+	t0 := time.Now()
+
+	p.admit(overlapSpike(3), t0)
+	p.admit(overlapSpike(4), t0.Add(5*time.Second))
+
+	if got := p.overlap.Queued; got != 1 {
+		t.Fatalf("Queued = %d, want 1", got)
+	}
+	if len(p.pending) != 1 {
+		t.Fatalf("pending = %d, want 1", len(p.pending))
+	}
+
+	// First spike ends at t0+30s; the queued one must start right there,
+	// not at the time expire() happens to run.
+	p.expire(t0.Add(35 * time.Second))
+	if len(p.active) != 1 || len(p.pending) != 0 {
+		t.Fatalf("after expiry active=%d pending=%d, want 1/0", len(p.active), len(p.pending))
+	}
+	if want := t0.Add(30 * time.Second); !p.active[0].start.Equal(want) {
+		t.Fatalf("queued spike start = %v, want %v", p.active[0].start, want)
+	}
+	if p.active[0].factor != 4 {
+		t.Fatalf("promoted factor = %v, want 4", p.active[0].factor)
+	}
+}
+
+func TestPoissonOverlap_QueueDropsOnceFull(t *testing.T) {
+	p := newOverlapSpike(config.SpikeOverlapQueue)
+	t0 := time.Now()
+
+	p.admit(overlapSpike(3), t0)
+	for i := 0; i < MaxPendingSpikes+3; i++ {
+		p.admit(overlapSpike(3), t0.Add(time.Second))
+	}
+
+	if got := p.PendingSpikes(); got != MaxPendingSpikes {
+		t.Fatalf("PendingSpikes = %d, want the %d cap", got, MaxPendingSpikes)
+	}
+	if st := p.OverlapStats(); st.Queued != MaxPendingSpikes || st.Dropped != 3 {
+		t.Fatalf("overlap stats = %+v, want %d queued and 3 dropped", st, MaxPendingSpikes)
+	}
+}
+
+func TestPoissonOverlap_SuperimposeSumsExcess(t *testing.T) {
+	p := newOverlapSpike(config.SpikeOverlapSuperimpose)
+	t0 := time.Now()
+
+	p.admit(overlapSpike(3), t0)
+	p.admit(overlapSpike(3), t0)
+
+	if got := p.overlap.Superimposed; got != 1 {
+		t.Fatalf("Superimposed = %d, want 1", got)
+	}
+
+	// Both spikes peak together at 3x; combined excess is 2+2.
+	peak := t0.Add(10 * time.Second)
+	mult := 1.0
+	for _, s := range p.active {
+		mult += calculateSpikeMultiplier(s, peak) - 1
+	}
+	if math.Abs(mult-5) > 1e-9 {
+		t.Fatalf("superimposed peak = %v, want 5", mult)
+	}
+}
+
+func TestPoissonOverlap_ManualPreemptsUnderDrop(t *testing.T) {
+	p := newOverlapSpike(config.SpikeOverlapDrop)
+	p.admit(overlapSpike(3), time.Now())
+
+	p.TriggerManualSpike(5, time.Minute)
+
+	if !p.IsManualSpike() {
+		t.Fatal("manual spike should be active")
+	}
+	if got := p.OverlapStats().Dropped; got != 1 {
+		t.Fatalf("Dropped = %d, want 1 (displaced auto spike)", got)
+	}
+}
+
+func TestPoissonOverlap_ManualQueuesUnderQueue(t *testing.T) {
+	p := newOverlapSpike(config.SpikeOverlapQueue)
+	p.admit(overlapSpike(3), time.Now())
+
+	p.TriggerManualSpike(5, time.Minute)
+
+	if p.IsManualSpike() {
+		t.Fatal("manual spike should wait behind the running spike")
+	}
+	if got := p.PendingSpikes(); got != 1 {
+		t.Fatalf("PendingSpikes = %d, want 1", got)
+	}
+}
+
+func TestGeneratePoissonEvents_OverlapModes(t *testing.T) {
+	cfg := config.Poisson{
+		Enabled:     true,
+		Lambda:      1, // arrivals every ~1s, clamped to [1s, 2s]
+		SpikeFactor: 2,
+		MinInterval: time.Second,
+		MaxInterval: 2 * time.Second,
+		RampUp:      2 * time.Second,
+		RampDown:    3 * time.Second,
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Minute)
+
+	gen := func(mode config.SpikeOverlap) []spikeEvent {
+		c := cfg
+		c.Overlap = mode
+		return generatePoissonEvents(c, start, end, rand.New(rand.NewSource(7)))
+	}
+
+	drop := gen(config.SpikeOverlapDrop)
+	queue := gen(config.SpikeOverlapQueue)
+	super := gen(config.SpikeOverlapSuperimpose)
+
+	if len(drop) >= len(super) {
+		t.Fatalf("drop kept %d events, superimpose %d; drop should discard overlaps",
+			len(drop), len(super))
+	}
+	// 5s spikes arriving every 1-2s fill the queue, so it keeps more
+	// than drop but not every arrival.
+	if len(queue) <= len(drop) || len(queue) >= len(super) {
+		t.Fatalf("queue kept %d events, want between drop's %d and all %d arrivals",
+			len(queue), len(drop), len(super))
+	}
+	// The last one queued waits behind at most MaxPendingSpikes others:
+	// its wait is at most that many spike windows.
+	if last := queue[len(queue)-1]; last.start.After(end.Add(time.Duration(MaxPendingSpikes) * 5 * time.Second)) {
+		t.Fatalf("last queued spike starts at %s, past a full queue's wait", last.start.Sub(start))
+	}
+	for _, evs := range [][]spikeEvent{drop, queue} {
+		for i := 1; i < len(evs); i++ {
+			if evs[i].start.Before(evs[i-1].end) {
+				t.Fatalf("event %d starts before event %d ends", i, i-1)
+			}
+		}
+	}
+
+	// Superimposed spikes stack above a single spike's factor.
+	var peak float64
+	for t0 := start; t0.Before(end); t0 = t0.Add(100 * time.Millisecond) {
+		if m, _ := poissonMultiplierAt(super, t0); m > peak {
+			peak = m
+		}
+	}
+	if peak <= cfg.SpikeFactor {
+		t.Fatalf("superimposed peak = %v, want > %v", peak, cfg.SpikeFactor)
+	}
+}
+
+func TestPoissonFreeze_HoldsTimelineAndShiftsOnThaw(t *testing.T) {
+	p := newOverlapSpike(config.SpikeOverlapDrop)
+	p.admit(overlapSpike(3), time.Now().Add(-5*time.Second)) // half-way up the ramp
+	next := p.nextSpikeTime
+
+	p.Freeze()
+	before := p.Multiplier()
+	time.Sleep(20 * time.Millisecond)
+	if got := p.Multiplier(); got != before {
+		t.Fatalf("frozen multiplier moved: %v -> %v", before, got)
+	}
+
+	p.Thaw()
+	if shift := p.nextSpikeTime.Sub(next); shift < 20*time.Millisecond {
+		t.Fatalf("next arrival shifted by %v, want >= 20ms", shift)
+	}
+	if got := p.Multiplier(); math.Abs(got-before) > 0.05 {
+		t.Fatalf("multiplier after thaw = %v, want ~%v (same ramp position)", got, before)
+	}
+}
+
+func TestPoissonInitialDelay_HoldsFirstArrival(t *testing.T) {
+	cfg := config.Poisson{
+		Enabled:      true,
+		Lambda:       1,
+		SpikeFactor:  2,
+		MinInterval:  time.Second,
+		MaxInterval:  2 * time.Second,
+		InitialDelay: time.Hour,
+	}
+	p := NewPoissonSpike(cfg)
+	if in := p.NextSpikeIn(); in < time.Hour {
+		t.Fatalf("first spike in %s, want at least initial_delay", in)
+	}
+	// A run triggered later still gets the whole delay.
+	p.mu.Lock()
+	p.nextSpikeTime = time.Now()
+	p.mu.Unlock()
+	p.Start()
+	if in := p.NextSpikeIn(); in < time.Hour {
+		t.Fatalf("after Start first spike in %s, want at least initial_delay", in)
+	}
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	evs := generatePoissonEvents(cfg, start, start.Add(2*time.Hour), rand.New(rand.NewSource(7)))
+	if len(evs) == 0 || evs[0].start.Before(start.Add(time.Hour)) {
+		t.Fatalf("simulated events = %d, first must start after initial_delay", len(evs))
+	}
+}
+
+// backdate moves a generator's timeline d into the past, so the
+// arrivals of its first d fall due at the next Multiplier call.
+func backdate(p *PoissonSpike, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.origin = p.origin.Add(-d)
+	p.nextSpikeTime = p.nextSpikeTime.Add(-d)
+}
+
+func TestPoissonReplay_ReproducesRecordedSchedule(t *testing.T) {
+	rec := NewPoissonSpike(config.Poisson{
+		Enabled:     true,
+		Lambda:      1,
+		SpikeFactor: 3,
+		MinInterval: time.Second,
+		MaxInterval: 2 * time.Second,
+		RampUp:      100 * time.Millisecond,
+		RampDown:    100 * time.Millisecond,
+		Overlap:     config.SpikeOverlapSuperimpose,
+	})
+	backdate(rec, time.Minute)
+	rec.Multiplier()
+	rec.TriggerManualSpike(2, time.Second)
+	recorded := rec.Schedule()
+	if len(recorded) < 30 || !recorded[len(recorded)-1].Manual {
+		t.Fatalf("recorded %d spikes, want a minute of arrivals then the manual one", len(recorded))
+	}
+
+	// Round-trip through a file shaped like the JSON result.
+	data, err := json.Marshal(struct {
+		Spikes []SpikeEvent `json:"spikes"`
+	}{recorded})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "result.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSpikeSchedule(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, recorded) {
+		t.Fatalf("loaded schedule differs from the recorded one:\n%v\n%v", loaded, recorded)
+	}
+
+	// A generator with Poisson off replays the same arrivals.
+	rp := NewPoissonSpike(config.Poisson{Overlap: config.SpikeOverlapSuperimpose})
+	rp.Replay(loaded)
+	backdate(rp, 2*time.Minute)
+	rp.Multiplier()
+	if got := rp.Schedule(); !reflect.DeepEqual(got, recorded) {
+		t.Fatalf("replayed schedule = %d spikes, want the recorded %d verbatim", len(got), len(recorded))
+	}
+	if rp.arrivalsDue() {
+		t.Fatal("replay should be exhausted after the last recorded spike")
+	}
+}
@@ -0,0 +1,95 @@
+package pattern
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// SpikeEvent is one spike arrival on a run's timeline (#1228): when it
+// arrived, measured from the start of the run with paused time left
+// out, and the shape it had. A run's events replayed in place of the
+// Poisson draw reproduce its spike timeline exactly.
+type SpikeEvent struct {
+	At       time.Duration
+	Factor   float64
+	RampUp   time.Duration
+	RampDown time.Duration
+	Manual   bool
+}
+
+// spikeEventJSON is SpikeEvent on disk, durations written the way
+// config files write them so a schedule can be edited by hand.
+type spikeEventJSON struct {
+	At       string  `json:"at"`
+	Factor   float64 `json:"factor"`
+	RampUp   string  `json:"ramp_up"`
+	RampDown string  `json:"ramp_down"`
+	Manual   bool    `json:"manual,omitempty"`
+}
+
+func (e SpikeEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(spikeEventJSON{
+		At:       e.At.String(),
+		Factor:   e.Factor,
+		RampUp:   e.RampUp.String(),
+		RampDown: e.RampDown.String(),
+		Manual:   e.Manual,
+	})
+}
+
+func (e *SpikeEvent) UnmarshalJSON(data []byte) error {
+	var j spikeEventJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	var err error
+	parse := func(field, s string) time.Duration {
+		if s == "" || err != nil {
+			return 0
+		}
+		d, perr := time.ParseDuration(s)
+		if perr != nil {
+			err = fmt.Errorf("%s: %w", field, perr)
+		}
+		return d
+	}
+	*e = SpikeEvent{
+		At:       parse("at", j.At),
+		Factor:   j.Factor,
+		RampUp:   parse("ramp_up", j.RampUp),
+		RampDown: parse("ramp_down", j.RampDown),
+		Manual:   j.Manual,
+	}
+	return err
+}
+
+// LoadSpikeSchedule reads the spike events to replay from path: the
+// JSON result of an earlier run, whose "spikes" field is its realized
+// schedule, or a file holding just {"spikes": [...]}. Events are
+// returned in arrival order.
+func LoadSpikeSchedule(path string) ([]SpikeEvent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f struct {
+		Spikes *[]SpikeEvent `json:"spikes"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if f.Spikes == nil {
+		return nil, fmt.Errorf("%s has no \"spikes\" field", path)
+	}
+	events := *f.Spikes
+	for i, e := range events {
+		if e.At < 0 || e.Factor <= 0 || e.RampUp < 0 || e.RampDown < 0 {
+			return nil, fmt.Errorf("%s: spike %d: at and ramps must be >= 0 and factor > 0", path, i)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At < events[j].At })
+	return events, nil
+}
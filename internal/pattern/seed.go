@@ -0,0 +1,27 @@
+package pattern
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"time"
+)
+
+// DeriveSeed returns the seed of the random source called name under
+// master (#1255). Sources derived from one master draw independently,
+// and the same master and name always give the same seed.
+func DeriveSeed(master int64, name string) int64 {
+	h := fnv.New64a()
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(master))
+	h.Write(b[:])
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// masterSeed is seed, or one drawn from the clock when it's 0.
+func masterSeed(seed int64) int64 {
+	if seed == 0 {
+		return time.Now().UnixNano()
+	}
+	return seed
+}
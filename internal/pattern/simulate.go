@@ -3,6 +3,7 @@ package pattern
 import (
 	"math"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/kar98k/internal/config"
@@ -31,8 +32,9 @@ type spikeEvent struct {
 // SimulateTimeline produces a deterministic forecast of the TPS curve
 // over `duration` starting at `start`, sampled every `resolution`.
 //
-// scheduleMult maps an hour-of-day (0..23) to its multiplier; pass nil
-// for the identity multiplier. The Poisson layer uses `seed` so the
+// scheduleMult maps a point in time to its schedule multiplier, e.g.
+// the controller Scheduler's MultiplierAt; pass nil for the identity
+// multiplier. The Poisson layer uses `seed` so the
 // same seed reproduces the same spike timeline; pass 0 to seed from
 // the wall clock.
 //
@@ -44,7 +46,7 @@ type spikeEvent struct {
 func SimulateTimeline(
 	cfg config.Pattern,
 	baseTPS, maxTPS float64,
-	scheduleMult func(int) float64,
+	scheduleMult func(time.Time) float64,
 	start time.Time,
 	duration, resolution time.Duration,
 	seed int64,
@@ -56,7 +58,7 @@ func SimulateTimeline(
 		resolution = time.Minute
 	}
 	if scheduleMult == nil {
-		scheduleMult = func(int) float64 { return 1 }
+		scheduleMult = func(time.Time) float64 { return 1 }
 	}
 	if seed == 0 {
 		seed = time.Now().UnixNano()
@@ -64,13 +66,38 @@ func SimulateTimeline(
 	rng := rand.New(rand.NewSource(seed))
 
 	end := start.Add(duration)
-	events := generatePoissonEvents(cfg.Poisson, start, end, rng)
+	poisson := cfg.Poisson
+	poisson.SpikeFactor = poisson.Factor(baseTPS)
+	events := generatePoissonEvents(poisson, start, end, rng)
+
+	// Blend sources (#1247) draw their own spikes and are averaged by
+	// weight, as the engine does; PoissonMult folds the blend in.
+	shares := blendShares(cfg.Blend)
+	blendEvents := make([][]spikeEvent, len(cfg.Blend))
+	for i, b := range cfg.Blend {
+		p := b.Poisson
+		p.SpikeFactor = p.Factor(baseTPS)
+		blendEvents[i] = generatePoissonEvents(p, start, end, rng)
+	}
 
 	n := int(duration/resolution) + 1
 	out := make([]SamplePoint, 0, n)
 	for t := start; !t.After(end); t = t.Add(resolution) {
-		sched := scheduleMult(t.Hour())
+		sched := scheduleMult(t)
 		poisson, spiking := poissonMultiplierAt(events, t)
+		if len(blendEvents) > 0 {
+			var blend float64
+			for i, ev := range blendEvents {
+				m, sp := poissonMultiplierAt(ev, t)
+				blend += shares[i] * m
+				spiking = spiking || sp
+			}
+			poisson *= blend
+		}
+		// The square wave starts on at `start`; PoissonMult
+		// folds it in too.
+		sq, _, _ := SquareAt(cfg.Square, t.Sub(start))
+		poisson *= sq
 		tps := baseTPS * sched * poisson
 		if maxTPS > 0 && tps > maxTPS {
 			tps = maxTPS
@@ -94,7 +121,8 @@ func SimulateTimeline(
 // generatePoissonEvents lays spikes out along the window using inverse
 // transform sampling — same math as runtime PoissonSpike, but driven
 // by the supplied RNG instead of a wall clock so the schedule is
-// reproducible from a seed.
+// reproducible from a seed. Arrivals that land inside a running spike
+// are resolved with cfg.Overlap exactly as the runtime does (#1175).
 func generatePoissonEvents(cfg config.Poisson, start, end time.Time, rng *rand.Rand) []spikeEvent {
 	if !cfg.Enabled || cfg.Lambda <= 0 {
 		return nil
@@ -107,7 +135,8 @@ func generatePoissonEvents(cfg config.Poisson, start, end time.Time, rng *rand.R
 	rampWindow := cfg.RampUp + cfg.RampDown
 
 	var events []spikeEvent
-	cursor := start
+	var lastEnd time.Time
+	cursor := start.Add(cfg.InitialDelay)
 	for {
 		u := rng.Float64()
 		if u == 0 {
@@ -124,36 +153,63 @@ func generatePoissonEvents(cfg config.Poisson, start, end time.Time, rng *rand.R
 		if !cursor.Before(end) {
 			break
 		}
+
+		at := cursor
+		if cursor.Before(lastEnd) {
+			switch overlapMode(cfg.Overlap) {
+			case config.SpikeOverlapQueue:
+				if queuedAfter(events, cursor) >= MaxPendingSpikes {
+					continue
+				}
+				at = lastEnd
+			case config.SpikeOverlapSuperimpose:
+			default:
+				continue
+			}
+		}
 		events = append(events, spikeEvent{
-			start:  cursor,
-			peak:   cursor.Add(cfg.RampUp),
-			end:    cursor.Add(rampWindow),
+			start:  at,
+			peak:   at.Add(cfg.RampUp),
+			end:    at.Add(rampWindow),
 			factor: cfg.SpikeFactor,
 		})
-		cursor = cursor.Add(rampWindow)
+		if e := at.Add(rampWindow); e.After(lastEnd) {
+			lastEnd = e
+		}
+		if overlapMode(cfg.Overlap) == config.SpikeOverlapSequential {
+			cursor = lastEnd
+		}
 	}
 	return events
 }
 
-// poissonMultiplierAt finds whether t lands inside any spike's ramp
-// window and returns the corresponding multiplier. Events are
-// monotonic in time and never overlap (cursor advances past the ramp
-// window when scheduling), so a binary search is sufficient.
+// queuedAfter counts the events that start after t: under
+// overlap=queue, the spikes still waiting in the queue at t.
+func queuedAfter(events []spikeEvent, t time.Time) int {
+	n := 0
+	for i := len(events) - 1; i >= 0 && events[i].start.After(t); i-- {
+		n++
+	}
+	return n
+}
+
+// poissonMultiplierAt finds every spike whose ramp window covers t and
+// returns the combined multiplier. Events are sorted by start and all
+// share the same ramp window, so their ends are sorted too: binary
+// search for the last event starting at or before t, then walk back
+// while events are still running. Under sequential, drop and queue that walk is at
+// most one step; under superimpose the excesses are summed.
 func poissonMultiplierAt(events []spikeEvent, t time.Time) (float64, bool) {
-	lo, hi := 0, len(events)-1
-	for lo <= hi {
-		mid := (lo + hi) / 2
-		e := events[mid]
-		switch {
-		case t.Before(e.start):
-			hi = mid - 1
-		case !t.Before(e.end):
-			lo = mid + 1
-		default:
-			return spikeMultiplier(e, t), true
-		}
+	i := sort.Search(len(events), func(i int) bool {
+		return events[i].start.After(t)
+	}) - 1
+
+	mult, spiking := 1.0, false
+	for ; i >= 0 && t.Before(events[i].end); i-- {
+		mult += spikeMultiplier(events[i], t) - 1.0
+		spiking = true
 	}
-	return 1, false
+	return mult, spiking
 }
 
 func spikeMultiplier(e spikeEvent, t time.Time) float64 {
@@ -12,8 +12,8 @@ func TestSimulateTimeline_FollowsScheduleCurve(t *testing.T) {
 		Poisson: config.Poisson{Enabled: false},
 		Noise:   config.Noise{Enabled: false},
 	}
-	sched := func(h int) float64 {
-		if h >= 9 && h < 17 {
+	sched := func(t time.Time) float64 {
+		if h := t.Hour(); h >= 9 && h < 17 {
 			return 2.0
 		}
 		return 0.5
@@ -123,3 +123,32 @@ func TestSimulateTimeline_PhaseDefaultsEmpty(t *testing.T) {
 		}
 	}
 }
+
+func TestSimulateTimeline_SoakSpikeIsPeriodic(t *testing.T) {
+	cfg := config.Pattern{Preset: config.PresetSoakSpike, SoakSpike: config.SoakSpike{
+		SpikeFactor: 3, SpikeEvery: 15 * time.Minute, SpikeDuration: 2 * time.Minute,
+	}}
+	if err := cfg.ApplyPreset(nil); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Whatever the seed, a spike starts on every 15-minute mark.
+	for _, seed := range []int64{1, 42, 7777} {
+		var starts []time.Duration
+		wasSpiking := false
+		for _, s := range SimulateTimeline(cfg, 100, 1000, nil, start, 2*time.Hour, 10*time.Second, seed) {
+			if s.Spiking && !wasSpiking {
+				starts = append(starts, s.Time.Sub(start))
+			}
+			wasSpiking = s.Spiking
+		}
+		if len(starts) != 7 {
+			t.Fatalf("seed %d: %d spikes in 2h, want 7: %v", seed, len(starts), starts)
+		}
+		for i, at := range starts {
+			if want := time.Duration(i+1) * 15 * time.Minute; at != want && at != want+10*time.Second {
+				t.Fatalf("seed %d: spike %d at %s, want %s", seed, i, at, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package pattern
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+// Square is the pattern.square on/off duty cycle. Its timeline starts
+// on, at construction or the last Start, and stops while frozen like
+// the Poisson layer's.
+type Square struct {
+	cfg config.Square
+
+	mu       sync.Mutex
+	origin   time.Time
+	frozenAt time.Time
+}
+
+// NewSquare creates a duty cycle starting on now.
+func NewSquare(cfg config.Square) *Square {
+	return &Square{cfg: cfg, origin: time.Now()}
+}
+
+// SquareAt returns cfg's multiplier elapsed into its timeline, whether
+// that is the on phase, and how long the phase has left. A disabled or
+// degenerate cycle is always on at 1.
+func SquareAt(cfg config.Square, elapsed time.Duration) (mult float64, on bool, left time.Duration) {
+	if !cfg.Enabled || cfg.OnDuration <= 0 || cfg.OffDuration <= 0 {
+		return 1, true, 0
+	}
+	pos := elapsed % (cfg.OnDuration + cfg.OffDuration)
+	if pos < cfg.OnDuration {
+		return 1, true, cfg.OnDuration - pos
+	}
+	return cfg.OffMultiplier, false, cfg.OnDuration + cfg.OffDuration - pos
+}
+
+// Start restarts the cycle, on, at now.
+func (s *Square) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.origin = s.clock()
+}
+
+// Multiplier returns the current phase's multiplier.
+func (s *Square) Multiplier() float64 {
+	m, _, _ := s.phase()
+	return m
+}
+
+// IsOn reports whether the cycle is in its on phase. A disabled cycle
+// is always on.
+func (s *Square) IsOn() bool {
+	_, on, _ := s.phase()
+	return on
+}
+
+func (s *Square) phase() (float64, bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SquareAt(s.cfg, s.clock().Sub(s.origin))
+}
+
+// clock returns the timeline's now. Callers hold s.mu.
+func (s *Square) clock() time.Time {
+	if !s.frozenAt.IsZero() {
+		return s.frozenAt
+	}
+	return time.Now()
+}
+
+// Freeze stops the cycle where it is until Thaw.
+func (s *Square) Freeze() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.frozenAt.IsZero() {
+		s.frozenAt = time.Now()
+	}
+}
+
+// Thaw resumes a frozen cycle in the phase, and at the point, it was
+// frozen in.
+func (s *Square) Thaw() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.frozenAt.IsZero() {
+		return
+	}
+	s.origin = s.origin.Add(time.Since(s.frozenAt))
+	s.frozenAt = time.Time{}
+}
@@ -0,0 +1,142 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+// reportJSON is ReportData as WriteJSON encodes it. Latencies are
+// in milliseconds whatever report.latency prints them in.
+type reportJSON struct {
+	DurationSeconds float64                 `json:"duration_seconds"`
+	Requests        int64                   `json:"requests"`
+	Errors          int64                   `json:"errors"`
+	SuccessRate     float64                 `json:"success_rate"` // percentage
+	AvgTPS          float64                 `json:"avg_tps"`
+	PeakTPS         float64                 `json:"peak_tps"`
+	Latency         reportLatencyJSON       `json:"latency_ms"`
+	LowConfidence   []config.PercentileNote `json:"low_confidence,omitempty"`
+	StatusCodes     map[string]int64        `json:"status_codes"`
+	LatencyDist     []reportBucketJSON      `json:"latency_distribution"`
+	IntervalSeconds float64                 `json:"interval_seconds"`
+	Slots           []reportSlotJSON        `json:"slots"`
+	Metadata        *config.RunMetadata     `json:"metadata,omitempty"`
+}
+
+type reportLatencyJSON struct {
+	Min float64 `json:"min"`
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+type reportBucketJSON struct {
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+type reportSlotJSON struct {
+	Time         time.Time `json:"time"`
+	TPS          float64   `json:"tps"`
+	Requests     int64     `json:"requests"`
+	Errors       int64     `json:"errors"`
+	AvgLatencyMs float64   `json:"avg_latency_ms"`
+}
+
+// WriteJSON writes the report as one JSON object (#1261): the
+// summary, percentiles and status codes, and a row per timeline slot,
+// for CI to track between runs. A report with no requests still writes
+// the object, with empty lists.
+func WriteJSON(r ReportData, w io.Writer) error {
+	out := reportJSON{
+		DurationSeconds: r.TotalDuration.Seconds(),
+		Requests:        r.TotalRequests,
+		Errors:          r.TotalErrors,
+		SuccessRate:     r.SuccessRate,
+		AvgTPS:          r.AvgTPS,
+		PeakTPS:         r.PeakTPS,
+		Latency: reportLatencyJSON{
+			Min: r.MinLatency, Avg: r.AvgLatency, Max: r.MaxLatency,
+			P50: r.P50Latency, P95: r.P95Latency, P99: r.P99Latency,
+		},
+		LowConfidence:   r.Confidence,
+		StatusCodes:     make(map[string]int64, len(r.StatusCodes)),
+		LatencyDist:     make([]reportBucketJSON, len(r.LatencyDist)),
+		IntervalSeconds: r.Interval.Seconds(),
+		Slots:           make([]reportSlotJSON, len(r.TimeSlots)),
+	}
+	for code, n := range r.StatusCodes {
+		out.StatusCodes[strconv.Itoa(code)] = n
+	}
+	for i, b := range r.LatencyDist {
+		out.LatencyDist[i] = reportBucketJSON{b.Label, b.Count}
+	}
+	for i, s := range r.TimeSlots {
+		out.Slots[i] = reportSlotJSON{s.Time.UTC(), s.TPS, s.Requests, s.Errors, s.AvgLatency}
+	}
+	if r.Metadata.Version != "" {
+		md := r.Metadata
+		out.Metadata = &md
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// WriteCSV writes the timeline as CSV, a row per slot, followed
+// by a blank line and a metric,value summary section (#1261). A report
+// with no slots writes the header and the summary.
+func WriteCSV(r ReportData, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "tps", "requests", "errors", "avg_latency_ms"})
+	for _, s := range r.TimeSlots {
+		cw.Write([]string{
+			s.Time.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(s.TPS, 'f', 2, 64),
+			strconv.FormatInt(s.Requests, 10),
+			strconv.FormatInt(s.Errors, 10),
+			strconv.FormatFloat(s.AvgLatency, 'f', 3, 64),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return err
+	}
+
+	ms := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	summary := [][]string{
+		{"metric", "value"},
+		{"duration_seconds", strconv.FormatFloat(r.TotalDuration.Seconds(), 'f', 0, 64)},
+		{"requests", strconv.FormatInt(r.TotalRequests, 10)},
+		{"errors", strconv.FormatInt(r.TotalErrors, 10)},
+		{"success_rate", strconv.FormatFloat(r.SuccessRate, 'f', 2, 64)},
+		{"avg_tps", strconv.FormatFloat(r.AvgTPS, 'f', 2, 64)},
+		{"peak_tps", strconv.FormatFloat(r.PeakTPS, 'f', 2, 64)},
+		{"latency_min_ms", ms(r.MinLatency)},
+		{"latency_avg_ms", ms(r.AvgLatency)},
+		{"latency_max_ms", ms(r.MaxLatency)},
+		{"latency_p50_ms", ms(r.P50Latency)},
+		{"latency_p95_ms", ms(r.P95Latency)},
+		{"latency_p99_ms", ms(r.P99Latency)},
+	}
+	codes := make([]int, 0, len(r.StatusCodes))
+	for code := range r.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		summary = append(summary, []string{"status_" + strconv.Itoa(code), strconv.FormatInt(r.StatusCodes[code], 10)})
+	}
+	return cw.WriteAll(summary)
+}
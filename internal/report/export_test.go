@@ -0,0 +1,141 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+func TestWriteJSON_RoundTrip(t *testing.T) {
+	r := testReport()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := range r.TimeSlots {
+		r.TimeSlots[i].Time = start.Add(time.Duration(i) * r.Interval)
+	}
+	r.Metadata = config.RunMetadata{Version: "1.2.3", OS: "linux", Started: start}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(r, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var got reportJSON
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if got.Requests != 1500 || got.Errors != 15 || got.SuccessRate != 99 || got.DurationSeconds != 15 {
+		t.Fatalf("totals = %+v", got)
+	}
+	if got.AvgTPS != 100 || got.PeakTPS != 180 || got.IntervalSeconds != 5 {
+		t.Fatalf("tps = %v / %v every %vs", got.AvgTPS, got.PeakTPS, got.IntervalSeconds)
+	}
+	want := reportLatencyJSON{Min: 2, Avg: 35, Max: 480, P50: 21, P95: 120, P99: 310}
+	if got.Latency != want {
+		t.Fatalf("latency = %+v, want %+v", got.Latency, want)
+	}
+	if len(got.StatusCodes) != 3 || got.StatusCodes["503"] != 15 || got.StatusCodes["200"] != 1480 {
+		t.Fatalf("status codes = %v", got.StatusCodes)
+	}
+	if len(got.LatencyDist) != 3 || got.LatencyDist[1] != (reportBucketJSON{"10-25ms", 700}) {
+		t.Fatalf("distribution = %+v", got.LatencyDist)
+	}
+	if len(got.Slots) != 3 || !got.Slots[1].Time.Equal(start.Add(5*time.Second)) ||
+		got.Slots[1].Requests != 900 || got.Slots[1].Errors != 15 || got.Slots[1].AvgLatencyMs != 140 {
+		t.Fatalf("slots = %+v", got.Slots)
+	}
+	if got.Metadata == nil || got.Metadata.Version != "1.2.3" {
+		t.Fatalf("metadata = %+v", got.Metadata)
+	}
+}
+
+func TestWriteJSON_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(ReportData{}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	// CI reading the file shouldn't have to tell missing from empty.
+	for _, key := range []string{"status_codes", "latency_distribution", "slots"} {
+		if v, ok := got[key]; !ok || v == nil {
+			t.Errorf("%s = %v, want an empty list or object", key, v)
+		}
+	}
+	if _, ok := got["metadata"]; ok {
+		t.Error("metadata without a version should be left out")
+	}
+}
+
+func TestWriteCSV_RowsAndSummary(t *testing.T) {
+	r := testReport()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := range r.TimeSlots {
+		r.TimeSlots[i].Time = start.Add(time.Duration(i) * r.Interval)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(r, &buf); err != nil {
+		t.Fatal(err)
+	}
+	timeline, summary, ok := strings.Cut(buf.String(), "\n\n")
+	if !ok {
+		t.Fatalf("no blank line between the timeline and the summary:\n%s", buf.String())
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(timeline)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || strings.Join(rows[0], ",") != "time,tps,requests,errors,avg_latency_ms" {
+		t.Fatalf("timeline = %v", rows)
+	}
+	if strings.Join(rows[2], ",") != "2026-01-01T12:00:05Z,180.00,900,15,140.000" {
+		t.Fatalf("second slot = %v", rows[2])
+	}
+
+	rows, err = csv.NewReader(strings.NewReader(summary)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]string)
+	for _, row := range rows[1:] {
+		values[row[0]] = row[1]
+	}
+	for metric, want := range map[string]string{
+		"duration_seconds": "15",
+		"requests":         "1500",
+		"errors":           "15",
+		"success_rate":     "99.00",
+		"peak_tps":         "180.00",
+		"latency_p99_ms":   "310.000",
+		"status_200":       "1480",
+		"status_503":       "15",
+	} {
+		if values[metric] != want {
+			t.Errorf("%s = %q, want %q", metric, values[metric], want)
+		}
+	}
+	if rows[len(rows)-1][0] != "status_503" {
+		t.Error("status codes should close the summary in ascending order")
+	}
+}
+
+func TestWriteCSV_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(ReportData{}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "time,tps,requests,errors,avg_latency_ms\n\nmetric,value\n"
+	if !strings.HasPrefix(buf.String(), want) {
+		t.Fatalf("got:\n%s\nwant header, blank line, then the summary", buf.String())
+	}
+	if !strings.Contains(buf.String(), "requests,0\n") || strings.Contains(buf.String(), "status_") {
+		t.Fatalf("empty summary wrong:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,392 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// htmlTemplate is the report as a standalone page (#1260): inline CSS
+// and SVG, no scripts, in the look of the `kar script --report` page.
+// The shared sections come from ReportData; a `kar run` report adds
+// its run-only sections from .Run.
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>kar98k — {{if .Run}}run{{else}}test{{end}} report</title>
+<style>
+* { box-sizing: border-box; margin: 0; padding: 0; }
+body { background: #111; color: #ccc; font-family: 'Segoe UI', Roboto, monospace; font-size: 14px; padding: 24px; }
+h1 { color: #87CEEB; font-size: 22px; margin-bottom: 4px; }
+.meta { color: #666; font-size: 12px; margin-bottom: 24px; }
+.cards { display: flex; gap: 16px; flex-wrap: wrap; margin-bottom: 24px; }
+.card { background: #1a1a1a; border: 1px solid #222; border-radius: 6px; padding: 16px 20px; min-width: 140px; }
+.card-label { color: #666; font-size: 11px; text-transform: uppercase; letter-spacing: 1px; margin-bottom: 6px; }
+.card-value { color: #87CEEB; font-size: 24px; font-weight: bold; }
+section { margin-bottom: 24px; }
+h2 { color: #87CEEB; font-size: 14px; text-transform: uppercase; letter-spacing: 1px; margin-bottom: 10px; border-bottom: 1px solid #222; padding-bottom: 6px; }
+table { width: 100%; border-collapse: collapse; }
+th { text-align: left; color: #555; font-size: 11px; text-transform: uppercase; letter-spacing: 1px; padding: 6px 10px; border-bottom: 1px solid #222; }
+td { padding: 7px 10px; border-bottom: 1px solid #1e1e1e; }
+tr:last-child td { border-bottom: none; }
+.fail { color: #e05050; }
+.warn { color: #f0a050; }
+.ok { color: #50c878; }
+tr.breach td { background: #2a1515; }
+.bar { background: #87CEEB; height: 10px; border-radius: 2px; }
+</style>
+</head>
+<body>
+<h1>{{if .Run}}Run{{else}}Test{{end}} report</h1>
+<div class="meta">Duration: {{.Duration}}{{with .Metadata}}{{if not .Started.IsZero}} &nbsp;|&nbsp; {{.Started.Format "2006-01-02 15:04:05"}} → {{.Ended.Format "15:04:05"}}{{end}}{{end}}</div>
+{{with .Run}}{{with .Config}}<div class="meta">Config {{.Hash}} &nbsp;|&nbsp; base {{printf "%.0f" .BaseTPS}} / max {{printf "%.0f" .MaxTPS}} TPS &nbsp;|&nbsp; {{.Pattern}} &nbsp;|&nbsp; {{len .Targets}} target(s)</div>
+{{end}}{{end}}{{with .Metadata}}{{if .Version}}<div class="meta">kar {{.Version}}{{with .GitCommit}} ({{.}}){{end}} &nbsp;|&nbsp; {{with .Host}}{{.}} &nbsp;|&nbsp; {{end}}{{.OS}}/{{.Arch}} &nbsp;|&nbsp; {{.GoVersion}}{{range $k, $v := .Tags}} &nbsp;|&nbsp; {{$k}}={{$v}}{{end}}</div>
+{{end}}{{end}}
+<div class="cards">
+  <div class="card"><div class="card-label">Requests</div><div class="card-value">{{.TotalRequests}}</div></div>
+  <div class="card"><div class="card-label">Errors</div><div class="card-value">{{.TotalErrors}}</div></div>
+  <div class="card"><div class="card-label">Success rate</div><div class="card-value">{{printf "%.2f" .SuccessRate}}%</div></div>
+  <div class="card"><div class="card-label">TPS avg / peak</div><div class="card-value">{{printf "%.1f" .AvgTPS}} / {{printf "%.1f" .PeakTPS}}</div></div>
+{{with .Run}}  <div class="card"><div class="card-label">P95 (corrected)</div><div class="card-value">{{if .Omitted "p95"}}n/a{{else}}{{lat .P95Corr}}{{end}}</div></div>
+  <div class="card"><div class="card-label">P99 (corrected)</div><div class="card-value">{{if .Omitted "p99"}}n/a{{else}}{{lat .P99Corr}}{{end}}</div></div>
+{{end}}</div>
+<section>
+<h2>Latency</h2>
+<table>
+<tr><th>Min</th><th>Avg</th><th>Max</th><th>P50</th><th>P95</th><th>P99</th></tr>
+<tr><td>{{lat .MinLatency}}</td><td>{{lat .AvgLatency}}</td><td>{{lat .MaxLatency}}</td><td>{{.P50}}</td><td>{{.P95}}</td><td>{{.P99}}</td></tr>
+</table>
+{{if .Run}}<div class="meta">As observed; the P95 and P99 cards are corrected for coordinated omission.</div>
+{{end}}{{range .Confidence}}<div class="meta fail">{{if .Omitted}}{{.Percentile}} left out{{else}}{{.Percentile}} is low confidence{{end}}: {{.Samples}} samples, {{.Needed}} needed for a reliable figure.</div>
+{{end}}</section>
+{{with .Run}}{{with .Fidelity}}<div class="meta{{if .Low}} fail{{end}}">Delivered {{printf "%.0f" .Percent}}% of the requested load ({{printf "%.1f" .AchievedTPS}} of {{printf "%.1f" .RequestedTPS}} TPS on average){{if .Low}}: kar or the target couldn't keep up, so the figures describe a lighter load than configured{{end}}.</div>
+{{end}}{{with .SaturatedSeconds}}<div class="meta fail">The worker pool was saturated for {{.}}s: every worker busy and the queue full, so kar couldn't feed the requested TPS.</div>
+{{end}}{{with .Warmup}}<div class="meta">Latency figures exclude {{.Excluded}} warmup requests (warmup P50 {{lat .P50Ms}}, P99 {{lat .P99Ms}}).</div>
+{{end}}{{with .TLS}}<div class="meta">TLS handshakes: {{.Full}} full, {{.Resumed}} resumed{{if .Warmed}}, {{.Warmed}} hosts warmed up before the run{{end}}.</div>
+{{end}}{{end}}{{with .Chart}}
+<section>
+<h2>Timeline ({{$.Interval}} intervals)</h2>
+<svg width="100%" viewBox="0 0 {{.Width}} {{.Height}}" preserveAspectRatio="none" role="img" aria-label="TPS and latency over time">
+{{range .Bars}}<rect x="{{.X}}" y="{{.Y}}" width="{{.W}}" height="{{.H}}" fill="{{if .Slow}}#e05050{{else}}#2e5f73{{end}}"><title>{{.Title}}</title></rect>
+{{end}}{{with .Expected}}<line x1="0" x2="{{$.Chart.Width}}" y1="{{.}}" y2="{{.}}" stroke="#f0a050" stroke-dasharray="4 3"/>
+{{end}}<polyline points="{{.Latency}}" fill="none" stroke="#87CEEB" stroke-width="1.5"/>
+</svg>
+<div class="meta">Bars: TPS, up to {{printf "%.0f" .MaxTPS}}. Line: average latency, up to {{.MaxLatency}}.{{if $.ExpectedLatency}} Dashed: expected latency ({{$.ExpectedLatency}}); red bars exceed it.{{end}}</div>
+</section>
+{{end}}{{if .Dist}}
+<section>
+<h2>Latency distribution</h2>
+<table>
+<tr><th>Latency</th><th>Requests</th><th style="width:50%"></th></tr>
+{{range .Dist}}<tr><td>{{.Label}}</td><td>{{.Count}}</td><td><div class="bar" style="width:{{.Percent}}%"></div></td></tr>
+{{end}}</table>
+</section>
+{{end}}{{if .Codes}}
+<section>
+<h2>Status codes</h2>
+<table>
+<tr><th>Status</th><th>Requests</th></tr>
+{{range .Codes}}<tr><td class="{{.Class}}">{{.Code}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{with .Run}}{{if .Targets}}
+<section>
+<h2>Per-target latency</h2>
+<table>
+<tr><th>Target</th><th>Samples</th><th>P95</th><th>P99</th><th>Expected</th></tr>
+{{range .Targets}}<tr{{if .OverExpected}} class="breach"{{end}}><td>{{.Target}}</td><td>{{.Samples}}</td><td{{if .OverExpected}} class="fail"{{end}}>{{lat .P95Ms}}</td><td>{{lat .P99Ms}}</td><td>{{if .ExpectedMs}}{{lat .ExpectedMs}}{{else}}—{{end}}</td></tr>
+{{end}}</table>
+<table>
+<tr><th></th><th>P95</th><th>P99</th></tr>
+<tr><td>Pooled</td><td>{{lat .P95Raw}}</td><td>{{lat .P99Raw}}</td></tr>
+<tr><td>Per-target average</td><td>{{lat .P95TargetAvg}}</td><td>{{lat .P99TargetAvg}}</td></tr>
+</table>
+<div class="meta">Pooled percentiles weigh every request equally, so the busiest target dominates them; the per-target average weighs every target equally, so a slow low-traffic endpoint shows.</div>
+</section>
+{{end}}{{if .Segments}}
+<section>
+<h2>Segments</h2>
+<table>
+<tr><th>Window</th><th>Requests</th><th>Errors</th><th>P50</th><th>P95</th><th>P99</th><th>SLO</th></tr>
+{{range .Segments}}<tr{{if .Breaches}} class="breach"{{end}}><td>{{.Start.Format "15:04"}}–{{.End.Format "15:04"}}</td><td>{{.Requests}}</td><td>{{.Errors}} ({{printf "%.2f" .ErrorRate}}%)</td><td>{{lat .P50Ms}}</td><td>{{lat .P95Ms}}</td><td>{{lat .P99Ms}}</td><td>{{if .Breaches}}<span class="fail">{{range $i, $b := .Breaches}}{{if $i}}, {{end}}{{$b}}{{end}}</span>{{else}}ok{{end}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{if .Stages}}
+<section>
+<h2>Stages</h2>
+<table>
+<tr><th>Stage</th><th>Requests</th><th>Errors</th><th>P50</th><th>P95</th><th>P99</th><th>SLO</th></tr>
+{{range .Stages}}<tr{{if not .Passed}} class="breach"{{end}}><td>{{.Name}}</td><td>{{.Requests}}</td><td>{{.Errors}} ({{printf "%.2f" .ErrorRate}}%)</td><td>{{lat .P50Ms}}</td><td>{{lat .P95Ms}}</td><td>{{lat .P99Ms}}</td><td>{{if .Breaches}}<span class="fail">{{range $i, $b := .Breaches}}{{if $i}}, {{end}}{{$b}}{{end}}</span>{{else if .SLO}}ok{{else}}—{{end}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{if .Slowest}}
+<section>
+<h2>Slowest requests</h2>
+<table>
+<tr><th>Time</th><th>Target</th><th>Request</th><th>Status</th><th>Duration</th></tr>
+{{range .Slowest}}<tr><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Target}}{{if .Spec}} / {{.Spec}}{{end}}</td><td>{{.Method}} {{.URL}}</td><td>{{if .Error}}<span class="fail">{{.Error}}</span>{{else}}{{.StatusText}}{{end}}</td><td>{{lat .DurationMs}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{range .SizeLatency}}
+<section>
+<h2>Latency by {{.By}} size: {{.Target}}</h2>
+<p>{{.Samples}} requests, correlation {{printf "%.2f" .Correlation}}</p>
+<table>
+<tr><th>Size</th><th>Requests</th><th>P50</th><th>P95</th><th>P99</th></tr>
+{{range .Buckets}}<tr><td>{{.Label}}</td><td>{{.Requests}}</td><td>{{lat .P50Ms}}</td><td>{{lat .P95Ms}}</td><td>{{lat .P99Ms}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{with .GCImpact}}
+<section>
+<h2>kar GC impact</h2>
+<table>
+<tr><th>GC pauses</th><th>Pause total</th><th>Max pause</th><th>Overlapped requests</th><th>Self-inflicted latency</th></tr>
+<tr><td>{{.Pauses}}</td><td>{{lat .PauseTotalMs}}</td><td>{{lat .PauseMaxMs}}</td><td>{{.Overlapped}} ({{printf "%.2f" .OverlappedPct}}%)</td><td>{{lat .SelfInflictedMs}}</td></tr>
+</table>
+</section>
+{{end}}{{if .Cache}}
+<section>
+<h2>Cache hits and misses</h2>
+<table>
+<tr><th>Target</th><th>Varied</th><th>Hits</th><th>Misses</th><th>Hit rate</th><th>Hit P50 / P95 / P99</th><th>Miss P50 / P95 / P99</th></tr>
+{{range .Cache}}<tr><td>{{.Target}}</td><td>{{.Varied}}</td><td>{{.Hits}}</td><td>{{.Misses}}</td><td>{{printf "%.1f" .HitPct}}%</td><td>{{lat .HitP50Ms}} / {{lat .HitP95Ms}} / {{lat .HitP99Ms}}</td><td>{{lat .MissP50Ms}} / {{lat .MissP95Ms}} / {{lat .MissP99Ms}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{if .LongPoll}}
+<section>
+<h2>Long polls</h2>
+<table>
+<tr><th>Target</th><th>Polls</th><th>Responded</th><th>Expired</th><th>Errors</th><th>Hold P50 / P95 / P99</th><th>Max hold</th></tr>
+{{range .LongPoll}}<tr><td>{{.Target}}</td><td>{{.Polls}}</td><td>{{.Responded}}</td><td>{{.Expired}}</td><td>{{.Errors}}</td><td>{{lat .HoldP50Ms}} / {{lat .HoldP95Ms}} / {{lat .HoldP99Ms}}</td><td>{{lat .HoldMaxMs}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{if .Duplicates}}
+<section>
+<h2>Duplicate responses</h2>
+<table>
+<tr><th>Target</th><th>Compared</th><th>Duplicates</th><th>Marked</th><th>Missing</th><th>Untracked</th><th>Examples</th></tr>
+{{range .Duplicates}}<tr><td>{{.Target}}</td><td>{{.Checked}}</td><td{{if .Duplicates}} class="fail"{{end}}>{{.Duplicates}}</td><td{{if .Markers}} class="fail"{{end}}>{{.Markers}}</td><td>{{.Missing}}</td><td>{{.Untracked}}</td><td>{{range $i, $e := .Examples}}{{if $i}}, {{end}}{{$e}}{{end}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{with .ErrorMatrix}}
+<section>
+<h2>Errors by target</h2>
+<table>
+<tr><th>Target</th><th>Errors</th>{{range .Classes}}<th>{{.}}</th>{{end}}</tr>
+{{$classes := .Classes}}{{range .Rows}}{{$counts := .Counts}}<tr><td>{{.Target}}</td><td>{{.Errors}}</td>{{range $classes}}<td>{{with index $counts .}}{{.}}{{else}}—{{end}}</td>{{end}}</tr>
+{{end}}</table>
+</section>
+{{end}}{{if .Specs}}
+<section>
+<h2>Request specs</h2>
+<table>
+<tr><th>Target</th><th>Spec</th><th>Requests</th><th>Errors</th></tr>
+{{range .Specs}}<tr><td>{{.Target}}</td><td>{{.Spec}}</td><td>{{.Requests}}</td><td>{{.Errors}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{if .Intent}}
+<section>
+<h2>Intent check</h2>
+<table>
+<tr><th>Check</th><th>Expected</th><th>Observed</th><th></th></tr>
+{{range .Intent}}<tr><td class="fail">{{.Check}}</td><td>{{.Expected}}</td><td>{{.Observed}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{if .PostChecks}}
+<section>
+<h2>Post checks</h2>
+<table>
+<tr><th>Check</th><th>URL</th><th>Status</th><th>Result</th></tr>
+{{range .PostChecks}}<tr{{if not .Passed}} class="breach"{{end}}><td>{{.Name}}</td><td>{{.URL}}</td><td>{{if .Status}}{{.Status}}{{else}}—{{end}}</td><td>{{if .Passed}}ok{{else}}<span class="fail">{{range $i, $f := .Failures}}{{if $i}}; {{end}}{{$f}}{{end}}</span>{{end}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{end}}{{if .Slots}}
+<section>
+<h2>Timeline detail</h2>
+<table>
+<tr><th>Time</th><th>TPS</th><th>Requests</th><th>Errors</th><th>Avg latency</th></tr>
+{{range .Slots}}<tr{{if .Slow}} class="breach"{{end}}><td>{{.Range}}{{if .Spike}} <span class="warn">spike</span>{{end}}</td><td>{{printf "%.0f" .TPS}}</td><td>{{.Requests}}</td><td{{if .Errors}} class="fail"{{end}}>{{.Errors}}</td><td>{{.Latency}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}
+</body>
+</html>
+`
+
+// htmlTmpl's lat func is replaced per report with the report's latency
+// format.
+var htmlTmpl = template.Must(template.New("report").
+	Funcs(template.FuncMap{"lat": func(float64) string { return "" }}).
+	Parse(htmlTemplate))
+
+// Chart geometry, in SVG user units.
+const (
+	chartWidth  = 960
+	chartHeight = 240
+)
+
+// htmlReport is ReportData laid out for htmlTemplate.
+type htmlReport struct {
+	ReportData
+	Duration      string
+	P50, P95, P99 string
+	Chart         *htmlChart
+	Dist          []htmlBar
+	Codes         []htmlCode
+	Slots         []htmlSlot
+}
+
+type htmlChart struct {
+	Width, Height int
+	Bars          []htmlChartBar
+	// Latency is the average latency polyline's points.
+	Latency    string
+	Expected   float64 // y of the expected latency line, 0 for none
+	MaxTPS     float64
+	MaxLatency string
+}
+
+type htmlChartBar struct {
+	X, Y, W, H float64
+	Title      string
+	Slow       bool
+}
+
+type htmlBar struct {
+	Label   string
+	Count   int64
+	Percent float64
+}
+
+type htmlCode struct {
+	Code  int
+	Count int64
+	Class string
+}
+
+type htmlSlot struct {
+	Range    string
+	TPS      float64
+	Requests int64
+	Errors   int64
+	Latency  string
+	Spike    bool
+	Slow     bool
+}
+
+// WriteHTML writes the report to path as a self-contained HTML page
+// (#1260): overview, percentiles, the latency histogram, status codes
+// and the timeline, charted in inline SVG, then the run-only sections
+// of r.Run when set.
+func WriteHTML(r ReportData, path string) error {
+	tmpl, err := htmlTmpl.Clone()
+	if err != nil {
+		return err
+	}
+	tmpl.Funcs(template.FuncMap{"lat": r.Latency.Format})
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(f, newHTMLReport(r)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func newHTMLReport(r ReportData) htmlReport {
+	lat := r.Latency.Format
+	h := htmlReport{
+		ReportData: r,
+		Duration:   r.TotalDuration.Round(time.Second).String(),
+		P50:        r.Percentile("p50", r.P50Latency),
+		P95:        r.Percentile("p95", r.P95Latency),
+		P99:        r.Percentile("p99", r.P99Latency),
+	}
+
+	var maxCount int64 = 1
+	for _, b := range r.LatencyDist {
+		maxCount = max(maxCount, b.Count)
+	}
+	for _, b := range r.LatencyDist {
+		h.Dist = append(h.Dist, htmlBar{b.Label, b.Count, float64(b.Count) / float64(maxCount) * 100})
+	}
+
+	for code, n := range r.StatusCodes {
+		class := "fail"
+		switch {
+		case code >= 200 && code < 400:
+			class = "ok"
+		case code >= 400 && code < 500:
+			class = "warn"
+		}
+		h.Codes = append(h.Codes, htmlCode{code, n, class})
+	}
+	sort.Slice(h.Codes, func(i, j int) bool { return h.Codes[i].Code < h.Codes[j].Code })
+
+	if len(r.TimeSlots) == 0 {
+		return h
+	}
+	var sumTPS float64
+	for _, s := range r.TimeSlots {
+		sumTPS += s.TPS
+	}
+	avgTPS := sumTPS / float64(len(r.TimeSlots))
+	for i, s := range r.TimeSlots {
+		h.Slots = append(h.Slots, htmlSlot{
+			Range:    SlotRange(i, r.Interval),
+			TPS:      s.TPS,
+			Requests: s.Requests,
+			Errors:   s.Errors,
+			Latency:  lat(s.AvgLatency),
+			Spike:    s.TPS > avgTPS*1.5,
+			Slow:     OverExpected(s.AvgLatency, r.ExpectedLatency),
+		})
+	}
+	h.Chart = newHTMLChart(r)
+	return h
+}
+
+// newHTMLChart draws the timeline: a TPS bar per slot, and the average
+// latency as a line on its own scale.
+func newHTMLChart(r ReportData) *htmlChart {
+	c := &htmlChart{Width: chartWidth, Height: chartHeight}
+	expectedMs := float64(r.ExpectedLatency) / float64(time.Millisecond)
+	maxLat := expectedMs
+	for _, s := range r.TimeSlots {
+		c.MaxTPS = max(c.MaxTPS, s.TPS)
+		maxLat = max(maxLat, s.AvgLatency)
+	}
+	tpsScale, latScale := 0.0, 0.0
+	if c.MaxTPS > 0 {
+		tpsScale = chartHeight / c.MaxTPS
+	}
+	if maxLat > 0 {
+		latScale = chartHeight / maxLat
+	}
+	c.MaxLatency = r.Latency.Format(maxLat)
+	if expectedMs > 0 {
+		c.Expected = chartHeight - expectedMs*latScale
+	}
+
+	w := float64(chartWidth) / float64(len(r.TimeSlots))
+	points := make([]string, len(r.TimeSlots))
+	for i, s := range r.TimeSlots {
+		x := float64(i) * w
+		bh := s.TPS * tpsScale
+		c.Bars = append(c.Bars, htmlChartBar{
+			X: x, Y: chartHeight - bh, W: max(w-1, 1), H: bh,
+			Title: fmt.Sprintf("%s: %.0f TPS, %s", SlotRange(i, r.Interval), s.TPS, r.Latency.Format(s.AvgLatency)),
+			Slow:  OverExpected(s.AvgLatency, r.ExpectedLatency),
+		})
+		points[i] = fmt.Sprintf("%.1f,%.1f", x+w/2, chartHeight-s.AvgLatency*latScale)
+	}
+	c.Latency = strings.Join(points, " ")
+	return c
+}
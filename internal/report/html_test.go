@@ -0,0 +1,120 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+func testReport() ReportData {
+	return ReportData{
+		TotalRequests:   1500,
+		TotalErrors:     15,
+		TotalDuration:   15 * time.Second,
+		AvgTPS:          100,
+		PeakTPS:         180,
+		MinLatency:      2,
+		MaxLatency:      480,
+		AvgLatency:      35,
+		P50Latency:      21,
+		P95Latency:      120,
+		P99Latency:      310,
+		SuccessRate:     99,
+		Interval:        5 * time.Second,
+		ExpectedLatency: 100 * time.Millisecond,
+		TimeSlots: []TimeSlot{
+			{TPS: 60, Requests: 300, AvgLatency: 20},
+			{TPS: 180, Requests: 900, Errors: 15, AvgLatency: 140},
+			{TPS: 60, Requests: 300, AvgLatency: 25},
+		},
+		LatencyDist: []LatencyBucket{{"<10ms", 400}, {"10-25ms", 700}, {">250ms", 20}},
+		StatusCodes: map[int]int64{200: 1480, 404: 5, 503: 15},
+	}
+}
+
+func writeTestHTML(t *testing.T, r ReportData) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "report.html")
+	if err := WriteHTML(r, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWriteHTML_Sections(t *testing.T) {
+	html := writeTestHTML(t, testReport())
+
+	for _, want := range []string{
+		// stats
+		">1500<", ">15<", "99.00%", "100.0 / 180.0",
+		"<td>2.00ms</td>", "<td>480.00ms</td>", "<td>21.00ms</td>", "<td>120.00ms</td>", "<td>310.00ms</td>",
+		// status codes, classed by family
+		`<td class="ok">200</td><td>1480</td>`,
+		`<td class="warn">404</td><td>5</td>`,
+		`<td class="fail">503</td><td>15</td>`,
+		// latency distribution
+		"<td>10-25ms</td><td>700</td>",
+		// timeline detail
+		"00:00-00:05", "00:05-00:10", "00:10-00:15",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	if strings.Index(html, ">200<") > strings.Index(html, ">404<") {
+		t.Error("status codes should be sorted")
+	}
+}
+
+func TestWriteHTML_Chart(t *testing.T) {
+	html := writeTestHTML(t, testReport())
+
+	if !strings.Contains(html, "<svg") || strings.Count(html, "<rect") != 3 {
+		t.Fatalf("chart should draw one bar per slot:\n%s", html)
+	}
+	// Only the middle slot is over the 100ms expected latency.
+	if strings.Count(html, `fill="#e05050"`) != 1 || strings.Count(html, `class="breach"`) != 1 {
+		t.Fatal("chart and timeline should flag exactly the slow slot")
+	}
+	if !strings.Contains(html, "stroke-dasharray") || !strings.Contains(html, "<polyline") {
+		t.Fatal("chart missing the expected latency line or the latency polyline")
+	}
+	// The middle slot's 180 TPS is over 1.5x the 100 TPS average.
+	if strings.Count(html, ">spike<") != 1 {
+		t.Fatal("timeline should mark the one spike slot")
+	}
+}
+
+func TestWriteHTML_Empty(t *testing.T) {
+	html := writeTestHTML(t, ReportData{})
+
+	if !strings.Contains(html, "Test report") {
+		t.Fatal("an empty report should still render")
+	}
+	for _, absent := range []string{"<svg", "Status codes", "Timeline detail", "Latency distribution"} {
+		if strings.Contains(html, absent) {
+			t.Errorf("empty report shouldn't have %q", absent)
+		}
+	}
+}
+
+func TestWriteHTML_OmitsLowConfidencePercentiles(t *testing.T) {
+	r := testReport()
+	r.Confidence = config.Report{}.PercentileNotes(50, 95, 99)
+	html := writeTestHTML(t, r)
+
+	if !strings.Contains(html, "<td>120.00ms</td>") || strings.Contains(html, "310.00ms") {
+		t.Fatal("P95 from 50 samples should print, P99 should not")
+	}
+	if !strings.Contains(html, "p99 left out: 50 samples") {
+		t.Fatal("report should explain the omitted percentile")
+	}
+}
@@ -0,0 +1,142 @@
+// Package report renders a run's report: the figures the TUI's report
+// screen shows, written out as HTML, JSON or CSV (#1260) so they
+// outlive the session. `kar start` and the daemon's html sink both
+// render through it.
+package report
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+// TimeSlot represents stats for a specific time period
+type TimeSlot struct {
+	Time       time.Time
+	TPS        float64
+	Requests   int64
+	Errors     int64
+	AvgLatency float64
+}
+
+// LatencyBucket represents a latency distribution bucket
+type LatencyBucket struct {
+	Label string
+	Count int64
+}
+
+// ReportData holds all data for the final report
+type ReportData struct {
+	// Overall stats
+	TotalRequests int64
+	TotalErrors   int64
+	TotalDuration time.Duration
+	AvgTPS        float64
+	PeakTPS       float64
+	MinLatency    float64
+	MaxLatency    float64
+	AvgLatency    float64
+	P50Latency    float64
+	P95Latency    float64
+	P99Latency    float64
+	SuccessRate   float64
+
+	// Time series data (for graph), one slot per Interval
+	TimeSlots []TimeSlot
+	Interval  time.Duration
+
+	// ExpectedLatency is the target's expected_latency (#1209); slots
+	// whose latency exceeds it are flagged. 0 = no reference.
+	ExpectedLatency time.Duration
+
+	// Latency is the unit and precision latencies print with (#1213).
+	Latency config.LatencyFormat
+
+	// Latency distribution
+	LatencyDist []LatencyBucket
+
+	// Status code distribution
+	StatusCodes map[int]int64
+
+	// Metadata names the kar build, host and tags behind the session
+	// (#1236).
+	Metadata config.RunMetadata
+
+	// Confidence notes percentiles computed from too few samples to
+	// trust (#1240); the Omitted ones print as n/a.
+	Confidence []config.PercentileNote
+
+	// Run is the daemon's full result, an *output.Result, when the
+	// report comes from a `kar run`: WriteHTML follows the shared
+	// sections with its run-only ones (segments, stages, slowest
+	// requests, ...). Nil for a `kar start` session.
+	Run any
+}
+
+// Percentile formats the report's percentile p ("p95") at ms, or n/a
+// when it had too few samples to print.
+func (r ReportData) Percentile(p string, ms float64) string {
+	for _, n := range r.Confidence {
+		if n.Percentile == p && n.Omitted {
+			return "n/a"
+		}
+	}
+	return r.Latency.Format(ms)
+}
+
+// ConfidenceLines describes each entry of Confidence in a sentence.
+func (r ReportData) ConfidenceLines() []string {
+	out := make([]string, len(r.Confidence))
+	for i, n := range r.Confidence {
+		what := "is low confidence"
+		if n.Omitted {
+			what = "left out"
+		}
+		out[i] = fmt.Sprintf("%s %s: %d samples, %d needed", strings.ToUpper(n.Percentile), what, n.Samples, n.Needed)
+	}
+	return out
+}
+
+// OverExpected reports whether latencyMs exceeds the expected latency.
+func OverExpected(latencyMs float64, expected time.Duration) bool {
+	return expected > 0 && latencyMs > float64(expected)/float64(time.Millisecond)
+}
+
+// SlotRange labels slot i as MM:SS-MM:SS, or in seconds with one
+// decimal for sub-second intervals.
+func SlotRange(i int, interval time.Duration) string {
+	start := time.Duration(i) * interval
+	end := start + interval
+	if interval%time.Second != 0 {
+		return fmt.Sprintf("%5.1f-%5.1fs", start.Seconds(), end.Seconds())
+	}
+	s, e := int(start.Seconds()), int(end.Seconds())
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", s/60, s%60, e/60, e%60)
+}
+
+// LatencyBuckets are the report histogram's buckets, by upper bound
+// in ms; the last is open-ended.
+var LatencyBuckets = [...]struct {
+	Label string
+	Below float64
+}{
+	{"<10ms", 10},
+	{"10-25ms", 25},
+	{"25-50ms", 50},
+	{"50-100ms", 100},
+	{"100-250ms", 250},
+	{">250ms", math.Inf(1)},
+}
+
+// BucketOf returns the index of the bucket ms falls in.
+func BucketOf(ms float64) int {
+	for i, b := range LatencyBuckets {
+		if ms < b.Below {
+			return i
+		}
+	}
+	return len(LatencyBuckets) - 1
+}
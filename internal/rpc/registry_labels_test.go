@@ -60,7 +60,7 @@ func gatherWorkerIDs(t *testing.T, m *health.Metrics) map[string]struct{} {
 // newTestMetrics returns a Metrics instance backed by a fresh registry so
 // tests never collide on global Prometheus state.
 func newTestMetrics() *health.Metrics {
-	return health.NewMetricsWithRegistry(prometheus.NewRegistry())
+	return health.NewMetrics(prometheus.NewRegistry())
 }
 
 // TestPerWorkerLabels_ThreeWorkers registers 3 workers, pushes a StatsPush
@@ -1,11 +1,15 @@
 package script
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"math"
 	"os"
+	"strconv"
+	"strings"
 "sync/atomic"
 	"time"
 )
@@ -274,3 +278,178 @@ func ExportJUnit(path string, runner Runner, elapsed time.Duration) error {
 
 	return nil
 }
+
+// k6Summary mirrors the JSON written by `k6 run --summary-export`, so
+// pipelines that already ingest k6 summaries can read kar results
+// unchanged. Only the metrics kar actually measures are emitted; see
+// ExportK6 for the field mapping.
+type k6Summary struct {
+	RootGroup k6Group                   `json:"root_group"`
+	Metrics   map[string]map[string]any `json:"metrics"`
+}
+
+type k6Group struct {
+	Name   string             `json:"name"`
+	Path   string             `json:"path"`
+	ID     string             `json:"id"`
+	Groups map[string]k6Group `json:"groups"`
+	Checks map[string]k6Check `json:"checks"`
+}
+
+type k6Check struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	ID     string `json:"id"`
+	Passes int64  `json:"passes"`
+	Fails  int64  `json:"fails"`
+}
+
+// ExportK6 writes results in k6's --summary-export JSON schema.
+//
+// Mapping (kar → k6):
+//   - latency histogram → http_req_duration {avg,min,med,max,p(90),p(95),p(99)} in ms
+//   - total requests / elapsed → http_reqs {count,rate}
+//   - errors → http_req_failed {passes,fails,value}; k6 counts a
+//     failed request as a "pass" of this rate metric, so passes = errors
+//   - checks → root_group.checks and the aggregate checks {passes,fails,value}
+//   - thresholds → "thresholds" on the owning metric, true meaning the
+//     threshold was crossed (k6's legacy convention)
+//
+// Not populated, because kar does not measure them: the http_req_*
+// timing breakdown (blocked, connecting, tls_handshaking, sending,
+// waiting, receiving), data_sent/data_received, iterations, vus and
+// per-tag submetrics. Groups are always empty.
+func ExportK6(path string, runner Runner, elapsed time.Duration) error {
+	m := runner.Metrics()
+	sc := runner.Scenario()
+
+	totalReqs := atomic.LoadInt64(&m.TotalRequests)
+	totalErrs := atomic.LoadInt64(&m.TotalErrors)
+
+	rate := 0.0
+	if elapsed.Seconds() > 0 {
+		rate = float64(totalReqs) / elapsed.Seconds()
+	}
+
+	toMS := func(us float64) float64 { return us / 1000 }
+	duration := map[string]any{}
+	m.mu.Lock()
+	if m.Histogram.TotalCount() > 0 {
+		duration["avg"] = toMS(m.Histogram.Mean())
+		duration["min"] = toMS(float64(m.Histogram.Min()))
+		duration["med"] = toMS(float64(m.Histogram.ValueAtPercentile(50)))
+		duration["max"] = toMS(float64(m.Histogram.Max()))
+		duration["p(90)"] = toMS(float64(m.Histogram.ValueAtPercentile(90)))
+		duration["p(95)"] = toMS(float64(m.Histogram.ValueAtPercentile(95)))
+		duration["p(99)"] = toMS(float64(m.Histogram.ValueAtPercentile(99)))
+	}
+	checks := make([]CheckResult, len(m.Checks))
+	copy(checks, m.Checks)
+	m.mu.Unlock()
+
+	failedRate := 0.0
+	if totalReqs > 0 {
+		failedRate = float64(totalErrs) / float64(totalReqs)
+	}
+
+	root := k6Group{
+		Name:   "",
+		Path:   "",
+		ID:     k6ID(""),
+		Groups: map[string]k6Group{},
+		Checks: make(map[string]k6Check, len(checks)),
+	}
+	var checkPasses, checkFails int64
+	for _, c := range checks {
+		root.Checks[c.Name] = k6Check{
+			Name:   c.Name,
+			Path:   "::" + c.Name,
+			ID:     k6ID("::" + c.Name),
+			Passes: c.Passed,
+			Fails:  c.Failed,
+		}
+		checkPasses += c.Passed
+		checkFails += c.Failed
+	}
+
+	metrics := map[string]map[string]any{
+		"http_req_duration": duration,
+		"http_reqs": {
+			"count": totalReqs,
+			"rate":  rate,
+		},
+		"http_req_failed": {
+			"passes": totalErrs,
+			"fails":  totalReqs - totalErrs,
+			"value":  failedRate,
+		},
+	}
+	if len(checks) > 0 {
+		checkRate := 0.0
+		if total := checkPasses + checkFails; total > 0 {
+			checkRate = float64(checkPasses) / float64(total)
+		}
+		metrics["checks"] = map[string]any{
+			"passes": checkPasses,
+			"fails":  checkFails,
+			"value":  checkRate,
+		}
+	}
+
+	for metric, condition := range sc.Thresholds {
+		name, expr := k6Threshold(metric, condition)
+		entry, ok := metrics[name]
+		if !ok {
+			continue
+		}
+		th, _ := entry["thresholds"].(map[string]bool)
+		if th == nil {
+			th = make(map[string]bool)
+			entry["thresholds"] = th
+		}
+		th[expr] = !evaluateThreshold(metric, condition, m)
+	}
+
+	data, err := json.MarshalIndent(k6Summary{RootGroup: root, Metrics: metrics}, "", "    ")
+	if err != nil {
+		return fmt.Errorf("marshalling k6 summary: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing k6 summary to %s: %w", path, err)
+	}
+	return nil
+}
+
+// k6Threshold converts a kar threshold key/condition into k6's metric
+// name and expression: "http_req_duration{p95}" + "< 500ms" becomes
+// "http_req_duration" + "p(95)<500" (k6 compares durations as plain
+// milliseconds); rate metrics get a "rate" prefix.
+func k6Threshold(metric, condition string) (name, expr string) {
+	name = metric
+	agg := ""
+	if i := strings.IndexByte(metric, '{'); i >= 0 {
+		name = metric[:i]
+		agg = strings.Trim(metric[i:], "{}")
+	}
+	switch {
+	case name == "http_req_failed" || name == "checks":
+		agg = "rate"
+	case strings.HasPrefix(agg, "p") && !strings.HasPrefix(agg, "p("):
+		agg = "p(" + agg[1:] + ")"
+	}
+
+	var op, value string
+	if _, err := fmt.Sscanf(condition, "%s %s", &op, &value); err != nil {
+		return name, agg + strings.ReplaceAll(condition, " ", "")
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		value = strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+	}
+	return name, agg + op + value
+}
+
+// k6ID reproduces k6's group/check identifier: the hex MD5 of the path.
+func k6ID(path string) string {
+	sum := md5.Sum([]byte(path))
+	return hex.EncodeToString(sum[:])
+}
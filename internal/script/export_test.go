@@ -0,0 +1,70 @@
+package script
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExportK6_MapsSummarySchema(t *testing.T) {
+	r := newPopulatedRunner(t)
+	r.metrics.Checks = []CheckResult{{Name: "status is 200", Passed: 500, Failed: 30}}
+	r.scenario.Thresholds = map[string]string{
+		"http_req_duration{p95}": "< 100ms",
+		"http_req_failed":        "< 0.01",
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := ExportK6(path, r, time.Minute); err != nil {
+		t.Fatalf("ExportK6: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read summary: %v", err)
+	}
+
+	var got struct {
+		RootGroup struct {
+			Checks map[string]struct {
+				Path   string `json:"path"`
+				Passes int64  `json:"passes"`
+				Fails  int64  `json:"fails"`
+			} `json:"checks"`
+		} `json:"root_group"`
+		Metrics map[string]map[string]any `json:"metrics"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	reqs := got.Metrics["http_reqs"]
+	if reqs["count"].(float64) != float64(r.metrics.TotalRequests) {
+		t.Fatalf("http_reqs.count = %v, want %d", reqs["count"], r.metrics.TotalRequests)
+	}
+	failed := got.Metrics["http_req_failed"]
+	if failed["passes"].(float64) != float64(r.metrics.TotalErrors) {
+		t.Fatalf("http_req_failed.passes = %v, want %d errors", failed["passes"], r.metrics.TotalErrors)
+	}
+	dur := got.Metrics["http_req_duration"]
+	for _, k := range []string{"avg", "min", "med", "max", "p(90)", "p(95)"} {
+		if _, ok := dur[k]; !ok {
+			t.Fatalf("http_req_duration missing %q: %v", k, dur)
+		}
+	}
+
+	// 25 errors out of 530 is ~4.7% — the failed-rate threshold is crossed.
+	th, ok := failed["thresholds"].(map[string]any)
+	if !ok || th["rate<0.01"] != true {
+		t.Fatalf("http_req_failed thresholds = %v, want rate<0.01 crossed", failed["thresholds"])
+	}
+	if th, ok := dur["thresholds"].(map[string]any); !ok || th["p(95)<100"] == nil {
+		t.Fatalf("http_req_duration thresholds = %v, want p(95)<100", dur["thresholds"])
+	}
+
+	c, ok := got.RootGroup.Checks["status is 200"]
+	if !ok || c.Passes != 500 || c.Fails != 30 || c.Path != "::status is 200" {
+		t.Fatalf("root_group check = %+v", c)
+	}
+}
@@ -19,6 +19,10 @@ type Picker struct {
 
 	mu  sync.Mutex
 	rng *rand.Rand
+	// factors scales each target's weight, by index, under adaptive
+	// weighting (#1234); nil picks by the configured weights alone.
+	factors []float64
+	scaled  float64
 }
 
 // New builds a Picker over the supplied targets, summing positive
@@ -56,6 +60,11 @@ func (p *Picker) Pick() *config.Target {
 	}
 
 	p.mu.Lock()
+	if p.factors != nil {
+		t := p.pickScaled()
+		p.mu.Unlock()
+		return t
+	}
 	r := p.rng.Intn(p.totalWeight)
 	p.mu.Unlock()
 
@@ -80,6 +89,174 @@ func (p *Picker) Pick() *config.Target {
 	return nil
 }
 
+// pickScaled is Pick over weight × factor. Callers hold p.mu.
+func (p *Picker) pickScaled() *config.Target {
+	r := p.rng.Float64() * p.scaled
+	var last *config.Target
+	for i := range p.targets {
+		if p.targets[i].Weight <= 0 {
+			continue
+		}
+		w := float64(p.targets[i].Weight) * p.factors[i]
+		if w <= 0 {
+			continue
+		}
+		last = &p.targets[i]
+		if r < w {
+			return last
+		}
+		r -= w
+	}
+	// Why: float rounding can leave r just past the final bucket.
+	return last
+}
+
+// SetFactors scales each named target's weight by its factor from the
+// next Pick on (#1234). Targets missing from factors keep factor 1; an
+// empty or nil map restores the configured weights. Factors of zero
+// or below are treated as 1 too — weight 0 is how a config switches a
+// target off, and a factor must not do that behind its back.
+func (p *Picker) SetFactors(factors map[string]float64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(factors) == 0 {
+		p.factors, p.scaled = nil, 0
+		return
+	}
+	fs := make([]float64, len(p.targets))
+	var scaled float64
+	for i, t := range p.targets {
+		f, ok := factors[t.Name]
+		if !ok || f <= 0 {
+			f = 1
+		}
+		fs[i] = f
+		if t.Weight > 0 {
+			scaled += float64(t.Weight) * f
+		}
+	}
+	p.factors, p.scaled = fs, scaled
+}
+
+// Factors returns the factors SetFactors installed, by target name;
+// nil when picking by the configured weights alone.
+func (p *Picker) Factors() map[string]float64 {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.factors == nil {
+		return nil
+	}
+	out := make(map[string]float64, len(p.targets))
+	for i, t := range p.targets {
+		out[t.Name] = p.factors[i]
+	}
+	return out
+}
+
+// Shares returns each positively weighted target's chance of being
+// picked, factors included.
+func (p *Picker) Shares() map[string]float64 {
+	out := make(map[string]float64)
+	if p == nil || p.totalWeight <= 0 {
+		return out
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, t := range p.targets {
+		if t.Weight <= 0 {
+			continue
+		}
+		if p.factors != nil {
+			out[t.Name] = float64(t.Weight) * p.factors[i] / p.scaled
+		} else {
+			out[t.Name] = float64(t.Weight) / float64(p.totalWeight)
+		}
+	}
+	return out
+}
+
+// PickRequest resolves t against one of its request specs chosen by
+// weight (an unset weight counts as 1), or failing those one of its
+// Methods (#1239). A target without either resolves to itself. The
+// result is a copy, so callers may hand it to a worker.Job directly
+// (#1182).
+func (p *Picker) PickRequest(t *config.Target) config.Target {
+	if len(t.Requests) == 0 {
+		return p.pickMethod(t)
+	}
+	total := 0
+	for i := range t.Requests {
+		total += specWeight(&t.Requests[i])
+	}
+	if total <= 0 {
+		return t.Resolve(nil)
+	}
+
+	p.mu.Lock()
+	r := p.rng.Intn(total)
+	p.mu.Unlock()
+
+	for i := range t.Requests {
+		w := specWeight(&t.Requests[i])
+		if r < w {
+			return t.Resolve(&t.Requests[i])
+		}
+		r -= w
+	}
+	return t.Resolve(nil)
+}
+
+// pickMethod is PickRequest for a target's Methods.
+func (p *Picker) pickMethod(t *config.Target) config.Target {
+	total := 0
+	for _, m := range t.Methods {
+		total += methodWeight(m)
+	}
+	if total <= 0 {
+		return t.Resolve(nil)
+	}
+
+	p.mu.Lock()
+	r := p.rng.Intn(total)
+	p.mu.Unlock()
+
+	for _, m := range t.Methods {
+		w := methodWeight(m)
+		if r < w {
+			spec := m.Spec()
+			return t.Resolve(&spec)
+		}
+		r -= w
+	}
+	return t.Resolve(nil)
+}
+
+func methodWeight(m config.WeightedMethod) int {
+	switch {
+	case m.Weight == 0:
+		return 1
+	case m.Weight < 0:
+		return 0
+	}
+	return m.Weight
+}
+
+func specWeight(s *config.RequestSpec) int {
+	switch {
+	case s.Weight == 0:
+		return 1
+	case s.Weight < 0:
+		return 0
+	}
+	return s.Weight
+}
+
 // Len returns the number of targets in the underlying set, including
 // zero-weight entries.
 func (p *Picker) Len() int {
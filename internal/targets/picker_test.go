@@ -104,3 +104,103 @@ func TestLen(t *testing.T) {
 		t.Fatal("Len should count zero-weight entries")
 	}
 }
+
+func TestPickRequest(t *testing.T) {
+	tgt := config.Target{
+		Name:   "orders",
+		URL:    "http://orders/orders",
+		Method: "GET",
+		Requests: []config.RequestSpec{
+			{Name: "list", Weight: 3},
+			{Name: "create", Method: "POST"}, // unset weight counts as 1
+		},
+	}
+	p := NewWithSeed([]config.Target{tgt}, 11)
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		got := p.PickRequest(&tgt)
+		if got.Requests != nil {
+			t.Fatalf("resolved target should not carry Requests")
+		}
+		if got.Spec == "create" && got.Method != "POST" {
+			t.Fatalf("create spec resolved with method %q", got.Method)
+		}
+		counts[got.Spec]++
+	}
+	ratio := float64(counts["list"]) / float64(counts["create"])
+	if math.Abs(ratio-3) > 0.4 {
+		t.Fatalf("expected ~3:1 list:create, got %v", counts)
+	}
+
+	plain := config.Target{Name: "plain"}
+	if got := p.PickRequest(&plain); got.Spec != "" || got.Name != "plain" {
+		t.Fatalf("plain target should resolve to itself, got %+v", got)
+	}
+}
+
+func TestPickRequest_Methods(t *testing.T) {
+	tgt := config.Target{
+		Name:   "orders",
+		Method: "GET",
+		Body:   "default",
+		Methods: []config.WeightedMethod{
+			{Method: "get", Weight: 3},
+			{Method: "POST", Body: `{"id":1}`}, // unset weight counts as 1
+		},
+	}
+	p := NewWithSeed([]config.Target{tgt}, 5)
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		got := p.PickRequest(&tgt)
+		if got.Methods != nil {
+			t.Fatalf("resolved target should not carry Methods")
+		}
+		if got.Spec != got.Method {
+			t.Fatalf("spec %q should name the method %q", got.Spec, got.Method)
+		}
+		if want := map[string]string{"GET": "default", "POST": `{"id":1}`}[got.Method]; got.Body != want {
+			t.Fatalf("%s resolved with body %q, want %q", got.Method, got.Body, want)
+		}
+		counts[got.Method]++
+	}
+	ratio := float64(counts["GET"]) / float64(counts["POST"])
+	if math.Abs(ratio-3) > 0.4 {
+		t.Fatalf("expected ~3:1 GET:POST, got %v", counts)
+	}
+}
+
+func TestSetFactors_ScalesWeights(t *testing.T) {
+	tgts := []config.Target{
+		{Name: "fast", Weight: 1},
+		{Name: "slow", Weight: 1},
+		{Name: "off", Weight: 0},
+	}
+	p := NewWithSeed(tgts, 3)
+	p.SetFactors(map[string]float64{"slow": 0.25, "off": 1})
+
+	shares := p.Shares()
+	if math.Abs(shares["fast"]-0.8) > 1e-9 || math.Abs(shares["slow"]-0.2) > 1e-9 {
+		t.Fatalf("shares = %v, want fast 0.8 slow 0.2", shares)
+	}
+	if _, ok := shares["off"]; ok {
+		t.Fatalf("zero-weight target must stay out of the shares: %v", shares)
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 10_000; i++ {
+		counts[p.Pick().Name]++
+	}
+	if counts["off"] != 0 {
+		t.Fatalf("factor revived a zero-weight target: %v", counts)
+	}
+	if got := float64(counts["slow"]) / 10_000; math.Abs(got-0.2) > 0.03 {
+		t.Fatalf("slow picked %.3f of the time, want ~0.2", got)
+	}
+
+	p.SetFactors(nil)
+	if shares := p.Shares(); shares["slow"] != 0.5 {
+		t.Fatalf("nil factors should restore the weights, got %v", shares)
+	}
+}
@@ -0,0 +1,186 @@
+// Package template expands request body templates (#1251). A body such
+// as
+//
+//	{"id": "{{uuid}}", "seq": {{counter}}, "ts": "{{now}}"}
+//
+// is parsed once into literal and placeholder parts and expanded per
+// request, so every request carries its own payload. Placeholders:
+//
+//	{{uuid}}             random version 4 UUID
+//	{{counter}}          1, 2, 3, ... from the Counter passed to Append
+//	{{randInt min max}}  random integer in [min, max]
+//	{{now}}              current time, RFC 3339 in UTC
+package template
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mrand "math/rand"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Counter is the sequence {{counter}} draws from. It is safe for
+// concurrent use; share one to number requests across templates.
+type Counter struct {
+	n atomic.Uint64
+}
+
+// Next returns the next value, starting at 1.
+func (c *Counter) Next() uint64 {
+	return c.n.Add(1)
+}
+
+type kind int
+
+const (
+	literal kind = iota
+	uuidFn
+	counterFn
+	randIntFn
+	nowFn
+)
+
+type part struct {
+	kind     kind
+	text     string // literal
+	min, max int64  // randInt
+}
+
+// Template is a parsed body. It is immutable and safe for concurrent
+// use.
+type Template struct {
+	parts []part
+	size  int // literal bytes, a hint for Append's buffer
+}
+
+// Has reports whether s contains a placeholder opening; bodies without
+// one need no template.
+func Has(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// Parse parses s. Unknown functions, wrong arguments and unclosed
+// placeholders are errors rather than literal text.
+func Parse(s string) (*Template, error) {
+	t := &Template{}
+	for s != "" {
+		i := strings.Index(s, "{{")
+		if i < 0 {
+			t.add(part{kind: literal, text: s})
+			break
+		}
+		if i > 0 {
+			t.add(part{kind: literal, text: s[:i]})
+		}
+		s = s[i+2:]
+		j := strings.Index(s, "}}")
+		if j < 0 {
+			return nil, fmt.Errorf("unclosed {{ at %q", clip(s))
+		}
+		p, err := parseCall(strings.Fields(s[:j]))
+		if err != nil {
+			return nil, fmt.Errorf("{{%s}}: %w", s[:j], err)
+		}
+		t.add(p)
+		s = s[j+2:]
+	}
+	return t, nil
+}
+
+func (t *Template) add(p part) {
+	t.size += len(p.text)
+	t.parts = append(t.parts, p)
+}
+
+func parseCall(fields []string) (part, error) {
+	if len(fields) == 0 {
+		return part{}, fmt.Errorf("empty placeholder")
+	}
+	name, args := fields[0], fields[1:]
+	want := 0
+	var p part
+	switch name {
+	case "uuid":
+		p.kind = uuidFn
+	case "counter":
+		p.kind = counterFn
+	case "now":
+		p.kind = nowFn
+	case "randInt":
+		p.kind = randIntFn
+		want = 2
+	default:
+		return part{}, fmt.Errorf("unknown function %q (use uuid, counter, randInt or now)", name)
+	}
+	if len(args) != want {
+		return part{}, fmt.Errorf("%s takes %d arguments, got %d", name, want, len(args))
+	}
+	if p.kind == randIntFn {
+		var err error
+		if p.min, err = strconv.ParseInt(args[0], 10, 64); err != nil {
+			return part{}, fmt.Errorf("randInt min: %w", err)
+		}
+		if p.max, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return part{}, fmt.Errorf("randInt max: %w", err)
+		}
+		if p.min > p.max {
+			return part{}, fmt.Errorf("randInt min %d is above max %d", p.min, p.max)
+		}
+		if p.max-p.min+1 <= 0 {
+			return part{}, fmt.Errorf("randInt range %d..%d is too wide", p.min, p.max)
+		}
+	}
+	return p, nil
+}
+
+// Append expands t onto dst and returns the extended slice. ctr
+// numbers {{counter}}.
+func (t *Template) Append(dst []byte, ctr *Counter) []byte {
+	if dst == nil {
+		dst = make([]byte, 0, t.size+32*(len(t.parts)))
+	}
+	for _, p := range t.parts {
+		switch p.kind {
+		case literal:
+			dst = append(dst, p.text...)
+		case uuidFn:
+			dst = appendUUID(dst)
+		case counterFn:
+			dst = strconv.AppendUint(dst, ctr.Next(), 10)
+		case randIntFn:
+			dst = strconv.AppendInt(dst, p.min+mrand.Int63n(p.max-p.min+1), 10)
+		case nowFn:
+			dst = time.Now().UTC().AppendFormat(dst, time.RFC3339)
+		}
+	}
+	return dst
+}
+
+func appendUUID(dst []byte) []byte {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	var h [36]byte
+	hex.Encode(h[0:8], b[0:4])
+	h[8] = '-'
+	hex.Encode(h[9:13], b[4:6])
+	h[13] = '-'
+	hex.Encode(h[14:18], b[6:8])
+	h[18] = '-'
+	hex.Encode(h[19:23], b[8:10])
+	h[23] = '-'
+	hex.Encode(h[24:], b[10:])
+	return append(dst, h[:]...)
+}
+
+func clip(s string) string {
+	if len(s) > 20 {
+		return s[:20] + "..."
+	}
+	return s
+}
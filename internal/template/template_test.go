@@ -0,0 +1,92 @@
+package template
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTemplate_Expands(t *testing.T) {
+	tpl, err := Parse(`{"id": "{{uuid}}", "seq": {{ counter }}, "n": {{randInt 5 7}}, "ts": "{{now}}"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`^\{"id": "([0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12})", "seq": (\d+), "n": (\d+), "ts": "([^"]+)"\}$`)
+	var ctr Counter
+	seen := map[string]bool{}
+	for i := 1; i <= 50; i++ {
+		out := string(tpl.Append(nil, &ctr))
+		m := re.FindStringSubmatch(out)
+		if m == nil {
+			t.Fatalf("expansion %q doesn't match", out)
+		}
+		if seen[m[1]] {
+			t.Fatalf("uuid %s repeated", m[1])
+		}
+		seen[m[1]] = true
+		if m[2] != strconv.Itoa(i) {
+			t.Fatalf("seq = %s, want %d", m[2], i)
+		}
+		if n, _ := strconv.Atoi(m[3]); n < 5 || n > 7 {
+			t.Fatalf("randInt 5 7 = %d", n)
+		}
+		if _, err := time.Parse(time.RFC3339, m[4]); err != nil {
+			t.Fatalf("now: %v", err)
+		}
+	}
+}
+
+func TestCounter_SharedAcrossGoroutines(t *testing.T) {
+	a, _ := Parse("{{counter}}")
+	b, _ := Parse("b{{counter}}")
+	var ctr Counter
+	var mu sync.Mutex
+	got := map[string]bool{}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				tpl := a
+				if i%2 == 1 {
+					tpl = b
+				}
+				v := strings.TrimPrefix(string(tpl.Append(nil, &ctr)), "b")
+				mu.Lock()
+				got[v] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(got) != 800 {
+		t.Fatalf("%d distinct counter values from 800 expansions", len(got))
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, s := range []string{
+		`{"id": "{{uuid}"}`,
+		`{{ }}`,
+		`{{seq}}`,
+		`{{uuid 1}}`,
+		`{{randInt 1}}`,
+		`{{randInt a 2}}`,
+		`{{randInt 9 2}}`,
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded", s)
+		}
+	}
+	tpl, err := Parse(`{"static": true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(tpl.Append(nil, nil)); got != `{"static": true}` {
+		t.Fatalf("literal body = %q", got)
+	}
+}
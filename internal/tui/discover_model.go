@@ -5,6 +5,7 @@ import (
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kar98k/internal/config"
 )
 
 // Discovery screen states
@@ -24,6 +25,7 @@ type DiscoverModel struct {
 	err          error
 	spinnerFrame int
 	startTime    time.Time
+	latency      config.LatencyFormat
 
 	// Configuration from inputs
 	TargetURL      string
@@ -152,6 +154,11 @@ func NewDiscoverModel() DiscoverModel {
 }
 
 // Init initializes the discover model.
+// SetLatencyFormat sets how latencies are printed (#1213).
+func (m *DiscoverModel) SetLatencyFormat(f config.LatencyFormat) {
+	m.latency = f
+}
+
 func (m DiscoverModel) Init() tea.Cmd {
 	return tea.Batch(textinput.Blink, discoverTickCmd())
 }
@@ -152,7 +152,7 @@ func (m DiscoverModel) viewRunning() string {
 			lipgloss.JoinVertical(lipgloss.Left,
 				LabelStyle.Render("P95 Latency"),
 				lipgloss.JoinHorizontal(lipgloss.Center,
-					ValueStyle.Render(fmt.Sprintf("  %.0fms", m.P95Latency)),
+					ValueStyle.Render("  "+m.latency.Format(m.P95Latency)),
 					" ",
 					latencyStatus,
 					" ",
@@ -250,7 +250,7 @@ func (m DiscoverModel) viewResult() string {
 		"",
 		lipgloss.JoinHorizontal(lipgloss.Center,
 			LabelStyle.Render("  P95 Latency      "),
-			ValueStyle.Render(fmt.Sprintf("%.0fms", m.FinalP95)),
+			ValueStyle.Render(m.latency.Format(m.FinalP95)),
 		),
 		lipgloss.JoinHorizontal(lipgloss.Center,
 			LabelStyle.Render("  Error Rate       "),
@@ -2,27 +2,32 @@ package tui
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/report"
 )
 
 // Log file path
 var logFile *os.File
 
-// InitLogger initializes the log file
-func InitLogger() error {
-	dir := filepath.Join(os.TempDir(), "kar98k")
-	if err := os.MkdirAll(dir, 0755); err != nil {
+// InitLogger opens the log file at path, normally daemon.GetLogPath(),
+// so the TUI and the daemon share one log
+func InitLogger(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return err
 	}
 	var err error
-	logFile, err = os.OpenFile(filepath.Join(dir, "kar98k.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	logFile, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	return err
 }
 
@@ -56,46 +61,13 @@ const (
 	ScreenReport
 )
 
-// TimeSlot represents stats for a specific time period
-type TimeSlot struct {
-	Time       time.Time
-	TPS        float64
-	Requests   int64
-	Errors     int64
-	AvgLatency float64
-}
-
-// LatencyBucket represents a latency distribution bucket
-type LatencyBucket struct {
-	Label string
-	Count int64
-}
-
-// ReportData holds all data for the final report
-type ReportData struct {
-	// Overall stats
-	TotalRequests   int64
-	TotalErrors     int64
-	TotalDuration   time.Duration
-	AvgTPS          float64
-	PeakTPS         float64
-	MinLatency      float64
-	MaxLatency      float64
-	AvgLatency      float64
-	P50Latency      float64
-	P95Latency      float64
-	P99Latency      float64
-	SuccessRate     float64
-
-	// Time series data (for graph)
-	TimeSlots []TimeSlot
-
-	// Latency distribution
-	LatencyDist []LatencyBucket
-
-	// Status code distribution
-	StatusCodes map[int]int64
-}
+// The report's types live in package report, shared with the daemon's
+// HTML sink (#1260).
+type (
+	TimeSlot      = report.TimeSlot
+	LatencyBucket = report.LatencyBucket
+	ReportData    = report.ReportData
+)
 
 // Model is the main TUI model
 type Model struct {
@@ -130,9 +102,14 @@ type Model struct {
 	IsSpiking    bool
 
 	// Stats collection for report
-	latencies     []float64
+	latencies     latencySamples
 	peakTPS       float64
 	timeSlots     []TimeSlot
+	slotInterval  time.Duration
+	expected      time.Duration
+	latency       config.LatencyFormat
+	meta          config.RunMetadata
+	minSamples    int
 	lastSlotTime  time.Time
 	slotRequests  int64
 	slotErrors    int64
@@ -150,8 +127,39 @@ type Model struct {
 	ManualSpikeFactor   float64
 	ManualSpikeEndTime  time.Time
 
+	// Multiplier is the live rate knob on the running screen (#1232),
+	// applied on top of the pattern and any spike. Arrow keys send it
+	// to the running daemon with sendMultiplier, and Multiplier is what
+	// the daemon reported back: wantMultiplier is the value last asked
+	// for, multiplierSeq numbers the requests so a late reply can't
+	// undo a newer one, and multiplierErr is why the last wasn't taken.
+	Multiplier     float64
+	wantMultiplier float64
+	multiplierSeq  int
+	multiplierAck  int
+	multiplierErr  error
+	sendMultiplier func(mult float64) (float64, error)
+
+	// launch starts the run when Fire is pressed; launchErr is why it
+	// didn't, shown on the Review screen.
+	launch    func(cfg map[string]string) error
+	launchErr error
+
 	// Final report data
 	Report ReportData
+
+	// saveConfig writes the collected configuration to a YAML file;
+	// the Review screen offers it when set (#1257). savePath is the
+	// file name being typed, saveNote the last save's outcome.
+	saveConfig func(cfg map[string]string, path string) error
+	savePath   textinput.Model
+	savingPath bool
+	saveNote   string
+	saveFailed bool
+
+	// scheduleErr is why Schedule doesn't parse; the Review screen
+	// shows it and won't fire until it's fixed (#1258).
+	scheduleErr error
 }
 
 // NewModel creates a new TUI model
@@ -167,10 +175,12 @@ func NewModel() Model {
 		SpikeFactor:   "2.0",
 		NoiseAmp:      "0.10",
 		statusCodes:   make(map[int]int64),
-		latencies:     make([]float64, 0),
 		timeSlots:     make([]TimeSlot, 0),
+		slotInterval:  DefaultSlotInterval,
 		slotLatencies: make([]float64, 0),
+		Multiplier:    1,
 	}
+	m.wantMultiplier = m.Multiplier
 
 	// Create text inputs (10 total)
 	m.inputs = make([]textinput.Model, 10)
@@ -243,6 +253,12 @@ func NewModel() Model {
 	m.inputs[9].CharLimit = 10
 	m.inputs[9].Width = 20
 
+	m.savePath = textinput.New()
+	m.savePath.Placeholder = "kar.yaml"
+	m.savePath.SetValue("kar.yaml")
+	m.savePath.CharLimit = 256
+	m.savePath.Width = 40
+
 	return m
 }
 
@@ -263,6 +279,172 @@ type SpikeMsg struct {
 	Duration time.Duration
 }
 
+// Live multiplier bounds and arrow-key step.
+const (
+	MinMultiplier  = 0.1
+	MaxMultiplier  = 10.0
+	MultiplierStep = 0.1
+)
+
+// multiplierMsg is the daemon's answer to a multiplier change.
+type multiplierMsg struct {
+	seq     int
+	want    float64
+	applied float64
+	err     error
+}
+
+// adjustMultiplier moves the live multiplier by delta, within bounds.
+// With a sender set the change goes to the daemon and takes effect on
+// its multiplierMsg; without one it applies to the session at once.
+func (m *Model) adjustMultiplier(delta float64) tea.Cmd {
+	next := math.Round((m.wantMultiplier+delta)*10) / 10
+	next = math.Max(MinMultiplier, math.Min(MaxMultiplier, next))
+	if next == m.wantMultiplier {
+		return nil
+	}
+	m.wantMultiplier = next
+	if m.sendMultiplier == nil {
+		Log("EVENT: Multiplier %.1fx -> %.1fx", m.Multiplier, next)
+		m.Multiplier = next
+		return nil
+	}
+	m.multiplierSeq++
+	seq, send := m.multiplierSeq, m.sendMultiplier
+	return func() tea.Msg {
+		applied, err := send(next)
+		return multiplierMsg{seq: seq, want: next, applied: applied, err: err}
+	}
+}
+
+// applyMultiplier takes the daemon's answer to a multiplier change.
+func (m *Model) applyMultiplier(msg multiplierMsg) {
+	if msg.seq < m.multiplierAck {
+		return
+	}
+	m.multiplierAck = msg.seq
+	if msg.err != nil {
+		Log("ERROR: Multiplier %.1fx not applied: %v", msg.want, msg.err)
+		m.multiplierErr = msg.err
+		if msg.seq == m.multiplierSeq {
+			m.wantMultiplier = m.Multiplier
+		}
+		return
+	}
+	Log("EVENT: Multiplier %.1fx -> %.1fx", m.Multiplier, msg.applied)
+	m.Multiplier, m.multiplierErr = msg.applied, nil
+	if msg.seq == m.multiplierSeq {
+		m.wantMultiplier = msg.applied
+	}
+}
+
+// renderMultiplierNote says when the daemon hasn't taken the
+// multiplier asked for yet, or refused it.
+func (m Model) renderMultiplierNote() string {
+	switch {
+	case m.multiplierErr != nil:
+		return ErrorStyle.Render("  not applied: " + m.multiplierErr.Error())
+	case m.wantMultiplier != m.Multiplier:
+		return DimStyle.Render(fmt.Sprintf("  → %.1fx", m.wantMultiplier))
+	}
+	return ""
+}
+
+// DefaultSlotInterval is the report time slot width when none is set.
+const DefaultSlotInterval = config.DefaultSlotInterval
+
+// SetSlotInterval sets the report time slot width (report.interval,
+// #1199). Non-positive values keep the current one.
+func (m *Model) SetSlotInterval(d time.Duration) {
+	if d > 0 {
+		m.slotInterval = d
+	}
+}
+
+// SetExpectedLatency sets the latency reference shown on the live
+// view and the report timeline (targets[].expected_latency, #1209).
+func (m *Model) SetExpectedLatency(d time.Duration) {
+	if d > 0 {
+		m.expected = d
+	}
+}
+
+// SetLatencyFormat sets how latencies are printed (report.latency,
+// #1213).
+func (m *Model) SetLatencyFormat(f config.LatencyFormat) {
+	m.latency = f
+}
+
+// SetMetadata sets the build and host recorded in the report; its
+// start and end are filled in from the session.
+func (m *Model) SetMetadata(meta config.RunMetadata) {
+	m.meta = meta
+}
+
+// SetMinSamples sets report.min_samples, the samples a percentile
+// needs beyond it to be trusted (#1240).
+func (m *Model) SetMinSamples(n int) {
+	m.minSamples = n
+}
+
+// SetConfigSaver enables the Review screen's save action; save writes
+// GetConfig's map to a YAML file at path.
+func (m *Model) SetConfigSaver(save func(cfg map[string]string, path string) error) {
+	m.saveConfig = save
+}
+
+// SetLauncher makes Fire start the run: launch gets GetConfig's map
+// and the Review screen stays put, showing the error, if it fails.
+func (m *Model) SetLauncher(launch func(cfg map[string]string) error) {
+	m.launch = launch
+}
+
+// SetMultiplierSender sends the running screen's multiplier to the
+// daemon (#1232); send returns the multiplier the daemon applied.
+func (m *Model) SetMultiplierSender(send func(mult float64) (float64, error)) {
+	m.sendMultiplier = send
+}
+
+// reviewButtons is the number of buttons on the Review screen: fire,
+// save when a saver is set, and back, always last.
+func (m Model) reviewButtons() int {
+	if m.saveConfig != nil {
+		return 3
+	}
+	return 2
+}
+
+// updateSavePath handles keys while the Review screen asks for the
+// file to save to.
+func (m Model) updateSavePath(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.savingPath = false
+		m.savePath.Blur()
+		return m, nil
+	case "enter":
+		path := strings.TrimSpace(m.savePath.Value())
+		if path == "" {
+			path = m.savePath.Placeholder
+		}
+		m.savingPath = false
+		m.savePath.Blur()
+		if err := m.saveConfig(m.GetConfig(), path); err != nil {
+			m.saveNote, m.saveFailed = "Save failed: "+err.Error(), true
+			Log("ERROR: saving config to %s: %v", path, err)
+		} else {
+			m.saveNote, m.saveFailed = "Saved to "+path+" (kar run --config "+path+")", false
+			Log("EVENT: Config saved to %s", path)
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.savePath, cmd = m.savePath.Update(msg)
+	return m, cmd
+}
+
 func tickCmd() tea.Cmd {
 	return tea.Tick(time.Millisecond*100, func(t time.Time) tea.Msg {
 		return tickMsg(t)
@@ -273,6 +455,19 @@ func tickCmd() tea.Cmd {
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.screen == ScreenReview && m.savingPath {
+			return m.updateSavePath(msg)
+		}
+		if m.screen == ScreenRunning {
+			switch msg.String() {
+			case "up", "right", "+", "=":
+				return m, m.adjustMultiplier(MultiplierStep)
+			case "down", "left", "-":
+				return m, m.adjustMultiplier(-MultiplierStep)
+			case "0":
+				return m, m.adjustMultiplier(1 - m.wantMultiplier)
+			}
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
 			// On Running screen, show report first
@@ -332,6 +527,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, tea.Quit
 
+	case multiplierMsg:
+		m.applyMultiplier(msg)
+		return m, nil
+
 	case SpikeMsg:
 		// Handle manual spike command
 		if m.screen == ScreenRunning {
@@ -385,14 +584,28 @@ func (m *Model) handleEnter() (tea.Model, tea.Cmd) {
 		m.NoiseAmp = m.inputs[7].Value()
 		m.Schedule = m.inputs[8].Value()
 		m.PoissonLambda = m.inputs[9].Value() // optional override
+		_, m.scheduleErr = config.ParseSchedule(m.Schedule)
 		m.screen = ScreenReview
 		m.cursor = 0
 	case ScreenReview:
-		if m.cursor == 0 { // Fire!
+		switch {
+		case m.cursor == 0 && m.scheduleErr != nil: // Fire, but the schedule is invalid
+			return m, nil
+		case m.cursor == 0: // Fire!
+			if m.launch != nil {
+				if m.launchErr = m.launch(m.GetConfig()); m.launchErr != nil {
+					Log("ERROR: starting the run: %v", m.launchErr)
+					return m, nil
+				}
+			}
 			m.screen = ScreenRunning
 			m.triggered = true
 			m.startTime = time.Now()
-		} else { // Back
+		case m.cursor == 1 && m.saveConfig != nil: // Save to file
+			m.savingPath = true
+			m.saveNote = ""
+			return m, m.savePath.Focus()
+		default: // Back
 			m.screen = ScreenTargetSetup
 		}
 	case ScreenRunning:
@@ -419,7 +632,7 @@ func (m *Model) handleNext() (tea.Model, tea.Cmd) {
 		m.focusIndex = (m.focusIndex + 1) % 5 // 5 fields now
 		m.inputs[5+m.focusIndex].Focus()
 	case ScreenReview:
-		m.cursor = (m.cursor + 1) % 2
+		m.cursor = (m.cursor + 1) % m.reviewButtons()
 	}
 	return m, nil
 }
@@ -439,7 +652,8 @@ func (m *Model) handlePrev() (tea.Model, tea.Cmd) {
 		m.focusIndex = (m.focusIndex - 1 + 5) % 5 // 5 fields now
 		m.inputs[5+m.focusIndex].Focus()
 	case ScreenReview:
-		m.cursor = (m.cursor - 1 + 2) % 2
+		n := m.reviewButtons()
+		m.cursor = (m.cursor - 1 + n) % n
 	}
 	return m, nil
 }
@@ -494,6 +708,7 @@ func (m *Model) updateRunningStats() {
 			m.CurrentTPS *= 3.0 // ~255 ~ 345 during spike
 		}
 	}
+	m.CurrentTPS *= m.Multiplier
 
 	m.RequestsSent = int64(elapsed * baseTPS)
 	m.ErrorCount = int64(elapsed * 0.5)
@@ -538,7 +753,7 @@ func (m *Model) updateRunningStats() {
 
 	// Simulate latency collection (in real impl, this comes from actual requests)
 	simulatedLatency := m.AvgLatency + float64(m.spinnerFrame%10) - 5
-	m.latencies = append(m.latencies, simulatedLatency)
+	m.latencies.add(simulatedLatency)
 	m.slotLatencies = append(m.slotLatencies, simulatedLatency)
 
 	// Simulate status codes
@@ -550,13 +765,13 @@ func (m *Model) updateRunningStats() {
 		m.statusCodes[200]++
 	}
 
-	// Collect time slot data every 5 seconds
+	// Collect time slot data every slot interval
 	now := time.Now()
 	if m.lastSlotTime.IsZero() {
 		m.lastSlotTime = now
 	}
 
-	if now.Sub(m.lastSlotTime) >= 5*time.Second {
+	if now.Sub(m.lastSlotTime) >= m.slotInterval {
 		// Calculate slot stats
 		slotAvgLatency := 0.0
 		if len(m.slotLatencies) > 0 {
@@ -729,6 +944,7 @@ func (m Model) viewPatternConfig() string {
 		m.renderInput(8, m.focusIndex == 3),
 		DimStyle.Render("  Time-based TPS multiplier. Format: hour-hour:factor"),
 		DimStyle.Render("  ex) 9-18:1.5  = 1.5x during 9AM-6PM"),
+		DimStyle.Render("  ex) 22-2:0.3  = 0.3x overnight, wrapping past midnight"),
 		"",
 		LabelStyle.Render("Lambda (advanced, optional)"),
 		m.renderInput(9, m.focusIndex == 4),
@@ -776,28 +992,66 @@ func (m Model) viewReview() string {
 		fmt.Sprintf("  %s %s  %s %sx", LabelStyle.Render("Interval:"), ValueStyle.Render(intervalStr), LabelStyle.Render("Spike:"), ValueStyle.Render(m.SpikeFactor)),
 		fmt.Sprintf("  %s ±%s%%", LabelStyle.Render("Noise:"), ValueStyle.Render(m.NoiseAmp)),
 	)
+	if m.Schedule != "" {
+		configSummary = lipgloss.JoinVertical(lipgloss.Left, configSummary,
+			fmt.Sprintf("  %s %s", LabelStyle.Render("Schedule:"), ValueStyle.Render(m.Schedule)))
+	}
 
 	box := BorderStyle.Width(60).Render(configSummary)
 	b.WriteString(lipgloss.Place(m.width, 0, lipgloss.Center, lipgloss.Top, box))
 
 	b.WriteString("\n\n")
 
-	// Fire button
-	var fireBtn, backBtn string
+	// Fire, save and back buttons
+	fireBtn := ButtonStyle.Render(" " + TriggerReady + " PULL TRIGGER ")
 	if m.cursor == 0 {
 		fireBtn = ActiveButtonStyle.Render(" " + Crosshair + " PULL TRIGGER ")
-		backBtn = ButtonStyle.Render(" ← BACK ")
-	} else {
-		fireBtn = ButtonStyle.Render(" " + TriggerReady + " PULL TRIGGER ")
-		backBtn = ActiveButtonStyle.Render(" ← BACK ")
 	}
+	parts := []string{fireBtn}
+	if m.saveConfig != nil {
+		style := ButtonStyle
+		if m.cursor == 1 {
+			style = ActiveButtonStyle
+		}
+		parts = append(parts, "  ", style.Render(" 💾 SAVE TO FILE "))
+	}
+	backStyle := ButtonStyle
+	if m.cursor == m.reviewButtons()-1 {
+		backStyle = ActiveButtonStyle
+	}
+	parts = append(parts, "  ", backStyle.Render(" ← BACK "))
 
-	buttons := lipgloss.JoinHorizontal(lipgloss.Center, fireBtn, "  ", backBtn)
+	buttons := lipgloss.JoinHorizontal(lipgloss.Center, parts...)
 	b.WriteString(lipgloss.Place(m.width, 0, lipgloss.Center, lipgloss.Top, buttons))
 
+	help := "TAB: switch button • ENTER: select • ESC: back"
+	switch {
+	case m.savingPath:
+		b.WriteString("\n\n")
+		b.WriteString(lipgloss.Place(m.width, 0, lipgloss.Center, lipgloss.Top,
+			LabelStyle.Render("Save to: ")+m.savePath.View()))
+		help = "ENTER: save • ESC: cancel"
+	case m.scheduleErr != nil:
+		b.WriteString("\n\n")
+		b.WriteString(lipgloss.Place(m.width, 0, lipgloss.Center, lipgloss.Top,
+			ErrorStyle.Render("Invalid schedule: "+m.scheduleErr.Error())))
+		help = "BACK to fix the schedule • ESC: back"
+	case m.launchErr != nil:
+		b.WriteString("\n\n")
+		b.WriteString(lipgloss.Place(m.width, 0, lipgloss.Center, lipgloss.Top,
+			ErrorStyle.Render("Start failed: "+m.launchErr.Error())))
+	case m.saveNote != "":
+		style := SuccessStyle
+		if m.saveFailed {
+			style = ErrorStyle
+		}
+		b.WriteString("\n\n")
+		b.WriteString(lipgloss.Place(m.width, 0, lipgloss.Center, lipgloss.Top, style.Render(m.saveNote)))
+	}
+
 	b.WriteString("\n\n\n")
 	b.WriteString(lipgloss.Place(m.width, 0, lipgloss.Center, lipgloss.Top,
-		HelpStyle.Render("TAB: switch button • ENTER: select • ESC: back")))
+		HelpStyle.Render(help)))
 
 	return b.String()
 }
@@ -851,6 +1105,12 @@ func (m Model) viewRunning() string {
 		fmt.Sprintf("  %s %s", ValueStyle.Render(fmt.Sprintf("%.0f", m.CurrentTPS)), DimStyle.Render("/ 1000")),
 		"  "+ProgressBar(tpsPercent, 40),
 		"",
+		LabelStyle.Render("Multiplier"),
+		fmt.Sprintf("  %s %s%s",
+			ProgressBar((m.Multiplier-MinMultiplier)/(MaxMultiplier-MinMultiplier), 30),
+			ValueStyle.Render(fmt.Sprintf("%.1fx", m.Multiplier)),
+			m.renderMultiplierNote()),
+		"",
 		lipgloss.JoinHorizontal(lipgloss.Top,
 			lipgloss.JoinVertical(lipgloss.Left,
 				LabelStyle.Render("Requests Sent"),
@@ -864,7 +1124,7 @@ func (m Model) viewRunning() string {
 			"    ",
 			lipgloss.JoinVertical(lipgloss.Left,
 				LabelStyle.Render("Avg Latency"),
-				ValueStyle.Render(fmt.Sprintf("  %.1fms", m.AvgLatency)),
+				m.renderLiveLatency(),
 			),
 		),
 		"",
@@ -900,11 +1160,25 @@ func (m Model) viewRunning() string {
 
 	b.WriteString("\n\n")
 	b.WriteString(lipgloss.Place(m.width, 0, lipgloss.Center, lipgloss.Top,
-		HelpStyle.Render("ENTER: pause/resume • Q: stop and exit")))
+		HelpStyle.Render("←/→: multiplier • 0: reset • ENTER: pause/resume • Q: stop and exit")))
 
 	return b.String()
 }
 
+// renderLiveLatency renders the running average latency, in red against
+// the expected latency reference once it exceeds it (#1209).
+func (m Model) renderLiveLatency() string {
+	value := "  " + m.latency.Format(m.AvgLatency)
+	if m.expected <= 0 {
+		return ValueStyle.Render(value)
+	}
+	ref := DimStyle.Render(fmt.Sprintf(" / ≤ %s", m.expected))
+	if report.OverExpected(m.AvgLatency, m.expected) {
+		return ErrorStyle.Render(value) + ref
+	}
+	return ValueStyle.Render(value) + ref
+}
+
 func (m Model) renderHeader(title, step string) string {
 	header := lipgloss.JoinHorizontal(lipgloss.Center,
 		MiniLogo(),
@@ -953,7 +1227,13 @@ func (m *Model) generateReport() {
 	r.TotalDuration = time.Since(m.startTime)
 	r.PeakTPS = m.peakTPS
 	r.TimeSlots = m.timeSlots
+	r.Interval = m.slotInterval
+	r.ExpectedLatency = m.expected
+	r.Latency = m.latency
 	r.StatusCodes = m.statusCodes
+	r.Metadata = m.meta
+	r.Metadata.Started = m.startTime
+	r.Metadata.Ended = time.Now()
 
 	// Calculate average TPS
 	if r.TotalDuration.Seconds() > 0 {
@@ -965,41 +1245,71 @@ func (m *Model) generateReport() {
 		r.SuccessRate = float64(r.TotalRequests-r.TotalErrors) / float64(r.TotalRequests) * 100
 	}
 
-	// Calculate latency stats
-	if len(m.latencies) > 0 {
-		sorted := make([]float64, len(m.latencies))
-		copy(sorted, m.latencies)
-		sortFloat64s(sorted)
-
-		r.MinLatency = sorted[0]
-		r.MaxLatency = sorted[len(sorted)-1]
+	// Calculate latency stats. Min, max, average and the histogram
+	// count every sample; percentiles come from the reservoir.
+	if l := m.latencies; l.count > 0 {
+		sorted := make([]float64, len(l.kept))
+		copy(sorted, l.kept)
+		sort.Float64s(sorted)
 
-		// Average
-		sum := 0.0
-		for _, l := range sorted {
-			sum += l
-		}
-		r.AvgLatency = sum / float64(len(sorted))
+		r.MinLatency = l.min
+		r.MaxLatency = l.max
+		r.AvgLatency = l.sum / float64(l.count)
 
 		// Percentiles
 		r.P50Latency = percentile(sorted, 50)
 		r.P95Latency = percentile(sorted, 95)
 		r.P99Latency = percentile(sorted, 99)
+		r.Confidence = config.Report{MinSamples: m.minSamples}.PercentileNotes(l.count, 50, 95, 99)
 
 		// Latency distribution buckets
-		r.LatencyDist = calculateLatencyDist(sorted)
+		r.LatencyDist = l.dist()
 	}
 }
 
-// sortFloat64s sorts a slice of float64 in ascending order
-func sortFloat64s(arr []float64) {
-	for i := 0; i < len(arr); i++ {
-		for j := i + 1; j < len(arr); j++ {
-			if arr[j] < arr[i] {
-				arr[i], arr[j] = arr[j], arr[i]
-			}
-		}
+// maxLatencySamples bounds the latencies a session keeps for its
+// percentiles (#1262): about 800KB, and a sort the report screen
+// doesn't notice.
+const maxLatencySamples = 100_000
+
+// latencySamples collects a session's latencies in bounded memory: the
+// exact count, sum, min, max and histogram, plus a uniform reservoir
+// of at most maxLatencySamples for the percentiles, which keeps them
+// within a fraction of a percentile of the exact values.
+type latencySamples struct {
+	kept     []float64
+	count    int64
+	sum      float64
+	min, max float64
+	buckets  [len(report.LatencyBuckets)]int64
+}
+
+// add records one latency in ms. Past maxLatencySamples it replaces a
+// random kept sample with probability kept/count (Algorithm R).
+func (l *latencySamples) add(ms float64) {
+	l.count++
+	l.sum += ms
+	if l.count == 1 || ms < l.min {
+		l.min = ms
+	}
+	if l.count == 1 || ms > l.max {
+		l.max = ms
+	}
+	l.buckets[report.BucketOf(ms)]++
+	if len(l.kept) < maxLatencySamples {
+		l.kept = append(l.kept, ms)
+	} else if i := rand.Int63n(l.count); i < maxLatencySamples {
+		l.kept[i] = ms
+	}
+}
+
+// dist returns the histogram of every latency added.
+func (l *latencySamples) dist() []LatencyBucket {
+	out := make([]LatencyBucket, len(report.LatencyBuckets))
+	for i, b := range report.LatencyBuckets {
+		out[i] = LatencyBucket{Label: b.Label, Count: l.buckets[i]}
 	}
+	return out
 }
 
 // percentile calculates the p-th percentile of sorted data
@@ -1011,37 +1321,6 @@ func percentile(sorted []float64, p float64) float64 {
 	return sorted[index]
 }
 
-// calculateLatencyDist creates latency distribution buckets
-func calculateLatencyDist(sorted []float64) []LatencyBucket {
-	buckets := []LatencyBucket{
-		{Label: "<10ms", Count: 0},
-		{Label: "10-25ms", Count: 0},
-		{Label: "25-50ms", Count: 0},
-		{Label: "50-100ms", Count: 0},
-		{Label: "100-250ms", Count: 0},
-		{Label: ">250ms", Count: 0},
-	}
-
-	for _, l := range sorted {
-		switch {
-		case l < 10:
-			buckets[0].Count++
-		case l < 25:
-			buckets[1].Count++
-		case l < 50:
-			buckets[2].Count++
-		case l < 100:
-			buckets[3].Count++
-		case l < 250:
-			buckets[4].Count++
-		default:
-			buckets[5].Count++
-		}
-	}
-
-	return buckets
-}
-
 // viewReport renders the final report screen
 func (m Model) viewReport() string {
 	var b strings.Builder
@@ -1074,14 +1353,17 @@ func (m Model) viewReport() string {
 	latency := lipgloss.JoinVertical(lipgloss.Left,
 		SubtitleStyle.Render("Latency Distribution"),
 		"",
-		fmt.Sprintf("  %s %s", LabelStyle.Render("Min:"), ValueStyle.Render(fmt.Sprintf("%.2fms", r.MinLatency))),
-		fmt.Sprintf("  %s %s", LabelStyle.Render("Avg:"), ValueStyle.Render(fmt.Sprintf("%.2fms", r.AvgLatency))),
-		fmt.Sprintf("  %s %s", LabelStyle.Render("Max:"), WarningStyle.Render(fmt.Sprintf("%.2fms", r.MaxLatency))),
+		fmt.Sprintf("  %s %s", LabelStyle.Render("Min:"), ValueStyle.Render(r.Latency.Format(r.MinLatency))),
+		fmt.Sprintf("  %s %s", LabelStyle.Render("Avg:"), ValueStyle.Render(r.Latency.Format(r.AvgLatency))),
+		fmt.Sprintf("  %s %s", LabelStyle.Render("Max:"), WarningStyle.Render(r.Latency.Format(r.MaxLatency))),
 		"",
-		fmt.Sprintf("  %s %s", LabelStyle.Render("P50:"), ValueStyle.Render(fmt.Sprintf("%.2fms", r.P50Latency))),
-		fmt.Sprintf("  %s %s", LabelStyle.Render("P95:"), ValueStyle.Render(fmt.Sprintf("%.2fms", r.P95Latency))),
-		fmt.Sprintf("  %s %s", LabelStyle.Render("P99:"), WarningStyle.Render(fmt.Sprintf("%.2fms", r.P99Latency))),
+		fmt.Sprintf("  %s %s", LabelStyle.Render("P50:"), ValueStyle.Render(r.Percentile("p50", r.P50Latency))),
+		fmt.Sprintf("  %s %s", LabelStyle.Render("P95:"), ValueStyle.Render(r.Percentile("p95", r.P95Latency))),
+		fmt.Sprintf("  %s %s", LabelStyle.Render("P99:"), WarningStyle.Render(r.Percentile("p99", r.P99Latency))),
 	)
+	for _, line := range r.ConfidenceLines() {
+		latency = lipgloss.JoinVertical(lipgloss.Left, latency, "  "+WarningStyle.Render(line))
+	}
 
 	// Latency histogram
 	histogram := m.renderLatencyHistogram(r.LatencyDist)
@@ -1090,24 +1372,35 @@ func (m Model) viewReport() string {
 	statusSection := m.renderStatusCodes(r.StatusCodes)
 
 	// Time series mini-chart
-	timeChart := m.renderTimeChart(r.TimeSlots)
+	chartWidth := m.reportBoxWidth(reportChartWidth)
+	timeChart := m.renderTimeChart(r.TimeSlots, r.Interval, r.ExpectedLatency, chartWidth-4)
 
-	// Layout
+	// Layout: side by side when the terminal fits both columns, stacked
+	// otherwise so piped or narrow sessions don't overflow (#1194).
 	leftCol := lipgloss.JoinVertical(lipgloss.Left, overview, "", Divider(30), "", latency)
 	rightCol := lipgloss.JoinVertical(lipgloss.Left, histogram, "", statusSection)
 
-	topSection := lipgloss.JoinHorizontal(lipgloss.Top,
-		BorderStyle.Width(35).Render(leftCol),
-		"  ",
-		BorderStyle.Width(35).Render(rightCol),
-	)
+	var topSection string
+	if m.width == 0 || m.width >= reportWideWidth {
+		topSection = lipgloss.JoinHorizontal(lipgloss.Top,
+			BorderStyle.Width(reportColumnWidth).Render(leftCol),
+			"  ",
+			BorderStyle.Width(reportColumnWidth).Render(rightCol),
+		)
+	} else {
+		colWidth := m.reportBoxWidth(reportChartWidth)
+		topSection = lipgloss.JoinVertical(lipgloss.Left,
+			BorderStyle.Width(colWidth).Render(leftCol),
+			BorderStyle.Width(colWidth).Render(rightCol),
+		)
+	}
 
 	b.WriteString(lipgloss.Place(m.width, 0, lipgloss.Center, lipgloss.Top, topSection))
 	b.WriteString("\n\n")
 
 	// Time chart (full width)
 	if len(r.TimeSlots) > 0 {
-		chartBox := BorderStyle.Width(72).Render(timeChart)
+		chartBox := BorderStyle.Width(chartWidth).Render(timeChart)
 		b.WriteString(lipgloss.Place(m.width, 0, lipgloss.Center, lipgloss.Top, chartBox))
 		b.WriteString("\n\n")
 	}
@@ -1119,6 +1412,39 @@ func (m Model) viewReport() string {
 	return b.String()
 }
 
+// timeChartFullWidth is the widest time chart row, latency column included.
+const timeChartFullWidth = 52
+
+// Report layout widths. A box's rendered width is its style width plus
+// the two border cells.
+const (
+	reportColumnWidth = 35
+	reportChartWidth  = 72
+	reportWideWidth   = 2*(reportColumnWidth+2) + 2
+	reportMinBoxWidth = 30
+)
+
+// reportBoxWidth clamps a box width to the terminal. An unknown width (no
+// WindowSizeMsg yet) keeps the preferred size.
+func (m Model) reportBoxWidth(want int) int {
+	if m.width == 0 {
+		return want
+	}
+	if avail := m.width - 2; avail < want {
+		if avail < reportMinBoxWidth {
+			return reportMinBoxWidth
+		}
+		return avail
+	}
+	return want
+}
+
+// HasReport reports whether the TUI ended on the report screen, i.e. a run
+// happened and Report is populated.
+func (m Model) HasReport() bool {
+	return m.screen == ScreenReport
+}
+
 // coloredSuccessRate returns success rate with appropriate color
 func (m Model) coloredSuccessRate(rate float64) string {
 	rateStr := fmt.Sprintf("%.2f%%", rate)
@@ -1211,14 +1537,16 @@ func (m Model) renderStatusCodes(codes map[int]int64) string {
 	return b.String()
 }
 
-// renderTimeChart renders a time-series table with detailed stats
-func (m Model) renderTimeChart(slots []TimeSlot) string {
+// renderTimeChart renders a time-series table with detailed stats. width
+// is the content width available; below the full table width the latency
+// column is dropped rather than letting rows wrap.
+func (m Model) renderTimeChart(slots []TimeSlot, interval, expected time.Duration, width int) string {
 	if len(slots) == 0 {
 		return DimStyle.Render("No time series data collected (test was too short)")
 	}
 
 	var b strings.Builder
-	b.WriteString(SubtitleStyle.Render("Timeline Summary (5s intervals)"))
+	b.WriteString(SubtitleStyle.Render(fmt.Sprintf("Timeline Summary (%s intervals)", interval)))
 	b.WriteString("\n\n")
 
 	// Calculate stats
@@ -1245,11 +1573,20 @@ func (m Model) renderTimeChart(slots []TimeSlot) string {
 		LabelStyle.Render("Min TPS:"), minTPS,
 		LabelStyle.Render("Avg TPS:"), avgTPS,
 		LabelStyle.Render("Max TPS:"), maxTPS))
+	if expected > 0 {
+		b.WriteString(fmt.Sprintf("  %s %s\n", LabelStyle.Render("Expected latency:"), ValueStyle.Render("≤ "+expected.String())))
+	}
 	b.WriteString("\n")
 
 	// Table header
-	b.WriteString(DimStyle.Render("  Time       TPS     Reqs    Errs   Latency\n"))
-	b.WriteString(DimStyle.Render("  " + strings.Repeat("-", 48) + "\n"))
+	compact := width < timeChartFullWidth
+	if compact {
+		b.WriteString(DimStyle.Render("  Time       TPS     Reqs    Errs\n"))
+		b.WriteString(DimStyle.Render("  " + strings.Repeat("-", 36) + "\n"))
+	} else {
+		b.WriteString(DimStyle.Render("  Time       TPS     Reqs    Errs   Latency\n"))
+		b.WriteString(DimStyle.Render("  " + strings.Repeat("-", 48) + "\n"))
+	}
 
 	// Show last 8 slots (most recent data)
 	startIdx := 0
@@ -1258,13 +1595,7 @@ func (m Model) renderTimeChart(slots []TimeSlot) string {
 	}
 
 	for i, slot := range slots[startIdx:] {
-		timeStart := (startIdx + i) * 5
-		timeEnd := timeStart + 5
-
-		// Format time as MM:SS
-		timeStr := fmt.Sprintf("%02d:%02d-%02d:%02d",
-			timeStart/60, timeStart%60,
-			timeEnd/60, timeEnd%60)
+		timeStr := report.SlotRange(startIdx+i, interval)
 
 		// Spike indicator
 		spikeMarker := " "
@@ -1278,13 +1609,32 @@ func (m Model) renderTimeChart(slots []TimeSlot) string {
 			errStr = ErrorStyle.Render(errStr)
 		}
 
-		b.WriteString(fmt.Sprintf("  %s %s%6.0f  %6d  %6s  %6.1fms\n",
+		// Latency above the expected latency (#1209)
+		slowMarker := ""
+		latStr := fmt.Sprintf("%8s", m.latency.Format(slot.AvgLatency))
+		if report.OverExpected(slot.AvgLatency, expected) {
+			slowMarker = ErrorStyle.Render(" !")
+			latStr = ErrorStyle.Render(latStr)
+		}
+
+		if compact {
+			b.WriteString(fmt.Sprintf("  %s %s%6.0f  %6d  %6s%s\n",
+				DimStyle.Render(timeStr),
+				spikeMarker,
+				slot.TPS,
+				slot.Requests,
+				errStr,
+				slowMarker))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("  %s %s%6.0f  %6d  %6s  %s%s\n",
 			DimStyle.Render(timeStr),
 			spikeMarker,
 			slot.TPS,
 			slot.Requests,
 			errStr,
-			slot.AvgLatency))
+			latStr,
+			slowMarker))
 	}
 
 	if startIdx > 0 {
@@ -1293,6 +1643,10 @@ func (m Model) renderTimeChart(slots []TimeSlot) string {
 
 	b.WriteString("\n")
 	b.WriteString(DimStyle.Render("  * = spike detected (>1.5x avg TPS)"))
+	if expected > 0 {
+		b.WriteString("\n")
+		b.WriteString(DimStyle.Render(fmt.Sprintf("  ! = latency above expected (%s)", expected)))
+	}
 
 	return b.String()
 }
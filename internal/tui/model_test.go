@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// selectionSort is the sort the report used before sort.Float64s
+// (#1262), kept as the reference it must agree with.
+func selectionSort(arr []float64) {
+	for i := 0; i < len(arr); i++ {
+		for j := i + 1; j < len(arr); j++ {
+			if arr[j] < arr[i] {
+				arr[i], arr[j] = arr[j], arr[i]
+			}
+		}
+	}
+}
+
+func TestPercentile_SameAfterSortSwitch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 7, 100, 1001} {
+		data := make([]float64, n)
+		for i := range data {
+			// Rounded so duplicates are common.
+			data[i] = math.Round(rng.ExpFloat64()*20*10) / 10
+		}
+		old := append([]float64(nil), data...)
+		selectionSort(old)
+		cur := append([]float64(nil), data...)
+		sort.Float64s(cur)
+
+		for p := 0.0; p <= 100; p += 0.5 {
+			if a, b := percentile(old, p), percentile(cur, p); a != b {
+				t.Fatalf("n=%d p%v: %v with sort.Float64s, %v before", n, p, b, a)
+			}
+		}
+	}
+}
+
+func TestGenerateReport_ReservoirPercentilesNearExact(t *testing.T) {
+	const n = 3 * maxLatencySamples
+	m := NewModel()
+	rng := rand.New(rand.NewSource(2))
+	all := make([]float64, n)
+	for i := range all {
+		all[i] = rng.ExpFloat64() * 30 // ms, long-tailed
+		m.latencies.add(all[i])
+	}
+	m.generateReport()
+	r := m.Report
+
+	if len(m.latencies.kept) != maxLatencySamples {
+		t.Fatalf("reservoir holds %d samples, want %d", len(m.latencies.kept), maxLatencySamples)
+	}
+	sort.Float64s(all)
+	if r.MinLatency != all[0] || r.MaxLatency != all[n-1] {
+		t.Fatalf("min/max = %v/%v, want the exact %v/%v", r.MinLatency, r.MaxLatency, all[0], all[n-1])
+	}
+	var total int64
+	for _, b := range r.LatencyDist {
+		total += b.Count
+	}
+	if total != n {
+		t.Fatalf("histogram counts %d latencies, want all %d", total, n)
+	}
+
+	// A reservoir of 100k puts a percentile's rank within about 0.03
+	// points of the exact one (one standard error at p99); allow 0.25.
+	for _, c := range []struct {
+		p   float64
+		got float64
+	}{{50, r.P50Latency}, {95, r.P95Latency}, {99, r.P99Latency}} {
+		lo, hi := percentile(all, c.p-0.25), percentile(all, c.p+0.25)
+		if c.got < lo || c.got > hi {
+			t.Errorf("p%v = %.3fms, want within the exact p%v..p%v (%.3f..%.3fms)",
+				c.p, c.got, c.p-0.25, c.p+0.25, lo, hi)
+		}
+	}
+}
+
+func TestMultiplier_ShowsWhatTheDaemonApplied(t *testing.T) {
+	m := NewModel()
+	m.screen = ScreenRunning
+	var sent []float64
+	fail := false
+	m.SetMultiplierSender(func(mult float64) (float64, error) {
+		sent = append(sent, mult)
+		if fail {
+			return 0, errors.New("daemon not running")
+		}
+		return math.Min(mult, 1.1), nil // the daemon caps it
+	})
+
+	press := func(key tea.KeyType) {
+		t.Helper()
+		next, cmd := m.Update(tea.KeyMsg{Type: key})
+		m = next.(Model)
+		if cmd != nil {
+			next, _ = m.Update(cmd())
+			m = next.(Model)
+		}
+	}
+
+	press(tea.KeyUp)
+	if m.Multiplier != 1.1 || len(sent) != 1 || sent[0] != 1.1 {
+		t.Fatalf("after up: multiplier %v, sent %v", m.Multiplier, sent)
+	}
+	press(tea.KeyUp)
+	if m.Multiplier != 1.1 || sent[1] != 1.2 {
+		t.Fatalf("asked for 1.2, daemon applied 1.1: showing %v, sent %v", m.Multiplier, sent)
+	}
+
+	fail = true
+	press(tea.KeyDown)
+	if m.Multiplier != 1.1 || m.multiplierErr == nil || m.wantMultiplier != 1.1 {
+		t.Fatalf("refused change: multiplier %v, want %v, err %v", m.Multiplier, m.wantMultiplier, m.multiplierErr)
+	}
+}
@@ -0,0 +1,126 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kar98k/internal/report"
+)
+
+// RenderReportText renders the report as plain text: no ANSI styling and
+// no box drawing, so it survives logs, pipes and email bodies (#1194).
+// Unlike the on-screen chart, the timeline lists every interval.
+func RenderReportText(r ReportData) string {
+	var b strings.Builder
+
+	b.WriteString("kar98k TEST REPORT\n")
+	b.WriteString(strings.Repeat("=", 18) + "\n\n")
+
+	b.WriteString("Overview\n")
+	b.WriteString(fmt.Sprintf("  Duration:        %s\n", r.TotalDuration.Round(time.Second)))
+	b.WriteString(fmt.Sprintf("  Total Requests:  %d\n", r.TotalRequests))
+	b.WriteString(fmt.Sprintf("  Errors:          %d\n", r.TotalErrors))
+	b.WriteString(fmt.Sprintf("  Success Rate:    %.2f%%\n", r.SuccessRate))
+	b.WriteString(fmt.Sprintf("  TPS (avg/peak):  %.1f / %.1f\n\n", r.AvgTPS, r.PeakTPS))
+
+	if md := r.Metadata; md.Version != "" {
+		b.WriteString("Run\n")
+		version := md.Version
+		if md.GitCommit != "" {
+			version += " (" + md.GitCommit + ")"
+		}
+		b.WriteString(fmt.Sprintf("  kar:             %s\n", version))
+		if md.Host != "" {
+			b.WriteString(fmt.Sprintf("  Host:            %s\n", md.Host))
+		}
+		b.WriteString(fmt.Sprintf("  Platform:        %s/%s, %s\n", md.OS, md.Arch, md.GoVersion))
+		b.WriteString(fmt.Sprintf("  Started:         %s\n", md.Started.Format(time.RFC3339)))
+		b.WriteString(fmt.Sprintf("  Ended:           %s\n", md.Ended.Format(time.RFC3339)))
+		tags := make([]string, 0, len(md.Tags))
+		for k, v := range md.Tags {
+			tags = append(tags, k+"="+v)
+		}
+		sort.Strings(tags)
+		for _, t := range tags {
+			b.WriteString(fmt.Sprintf("  Tag:             %s\n", t))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("Latency\n")
+	lat := r.Latency.Format
+	b.WriteString(fmt.Sprintf("  Min: %s  Avg: %s  Max: %s\n", lat(r.MinLatency), lat(r.AvgLatency), lat(r.MaxLatency)))
+	b.WriteString(fmt.Sprintf("  P50: %s  P95: %s  P99: %s\n", r.Percentile("p50", r.P50Latency), r.Percentile("p95", r.P95Latency), r.Percentile("p99", r.P99Latency)))
+	for _, line := range r.ConfidenceLines() {
+		b.WriteString("  Warning: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	if len(r.LatencyDist) > 0 {
+		b.WriteString("Latency Histogram\n")
+		var maxCount int64 = 1
+		for _, bucket := range r.LatencyDist {
+			if bucket.Count > maxCount {
+				maxCount = bucket.Count
+			}
+		}
+		const barWidth = 20
+		for _, bucket := range r.LatencyDist {
+			barLen := int(float64(bucket.Count) / float64(maxCount) * barWidth)
+			if barLen == 0 && bucket.Count > 0 {
+				barLen = 1
+			}
+			b.WriteString(fmt.Sprintf("  %9s %s%s %d\n", bucket.Label,
+				strings.Repeat("=", barLen), strings.Repeat("-", barWidth-barLen), bucket.Count))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.StatusCodes) > 0 {
+		b.WriteString("Status Codes\n")
+		codes := make([]int, 0, len(r.StatusCodes))
+		for code := range r.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			b.WriteString(fmt.Sprintf("  %d: %d\n", code, r.StatusCodes[code]))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.TimeSlots) > 0 {
+		var sumTPS float64
+		for _, slot := range r.TimeSlots {
+			sumTPS += slot.TPS
+		}
+		avgTPS := sumTPS / float64(len(r.TimeSlots))
+
+		b.WriteString(fmt.Sprintf("Timeline (%s intervals)\n", r.Interval))
+		if r.ExpectedLatency > 0 {
+			b.WriteString(fmt.Sprintf("  Expected latency: <= %s\n", r.ExpectedLatency))
+		}
+		b.WriteString("  Time          TPS     Reqs    Errs   Latency\n")
+		b.WriteString("  " + strings.Repeat("-", 48) + "\n")
+		for i, slot := range r.TimeSlots {
+			marker := " "
+			if slot.TPS > avgTPS*1.5 {
+				marker = "*"
+			}
+			slow := ""
+			if report.OverExpected(slot.AvgLatency, r.ExpectedLatency) {
+				slow = " !"
+			}
+			b.WriteString(fmt.Sprintf("  %s %s%6.0f  %6d  %6d  %8s%s\n",
+				report.SlotRange(i, r.Interval), marker, slot.TPS, slot.Requests, slot.Errors, lat(slot.AvgLatency), slow))
+		}
+		b.WriteString("\n  * = spike detected (>1.5x avg TPS)\n")
+		if r.ExpectedLatency > 0 {
+			b.WriteString(fmt.Sprintf("  ! = latency above expected (%s)\n", r.ExpectedLatency))
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,26 @@
+package worker
+
+import (
+	"log"
+
+	"github.com/kar98k/internal/template"
+)
+
+// expandBody returns body with its template placeholders expanded
+// (#1251). Each distinct body is parsed once and cached, so a request
+// costs only its own expansion. Load rejects malformed templates; one
+// that slips through (e.g. a hand-built config) is sent as is.
+func (p *Pool) expandBody(body string) []byte {
+	t, ok := p.templates.Load(body)
+	if !ok {
+		parsed, err := template.Parse(body)
+		if err != nil {
+			log.Printf("[worker] body template: %v; sending the body unexpanded", err)
+		}
+		t, _ = p.templates.LoadOrStore(body, parsed)
+	}
+	if t.(*template.Template) == nil {
+		return []byte(body)
+	}
+	return t.(*template.Template).Append(nil, &p.bodyCounter)
+}
@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/hdrbounds"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// CacheStat is one cache_bust target's hit/miss breakdown (#1206).
+// Hits and Misses only count responses that carried the configured
+// status header.
+type CacheStat struct {
+	Target    string  `json:"target"`
+	Varied    int64   `json:"varied"`
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	HitPct    float64 `json:"hit_pct"`
+	HitP50Ms  float64 `json:"hit_p50_ms"`
+	HitP95Ms  float64 `json:"hit_p95_ms"`
+	HitP99Ms  float64 `json:"hit_p99_ms"`
+	MissP50Ms float64 `json:"miss_p50_ms"`
+	MissP95Ms float64 `json:"miss_p95_ms"`
+	MissP99Ms float64 `json:"miss_p99_ms"`
+}
+
+// cacheTarget is one target's varied-request count and hit/miss
+// latency histograms.
+type cacheTarget struct {
+	varied int64
+	hit    *hdrhistogram.Histogram
+	miss   *hdrhistogram.Histogram
+}
+
+// cacheStats holds the cache_bust targets' figures, under its own lock
+// so cache targets don't contend on latMu.
+type cacheStats struct {
+	mu      sync.Mutex
+	targets map[string]*cacheTarget
+}
+
+// get returns target's entry, creating it. Callers hold mu.
+func (c *cacheStats) get(target string) *cacheTarget {
+	if c.targets == nil {
+		c.targets = make(map[string]*cacheTarget)
+	}
+	t, ok := c.targets[target]
+	if !ok {
+		t = &cacheTarget{
+			hit:  hdrhistogram.New(hdrbounds.Min, hdrbounds.Max, int(hdrbounds.SigFigs)),
+			miss: hdrhistogram.New(hdrbounds.Min, hdrbounds.Max, int(hdrbounds.SigFigs)),
+		}
+		c.targets[target] = t
+	}
+	return t
+}
+
+// bustCache varies req per the target's cache_bust settings and asks
+// for the cache status header.
+func (p *Pool) bustCache(name string, cb *config.CacheBust, req *protocol.Request) {
+	req.CaptureHeader = cb.StatusHeader
+	if cb.Fraction <= 0 || rand.Float64() >= cb.Fraction {
+		return
+	}
+	req.URL = cb.Vary(req.URL, rand.Uint64())
+	p.cache.mu.Lock()
+	p.cache.get(name).varied++
+	p.cache.mu.Unlock()
+}
+
+// recordCache files a response under hit or miss when it carried the
+// cache status header.
+func (p *Pool) recordCache(name string, cb *config.CacheBust, resp *protocol.Response) {
+	hit, ok := cb.Hit(resp.Header)
+	if !ok || resp.Error != nil {
+		return
+	}
+	micros := resp.Duration.Microseconds()
+	if micros < hdrbounds.Min {
+		micros = hdrbounds.Min
+	} else if micros > hdrbounds.Max {
+		micros = hdrbounds.Max
+	}
+
+	p.cache.mu.Lock()
+	t := p.cache.get(name)
+	if hit {
+		_ = t.hit.RecordValue(micros)
+	} else {
+		_ = t.miss.RecordValue(micros)
+	}
+	p.cache.mu.Unlock()
+	p.metrics.RecordCacheResult(name, hit, resp.Duration.Seconds())
+}
+
+// CacheStats returns the cache_bust targets' breakdown, sorted by
+// target. Empty unless some target sets cache_bust.
+func (p *Pool) CacheStats() []CacheStat {
+	p.cache.mu.Lock()
+	defer p.cache.mu.Unlock()
+
+	out := make([]CacheStat, 0, len(p.cache.targets))
+	for name, t := range p.cache.targets {
+		st := CacheStat{
+			Target: name,
+			Varied: t.varied,
+			Hits:   t.hit.TotalCount(),
+			Misses: t.miss.TotalCount(),
+		}
+		if n := st.Hits + st.Misses; n > 0 {
+			st.HitPct = float64(st.Hits) / float64(n) * 100
+		}
+		st.HitP50Ms, st.HitP95Ms, st.HitP99Ms = quantilesMs(t.hit)
+		st.MissP50Ms, st.MissP95Ms, st.MissP99Ms = quantilesMs(t.miss)
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Target < out[j].Target })
+	return out
+}
+
+func quantilesMs(h *hdrhistogram.Histogram) (p50, p95, p99 float64) {
+	if h.TotalCount() == 0 {
+		return 0, 0, 0
+	}
+	return float64(h.ValueAtQuantile(50)) / 1000.0,
+		float64(h.ValueAtQuantile(95)) / 1000.0,
+		float64(h.ValueAtQuantile(99)) / 1000.0
+}
@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"sort"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// ConnPoolStat is kar's connection pool to one host over one protocol
+// (#1210). Targets sharing a host share its connections, except
+// targets with max_conns_per_host, whose dedicated clients are added
+// in.
+type ConnPoolStat struct {
+	Protocol string `json:"protocol"`
+	Host     string `json:"host"`
+	Open     int64  `json:"open"`
+	Idle     int64  `json:"idle"`
+	InUse    int64  `json:"in_use"`
+}
+
+// ConnPoolStats returns the connection pools of every client that can
+// report them, sorted by protocol and host.
+func (p *Pool) ConnPoolStats() []ConnPoolStat {
+	type key struct{ proto, host string }
+	sums := make(map[key]*ConnPoolStat)
+	add := func(proto config.Protocol, c protocol.Client) {
+		r, ok := c.(protocol.ConnReporter)
+		if !ok {
+			return
+		}
+		for _, s := range r.ConnStats() {
+			k := key{string(proto), s.Host}
+			sum, ok := sums[k]
+			if !ok {
+				sum = &ConnPoolStat{Protocol: k.proto, Host: k.host}
+				sums[k] = sum
+			}
+			sum.Open += s.Open
+			sum.Idle += s.Idle
+			sum.InUse += s.InUse
+		}
+	}
+	for proto, c := range p.clients {
+		add(proto, c)
+	}
+	p.targetClients.Range(func(_, c any) bool {
+		proto := config.ProtocolHTTP
+		if _, ok := c.(protocol.StreamReporter); ok {
+			proto = config.ProtocolHTTP2
+		}
+		add(proto, c.(protocol.Client))
+		return true
+	})
+
+	out := make([]ConnPoolStat, 0, len(sums))
+	for _, s := range sums {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Protocol != out[j].Protocol {
+			return out[i].Protocol < out[j].Protocol
+		}
+		return out[i].Host < out[j].Host
+	})
+	return out
+}
+
+// HTTP2Stat is one http2 target's connections and streams per host
+// (#1248).
+type HTTP2Stat struct {
+	Target string `json:"target"`
+	protocol.StreamStats
+}
+
+// HTTP2Stats returns the streams of every http2 target with its own
+// connection settings, sorted by target and host.
+func (p *Pool) HTTP2Stats() []HTTP2Stat {
+	var out []HTTP2Stat
+	p.streamReporters.Range(func(name, r any) bool {
+		for _, s := range r.(protocol.StreamReporter).StreamStats() {
+			out = append(out, HTTP2Stat{Target: name.(string), StreamStats: s})
+		}
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Target != out[j].Target {
+			return out[i].Target < out[j].Target
+		}
+		return out[i].Host < out[j].Host
+	})
+	return out
+}
+
+// recordConnPool publishes ConnPoolStats as gauges; measureTPS calls
+// it every second.
+func (p *Pool) recordConnPool() {
+	for _, s := range p.ConnPoolStats() {
+		p.metrics.SetConnPool(s.Protocol, s.Host, s.Open, s.Idle, s.InUse)
+	}
+}
@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/dataset"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// DataSources maps target name to the row source of its data_file
+// (#1211).
+type DataSources map[string]*dataset.Source
+
+// LoadDataSources opens the data_file of every target that has one, so
+// a missing or malformed file fails the start rather than the run.
+func LoadDataSources(targets []config.Target) (DataSources, error) {
+	out := make(DataSources)
+	for _, t := range targets {
+		df := t.DataFile
+		if df == nil {
+			continue
+		}
+		set, err := dataset.Load(df.Path)
+		if err != nil {
+			return nil, fmt.Errorf("target %q data_file: %w", t.Name, err)
+		}
+		src, err := dataset.NewSource(set, df.Order, df.Loop, df.WeightColumn, time.Now().UnixNano())
+		if err != nil {
+			return nil, fmt.Errorf("target %q data_file: %w", t.Name, err)
+		}
+		out[t.Name] = src
+	}
+	return out, nil
+}
+
+// dataFiles holds the targets' row sources and which exhausted ones
+// have been logged.
+type dataFiles struct {
+	sources   DataSources
+	exhausted sync.Map // target name -> true
+}
+
+// SetDataSources sets the targets' row sources. Call before Start.
+func (p *Pool) SetDataSources(ds DataSources) {
+	p.data.sources = ds
+}
+
+// nextRow draws the row for a request to target. ok is false when the
+// target's sequential data_file has run out; the request is then not
+// sent.
+func (p *Pool) nextRow(target string) (row dataset.Row, has, ok bool) {
+	src := p.data.sources[target]
+	if src == nil {
+		return dataset.Row{}, false, true
+	}
+	row, ok = src.Next()
+	if !ok {
+		if _, logged := p.data.exhausted.LoadOrStore(target, true); !logged {
+			log.Printf("[worker] data_file of target %q exhausted; no more requests are sent to it", target)
+		}
+		return dataset.Row{}, false, false
+	}
+	return row, true, true
+}
+
+// applyRow substitutes row into req's URL, header values and body.
+// Headers get a fresh map: the target's is shared by every request.
+func applyRow(req *protocol.Request, row dataset.Row) {
+	req.URL = row.ExpandURL(req.URL)
+	if len(req.Headers) > 0 {
+		headers := make(map[string]string, len(req.Headers))
+		for k, v := range req.Headers {
+			headers[k] = row.Expand(v)
+		}
+		req.Headers = headers
+	}
+	if len(req.Body) > 0 {
+		req.Body = []byte(row.Expand(string(req.Body)))
+	}
+}
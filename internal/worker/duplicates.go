@@ -0,0 +1,168 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// maxDuplicateExamples is how many repeated values a DuplicateStat
+// lists.
+const maxDuplicateExamples = 5
+
+// DuplicateStat is a duplicates target's check over the run so far
+// (#1256).
+type DuplicateStat struct {
+	Target string `json:"target"`
+	// Checked counts the successful responses compared; Duplicates is
+	// those repeating an earlier one's value.
+	Checked    int64 `json:"checked"`
+	Duplicates int64 `json:"duplicates"`
+	// Markers counts responses carrying duplicates.marker.
+	Markers int64 `json:"markers,omitempty"`
+	// Missing counts responses without the field, or whose captured
+	// body didn't parse as JSON.
+	Missing int64 `json:"missing,omitempty"`
+	// Untracked counts values first seen after max_tracked was
+	// reached, which later responses aren't compared against.
+	Untracked int64 `json:"untracked,omitempty"`
+	// Examples are the first few repeated values.
+	Examples []string `json:"examples,omitempty"`
+}
+
+// duplicateTracker holds the duplicates targets' seen values, as
+// 64-bit hashes, under its own lock like headerTracks.
+type duplicateTracker struct {
+	mu      sync.Mutex
+	targets map[string]*duplicateState
+}
+
+type duplicateState struct {
+	stat DuplicateStat
+	seen map[uint64]struct{}
+}
+
+// checkDuplicate reports whether resp is a duplicate under d: it
+// carries the marker or, when success, repeats a value seen before.
+func (p *Pool) checkDuplicate(target string, d *config.Duplicates, resp *protocol.Response, success bool) bool {
+	marked := d.Marker != "" && bytes.Contains(resp.Body, []byte(d.Marker))
+	compares := d.Field != "" || d.Body
+	var key string
+	var found bool
+	if success && compares {
+		key, found = duplicateKey(d, resp.Body)
+	}
+
+	t := &p.dups
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.targets == nil {
+		t.targets = make(map[string]*duplicateState)
+	}
+	s, ok := t.targets[target]
+	if !ok {
+		s = &duplicateState{stat: DuplicateStat{Target: target}, seen: make(map[uint64]struct{})}
+		t.targets[target] = s
+	}
+	if marked {
+		s.stat.Markers++
+	}
+	if !success || !compares {
+		return marked
+	}
+	s.stat.Checked++
+	if !found {
+		s.stat.Missing++
+		return marked
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	if _, dup := s.seen[sum]; dup {
+		s.stat.Duplicates++
+		if len(s.stat.Examples) < maxDuplicateExamples {
+			s.stat.Examples = append(s.stat.Examples, duplicateExample(d, key, sum))
+		}
+		return true
+	}
+	if len(s.seen) >= d.Tracked() {
+		s.stat.Untracked++
+		return marked
+	}
+	s.seen[sum] = struct{}{}
+	return marked
+}
+
+// duplicateKey is what d compares in body: the whole body or its
+// field's value. False when the field is missing.
+func duplicateKey(d *config.Duplicates, body []byte) (string, bool) {
+	if d.Body {
+		return string(body), true
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return "", false
+	}
+	for _, seg := range strings.Split(d.Field, ".") {
+		switch n := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = n[seg]; !ok {
+				return "", false
+			}
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(n) {
+				return "", false
+			}
+			v = n[i]
+		default:
+			return "", false
+		}
+	}
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	}
+	b, _ := json.Marshal(v)
+	return string(b), true
+}
+
+// duplicateExample is how a repeated value is listed: the field's
+// value, shortened, or the body's hash.
+func duplicateExample(d *config.Duplicates, key string, sum uint64) string {
+	if d.Body {
+		return "body " + strconv.FormatUint(sum, 16)
+	}
+	if len(key) > 64 {
+		return key[:61] + "..."
+	}
+	return key
+}
+
+// Duplicates returns every duplicates target's check so far, sorted by
+// target.
+func (p *Pool) Duplicates() []DuplicateStat {
+	t := &p.dups
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]DuplicateStat, 0, len(t.targets))
+	for _, s := range t.targets {
+		st := s.stat
+		st.Examples = append([]string(nil), s.stat.Examples...)
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Target < out[j].Target })
+	return out
+}
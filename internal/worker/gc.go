@@ -0,0 +1,240 @@
+package worker
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// gcPollInterval is how often the GC watcher reads pause history. A
+// request that ends after the latest read is held until the next one,
+// since a pause it overlapped may not have been read yet, so its
+// overlap is settled up to one interval late but never missed.
+const gcPollInterval = 10 * time.Millisecond
+
+// gcHistory matches the runtime's own pause history (MemStats.PauseNs).
+const gcHistory = 256
+
+// gcPause is one stop-the-world window.
+type gcPause struct {
+	start, end time.Time
+}
+
+// gcWatch records kar's own GC pauses and which requests overlapped
+// them (#1196). Pauses come from debug.ReadGCStats, which reports the
+// same end timestamps and durations as GODEBUG=gctrace without
+// parsing stderr, and unlike runtime.ReadMemStats doesn't stop the
+// world itself.
+type gcWatch struct {
+	enabled atomic.Bool
+
+	mu      sync.Mutex
+	pauses  [gcHistory]gcPause // ring, newest at (next-1)
+	next    int
+	kept    int
+	lastNum int64
+	// readAt is when the pause history was last read: every pause that
+	// ended before it is in pauses. pending are the requests that
+	// ended after it, checked at the next read.
+	readAt  time.Time
+	pending []gcPause
+
+	count      int64 // pauses seen
+	pauseTotal time.Duration
+	pauseMax   time.Duration
+
+	overlapped int64 // requests in flight during a pause
+	selfNanos  int64 // summed request/pause overlap
+}
+
+// GCImpact summarises how kar's own GC pauses overlapped the run.
+type GCImpact struct {
+	Pauses       int64   `json:"pauses"`
+	PauseTotalMs float64 `json:"pause_total_ms"`
+	PauseMaxMs   float64 `json:"pause_max_ms"`
+	// Overlapped counts requests that were in flight during a pause;
+	// OverlappedPct is their share of all requests.
+	Overlapped    int64   `json:"overlapped_requests"`
+	OverlappedPct float64 `json:"overlapped_pct"`
+	// SelfInflictedMs sums each overlapped request's time inside a
+	// pause: the latency kar itself added to the measurements.
+	SelfInflictedMs float64 `json:"self_inflicted_ms"`
+}
+
+// SetGCImpact turns GC pause tracking on. Call before Start.
+func (p *Pool) SetGCImpact(on bool) {
+	p.gc.enabled.Store(on)
+}
+
+// start marks the pauses so far as seen, so only the run's own are
+// counted. Called by Start before watchGC.
+func (g *gcWatch) start() {
+	var stats debug.GCStats
+	now := time.Now()
+	debug.ReadGCStats(&stats)
+	g.mu.Lock()
+	g.lastNum = stats.NumGC
+	g.readAt = now
+	g.mu.Unlock()
+}
+
+// watchGC polls the runtime's pause history until ctx ends. Started by
+// Start when GC impact tracking is on.
+func (p *Pool) watchGC(ctx context.Context) {
+	ticker := time.NewTicker(gcPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.readGC()
+		}
+	}
+}
+
+// readGC reads the pause history and settles the requests waiting on
+// it. Stop calls it once more after the last request, so none are left
+// pending.
+func (p *Pool) readGC() {
+	var stats debug.GCStats
+	// Taken before the read: a pause that ended earlier is in stats.
+	now := time.Now()
+	debug.ReadGCStats(&stats)
+	for _, d := range p.gc.ingest(stats, now) {
+		p.metrics.RecordGCOverlap(d.Seconds())
+	}
+}
+
+// ingest adds the pauses that happened since the previous read, made
+// at readAt, and returns the overlaps of the pending requests that
+// ended before it, which it has counted. stats.Pause and
+// stats.PauseEnd list the most recent first.
+func (g *gcWatch) ingest(stats debug.GCStats, readAt time.Time) []time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n := int(stats.NumGC - g.lastNum)
+	g.lastNum = stats.NumGC
+	if n > len(stats.Pause) {
+		n = len(stats.Pause)
+	}
+	if n > len(stats.PauseEnd) {
+		n = len(stats.PauseEnd)
+	}
+	for i := n - 1; i >= 0; i-- {
+		g.add(stats.PauseEnd[i].Add(-stats.Pause[i]), stats.PauseEnd[i])
+	}
+	if readAt.After(g.readAt) {
+		g.readAt = readAt
+	}
+
+	var settled []time.Duration
+	kept := g.pending[:0]
+	for _, r := range g.pending {
+		if r.end.After(g.readAt) {
+			kept = append(kept, r)
+			continue
+		}
+		if d := g.overlap(r.start, r.end); d > 0 {
+			g.countOverlap(d)
+			settled = append(settled, d)
+		}
+	}
+	g.pending = kept
+	return settled
+}
+
+// add records one pause. Caller holds mu.
+func (g *gcWatch) add(start, end time.Time) {
+	g.pauses[g.next] = gcPause{start: start, end: end}
+	g.next = (g.next + 1) % gcHistory
+	if g.kept < gcHistory {
+		g.kept++
+	}
+	d := end.Sub(start)
+	g.count++
+	g.pauseTotal += d
+	if d > g.pauseMax {
+		g.pauseMax = d
+	}
+}
+
+// overlap returns how much of [start, end] fell inside known pauses.
+// Caller holds mu.
+func (g *gcWatch) overlap(start, end time.Time) time.Duration {
+	var total time.Duration
+	for i := 1; i <= g.kept; i++ {
+		gp := g.pauses[(g.next-i+gcHistory)%gcHistory]
+		if !gp.end.After(start) {
+			break // older pauses all ended before the request began
+		}
+		lo, hi := gp.start, gp.end
+		if lo.Before(start) {
+			lo = start
+		}
+		if hi.After(end) {
+			hi = end
+		}
+		if hi.After(lo) {
+			total += hi.Sub(lo)
+		}
+	}
+	return total
+}
+
+// countOverlap counts one request that spent d inside pauses.
+func (g *gcWatch) countOverlap(d time.Duration) {
+	atomic.AddInt64(&g.overlapped, 1)
+	atomic.AddInt64(&g.selfNanos, int64(d))
+}
+
+// check returns how much of the request [start, end] fell inside
+// pauses, or holds it for the next read when it ended after the last
+// one: the world is running whenever a request's end is timestamped,
+// so any pause it overlapped ended before that, and is known once the
+// history has been read past it.
+func (g *gcWatch) check(start, end time.Time) (d time.Duration, settled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if end.After(g.readAt) {
+		g.pending = append(g.pending, gcPause{start: start, end: end})
+		return 0, false
+	}
+	return g.overlap(start, end), true
+}
+
+// recordGCOverlap flags a completed request that overlapped a pause.
+func (p *Pool) recordGCOverlap(start, end time.Time) {
+	if !p.gc.enabled.Load() {
+		return
+	}
+	d, settled := p.gc.check(start, end)
+	if !settled || d <= 0 {
+		return
+	}
+	p.gc.countOverlap(d)
+	p.metrics.RecordGCOverlap(d.Seconds())
+}
+
+// GCImpact returns the GC summary, or nil when tracking is off.
+func (p *Pool) GCImpact() *GCImpact {
+	g := &p.gc
+	if !g.enabled.Load() {
+		return nil
+	}
+	g.mu.Lock()
+	out := &GCImpact{
+		Pauses:       g.count,
+		PauseTotalMs: float64(g.pauseTotal) / float64(time.Millisecond),
+		PauseMaxMs:   float64(g.pauseMax) / float64(time.Millisecond),
+	}
+	g.mu.Unlock()
+	out.Overlapped = atomic.LoadInt64(&g.overlapped)
+	out.SelfInflictedMs = float64(atomic.LoadInt64(&g.selfNanos)) / float64(time.Millisecond)
+	if total := atomic.LoadInt64(&p.totalRequests); total > 0 {
+		out.OverlappedPct = float64(out.Overlapped) / float64(total) * 100
+	}
+	return out
+}
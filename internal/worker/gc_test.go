@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+)
+
+func TestGCImpact_FlagsOverlappingRequests(t *testing.T) {
+	p := newTestPool(t)
+	if p.GCImpact() != nil {
+		t.Fatal("GCImpact should be nil while tracking is off")
+	}
+	p.SetGCImpact(true)
+
+	t0 := time.Now()
+	ms := func(n int) time.Time { return t0.Add(time.Duration(n) * time.Millisecond) }
+	// Newest-first, as debug.ReadGCStats reports them: a 2ms pause
+	// ending at 12ms and a 1ms pause ending at 31ms.
+	p.gc.ingest(debug.GCStats{
+		NumGC:    2,
+		Pause:    []time.Duration{time.Millisecond, 2 * time.Millisecond},
+		PauseEnd: []time.Time{ms(31), ms(12)},
+	}, ms(60))
+
+	p.recordGCOverlap(ms(0), ms(5))   // before both
+	p.recordGCOverlap(ms(11), ms(20)) // half of the first pause
+	p.recordGCOverlap(ms(0), ms(40))  // spans both
+	p.recordGCOverlap(ms(32), ms(50)) // after both
+
+	got := p.GCImpact()
+	if got.Pauses != 2 || got.PauseMaxMs != 2 || got.PauseTotalMs != 3 {
+		t.Fatalf("pause summary = %+v", got)
+	}
+	if got.Overlapped != 2 {
+		t.Fatalf("overlapped = %d, want 2", got.Overlapped)
+	}
+	if math.Abs(got.SelfInflictedMs-4) > 1e-6 {
+		t.Fatalf("self-inflicted = %vms, want 4ms (1 + 2 + 1)", got.SelfInflictedMs)
+	}
+}
+
+func TestGCImpact_IngestSkipsSeenPauses(t *testing.T) {
+	p := newTestPool(t)
+	now := time.Now()
+	stats := debug.GCStats{NumGC: 1, Pause: []time.Duration{time.Millisecond}, PauseEnd: []time.Time{now}}
+	p.gc.ingest(stats, now)
+	p.gc.ingest(stats, now)
+	if p.gc.count != 1 {
+		t.Fatalf("pause counted %d times, want once", p.gc.count)
+	}
+}
+
+func TestGCImpact_SettlesRequestsBeforeTheirPauseIsRead(t *testing.T) {
+	p := newTestPool(t)
+	p.SetGCImpact(true)
+	t0 := time.Now()
+	ms := func(n int) time.Time { return t0.Add(time.Duration(n) * time.Millisecond) }
+	p.gc.ingest(debug.GCStats{}, ms(0))
+
+	// Ends 1ms after a pause the watcher hasn't read yet.
+	p.recordGCOverlap(ms(2), ms(6))
+	if got := p.GCImpact().Overlapped; got != 0 {
+		t.Fatalf("overlapped = %d before the pause was read", got)
+	}
+	p.gc.ingest(debug.GCStats{
+		NumGC:    1,
+		Pause:    []time.Duration{2 * time.Millisecond},
+		PauseEnd: []time.Time{ms(5)},
+	}, ms(10))
+
+	got := p.GCImpact()
+	if got.Overlapped != 1 || math.Abs(got.SelfInflictedMs-2) > 1e-6 {
+		t.Fatalf("impact = %+v, want the held request counted with 2ms", got)
+	}
+	if len(p.gc.pending) != 0 {
+		t.Fatalf("%d requests still pending", len(p.gc.pending))
+	}
+}
+
+func TestGCImpact_CountsShortRequestsDuringRealGC(t *testing.T) {
+	p := newTestPool(t)
+	p.SetGCImpact(true)
+	p.gc.start()
+	ctx, cancel := context.WithCancel(context.Background())
+	go p.watchGC(ctx)
+
+	// Each "request" is just a forced collection, far shorter than
+	// gcPollInterval and over before the watcher reads the pause.
+	const n = 20
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		runtime.GC()
+		p.recordGCOverlap(start, time.Now())
+	}
+	cancel()
+	p.readGC() // as Stop does
+
+	if got := p.GCImpact(); got.Overlapped != n || got.Pauses < n {
+		t.Fatalf("impact = %+v, want all %d requests overlapped", got, n)
+	}
+}
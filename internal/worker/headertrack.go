@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/kar98k/pkg/protocol"
+)
+
+// HeaderTrack is a track_header target's header over the run so far.
+// Min and Max are set while every value seen parsed as a number.
+type HeaderTrack struct {
+	Target  string   `json:"target"`
+	Header  string   `json:"header"`
+	Samples int64    `json:"samples"`
+	Changes int64    `json:"changes"` // times the value differed from the previous one
+	First   string   `json:"first"`
+	Last    string   `json:"last"`
+	Min     *float64 `json:"min,omitempty"`
+	Max     *float64 `json:"max,omitempty"`
+}
+
+// headerTracks holds the track_header targets' samples, under its own
+// lock like cacheStats.
+type headerTracks struct {
+	mu      sync.Mutex
+	targets map[string]*HeaderTrack
+	// numeric is false for a target once a value didn't parse.
+	numeric map[string]bool
+}
+
+// trackHeader samples a track_header target's response. Responses
+// without the header, failed ones included, aren't samples.
+func (p *Pool) trackHeader(target, header string, resp *protocol.Response) {
+	if resp.Header == "" {
+		return
+	}
+	h := &p.tracks
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.targets == nil {
+		h.targets = make(map[string]*HeaderTrack)
+		h.numeric = make(map[string]bool)
+	}
+	t, ok := h.targets[target]
+	if !ok {
+		t = &HeaderTrack{Target: target, Header: header, First: resp.Header}
+		h.targets[target] = t
+		h.numeric[target] = true
+	} else if resp.Header != t.Last {
+		t.Changes++
+	}
+	t.Samples++
+	t.Last = resp.Header
+
+	if !h.numeric[target] {
+		return
+	}
+	v, err := strconv.ParseFloat(resp.Header, 64)
+	if err != nil {
+		h.numeric[target] = false
+		t.Min, t.Max = nil, nil
+		return
+	}
+	if t.Min == nil {
+		t.Min, t.Max = new(float64), new(float64)
+		*t.Min, *t.Max = v, v
+	}
+	*t.Min = min(*t.Min, v)
+	*t.Max = max(*t.Max, v)
+}
+
+// HeaderTracks returns every track_header target's header so far,
+// sorted by target.
+func (p *Pool) HeaderTracks() []HeaderTrack {
+	h := &p.tracks
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]HeaderTrack, 0, len(h.targets))
+	for _, t := range h.targets {
+		st := *t
+		if t.Min != nil {
+			lo, hi := *t.Min, *t.Max
+			st.Min, st.Max = &lo, &hi
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Target < out[j].Target })
+	return out
+}
@@ -0,0 +1,202 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/template"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// Hold times are recorded in milliseconds up to an hour: long polls
+// outlast hdrbounds' 60s ceiling.
+const (
+	holdMinMs   = 1
+	holdMaxMs   = 3_600_000
+	holdSigFigs = 3
+)
+
+// pausedPollWait is how often a paused poller checks for resume.
+const pausedPollWait = 100 * time.Millisecond
+
+// LongPollStat is one long_poll target's polls and hold times (#1218).
+// Hold percentiles cover responded polls: expired ones all held for
+// the timeout.
+type LongPollStat struct {
+	Target    string  `json:"target"`
+	Open      int64   `json:"open"`
+	Polls     int64   `json:"polls"`
+	Responded int64   `json:"responded"`
+	Expired   int64   `json:"expired"`
+	Errors    int64   `json:"errors"`
+	HoldP50Ms float64 `json:"hold_p50_ms"`
+	HoldP95Ms float64 `json:"hold_p95_ms"`
+	HoldP99Ms float64 `json:"hold_p99_ms"`
+	HoldMaxMs float64 `json:"hold_max_ms"`
+}
+
+// pollTarget is one long_poll target's counters and hold histogram.
+type pollTarget struct {
+	open                      int64 // atomic
+	polls, responded, expired int64
+	errors                    int64
+	hold                      *hdrhistogram.Histogram
+}
+
+// longPolls holds the long_poll targets' figures.
+type longPolls struct {
+	mu      sync.Mutex
+	targets map[string]*pollTarget
+}
+
+// get returns target's entry, creating it.
+func (l *longPolls) get(target string) *pollTarget {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.targets == nil {
+		l.targets = make(map[string]*pollTarget)
+	}
+	t, ok := l.targets[target]
+	if !ok {
+		t = &pollTarget{hold: hdrhistogram.New(holdMinMs, holdMaxMs, holdSigFigs)}
+		l.targets[target] = t
+	}
+	return t
+}
+
+// RunLongPoll holds t.LongPoll.Concurrency polls open against t until
+// ctx ends. Polls bypass the rate limiter and the job queue: a long-poll
+// endpoint is driven by how many clients wait on it, not by a rate.
+// While the pool is paused no new poll starts.
+func (p *Pool) RunLongPoll(ctx context.Context, t config.Target, client protocol.Client) {
+	pt := p.polls.get(t.Name)
+	var wg sync.WaitGroup
+	for i := 0; i < t.LongPoll.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if p.paused.Load() {
+					if !sleepCtx(ctx, pausedPollWait) {
+						return
+					}
+					continue
+				}
+				p.poll(ctx, t, client, pt)
+				if !sleepCtx(ctx, t.LongPoll.Gap) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// poll sends one poll and files its outcome. A poll still open when
+// the target's timeout fires is expired, which is what a long-poll
+// endpoint with nothing to say does, not an error.
+func (p *Pool) poll(ctx context.Context, t config.Target, client protocol.Client, pt *pollTarget) {
+	req := &protocol.Request{
+		URL:       t.URL,
+		Method:    t.Method,
+		Headers:   t.Headers,
+		Body:      []byte(t.Body),
+		Timeout:   t.Timeout,
+		TraceTTFB: true,
+	}
+	if template.Has(t.Body) {
+		req.Body = p.expandBody(t.Body)
+	}
+	atomic.AddInt64(&pt.open, 1)
+	p.metrics.AddLongPollsActive(t.Name, 1)
+	doCtx, cancel := context.WithTimeout(ctx, t.TotalTimeLimit())
+	resp := p.do(doCtx, client, req)
+	cancel()
+	atomic.AddInt64(&pt.open, -1)
+	p.metrics.AddLongPollsActive(t.Name, -1)
+	if ctx.Err() != nil {
+		return // cut short by the run ending
+	}
+
+	hold := resp.TTFB
+	if hold == 0 {
+		hold = resp.Duration
+	}
+	outcome := "responded"
+	switch {
+	case resp.Error != nil && errors.Is(resp.Error, context.DeadlineExceeded):
+		outcome = "expired"
+		hold = resp.Duration
+	case resp.Error != nil || !t.IsSuccess(resp.StatusCode):
+		outcome = "error"
+	}
+	p.metrics.RecordLongPoll(t.Name, outcome, hold.Seconds())
+
+	p.polls.mu.Lock()
+	pt.polls++
+	switch outcome {
+	case "responded":
+		pt.responded++
+		ms := hold.Milliseconds()
+		if ms < holdMinMs {
+			ms = holdMinMs
+		} else if ms > holdMaxMs {
+			ms = holdMaxMs
+		}
+		_ = pt.hold.RecordValue(ms)
+	case "expired":
+		pt.expired++
+	default:
+		pt.errors++
+	}
+	p.polls.mu.Unlock()
+}
+
+// LongPollStats returns the long_poll targets' figures, sorted by
+// target. Empty unless some target sets long_poll.
+func (p *Pool) LongPollStats() []LongPollStat {
+	p.polls.mu.Lock()
+	defer p.polls.mu.Unlock()
+
+	out := make([]LongPollStat, 0, len(p.polls.targets))
+	for name, t := range p.polls.targets {
+		st := LongPollStat{
+			Target:    name,
+			Open:      atomic.LoadInt64(&t.open),
+			Polls:     t.polls,
+			Responded: t.responded,
+			Expired:   t.expired,
+			Errors:    t.errors,
+		}
+		if t.hold.TotalCount() > 0 {
+			st.HoldP50Ms = float64(t.hold.ValueAtQuantile(50))
+			st.HoldP95Ms = float64(t.hold.ValueAtQuantile(95))
+			st.HoldP99Ms = float64(t.hold.ValueAtQuantile(99))
+			st.HoldMaxMs = float64(t.hold.Max())
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Target < out[j].Target })
+	return out
+}
+
+// sleepCtx waits d, returning false if ctx ended first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
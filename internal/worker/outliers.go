@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// SlowRequest is one of the slowest requests of the run (#1191): the
+// concrete example behind a bad P99.
+type SlowRequest struct {
+	Time   time.Time `json:"time"`
+	Target string    `json:"target"`
+	Spec   string    `json:"spec,omitempty"`
+	Method string    `json:"method"`
+	URL    string    `json:"url"`
+	Status int       `json:"status"`
+	// Protocol says how to read Status: a gRPC code for gRPC targets
+	// (#1259), an HTTP status otherwise.
+	Protocol   config.Protocol `json:"protocol,omitempty"`
+	DurationMs float64         `json:"duration_ms"`
+	Error      string          `json:"error,omitempty"`
+
+	dur time.Duration
+}
+
+// StatusText is Status as its protocol names it, e.g. "503" or
+// "Unavailable".
+func (r SlowRequest) StatusText() string {
+	return r.Protocol.StatusText(r.Status)
+}
+
+// slowHeap is a min-heap on duration: the root is the fastest of the
+// kept requests, i.e. the one a new slower request evicts.
+type slowHeap []SlowRequest
+
+func (h slowHeap) Len() int           { return len(h) }
+func (h slowHeap) Less(i, j int) bool { return h[i].dur < h[j].dur }
+func (h slowHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *slowHeap) Push(x any)        { *h = append(*h, x.(SlowRequest)) }
+func (h *slowHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// outliers keeps the N slowest requests. floor mirrors the heap root's
+// duration once the heap is full, so the common case — a request
+// faster than every kept one — is a single atomic load with no lock.
+type outliers struct {
+	mu    sync.Mutex
+	n     int
+	heap  slowHeap
+	floor int64 // nanoseconds; 0 until the heap is full
+}
+
+// SetSlowestN sets how many of the slowest requests to keep. Zero
+// disables capture. Already kept requests are discarded.
+func (p *Pool) SetSlowestN(n int) {
+	o := &p.slow
+	o.mu.Lock()
+	o.n = n
+	o.heap = make(slowHeap, 0, n)
+	atomic.StoreInt64(&o.floor, 0)
+	o.mu.Unlock()
+}
+
+// recordSlow offers a completed request to the outlier heap. Method
+// and URL come from req, the request as sent: data rows, templates,
+// cache busting and pre_request hooks have all been applied to it.
+func (p *Pool) recordSlow(job Job, req *protocol.Request, status int, dur time.Duration, err error) {
+	o := &p.slow
+	if floor := atomic.LoadInt64(&o.floor); floor > 0 && int64(dur) <= floor {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.n <= 0 {
+		return
+	}
+	if len(o.heap) == o.n {
+		if dur <= o.heap[0].dur {
+			return
+		}
+		heap.Pop(&o.heap)
+	}
+	r := SlowRequest{
+		Time:       time.Now().Add(-dur),
+		Target:     job.Target.Name,
+		Spec:       job.Target.Spec,
+		Method:     req.Method,
+		URL:        req.URL,
+		Status:     status,
+		Protocol:   job.Target.Protocol,
+		DurationMs: float64(dur.Microseconds()) / 1000.0,
+		dur:        dur,
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	heap.Push(&o.heap, r)
+	if len(o.heap) == o.n {
+		atomic.StoreInt64(&o.floor, int64(o.heap[0].dur))
+	}
+}
+
+// Slowest returns the kept requests, slowest first.
+func (p *Pool) Slowest() []SlowRequest {
+	o := &p.slow
+	o.mu.Lock()
+	out := append([]SlowRequest(nil), o.heap...)
+	o.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].dur > out[j].dur })
+	return out
+}
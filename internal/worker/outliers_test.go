@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/pkg/protocol"
+)
+
+func TestSlowest_KeepsTopN(t *testing.T) {
+	p := newTestPool(t)
+	job := Job{Target: config.Target{Name: "api", Method: "GET", URL: "http://api/orders?id=7"}}
+	req := &protocol.Request{Method: "GET", URL: "http://api/orders?id=7"}
+
+	p.recordSlow(job, req, 200, time.Second, nil) // capture disabled: dropped
+	p.SetSlowestN(3)
+	for _, ms := range []int{5, 40, 10, 90, 1, 70, 20} {
+		p.recordSlow(job, req, 200, time.Duration(ms)*time.Millisecond, nil)
+	}
+	p.recordSlow(job, req, 0, 80*time.Millisecond, errors.New("timeout"))
+
+	got := p.Slowest()
+	want := []float64{90, 80, 70}
+	if len(got) != len(want) {
+		t.Fatalf("got %d requests, want %d: %+v", len(got), len(want), got)
+	}
+	for i, ms := range want {
+		if got[i].DurationMs != ms {
+			t.Fatalf("[%d] = %vms, want %vms", i, got[i].DurationMs, ms)
+		}
+	}
+	if got[1].Error != "timeout" || got[1].Status != 0 {
+		t.Fatalf("error request lost its details: %+v", got[1])
+	}
+	if got[0].URL != "http://api/orders?id=7" || got[0].Target != "api" {
+		t.Fatalf("request details missing: %+v", got[0])
+	}
+}
+
+func TestSlowest_StatusTextFollowsProtocol(t *testing.T) {
+	p := newTestPool(t)
+	p.SetSlowestN(2)
+	p.recordSlow(Job{Target: config.Target{Name: "api", Protocol: config.ProtocolHTTP}}, &protocol.Request{}, 503, 20*time.Millisecond, nil)
+	p.recordSlow(Job{Target: config.Target{Name: "rpc", Protocol: config.ProtocolGRPC}}, &protocol.Request{}, 14, 10*time.Millisecond, nil)
+
+	got := p.Slowest()
+	if len(got) != 2 || got[0].StatusText() != "503" || got[1].StatusText() != "Unavailable" {
+		t.Fatalf("status texts = %+v, want 503 then Unavailable", got)
+	}
+	if s := (SlowRequest{Protocol: config.ProtocolGRPCWeb}).StatusText(); s != "OK" {
+		t.Fatalf("gRPC-Web status 0 = %q, want OK", s)
+	}
+}
+
+func TestSlowest_RecordsTheRequestAsSent(t *testing.T) {
+	p := newTestPool(t)
+	p.SetRate(1000)
+	p.SetSlowestN(1)
+	target := config.Target{
+		Name: "api", Method: "GET", URL: "http://api/orders", Protocol: config.ProtocolHTTP,
+		CacheBust: &config.CacheBust{Fraction: 1, Paths: []string{"/orders/7"}},
+	}
+	p.processJob(context.Background(), Job{Target: target, Client: okClient{}})
+
+	got := p.Slowest()
+	if len(got) != 1 || got[0].URL != "http://api/orders/7" || got[0].Method != "GET" {
+		t.Fatalf("slowest = %+v, want the cache-busted URL that was sent", got)
+	}
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"github.com/kar98k/pkg/protocol"
+)
+
+// do sends req through c. A panicking client fails the request instead
+// of the pool (#1216): the panic is logged with its stack, counted in
+// kar98k_panics_total and returned as the response's error.
+func (p *Pool) do(ctx context.Context, c protocol.Client, req *protocol.Request) (resp *protocol.Response) {
+	defer func() {
+		if v := recover(); v != nil {
+			p.recordPanic(v)
+			resp = &protocol.Response{Error: fmt.Errorf("client panic: %v", v)}
+		}
+	}()
+	return c.Do(ctx, req)
+}
+
+// runJob is processJob with a last-resort recover, so a panic outside
+// the client — in a hook, say — drops that one job and the worker
+// goroutine lives on.
+func (p *Pool) runJob(ctx context.Context, job Job) {
+	defer func() {
+		if v := recover(); v != nil {
+			p.recordPanic(v)
+		}
+	}()
+	p.processJob(ctx, job)
+}
+
+func (p *Pool) recordPanic(v any) {
+	log.Printf("[worker] PANIC recovered: %v\n%s", v, debug.Stack())
+	p.metrics.RecordPanic("worker")
+}
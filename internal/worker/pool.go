@@ -2,8 +2,14 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -12,8 +18,11 @@ import (
 	"github.com/kar98k/internal/config"
 	"github.com/kar98k/internal/hdrbounds"
 	"github.com/kar98k/internal/health"
+	"github.com/kar98k/internal/hooks"
+	"github.com/kar98k/internal/template"
 	"github.com/kar98k/pkg/protocol"
 	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
 )
 
 // dropWindow is the rolling-window length used for sustained-rate
@@ -47,6 +56,9 @@ type Pool struct {
 	mu       sync.RWMutex
 	tpsCount int64
 	lastTPS  time.Time
+	// achievedBits is the last full second's completed requests as
+	// float64 bits, published by measureTPS.
+	achievedBits uint64
 
 	// Drop tracking. submitCount/dropCount are bumped from the hot path
 	// via atomics; the ring buffers are owned by measureTPS.
@@ -69,6 +81,11 @@ type Pool struct {
 	errorHist     [dropWindow]int64
 	errorRateBits uint64 // float64 bits, set/read atomically
 
+	// Lifetime totals for end-of-run reports (#1187). Unlike the
+	// breaker's errorSlot these count every non-success response.
+	totalRequests int64
+	totalErrors   int64
+
 	// paused is set by Pause/Resume. While paused, processJob returns
 	// without firing the request — workers stay alive, the rate limiter
 	// keeps its setting, but no traffic flows.
@@ -83,32 +100,191 @@ type Pool struct {
 	latRaw       *hdrhistogram.Histogram // observed latency (t_done - t_sent)
 	latCorrected *hdrhistogram.Histogram // CO-corrected via RecordCorrectedValue
 	currentPhase string
+
+	// specStats maps "target\x00spec" to *specCounter (#1182).
+	specStats sync.Map
+	// errorStats maps "target\x00class" to *int64 (#1229).
+	errorStats sync.Map
+
+	// Runtime resizing (#1181). size is the desired worker count and
+	// workers the number currently running; both are atomics so the
+	// worker loop can retire itself without a lock. resizeMu serialises
+	// SetPoolSize and guards lastResize and runCtx.
+	size       int64
+	workers    int64
+	resizeMu   sync.Mutex
+	lastResize time.Time
+	runCtx     context.Context
+
+	// startJitter staggers the workers launched by Start (#1192).
+	// Guarded by resizeMu.
+	startJitter time.Duration
+
+	// latByTarget keeps one raw histogram per target name so reports
+	// can show per-target percentiles and an unweighted per-target
+	// average next to the pooled figure (#1177). Guarded by latMu.
+	latByTarget map[string]*hdrhistogram.Histogram
+
+	// latTTFB holds time-to-first-byte for targets with record_ttfb or
+	// first_byte_only, kept apart from latRaw because for streams the
+	// two measure different things (#1185). Guarded by latMu.
+	latTTFB *hdrhistogram.Histogram
+
+	// Report segments (#1189): one histogram per fixed window, see
+	// segments.go. Guarded by latMu.
+	segWindow time.Duration
+	segOrigin time.Time
+	segments  []*segment
+	// stages are the scenario phases' own stats (#1262), see
+	// stages.go. Guarded by latMu.
+	stages []*stage
+	// The report timeline (#1260), see timeslots.go. Guarded by
+	// latMu.
+	slotWidth  time.Duration
+	slotOrigin time.Time
+	slots      []timeSlot
+	// statuses counts responses by HTTP status code (#1260).
+	statuses statusCounts
+	// latWindow is the raw histogram drained by LatencyWindow for
+	// cliff detection (#1252); nil until EnableLatencyWindow.
+	latWindow *hdrhistogram.Histogram
+
+	// slow keeps the slowest N requests for the report (#1191), see
+	// outliers.go.
+	slow outliers
+
+	// gc tracks kar's own GC pauses against requests (#1196), see
+	// gc.go.
+	gc gcWatch
+
+	// cache holds the cache_bust targets' hit/miss figures (#1206),
+	// see cache.go.
+	cache cacheStats
+
+	// warm keeps warmup requests out of the primary percentiles
+	// (#1207), see warmup.go.
+	warm warmup
+
+	// data feeds data_file rows into requests (#1211), see data.go.
+	data dataFiles
+
+	// polls holds the long_poll targets' figures (#1218), see
+	// longpoll.go.
+	polls longPolls
+
+	// sat tracks generator saturation (#1222), see saturation.go.
+	sat saturation
+
+	// bodies maps target name to the *bodyGen drawing its
+	// synthetic_body payloads (#1217), see synthbody.go.
+	bodies sync.Map
+
+	// templates maps a templated body to its *template.Template, and
+	// bodyCounter numbers {{counter}} across every target (#1251), see
+	// bodytemplate.go.
+	templates   sync.Map
+	bodyCounter template.Counter
+
+	// tracks holds track_header samples, see headertrack.go.
+	tracks headerTracks
+	dups   duplicateTracker
+	// sizes is report.size_latency's breakdown (#1258).
+	sizes sizeLatency
+	// samples holds the samples sink's raw requests (#1260).
+	samples sampleCapture
+
+	// targetDone maps target name to *int64, the requests completed
+	// this second; measureTPS swaps them into targetTPS (guarded by
+	// mu) for the per-target achieved rate (#1201).
+	targetDone sync.Map
+	targetTPS  map[string]float64
+
+	// targetWindow maps target name to *windowCounter, the traffic
+	// since the last TargetWindow call (#1234).
+	targetWindow sync.Map
+
+	// hooks holds the optional scripting hooks (#1200). Set before
+	// Start and read-only afterwards.
+	hooks *hooks.Hooks
+
+	// targetClients holds a dedicated client per target with
+	// max_conns_per_host, so its connection cap isn't shared with
+	// other targets on the same protocol (#1188). connQueued counts
+	// requests that waited for one of those connections.
+	clientCfg     protocol.ClientConfig
+	targetClients sync.Map // target name -> protocol.Client
+	// streamReporters are the http2 targets' own clients, kept apart
+	// from targetClients because fault injection wraps those.
+	streamReporters sync.Map // target name -> protocol.StreamReporter
+	connQueued      int64
+	// warmed counts the hosts Warm prepared (#1257).
+	warmed int64
+
+	// unknownProtocols holds the protocols GetClient already warned
+	// about falling back to HTTP for (#1233).
+	unknownProtocols sync.Map
 }
 
 // NewPool creates a new worker pool.
 func NewPool(cfg config.Worker, metrics *health.Metrics) *Pool {
 	// Initialize protocol clients
-	clientCfg := protocol.ClientConfig{
-		MaxIdleConns:    cfg.MaxIdleConns,
-		IdleConnTimeout: cfg.IdleConnTimeout,
-		TLSInsecure:     true,
+	// One dial limiter for every client, so worker.conn_rate caps
+	// kar's new connections as a whole (#1261).
+	var dials *rate.Limiter
+	if perSecond, burst := cfg.ConnLimit(); perSecond > 0 {
+		dials = rate.NewLimiter(rate.Limit(perSecond), burst)
 	}
-
+	clientCfg := clientConfig(cfg, config.ProtocolHTTP, dials)
 	clients := map[config.Protocol]protocol.Client{
-		config.ProtocolHTTP:  protocol.NewHTTPClient(clientCfg),
-		config.ProtocolHTTP2: protocol.NewHTTP2Client(clientCfg),
-		config.ProtocolGRPC:  protocol.NewGRPCClient(clientCfg),
+		config.ProtocolHTTP:    protocol.NewHTTPClient(clientCfg),
+		config.ProtocolHTTP2:   protocol.NewHTTP2Client(clientConfig(cfg, config.ProtocolHTTP2, dials)),
+		config.ProtocolGRPC:    protocol.NewGRPCClient(clientConfig(cfg, config.ProtocolGRPC, dials)),
+		config.ProtocolGRPCWeb: protocol.NewGRPCWebClient(clientConfig(cfg, config.ProtocolGRPCWeb, dials)),
+	}
+	if cfg.FaultInject.Enabled() {
+		for proto, c := range clients {
+			clients[proto] = withFaults(c, proto, cfg.FaultInject, metrics)
+		}
+		log.Printf("[worker] WARNING: fault injection on (timeout=%g error=%g slow=%g): results describe kar, not the target",
+			cfg.FaultInject.Timeout, cfg.FaultInject.Error, cfg.FaultInject.Slow)
+	}
+	if dials != nil {
+		log.Printf("[worker] new connections limited to %g/s (burst %d)", float64(dials.Limit()), dials.Burst())
+	}
+	if cfg.TLSInsecure {
+		log.Printf("[worker] WARNING: TLS certificate verification is OFF (worker.tls_insecure): certificate errors on targets won't fail requests")
 	}
 
 	return &Pool{
 		cfg:          cfg,
 		metrics:      metrics,
 		clients:      clients,
+		clientCfg:    clientCfg,
 		limiter:      rate.NewLimiter(rate.Limit(100), 1), // Initial rate, will be updated
 		jobs:         make(chan Job, cfg.QueueSize),
 		lastTPS:      time.Now(),
 		latRaw:       hdrhistogram.New(hdrbounds.Min, hdrbounds.Max, int(hdrbounds.SigFigs)),
 		latCorrected: hdrhistogram.New(hdrbounds.Min, hdrbounds.Max, int(hdrbounds.SigFigs)),
+		latByTarget:  make(map[string]*hdrhistogram.Histogram),
+		latTTFB:      hdrhistogram.New(hdrbounds.Min, hdrbounds.Max, int(hdrbounds.SigFigs)),
+		size:         int64(cfg.PoolSize),
+	}
+}
+
+// clientConfig builds proto's client settings, including its
+// keep-alive block (#1202), with dials paced by the shared limiter.
+func clientConfig(cfg config.Worker, proto config.Protocol, dials *rate.Limiter) protocol.ClientConfig {
+	ka := cfg.KeepAlive.For(proto)
+	return protocol.ClientConfig{
+		MaxIdleConns:    cfg.MaxIdleConns,
+		IdleConnTimeout: cfg.IdleConnTimeout,
+		TLSInsecure:     cfg.TLSInsecure,
+		Plaintext:       true,
+		TCPKeepAlive:    ka.TCP,
+		PingInterval:    ka.Ping,
+		PingTimeout:     ka.PingTimeout,
+		TLSSessionCache: cfg.TLS.SessionCache,
+		DialLimiter:     dials,
 	}
 }
 
@@ -116,33 +292,179 @@ func NewPool(cfg config.Worker, metrics *health.Metrics) *Pool {
 func (p *Pool) Start(ctx context.Context) {
 	ctx, p.cancel = context.WithCancel(ctx)
 
-	// Start worker goroutines
-	for i := 0; i < p.cfg.PoolSize; i++ {
-		p.wg.Add(1)
-		go p.worker(ctx)
-	}
+	p.resizeMu.Lock()
+	p.runCtx = ctx
+	p.spawn(ctx, p.PoolSize(), p.startJitter)
+	p.resizeMu.Unlock()
 
 	// Start TPS measurement goroutine
 	go p.measureTPS(ctx)
 
+	if p.gc.enabled.Load() {
+		p.gc.start()
+		go p.watchGC(ctx)
+	}
+
 	log.Printf("[worker] started %d workers with queue size %d", p.cfg.PoolSize, p.cfg.QueueSize)
 }
 
+// spawn starts n worker goroutines, each delayed by a random offset
+// in [0, jitter) when jitter > 0.
+func (p *Pool) spawn(ctx context.Context, n int, jitter time.Duration) {
+	for i := 0; i < n; i++ {
+		var delay time.Duration
+		if jitter > 0 {
+			delay = time.Duration(rand.Int63n(int64(jitter)))
+		}
+		atomic.AddInt64(&p.workers, 1)
+		p.wg.Add(1)
+		go p.worker(ctx, delay)
+	}
+}
+
+// jitterTimeout draws a request timeout uniformly from d×(1±frac)
+// (#1243).
+func jitterTimeout(d time.Duration, frac float64) time.Duration {
+	if frac <= 0 || d <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + frac*(2*rand.Float64()-1)))
+}
+
+// SetStartJitter staggers the workers launched by Start over a random
+// offset in [0, d), so the first requests don't all leave at once
+// (#1192). Workers added later by SetPoolSize start immediately.
+func (p *Pool) SetStartJitter(d time.Duration) {
+	p.resizeMu.Lock()
+	p.startJitter = d
+	p.resizeMu.Unlock()
+}
+
+// SetHooks installs the scripting hooks processJob runs around every
+// request (#1200). Call before Start; nil disables them.
+func (p *Pool) SetHooks(h *hooks.Hooks) {
+	p.hooks = h
+}
+
 // worker is the main worker goroutine.
-func (p *Pool) worker(ctx context.Context) {
+func (p *Pool) worker(ctx context.Context, delay time.Duration) {
 	defer p.wg.Done()
 
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			atomic.AddInt64(&p.workers, -1)
+			return
+		case <-time.After(delay):
+		}
+	}
+
 	for {
+		// Shrinking is cooperative: a worker retires between jobs when
+		// the pool is over its desired size. An idle worker blocked on
+		// the queue retires after its next job.
+		if p.retire() {
+			return
+		}
 		select {
 		case <-ctx.Done():
+			atomic.AddInt64(&p.workers, -1)
 			return
 		case job, ok := <-p.jobs:
 			if !ok {
+				atomic.AddInt64(&p.workers, -1)
 				return
 			}
-			p.processJob(ctx, job)
+			p.runJob(ctx, job)
+		}
+	}
+}
+
+// retire claims one excess worker slot. It returns true when the
+// caller should exit; the CAS ensures exactly (workers - size) callers
+// win even when many check at once.
+func (p *Pool) retire() bool {
+	for {
+		cur := atomic.LoadInt64(&p.workers)
+		if cur <= atomic.LoadInt64(&p.size) {
+			return false
 		}
+		if atomic.CompareAndSwapInt64(&p.workers, cur, cur-1) {
+			return true
+		}
+	}
+}
+
+// ErrResizeTooSoon is returned by SetPoolSize when the previous resize
+// happened less than worker.min_resize_interval ago.
+var ErrResizeTooSoon = errors.New("pool resized too recently")
+
+// SetPoolSize changes the number of worker goroutines at runtime.
+// Growing spawns workers immediately; shrinking lowers the target and
+// lets surplus workers exit after their current job, so in-flight
+// requests are never cut off. Calls closer together than
+// MinResizeInterval fail with ErrResizeTooSoon to avoid thrashing.
+func (p *Pool) SetPoolSize(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("pool size must be positive, got %d", n)
+	}
+
+	p.resizeMu.Lock()
+	defer p.resizeMu.Unlock()
+
+	if p.runCtx == nil {
+		return errors.New("pool not started")
 	}
+	if since := time.Since(p.lastResize); !p.lastResize.IsZero() && since < p.cfg.MinResizeInterval {
+		return fmt.Errorf("%w: retry in %s", ErrResizeTooSoon,
+			(p.cfg.MinResizeInterval - since).Round(time.Second))
+	}
+
+	prev := atomic.SwapInt64(&p.size, int64(n))
+	if grow := n - int(atomic.LoadInt64(&p.workers)); grow > 0 {
+		p.spawn(p.runCtx, grow, 0)
+	}
+	p.lastResize = time.Now()
+
+	log.Printf("[worker] pool size %d -> %d", prev, n)
+	return nil
+}
+
+// PoolSize returns the desired number of workers. The running count
+// may briefly exceed it while surplus workers finish their jobs.
+func (p *Pool) PoolSize() int {
+	return int(atomic.LoadInt64(&p.size))
+}
+
+// classifyTimeout counts a failed request that timed out, telling a
+// target that never accepted the connection from one slow to answer
+// (#1225).
+func (p *Pool) classifyTimeout(t config.Target, resp *protocol.Response) {
+	switch {
+	case errors.Is(resp.Error, protocol.ErrConnectTimeout):
+		p.metrics.RecordTimeout(t.Name, "connect")
+	case errors.Is(resp.Error, context.DeadlineExceeded),
+		t.Protocol.GRPCStatus() && resp.StatusCode == int(codes.DeadlineExceeded):
+		p.metrics.RecordTimeout(t.Name, "response")
+	}
+}
+
+// withTotalTime bounds ctx by limit, the target's max_total_time, and
+// reports whether the bound fired. gRPC calls get a timer and cancel
+// rather than a deadline: grpc-go sends any context deadline as
+// grpc-timeout, which propagate_deadline: false keeps off the wire.
+func withTotalTime(ctx context.Context, proto config.Protocol, limit time.Duration) (context.Context, context.CancelFunc, func() bool) {
+	if proto != config.ProtocolGRPC {
+		doCtx, cancel := context.WithTimeout(ctx, limit)
+		return doCtx, cancel, func() bool { return errors.Is(doCtx.Err(), context.DeadlineExceeded) }
+	}
+	doCtx, cancel := context.WithCancel(ctx)
+	var fired atomic.Bool
+	timer := time.AfterFunc(limit, func() {
+		fired.Store(true)
+		cancel()
+	})
+	return doCtx, func() { timer.Stop(); cancel() }, fired.Load
 }
 
 // processJob executes a single job.
@@ -159,6 +481,12 @@ func (p *Pool) processJob(ctx context.Context, job Job) {
 		return
 	}
 
+	// A sequential data_file that ran out ends the target's traffic.
+	row, hasRow, ok := p.nextRow(job.Target.Name)
+	if !ok {
+		return
+	}
+
 	atomic.AddInt64(&p.active, 1)
 	p.metrics.IncRequestsInFlight()
 	defer func() {
@@ -175,31 +503,278 @@ func (p *Pool) processJob(ctx context.Context, job Job) {
 		Method:  job.Target.Method,
 		Headers: job.Target.Headers,
 		Body:    []byte(job.Target.Body),
-		Timeout: job.Target.Timeout,
+		Timeout: jitterTimeout(job.Target.Timeout, job.Target.TimeoutJitter),
+
+		ConnectTimeout: job.Target.ConnectTimeout,
+
+		PropagateDeadline: job.Target.PropagateDeadline,
+		DeadlineHeader:    job.Target.DeadlineHeader,
+
+		TraceTTFB:     job.Target.RecordTTFB,
+		FirstByteOnly: job.Target.FirstByteOnly,
+		TraceConnWait: job.Target.MaxConnsPerHost > 0,
+	}
+	if req.PropagateDeadline && req.DeadlineHeader == "" {
+		req.DeadlineHeader = config.DefaultDeadlineHeader
+	}
+	// Before applyRow, so a row's values aren't read as placeholders.
+	if template.Has(job.Target.Body) {
+		req.Body = p.expandBody(job.Target.Body)
+	}
+	if hasRow {
+		applyRow(req, row)
+	}
+	// After applyRow: random bytes aren't a template.
+	if sb := job.Target.SyntheticBody; sb != nil {
+		p.syntheticBody(job.Target.Name, sb, req)
+	}
+	if cb := job.Target.CacheBust; cb != nil {
+		p.bustCache(job.Target.Name, cb, req)
+	}
+	if job.Target.TrackHeader != "" {
+		req.CaptureHeader = job.Target.TrackHeader
+	}
+	req.CaptureBody = max(job.Target.Expect.CaptureBody(), job.Target.Duplicates.CaptureBody())
+	if g := job.Target.GRPC; g != nil {
+		req.GRPCMethod, req.Protoset = g.Method, g.Protoset
 	}
 
-	// Execute request
-	resp := job.Client.Do(ctx, req)
+	// A failing pre_request hook fails the request without sending it:
+	// the request it would have built is unknown.
+	var resp *protocol.Response
+	if err := p.hooks.PreRequest(job.Target.Name, req); err != nil {
+		p.metrics.RecordHookError("pre_request")
+		resp = &protocol.Response{Error: err}
+	} else {
+		// Execute request under the target's hard total-time bound (#1197).
+		// Why: the per-request Timeout is optional; without this a target
+		// that trickles its body forever would hold the worker for good.
+		limit := job.Target.TotalTimeLimit()
+		doCtx, cancel, expired := withTotalTime(ctx, job.Target.Protocol, limit)
+		resp = p.do(doCtx, job.Client, req)
+		if resp.Error != nil && ctx.Err() == nil && expired() {
+			resp.Error = fmt.Errorf("%w: exceeded max_total_time %s", context.DeadlineExceeded, limit)
+			resp.StatusCode = 0
+			if job.Target.Protocol.GRPCStatus() {
+				resp.StatusCode = int(codes.DeadlineExceeded)
+			}
+			p.metrics.RecordDeadlineExceeded(job.Target.Name)
+		} else if resp.Error != nil && ctx.Err() == nil {
+			p.classifyTimeout(job.Target, resp)
+		}
+		cancel()
+	}
 
 	// Record metrics
+	success := job.Target.IsSuccess(resp.StatusCode) && job.Target.Expect.MatchBody(resp.Body)
+	verdict, err := p.hooks.PostResponse(job.Target.Name, req, resp)
+	if err != nil {
+		p.metrics.RecordHookError("post_response")
+		verdict = hooks.Failure
+	}
+	switch verdict {
+	case hooks.Success:
+		success = true
+	case hooks.Failure:
+		success = false
+	}
+	// Warmup included: a duplicate is a duplicate whenever it happens.
+	var duplicate bool // and failed for it
+	if d := job.Target.Duplicates; d != nil {
+		duplicate = p.checkDuplicate(job.Target.Name, d, resp, success) && d.Fail
+		if duplicate {
+			success = false
+		}
+	}
 	p.metrics.RecordRequest(
 		job.Target.Name,
 		string(job.Target.Protocol),
-		resp.StatusCode,
+		success,
 		resp.Duration.Seconds(),
 	)
+	if job.Target.Spec != "" {
+		p.metrics.RecordSpecRequest(job.Target.Name, job.Target.Spec, success)
+		p.recordSpec(job.Target.Name, job.Target.Spec, success)
+	}
 
-	p.recordLatency(resp.Duration)
+	// Warmup requests (#1207) stay out of the primary latency figures;
+	// segments keep them, since showing warming is what they're for.
+	done := time.Now()
+	// Warmup included: how the target's state evolves from the start
+	// is the point.
+	if job.Target.TrackHeader != "" {
+		p.trackHeader(job.Target.Name, job.Target.TrackHeader, resp)
+	}
+	warmup := p.inWarmup(done)
+	p.recordSample(job, resp, success, warmup, done)
+	if warmup {
+		p.recordWarmup(resp.Duration)
+	} else {
+		p.recordLatency(resp.Duration)
+		p.recordTargetLatency(job.Target.Name, resp.Duration)
+		p.recordSlow(job, req, resp.StatusCode, resp.Duration, resp.Error)
+		if cb := job.Target.CacheBust; cb != nil {
+			p.recordCache(job.Target.Name, cb, resp)
+		}
+		if resp.TTFB > 0 {
+			p.recordTTFB(resp.TTFB)
+		}
+		if success {
+			p.recordSize(job.Target.Name, resp)
+		}
+	}
+	p.recordSegment(done, resp.Duration, success)
+	p.recordGCOverlap(done.Add(-resp.Duration), done)
+	if resp.TTFB > 0 {
+		p.metrics.RecordTTFB(job.Target.Name, resp.TTFB.Seconds())
+	}
+	if req.TraceConnWait && resp.Error == nil {
+		p.metrics.RecordConnWait(job.Target.Name, resp.ConnWait.Seconds(), resp.ConnQueued)
+		if resp.ConnQueued {
+			atomic.AddInt64(&p.connQueued, 1)
+		}
+	}
 
 	// Increment TPS counter and feed the per-second request/error
 	// slots so the breaker can compute a sustained error rate.
 	atomic.AddInt64(&p.tpsCount, 1)
 	atomic.AddInt64(&p.requestSlot, 1)
-	if resp.StatusCode >= 500 || resp.StatusCode == 0 {
+	atomic.AddInt64(&p.totalRequests, 1)
+	p.countTarget(job.Target.Name)
+	p.recordWindow(job.Target.Name, resp.Duration, success)
+	if resp.StatusCode != 0 && !job.Target.Protocol.GRPCStatus() {
+		p.recordStatus(resp.StatusCode)
+	}
+	if !success {
+		atomic.AddInt64(&p.totalErrors, 1)
+		class := errorClass(job.Target, resp)
+		if duplicate {
+			class = "duplicate"
+		}
+		p.recordError(job.Target.Name, class)
+	}
+	// Without explicit success codes the breaker only counts server
+	// errors, so a 404-heavy mix doesn't trip it; with them, anything
+	// outside the list is an error by the user's own definition.
+	failed := !success
+	if verdict == hooks.Keep && len(job.Target.SuccessCodes) == 0 && job.Target.Expect == nil && !duplicate && !job.Target.Protocol.GRPCStatus() {
+		failed = resp.StatusCode >= 500 || resp.StatusCode == 0
+	}
+	if failed {
 		atomic.AddInt64(&p.errorSlot, 1)
 	}
 }
 
+// specCounter holds per-spec request/error totals.
+type specCounter struct {
+	requests int64
+	errors   int64
+}
+
+// recordSpec bumps the per-spec counters. sync.Map keeps the hot path
+// lock-free once a spec's counter exists.
+func (p *Pool) recordSpec(target, spec string, success bool) {
+	key := target + "\x00" + spec
+	v, ok := p.specStats.Load(key)
+	if !ok {
+		v, _ = p.specStats.LoadOrStore(key, &specCounter{})
+	}
+	c := v.(*specCounter)
+	atomic.AddInt64(&c.requests, 1)
+	if !success {
+		atomic.AddInt64(&c.errors, 1)
+	}
+}
+
+// SpecStat is the success/error breakdown for one request-mix spec.
+type SpecStat struct {
+	Target   string `json:"target"`
+	Spec     string `json:"spec"`
+	Requests int64  `json:"requests"`
+	Errors   int64  `json:"errors"`
+}
+
+// SpecStats returns per-spec totals sorted by target then spec. Empty
+// unless some target defines `requests:`.
+func (p *Pool) SpecStats() []SpecStat {
+	var out []SpecStat
+	p.specStats.Range(func(k, v any) bool {
+		target, spec, _ := strings.Cut(k.(string), "\x00")
+		c := v.(*specCounter)
+		out = append(out, SpecStat{
+			Target:   target,
+			Spec:     spec,
+			Requests: atomic.LoadInt64(&c.requests),
+			Errors:   atomic.LoadInt64(&c.errors),
+		})
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Target != out[j].Target {
+			return out[i].Target < out[j].Target
+		}
+		return out[i].Spec < out[j].Spec
+	})
+	return out
+}
+
+// errorClass names why a request failed, at the finest grain the
+// report's error matrix shows (#1229): the transport error class for
+// requests that got no answer, expect_body for a good status whose body
+// failed expect.body_contains, otherwise the status code, by name for
+// protocols whose status is a gRPC code. Duplicates that fail the
+// request are "duplicate" instead (#1256).
+func errorClass(t config.Target, resp *protocol.Response) string {
+	switch {
+	case errors.Is(resp.Error, protocol.ErrConnectTimeout):
+		return "connect_timeout"
+	case t.Protocol.GRPCStatus():
+		return t.Protocol.StatusText(resp.StatusCode)
+	case resp.Error != nil && resp.StatusCode == 0:
+		return string(health.ClassifyError(resp.Error))
+	case resp.Error == nil && t.IsSuccess(resp.StatusCode) && !t.Expect.MatchBody(resp.Body):
+		return "expect_body"
+	}
+	return strconv.Itoa(resp.StatusCode)
+}
+
+// recordError counts a failed request in its target's error class.
+func (p *Pool) recordError(target, class string) {
+	key := target + "\x00" + class
+	v, ok := p.errorStats.Load(key)
+	if !ok {
+		v, _ = p.errorStats.LoadOrStore(key, new(int64))
+	}
+	atomic.AddInt64(v.(*int64), 1)
+}
+
+// ErrorCount is how many of a target's failed requests fell in one
+// error class: a status code, a gRPC code name, or a transport error
+// class such as dns, refused, timeout or connect_timeout.
+type ErrorCount struct {
+	Target string `json:"target"`
+	Class  string `json:"class"`
+	Count  int64  `json:"count"`
+}
+
+// ErrorCounts returns the failed requests per target and error class,
+// sorted by target then class.
+func (p *Pool) ErrorCounts() []ErrorCount {
+	var out []ErrorCount
+	p.errorStats.Range(func(k, v any) bool {
+		target, class, _ := strings.Cut(k.(string), "\x00")
+		out = append(out, ErrorCount{Target: target, Class: class, Count: atomic.LoadInt64(v.(*int64))})
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Target != out[j].Target {
+			return out[i].Target < out[j].Target
+		}
+		return out[i].Class < out[j].Class
+	})
+	return out
+}
+
 // recordLatency feeds an observed request duration into both the raw
 // and the coordinated-omission-corrected histograms. The expected
 // inter-request interval is derived from the rate limiter's current
@@ -249,16 +824,105 @@ func (p *Pool) measureTPS(ctx context.Context) {
 		case <-ticker.C:
 			count := atomic.SwapInt64(&p.tpsCount, 0)
 			p.metrics.SetCurrentTPS(float64(count))
+			atomic.StoreUint64(&p.achievedBits, math.Float64bits(float64(count)))
 
 			drops := atomic.SwapInt64(&p.dropCount, 0)
 			submits := atomic.SwapInt64(&p.submitCount, 0)
 			p.recordDropSlot(drops, submits)
+			p.checkSaturation(drops)
 
 			reqs := atomic.SwapInt64(&p.requestSlot, 0)
 			errs := atomic.SwapInt64(&p.errorSlot, 0)
 			p.recordErrorSlot(errs, reqs)
+
+			p.recordTargetTPS()
+			p.recordConnPool()
+		}
+	}
+}
+
+// countTarget bumps name's completed-request counter for this second.
+func (p *Pool) countTarget(name string) {
+	v, ok := p.targetDone.Load(name)
+	if !ok {
+		v, _ = p.targetDone.LoadOrStore(name, new(int64))
+	}
+	atomic.AddInt64(v.(*int64), 1)
+}
+
+// windowCounter accumulates one target's traffic between TargetWindow
+// calls.
+type windowCounter struct {
+	requests int64
+	errors   int64
+	micros   int64
+}
+
+func (p *Pool) recordWindow(name string, d time.Duration, success bool) {
+	v, ok := p.targetWindow.Load(name)
+	if !ok {
+		v, _ = p.targetWindow.LoadOrStore(name, &windowCounter{})
+	}
+	c := v.(*windowCounter)
+	atomic.AddInt64(&c.requests, 1)
+	atomic.AddInt64(&c.micros, d.Microseconds())
+	if !success {
+		atomic.AddInt64(&c.errors, 1)
+	}
+}
+
+// TargetHealth is one target's traffic over a TargetWindow.
+type TargetHealth struct {
+	Requests int64
+	Errors   int64
+	// MeanLatency averages every completed request, failures included.
+	MeanLatency time.Duration
+}
+
+// TargetWindow returns each target's traffic since the previous call
+// and starts a new window, for adaptive weighting (#1234). Targets
+// with nothing completed in the window are absent.
+func (p *Pool) TargetWindow() map[string]TargetHealth {
+	out := make(map[string]TargetHealth)
+	p.targetWindow.Range(func(k, v any) bool {
+		c := v.(*windowCounter)
+		reqs := atomic.SwapInt64(&c.requests, 0)
+		errs := atomic.SwapInt64(&c.errors, 0)
+		micros := atomic.SwapInt64(&c.micros, 0)
+		if reqs > 0 {
+			out[k.(string)] = TargetHealth{
+				Requests:    reqs,
+				Errors:      errs,
+				MeanLatency: time.Duration(micros/reqs) * time.Microsecond,
+			}
 		}
+		return true
+	})
+	return out
+}
+
+// recordTargetTPS publishes the last second's per-target counts.
+func (p *Pool) recordTargetTPS() {
+	tps := make(map[string]float64)
+	p.targetDone.Range(func(k, v any) bool {
+		tps[k.(string)] = float64(atomic.SwapInt64(v.(*int64), 0))
+		return true
+	})
+	p.mu.Lock()
+	p.targetTPS = tps
+	p.mu.Unlock()
+}
+
+// TargetAchievedTPS returns each target's requests completed in the
+// last full second. Targets that have never completed one are absent.
+func (p *Pool) TargetAchievedTPS() map[string]float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make(map[string]float64, len(p.targetTPS))
+	for k, v := range p.targetTPS {
+		out[k] = v
 	}
+	return out
 }
 
 // recordErrorSlot writes one second's request/error counts into the
@@ -288,6 +952,11 @@ func (p *Pool) recordErrorSlot(errs, reqs int64) {
 	atomic.StoreUint64(&p.errorRateBits, math.Float64bits(rate))
 }
 
+// AchievedTPS returns the requests completed in the last full second.
+func (p *Pool) AchievedTPS() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&p.achievedBits))
+}
+
 // ErrorRate returns the sustained error rate (errors / total requests)
 // over the last dropWindow seconds. Returns 0 before the first
 // sampling tick fires. The breaker uses this to detect prolonged
@@ -406,6 +1075,7 @@ func (p *Pool) Submit(job Job) bool {
 func (p *Pool) SetPhase(phase string) {
 	p.latMu.Lock()
 	p.currentPhase = phase
+	p.startStage(phase, time.Now())
 	p.latMu.Unlock()
 }
 
@@ -423,15 +1093,99 @@ func (p *Pool) SetRate(tps float64) {
 	p.metrics.SetTargetTPS(tps)
 }
 
-// GetClient returns the client for a given protocol.
+// GetClient returns the client for a given protocol. An unknown one,
+// possible only with worker.strict_protocol off, gets the HTTP client
+// and a warning logged once.
 func (p *Pool) GetClient(proto config.Protocol) protocol.Client {
 	client, ok := p.clients[proto]
 	if !ok {
+		if _, warned := p.unknownProtocols.LoadOrStore(proto, true); !warned && proto != "" {
+			log.Printf("[worker] WARNING: unknown protocol %q, sending http instead (worker.strict_protocol is off)", proto)
+		}
 		return p.clients[config.ProtocolHTTP]
 	}
 	return client
 }
 
+// ClientFor returns the client for target t. Targets with
+// max_conns_per_host or pipeline, or whose tls_insecure differs from
+// the global setting, get their own lazily built HTTP/1.1 client so
+// the setting applies to that target alone, as do http2 targets with
+// an http2 block (#1248); everything else shares the per-protocol
+// client from GetClient.
+func (p *Pool) ClientFor(t config.Target) protocol.Client {
+	skipVerify := t.SkipTLSVerify(p.cfg.TLSInsecure)
+	var own bool
+	switch t.Protocol {
+	case config.ProtocolHTTP, "":
+		own = t.MaxConnsPerHost > 0 || t.Pipeline > 1 || skipVerify != p.cfg.TLSInsecure
+	case config.ProtocolHTTP2:
+		own = t.HTTP2.Set()
+	}
+	if !own {
+		return p.GetClient(t.Protocol)
+	}
+	if c, ok := p.targetClients.Load(t.Name); ok {
+		return c.(protocol.Client)
+	}
+	var client protocol.Client
+	proto := config.ProtocolHTTP
+	if t.Protocol == config.ProtocolHTTP2 {
+		proto = config.ProtocolHTTP2
+		cfg := clientConfig(p.cfg, proto, p.clientCfg.DialLimiter)
+		cfg.TLSInsecure = skipVerify
+		client = protocol.NewHTTP2ConnClient(cfg, protocol.HTTP2Options{
+			Connections: t.HTTP2.Connections,
+			MaxStreams:  t.HTTP2.MaxStreams,
+		})
+	} else {
+		cfg := p.clientCfg
+		cfg.MaxConnsPerHost = t.MaxConnsPerHost
+		cfg.TLSInsecure = skipVerify
+		client = protocol.NewHTTPClient(cfg)
+		if t.Pipeline > 1 {
+			client = protocol.NewPipelineClient(cfg, t.Pipeline)
+		}
+	}
+	streams, _ := client.(protocol.StreamReporter)
+	if p.cfg.FaultInject.Enabled() {
+		client = withFaults(client, proto, p.cfg.FaultInject, p.metrics)
+	}
+	// On a lost race the spare client is dropped before it dials.
+	c, loaded := p.targetClients.LoadOrStore(t.Name, client)
+	if !loaded && streams != nil {
+		p.streamReporters.Store(t.Name, streams)
+	}
+	if !loaded && skipVerify && !p.cfg.TLSInsecure {
+		log.Printf("[worker] WARNING: TLS certificate verification is OFF for target %q (tls_insecure)", t.Name)
+	}
+	return c.(protocol.Client)
+}
+
+// withFaults wraps c in a fault-injecting client (#1198). Faked errors
+// use the protocol's own failure status so IsSuccess sees them as
+// failures: HTTP 500, gRPC UNAVAILABLE.
+func withFaults(c protocol.Client, proto config.Protocol, f config.FaultInject, metrics *health.Metrics) protocol.Client {
+	status := 500
+	if proto.GRPCStatus() {
+		status = int(codes.Unavailable)
+	}
+	return protocol.NewFaultClient(c, protocol.FaultConfig{
+		TimeoutRate: f.Timeout,
+		ErrorRate:   f.Error,
+		SlowRate:    f.Slow,
+		SlowDelay:   f.Delay(),
+		ErrorStatus: status,
+		OnFault:     metrics.RecordInjectedFault,
+	})
+}
+
+// ConnQueued returns how many requests blocked waiting for a
+// connection on a max_conns_per_host target.
+func (p *Pool) ConnQueued() int64 {
+	return atomic.LoadInt64(&p.connQueued)
+}
+
 // Active returns the number of currently active workers.
 func (p *Pool) Active() int {
 	return int(atomic.LoadInt64(&p.active))
@@ -447,6 +1201,12 @@ func (p *Pool) TotalDrops() int64 {
 	return atomic.LoadInt64(&p.totalDrops)
 }
 
+// Totals returns the lifetime count of completed requests and of those
+// that weren't a success by the target's definition.
+func (p *Pool) Totals() (requests, errors int64) {
+	return atomic.LoadInt64(&p.totalRequests), atomic.LoadInt64(&p.totalErrors)
+}
+
 // DropRate returns the sustained drop rate over the last dropWindow
 // seconds, as drops/(drops+submits). Returns 0 before the first tick.
 func (p *Pool) DropRate() float64 {
@@ -471,6 +1231,116 @@ func (p *Pool) LatencyPercentile(percentile float64, corrected bool) float64 {
 	return float64(hist.ValueAtQuantile(percentile)) / 1000.0
 }
 
+// recordTargetLatency feeds the per-target raw histogram. Only raw
+// values are kept per target: CO correction depends on the pool-wide
+// send interval, which has no per-target meaning.
+func (p *Pool) recordTargetLatency(target string, observed time.Duration) {
+	micros := observed.Microseconds()
+	if micros < hdrbounds.Min {
+		micros = hdrbounds.Min
+	} else if micros > hdrbounds.Max {
+		micros = hdrbounds.Max
+	}
+
+	p.latMu.Lock()
+	h, ok := p.latByTarget[target]
+	if !ok {
+		h = hdrhistogram.New(hdrbounds.Min, hdrbounds.Max, int(hdrbounds.SigFigs))
+		p.latByTarget[target] = h
+	}
+	_ = h.RecordValue(micros)
+	p.latMu.Unlock()
+}
+
+// recordTTFB feeds the time-to-first-byte histogram.
+func (p *Pool) recordTTFB(ttfb time.Duration) {
+	micros := ttfb.Microseconds()
+	if micros < hdrbounds.Min {
+		micros = hdrbounds.Min
+	} else if micros > hdrbounds.Max {
+		micros = hdrbounds.Max
+	}
+
+	p.latMu.Lock()
+	_ = p.latTTFB.RecordValue(micros)
+	p.latMu.Unlock()
+}
+
+// TTFBPercentile returns the time-to-first-byte percentile in
+// milliseconds, or 0 when no target records TTFB.
+func (p *Pool) TTFBPercentile(percentile float64) float64 {
+	p.latMu.Lock()
+	defer p.latMu.Unlock()
+	if p.latTTFB.TotalCount() == 0 {
+		return 0
+	}
+	return float64(p.latTTFB.ValueAtQuantile(percentile)) / 1000.0
+}
+
+// TargetLatency is one target's raw latency percentiles in milliseconds.
+type TargetLatency struct {
+	Target  string  `json:"target"`
+	Samples int64   `json:"samples"`
+	P95Ms   float64 `json:"p95_ms"`
+	P99Ms   float64 `json:"p99_ms"`
+
+	// ExpectedMs is the target's expected_latency, filled in by the
+	// daemon (#1209); 0 when unset.
+	ExpectedMs float64 `json:"expected_ms,omitempty"`
+}
+
+// OverExpected reports whether the target's P95 exceeds its expected
+// latency.
+func (t TargetLatency) OverExpected() bool {
+	return t.ExpectedMs > 0 && t.P95Ms > t.ExpectedMs
+}
+
+// TargetLatencies returns raw percentiles for every target that has
+// recorded at least one sample, sorted by target name.
+func (p *Pool) TargetLatencies() []TargetLatency {
+	p.latMu.Lock()
+	defer p.latMu.Unlock()
+
+	out := make([]TargetLatency, 0, len(p.latByTarget))
+	for name, h := range p.latByTarget {
+		if h.TotalCount() == 0 {
+			continue
+		}
+		out = append(out, TargetLatency{
+			Target:  name,
+			Samples: h.TotalCount(),
+			P95Ms:   float64(h.ValueAtQuantile(95)) / 1000.0,
+			P99Ms:   float64(h.ValueAtQuantile(99)) / 1000.0,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Target < out[j].Target })
+	return out
+}
+
+// TargetAvgLatencyPercentile returns the unweighted mean of each
+// target's own raw percentile, in milliseconds. Unlike
+// LatencyPercentile (pooled, request-weighted) every target counts
+// once regardless of volume, so a slow low-traffic endpoint is not
+// hidden behind a fast high-traffic one. Returns 0 with no samples.
+func (p *Pool) TargetAvgLatencyPercentile(percentile float64) float64 {
+	p.latMu.Lock()
+	defer p.latMu.Unlock()
+
+	var sum float64
+	var n int
+	for _, h := range p.latByTarget {
+		if h.TotalCount() == 0 {
+			continue
+		}
+		sum += float64(h.ValueAtQuantile(percentile)) / 1000.0
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
 // LatencySamples returns (raw, corrected) sample counts. The corrected
 // count is typically larger because RecordCorrectedValue synthesises
 // samples for each missed slot during a stall.
@@ -550,11 +1420,18 @@ func (p *Pool) Stop() {
 
 	close(p.jobs)
 	p.wg.Wait()
+	if p.gc.enabled.Load() {
+		p.readGC() // settle the requests that ended after the last poll
+	}
 
 	// Close all clients
 	for _, client := range p.clients {
 		client.Close()
 	}
+	p.targetClients.Range(func(_, c any) bool {
+		c.(protocol.Client).Close()
+		return true
+	})
 
 	log.Printf("[worker] all workers stopped")
 }
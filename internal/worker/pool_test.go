@@ -1,14 +1,42 @@
 package worker
 
 import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
 	"github.com/kar98k/internal/config"
 	"github.com/kar98k/internal/health"
+	"github.com/kar98k/internal/hooks"
+	"github.com/kar98k/pkg/protocol"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpchealth "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
 )
 
 // freshMetrics returns a Metrics bound to a private registry. Each test
@@ -16,7 +44,7 @@ import (
 // on duplicate names across cases.
 func freshMetrics(t *testing.T) *health.Metrics {
 	t.Helper()
-	return health.NewMetricsWithRegistry(prometheus.NewRegistry())
+	return health.NewMetrics(prometheus.NewRegistry())
 }
 
 // counterValue reads a Prometheus counter without going through the
@@ -216,6 +244,50 @@ func TestLatencyPercentile_EmptyReturnsZero(t *testing.T) {
 	}
 }
 
+// TestTargetAvgLatency_UnweightedVsPooled: one busy fast target and
+// one quiet slow target. The pooled P95 follows the busy target; the
+// per-target average gives the slow one equal say.
+func TestTargetAvgLatency_UnweightedVsPooled(t *testing.T) {
+	p := newTestPool(t)
+	p.SetRate(100)
+
+	for i := 0; i < 990; i++ {
+		p.recordLatency(1 * time.Millisecond)
+		p.recordTargetLatency("fast", 1*time.Millisecond)
+	}
+	for i := 0; i < 10; i++ {
+		p.recordLatency(100 * time.Millisecond)
+		p.recordTargetLatency("slow", 100*time.Millisecond)
+	}
+
+	pooled := p.LatencyPercentile(95, false)
+	avg := p.TargetAvgLatencyPercentile(95)
+	if pooled > 5 {
+		t.Fatalf("pooled P95 = %.2fms, want it dominated by the fast target", pooled)
+	}
+	if avg < 40 || avg > 60 {
+		t.Fatalf("per-target avg P95 = %.2fms, want ~50ms (mean of 1ms and 100ms)", avg)
+	}
+
+	tl := p.TargetLatencies()
+	if len(tl) != 2 || tl[0].Target != "fast" || tl[1].Target != "slow" {
+		t.Fatalf("TargetLatencies = %+v, want fast then slow", tl)
+	}
+	if tl[0].Samples != 990 || tl[1].Samples != 10 {
+		t.Fatalf("sample counts = %d/%d, want 990/10", tl[0].Samples, tl[1].Samples)
+	}
+}
+
+func TestTargetAvgLatency_EmptyReturnsZero(t *testing.T) {
+	p := newTestPool(t)
+	if got := p.TargetAvgLatencyPercentile(95); got != 0 {
+		t.Fatalf("empty per-target avg = %v, want 0", got)
+	}
+	if got := p.TargetLatencies(); len(got) != 0 {
+		t.Fatalf("TargetLatencies = %+v, want empty", got)
+	}
+}
+
 func TestSnapshotAndResetHistograms(t *testing.T) {
 	p := newTestPool(t)
 	p.SetRate(100) // 10ms expected interval
@@ -262,3 +334,1358 @@ func TestSnapshotAndResetHistograms(t *testing.T) {
 		t.Fatalf("decoded raw count = %d, want %d", decoded.TotalCount(), nSamples)
 	}
 }
+
+// waitWorkers polls until the running worker count settles at want.
+func waitWorkers(t *testing.T, p *Pool, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if atomic.LoadInt64(&p.workers) == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("workers = %d, want %d", atomic.LoadInt64(&p.workers), want)
+}
+
+func TestSetPoolSize_GrowAndShrink(t *testing.T) {
+	cfg := config.Worker{PoolSize: 2, QueueSize: 16, MaxIdleConns: 1, IdleConnTimeout: time.Second}
+	p := NewPool(cfg, freshMetrics(t))
+	p.Pause() // jobs are consumed but never sent
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	defer p.Stop()
+
+	waitWorkers(t, p, 2)
+
+	if err := p.SetPoolSize(5); err != nil {
+		t.Fatalf("grow: %v", err)
+	}
+	waitWorkers(t, p, 5)
+
+	p.resizeMu.Lock()
+	p.lastResize = time.Time{} // skip the anti-thrash interval
+	p.resizeMu.Unlock()
+	if err := p.SetPoolSize(1); err != nil {
+		t.Fatalf("shrink: %v", err)
+	}
+	// Idle workers retire after their next job; feed enough to wake them.
+	for i := 0; i < 8; i++ {
+		p.Submit(Job{})
+	}
+	waitWorkers(t, p, 1)
+	if got := p.PoolSize(); got != 1 {
+		t.Fatalf("PoolSize = %d, want 1", got)
+	}
+}
+
+func TestSetPoolSize_MinResizeInterval(t *testing.T) {
+	cfg := config.Worker{PoolSize: 1, QueueSize: 4, MaxIdleConns: 1, IdleConnTimeout: time.Second,
+		MinResizeInterval: time.Hour}
+	p := NewPool(cfg, freshMetrics(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	defer p.Stop()
+
+	if err := p.SetPoolSize(2); err != nil {
+		t.Fatalf("first resize: %v", err)
+	}
+	if err := p.SetPoolSize(3); !errors.Is(err, ErrResizeTooSoon) {
+		t.Fatalf("second resize err = %v, want ErrResizeTooSoon", err)
+	}
+	if err := p.SetPoolSize(0); err == nil {
+		t.Fatal("SetPoolSize(0) should fail")
+	}
+}
+
+func TestSetPoolSize_BeforeStartFails(t *testing.T) {
+	p := newTestPool(t)
+	if err := p.SetPoolSize(4); err == nil {
+		t.Fatal("SetPoolSize before Start should fail")
+	}
+}
+
+func TestSpecStats_SortedBreakdown(t *testing.T) {
+	p := newTestPool(t)
+	p.recordSpec("orders", "remove", true)
+	p.recordSpec("orders", "create", true)
+	p.recordSpec("orders", "create", false)
+	p.recordSpec("auth", "login", true)
+
+	got := p.SpecStats()
+	want := []SpecStat{
+		{Target: "auth", Spec: "login", Requests: 1},
+		{Target: "orders", Spec: "create", Requests: 2, Errors: 1},
+		{Target: "orders", Spec: "remove", Requests: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("[%d] got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTTFBPercentile(t *testing.T) {
+	p := newTestPool(t)
+	if got := p.TTFBPercentile(95); got != 0 {
+		t.Fatalf("empty TTFB p95 = %v, want 0", got)
+	}
+	for i := 1; i <= 100; i++ {
+		p.recordTTFB(time.Duration(i) * time.Millisecond)
+	}
+	if got := p.TTFBPercentile(95); math.Abs(got-95) > 1 {
+		t.Fatalf("TTFB p95 = %v, want ~95ms", got)
+	}
+	// TTFB must not leak into the whole-response histogram.
+	if got := p.LatencyPercentile(95, false); got != 0 {
+		t.Fatalf("raw latency p95 = %v, want 0", got)
+	}
+}
+
+func TestClientFor_MaxConnsPerHost(t *testing.T) {
+	p := newTestPool(t)
+	shared := config.Target{Name: "shared", Protocol: config.ProtocolHTTP}
+	capped := config.Target{Name: "capped", Protocol: config.ProtocolHTTP, MaxConnsPerHost: 1}
+
+	if p.ClientFor(shared) != p.GetClient(config.ProtocolHTTP) {
+		t.Fatalf("uncapped target should use the shared client")
+	}
+	c := p.ClientFor(capped)
+	if c == p.GetClient(config.ProtocolHTTP) {
+		t.Fatalf("capped target should get its own client")
+	}
+	if p.ClientFor(capped) != c {
+		t.Fatalf("capped client should be reused across calls")
+	}
+}
+
+func TestClientFor_HTTP2ConnectionsAndStreams(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		peers = map[string]bool{}
+	)
+	release := make(chan struct{})
+	srv := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		peers[r.RemoteAddr] = true
+		mu.Unlock()
+		<-release
+	}), &http2.Server{}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	target := config.Target{Name: "h2", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP2,
+		HTTP2: config.HTTP2Settings{Connections: 3, MaxStreams: 2}}
+	client := p.ClientFor(target)
+	if client == p.GetClient(config.ProtocolHTTP2) {
+		t.Fatalf("http2 target with an http2 block should get its own client")
+	}
+
+	// 3 connections × 2 streams hold 6 requests; the other 3 wait.
+	var wg sync.WaitGroup
+	errs := make(chan error, 9)
+	for range 9 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp := client.Do(context.Background(), &protocol.Request{URL: srv.URL, Method: "GET"}); resp.Error != nil {
+				errs <- resp.Error
+			}
+		}()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st := p.HTTP2Stats()
+		if len(st) == 1 && st[0].Streams == 6 && st[0].Waited == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("streams never settled at 6 in flight with 3 waiting: %+v", st)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	st := p.HTTP2Stats()[0]
+	if st.Target != "h2" || st.Connections != 3 || st.PeakStreams != 2 || st.Streams != 0 {
+		t.Fatalf("stats = %+v", st)
+	}
+	if len(peers) != 3 {
+		t.Fatalf("server saw %d connections, want 3", len(peers))
+	}
+}
+
+func TestClientFor_TLSVerification(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	skip := true
+	verified := config.Target{Name: "verified", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP}
+	insecure := verified
+	insecure.Name = "insecure"
+	insecure.TLSInsecure = &skip
+
+	// The test server's certificate is self-signed: verification on by
+	// default must fail the request, the per-target opt-out must not.
+	if resp := p.ClientFor(verified).Do(context.Background(), &protocol.Request{URL: srv.URL, Method: "GET"}); resp.Error == nil {
+		t.Fatalf("self-signed certificate accepted with verification on")
+	}
+	if resp := p.ClientFor(insecure).Do(context.Background(), &protocol.Request{URL: srv.URL, Method: "GET"}); resp.Error != nil {
+		t.Fatalf("tls_insecure target failed: %v", resp.Error)
+	}
+}
+
+func TestWarm_ResumesTLSSessions(t *testing.T) {
+	var heads int64
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			atomic.AddInt64(&heads, 1)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.Worker{PoolSize: 1, QueueSize: 1, MaxIdleConns: 1, IdleConnTimeout: time.Second, TLSInsecure: true}
+	cfg.TLS.SessionCache = 8
+	p := NewPool(cfg, freshMetrics(t))
+	target := config.Target{Name: "api", URL: srv.URL + "/v1/{{seq}}", Method: "GET", Protocol: config.ProtocolHTTP}
+	if p.TLSHandshakes() != nil {
+		t.Fatal("handshakes reported before any connection")
+	}
+
+	p.Warm(context.Background(), []config.Target{target, target})
+	if got := atomic.LoadInt64(&heads); got != 1 {
+		t.Fatalf("warmup sent %d HEAD requests, want one per host", got)
+	}
+	// A new connection resumes the session the warmup cached.
+	p.GetClient(config.ProtocolHTTP).Close()
+	resp := p.ClientFor(target).Do(context.Background(), &protocol.Request{URL: srv.URL, Method: "GET"})
+	if resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	if st := p.TLSHandshakes(); st == nil || st.Full != 1 || st.Resumed != 1 || st.Warmed != 1 {
+		t.Fatalf("handshakes = %+v, want 1 full, 1 resumed, 1 warmed", st)
+	}
+}
+
+func TestClientFor_PipelinesRequests(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	var accepted int64
+	// The server only answers once all three requests are on the wire,
+	// so they arrive only if the client pipelines them.
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt64(&accepted, 1)
+			go func() {
+				defer conn.Close()
+				br := bufio.NewReader(conn)
+				for i := 0; i < 3; i++ {
+					if _, err := http.ReadRequest(br); err != nil {
+						return
+					}
+				}
+				for i := 0; i < 3; i++ {
+					fmt.Fprintf(conn, "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok")
+				}
+				io.Copy(io.Discard, br)
+			}()
+		}
+	}()
+
+	p := newTestPool(t)
+	url := "http://" + ln.Addr().String() + "/"
+	target := config.Target{Name: "legacy", URL: url, Method: "GET", Protocol: config.ProtocolHTTP, Pipeline: 3}
+	client := p.ClientFor(target)
+	if client == p.GetClient(config.ProtocolHTTP) {
+		t.Fatalf("pipelined target should get its own client")
+	}
+
+	var wg sync.WaitGroup
+	resps := make([]*protocol.Response, 3)
+	for i := range resps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resps[i] = client.Do(context.Background(), &protocol.Request{URL: url, Method: "GET", Timeout: 2 * time.Second})
+		}()
+	}
+	wg.Wait()
+	for i, r := range resps {
+		if r.Error != nil || r.StatusCode != 200 || r.BytesRead != 2 {
+			t.Fatalf("request %d: status %d, %d bytes, err %v", i, r.StatusCode, r.BytesRead, r.Error)
+		}
+	}
+	if n := atomic.LoadInt64(&accepted); n != 1 {
+		t.Fatalf("connections = %d, want all three requests on one", n)
+	}
+}
+
+func TestProcessJob_CountsConnQueueWait(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	target := config.Target{Name: "capped", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP, MaxConnsPerHost: 1}
+	job := Job{Target: target, Client: p.ClientFor(target)}
+
+	// Two concurrent requests against a one-connection cap: the second
+	// has to wait for the first to hand its connection over.
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.processJob(context.Background(), job)
+		}()
+	}
+	wg.Wait()
+	if got := p.ConnQueued(); got != 1 {
+		t.Fatalf("ConnQueued = %d, want 1", got)
+	}
+}
+
+func TestProcessJob_GRPCHeadersBecomeMetadata(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan metadata.MD, 1)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		got <- md
+		return h(ctx, req)
+	}))
+	grpc_health_v1.RegisterHealthServer(srv, grpchealth.NewServer())
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	target := config.Target{
+		Name:     "grpc",
+		URL:      lis.Addr().String(),
+		Protocol: config.ProtocolGRPC,
+		Headers:  map[string]string{"Authorization": "Bearer t0k", "x-tenant-id": "acme"},
+	}
+	p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolGRPC)})
+
+	select {
+	case md := <-got:
+		if v := md.Get("authorization"); len(v) != 1 || v[0] != "Bearer t0k" {
+			t.Fatalf("authorization metadata = %v", v)
+		}
+		if v := md.Get("x-tenant-id"); len(v) != 1 || v[0] != "acme" {
+			t.Fatalf("x-tenant-id metadata = %v", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server never saw the call")
+	}
+}
+
+func TestProcessJob_GRPCCallsMethodThroughReflection(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan string, 4)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+		if r, ok := req.(*grpc_health_v1.HealthCheckRequest); ok {
+			got <- info.FullMethod + " " + r.GetService()
+		}
+		return h(ctx, req)
+	}))
+	health := grpchealth.NewServer()
+	health.SetServingStatus("db", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(srv, health)
+	reflection.Register(srv)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	target := config.Target{
+		Name:     "grpc",
+		URL:      lis.Addr().String(),
+		Protocol: config.ProtocolGRPC,
+		Body:     `{"service": "db"}`,
+		GRPC:     &config.GRPCCall{Method: "grpc.health.v1.Health/Check"},
+	}
+	p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolGRPC)})
+	target.Body = `{"service": "cache"}`
+	p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolGRPC)})
+
+	for _, want := range []string{"/grpc.health.v1.Health/Check db", "/grpc.health.v1.Health/Check cache"} {
+		select {
+		case call := <-got:
+			if call != want {
+				t.Fatalf("server saw %q, want %q", call, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("server never saw %q", want)
+		}
+	}
+	// The unknown service answers NOT_FOUND, which is the call's status.
+	want := []ErrorCount{{Target: "grpc", Class: "NotFound", Count: 1}}
+	if got := p.ErrorCounts(); !slices.Equal(got, want) {
+		t.Fatalf("error counts = %+v, want %+v", got, want)
+	}
+}
+
+// stallingGRPCServer answers health checks only once the client gives
+// up, reporting on deadlines whether each call carried a grpc-timeout.
+func stallingGRPCServer(t *testing.T) (addr string, deadlines <-chan bool) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan bool, 4)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+		_, ok := ctx.Deadline()
+		got <- ok
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+		return h(ctx, req)
+	}))
+	grpc_health_v1.RegisterHealthServer(srv, grpchealth.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String(), got
+}
+
+func TestProcessJob_GRPCTimeoutWithoutDeadlineIsDeadlineExceeded(t *testing.T) {
+	addr, deadlines := stallingGRPCServer(t)
+	p := newTestPool(t)
+	p.SetRate(1000)
+	target := config.Target{
+		Name: "grpc", URL: addr, Protocol: config.ProtocolGRPC,
+		Timeout: 100 * time.Millisecond,
+	}
+	p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolGRPC)})
+
+	if <-deadlines {
+		t.Fatal("call carried grpc-timeout with propagate_deadline off")
+	}
+	want := []ErrorCount{{Target: "grpc", Class: "DeadlineExceeded", Count: 1}}
+	if got := p.ErrorCounts(); !slices.Equal(got, want) {
+		t.Fatalf("error counts = %+v, want %+v", got, want)
+	}
+}
+
+func TestProcessJob_GRPCMaxTotalTimeSendsNoDeadline(t *testing.T) {
+	addr, deadlines := stallingGRPCServer(t)
+	p := newTestPool(t)
+	p.SetRate(1000)
+	target := config.Target{
+		Name: "grpc", URL: addr, Protocol: config.ProtocolGRPC,
+		MaxTotalTime: 100 * time.Millisecond,
+	}
+	start := time.Now()
+	p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolGRPC)})
+
+	if took := time.Since(start); took > 2*time.Second {
+		t.Fatalf("call took %s, want it cut at max_total_time", took)
+	}
+	if <-deadlines {
+		t.Fatal("max_total_time reached the server as grpc-timeout")
+	}
+	want := []ErrorCount{{Target: "grpc", Class: "DeadlineExceeded", Count: 1}}
+	if got := p.ErrorCounts(); !slices.Equal(got, want) {
+		t.Fatalf("error counts = %+v, want %+v", got, want)
+	}
+}
+
+func TestProcessJob_GRPCWebFramesCallAndReadsTrailers(t *testing.T) {
+	type call struct {
+		path, contentType string
+		msg               []byte
+	}
+	calls := make(chan call, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if len(body) < 5 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+			http.Error(w, "bad frame", http.StatusBadRequest)
+			return
+		}
+		calls <- call{r.URL.Path, r.Header.Get("Content-Type"), body[5:]}
+
+		status := "0"
+		if r.URL.Path == "/echo.Echo/Fail" {
+			status = "14"
+		}
+		w.Header().Set("Content-Type", "application/grpc-web+proto")
+		trailer := []byte("grpc-status:" + status + "\r\ngrpc-message:down%20for%20maintenance\r\n")
+		var frames []byte
+		for _, f := range []struct {
+			flag byte
+			data []byte
+		}{{0, []byte("reply")}, {0x80, trailer}} {
+			frames = append(frames, f.flag)
+			frames = binary.BigEndian.AppendUint32(frames, uint32(len(f.data)))
+			frames = append(frames, f.data...)
+		}
+		w.Write(frames)
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	ok := config.Target{Name: "ok", URL: srv.URL, Body: "\x0a\x02hi", Protocol: config.ProtocolGRPCWeb}
+	fail := config.Target{Name: "fail", URL: srv.URL + "/echo.Echo/Fail", Protocol: config.ProtocolGRPCWeb}
+	for _, target := range []config.Target{ok, fail} {
+		p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(target.Protocol)})
+	}
+
+	first := <-calls
+	if first.path != protocol.DefaultGRPCWebMethod || first.contentType != "application/grpc-web+proto" || string(first.msg) != ok.Body {
+		t.Fatalf("call = %+v, want the health method with the body as the framed message", first)
+	}
+	if reqs, errs := p.Totals(); reqs != 2 || errs != 1 {
+		t.Fatalf("totals = %d requests / %d errors, want 2 / 1: grpc-status 14 in the trailers fails the call", reqs, errs)
+	}
+}
+
+func TestProcessJob_MaxTotalTimeCutsOffTricklingBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Headers arrive promptly, then the body never finishes.
+		w.WriteHeader(http.StatusOK)
+		for {
+			if _, err := w.Write([]byte("x")); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	target := config.Target{Name: "trickle", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP, MaxTotalTime: 100 * time.Millisecond}
+
+	start := time.Now()
+	p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolHTTP)})
+	if took := time.Since(start); took > 2*time.Second {
+		t.Fatalf("request ran for %s despite max_total_time", took)
+	}
+	if _, errs := p.Totals(); errs != 1 {
+		t.Fatalf("errors = %d, want the cut-off request counted as one", errs)
+	}
+	if got := counterValue(t, p.metrics.DeadlineExceededTotal.WithLabelValues("trickle")); got != 1 {
+		t.Fatalf("deadline_exceeded_total = %v, want 1", got)
+	}
+}
+
+// connectTimeoutClient fails every request as if its dial ran out of
+// connect_timeout, recording the timeout it was given.
+type connectTimeoutClient struct{ got atomic.Int64 }
+
+func (c *connectTimeoutClient) Do(_ context.Context, req *protocol.Request) *protocol.Response {
+	c.got.Store(int64(req.ConnectTimeout))
+	return &protocol.Response{Error: fmt.Errorf("Get %q: %w", req.URL, protocol.ErrConnectTimeout)}
+}
+func (c *connectTimeoutClient) Close() error { return nil }
+
+func TestProcessJob_TimeoutsSplitByPhase(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	target := config.Target{Name: "api", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP,
+		Timeout: 50 * time.Millisecond, ConnectTimeout: 20 * time.Millisecond}
+
+	// The local server accepts at once, so the timeout is the response's.
+	p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolHTTP)})
+	c := &connectTimeoutClient{}
+	p.processJob(context.Background(), Job{Target: target, Client: c})
+
+	if got := time.Duration(c.got.Load()); got != target.ConnectTimeout {
+		t.Fatalf("request ConnectTimeout = %s, want %s", got, target.ConnectTimeout)
+	}
+	if _, errs := p.Totals(); errs != 2 {
+		t.Fatalf("errors = %d, want 2", errs)
+	}
+	for _, phase := range []string{"connect", "response"} {
+		if got := counterValue(t, p.metrics.TimeoutsTotal.WithLabelValues("api", phase)); got != 1 {
+			t.Fatalf("timeouts_total{phase=%s} = %v, want 1", phase, got)
+		}
+	}
+}
+
+// cannedClient answers every request with resp.
+type cannedClient struct{ resp protocol.Response }
+
+func (c cannedClient) Do(context.Context, *protocol.Request) *protocol.Response {
+	resp := c.resp
+	return &resp
+}
+func (cannedClient) Close() error { return nil }
+
+func TestProcessJob_ErrorCountsByTargetAndClass(t *testing.T) {
+	p := newTestPool(t)
+	p.SetRate(1000)
+	api := config.Target{Name: "api", Method: "GET", Protocol: config.ProtocolHTTP}
+	rpc := config.Target{Name: "rpc", Protocol: config.ProtocolGRPC}
+	jobs := []Job{
+		{Target: api, Client: cannedClient{protocol.Response{StatusCode: 503}}},
+		{Target: api, Client: cannedClient{protocol.Response{StatusCode: 503}}},
+		{Target: api, Client: cannedClient{protocol.Response{StatusCode: 200}}},
+		{Target: api, Client: cannedClient{protocol.Response{Error: &net.DNSError{Err: "no such host", IsNotFound: true}}}},
+		{Target: api, Client: cannedClient{protocol.Response{Error: fmt.Errorf("%w: dial", protocol.ErrConnectTimeout)}}},
+		{Target: rpc, Client: cannedClient{protocol.Response{StatusCode: int(codes.Unavailable), Error: errors.New("unavailable")}}},
+	}
+	for _, j := range jobs {
+		p.processJob(context.Background(), j)
+	}
+
+	want := []ErrorCount{
+		{Target: "api", Class: "503", Count: 2},
+		{Target: "api", Class: "connect_timeout", Count: 1},
+		{Target: "api", Class: "dns", Count: 1},
+		{Target: "rpc", Class: "Unavailable", Count: 1},
+	}
+	if got := p.ErrorCounts(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("error counts = %+v, want %+v", got, want)
+	}
+}
+
+func TestProcessJob_LabelCapCollapsesExtraTargets(t *testing.T) {
+	p := newTestPool(t)
+	p.SetRate(1000)
+	p.metrics.SetLabelLimit(2)
+	for _, name := range []string{"a", "b", "c", "d", "a"} {
+		target := config.Target{Name: name, Method: "GET", Protocol: config.ProtocolHTTP}
+		p.processJob(context.Background(), Job{Target: target, Client: okClient{}})
+	}
+
+	for target, want := range map[string]float64{"a": 2, "b": 1, health.OtherLabel: 2} {
+		if got := counterValue(t, p.metrics.RequestsTotal.WithLabelValues(target, "success", "http")); got != want {
+			t.Fatalf("requests_total{target=%s} = %v, want %v", target, got, want)
+		}
+	}
+	if got := counterValue(t, p.metrics.LabelsCollapsedTotal.WithLabelValues("target")); got != 2 {
+		t.Fatalf("collapsed target values = %v, want 2", got)
+	}
+}
+
+func TestProcessJob_FaultInjectFakesErrorsWithoutCallingTarget(t *testing.T) {
+	var hits int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&hits, 1)
+	}))
+	defer srv.Close()
+
+	p := NewPool(config.Worker{
+		PoolSize:    1,
+		QueueSize:   1,
+		FaultInject: config.FaultInject{Error: 1},
+	}, freshMetrics(t))
+	p.SetRate(1000)
+	target := config.Target{Name: "api", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP}
+	for i := 0; i < 3; i++ {
+		p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolHTTP)})
+	}
+
+	if n := atomic.LoadInt64(&hits); n != 0 {
+		t.Fatalf("target received %d requests; faked errors must not reach it", n)
+	}
+	if reqs, errs := p.Totals(); reqs != 3 || errs != 3 {
+		t.Fatalf("totals = %d requests / %d errors, want 3 / 3", reqs, errs)
+	}
+	if got := counterValue(t, p.metrics.InjectedFaultsTotal.WithLabelValues(protocol.FaultError)); got != 3 {
+		t.Fatalf("injected_faults_total{kind=error} = %v, want 3", got)
+	}
+}
+
+func TestProcessJob_HooksSignRequestAndOverrideVerdict(t *testing.T) {
+	var gotSig atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig.Store(r.Header.Get("X-Sig"))
+		w.Write([]byte(`{"ok": false}`))
+	}))
+	defer srv.Close()
+
+	script := filepath.Join(t.TempDir(), "hooks.star")
+	src := `
+def pre_request(req):
+    req["headers"]["X-Sig"] = hmac_sha256("k", req["url"])
+
+def post_response(req, resp):
+    return resp.status == 200 and resp.bytes < 10
+`
+	if err := os.WriteFile(script, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h, err := hooks.Load(script, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	p.SetHooks(h)
+	shared := map[string]string{"Accept": "application/json"}
+	target := config.Target{Name: "hooked", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP, Headers: shared}
+	p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolHTTP)})
+
+	if sig, _ := gotSig.Load().(string); len(sig) != 64 {
+		t.Fatalf("X-Sig = %q, want a hex HMAC set by pre_request", sig)
+	}
+	if _, ok := shared["X-Sig"]; ok {
+		t.Fatal("pre_request mutated the target's shared header map")
+	}
+	if _, errs := p.Totals(); errs != 1 {
+		t.Fatalf("errors = %d, want the 200 failed by post_response", errs)
+	}
+}
+
+func TestProcessJob_SequentialDataFileSendsEachRowOnce(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path+" "+r.Header.Get("X-User"))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	csvPath := filepath.Join(t.TempDir(), "users.csv")
+	if err := os.WriteFile(csvPath, []byte("id,name\n1,ann\n2,bob\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	target := config.Target{
+		Name: "users", URL: srv.URL + "/users/${id}", Method: "GET", Protocol: config.ProtocolHTTP,
+		Headers:  map[string]string{"X-User": "${name}"},
+		DataFile: &config.DataFile{Path: csvPath},
+	}
+	ds, err := LoadDataSources([]config.Target{target})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	p.SetDataSources(ds)
+	for i := 0; i < 4; i++ {
+		p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolHTTP)})
+	}
+
+	if len(paths) != 2 || paths[0] != "/users/1 ann" || paths[1] != "/users/2 bob" {
+		t.Fatalf("requests = %q, want each row once then none", paths)
+	}
+	if target.Headers["X-User"] != "${name}" {
+		t.Fatal("row substitution mutated the target's shared header map")
+	}
+}
+
+func TestProcessJob_ExpandsBodyTemplatePerRequest(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	a := config.Target{Name: "a", URL: srv.URL, Method: "POST", Protocol: config.ProtocolHTTP, Body: `{"seq": {{counter}}}`}
+	b := a
+	b.Name, b.Body = "b", `seq={{counter}}`
+	for _, target := range []config.Target{a, b, a} {
+		p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolHTTP)})
+	}
+
+	want := []string{`{"seq": 1}`, `seq=2`, `{"seq": 3}`}
+	if len(bodies) != 3 || bodies[0] != want[0] || bodies[1] != want[1] || bodies[2] != want[2] {
+		t.Fatalf("bodies = %q, want %q: one counter across the pool", bodies, want)
+	}
+}
+
+func TestProcessJob_TracksHeaderOverTime(t *testing.T) {
+	var remaining atomic.Int64
+	remaining.Store(3)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(remaining.Add(-1), 10))
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	target := config.Target{Name: "api", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP, TrackHeader: "X-RateLimit-Remaining"}
+	for i := 0; i < 3; i++ {
+		p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolHTTP)})
+	}
+
+	tracks := p.HeaderTracks()
+	if len(tracks) != 1 {
+		t.Fatalf("tracks = %+v", tracks)
+	}
+	h := tracks[0]
+	if h.Header != "X-RateLimit-Remaining" || h.First != "2" || h.Last != "0" || h.Samples != 3 || h.Changes != 2 {
+		t.Fatalf("track = %+v, want 2 counting down to 0", h)
+	}
+	if h.Min == nil || *h.Min != 0 || *h.Max != 2 {
+		t.Fatalf("numeric range = %v..%v, want 0..2", h.Min, h.Max)
+	}
+
+	// A value that isn't a number drops the range.
+	p.trackHeader("api", "X-RateLimit-Remaining", &protocol.Response{Header: "unlimited"})
+	if h := p.HeaderTracks()[0]; h.Min != nil || h.Last != "unlimited" {
+		t.Fatalf("after a non-numeric value: %+v", h)
+	}
+}
+
+func TestProcessJob_ExpectCountsLogicalErrors(t *testing.T) {
+	var n atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/accepted" {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if n.Add(1)%2 == 0 {
+			w.Write([]byte(`{"error": true}`))
+			return
+		}
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	body := config.Target{Name: "body", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP,
+		Expect: &config.Expect{BodyContains: `"ok"`}}
+	status := config.Target{Name: "status", URL: srv.URL + "/accepted", Method: "GET", Protocol: config.ProtocolHTTP,
+		Expect: &config.Expect{Status: []int{200}}}
+	for i := 0; i < 4; i++ {
+		p.processJob(context.Background(), Job{Target: body, Client: p.GetClient(config.ProtocolHTTP)})
+	}
+	p.processJob(context.Background(), Job{Target: status, Client: p.GetClient(config.ProtocolHTTP)})
+
+	want := []ErrorCount{{Target: "body", Class: "expect_body", Count: 2}, {Target: "status", Class: "202", Count: 1}}
+	if got := p.ErrorCounts(); !slices.Equal(got, want) {
+		t.Fatalf("error counts = %+v, want %+v", got, want)
+	}
+	if got := atomic.LoadInt64(&p.errorSlot); got != 3 {
+		t.Fatalf("breaker errors = %d, want 3: a failed assertion is an error by the user's definition", got)
+	}
+}
+
+func TestProcessJob_DuplicatesFlagsRepeatedIDs(t *testing.T) {
+	// IDs 1, 2, 2, 3, then a 409 saying the order already exists.
+	ids := []string{`{"data":{"id":1}}`, `{"data":{"id":2}}`, `{"data":{"id":2}}`, `{"data":{}}`}
+	var n atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(n.Add(1)) - 1
+		if i >= len(ids) {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error": "order already exists"}`))
+			return
+		}
+		w.Write([]byte(ids[i]))
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	tgt := config.Target{Name: "orders", URL: srv.URL, Method: "POST", Protocol: config.ProtocolHTTP,
+		Duplicates: &config.Duplicates{Field: "data.id", Marker: "already exists", Fail: true}}
+	for i := 0; i < 5; i++ {
+		p.processJob(context.Background(), Job{Target: tgt, Client: p.GetClient(config.ProtocolHTTP)})
+	}
+
+	want := DuplicateStat{Target: "orders", Checked: 4, Duplicates: 1, Markers: 1, Missing: 1, Examples: []string{"2"}}
+	if got := p.Duplicates(); len(got) != 1 || !reflect.DeepEqual(got[0], want) {
+		t.Fatalf("duplicates = %+v, want %+v", got, want)
+	}
+	// The repeated ID and the marked 409 both fail the request.
+	wantErrs := []ErrorCount{{Target: "orders", Class: "duplicate", Count: 2}}
+	if got := p.ErrorCounts(); !slices.Equal(got, wantErrs) {
+		t.Fatalf("error counts = %+v, want %+v", got, wantErrs)
+	}
+}
+
+func TestProcessJob_DuplicatesReportOnlyByDefault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("same body"))
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	tgt := config.Target{Name: "static", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP,
+		Duplicates: &config.Duplicates{Body: true, MaxTracked: 1}}
+	for i := 0; i < 3; i++ {
+		p.processJob(context.Background(), Job{Target: tgt, Client: p.GetClient(config.ProtocolHTTP)})
+	}
+	if got := p.Duplicates(); len(got) != 1 || got[0].Duplicates != 2 || len(got[0].Examples) != 2 {
+		t.Fatalf("duplicates = %+v, want 2 repeats of the body", got)
+	}
+	if got := p.ErrorCounts(); len(got) != 0 {
+		t.Fatalf("error counts = %+v, want none without fail", got)
+	}
+}
+
+func TestTargetAchievedTPS(t *testing.T) {
+	p := newTestPool(t)
+	for i := 0; i < 3; i++ {
+		p.countTarget("api")
+	}
+	p.countTarget("auth")
+	p.recordTargetTPS()
+	got := p.TargetAchievedTPS()
+	if got["api"] != 3 || got["auth"] != 1 {
+		t.Fatalf("per-target TPS = %v, want api 3, auth 1", got)
+	}
+	p.recordTargetTPS()
+	if got := p.TargetAchievedTPS(); got["api"] != 0 {
+		t.Fatalf("counters not reset: %v", got)
+	}
+}
+
+func TestClientConfig_KeepAlivePerProtocol(t *testing.T) {
+	cfg := config.Worker{
+		MaxIdleConns: 10,
+		KeepAlive: config.KeepAlive{
+			HTTP:  config.KeepAliveSettings{TCP: 15 * time.Second},
+			HTTP2: config.KeepAliveSettings{Ping: 20 * time.Second, PingTimeout: 3 * time.Second},
+		},
+	}
+	if c := clientConfig(cfg, config.ProtocolHTTP, nil); c.TCPKeepAlive != 15*time.Second || c.PingInterval != 0 {
+		t.Fatalf("http client config = %+v", c)
+	}
+	if c := clientConfig(cfg, config.ProtocolHTTP2, nil); c.PingInterval != 20*time.Second || c.PingTimeout != 3*time.Second || c.TCPKeepAlive != 0 {
+		t.Fatalf("http2 client config = %+v", c)
+	}
+	if c := clientConfig(cfg, config.ProtocolGRPC, nil); c.PingInterval != 0 || c.MaxIdleConns != 10 {
+		t.Fatalf("grpc client config = %+v", c)
+	}
+}
+
+func TestNewPool_ConnRatePacesDials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Closing every connection makes each request dial a new one.
+		w.Header().Set("Connection", "close")
+	}))
+	defer srv.Close()
+
+	p := NewPool(config.Worker{PoolSize: 1, QueueSize: 1, ConnRate: 20}, freshMetrics(t))
+	if p.clientCfg.DialLimiter == nil {
+		t.Fatal("conn_rate set but no dial limiter")
+	}
+	client := p.GetClient(config.ProtocolHTTP)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		resp := client.Do(context.Background(), &protocol.Request{URL: srv.URL, Method: http.MethodGet, Timeout: 5 * time.Second})
+		if resp.Error != nil {
+			t.Fatalf("request %d: %v", i, resp.Error)
+		}
+	}
+	// Burst 1 at 20/s: the first dial is free, the other three wait
+	// 50ms each.
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Fatalf("4 dials took %v, want >= 150ms at 20/s", elapsed)
+	}
+}
+
+func TestProcessJob_CacheBust(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get(config.DefaultCacheBustParam) != "" {
+			w.Header().Set("X-Cache", "MISS")
+		} else {
+			w.Header().Set("X-Cache", "HIT from edge")
+		}
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(10000)
+	target := config.Target{
+		Name: "cdn", URL: srv.URL + "/img?size=1", Method: "GET", Protocol: config.ProtocolHTTP,
+		CacheBust: &config.CacheBust{Fraction: 0.5, StatusHeader: "X-Cache"},
+	}
+	const n = 200
+	for i := 0; i < n; i++ {
+		p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolHTTP)})
+	}
+
+	stats := p.CacheStats()
+	if len(stats) != 1 {
+		t.Fatalf("CacheStats = %+v, want one target", stats)
+	}
+	st := stats[0]
+	if st.Hits+st.Misses != n {
+		t.Fatalf("hits+misses = %d, want %d", st.Hits+st.Misses, n)
+	}
+	if st.Misses != st.Varied {
+		t.Fatalf("misses = %d, varied = %d: every varied request should miss", st.Misses, st.Varied)
+	}
+	if st.Varied < n/4 || st.Varied > 3*n/4 {
+		t.Fatalf("varied = %d of %d, want about half", st.Varied, n)
+	}
+}
+
+func TestProcessJob_WarmupRequestsExcluded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(10000)
+	p.SetWarmup(5, 0)
+	target := config.Target{Name: "jit", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP}
+	for i := 0; i < 12; i++ {
+		p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolHTTP)})
+	}
+
+	w := p.Warmup()
+	if w == nil || w.Excluded != 5 || w.Active {
+		t.Fatalf("Warmup = %+v, want 5 excluded and done", w)
+	}
+	if got := p.latRaw.TotalCount(); got != 7 {
+		t.Fatalf("primary histogram holds %d samples, want the 7 after warmup", got)
+	}
+	if req, _ := p.Totals(); req != 12 {
+		t.Fatalf("requests = %d, warmup requests still count as sent", req)
+	}
+
+	p2 := newTestPool(t)
+	if p2.Warmup() != nil {
+		t.Fatal("Warmup should be nil when off")
+	}
+	p2.SetRate(10000)
+	p2.SetWarmup(1, time.Hour)
+	for i := 0; i < 3; i++ {
+		p2.processJob(context.Background(), Job{Target: target, Client: p2.GetClient(config.ProtocolHTTP)})
+	}
+	if w := p2.Warmup(); w.Excluded != 3 || !w.Active {
+		t.Fatalf("Warmup = %+v, want everything excluded until warmup_duration passes", w)
+	}
+}
+
+func TestConnPoolStats_HTTPOpenIdleInUse(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(10000)
+	target := config.Target{Name: "api", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP}
+	done := make(chan struct{})
+	go func() {
+		p.processJob(context.Background(), Job{Target: target, Client: p.GetClient(config.ProtocolHTTP)})
+		close(done)
+	}()
+
+	host := srv.Listener.Addr().String()
+	find := func() ConnPoolStat {
+		for _, s := range p.ConnPoolStats() {
+			if s.Protocol == string(config.ProtocolHTTP) && s.Host == host {
+				return s
+			}
+		}
+		t.Fatalf("no http stats for %s in %+v", host, p.ConnPoolStats())
+		return ConnPoolStat{}
+	}
+
+	<-entered
+	if s := find(); s.Open != 1 || s.InUse != 1 || s.Idle != 0 {
+		t.Fatalf("during the request: %+v, want 1 open, 1 in use", s)
+	}
+	close(release)
+	<-done
+	if s := find(); s.Open != 1 || s.InUse != 0 || s.Idle != 1 {
+		t.Fatalf("after the request: %+v, want 1 open, 1 idle", s)
+	}
+	p.recordConnPool()
+
+	p.GetClient(config.ProtocolHTTP).Close()
+	if s := find(); s.Open != 0 || s.Idle != 0 {
+		t.Fatalf("after closing idle connections: %+v, want none open", s)
+	}
+}
+
+// panicClient is a protocol client with a bug: every request panics.
+type panicClient struct{}
+
+func (panicClient) Do(context.Context, *protocol.Request) *protocol.Response { panic("boom") }
+func (panicClient) Close() error                                             { return nil }
+
+// okClient answers every request with a 200.
+type okClient struct{}
+
+func (okClient) Do(context.Context, *protocol.Request) *protocol.Response {
+	return &protocol.Response{StatusCode: 200, Duration: time.Millisecond}
+}
+func (okClient) Close() error { return nil }
+
+func TestPool_PanickingClientFailsRequestNotPool(t *testing.T) {
+	m := freshMetrics(t)
+	p := NewPool(config.Worker{PoolSize: 1, QueueSize: 8, MaxIdleConns: 1, IdleConnTimeout: time.Second}, m)
+	p.SetRate(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	defer p.Stop()
+
+	target := config.Target{Name: "buggy", Protocol: config.ProtocolHTTP}
+	p.Submit(Job{Target: target, Client: panicClient{}})
+	p.Submit(Job{Target: target, Client: panicClient{}})
+	p.Submit(Job{Target: target, Client: okClient{}})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if reqs, _ := p.Totals(); reqs == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the lone worker stopped processing after a client panic")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, errs := p.Totals(); errs != 2 {
+		t.Fatalf("errors = %d, want the 2 panicking requests", errs)
+	}
+	if got := counterValue(t, m.PanicsTotal.WithLabelValues("worker")); got != 2 {
+		t.Fatalf("panics_total{component=worker} = %v, want 2", got)
+	}
+}
+
+func TestBodyGen_Distributions(t *testing.T) {
+	fixed := newBodyGen(config.SyntheticBody{Size: 512}, 1)
+	a, b := fixed.next(), fixed.next()
+	if len(a) != 512 || len(b) != 512 {
+		t.Fatalf("fixed sizes = %d, %d; want 512", len(a), len(b))
+	}
+	if &a[0] != &b[0] {
+		t.Error("fixed bodies should reuse one buffer")
+	}
+
+	uniform := newBodyGen(config.SyntheticBody{Distribution: config.SizeUniform, MinSize: 100, MaxSize: 200}, 2)
+	lo, hi := 1<<30, 0
+	for i := 0; i < 2000; i++ {
+		n := len(uniform.next())
+		lo, hi = min(lo, n), max(hi, n)
+	}
+	if lo < 100 || hi > 200 || lo > 110 || hi < 190 {
+		t.Errorf("uniform sizes span %d..%d, want about 100..200", lo, hi)
+	}
+
+	logn := newBodyGen(config.SyntheticBody{Distribution: config.SizeLognormal, Size: 1000, MaxSize: 20000}, 3)
+	sizes := make([]int, 4001)
+	for i := range sizes {
+		sizes[i] = len(logn.next())
+		if sizes[i] > 20000 {
+			t.Fatalf("lognormal size %d above max_size", sizes[i])
+		}
+	}
+	sort.Ints(sizes)
+	if median := sizes[len(sizes)/2]; median < 900 || median > 1100 {
+		t.Errorf("lognormal median = %d, want about 1000", median)
+	}
+	if sizes[len(sizes)*9/10] < 3000 {
+		t.Errorf("lognormal p90 = %d, want a long tail (about 3600 at sigma 1)", sizes[len(sizes)*9/10])
+	}
+}
+
+func TestProcessJob_SyntheticBody(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, fmt.Sprintf("%d %s", len(body), r.Header.Get("Content-Type")))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	p.SetRate(1000)
+	target := config.Target{
+		Name: "upload", URL: srv.URL, Method: "POST", Protocol: config.ProtocolHTTP,
+		SyntheticBody: &config.SyntheticBody{Size: 2048, ContentType: "application/x-test"},
+	}
+	client := p.GetClient(config.ProtocolHTTP)
+	p.processJob(context.Background(), Job{Target: target, Client: client})
+
+	target.Headers = map[string]string{"content-type": "text/plain"}
+	p.processJob(context.Background(), Job{Target: target, Client: client})
+
+	if len(got) != 2 || got[0] != "2048 application/x-test" || got[1] != "2048 text/plain" {
+		t.Fatalf("server saw %q", got)
+	}
+}
+
+func TestRunLongPoll_HoldsConcurrencyAndSplitsOutcomes(t *testing.T) {
+	var n int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every other poll gets an event after 30ms; the rest are held
+		// until the client gives up.
+		if atomic.AddInt64(&n, 1)%2 == 1 {
+			time.Sleep(30 * time.Millisecond)
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	p := newTestPool(t)
+	target := config.Target{
+		Name: "events", URL: srv.URL, Method: "GET", Protocol: config.ProtocolHTTP,
+		Timeout: 100 * time.Millisecond, LongPoll: &config.LongPoll{Concurrency: 2},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+
+	// Concurrency is measured on the client: the server's handler can
+	// still be unwinding a cancelled poll when the next one arrives.
+	var peak int64
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		for ctx.Err() == nil {
+			for _, st := range p.LongPollStats() {
+				if st.Open > peak {
+					peak = st.Open
+				}
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	p.RunLongPoll(ctx, target, p.GetClient(config.ProtocolHTTP))
+	<-sampled
+
+	stats := p.LongPollStats()
+	if len(stats) != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	st := stats[0]
+	if st.Responded == 0 || st.Expired == 0 || st.Errors != 0 {
+		t.Fatalf("responded=%d expired=%d errors=%d; want events and expiries, no errors", st.Responded, st.Expired, st.Errors)
+	}
+	if st.HoldP50Ms < 25 || st.HoldP50Ms > 80 {
+		t.Errorf("hold p50 = %vms, want about 30ms", st.HoldP50Ms)
+	}
+	if peak > 2 {
+		t.Errorf("%d polls open at once, want at most concurrency 2", peak)
+	}
+	if st.Open != 0 {
+		t.Errorf("open = %d after the run, want 0", st.Open)
+	}
+	if reqs, _ := p.Totals(); reqs != 0 {
+		t.Errorf("polls leaked into request totals: %d", reqs)
+	}
+}
+
+func TestCheckSaturation_WarnsAfterStreakAndGrows(t *testing.T) {
+	p := drainPool(t, 1)
+	p.cfg.OnSaturation = config.SaturationGrow
+	p.cfg.MaxPoolSize = 3
+	// SetPoolSize needs the run context Start would set; the added
+	// workers exit with it.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.runCtx = ctx
+
+	// Let the limiter fill its bucket: nobody takes tokens, as when
+	// every worker is busy.
+	p.SetRate(100)
+	time.Sleep(150 * time.Millisecond)
+
+	for i := 0; i < saturationAfter-1; i++ {
+		p.checkSaturation(1)
+	}
+	if s := p.Saturation(); s.Active || s.PoolSize != 1 {
+		t.Fatalf("saturated before the streak: %+v", s)
+	}
+	p.checkSaturation(1)
+	if s := p.Saturation(); !s.Active || s.PoolSize != 2 || s.Grown != 1 {
+		t.Fatalf("want saturated and doubled to 2, got %+v", s)
+	}
+	p.checkSaturation(1)
+	if s := p.Saturation(); s.PoolSize != 3 {
+		t.Fatalf("growth should stop at max_pool_size 3, got %+v", s)
+	}
+
+	// A second without a full queue ends it; the total stays.
+	p.checkSaturation(0)
+	if s := p.Saturation(); s.Active || s.Seconds != saturationAfter+1 {
+		t.Fatalf("want cleared with %d saturated seconds, got %+v", saturationAfter+1, s)
+	}
+}
+
+func TestTargetWindow_ResetsEachCall(t *testing.T) {
+	p := newTestPool(t)
+	p.SetRate(1000)
+	api := config.Target{Name: "api", Method: "GET", Protocol: config.ProtocolHTTP}
+	for _, resp := range []protocol.Response{
+		{StatusCode: 200, Duration: 10 * time.Millisecond},
+		{StatusCode: 200, Duration: 20 * time.Millisecond},
+		{StatusCode: 500, Duration: 30 * time.Millisecond},
+	} {
+		p.processJob(context.Background(), Job{Target: api, Client: cannedClient{resp}})
+	}
+
+	want := map[string]TargetHealth{"api": {Requests: 3, Errors: 1, MeanLatency: 20 * time.Millisecond}}
+	if got := p.TargetWindow(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("window = %+v, want %+v", got, want)
+	}
+	if got := p.TargetWindow(); len(got) != 0 {
+		t.Fatalf("second call should start from an empty window, got %+v", got)
+	}
+}
+
+func TestJitterTimeout_SpreadsWithinBand(t *testing.T) {
+	if got := jitterTimeout(time.Second, 0); got != time.Second {
+		t.Fatalf("no jitter changed the timeout to %v", got)
+	}
+	lo, hi := time.Hour, time.Duration(0)
+	for i := 0; i < 2000; i++ {
+		d := jitterTimeout(time.Second, 0.2)
+		if d < 800*time.Millisecond || d > 1200*time.Millisecond {
+			t.Fatalf("jittered timeout %v outside 1s±20%%", d)
+		}
+		lo, hi = min(lo, d), max(hi, d)
+	}
+	if hi-lo < 300*time.Millisecond {
+		t.Fatalf("timeouts barely spread: %v..%v", lo, hi)
+	}
+}
@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kar98k/pkg/protocol"
+)
+
+// LatencySample is one request as the samples sink exports it (#1260):
+// the raw measurement behind the percentiles, for analysis outside kar.
+type LatencySample struct {
+	Time     time.Time // when the request completed
+	Target   string
+	Spec     string
+	Status   int
+	Success  bool
+	Warmup   bool
+	Duration time.Duration
+	TTFB     time.Duration // 0 unless the target records it
+	BytesIn  int64
+	BytesOut int64
+}
+
+// sampleCapture keeps the samples sink's requests: a SampleRate share
+// of them, thinned by reservoir sampling to at most max, so a long run
+// still yields a uniform subset in bounded memory.
+type sampleCapture struct {
+	on   atomic.Bool // skips the lock while capture is off
+	mu   sync.Mutex
+	rate float64
+	max  int
+	seen int64 // requests offered to the reservoir
+	buf  []LatencySample
+	rng  *rand.Rand
+}
+
+// SetSampleCapture keeps rate (0-1] of the requests, at most
+// maxSamples of them, for Samples. Call before Start; maxSamples <= 0
+// leaves capture off.
+func (p *Pool) SetSampleCapture(rate float64, maxSamples int) {
+	if maxSamples <= 0 {
+		return
+	}
+	s := &p.samples
+	s.mu.Lock()
+	s.rate, s.max, s.seen = rate, maxSamples, 0
+	s.buf = make([]LatencySample, 0, min(maxSamples, 4096))
+	s.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	s.mu.Unlock()
+	s.on.Store(true)
+}
+
+// recordSample offers a completed request to the capture.
+func (p *Pool) recordSample(job Job, resp *protocol.Response, success, warmup bool, done time.Time) {
+	s := &p.samples
+	if !s.on.Load() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rate < 1 && s.rng.Float64() >= s.rate {
+		return
+	}
+	sample := LatencySample{
+		Time:     done,
+		Target:   job.Target.Name,
+		Spec:     job.Target.Spec,
+		Status:   resp.StatusCode,
+		Success:  success,
+		Warmup:   warmup,
+		Duration: resp.Duration,
+		TTFB:     resp.TTFB,
+		BytesIn:  resp.BytesRead,
+		BytesOut: resp.BytesWritten,
+	}
+	s.seen++
+	if len(s.buf) < s.max {
+		s.buf = append(s.buf, sample)
+		return
+	}
+	if i := s.rng.Int63n(s.seen); i < int64(s.max) {
+		s.buf[i] = sample
+	}
+}
+
+// Samples returns the captured requests in completion order, and how
+// many passed sample_rate: more than were kept once the reservoir
+// filled.
+func (p *Pool) Samples() (samples []LatencySample, seen int64) {
+	s := &p.samples
+	s.mu.Lock()
+	out := append([]LatencySample(nil), s.buf...)
+	seen = s.seen
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, seen
+}
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/pkg/protocol"
+)
+
+func TestSamples_ReservoirKeepsUniformSubset(t *testing.T) {
+	p := newTestPool(t)
+	job := Job{Target: config.Target{Name: "api"}}
+	start := time.Now()
+	p.recordSample(job, &protocol.Response{}, true, false, start) // capture off: dropped
+	if got, seen := p.Samples(); len(got) != 0 || seen != 0 {
+		t.Fatalf("samples while off = %d of %d", len(got), seen)
+	}
+
+	p.SetSampleCapture(1, 100)
+	for i := range 10000 {
+		p.recordSample(job, &protocol.Response{StatusCode: 200, Duration: time.Duration(i)}, true, false, start.Add(time.Duration(i)*time.Millisecond))
+	}
+	got, seen := p.Samples()
+	if len(got) != 100 || seen != 10000 {
+		t.Fatalf("kept %d of %d, want 100 of 10000", len(got), seen)
+	}
+	var late int
+	for i, s := range got {
+		if i > 0 && s.Time.Before(got[i-1].Time) {
+			t.Fatal("samples not in completion order")
+		}
+		if s.Duration >= 5000 {
+			late++
+		}
+	}
+	// A uniform subset has about half its samples from each half of the
+	// run; keeping only the first 100 would give none.
+	if late < 25 || late > 75 {
+		t.Fatalf("%d of 100 samples from the second half, want about 50", late)
+	}
+}
+
+func TestSamples_RateThinsRequests(t *testing.T) {
+	p := newTestPool(t)
+	p.SetSampleCapture(0.1, config.DefaultMaxSamples)
+	for range 10000 {
+		p.recordSample(Job{}, &protocol.Response{}, true, false, time.Now())
+	}
+	if got, seen := p.Samples(); len(got) != int(seen) || seen < 800 || seen > 1200 {
+		t.Fatalf("kept %d of %d at rate 0.1, want about 1000", len(got), seen)
+	}
+}
@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/kar98k/internal/config"
+)
+
+// saturationAfter is how many consecutive saturated seconds it takes
+// to call the generator saturated, so one slow second isn't reported.
+const saturationAfter = 10
+
+// Saturation says whether the pool can feed the requested TPS (#1222).
+// The controller offers jobs far faster than any rate, so a full queue
+// alone is normal; the pool is saturated when the queue is full and
+// the rate limiter's tokens go unused, i.e. every worker is busy.
+type Saturation struct {
+	// Active is set while the pool has been saturated for at least
+	// saturationAfter seconds in a row.
+	Active bool `json:"active"`
+	// Seconds is the run's total saturated time, in seconds.
+	Seconds int64 `json:"seconds"`
+	// Grown counts the pool doublings on_saturation: grow made.
+	Grown    int `json:"grown,omitempty"`
+	PoolSize int `json:"pool_size"`
+}
+
+// saturation is the pool's saturation tracker, fed by measureTPS.
+type saturation struct {
+	mu       sync.Mutex
+	streak   int
+	total    int64
+	active   bool
+	grown    int
+	lastWarn time.Time
+}
+
+// checkSaturation judges the last second. drops is how many Submits
+// found the queue full.
+func (p *Pool) checkSaturation(drops int64) {
+	busy := drops > 0 && !p.paused.Load() && p.limiter.Tokens() >= float64(p.limiter.Burst())
+
+	s := &p.sat
+	s.mu.Lock()
+	if !busy {
+		s.streak = 0
+		s.active = false
+		s.mu.Unlock()
+		p.metrics.SetGeneratorSaturated(false)
+		return
+	}
+	s.streak++
+	s.total++
+	s.active = s.streak >= saturationAfter
+	active, streak := s.active, s.streak
+	warn := active && time.Since(s.lastWarn) >= dropWarnCooldown
+	if warn {
+		s.lastWarn = time.Now()
+	}
+	s.mu.Unlock()
+	if !active {
+		return
+	}
+
+	p.metrics.SetGeneratorSaturated(true)
+	if warn {
+		log.Printf("[worker] WARNING: generator saturated: all %d workers busy and the queue full for %ds; "+
+			"kar can't feed the requested TPS. Raise worker.pool_size (kar scale) or set worker.on_saturation: grow",
+			p.PoolSize(), streak)
+	}
+	if p.cfg.OnSaturation == config.SaturationGrow {
+		p.growSaturated()
+	}
+}
+
+// growSaturated doubles the pool, up to worker.max_pool_size. Resizes
+// keep to min_resize_interval, so a pool that stays saturated grows
+// one step per interval.
+func (p *Pool) growSaturated() {
+	size, ceiling := p.PoolSize(), p.cfg.PoolCeiling()
+	if size >= ceiling {
+		return
+	}
+	next := min(2*size, ceiling)
+	if err := p.SetPoolSize(next); err != nil {
+		if !errors.Is(err, ErrResizeTooSoon) {
+			log.Printf("[worker] on_saturation: grow: %v", err)
+		}
+		return
+	}
+	p.sat.mu.Lock()
+	p.sat.grown++
+	p.sat.mu.Unlock()
+	log.Printf("[worker] on_saturation: grow: pool %d -> %d workers (max %d)", size, next, ceiling)
+}
+
+// Saturation returns the pool's saturation state.
+func (p *Pool) Saturation() Saturation {
+	p.sat.mu.Lock()
+	defer p.sat.mu.Unlock()
+	return Saturation{
+		Active:   p.sat.active,
+		Seconds:  p.sat.total,
+		Grown:    p.sat.grown,
+		PoolSize: p.PoolSize(),
+	}
+}
@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/kar98k/internal/hdrbounds"
+)
+
+// segment is one fixed report window: its own raw histogram plus
+// request/error counts (#1189). Guarded by Pool.latMu.
+type segment struct {
+	start    time.Time
+	hist     *hdrhistogram.Histogram
+	requests int64
+	errors   int64
+}
+
+// Segment is the summary of one report window. Latencies are raw, in
+// milliseconds; ErrorRate is a percentage.
+type Segment struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Requests  int64     `json:"requests"`
+	Errors    int64     `json:"errors"`
+	ErrorRate float64   `json:"error_rate"`
+	P50Ms     float64   `json:"p50_ms"`
+	P95Ms     float64   `json:"p95_ms"`
+	P99Ms     float64   `json:"p99_ms"`
+}
+
+// SetSegmentWindow enables per-window aggregation with the given
+// width. Windows are anchored at the first recorded request, so idle
+// time before the trigger doesn't produce empty leading segments.
+// A zero window disables segmentation.
+func (p *Pool) SetSegmentWindow(d time.Duration) {
+	p.latMu.Lock()
+	p.segWindow = d
+	p.latMu.Unlock()
+}
+
+// recordSegment adds one completed request to the window containing
+// now, opening new windows as time passes, to the current scenario
+// phase and to the report timeline. Windows with no traffic (e.g. while paused) are
+// skipped rather than stored empty.
+func (p *Pool) recordSegment(now time.Time, observed time.Duration, success bool) {
+	micros := observed.Microseconds()
+	if micros < hdrbounds.Min {
+		micros = hdrbounds.Min
+	} else if micros > hdrbounds.Max {
+		micros = hdrbounds.Max
+	}
+
+	p.latMu.Lock()
+	defer p.latMu.Unlock()
+	if p.latWindow != nil {
+		_ = p.latWindow.RecordValue(micros)
+	}
+	p.recordStage(now, micros, success)
+	p.recordTimeSlot(now, micros, success)
+	if p.segWindow <= 0 {
+		return
+	}
+	if p.segOrigin.IsZero() {
+		p.segOrigin = now
+	}
+	start := p.segOrigin.Add(now.Sub(p.segOrigin) / p.segWindow * p.segWindow)
+	var seg *segment
+	if n := len(p.segments); n > 0 && p.segments[n-1].start.Equal(start) {
+		seg = p.segments[n-1]
+	} else {
+		seg = &segment{
+			start: start,
+			hist:  hdrhistogram.New(hdrbounds.Min, hdrbounds.Max, int(hdrbounds.SigFigs)),
+		}
+		p.segments = append(p.segments, seg)
+	}
+	_ = seg.hist.RecordValue(micros)
+	seg.requests++
+	if !success {
+		seg.errors++
+	}
+}
+
+// Segments returns one summary per report window that saw traffic,
+// oldest first. The last window may still be filling.
+func (p *Pool) Segments() []Segment {
+	p.latMu.Lock()
+	defer p.latMu.Unlock()
+	out := make([]Segment, 0, len(p.segments))
+	for _, s := range p.segments {
+		seg := Segment{
+			Start:    s.start,
+			End:      s.start.Add(p.segWindow),
+			Requests: s.requests,
+			Errors:   s.errors,
+			P50Ms:    float64(s.hist.ValueAtQuantile(50)) / 1000.0,
+			P95Ms:    float64(s.hist.ValueAtQuantile(95)) / 1000.0,
+			P99Ms:    float64(s.hist.ValueAtQuantile(99)) / 1000.0,
+		}
+		if s.requests > 0 {
+			seg.ErrorRate = float64(s.errors) / float64(s.requests) * 100
+		}
+		out = append(out, seg)
+	}
+	return out
+}
+
+// EnableLatencyWindow starts recording every request into a histogram
+// that LatencyWindow drains, for cliff detection (#1252).
+func (p *Pool) EnableLatencyWindow() {
+	p.latMu.Lock()
+	defer p.latMu.Unlock()
+	if p.latWindow == nil {
+		p.latWindow = hdrhistogram.New(hdrbounds.Min, hdrbounds.Max, int(hdrbounds.SigFigs))
+	}
+}
+
+// LatencyWindow returns the raw P95 in milliseconds and the request
+// count since the previous call, and starts a new window. Both are 0
+// before EnableLatencyWindow or when nothing completed.
+func (p *Pool) LatencyWindow() (p95Ms float64, requests int64) {
+	p.latMu.Lock()
+	defer p.latMu.Unlock()
+	if p.latWindow == nil || p.latWindow.TotalCount() == 0 {
+		return 0, 0
+	}
+	p95Ms = float64(p.latWindow.ValueAtQuantile(95)) / 1000.0
+	requests = p.latWindow.TotalCount()
+	p.latWindow.Reset()
+	return p95Ms, requests
+}
@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSegments_FixedWindows(t *testing.T) {
+	p := newTestPool(t)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	p.recordSegment(base, 10*time.Millisecond, true) // disabled: dropped
+	p.SetSegmentWindow(time.Minute)
+
+	p.recordSegment(base, 10*time.Millisecond, true)
+	p.recordSegment(base.Add(30*time.Second), 20*time.Millisecond, false)
+	// Nothing in minute two; minute three degrades.
+	p.recordSegment(base.Add(2*time.Minute+time.Second), 200*time.Millisecond, true)
+
+	segs := p.Segments()
+	if len(segs) != 2 {
+		t.Fatalf("got %d segments, want 2 (empty window skipped): %+v", len(segs), segs)
+	}
+	if !segs[0].Start.Equal(base) || !segs[0].End.Equal(base.Add(time.Minute)) {
+		t.Fatalf("first window = %v..%v", segs[0].Start, segs[0].End)
+	}
+	if segs[0].Requests != 2 || segs[0].Errors != 1 || segs[0].ErrorRate != 50 {
+		t.Fatalf("first window counts = %+v", segs[0])
+	}
+	if !segs[1].Start.Equal(base.Add(2 * time.Minute)) {
+		t.Fatalf("second window starts %v, want aligned to origin", segs[1].Start)
+	}
+	if segs[1].P99Ms < 190 || segs[0].P99Ms > 25 {
+		t.Fatalf("per-window percentiles not separated: %+v", segs)
+	}
+}
+
+func TestStages_SplitByPhase(t *testing.T) {
+	p := newTestPool(t)
+	base := time.Now()
+
+	p.recordSegment(base, 10*time.Millisecond, true) // before any phase: dropped
+	p.SetPhase("warmup")
+	p.recordSegment(base, 10*time.Millisecond, true)
+	p.recordSegment(base, 12*time.Millisecond, false)
+	p.SetPhase("steady")
+	p.recordSegment(base.Add(time.Second), 200*time.Millisecond, true)
+
+	st := p.Stages()
+	if len(st) != 2 || st[0].Name != "warmup" || st[1].Name != "steady" {
+		t.Fatalf("stages = %+v, want warmup then steady", st)
+	}
+	if st[0].Requests != 2 || st[0].Errors != 1 || st[0].ErrorRate != 50 {
+		t.Fatalf("warmup counts = %+v", st[0])
+	}
+	if !st[0].End.Equal(st[1].Start) {
+		t.Fatalf("warmup ends %v, want where steady starts (%v)", st[0].End, st[1].Start)
+	}
+	if st[1].Requests != 1 || st[1].P99Ms < 190 || st[0].P99Ms > 15 {
+		t.Fatalf("per-stage percentiles not separated: %+v", st)
+	}
+}
+
+func TestTimeSlots_KeepIdleSlots(t *testing.T) {
+	p := newTestPool(t)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	p.recordSegment(base, 10*time.Millisecond, true) // disabled: dropped
+	p.SetSlotInterval(5 * time.Second)
+
+	p.recordSegment(base, 10*time.Millisecond, true)
+	p.recordSegment(base.Add(time.Second), 30*time.Millisecond, false)
+	// Nothing from 5s to 10s.
+	p.recordSegment(base.Add(12*time.Second), 100*time.Millisecond, true)
+
+	slots := p.TimeSlots()
+	if len(slots) != 3 {
+		t.Fatalf("got %d slots, want 3 (idle slot kept): %+v", len(slots), slots)
+	}
+	if slots[0].Requests != 2 || slots[0].Errors != 1 || slots[0].AvgLatencyMs != 20 {
+		t.Fatalf("first slot = %+v", slots[0])
+	}
+	if slots[1].Requests != 0 || !slots[1].Start.Equal(base.Add(5*time.Second)) {
+		t.Fatalf("idle slot = %+v", slots[1])
+	}
+	if slots[2].Requests != 1 || slots[2].AvgLatencyMs != 100 {
+		t.Fatalf("last slot = %+v", slots[2])
+	}
+}
+
+func TestLatencyCounts_Buckets(t *testing.T) {
+	p := newTestPool(t)
+	for _, d := range []time.Duration{2 * time.Millisecond, 15 * time.Millisecond, 16 * time.Millisecond, 400 * time.Millisecond} {
+		p.recordLatency(d)
+	}
+	got := p.LatencyCounts([]float64{10, 25, math.Inf(1)})
+	if got[0] != 1 || got[1] != 2 || got[2] != 1 {
+		t.Fatalf("counts = %v, want [1 2 1]", got)
+	}
+	if lo, hi := p.LatencyRange(); lo < 1.9 || lo > 2.1 || hi < 399 || hi > 401 {
+		t.Fatalf("range = %.2f..%.2f, want 2..400", lo, hi)
+	}
+}
@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/internal/hdrbounds"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// SizeLatencyStat is one target's latency by request or response size
+// (#1258). Correlation is Pearson's r between size and latency over
+// every sample: near 1, bigger is slower; near 0, size doesn't matter
+// (or matters in a way a straight line misses: read the buckets).
+type SizeLatencyStat struct {
+	Target      string       `json:"target"`
+	By          string       `json:"by"`
+	Samples     int64        `json:"samples"`
+	Correlation float64      `json:"correlation"`
+	Buckets     []SizeBucket `json:"buckets"`
+}
+
+// SizeBucket is the requests up to Max bytes (and above the previous
+// bucket's); the last bucket has no Max.
+type SizeBucket struct {
+	Label    string  `json:"label"`
+	Max      int64   `json:"max,omitempty"`
+	Requests int64   `json:"requests"`
+	P50Ms    float64 `json:"p50_ms"`
+	P95Ms    float64 `json:"p95_ms"`
+	P99Ms    float64 `json:"p99_ms"`
+}
+
+// sizeTarget is one target's bucket histograms and the running sums
+// the correlation is computed from.
+type sizeTarget struct {
+	hists                    []*hdrhistogram.Histogram
+	n, sx, sy, sxx, syy, sxy float64
+}
+
+// sizeLatency holds report.size_latency's figures, under its own lock
+// like cacheStats. bounds is nil while it's off.
+type sizeLatency struct {
+	mu      sync.Mutex
+	by      string
+	bounds  []int64
+	labels  []string
+	targets map[string]*sizeTarget
+}
+
+// SetSizeLatency turns the size/latency analysis on per cfg. Call
+// before Start; cfg is validated, so unparseable buckets leave it off.
+func (p *Pool) SetSizeLatency(cfg config.SizeLatency) {
+	if !cfg.Enabled {
+		return
+	}
+	bounds, labels, err := cfg.Bounds()
+	if err != nil {
+		return
+	}
+	s := &p.sizes
+	s.mu.Lock()
+	s.by, s.bounds, s.labels = cfg.Measure(), bounds, labels
+	s.targets = make(map[string]*sizeTarget)
+	s.mu.Unlock()
+}
+
+// recordSize files a successful request under its size bucket.
+func (p *Pool) recordSize(target string, resp *protocol.Response) {
+	s := &p.sizes
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bounds == nil {
+		return
+	}
+	var size int64
+	switch s.by {
+	case config.SizeByRequest:
+		size = resp.BytesWritten
+	case config.SizeByTotal:
+		size = resp.BytesWritten + resp.BytesRead
+	default:
+		size = resp.BytesRead
+	}
+	t, ok := s.targets[target]
+	if !ok {
+		t = &sizeTarget{hists: make([]*hdrhistogram.Histogram, len(s.bounds)+1)}
+		s.targets[target] = t
+	}
+	i := sort.Search(len(s.bounds), func(i int) bool { return size <= s.bounds[i] })
+	if t.hists[i] == nil {
+		t.hists[i] = hdrhistogram.New(hdrbounds.Min, hdrbounds.Max, int(hdrbounds.SigFigs))
+	}
+	micros := min(max(resp.Duration.Microseconds(), hdrbounds.Min), hdrbounds.Max)
+	_ = t.hists[i].RecordValue(micros)
+
+	x, y := float64(size), float64(resp.Duration)/1e6
+	t.n++
+	t.sx += x
+	t.sy += y
+	t.sxx += x * x
+	t.syy += y * y
+	t.sxy += x * y
+}
+
+// SizeLatency returns each target's breakdown, sorted by target. Nil
+// unless report.size_latency is on. Empty buckets are left out.
+func (p *Pool) SizeLatency() []SizeLatencyStat {
+	s := &p.sizes
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bounds == nil {
+		return nil
+	}
+	out := make([]SizeLatencyStat, 0, len(s.targets))
+	for name, t := range s.targets {
+		st := SizeLatencyStat{Target: name, By: s.by, Samples: int64(t.n), Correlation: t.correlation()}
+		for i, h := range t.hists {
+			if h == nil {
+				continue
+			}
+			b := SizeBucket{Requests: h.TotalCount()}
+			switch {
+			case i == len(s.bounds):
+				b.Label = ">" + s.labels[i-1]
+			case i == 0:
+				b.Label, b.Max = "≤"+s.labels[0], s.bounds[0]
+			default:
+				b.Label, b.Max = s.labels[i-1]+"–"+s.labels[i], s.bounds[i]
+			}
+			b.P50Ms, b.P95Ms, b.P99Ms = quantilesMs(h)
+			st.Buckets = append(st.Buckets, b)
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Target < out[j].Target })
+	return out
+}
+
+// correlation is Pearson's r of the samples, 0 when either size or
+// latency never varied.
+func (t *sizeTarget) correlation() float64 {
+	if t.n < 2 {
+		return 0
+	}
+	cov := t.n*t.sxy - t.sx*t.sy
+	vx := t.n*t.sxx - t.sx*t.sx
+	vy := t.n*t.syy - t.sy*t.sy
+	if vx <= 0 || vy <= 0 {
+		return 0
+	}
+	return math.Max(-1, math.Min(1, cov/math.Sqrt(vx*vy)))
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/pkg/protocol"
+)
+
+func TestSizeLatency_BucketsAndCorrelation(t *testing.T) {
+	p := newTestPool(t)
+	p.recordSize("files", &protocol.Response{BytesRead: 10, Duration: time.Millisecond})
+	if p.SizeLatency() != nil {
+		t.Fatal("SizeLatency should be nil while the analysis is off")
+	}
+	p.SetSizeLatency(config.SizeLatency{Enabled: true, Buckets: []string{"1KB", "1MB"}})
+
+	// Latency grows with size: 1ms per 100KB, plus 1ms.
+	for _, kb := range []int64{0, 100, 200, 500, 900, 2000} {
+		p.recordSize("files", &protocol.Response{
+			BytesRead: kb * 1024,
+			Duration:  time.Millisecond + time.Duration(kb)*10*time.Microsecond,
+		})
+	}
+
+	got := p.SizeLatency()
+	if len(got) != 1 || got[0].Target != "files" || got[0].By != config.SizeByResponse || got[0].Samples != 6 {
+		t.Fatalf("size latency = %+v", got)
+	}
+	if math.Abs(got[0].Correlation-1) > 1e-9 {
+		t.Fatalf("correlation = %v, want 1", got[0].Correlation)
+	}
+	b := got[0].Buckets
+	if len(b) != 3 || b[0].Label != "≤1KB" || b[0].Requests != 1 ||
+		b[1].Label != "1KB–1MB" || b[1].Requests != 4 || b[1].Max != 1<<20 ||
+		b[2].Label != ">1MB" || b[2].Requests != 1 || b[2].Max != 0 {
+		t.Fatalf("buckets = %+v", b)
+	}
+	if b[0].P50Ms >= b[1].P50Ms || b[1].P50Ms >= b[2].P50Ms {
+		t.Fatalf("bucket P50s not increasing: %+v", b)
+	}
+}
+
+func TestSizeLatency_ConstantSizeHasNoCorrelation(t *testing.T) {
+	p := newTestPool(t)
+	p.SetSizeLatency(config.SizeLatency{Enabled: true, By: config.SizeByRequest})
+	for i := 1; i <= 3; i++ {
+		p.recordSize("api", &protocol.Response{BytesWritten: 512, BytesRead: int64(i) << 20, Duration: time.Duration(i) * time.Millisecond})
+	}
+	got := p.SizeLatency()
+	if len(got) != 1 || got[0].Correlation != 0 || len(got[0].Buckets) != 1 || got[0].Buckets[0].Requests != 3 {
+		t.Fatalf("size latency = %+v, want one bucket and no correlation", got)
+	}
+}
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/kar98k/internal/hdrbounds"
+)
+
+// stage collects one scenario phase's requests (#1262): a report
+// segment bounded by phase changes instead of a fixed window. Guarded
+// by Pool.latMu.
+type stage struct {
+	name     string
+	start    time.Time
+	last     time.Time // latest request recorded
+	hist     *hdrhistogram.Histogram
+	requests int64
+	errors   int64
+}
+
+// StageStat is the summary of one scenario phase. Latencies are raw,
+// in milliseconds; ErrorRate is a percentage.
+type StageStat struct {
+	Name      string    `json:"name"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Requests  int64     `json:"requests"`
+	Errors    int64     `json:"errors"`
+	ErrorRate float64   `json:"error_rate"`
+	P50Ms     float64   `json:"p50_ms"`
+	P95Ms     float64   `json:"p95_ms"`
+	P99Ms     float64   `json:"p99_ms"`
+}
+
+// startStage opens the stats of phase name. Caller holds latMu.
+func (p *Pool) startStage(name string, now time.Time) {
+	if name == "" {
+		return
+	}
+	p.stages = append(p.stages, &stage{
+		name:  name,
+		start: now,
+		hist:  hdrhistogram.New(hdrbounds.Min, hdrbounds.Max, int(hdrbounds.SigFigs)),
+	})
+}
+
+// recordStage adds one completed request to the current phase, if
+// any. Caller holds latMu.
+func (p *Pool) recordStage(now time.Time, micros int64, success bool) {
+	n := len(p.stages)
+	if n == 0 {
+		return
+	}
+	s := p.stages[n-1]
+	_ = s.hist.RecordValue(micros)
+	s.requests++
+	if !success {
+		s.errors++
+	}
+	s.last = now
+}
+
+// Stages returns one summary per scenario phase entered, in order. A
+// phase ends where the next starts; the last at its latest request.
+func (p *Pool) Stages() []StageStat {
+	p.latMu.Lock()
+	defer p.latMu.Unlock()
+	out := make([]StageStat, len(p.stages))
+	for i, s := range p.stages {
+		st := StageStat{
+			Name:     s.name,
+			Start:    s.start,
+			End:      s.last,
+			Requests: s.requests,
+			Errors:   s.errors,
+			P50Ms:    float64(s.hist.ValueAtQuantile(50)) / 1000.0,
+			P95Ms:    float64(s.hist.ValueAtQuantile(95)) / 1000.0,
+			P99Ms:    float64(s.hist.ValueAtQuantile(99)) / 1000.0,
+		}
+		if i+1 < len(p.stages) {
+			st.End = p.stages[i+1].start
+		}
+		if s.requests > 0 {
+			st.ErrorRate = float64(s.errors) / float64(s.requests) * 100
+		}
+		out[i] = st
+	}
+	return out
+}
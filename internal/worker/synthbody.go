@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// bodyGen draws a target's synthetic_body payloads (#1217). Every body
+// is a prefix of one random buffer that only grows, so a request costs
+// no allocation or random fill once the buffer covers its size. Bodies
+// are handed out read-only; growing allocates a new array, leaving
+// slices already in flight untouched.
+type bodyGen struct {
+	cfg config.SyntheticBody
+
+	mu  sync.Mutex
+	rng *rand.Rand
+	buf []byte
+}
+
+func newBodyGen(cfg config.SyntheticBody, seed int64) *bodyGen {
+	return &bodyGen{cfg: cfg, rng: rand.New(rand.NewSource(seed))}
+}
+
+// next returns the next body.
+func (g *bodyGen) next() []byte {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n := g.size()
+	if n > len(g.buf) {
+		buf := make([]byte, n)
+		copy(buf, g.buf)
+		g.rng.Read(buf[len(g.buf):])
+		g.buf = buf
+	}
+	return g.buf[:n:n]
+}
+
+// size draws a body size from the distribution. Caller holds mu.
+func (g *bodyGen) size() int {
+	c := g.cfg
+	switch c.Distribution {
+	case config.SizeUniform:
+		if c.MaxSize <= c.MinSize {
+			return c.MinSize
+		}
+		return c.MinSize + g.rng.Intn(c.MaxSize-c.MinSize+1)
+	case config.SizeLognormal:
+		sigma := c.Sigma
+		if sigma == 0 {
+			sigma = 1
+		}
+		max := c.MaxSize
+		if max <= 0 {
+			max = config.DefaultSyntheticBodyMax
+		}
+		n := math.Round(float64(c.Size) * math.Exp(sigma*g.rng.NormFloat64()))
+		return int(math.Max(float64(c.MinSize), math.Min(n, float64(max))))
+	default:
+		return c.Size
+	}
+}
+
+// syntheticBody gives req the next body of the target's synthetic_body
+// and its content type, unless the target's headers name one.
+func (p *Pool) syntheticBody(name string, sb *config.SyntheticBody, req *protocol.Request) {
+	g, ok := p.bodies.Load(name)
+	if !ok {
+		g, _ = p.bodies.LoadOrStore(name, newBodyGen(*sb, time.Now().UnixNano()))
+	}
+	req.Body = g.(*bodyGen).next()
+
+	for k := range req.Headers {
+		if strings.EqualFold(k, "Content-Type") {
+			return
+		}
+	}
+	ct := sb.ContentType
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	headers := make(map[string]string, len(req.Headers)+1)
+	for k, v := range req.Headers {
+		headers[k] = v
+	}
+	headers["Content-Type"] = ct
+	req.Headers = headers
+}
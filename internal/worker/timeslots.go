@@ -0,0 +1,141 @@
+package worker
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// timeSlot is one report timeline slot (#1260). Guarded by Pool.latMu.
+type timeSlot struct {
+	requests int64
+	errors   int64
+	micros   int64 // summed latency
+}
+
+// TimeSlot is one timeline slot of the report: what completed between
+// Start and Start plus the slot interval.
+type TimeSlot struct {
+	Start        time.Time `json:"start"`
+	Requests     int64     `json:"requests"`
+	Errors       int64     `json:"errors"`
+	AvgLatencyMs float64   `json:"avg_latency_ms"`
+}
+
+// SetSlotInterval enables the report timeline with the given slot
+// width. Slots are anchored at the first recorded request, like
+// segments; unlike segments, idle slots are kept so the timeline has
+// no gaps. A zero interval disables it.
+func (p *Pool) SetSlotInterval(d time.Duration) {
+	p.latMu.Lock()
+	p.slotWidth = d
+	p.latMu.Unlock()
+}
+
+// recordTimeSlot adds one completed request to the slot containing
+// now. Caller holds latMu.
+func (p *Pool) recordTimeSlot(now time.Time, micros int64, success bool) {
+	if p.slotWidth <= 0 {
+		return
+	}
+	if p.slotOrigin.IsZero() {
+		p.slotOrigin = now
+	}
+	i := max(int(now.Sub(p.slotOrigin)/p.slotWidth), 0)
+	for len(p.slots) <= i {
+		p.slots = append(p.slots, timeSlot{})
+	}
+	s := &p.slots[i]
+	s.requests++
+	s.micros += micros
+	if !success {
+		s.errors++
+	}
+}
+
+// TimeSlots returns the report timeline, oldest slot first. The last
+// slot may still be filling.
+func (p *Pool) TimeSlots() []TimeSlot {
+	p.latMu.Lock()
+	defer p.latMu.Unlock()
+	out := make([]TimeSlot, len(p.slots))
+	for i, s := range p.slots {
+		out[i] = TimeSlot{
+			Start:    p.slotOrigin.Add(time.Duration(i) * p.slotWidth),
+			Requests: s.requests,
+			Errors:   s.errors,
+		}
+		if s.requests > 0 {
+			out[i].AvgLatencyMs = float64(s.micros) / float64(s.requests) / 1000.0
+		}
+	}
+	return out
+}
+
+// SlotInterval returns the timeline's slot width, 0 when disabled.
+func (p *Pool) SlotInterval() time.Duration {
+	p.latMu.Lock()
+	defer p.latMu.Unlock()
+	return p.slotWidth
+}
+
+// statusCounts maps a response status code to *int64 (#1260).
+type statusCounts struct{ m sync.Map }
+
+// recordStatus counts one response with status code code.
+func (p *Pool) recordStatus(code int) {
+	v, ok := p.statuses.m.Load(code)
+	if !ok {
+		v, _ = p.statuses.m.LoadOrStore(code, new(int64))
+	}
+	atomic.AddInt64(v.(*int64), 1)
+}
+
+// StatusCodes returns how many responses came back with each HTTP
+// status code. Requests that got no response, and gRPC targets, whose
+// codes aren't HTTP statuses, aren't counted.
+func (p *Pool) StatusCodes() map[int]int64 {
+	out := make(map[int]int64)
+	p.statuses.m.Range(func(k, v any) bool {
+		out[k.(int)] = atomic.LoadInt64(v.(*int64))
+		return true
+	})
+	return out
+}
+
+// LatencyRange returns the fastest and slowest request in the primary
+// latency figures, in milliseconds; 0, 0 before any.
+func (p *Pool) LatencyRange() (minMs, maxMs float64) {
+	p.latMu.Lock()
+	defer p.latMu.Unlock()
+	if p.latRaw.TotalCount() == 0 {
+		return 0, 0
+	}
+	return float64(p.latRaw.Min()) / 1000.0, float64(p.latRaw.Max()) / 1000.0
+}
+
+// LatencyCounts buckets the primary latency figures by upperMs, a
+// sorted list of exclusive upper bounds in milliseconds: counts[i] is
+// the requests below upperMs[i] and at or above upperMs[i-1].
+// Requests at or above the last bound aren't counted.
+func (p *Pool) LatencyCounts(upperMs []float64) []int64 {
+	counts := make([]int64, len(upperMs))
+	p.latMu.Lock()
+	defer p.latMu.Unlock()
+	for _, bar := range p.latRaw.Distribution() {
+		if bar.Count == 0 {
+			continue
+		}
+		ms := float64(bar.From) / 1000.0
+		if i := sort.SearchFloat64s(upperMs, ms); i < len(upperMs) {
+			if upperMs[i] == ms {
+				i++
+			}
+			if i < len(upperMs) {
+				counts[i] += bar.Count
+			}
+		}
+	}
+	return counts
+}
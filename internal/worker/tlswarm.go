@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kar98k/internal/config"
+	"github.com/kar98k/pkg/protocol"
+)
+
+// warmTimeout bounds each host's DNS lookup and warmup request.
+const warmTimeout = 5 * time.Second
+
+// TLSStat is the TLS handshakes of every kar client (#1257): full ones,
+// and ones that resumed a cached session. Warmed is the hosts
+// worker.tls.warmup prepared before the trigger; their handshakes are
+// counted too.
+type TLSStat struct {
+	Full    int64 `json:"full"`
+	Resumed int64 `json:"resumed"`
+	Warmed  int64 `json:"warmed,omitempty"`
+}
+
+// TLSHandshakes returns the handshakes of every client that counts
+// them, or nil before the first one.
+func (p *Pool) TLSHandshakes() *TLSStat {
+	st := TLSStat{Warmed: atomic.LoadInt64(&p.warmed)}
+	add := func(c protocol.Client) {
+		if r, ok := c.(protocol.TLSReporter); ok {
+			s := r.TLSStats()
+			st.Full += s.Full
+			st.Resumed += s.Resumed
+		}
+	}
+	for _, c := range p.clients {
+		add(c)
+	}
+	p.targetClients.Range(func(_, c any) bool {
+		add(c.(protocol.Client))
+		return true
+	})
+	if st.Full+st.Resumed == 0 {
+		return nil
+	}
+	return &st
+}
+
+// Warm prepares every target's host before the trigger
+// (worker.tls.warmup): it resolves the host, then sends one HEAD
+// request to its root through the client the target will use, so a
+// connection is pooled and, with a session cache, a TLS session is
+// ready to resume. Hosts are warmed in parallel; one that fails is
+// logged and left for the run to report.
+func (p *Pool) Warm(ctx context.Context, targets []config.Target) {
+	type key struct {
+		client protocol.Client
+		root   string
+	}
+	seen := make(map[key]bool)
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		u, err := url.Parse(t.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		client := p.ClientFor(t)
+		k := key{client, u.Scheme + "://" + u.Host + "/"}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, warmTimeout)
+			defer cancel()
+			if net.ParseIP(u.Hostname()) == nil {
+				if _, err := net.DefaultResolver.LookupHost(ctx, u.Hostname()); err != nil {
+					log.Printf("[worker] warmup: resolving %s: %v", u.Hostname(), err)
+					return
+				}
+			}
+			w, ok := client.(protocol.Warmer)
+			if !ok || (u.Scheme != "http" && u.Scheme != "https") {
+				return
+			}
+			if err := w.Warm(ctx, k.root); err != nil {
+				log.Printf("[worker] warmup: %s: %v", k.root, err)
+				return
+			}
+			atomic.AddInt64(&p.warmed, 1)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"sync/atomic"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/kar98k/internal/hdrbounds"
+)
+
+// WarmupStats describes the requests kept out of the primary
+// percentiles while the target warmed up (#1207).
+type WarmupStats struct {
+	// Active is set until both warmup conditions are met.
+	Active   bool    `json:"active"`
+	Excluded int64   `json:"excluded"`
+	P50Ms    float64 `json:"p50_ms"`
+	P99Ms    float64 `json:"p99_ms"`
+}
+
+// warmup tracks whether completed requests still belong to the warmup.
+// Warmup ends once `requests` requests have completed and `duration`
+// has passed since the first one; either may be zero. done makes the
+// steady state a single atomic load.
+type warmup struct {
+	requests int64
+	duration time.Duration
+
+	done      atomic.Bool
+	completed atomic.Int64
+	start     atomic.Int64 // unix nanos of the first completion
+	excluded  atomic.Int64
+	hist      *hdrhistogram.Histogram // guarded by Pool.latMu
+}
+
+// SetWarmup excludes the first requests completed requests, and those
+// completed within duration of the first, from the primary latency
+// figures. Call before Start; zero for both disables warmup.
+func (p *Pool) SetWarmup(requests int, duration time.Duration) {
+	w := &p.warm
+	w.requests = int64(requests)
+	w.duration = duration
+	w.hist = hdrhistogram.New(hdrbounds.Min, hdrbounds.Max, int(hdrbounds.SigFigs))
+	w.done.Store(requests <= 0 && duration <= 0)
+}
+
+// inWarmup counts a request completed at now and reports whether it
+// falls within the warmup.
+func (p *Pool) inWarmup(now time.Time) bool {
+	w := &p.warm
+	if w.done.Load() {
+		return false
+	}
+	n := w.completed.Add(1)
+	w.start.CompareAndSwap(0, now.UnixNano())
+	if n <= w.requests || now.Sub(time.Unix(0, w.start.Load())) < w.duration {
+		w.excluded.Add(1)
+		return true
+	}
+	w.done.Store(true)
+	return false
+}
+
+// recordWarmup keeps a warmup request's latency apart so the report
+// can still show what warmup looked like.
+func (p *Pool) recordWarmup(observed time.Duration) {
+	micros := observed.Microseconds()
+	if micros < hdrbounds.Min {
+		micros = hdrbounds.Min
+	} else if micros > hdrbounds.Max {
+		micros = hdrbounds.Max
+	}
+	p.latMu.Lock()
+	_ = p.warm.hist.RecordValue(micros)
+	p.latMu.Unlock()
+}
+
+// Warmup returns the warmup figures, or nil when warmup is off.
+func (p *Pool) Warmup() *WarmupStats {
+	w := &p.warm
+	if w.hist == nil || (w.requests <= 0 && w.duration <= 0) {
+		return nil
+	}
+	st := &WarmupStats{Active: !w.done.Load(), Excluded: w.excluded.Load()}
+	p.latMu.Lock()
+	if w.hist.TotalCount() > 0 {
+		st.P50Ms = float64(w.hist.ValueAtQuantile(50)) / 1000.0
+		st.P99Ms = float64(w.hist.ValueAtQuantile(99)) / 1000.0
+	}
+	p.latMu.Unlock()
+	return st
+}
@@ -0,0 +1,157 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ConnStats is a client's connection pool to one host (#1210). For
+// HTTP/2 and gRPC, which multiplex requests over a connection, InUse
+// counts in-flight requests and may exceed Open.
+type ConnStats struct {
+	Host  string `json:"host"`
+	Open  int64  `json:"open"`
+	Idle  int64  `json:"idle"`
+	InUse int64  `json:"in_use"`
+}
+
+// ConnReporter is implemented by clients that can report their
+// connection pools.
+type ConnReporter interface {
+	ConnStats() []ConnStats
+}
+
+// connTracker counts a client's open and in-use connections per host.
+// net/http doesn't expose its pool, so HTTP clients count dials and
+// closes themselves.
+type connTracker struct {
+	hosts sync.Map // host:port -> *hostConns
+	// limit paces dials (ClientConfig.DialLimiter); nil = unlimited.
+	limit *rate.Limiter
+}
+
+type hostConns struct {
+	open  int64
+	inUse int64
+}
+
+func (t *connTracker) host(addr string) *hostConns {
+	if h, ok := t.hosts.Load(addr); ok {
+		return h.(*hostConns)
+	}
+	h, _ := t.hosts.LoadOrStore(addr, &hostConns{})
+	return h.(*hostConns)
+}
+
+// connectTimeoutKey carries Request.ConnectTimeout from Do down to the
+// dialer; the transports only hand the dialer a context.
+type connectTimeoutKey struct{}
+
+func withConnectTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, connectTimeoutKey{}, d)
+}
+
+// dialer wraps dial so every connection it opens is counted until it
+// is closed, and bounded by the connect timeout ctx carries. With a
+// dial limiter, each dial first waits its turn; the connect timeout
+// starts once it has one.
+func (t *connTracker) dialer(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if err := waitDial(ctx, t.limit); err != nil {
+			return nil, err
+		}
+		conn, err := dialTimeout(ctx, dial, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		h := t.host(addr)
+		atomic.AddInt64(&h.open, 1)
+		return &trackedConn{Conn: conn, h: h}, nil
+	}
+}
+
+// waitDial waits for limit to allow a new connection (#1261). A wait
+// that would outlast ctx fails at once.
+func waitDial(ctx context.Context, limit *rate.Limiter) error {
+	if limit == nil {
+		return nil
+	}
+	if err := limit.Wait(ctx); err != nil {
+		return fmt.Errorf("connection rate limit: %w", err)
+	}
+	return nil
+}
+
+// dialTimeout dials under ctx's connect timeout, if any. Running out of
+// it is reported as ErrConnectTimeout; the request's own deadline
+// expiring first is left as is.
+func dialTimeout(ctx context.Context, dial func(ctx context.Context, network, addr string) (net.Conn, error), network, addr string) (net.Conn, error) {
+	d, _ := ctx.Value(connectTimeoutKey{}).(time.Duration)
+	if d <= 0 {
+		return dial(ctx, network, addr)
+	}
+	dctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	conn, err := dial(dctx, network, addr)
+	if err != nil && ctx.Err() == nil && errors.Is(dctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w: %s not connected after %s", ErrConnectTimeout, addr, d)
+	}
+	return conn, err
+}
+
+// stats returns every host seen so far, sorted. Hosts stay listed at
+// zero once their connections close so gauges drop back to 0.
+func (t *connTracker) stats() []ConnStats {
+	var out []ConnStats
+	t.hosts.Range(func(addr, v any) bool {
+		h := v.(*hostConns)
+		out = append(out, newConnStats(addr.(string), atomic.LoadInt64(&h.open), atomic.LoadInt64(&h.inUse)))
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
+
+func newConnStats(host string, open, inUse int64) ConnStats {
+	idle := open - inUse
+	if idle < 0 {
+		idle = 0
+	}
+	return ConnStats{Host: host, Open: open, Idle: idle, InUse: inUse}
+}
+
+// trackedConn decrements its host's open count on the first Close.
+type trackedConn struct {
+	net.Conn
+	h      *hostConns
+	closed int32
+}
+
+func (c *trackedConn) Close() error {
+	if atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
+		atomic.AddInt64(&c.h.open, -1)
+	}
+	return c.Conn.Close()
+}
+
+// hostAddr returns u's host:port as the transport dials it, with the
+// scheme's default port filled in.
+func hostAddr(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
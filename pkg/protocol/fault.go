@@ -0,0 +1,150 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrInjectedFault marks errors produced by FaultClient rather than by
+// the target.
+var ErrInjectedFault = errors.New("injected fault")
+
+// Fault kinds passed to FaultConfig.OnFault.
+const (
+	FaultTimeout = "timeout"
+	FaultError   = "error"
+	FaultSlow    = "slow"
+)
+
+// FaultConfig sets how often FaultClient fakes a failure. Rates are
+// probabilities per request and are rolled once, in order timeout,
+// error, slow, so they must sum to at most 1.
+type FaultConfig struct {
+	TimeoutRate float64
+	ErrorRate   float64
+	SlowRate    float64
+	// SlowDelay is added before a slow request is sent.
+	SlowDelay time.Duration
+	// ErrorStatus is the status a faked error returns: 500 for HTTP,
+	// a gRPC code for gRPC.
+	ErrorStatus int
+	// OnFault, when set, is called with the kind of every injected
+	// fault so callers can count them.
+	OnFault func(kind string)
+}
+
+// FaultClient wraps a Client and makes a configured share of requests
+// fail or slow down without touching the target, so kar's own error
+// handling — reports, metrics, circuit breaker — can be exercised
+// against a healthy service.
+type FaultClient struct {
+	inner Client
+	cfg   FaultConfig
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewFaultClient wraps inner.
+func NewFaultClient(inner Client, cfg FaultConfig) *FaultClient {
+	return &FaultClient{
+		inner: inner,
+		cfg:   cfg,
+		rng:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// ConnStats forwards to the wrapped client when it reports any.
+func (c *FaultClient) ConnStats() []ConnStats {
+	if r, ok := c.inner.(ConnReporter); ok {
+		return r.ConnStats()
+	}
+	return nil
+}
+
+// TLSStats forwards to the wrapped client when it counts handshakes.
+func (c *FaultClient) TLSStats() TLSStats {
+	if r, ok := c.inner.(TLSReporter); ok {
+		return r.TLSStats()
+	}
+	return TLSStats{}
+}
+
+// Warm forwards to the wrapped client when it can warm up; faults
+// aren't rolled for it.
+func (c *FaultClient) Warm(ctx context.Context, url string) error {
+	if w, ok := c.inner.(Warmer); ok {
+		return w.Warm(ctx, url)
+	}
+	return nil
+}
+
+// Do rolls for a fault and either fakes it or forwards to the wrapped
+// client.
+func (c *FaultClient) Do(ctx context.Context, req *Request) *Response {
+	c.mu.Lock()
+	roll := c.rng.Float64()
+	c.mu.Unlock()
+
+	switch {
+	case roll < c.cfg.TimeoutRate:
+		c.report(FaultTimeout)
+		return c.timeout(ctx, req)
+	case roll < c.cfg.TimeoutRate+c.cfg.ErrorRate:
+		c.report(FaultError)
+		return &Response{StatusCode: c.cfg.ErrorStatus}
+	case roll < c.cfg.TimeoutRate+c.cfg.ErrorRate+c.cfg.SlowRate:
+		c.report(FaultSlow)
+		start := time.Now()
+		if !sleepCtx(ctx, c.cfg.SlowDelay) {
+			return &Response{Error: ctx.Err(), Duration: time.Since(start)}
+		}
+		resp := c.inner.Do(ctx, req)
+		resp.Duration += c.cfg.SlowDelay
+		return resp
+	default:
+		return c.inner.Do(ctx, req)
+	}
+}
+
+// timeout holds the request until its own timeout (or ctx) would have
+// ended it, like a target that never answers.
+func (c *FaultClient) timeout(ctx context.Context, req *Request) *Response {
+	start := time.Now()
+	if req.Timeout > 0 {
+		sleepCtx(ctx, req.Timeout)
+	} else {
+		<-ctx.Done()
+	}
+	return &Response{
+		Error:    fmt.Errorf("%w: %w", ErrInjectedFault, context.DeadlineExceeded),
+		Duration: time.Since(start),
+	}
+}
+
+func (c *FaultClient) report(kind string) {
+	if c.cfg.OnFault != nil {
+		c.cfg.OnFault(kind)
+	}
+}
+
+// Close closes the wrapped client.
+func (c *FaultClient) Close() error {
+	return c.inner.Close()
+}
+
+// sleepCtx sleeps for d, returning false if ctx ended first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -3,46 +3,80 @@ package protocol
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 // GRPCClient implements Client for gRPC.
 type GRPCClient struct {
+	mu    sync.Mutex // guards conns and calls; Do runs on every worker
 	conns map[string]*grpc.ClientConn
-	cfg   ClientConfig
+	// calls counts each target's in-flight calls for ConnStats (#1210).
+	calls map[string]*int64
+	// methods holds resolved application methods by target, protoset
+	// and method name (#1253).
+	methods map[string]*methodEntry
+	cfg     ClientConfig
 }
 
 // NewGRPCClient creates a new gRPC client.
 func NewGRPCClient(cfg ClientConfig) *GRPCClient {
 	return &GRPCClient{
-		conns: make(map[string]*grpc.ClientConn),
-		cfg:   cfg,
+		conns:   make(map[string]*grpc.ClientConn),
+		calls:   make(map[string]*int64),
+		methods: make(map[string]*methodEntry),
+		cfg:     cfg,
 	}
 }
 
-// getConn returns a cached connection or creates a new one.
-func (c *GRPCClient) getConn(target string) (*grpc.ClientConn, error) {
+// getConn returns a cached connection or creates a new one, with the
+// target's in-flight call counter.
+func (c *GRPCClient) getConn(target string) (*grpc.ClientConn, *int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if conn, ok := c.conns[target]; ok {
-		return conn, nil
+		return conn, c.calls[target], nil
 	}
 
+	ping := c.cfg.PingInterval
+	if ping <= 0 {
+		ping = DefaultGRPCPing
+	}
 	opts := []grpc.DialOption{
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
-			Time:                10 * time.Second,
-			Timeout:             5 * time.Second,
+			Time:                ping,
+			Timeout:             c.cfg.pingTimeout(),
 			PermitWithoutStream: true,
 		}),
 	}
+	if c.cfg.TCPKeepAlive != 0 || c.cfg.DialLimiter != nil {
+		// Why: only when asked — a custom dialer bypasses grpc-go's
+		// proxy support.
+		dialer := &net.Dialer{KeepAlive: c.cfg.TCPKeepAlive}
+		opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			if err := waitDial(ctx, c.cfg.DialLimiter); err != nil {
+				return nil, err
+			}
+			return dialer.DialContext(ctx, "tcp", addr)
+		}))
+	}
 
-	if c.cfg.TLSInsecure {
+	if c.cfg.Plaintext {
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	} else {
 		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
@@ -52,32 +86,67 @@ func (c *GRPCClient) getConn(target string) (*grpc.ClientConn, error) {
 
 	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	c.conns[target] = conn
-	return conn, nil
+	c.calls[target] = new(int64)
+	return conn, c.calls[target], nil
 }
 
-// Do executes a gRPC health check request.
-// For simplicity, we use the standard gRPC health check protocol.
+// Do calls req.GRPCMethod with req.Body as its JSON request message,
+// or the standard health check when no method is set. StatusCode is
+// the call's gRPC status code.
 func (c *GRPCClient) Do(ctx context.Context, req *Request) *Response {
 	start := time.Now()
 	resp := &Response{}
 
-	conn, err := c.getConn(req.URL)
+	conn, calls, err := c.getConn(req.URL)
 	if err != nil {
 		resp.Error = err
 		resp.Duration = time.Since(start)
 		return resp
 	}
+	atomic.AddInt64(calls, 1)
+	defer atomic.AddInt64(calls, -1)
 
 	if req.Timeout > 0 {
 		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		if req.PropagateDeadline {
+			// grpc-go derives the grpc-timeout header from the
+			// context deadline.
+			ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		} else {
+			// Enforce the timeout without a context deadline so no
+			// grpc-timeout is put on the wire. The call then fails as
+			// Canceled; report it as the DeadlineExceeded it is.
+			ctx, cancel = context.WithCancel(ctx)
+			var fired atomic.Bool
+			timer := time.AfterFunc(req.Timeout, func() {
+				fired.Store(true)
+				cancel()
+			})
+			defer func() {
+				timer.Stop()
+				if fired.Load() && resp.Error != nil && (status.Code(resp.Error) == codes.Canceled || errors.Is(resp.Error, context.Canceled)) {
+					resp.Error = status.Errorf(codes.DeadlineExceeded, "timeout %s exceeded", req.Timeout)
+					resp.StatusCode = int(codes.DeadlineExceeded)
+				}
+			}()
+		}
 		defer cancel()
 	}
 
+	if len(req.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, outgoingMetadata(req.Headers))
+	}
+
+	if req.GRPCMethod != "" {
+		c.invoke(ctx, conn, req, resp)
+		resp.Duration = time.Since(start)
+		return resp
+	}
+
 	client := grpc_health_v1.NewHealthClient(conn)
 	healthResp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{
 		Service: "", // empty string means overall server health
@@ -102,11 +171,69 @@ func (c *GRPCClient) Do(ctx context.Context, req *Request) *Response {
 	return resp
 }
 
+// invoke calls req.GRPCMethod on conn, filling in resp but its
+// Duration. Resolving the method and encoding the body are part of the
+// request, so the first call also pays for the descriptor lookup.
+func (c *GRPCClient) invoke(ctx context.Context, conn *grpc.ClientConn, req *Request, resp *Response) {
+	m, err := c.method(ctx, req.URL, conn, req)
+	if err != nil {
+		resp.Error = err
+		resp.StatusCode = int(status.Code(err))
+		if resp.StatusCode == int(codes.Unknown) {
+			resp.StatusCode = int(codes.InvalidArgument)
+		}
+		return
+	}
+	wire, err := m.encode(req.Body)
+	if err != nil {
+		resp.Error = err
+		resp.StatusCode = int(codes.InvalidArgument)
+		return
+	}
+	resp.BytesWritten = int64(len(wire))
+	err = conn.Invoke(ctx, m.path, wire, &resp.BytesRead, grpc.ForceCodec(rawCodec{}))
+	resp.Error = err
+	resp.StatusCode = int(status.Code(err))
+}
+
+// outgoingMetadata maps target headers onto gRPC call metadata. Keys
+// are lowercased as HTTP/2 requires; "-bin" keys carry their value as
+// raw bytes, which grpc-go base64-encodes on the wire.
+func outgoingMetadata(headers map[string]string) metadata.MD {
+	md := make(metadata.MD, len(headers))
+	for k, v := range headers {
+		key := strings.ToLower(k)
+		md[key] = append(md[key], v)
+	}
+	return md
+}
+
+// ConnStats reports one entry per target. Its ClientConn counts as
+// open while Ready; in its other states it has no transport up.
+func (c *GRPCClient) ConnStats() []ConnStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]ConnStats, 0, len(c.conns))
+	for target, conn := range c.conns {
+		var open int64
+		if conn.GetState() == connectivity.Ready {
+			open = 1
+		}
+		out = append(out, newConnStats(target, open, atomic.LoadInt64(c.calls[target])))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
+
 // Close releases all connections.
 func (c *GRPCClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	for _, conn := range c.conns {
 		conn.Close()
 	}
 	c.conns = make(map[string]*grpc.ClientConn)
+	c.calls = make(map[string]*int64)
+	c.methods = make(map[string]*methodEntry)
 	return nil
 }
@@ -0,0 +1,274 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// grpcMethod is an application method resolved from its descriptor
+// (#1253): the path it's invoked on and the message type its JSON
+// request bodies encode to.
+type grpcMethod struct {
+	path  string // /package.Service/Method
+	input protoreflect.MessageDescriptor
+	// last is the most recent body and its encoding. Bodies are
+	// usually the same request after request, and protojson isn't
+	// cheap.
+	last atomic.Pointer[encodedBody]
+}
+
+type encodedBody struct {
+	json string
+	wire []byte
+}
+
+// encode converts a JSON request body to the method's wire format.
+// An empty body is the empty message.
+func (m *grpcMethod) encode(body []byte) ([]byte, error) {
+	if e := m.last.Load(); e != nil && e.json == string(body) {
+		return e.wire, nil
+	}
+	msg := dynamicpb.NewMessage(m.input)
+	if len(body) > 0 {
+		if err := protojson.Unmarshal(body, msg); err != nil {
+			return nil, fmt.Errorf("request body as %s: %w", m.input.FullName(), err)
+		}
+	}
+	wire, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	m.last.Store(&encodedBody{json: string(body), wire: wire})
+	return wire, nil
+}
+
+// methodEntry resolves one target's method once: the first caller
+// resolves while later ones wait, and a failure leaves it for the next
+// request to retry.
+type methodEntry struct {
+	mu sync.Mutex
+	m  atomic.Pointer[grpcMethod]
+}
+
+// method returns req.GRPCMethod resolved on target's connection.
+func (c *GRPCClient) method(ctx context.Context, target string, conn *grpc.ClientConn, req *Request) (*grpcMethod, error) {
+	key := target + "\x00" + req.Protoset + "\x00" + req.GRPCMethod
+	c.mu.Lock()
+	e, ok := c.methods[key]
+	if !ok {
+		e = &methodEntry{}
+		c.methods[key] = e
+	}
+	c.mu.Unlock()
+	if m := e.m.Load(); m != nil {
+		return m, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if m := e.m.Load(); m != nil {
+		return m, nil
+	}
+	m, err := resolveGRPCMethod(ctx, conn, req.GRPCMethod, req.Protoset)
+	if err != nil {
+		return nil, err
+	}
+	e.m.Store(m)
+	return m, nil
+}
+
+// SplitGRPCMethod splits a fully-qualified method, package.Service/Method
+// with an optional leading slash, into its service and method names.
+func SplitGRPCMethod(name string) (service, method string, err error) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(name, "/"), "/")
+	if !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		return "", "", fmt.Errorf("gRPC method %q is not package.Service/Method", name)
+	}
+	return service, method, nil
+}
+
+// resolveGRPCMethod looks name up in protoset or, without one, through
+// the server's reflection service. Only unary methods can be called.
+func resolveGRPCMethod(ctx context.Context, conn *grpc.ClientConn, name, protoset string) (*grpcMethod, error) {
+	svc, meth, err := SplitGRPCMethod(name)
+	if err != nil {
+		return nil, err
+	}
+	var files *protoregistry.Files
+	if protoset != "" {
+		files, err = loadProtoset(protoset)
+	} else {
+		files, err = reflectFiles(ctx, conn, svc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return findGRPCMethod(files, svc, meth)
+}
+
+// ValidateGRPCCall checks, without a server, that protoset describes
+// method as a unary method and, unless body is nil, that body is a
+// valid JSON request message for it.
+func ValidateGRPCCall(protoset, method string, body []byte) error {
+	svc, meth, err := SplitGRPCMethod(method)
+	if err != nil {
+		return err
+	}
+	files, err := loadProtoset(protoset)
+	if err != nil {
+		return err
+	}
+	m, err := findGRPCMethod(files, svc, meth)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		_, err = m.encode(body)
+	}
+	return err
+}
+
+func findGRPCMethod(files *protoregistry.Files, svc, meth string) (*grpcMethod, error) {
+	d, err := files.FindDescriptorByName(protoreflect.FullName(svc))
+	if err != nil {
+		return nil, fmt.Errorf("service %s: %w", svc, err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", svc)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(meth))
+	if md == nil {
+		return nil, fmt.Errorf("service %s has no method %s", svc, meth)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("%s/%s is a streaming method; only unary methods can be called", svc, meth)
+	}
+	return &grpcMethod{path: "/" + svc + "/" + meth, input: md.Input()}, nil
+}
+
+// loadProtoset reads a FileDescriptorSet file, as written by
+// protoc --descriptor_set_out --include_imports.
+func loadProtoset(path string) (*protoregistry.Files, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set := new(descriptorpb.FileDescriptorSet)
+	if err := proto.Unmarshal(b, set); err != nil {
+		return nil, fmt.Errorf("protoset %s: %w", path, err)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("protoset %s: %w", path, err)
+	}
+	return files, nil
+}
+
+// reflectFiles fetches the file defining service, and the files it
+// imports, from the server's reflection service. Imports the server
+// doesn't describe, such as well-known types, come from the ones
+// compiled into kar.
+func reflectFiles(ctx context.Context, conn *grpc.ClientConn, service string) (*protoregistry.Files, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("server reflection: %w", err)
+	}
+	fds := make(map[string]*descriptorpb.FileDescriptorProto)
+	ask := func(r *rpb.ServerReflectionRequest) error {
+		if err := stream.Send(r); err != nil {
+			return err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return status.Error(codes.Code(e.ErrorCode), e.ErrorMessage)
+		}
+		for _, b := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := new(descriptorpb.FileDescriptorProto)
+			if err := proto.Unmarshal(b, fd); err != nil {
+				return err
+			}
+			fds[fd.GetName()] = fd
+		}
+		return nil
+	}
+
+	err = ask(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("server reflection for %s (or set a protoset): %w", service, err)
+	}
+	for dep := missingDep(fds); dep != ""; dep = missingDep(fds) {
+		if fd, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+			fds[dep] = protodesc.ToFileDescriptorProto(fd)
+			continue
+		}
+		err := ask(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("server reflection for %s: %w", dep, err)
+		}
+		if _, ok := fds[dep]; !ok {
+			return nil, fmt.Errorf("server reflection didn't describe %s", dep)
+		}
+	}
+
+	set := new(descriptorpb.FileDescriptorSet)
+	for _, fd := range fds {
+		set.File = append(set.File, fd)
+	}
+	return protodesc.NewFiles(set)
+}
+
+// missingDep returns an import none of fds is, or "" when they're
+// complete.
+func missingDep(fds map[string]*descriptorpb.FileDescriptorProto) string {
+	for _, fd := range fds {
+		for _, dep := range fd.GetDependency() {
+			if _, ok := fds[dep]; !ok {
+				return dep
+			}
+		}
+	}
+	return ""
+}
+
+// rawCodec sends pre-encoded request bytes and, like a drained HTTP
+// body, only counts the response's: the reply is an *int64. Named
+// "proto" so the call's content type is the usual
+// application/grpc+proto.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	return v.([]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	*v.(*int64) = int64(len(data))
+	return nil
+}
+
+func (rawCodec) Name() string { return "proto" }
@@ -0,0 +1,224 @@
+package protocol
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC-Web framing: every message is prefixed by a flag byte and a
+// big-endian length. The response ends in a frame flagged
+// grpcWebTrailerFlag that carries the call's trailers as HTTP/1 header
+// lines, since browsers can't read HTTP trailers.
+const (
+	grpcWebContentType = "application/grpc-web+proto"
+	grpcWebTrailerFlag = 0x80
+)
+
+// DefaultGRPCWebMethod is called when a gRPC-Web target's URL has no
+// path: the standard health check, as for gRPC targets.
+const DefaultGRPCWebMethod = "/grpc.health.v1.Health/Check"
+
+// GRPCWebClient implements Client for gRPC-Web (#1226): unary calls
+// framed over plain HTTP, the way a browser reaches a service through
+// a gRPC-Web gateway such as Envoy. The target URL's path names the
+// method (/package.Service/Method) and Request.Body is the serialized
+// request message.
+type GRPCWebClient struct {
+	http *HTTPClient
+}
+
+// NewGRPCWebClient creates a new gRPC-Web client.
+func NewGRPCWebClient(cfg ClientConfig) *GRPCWebClient {
+	c := NewHTTPClient(cfg)
+	// Like a browser: HTTP/2 when TLS negotiates it, HTTP/1.1 otherwise.
+	c.client.Transport.(*http.Transport).ForceAttemptHTTP2 = true
+	return &GRPCWebClient{http: c}
+}
+
+// Do executes a unary gRPC-Web call. Response.StatusCode is the gRPC
+// status code, as for GRPCClient; a non-OK status is also the error.
+func (c *GRPCWebClient) Do(ctx context.Context, req *Request) *Response {
+	call, err := grpcWebURL(req.URL)
+	if err != nil {
+		return &Response{Error: err, StatusCode: int(codes.InvalidArgument)}
+	}
+
+	frame := make([]byte, 5+len(req.Body))
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(req.Body)))
+	copy(frame[5:], req.Body)
+
+	headers := make(map[string]string, len(req.Headers)+3)
+	for k, v := range req.Headers {
+		headers[k] = v
+	}
+	headers["Content-Type"] = grpcWebContentType
+	headers["X-Grpc-Web"] = "1"
+	if req.PropagateDeadline && req.Timeout > 0 {
+		headers["Grpc-Timeout"] = strconv.FormatInt(req.Timeout.Milliseconds(), 10) + "m"
+	}
+
+	var st grpcWebStatus
+	hreq := *req
+	hreq.URL = call
+	hreq.Method = http.MethodPost
+	hreq.Headers = headers
+	hreq.Body = frame
+	hreq.PropagateDeadline = false // sent as grpc-timeout above
+	hreq.FirstByteOnly = false
+	hreq.readBody = st.read
+
+	resp := c.http.Do(ctx, &hreq)
+	resp.StatusCode, resp.Error = st.result(resp.Error)
+	return resp
+}
+
+// grpcWebURL returns the URL to POST a call to: the target URL, with
+// DefaultGRPCWebMethod when it names no method.
+func grpcWebURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = DefaultGRPCWebMethod
+	}
+	return u.String(), nil
+}
+
+// grpcWebStatus is the call status read from a gRPC-Web response.
+type grpcWebStatus struct {
+	httpStatus int
+	found      bool
+	code       codes.Code
+	message    string
+}
+
+// read consumes the response frames and picks up grpc-status, which a
+// trailers-only response puts in its headers instead of a frame.
+func (s *grpcWebStatus) read(r *http.Response) (int64, error) {
+	s.httpStatus = r.StatusCode
+	s.parse(r.Header.Get("Grpc-Status"), r.Header.Get("Grpc-Message"))
+
+	br := bufio.NewReader(r.Body)
+	var n int64
+	var prefix [5]byte
+	for {
+		if _, err := io.ReadFull(br, prefix[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return n, nil
+			}
+			return n, err
+		}
+		n += int64(len(prefix))
+		size := int64(binary.BigEndian.Uint32(prefix[1:]))
+		if prefix[0]&grpcWebTrailerFlag == 0 {
+			m, err := io.CopyN(io.Discard, br, size)
+			n += m
+			if err != nil {
+				return n, err
+			}
+			continue
+		}
+		trailers, err := io.ReadAll(io.LimitReader(br, size))
+		n += int64(len(trailers))
+		if err != nil {
+			return n, err
+		}
+		var code, msg string
+		for _, line := range strings.Split(string(trailers), "\r\n") {
+			k, v, _ := strings.Cut(line, ":")
+			switch strings.ToLower(strings.TrimSpace(k)) {
+			case "grpc-status":
+				code = strings.TrimSpace(v)
+			case "grpc-message":
+				msg = strings.TrimSpace(v)
+			}
+		}
+		s.parse(code, msg)
+	}
+}
+
+func (s *grpcWebStatus) parse(code, msg string) {
+	if code == "" {
+		return
+	}
+	c, err := strconv.ParseUint(code, 10, 32)
+	if err != nil {
+		c = uint64(codes.Unknown)
+	}
+	if m, err := url.PathUnescape(msg); err == nil {
+		msg = m
+	}
+	s.found, s.code, s.message = true, codes.Code(c), msg
+}
+
+// result maps the call's outcome to a gRPC status code and error. A
+// call that never got a status fails with the code the transport
+// error or the HTTP status implies, never with OK.
+func (s *grpcWebStatus) result(err error) (int, error) {
+	switch {
+	case s.found && s.code != codes.OK:
+		return int(s.code), status.Error(s.code, s.message)
+	case s.found:
+		return int(codes.OK), err
+	case errors.Is(err, context.DeadlineExceeded):
+		return int(codes.DeadlineExceeded), err
+	case errors.Is(err, context.Canceled):
+		return int(codes.Canceled), err
+	case err != nil:
+		return int(codes.Unavailable), err
+	case s.httpStatus != http.StatusOK:
+		code := httpToGRPCCode(s.httpStatus)
+		return int(code), status.Errorf(code, "gRPC-Web: HTTP %d", s.httpStatus)
+	}
+	return int(codes.Internal), status.Error(codes.Internal, "gRPC-Web: response carried no grpc-status")
+}
+
+// httpToGRPCCode maps an HTTP status that came without a grpc-status,
+// typically from the gateway itself, per gRPC's HTTP mapping.
+func httpToGRPCCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.Internal
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	}
+	return codes.Unknown
+}
+
+// ConnStats reports the client's connections per host.
+func (c *GRPCWebClient) ConnStats() []ConnStats {
+	return c.http.ConnStats()
+}
+
+// TLSStats reports the client's handshakes.
+func (c *GRPCWebClient) TLSStats() TLSStats {
+	return c.http.TLSStats()
+}
+
+// Warm warms the connection to url's host.
+func (c *GRPCWebClient) Warm(ctx context.Context, url string) error {
+	return c.http.Warm(ctx, url)
+}
+
+// Close releases resources.
+func (c *GRPCWebClient) Close() error {
+	return c.http.Close()
+}
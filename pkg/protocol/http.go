@@ -7,7 +7,10 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptrace"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/net/http2"
@@ -17,22 +20,24 @@ import (
 type HTTPClient struct {
 	client  *http.Client
 	bufPool sync.Pool
+	conns   *connTracker
+	tls     *tlsCounter
 }
 
 // NewHTTPClient creates a new HTTP/1.1 client.
 func NewHTTPClient(cfg ClientConfig) *HTTPClient {
+	conns, counter := &connTracker{limit: cfg.DialLimiter}, &tlsCounter{}
 	transport := &http.Transport{
-		DialContext: (&net.Dialer{
+		DialContext: conns.dialer((&net.Dialer{
 			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
+			KeepAlive: cfg.tcpKeepAlive(),
+		}).DialContext),
 		MaxIdleConns:        cfg.MaxIdleConns,
 		MaxIdleConnsPerHost: cfg.MaxIdleConns,
+		MaxConnsPerHost:     cfg.MaxConnsPerHost,
 		IdleConnTimeout:     cfg.IdleConnTimeout,
 		DisableCompression:  true,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: cfg.TLSInsecure,
-		},
+		TLSClientConfig:     counter.config(cfg),
 	}
 
 	return &HTTPClient{
@@ -48,25 +53,43 @@ func NewHTTPClient(cfg ClientConfig) *HTTPClient {
 				return &buf
 			},
 		},
+		conns: conns,
+		tls:   counter,
 	}
 }
 
 // NewHTTP2Client creates a new HTTP/2 client.
 func NewHTTP2Client(cfg ClientConfig) *HTTPClient {
+	conns := &connTracker{limit: cfg.DialLimiter}
+	return newHTTPClientWith(newHTTP2Transport(cfg, conns), conns)
+}
+
+// newHTTP2Transport builds the HTTP/2 transport: cleartext (h2c), each
+// connection counted by conns.
+func newHTTP2Transport(cfg ClientConfig, conns *connTracker) *http2.Transport {
+	dial := conns.dialer((&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: cfg.tcpKeepAlive(),
+	}).DialContext)
 	transport := &http2.Transport{
 		AllowHTTP: true,
 		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
-			d := &net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}
-			return d.DialContext(ctx, network, addr)
+			return dial(ctx, network, addr)
 		},
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: cfg.TLSInsecure,
 		},
 	}
+	if cfg.PingInterval > 0 {
+		// A connection with no frames for ReadIdleTimeout gets a PING;
+		// no answer within PingTimeout closes it.
+		transport.ReadIdleTimeout = cfg.PingInterval
+		transport.PingTimeout = cfg.pingTimeout()
+	}
+	return transport
+}
 
+func newHTTPClientWith(transport http.RoundTripper, conns *connTracker) *HTTPClient {
 	return &HTTPClient{
 		client: &http.Client{
 			Transport: transport,
@@ -80,6 +103,8 @@ func NewHTTP2Client(cfg ClientConfig) *HTTPClient {
 				return &buf
 			},
 		},
+		conns: conns,
+		tls:   &tlsCounter{},
 	}
 }
 
@@ -94,6 +119,9 @@ func (c *HTTPClient) Do(ctx context.Context, req *Request) *Response {
 		resp.BytesWritten = int64(len(req.Body))
 	}
 
+	if req.ConnectTimeout > 0 {
+		ctx = withConnectTimeout(ctx, req.ConnectTimeout)
+	}
 	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, bodyReader)
 	if err != nil {
 		resp.Error = err
@@ -104,6 +132,9 @@ func (c *HTTPClient) Do(ctx context.Context, req *Request) *Response {
 	for k, v := range req.Headers {
 		httpReq.Header.Set(k, v)
 	}
+	if req.PropagateDeadline && req.Timeout > 0 && req.DeadlineHeader != "" {
+		httpReq.Header.Set(req.DeadlineHeader, strconv.FormatInt(req.Timeout.Milliseconds(), 10))
+	}
 
 	if req.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -112,6 +143,36 @@ func (c *HTTPClient) Do(ctx context.Context, req *Request) *Response {
 		httpReq = httpReq.WithContext(ctx)
 	}
 
+	// GotConn always marks the host's connection in use until Do
+	// returns, for the connection pool stats (#1210).
+	host := c.conns.host(hostAddr(httpReq.URL))
+	var acquired int32
+	defer func() {
+		if atomic.LoadInt32(&acquired) == 1 {
+			atomic.AddInt64(&host.inUse, -1)
+		}
+	}()
+	trace := &httptrace.ClientTrace{}
+	if req.TraceTTFB || req.FirstByteOnly {
+		trace.GotFirstResponseByte = func() { resp.TTFB = time.Since(start) }
+	}
+	var asked time.Time
+	if req.TraceConnWait {
+		trace.GetConn = func(string) { asked = time.Now() }
+	}
+	trace.GotConn = func(info httptrace.GotConnInfo) {
+		if atomic.CompareAndSwapInt32(&acquired, 0, 1) {
+			atomic.AddInt64(&host.inUse, 1)
+		}
+		if req.TraceConnWait {
+			resp.ConnWait = time.Since(asked)
+			// A reused conn that wasn't idle was handed over by a
+			// finishing request while this one waited in line.
+			resp.ConnQueued = info.Reused && !info.WasIdle
+		}
+	}
+	httpReq = httpReq.WithContext(httptrace.WithClientTrace(httpReq.Context(), trace))
+
 	httpResp, err := c.client.Do(httpReq)
 	if err != nil {
 		resp.Error = err
@@ -121,18 +182,60 @@ func (c *HTTPClient) Do(ctx context.Context, req *Request) *Response {
 	defer httpResp.Body.Close()
 
 	resp.StatusCode = httpResp.StatusCode
+	if req.CaptureHeader != "" {
+		resp.Header = httpResp.Header.Get(req.CaptureHeader)
+	}
+
+	if req.readBody != nil {
+		n, err := req.readBody(httpResp)
+		resp.BytesRead = n
+		resp.Duration = time.Since(start)
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		resp.Error = err
+		return resp
+	}
 
 	// Drain and discard response body
 	bufPtr := c.bufPool.Get().(*[]byte)
 	defer c.bufPool.Put(bufPtr)
 
-	n, _ := io.CopyBuffer(io.Discard, httpResp.Body, *bufPtr)
+	if req.FirstByteOnly {
+		// One Read returns whatever the first chunk/event delivered.
+		// Closing the body early costs the connection its keep-alive,
+		// which is the price of not sitting on an open stream.
+		n, _ := httpResp.Body.Read(*bufPtr)
+		resp.BytesRead = int64(n)
+		if req.CaptureBody > 0 {
+			resp.Body = bytes.Clone((*bufPtr)[:min(n, req.CaptureBody)])
+		}
+		resp.Duration = time.Since(start)
+		return resp
+	}
+
+	var n int64
+	if req.CaptureBody > 0 {
+		resp.Body, n, err = drainCapture(httpResp.Body, *bufPtr, req.CaptureBody)
+	} else {
+		n, err = io.CopyBuffer(io.Discard, httpResp.Body, *bufPtr)
+	}
 	resp.BytesRead = n
 	resp.Duration = time.Since(start)
+	if err != nil && ctx.Err() != nil {
+		// The body was cut off by a deadline; report it so callers can
+		// tell a truncated read from a complete one.
+		resp.Error = ctx.Err()
+	}
 
 	return resp
 }
 
+// ConnStats reports the client's connections per host.
+func (c *HTTPClient) ConnStats() []ConnStats {
+	return c.conns.stats()
+}
+
 // Close releases resources.
 func (c *HTTPClient) Close() error {
 	c.client.CloseIdleConnections()
@@ -0,0 +1,247 @@
+package protocol
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/net/http2"
+)
+
+// HTTP2Options tunes how an HTTP/2 client spreads requests over
+// connections (#1248). Go's transport opens one connection per host and
+// multiplexes every request on it up to the server's
+// SETTINGS_MAX_CONCURRENT_STREAMS, which hides how a server behaves
+// with many clients or a lower stream limit.
+type HTTP2Options struct {
+	// Connections is how many connections to open per host; requests
+	// go round-robin over them. 0 means 1.
+	Connections int
+	// MaxStreams caps each connection's concurrent streams on the
+	// client side; a request beyond it waits for a stream to finish.
+	// 0 leaves only the server's limit.
+	MaxStreams int
+}
+
+// StreamStats is an HTTP/2 client's connections to one host.
+type StreamStats struct {
+	Host string `json:"host"`
+	// Connections is how many of the configured connections are open.
+	Connections int64 `json:"connections"`
+	// Streams are in flight now; PeakStreams is the most that were in
+	// flight on one connection at once.
+	Streams     int64 `json:"streams"`
+	PeakStreams int64 `json:"peak_streams"`
+	MaxStreams  int   `json:"max_streams,omitempty"`
+	// Waited counts requests that waited for a stream under MaxStreams.
+	Waited int64 `json:"waited"`
+	// Redials counts connections opened again after the previous one
+	// closed, e.g. on GOAWAY.
+	Redials int64 `json:"redials"`
+}
+
+// StreamReporter is implemented by clients that report HTTP/2 streams.
+type StreamReporter interface {
+	StreamStats() []StreamStats
+}
+
+// HTTP2ConnClient is an HTTP/2 client with HTTP2Options applied.
+type HTTP2ConnClient struct {
+	*HTTPClient
+	pool *h2Pool
+}
+
+// NewHTTP2ConnClient creates an HTTP/2 client that opens
+// opts.Connections connections per host, each capped at
+// opts.MaxStreams concurrent streams.
+func NewHTTP2ConnClient(cfg ClientConfig, opts HTTP2Options) *HTTP2ConnClient {
+	conns := &connTracker{limit: cfg.DialLimiter}
+	if opts.Connections <= 0 {
+		opts.Connections = 1
+	}
+	pool := &h2Pool{t: newHTTP2Transport(cfg, conns), opts: opts}
+	return &HTTP2ConnClient{HTTPClient: newHTTPClientWith(pool, conns), pool: pool}
+}
+
+// StreamStats reports the client's connections and streams per host.
+func (c *HTTP2ConnClient) StreamStats() []StreamStats {
+	return c.pool.stats()
+}
+
+// h2Pool is the http.RoundTripper behind HTTP2ConnClient. It owns its
+// connections instead of leaving them to the transport's pool, which
+// would put every request on one.
+type h2Pool struct {
+	t     *http2.Transport
+	opts  HTTP2Options
+	hosts sync.Map // host:port -> *h2Host
+}
+
+type h2Host struct {
+	conns   []*h2Conn
+	next    atomic.Uint64
+	waited  atomic.Int64
+	redials atomic.Int64
+}
+
+type h2Conn struct {
+	mu     sync.Mutex
+	cc     *http2.ClientConn
+	slots  chan struct{} // nil without MaxStreams
+	active atomic.Int64
+	peak   atomic.Int64
+}
+
+func (p *h2Pool) host(addr string) *h2Host {
+	if h, ok := p.hosts.Load(addr); ok {
+		return h.(*h2Host)
+	}
+	h := &h2Host{conns: make([]*h2Conn, p.opts.Connections)}
+	for i := range h.conns {
+		h.conns[i] = &h2Conn{}
+		if p.opts.MaxStreams > 0 {
+			h.conns[i].slots = make(chan struct{}, p.opts.MaxStreams)
+		}
+	}
+	v, _ := p.hosts.LoadOrStore(addr, h)
+	return v.(*h2Host)
+}
+
+func (p *h2Pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	addr := hostAddr(req.URL)
+	h := p.host(addr)
+	c := h.conns[(h.next.Add(1)-1)%uint64(len(h.conns))]
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.GetConn != nil {
+		trace.GetConn(addr)
+	}
+	if c.slots != nil {
+		select {
+		case c.slots <- struct{}{}:
+		default:
+			h.waited.Add(1)
+			select {
+			case c.slots <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	cc, reused, err := p.clientConn(ctx, h, c, addr)
+	if err != nil {
+		c.release()
+		return nil, err
+	}
+	if trace != nil && trace.GotConn != nil {
+		trace.GotConn(httptrace.GotConnInfo{Reused: reused})
+	}
+
+	n := c.active.Add(1)
+	for peak := c.peak.Load(); n > peak && !c.peak.CompareAndSwap(peak, n); peak = c.peak.Load() {
+	}
+	resp, err := cc.RoundTrip(req)
+	if err != nil {
+		c.active.Add(-1)
+		c.release()
+		return nil, err
+	}
+	// The stream stays open until the body is closed.
+	resp.Body = &streamBody{ReadCloser: resp.Body, c: c}
+	return resp, nil
+}
+
+// clientConn returns c's connection, dialing it when it was never
+// opened or the previous one is closing.
+func (p *h2Pool) clientConn(ctx context.Context, h *h2Host, c *h2Conn, addr string) (*http2.ClientConn, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cc != nil {
+		st := c.cc.State()
+		if !st.Closed && !st.Closing {
+			return c.cc, true, nil
+		}
+		h.redials.Add(1)
+		c.cc = nil
+	}
+	conn, err := p.t.DialTLSContext(ctx, "tcp", addr, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	cc, err := p.t.NewClientConn(conn)
+	if err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	c.cc = cc
+	return cc, false, nil
+}
+
+func (c *h2Conn) release() {
+	if c.slots != nil {
+		<-c.slots
+	}
+}
+
+// streamBody ends its stream's accounting on the first Close.
+type streamBody struct {
+	io.ReadCloser
+	c    *h2Conn
+	once sync.Once
+}
+
+func (b *streamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.c.active.Add(-1)
+		b.c.release()
+	})
+	return err
+}
+
+// CloseIdleConnections closes every connection; http.Client calls it
+// from HTTPClient.Close.
+func (p *h2Pool) CloseIdleConnections() {
+	p.hosts.Range(func(_, v any) bool {
+		for _, c := range v.(*h2Host).conns {
+			c.mu.Lock()
+			if c.cc != nil {
+				c.cc.Close()
+				c.cc = nil
+			}
+			c.mu.Unlock()
+		}
+		return true
+	})
+}
+
+func (p *h2Pool) stats() []StreamStats {
+	var out []StreamStats
+	p.hosts.Range(func(addr, v any) bool {
+		h := v.(*h2Host)
+		st := StreamStats{
+			Host:       addr.(string),
+			MaxStreams: p.opts.MaxStreams,
+			Waited:     h.waited.Load(),
+			Redials:    h.redials.Load(),
+		}
+		for _, c := range h.conns {
+			c.mu.Lock()
+			if c.cc != nil && !c.cc.State().Closed {
+				st.Connections++
+			}
+			c.mu.Unlock()
+			st.Streams += c.active.Load()
+			st.PeakStreams = max(st.PeakStreams, c.peak.Load())
+		}
+		out = append(out, st)
+		return true
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
@@ -0,0 +1,435 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPipelineBroken fails the requests still queued on a pipelined
+// connection that broke or that the server closed, typically after
+// answering an earlier request with "Connection: close".
+var ErrPipelineBroken = errors.New("pipelined connection closed")
+
+// PipelineClient implements Client for HTTP/1.1 with request
+// pipelining (#1230): up to depth requests are written on a connection
+// before their responses are read. net/http never pipelines, so the
+// client speaks HTTP/1.1 over connections of its own; a new one is
+// dialed only when every open one has depth requests outstanding.
+//
+// Responses come back in request order, so a slow response holds up
+// every request queued behind it on its connection, and Duration
+// includes that wait. A request that times out can't be taken back:
+// its connection is closed and the requests behind it fail with
+// ErrPipelineBroken. Response bodies are always read in full, since the
+// next response starts where the body ends.
+type PipelineClient struct {
+	depth int
+	dial  func(ctx context.Context, network, addr string) (net.Conn, error)
+	tls   *tls.Config
+	idle  time.Duration
+	conns *connTracker
+	hs    *tlsCounter
+
+	mu     sync.Mutex
+	hosts  map[string][]*pipeConn // scheme://host:port -> connections
+	closed bool
+}
+
+// NewPipelineClient creates a client that pipelines up to depth
+// requests per connection.
+func NewPipelineClient(cfg ClientConfig, depth int) *PipelineClient {
+	if depth < 1 {
+		depth = 1
+	}
+	conns, counter := &connTracker{limit: cfg.DialLimiter}, &tlsCounter{}
+	tc := counter.config(cfg)
+	tc.NextProtos = []string{"http/1.1"}
+	return &PipelineClient{
+		depth: depth,
+		dial: conns.dialer((&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: cfg.tcpKeepAlive(),
+		}).DialContext),
+		tls:   tc,
+		idle:  cfg.IdleConnTimeout,
+		conns: conns,
+		hs:    counter,
+		hosts: make(map[string][]*pipeConn),
+	}
+}
+
+// pipeConn is one pipelined connection. Requests are queued in the
+// order they were written; its read loop answers them in that order.
+type pipeConn struct {
+	conn   net.Conn
+	host   *hostConns
+	br     *bufio.Reader
+	bw     *bufio.Writer
+	queued chan *pipeCall
+	done   chan struct{}
+	once   sync.Once
+
+	// ready is closed once the dial finished; dialErr is its outcome.
+	ready   chan struct{}
+	dialErr error
+
+	// wmu serializes writes so the queue order is the wire order.
+	// dead is set under it once the connection broke.
+	wmu  sync.Mutex
+	dead error
+
+	// Guarded by PipelineClient.mu.
+	inflight int
+	lastUsed time.Time
+}
+
+// close closes the connection and stops its read loop.
+func (pc *pipeConn) close() {
+	pc.once.Do(func() {
+		close(pc.done)
+		select {
+		case <-pc.ready:
+			if pc.conn != nil {
+				pc.conn.Close()
+			}
+		default:
+			// Still dialing: readLoop isn't running yet and will
+			// see done once it does.
+		}
+	})
+}
+
+// pipeCall is one request on a pipelined connection.
+type pipeCall struct {
+	req      *http.Request
+	opts     *Request
+	start    time.Time
+	deadline time.Time
+	resp     *Response
+	finished chan struct{}
+}
+
+// Do executes an HTTP request on a pipelined connection.
+func (c *PipelineClient) Do(ctx context.Context, req *Request) *Response {
+	start := time.Now()
+	resp := &Response{}
+
+	var body io.Reader
+	if len(req.Body) > 0 {
+		body = bytes.NewReader(req.Body)
+		resp.BytesWritten = int64(len(req.Body))
+	}
+	httpReq, err := http.NewRequest(req.Method, req.URL, body)
+	if err == nil && httpReq.URL.Scheme != "http" && httpReq.URL.Scheme != "https" {
+		err = fmt.Errorf("pipelining needs an http or https URL, got %q", req.URL)
+	}
+	if err != nil {
+		resp.Error = err
+		resp.Duration = time.Since(start)
+		return resp
+	}
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, v)
+	}
+	if req.PropagateDeadline && req.Timeout > 0 && req.DeadlineHeader != "" {
+		httpReq.Header.Set(req.DeadlineHeader, strconv.FormatInt(req.Timeout.Milliseconds(), 10))
+	}
+
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+
+	asked := time.Now()
+	pc, err := c.acquire(ctx, httpReq.URL.Scheme, hostAddr(httpReq.URL), req.ConnectTimeout)
+	if req.TraceConnWait {
+		resp.ConnWait = time.Since(asked)
+	}
+	if err != nil {
+		resp.Error = err
+		resp.Duration = time.Since(start)
+		return resp
+	}
+
+	call := &pipeCall{req: httpReq, opts: req, start: start, resp: resp, finished: make(chan struct{})}
+	call.deadline, _ = ctx.Deadline()
+	c.send(pc, call)
+
+	select {
+	case <-call.finished:
+		return resp
+	case <-ctx.Done():
+		// The read loop still owns resp; answer with a fresh one.
+		return &Response{
+			Error:        ctx.Err(),
+			Duration:     time.Since(start),
+			BytesWritten: resp.BytesWritten,
+			ConnWait:     resp.ConnWait,
+		}
+	}
+}
+
+// acquire returns a connection to addr with a free pipeline slot and
+// takes the slot, dialing a new connection when every open one is
+// full. A connection is listed while it is being dialed, so requests
+// arriving meanwhile queue on it instead of dialing their own.
+func (c *PipelineClient) acquire(ctx context.Context, scheme, addr string, connectTimeout time.Duration) (*pipeConn, error) {
+	key := scheme + "://" + addr
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, errors.New("pipeline client closed")
+	}
+	var pc *pipeConn
+	for _, p := range c.hosts[key] {
+		if p.inflight == 0 && c.idle > 0 && time.Since(p.lastUsed) > c.idle {
+			// Likely closed by the server by now; don't find out by
+			// losing a request to it.
+			c.removeLocked(key, p)
+			p.close()
+			continue
+		}
+		if p.inflight < c.depth {
+			pc = p
+			break
+		}
+	}
+	dial := pc == nil
+	if dial {
+		pc = &pipeConn{
+			host:   c.conns.host(addr),
+			queued: make(chan *pipeCall, c.depth),
+			done:   make(chan struct{}),
+			ready:  make(chan struct{}),
+		}
+		c.hosts[key] = append(c.hosts[key], pc)
+	}
+	pc.inflight++
+	c.mu.Unlock()
+	atomic.AddInt64(&pc.host.inUse, 1)
+
+	if dial {
+		pc.dialErr = c.dialConn(ctx, pc, scheme, addr, connectTimeout)
+		close(pc.ready)
+		if pc.dialErr != nil {
+			c.mu.Lock()
+			c.removeLocked(key, pc)
+			c.mu.Unlock()
+		} else {
+			go c.readLoop(key, pc)
+		}
+	}
+	select {
+	case <-pc.ready:
+	case <-ctx.Done():
+		c.release(pc)
+		return nil, ctx.Err()
+	}
+	if pc.dialErr != nil {
+		c.release(pc)
+		return nil, pc.dialErr
+	}
+	return pc, nil
+}
+
+// dialConn opens pc's connection, with a TLS handshake for https.
+func (c *PipelineClient) dialConn(ctx context.Context, pc *pipeConn, scheme, addr string, connectTimeout time.Duration) error {
+	if connectTimeout > 0 {
+		ctx = withConnectTimeout(ctx, connectTimeout)
+	}
+	conn, err := c.dial(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if scheme == "https" {
+		cfg := c.tls.Clone()
+		cfg.ServerName, _, _ = net.SplitHostPort(addr)
+		tc := tls.Client(conn, cfg)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tc
+	}
+	pc.conn = conn
+	pc.br = bufio.NewReader(conn)
+	pc.bw = bufio.NewWriter(conn)
+	return nil
+}
+
+// send queues call on pc and writes its request. A write error closes
+// the connection, which fails the call through the read loop.
+func (c *PipelineClient) send(pc *pipeConn, call *pipeCall) {
+	pc.wmu.Lock()
+	defer pc.wmu.Unlock()
+	if pc.dead != nil {
+		c.finish(pc, call, pc.dead)
+		return
+	}
+	pc.queued <- call // never blocks: acquire bounds inflight by depth
+	if err := call.req.Write(pc.bw); err != nil {
+		pc.close()
+		return
+	}
+	if err := pc.bw.Flush(); err != nil {
+		pc.close()
+	}
+}
+
+// readLoop reads pc's responses in order and hands each to the call
+// that is next in line. It ends when the connection breaks or closes,
+// failing every call still queued.
+func (c *PipelineClient) readLoop(key string, pc *pipeConn) {
+	var cur *pipeCall
+	var err error
+	for err == nil {
+		select {
+		case cur = <-pc.queued:
+		case <-pc.done:
+			cur, err = nil, net.ErrClosed
+			continue
+		}
+		var keep bool
+		keep, err = c.readResponse(pc, cur)
+		if err == nil {
+			c.finish(pc, cur, nil)
+			cur = nil
+			if !keep {
+				err = errors.New("server closed the connection")
+			}
+		}
+	}
+
+	pc.close()
+	pc.conn.Close() // in case close ran while the dial was in progress
+	c.mu.Lock()
+	c.removeLocked(key, pc)
+	c.mu.Unlock()
+
+	broken := fmt.Errorf("%w: %v", ErrPipelineBroken, err)
+	pc.wmu.Lock()
+	pc.dead = broken
+	pc.wmu.Unlock()
+	if cur != nil {
+		c.finish(pc, cur, err)
+	}
+	for {
+		select {
+		case call := <-pc.queued:
+			c.finish(pc, call, broken)
+		default:
+			return
+		}
+	}
+}
+
+// readResponse reads call's response into call.resp and reports
+// whether the server keeps the connection open after it.
+func (c *PipelineClient) readResponse(pc *pipeConn, call *pipeCall) (bool, error) {
+	pc.conn.SetReadDeadline(call.deadline)
+	if _, err := pc.br.Peek(1); err != nil {
+		return false, readError(err)
+	}
+	resp := call.resp
+	if call.opts.TraceTTFB || call.opts.FirstByteOnly {
+		resp.TTFB = time.Since(call.start)
+	}
+	httpResp, err := http.ReadResponse(pc.br, call.req)
+	if err != nil {
+		return false, readError(err)
+	}
+	var n int64
+	if call.opts.CaptureBody > 0 {
+		resp.Body, n, err = drainCapture(httpResp.Body, nil, call.opts.CaptureBody)
+	} else {
+		n, err = io.Copy(io.Discard, httpResp.Body)
+	}
+	httpResp.Body.Close()
+	resp.BytesRead = n
+	if err != nil {
+		return false, readError(err)
+	}
+	resp.StatusCode = httpResp.StatusCode
+	if call.opts.CaptureHeader != "" {
+		resp.Header = httpResp.Header.Get(call.opts.CaptureHeader)
+	}
+	return !httpResp.Close, nil
+}
+
+// readError reports a read that ran into the request's deadline as
+// context.DeadlineExceeded, as the other clients do.
+func readError(err error) error {
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// finish completes call with err and frees its pipeline slot.
+func (c *PipelineClient) finish(pc *pipeConn, call *pipeCall, err error) {
+	call.resp.Error = err
+	call.resp.Duration = time.Since(call.start)
+	close(call.finished)
+	c.release(pc)
+}
+
+// release frees a pipeline slot on pc.
+func (c *PipelineClient) release(pc *pipeConn) {
+	atomic.AddInt64(&pc.host.inUse, -1)
+	c.mu.Lock()
+	pc.inflight--
+	pc.lastUsed = time.Now()
+	c.mu.Unlock()
+}
+
+// removeLocked drops pc from the connections to key. c.mu must be
+// held.
+func (c *PipelineClient) removeLocked(key string, pc *pipeConn) {
+	list := c.hosts[key]
+	for i, p := range list {
+		if p == pc {
+			c.hosts[key] = append(list[:i:i], list[i+1:]...)
+			return
+		}
+	}
+}
+
+// ConnStats reports the client's connections per host. InUse counts
+// outstanding requests, so it can exceed Open by up to the depth.
+func (c *PipelineClient) ConnStats() []ConnStats {
+	return c.conns.stats()
+}
+
+// TLSStats reports the client's handshakes.
+func (c *PipelineClient) TLSStats() TLSStats {
+	return c.hs.stats()
+}
+
+// Close closes every connection; requests still queued fail with
+// ErrPipelineBroken.
+func (c *PipelineClient) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	var all []*pipeConn
+	for _, list := range c.hosts {
+		all = append(all, list...)
+	}
+	c.mu.Unlock()
+	for _, pc := range all {
+		pc.close()
+	}
+	return nil
+}
@@ -2,7 +2,12 @@ package protocol
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net/http"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 // Request represents a generic request to be sent.
@@ -12,8 +17,58 @@ type Request struct {
 	Headers map[string]string
 	Body    []byte
 	Timeout time.Duration
+
+	// ConnectTimeout bounds establishing a new connection (DNS and TCP
+	// connect) apart from Timeout, which covers the whole request. A
+	// dial that runs out fails with ErrConnectTimeout. Requests served
+	// by a pooled connection don't dial. Zero leaves only Timeout. HTTP
+	// only.
+	ConnectTimeout time.Duration
+
+	// PropagateDeadline exposes Timeout to the server: gRPC calls carry
+	// it as grpc-timeout, HTTP requests as DeadlineHeader (integer
+	// milliseconds). When false the timeout is enforced client-side
+	// only and the server never learns about it.
+	PropagateDeadline bool
+	DeadlineHeader    string
+
+	// TraceTTFB records Response.TTFB. FirstByteOnly stops reading the
+	// body after the first chunk and implies TraceTTFB; Duration then
+	// ends at that chunk rather than at end of stream.
+	TraceTTFB     bool
+	FirstByteOnly bool
+
+	// TraceConnWait records Response.ConnWait.
+	TraceConnWait bool
+
+	// CaptureHeader names a response header to copy into
+	// Response.Header, such as a cache status. HTTP only.
+	CaptureHeader string
+
+	// GRPCMethod is the package.Service/Method a gRPC request calls,
+	// with Body as its request message in protobuf JSON. Protoset is
+	// the FileDescriptorSet file describing it; empty resolves the
+	// method through server reflection. No method calls the standard
+	// health check. gRPC only.
+	GRPCMethod string
+	Protoset   string
+
+	// CaptureBody keeps up to this many leading bytes of the response
+	// body in Response.Body, for assertions on its content; the rest
+	// is drained as usual. Zero keeps nothing and leaves the drain
+	// copy-free. HTTP only.
+	CaptureBody int
+
+	// readBody, set by clients layered on HTTPClient, reads the response
+	// body in place of the drain and returns the bytes it read.
+	readBody func(*http.Response) (int64, error)
 }
 
+// ErrConnectTimeout is the error of a request whose connection couldn't
+// be established within Request.ConnectTimeout, so a target that is
+// unreachable can be told from one that is slow to answer.
+var ErrConnectTimeout = errors.New("connect timeout")
+
 // Response represents the result of a request.
 type Response struct {
 	StatusCode   int
@@ -21,6 +76,37 @@ type Response struct {
 	BytesRead    int64
 	BytesWritten int64
 	Error        error
+
+	// TTFB is the time from send to the first response byte. Zero
+	// unless the request asked for it and a response arrived.
+	TTFB time.Duration
+
+	// ConnWait is the time spent obtaining a connection: zero for an
+	// idle pooled one, the dial for a new one, and the queue wait when
+	// MaxConnsPerHost is exhausted. Zero unless TraceConnWait is set.
+	ConnWait time.Duration
+	// ConnQueued is set when the request had to wait for a connection
+	// another request released rather than getting an idle or new one.
+	ConnQueued bool
+
+	// Header is the value of Request.CaptureHeader, empty when the
+	// response didn't carry it.
+	Header string
+
+	// Body is the start of the response body, at most
+	// Request.CaptureBody bytes. Nil unless the request asked for it.
+	Body []byte
+}
+
+// drainCapture reads body to the end, keeping its first limit bytes,
+// and returns them with the total read.
+func drainCapture(body io.Reader, buf []byte, limit int) ([]byte, int64, error) {
+	kept, err := io.ReadAll(io.LimitReader(body, int64(limit)))
+	if err != nil {
+		return kept, int64(len(kept)), err
+	}
+	n, err := io.CopyBuffer(io.Discard, body, buf)
+	return kept, int64(len(kept)) + n, err
 }
 
 // Client is the interface for protocol implementations.
@@ -36,5 +122,55 @@ type Client interface {
 type ClientConfig struct {
 	MaxIdleConns    int
 	IdleConnTimeout time.Duration
-	TLSInsecure     bool
+	// TLSInsecure skips certificate verification. Off, a bad
+	// certificate fails the request.
+	TLSInsecure bool
+	// Plaintext makes the gRPC client connect without TLS, as kar's
+	// gRPC targets always have. Other clients pick TLS by URL scheme.
+	Plaintext bool
+	// MaxConnsPerHost bounds concurrent connections per host; 0 means
+	// unlimited. Only the HTTP/1.1 client honours it.
+	MaxConnsPerHost int
+	// TCPKeepAlive is the TCP keep-alive probe interval: 0 uses
+	// DefaultTCPKeepAlive (gRPC: grpc-go's own dialer), negative turns
+	// probes off.
+	TCPKeepAlive time.Duration
+	// PingInterval pings a connection that has been idle this long, so
+	// intermediaries see traffic between sparse requests: HTTP/2 PING
+	// frames, gRPC keepalive pings. 0 keeps the protocol default (off
+	// for HTTP/2, DefaultGRPCPing for gRPC). HTTP/1.1 has no ping.
+	PingInterval time.Duration
+	// PingTimeout drops a connection whose ping goes unanswered; 0
+	// uses DefaultPingTimeout.
+	PingTimeout time.Duration
+	// TLSSessionCache is how many TLS sessions a client keeps to resume
+	// on new connections. 0 keeps none: every new connection pays a
+	// full handshake. HTTP/1.1 and gRPC-Web only.
+	TLSSessionCache int
+	// DialLimiter paces the connections the client opens: each dial
+	// waits for a token (#1261). Clients sharing one share its rate.
+	// Nil means unlimited.
+	DialLimiter *rate.Limiter
+}
+
+// Keep-alive defaults, matching what the clients used before they were
+// configurable.
+const (
+	DefaultTCPKeepAlive = 30 * time.Second
+	DefaultGRPCPing     = 10 * time.Second
+	DefaultPingTimeout  = 5 * time.Second
+)
+
+func (c ClientConfig) tcpKeepAlive() time.Duration {
+	if c.TCPKeepAlive == 0 {
+		return DefaultTCPKeepAlive
+	}
+	return c.TCPKeepAlive
+}
+
+func (c ClientConfig) pingTimeout() time.Duration {
+	if c.PingTimeout <= 0 {
+		return DefaultPingTimeout
+	}
+	return c.PingTimeout
 }
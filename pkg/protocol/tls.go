@@ -0,0 +1,80 @@
+package protocol
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"sync/atomic"
+)
+
+// TLSStats counts a client's TLS handshakes (#1257): full ones, and
+// ones that resumed a session from the client's session cache.
+type TLSStats struct {
+	Full    int64 `json:"full"`
+	Resumed int64 `json:"resumed"`
+}
+
+// TLSReporter is implemented by clients that count their handshakes.
+type TLSReporter interface {
+	TLSStats() TLSStats
+}
+
+// Warmer is implemented by clients that can prepare a connection to a
+// URL's host before the run, so the first measured request doesn't pay
+// for it.
+type Warmer interface {
+	Warm(ctx context.Context, url string) error
+}
+
+// tlsCounter counts the handshakes of the connections dialed with its
+// config.
+type tlsCounter struct {
+	full, resumed int64
+}
+
+// config returns the TLS settings cfg's clients dial with. Without a
+// session cache every new connection does a full handshake, which is
+// Go's default and what kar always did.
+func (t *tlsCounter) config(cfg ClientConfig) *tls.Config {
+	tc := &tls.Config{
+		InsecureSkipVerify: cfg.TLSInsecure,
+		// VerifyConnection runs once per handshake, resumed or not,
+		// and whether or not certificates are verified.
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if cs.DidResume {
+				atomic.AddInt64(&t.resumed, 1)
+			} else {
+				atomic.AddInt64(&t.full, 1)
+			}
+			return nil
+		},
+	}
+	if cfg.TLSSessionCache > 0 {
+		tc.ClientSessionCache = tls.NewLRUClientSessionCache(cfg.TLSSessionCache)
+	}
+	return tc
+}
+
+func (t *tlsCounter) stats() TLSStats {
+	return TLSStats{Full: atomic.LoadInt64(&t.full), Resumed: atomic.LoadInt64(&t.resumed)}
+}
+
+// Warm sends a HEAD request to url and reads the response, leaving an
+// idle connection in the pool and, for https with a session cache, a
+// session to resume. The response status doesn't matter.
+func (c *HTTPClient) Warm(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// TLSStats reports the client's handshakes.
+func (c *HTTPClient) TLSStats() TLSStats {
+	return c.tls.stats()
+}